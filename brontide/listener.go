@@ -7,6 +7,7 @@ import (
 	"net"
 	"time"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/lightningnetwork/lnd/keychain"
 )
 
@@ -24,6 +25,14 @@ type Listener struct {
 
 	tcp *net.TCPListener
 
+	// addrFilter, if set, rejects connections from remote addresses
+	// before the handshake.
+	addrFilter func(net.Addr) error
+
+	// pubKeyFilter, if set, rejects connections from remote static keys
+	// once the handshake completes.
+	pubKeyFilter func(*btcec.PublicKey) error
+
 	handshakeSema chan struct{}
 	conns         chan maybeConn
 	quit          chan struct{}
@@ -32,10 +41,30 @@ type Listener struct {
 // A compile-time assertion to ensure that Conn meets the net.Listener interface.
 var _ net.Listener = (*Listener)(nil)
 
+// ListenerOption is a functional option that modifies a Listener.
+type ListenerOption func(*Listener)
+
+// WithAddrFilter returns an option that rejects any connection for which the
+// filter returns an error, before spending any resources on the handshake.
+func WithAddrFilter(filter func(net.Addr) error) ListenerOption {
+	return func(l *Listener) {
+		l.addrFilter = filter
+	}
+}
+
+// WithPubKeyFilter returns an option that rejects any connection for which the
+// filter returns an error when called with the remote static key learned
+// during the handshake.
+func WithPubKeyFilter(filter func(*btcec.PublicKey) error) ListenerOption {
+	return func(l *Listener) {
+		l.pubKeyFilter = filter
+	}
+}
+
 // NewListener returns a new net.Listener which enforces the Brontide scheme
 // during both initial connection establishment and data transfer.
-func NewListener(localStatic keychain.SingleKeyECDH,
-	listenAddr string) (*Listener, error) {
+func NewListener(localStatic keychain.SingleKeyECDH, listenAddr string,
+	opts ...ListenerOption) (*Listener, error) {
 
 	addr, err := net.ResolveTCPAddr("tcp", listenAddr)
 	if err != nil {
@@ -54,6 +83,9 @@ func NewListener(localStatic keychain.SingleKeyECDH,
 		conns:         make(chan maybeConn),
 		quit:          make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(brontideListener)
+	}
 
 	for i := 0; i < defaultHandshakes; i++ {
 		brontideListener.handshakeSema <- struct{}{}
@@ -109,6 +141,14 @@ func (l *Listener) doHandshake(conn net.Conn) {
 
 	remoteAddr := conn.RemoteAddr().String()
 
+	if l.addrFilter != nil {
+		if err := l.addrFilter(conn.RemoteAddr()); err != nil {
+			conn.Close()
+			l.rejectConn(rejectedConnErr(err, remoteAddr))
+			return
+		}
+	}
+
 	brontideConn := &Conn{
 		conn:  conn,
 		noise: NewBrontideMachine(false, l.localStatic, nil),
@@ -184,6 +224,17 @@ func (l *Listener) doHandshake(conn net.Conn) {
 		return
 	}
 
+	// Now that we know the identity of the remote peer, we'll make sure
+	// that we accept connections from it.
+	if l.pubKeyFilter != nil {
+		err := l.pubKeyFilter(brontideConn.RemotePub())
+		if err != nil {
+			brontideConn.conn.Close()
+			l.rejectConn(rejectedConnErr(err, remoteAddr))
+			return
+		}
+	}
+
 	// We'll reset the deadline as it's no longer critical beyond the
 	// initial handshake.
 	err = conn.SetReadDeadline(time.Time{})
@@ -3,6 +3,7 @@ package brontide
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -22,7 +23,9 @@ type maybeNetConn struct {
 	err  error
 }
 
-func makeListener() (*Listener, *lnwire.NetAddress, error) {
+func makeListener(opts ...ListenerOption) (*Listener, *lnwire.NetAddress,
+	error) {
+
 	// First, generate the long-term private keys for the brontide listener.
 	localPriv, err := btcec.NewPrivateKey()
 	if err != nil {
@@ -35,7 +38,7 @@ func makeListener() (*Listener, *lnwire.NetAddress, error) {
 	addr := "localhost:0"
 
 	// Our listener will be local, and the connection remote.
-	listener, err := NewListener(localKeyECDH, addr)
+	listener, err := NewListener(localKeyECDH, addr, opts...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -148,6 +151,49 @@ func TestConnectionCorrectness(t *testing.T) {
 	}
 }
 
+// TestListenerFilters asserts that the listener rejects connections from
+// addresses and static keys that its filters reject.
+func TestListenerFilters(t *testing.T) {
+	remotePriv, err := btcec.NewPrivateKey()
+	require.NoError(t, err, "unable to generate private key")
+	remoteKeyECDH := &keychain.PrivKeyECDH{PrivKey: remotePriv}
+
+	errRejected := errors.New("rejected")
+	rejectRemotePub := func(pub *btcec.PublicKey) error {
+		if pub.IsEqual(remotePriv.PubKey()) {
+			return errRejected
+		}
+		return nil
+	}
+	rejectAll := func(net.Addr) error {
+		return errRejected
+	}
+
+	for _, opt := range []ListenerOption{
+		WithPubKeyFilter(rejectRemotePub),
+		WithAddrFilter(rejectAll),
+	} {
+		listener, netAddr, err := makeListener(opt)
+		require.NoError(t, err, "unable to create listener")
+
+		go func() {
+			conn, err := Dial(
+				remoteKeyECDH, netAddr,
+				tor.DefaultConnTimeout, net.DialTimeout,
+			)
+			if err == nil {
+				conn.Close()
+			}
+		}()
+
+		conn, err := listener.Accept()
+		require.ErrorContains(t, err, errRejected.Error())
+		require.Nil(t, conn)
+
+		listener.Close()
+	}
+}
+
 // TestConecurrentHandshakes verifies the listener's ability to not be blocked
 // by other pending handshakes. This is tested by opening multiple tcp
 // connections with the listener, without completing any of the brontide acts.
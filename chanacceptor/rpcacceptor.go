@@ -270,80 +270,9 @@ func (r *RPCAcceptor) sendAcceptRequests(errChan chan error,
 				channelFeatures := lnwire.RawFeatureVector(
 					*req.OpenChanMsg.ChannelType,
 				)
-				switch {
-				case channelFeatures.OnlyContains(
-					lnwire.ZeroConfRequired,
-					lnwire.ScidAliasRequired,
-					lnwire.ScriptEnforcedLeaseRequired,
-					lnwire.AnchorsZeroFeeHtlcTxRequired,
-					lnwire.StaticRemoteKeyRequired,
-				):
-					commitmentType = lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE
-
-				case channelFeatures.OnlyContains(
-					lnwire.ZeroConfRequired,
-					lnwire.ScriptEnforcedLeaseRequired,
-					lnwire.AnchorsZeroFeeHtlcTxRequired,
-					lnwire.StaticRemoteKeyRequired,
-				):
-					commitmentType = lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE
-
-				case channelFeatures.OnlyContains(
-					lnwire.ScidAliasRequired,
-					lnwire.ScriptEnforcedLeaseRequired,
-					lnwire.AnchorsZeroFeeHtlcTxRequired,
-					lnwire.StaticRemoteKeyRequired,
-				):
-					commitmentType = lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE
-
-				case channelFeatures.OnlyContains(
-					lnwire.ScriptEnforcedLeaseRequired,
-					lnwire.AnchorsZeroFeeHtlcTxRequired,
-					lnwire.StaticRemoteKeyRequired,
-				):
-					commitmentType = lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE
-
-				case channelFeatures.OnlyContains(
-					lnwire.ZeroConfRequired,
-					lnwire.ScidAliasRequired,
-					lnwire.AnchorsZeroFeeHtlcTxRequired,
-					lnwire.StaticRemoteKeyRequired,
-				):
-					commitmentType = lnrpc.CommitmentType_ANCHORS
-
-				case channelFeatures.OnlyContains(
-					lnwire.ZeroConfRequired,
-					lnwire.AnchorsZeroFeeHtlcTxRequired,
-					lnwire.StaticRemoteKeyRequired,
-				):
-					commitmentType = lnrpc.CommitmentType_ANCHORS
-
-				case channelFeatures.OnlyContains(
-					lnwire.ScidAliasRequired,
-					lnwire.AnchorsZeroFeeHtlcTxRequired,
-					lnwire.StaticRemoteKeyRequired,
-				):
-					commitmentType = lnrpc.CommitmentType_ANCHORS
-
-				case channelFeatures.OnlyContains(
-					lnwire.AnchorsZeroFeeHtlcTxRequired,
-					lnwire.StaticRemoteKeyRequired,
-				):
-					commitmentType = lnrpc.CommitmentType_ANCHORS
-
-				case channelFeatures.OnlyContains(
-					lnwire.StaticRemoteKeyRequired,
-				):
-					commitmentType = lnrpc.CommitmentType_STATIC_REMOTE_KEY
-
-				case channelFeatures.OnlyContains():
-					commitmentType = lnrpc.CommitmentType_LEGACY
-
-				default:
-					log.Warnf("Unhandled commitment type "+
-						"in channel acceptor request: %v",
-						req.OpenChanMsg.ChannelType)
-				}
+				commitmentType = rpcCommitmentType(
+					channelFeatures,
+				)
 
 				if channelFeatures.IsSet(
 					lnwire.ZeroConfRequired,
@@ -511,3 +440,87 @@ func (r *RPCAcceptor) validateAcceptorResponse(dustLimit btcutil.Amount,
 // A compile-time constraint to ensure RPCAcceptor implements the ChannelAcceptor
 // interface.
 var _ ChannelAcceptor = (*RPCAcceptor)(nil)
+
+// rpcCommitmentType maps the features of an explicitly negotiated channel type
+// to the RPC commitment type. The zero-conf and scid-alias features can be
+// combined with all commitment types except for the legacy and static remote
+// key types. UNKNOWN_COMMITMENT_TYPE is returned for unhandled channel types.
+func rpcCommitmentType(
+	channelFeatures lnwire.RawFeatureVector) lnrpc.CommitmentType {
+
+	switch {
+	case channelFeatures.OnlyContains(
+		lnwire.ZeroConfRequired,
+		lnwire.ScidAliasRequired,
+		lnwire.ScriptEnforcedLeaseRequired,
+		lnwire.AnchorsZeroFeeHtlcTxRequired,
+		lnwire.StaticRemoteKeyRequired,
+	):
+		return lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE
+
+	case channelFeatures.OnlyContains(
+		lnwire.ZeroConfRequired,
+		lnwire.ScriptEnforcedLeaseRequired,
+		lnwire.AnchorsZeroFeeHtlcTxRequired,
+		lnwire.StaticRemoteKeyRequired,
+	):
+		return lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE
+
+	case channelFeatures.OnlyContains(
+		lnwire.ScidAliasRequired,
+		lnwire.ScriptEnforcedLeaseRequired,
+		lnwire.AnchorsZeroFeeHtlcTxRequired,
+		lnwire.StaticRemoteKeyRequired,
+	):
+		return lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE
+
+	case channelFeatures.OnlyContains(
+		lnwire.ScriptEnforcedLeaseRequired,
+		lnwire.AnchorsZeroFeeHtlcTxRequired,
+		lnwire.StaticRemoteKeyRequired,
+	):
+		return lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE
+
+	case channelFeatures.OnlyContains(
+		lnwire.ZeroConfRequired,
+		lnwire.ScidAliasRequired,
+		lnwire.AnchorsZeroFeeHtlcTxRequired,
+		lnwire.StaticRemoteKeyRequired,
+	):
+		return lnrpc.CommitmentType_ANCHORS
+
+	case channelFeatures.OnlyContains(
+		lnwire.ZeroConfRequired,
+		lnwire.AnchorsZeroFeeHtlcTxRequired,
+		lnwire.StaticRemoteKeyRequired,
+	):
+		return lnrpc.CommitmentType_ANCHORS
+
+	case channelFeatures.OnlyContains(
+		lnwire.ScidAliasRequired,
+		lnwire.AnchorsZeroFeeHtlcTxRequired,
+		lnwire.StaticRemoteKeyRequired,
+	):
+		return lnrpc.CommitmentType_ANCHORS
+
+	case channelFeatures.OnlyContains(
+		lnwire.AnchorsZeroFeeHtlcTxRequired,
+		lnwire.StaticRemoteKeyRequired,
+	):
+		return lnrpc.CommitmentType_ANCHORS
+
+	case channelFeatures.OnlyContains(
+		lnwire.StaticRemoteKeyRequired,
+	):
+		return lnrpc.CommitmentType_STATIC_REMOTE_KEY
+
+	case channelFeatures.OnlyContains():
+		return lnrpc.CommitmentType_LEGACY
+
+	default:
+		log.Warnf("Unhandled commitment type in channel open "+
+			"request: %v", channelFeatures)
+
+		return lnrpc.CommitmentType_UNKNOWN_COMMITMENT_TYPE
+	}
+}
@@ -0,0 +1,355 @@
+package chanacceptor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/tor"
+)
+
+var (
+	// errPeerDenied is returned when a channel is rejected because the
+	// peer is on the deny list, or isn't on the allow list while only
+	// allowed peers may open channels.
+	errPeerDenied = errors.New("channels from this peer are not accepted")
+
+	// errTorOnlyPeer is returned when a channel is rejected because the
+	// peer only announced onion addresses.
+	errTorOnlyPeer = errors.New("channels from tor-only peers are not " +
+		"accepted")
+)
+
+// NodeInfo is the information about a peer that is known from the channel
+// graph.
+type NodeInfo struct {
+	// NumChannels is the number of public channels of the node.
+	NumChannels int
+
+	// Capacity is the total capacity of the public channels of the node.
+	Capacity btcutil.Amount
+
+	// Addresses are the addresses that the node announced.
+	Addresses []net.Addr
+}
+
+// RuleAcceptorConfig houses the dependencies of the RuleAcceptor.
+type RuleAcceptorConfig struct {
+	// RulesFile is the path of the JSON file that the rules are read from.
+	RulesFile string
+
+	// FetchNodeInfo returns the information about the given node that is
+	// known from the channel graph. Nodes unknown to the graph should be
+	// returned with empty information rather than an error.
+	FetchNodeInfo func(node route.Vertex) (*NodeInfo, error)
+
+	// NumPendingChannels returns the number of pending channels with the
+	// given peer.
+	NumPendingChannels func(peer *btcec.PublicKey) (int, error)
+}
+
+// ChannelAcceptRules are the rules that the RuleAcceptor applies to inbound
+// channels, as read from the rules file. Rules that are left at their zero
+// value aren't applied.
+type ChannelAcceptRules struct {
+	// AllowedPeers are the hex encoded public keys of peers whose channels
+	// are accepted without applying any of the other rules.
+	AllowedPeers []string `json:"allowed_peers"`
+
+	// AllowedPeersOnly rejects channels from all peers that aren't in
+	// AllowedPeers.
+	AllowedPeersOnly bool `json:"allowed_peers_only"`
+
+	// DeniedPeers are the hex encoded public keys of peers whose channels
+	// are always rejected.
+	DeniedPeers []string `json:"denied_peers"`
+
+	// MinPeerChannels is the minimum number of public channels that the
+	// peer must have in our graph.
+	MinPeerChannels int `json:"min_peer_channels"`
+
+	// MinPeerCapacitySat is the minimum total capacity of the public
+	// channels of the peer in our graph.
+	MinPeerCapacitySat int64 `json:"min_peer_capacity_sat"`
+
+	// CommitmentTypes are the names of the accepted commitment types, as
+	// in lnrpc.CommitmentType (e.g. "ANCHORS"). If set, channels that don't
+	// explicitly negotiate one of these types are rejected.
+	CommitmentTypes []string `json:"commitment_types"`
+
+	// MaxPendingPerPeer is the maximum number of pending channels that
+	// we allow with a single peer.
+	MaxPendingPerPeer int `json:"max_pending_per_peer"`
+
+	// RejectTorOnly rejects channels from peers that only announced onion
+	// addresses.
+	RejectTorOnly bool `json:"reject_tor_only"`
+}
+
+// acceptRules is the parsed form of ChannelAcceptRules.
+type acceptRules struct {
+	ChannelAcceptRules
+
+	allowed         map[route.Vertex]struct{}
+	denied          map[route.Vertex]struct{}
+	commitmentTypes map[lnrpc.CommitmentType]struct{}
+}
+
+// parsePeers parses a list of hex encoded public keys.
+func parsePeers(peers []string) (map[route.Vertex]struct{}, error) {
+	vertices := make(map[route.Vertex]struct{}, len(peers))
+	for _, peer := range peers {
+		vertex, err := route.NewVertexFromStr(peer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer %v: %w", peer, err)
+		}
+		vertices[vertex] = struct{}{}
+	}
+
+	return vertices, nil
+}
+
+// parse validates the rules and converts them to their parsed form.
+func (c *ChannelAcceptRules) parse() (*acceptRules, error) {
+	switch {
+	case c.MinPeerChannels < 0:
+		return nil, errors.New("min_peer_channels must not be negative")
+
+	case c.MinPeerCapacitySat < 0:
+		return nil, errors.New("min_peer_capacity_sat must not be " +
+			"negative")
+
+	case c.MaxPendingPerPeer < 0:
+		return nil, errors.New("max_pending_per_peer must not be " +
+			"negative")
+	}
+
+	allowed, err := parsePeers(c.AllowedPeers)
+	if err != nil {
+		return nil, err
+	}
+
+	denied, err := parsePeers(c.DeniedPeers)
+	if err != nil {
+		return nil, err
+	}
+
+	for peer := range denied {
+		if _, ok := allowed[peer]; ok {
+			return nil, fmt.Errorf("peer %v is both allowed and "+
+				"denied", peer)
+		}
+	}
+
+	commitmentTypes := make(map[lnrpc.CommitmentType]struct{})
+	for _, name := range c.CommitmentTypes {
+		value, ok := lnrpc.CommitmentType_value[strings.ToUpper(name)]
+		if !ok || value == int32(
+			lnrpc.CommitmentType_UNKNOWN_COMMITMENT_TYPE,
+		) {
+
+			return nil, fmt.Errorf("unknown commitment type %v",
+				name)
+		}
+		commitmentTypes[lnrpc.CommitmentType(value)] = struct{}{}
+	}
+
+	return &acceptRules{
+		ChannelAcceptRules: *c,
+		allowed:            allowed,
+		denied:             denied,
+		commitmentTypes:    commitmentTypes,
+	}, nil
+}
+
+// RuleAcceptor is a ChannelAcceptor that applies declarative rules read from
+// a file, so that a channel acceptance policy can be enforced without a
+// connected RPC acceptor. The rules can be reloaded while lnd is running.
+//
+// NOTE: The RuleAcceptor never approves zero-conf channels, those still need
+// to be accepted by an RPC acceptor.
+type RuleAcceptor struct {
+	cfg *RuleAcceptorConfig
+
+	rules    *acceptRules
+	rulesMtx sync.RWMutex
+}
+
+// NewRuleAcceptor creates a RuleAcceptor and reads its rules from the
+// configured file.
+func NewRuleAcceptor(cfg *RuleAcceptorConfig) (*RuleAcceptor, error) {
+	r := &RuleAcceptor{
+		cfg: cfg,
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload reads the rules from the configured file again. If the file can't be
+// read or contains invalid rules, the current rules stay in place.
+func (r *RuleAcceptor) Reload() error {
+	rulesBytes, err := os.ReadFile(r.cfg.RulesFile)
+	if err != nil {
+		return fmt.Errorf("unable to read channel accept rules: %w",
+			err)
+	}
+
+	var rawRules ChannelAcceptRules
+	if err := json.Unmarshal(rulesBytes, &rawRules); err != nil {
+		return fmt.Errorf("unable to parse channel accept rules: %w",
+			err)
+	}
+
+	rules, err := rawRules.parse()
+	if err != nil {
+		return fmt.Errorf("invalid channel accept rules: %w", err)
+	}
+
+	r.rulesMtx.Lock()
+	r.rules = rules
+	r.rulesMtx.Unlock()
+
+	log.Infof("Loaded channel accept rules from %v", r.cfg.RulesFile)
+
+	return nil
+}
+
+// Accept applies the rules to the channel open request.
+//
+// NOTE: Part of the ChannelAcceptor interface.
+func (r *RuleAcceptor) Accept(
+	req *ChannelAcceptRequest) *ChannelAcceptResponse {
+
+	r.rulesMtx.RLock()
+	rules := r.rules
+	r.rulesMtx.RUnlock()
+
+	if err := r.checkRules(rules, req); err != nil {
+		log.Infof("Rejecting channel %x from %x: %v",
+			req.OpenChanMsg.PendingChannelID[:],
+			req.Node.SerializeCompressed(), err)
+
+		return NewChannelAcceptResponse(
+			false, err, nil, 0, 0, 0, 0, 0, 0, false,
+		)
+	}
+
+	return NewChannelAcceptResponse(
+		true, nil, nil, 0, 0, 0, 0, 0, 0, false,
+	)
+}
+
+// checkRules returns an error if the channel open request violates any of the
+// rules. The error is sent to the peer, so internal failures are replaced by a
+// generic error.
+func (r *RuleAcceptor) checkRules(rules *acceptRules,
+	req *ChannelAcceptRequest) error {
+
+	peer := route.NewVertex(req.Node)
+
+	if _, ok := rules.denied[peer]; ok {
+		return errPeerDenied
+	}
+
+	if _, ok := rules.allowed[peer]; ok {
+		return nil
+	}
+
+	if rules.AllowedPeersOnly {
+		return errPeerDenied
+	}
+
+	if len(rules.commitmentTypes) > 0 {
+		commitmentType := lnrpc.CommitmentType_UNKNOWN_COMMITMENT_TYPE
+		if req.OpenChanMsg.ChannelType != nil {
+			commitmentType = rpcCommitmentType(
+				lnwire.RawFeatureVector(
+					*req.OpenChanMsg.ChannelType,
+				),
+			)
+		}
+
+		if _, ok := rules.commitmentTypes[commitmentType]; !ok {
+			return fmt.Errorf("commitment type %v is not "+
+				"accepted", commitmentType)
+		}
+	}
+
+	if rules.MaxPendingPerPeer > 0 {
+		numPending, err := r.cfg.NumPendingChannels(req.Node)
+		if err != nil {
+			log.Errorf("Unable to fetch pending channels of %v: %v",
+				peer, err)
+
+			return errChannelRejected
+		}
+
+		if numPending >= rules.MaxPendingPerPeer {
+			return fmt.Errorf("too many pending channels, "+
+				"limit is %v", rules.MaxPendingPerPeer)
+		}
+	}
+
+	if rules.MinPeerChannels == 0 && rules.MinPeerCapacitySat == 0 &&
+		!rules.RejectTorOnly {
+
+		return nil
+	}
+
+	info, err := r.cfg.FetchNodeInfo(peer)
+	if err != nil {
+		log.Errorf("Unable to fetch node info of %v: %v", peer, err)
+
+		return errChannelRejected
+	}
+
+	if info.NumChannels < rules.MinPeerChannels {
+		return fmt.Errorf("peer has %v public channels, %v required",
+			info.NumChannels, rules.MinPeerChannels)
+	}
+
+	minCapacity := btcutil.Amount(rules.MinPeerCapacitySat)
+	if info.Capacity < minCapacity {
+		return fmt.Errorf("peer has %v public capacity, %v required",
+			info.Capacity, minCapacity)
+	}
+
+	if rules.RejectTorOnly && torOnly(info.Addresses) {
+		return errTorOnlyPeer
+	}
+
+	return nil
+}
+
+// torOnly returns true if all of the given addresses are onion addresses.
+// Nodes without any addresses aren't considered tor-only.
+func torOnly(addrs []net.Addr) bool {
+	if len(addrs) == 0 {
+		return false
+	}
+
+	for _, addr := range addrs {
+		if _, ok := addr.(*tor.OnionAddr); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// A compile-time constraint to ensure RuleAcceptor implements the
+// ChannelAcceptor interface.
+var _ ChannelAcceptor = (*RuleAcceptor)(nil)
@@ -0,0 +1,151 @@
+package chanacceptor
+
+import (
+	"encoding/hex"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/tor"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRules writes the given rules to the rules file.
+func writeRules(t *testing.T, path, rules string) {
+	require.NoError(t, os.WriteFile(path, []byte(rules), 0600))
+}
+
+// newTestPeer returns the public key of a new peer and its hex encoding.
+func newTestPeer(t *testing.T) (*btcec.PublicKey, string) {
+	priv, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	pub := priv.PubKey()
+
+	return pub, hex.EncodeToString(pub.SerializeCompressed())
+}
+
+// TestRuleAcceptor asserts that the rules of the RuleAcceptor are applied to
+// channel open requests, and that they can be reloaded.
+func TestRuleAcceptor(t *testing.T) {
+	t.Parallel()
+
+	rulesFile := filepath.Join(t.TempDir(), "rules.json")
+	writeRules(t, rulesFile, `{}`)
+
+	var (
+		nodeInfo   = &NodeInfo{}
+		numPending int
+	)
+	acceptor, err := NewRuleAcceptor(&RuleAcceptorConfig{
+		RulesFile: rulesFile,
+		FetchNodeInfo: func(route.Vertex) (*NodeInfo, error) {
+			return nodeInfo, nil
+		},
+		NumPendingChannels: func(*btcec.PublicKey) (int, error) {
+			return numPending, nil
+		},
+	})
+	require.NoError(t, err)
+
+	allowed, allowedHex := newTestPeer(t)
+	denied, deniedHex := newTestPeer(t)
+	other, _ := newTestPeer(t)
+
+	anchors := lnwire.ChannelType(*lnwire.NewRawFeatureVector(
+		lnwire.AnchorsZeroFeeHtlcTxRequired,
+		lnwire.StaticRemoteKeyRequired,
+	))
+
+	accepts := func(peer *btcec.PublicKey,
+		chanType *lnwire.ChannelType) bool {
+
+		resp := acceptor.Accept(&ChannelAcceptRequest{
+			Node: peer,
+			OpenChanMsg: &lnwire.OpenChannel{
+				ChannelType: chanType,
+			},
+		})
+		require.False(t, resp.ZeroConf)
+
+		return !resp.RejectChannel()
+	}
+
+	// Without any rules, all channels are accepted.
+	require.True(t, accepts(other, nil))
+
+	writeRules(t, rulesFile, `{
+		"allowed_peers": ["`+allowedHex+`"],
+		"denied_peers": ["`+deniedHex+`"],
+		"min_peer_channels": 2,
+		"min_peer_capacity_sat": 100000,
+		"commitment_types": ["anchors"],
+		"max_pending_per_peer": 1,
+		"reject_tor_only": true
+	}`)
+	require.NoError(t, acceptor.Reload())
+
+	// Denied peers are always rejected, allowed peers bypass all other
+	// rules.
+	require.False(t, accepts(denied, &anchors))
+	require.True(t, accepts(allowed, nil))
+
+	// The peer doesn't have enough public channels.
+	nodeInfo.NumChannels = 1
+	nodeInfo.Capacity = 200_000
+	require.False(t, accepts(other, &anchors))
+
+	nodeInfo.NumChannels = 2
+	require.True(t, accepts(other, &anchors))
+
+	// The commitment type must be negotiated explicitly.
+	require.False(t, accepts(other, nil))
+
+	// The peer has too little capacity.
+	nodeInfo.Capacity = 50_000
+	require.False(t, accepts(other, &anchors))
+	nodeInfo.Capacity = 200_000
+
+	// The peer has too many pending channels.
+	numPending = 1
+	require.False(t, accepts(other, &anchors))
+	numPending = 0
+
+	// The peer only announced onion addresses.
+	onionAddr := &tor.OnionAddr{
+		OnionService: "3g2upl4pq6kufc4m.onion",
+		Port:         9735,
+	}
+	nodeInfo.Addresses = []net.Addr{onionAddr}
+	require.False(t, accepts(other, &anchors))
+
+	nodeInfo.Addresses = append(nodeInfo.Addresses, &net.TCPAddr{
+		IP:   net.ParseIP("192.0.2.1"),
+		Port: 9735,
+	})
+	require.True(t, accepts(other, &anchors))
+
+	// Only allowed peers are accepted.
+	writeRules(t, rulesFile, `{
+		"allowed_peers": ["`+allowedHex+`"],
+		"allowed_peers_only": true
+	}`)
+	require.NoError(t, acceptor.Reload())
+	require.False(t, accepts(other, &anchors))
+	require.True(t, accepts(allowed, &anchors))
+
+	// Invalid rules are rejected and the current rules stay in place.
+	writeRules(t, rulesFile, `{"commitment_types": ["unknown"]}`)
+	require.ErrorContains(t, acceptor.Reload(), "unknown commitment type")
+	require.False(t, accepts(other, &anchors))
+
+	writeRules(t, rulesFile, `{
+		"allowed_peers": ["`+allowedHex+`"],
+		"denied_peers": ["`+allowedHex+`"]
+	}`)
+	require.ErrorContains(t, acceptor.Reload(), "both allowed and denied")
+}
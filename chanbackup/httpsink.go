@@ -0,0 +1,114 @@
+package chanbackup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HTTPSink is a BackupSink that uploads backups with a plain HTTP PUT request.
+// This is compatible with WebDAV servers as well as any other HTTP endpoint
+// that accepts uploads through PUT.
+type HTTPSink struct {
+	// baseURL is the URL of the collection the backups are stored in.
+	baseURL *url.URL
+
+	// user and password are the optional credentials used for HTTP basic
+	// authentication.
+	user     string
+	password string
+
+	client *http.Client
+}
+
+// NewHTTPSink creates a new HTTPSink that stores backups within the collection
+// at the given URL. If user is non-empty, requests are authenticated through
+// HTTP basic authentication.
+func NewHTTPSink(rawURL, user, password string) (*HTTPSink, error) {
+	baseURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup URL: %v", err)
+	}
+
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported backup URL scheme: %v",
+			baseURL.Scheme)
+	}
+
+	// We'll treat the URL as a collection that the backups are stored
+	// in, so make sure it ends with a slash.
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+
+	return &HTTPSink{
+		baseURL:  baseURL,
+		user:     user,
+		password: password,
+		client:   &http.Client{},
+	}, nil
+}
+
+// Name returns a human readable name that identifies the sink.
+//
+// NOTE: This is part of the BackupSink interface.
+func (h *HTTPSink) Name() string {
+	// Make sure we never leak any credentials embedded in the URL.
+	return "http(" + h.baseURL.Redacted() + ")"
+}
+
+// Upload stores the packed multi backup under the given object name within the
+// collection of the sink.
+//
+// NOTE: This is part of the BackupSink interface.
+func (h *HTTPSink) Upload(ctx context.Context, objectName string,
+	backup PackedMulti) error {
+
+	objectURL := h.baseURL.ResolveReference(&url.URL{Path: objectName})
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPut, objectURL.String(),
+		bytes.NewReader(backup),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if h.user != "" {
+		req.SetBasicAuth(h.user, h.password)
+	}
+
+	return doUpload(h.client, req)
+}
+
+// A compile-time check to ensure HTTPSink implements the BackupSink interface.
+var _ BackupSink = (*HTTPSink)(nil)
+
+// doUpload executes the upload request and turns any non-successful response
+// into an error.
+func doUpload(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		// Drain the body so the connection can be reused.
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return nil
+	}
+
+	// Include the start of the response body in the error, as servers
+	// usually give some indication of what went wrong.
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+
+	return fmt.Errorf("upload failed with status %v: %s", resp.Status,
+		bytes.TrimSpace(body))
+}
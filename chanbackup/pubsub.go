@@ -45,6 +45,11 @@ type ChannelEvent struct {
 	// NewChans is the set of channels that have been opened since the last
 	// event.
 	NewChans []ChannelWithAddrs
+
+	// UpdatedChans is a set of open channels with their latest state. The
+	// recovery data of their backups is refreshed if their commitment
+	// height or local balance changed.
+	UpdatedChans []ChannelWithAddrs
 }
 
 // ChannelSubscription represents an intent to be notified of any updates to
@@ -270,6 +275,14 @@ func (s *SubSwapper) backupUpdater() {
 				)
 			}
 
+			// For all updated channels, we'll refresh the recovery
+			// data of their SCB. Channels that are no longer part
+			// of our backup state are skipped, so a refresh can't
+			// bring back a closed channel.
+			numRefreshed := s.refreshSingles(
+				chanUpdate.UpdatedChans,
+			)
+
 			// For all closed channels, we'll remove the prior
 			// backup state.
 			closedChans := make(
@@ -286,6 +299,14 @@ func (s *SubSwapper) backupUpdater() {
 				closedChans = append(closedChans, closedChan)
 			}
 
+			// If only the state of channels was refreshed, but none
+			// of them changed, there's nothing to update.
+			if len(chanUpdate.NewChans) == 0 &&
+				len(closedChans) == 0 && numRefreshed == 0 {
+
+				continue
+			}
+
 			newStateSize := len(s.backupState)
 
 			log.Infof("Updating on-disk multi SCB backup: "+
@@ -308,3 +329,41 @@ func (s *SubSwapper) backupUpdater() {
 		}
 	}
 }
+
+// refreshSingles re-creates the SCBs of the given channels if their commitment
+// height or local balance changed since their backup was created, and returns
+// the number of refreshed SCBs. If no addresses are known for a channel peer,
+// the addresses of the prior backup are kept.
+func (s *SubSwapper) refreshSingles(updatedChans []ChannelWithAddrs) int {
+	var numRefreshed int
+	for _, updatedChan := range updatedChans {
+		chanPoint := updatedChan.FundingOutpoint
+
+		oldSingle, ok := s.backupState[chanPoint]
+		if !ok {
+			continue
+		}
+
+		localCommit := updatedChan.LocalCommitment
+		if localCommit.CommitHeight == oldSingle.CommitHeight &&
+			localCommit.LocalBalance == oldSingle.LocalBalance {
+
+			continue
+		}
+
+		addrs := updatedChan.Addrs
+		if len(addrs) == 0 {
+			addrs = oldSingle.Addresses
+		}
+
+		log.Debugf("Refreshing backup of channel %v at commit "+
+			"height %v", chanPoint, localCommit.CommitHeight)
+
+		s.backupState[chanPoint] = NewSingle(
+			updatedChan.OpenChannel, addrs,
+		)
+		numRefreshed++
+	}
+
+	return numRefreshed
+}
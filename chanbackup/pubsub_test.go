@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/lnencrypt"
 	"github.com/stretchr/testify/require"
@@ -278,3 +279,80 @@ func TestSubSwapperUpdater(t *testing.T) {
 	// sub-swapper switches the new set with the old.
 	assertExpectedBackupSwap(t, swapper, subSwapper, keyRing, backupSet)
 }
+
+// TestSubSwapperRefresh tests that the SubSwapper refreshes the backups of
+// channels whose state changed, and only swaps out the multi backup if any of
+// them did.
+func TestSubSwapperRefresh(t *testing.T) {
+	t.Parallel()
+
+	keyRing := &lnencrypt.MockKeyRing{}
+	chanNotifier := newMockChannelNotifier()
+	swapper := newMockSwapper(keyRing)
+
+	channel, err := genRandomOpenChannelShell()
+	require.NoError(t, err)
+	single := NewSingle(channel, nil)
+
+	subSwapper, err := NewSubSwapper(
+		[]Single{single}, chanNotifier, keyRing, swapper,
+	)
+	require.NoError(t, err)
+	require.NoError(t, subSwapper.Start())
+	defer subSwapper.Stop()
+
+	backupSet := map[wire.OutPoint]Single{
+		channel.FundingOutpoint: single,
+	}
+	assertExpectedBackupSwap(t, swapper, subSwapper, keyRing, backupSet)
+
+	sendRefresh := func(chans ...*channeldb.OpenChannel) {
+		t.Helper()
+
+		chanEvent := ChannelEvent{}
+		for _, c := range chans {
+			chanEvent.UpdatedChans = append(
+				chanEvent.UpdatedChans, ChannelWithAddrs{
+					OpenChannel: c,
+				},
+			)
+		}
+
+		select {
+		case chanNotifier.chanEvents <- chanEvent:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("update swapper didn't read refresh")
+		}
+	}
+
+	// A refresh of a channel that didn't change doesn't update the backup,
+	// and a channel that isn't part of the backup isn't added.
+	unknownChannel, err := genRandomOpenChannelShell()
+	require.NoError(t, err)
+	sendRefresh(channel, unknownChannel)
+
+	// Once the channel state moved on, its backup is refreshed.
+	channel.LocalCommitment.CommitHeight++
+	channel.LocalCommitment.LocalBalance += 1000
+	sendRefresh(channel, unknownChannel)
+
+	select {
+	case packedMulti := <-swapper.swaps:
+		multi, err := packedMulti.Unpack(keyRing)
+		require.NoError(t, err)
+		require.Len(t, multi.StaticBackups, 1)
+
+		refreshed := multi.StaticBackups[0]
+		require.Equal(
+			t, channel.LocalCommitment.CommitHeight,
+			refreshed.CommitHeight,
+		)
+		require.Equal(
+			t, channel.LocalCommitment.LocalBalance,
+			refreshed.LocalBalance,
+		)
+
+	case <-time.After(time.Second * 5):
+		t.Fatalf("update swapper didn't swap out multi")
+	}
+}
@@ -1,6 +1,7 @@
 package chanbackup
 
 import (
+	"fmt"
 	"net"
 
 	"github.com/btcsuite/btcd/btcec/v2"
@@ -47,7 +48,21 @@ func Recover(backups []Single, restorer ChannelRestorer,
 		log.Infof("Restoring ChannelPoint(%v) to disk: ",
 			backup.FundingOutpoint)
 
-		err := restorer.RestoreChansFromSingles(backup)
+		// Before we write anything to disk, we'll make sure the
+		// recovery data within the backup is consistent.
+		if err := backup.SanityCheck(); err != nil {
+			return fmt.Errorf("invalid backup for "+
+				"ChannelPoint(%v): %v", backup.FundingOutpoint,
+				err)
+		}
+
+		summary, err := NewRecoverySummary(backup)
+		if err != nil {
+			return err
+		}
+		log.Infof("Expected recovery for %v", summary)
+
+		err = restorer.RestoreChansFromSingles(backup)
 
 		// If a channel is already present in the channel DB, we can
 		// just continue. No reason to fail a whole set of multi backups
@@ -91,17 +106,23 @@ func Recover(backups []Single, restorer ChannelRestorer,
 // and also reach out to connect to any of the known node addresses for that
 // channel. It is assumes that after this method exists, if a connection we
 // able to be established, then then PeerConnector will continue to attempt to
-// re-establish a persistent connection in the background.
+// re-establish a persistent connection in the background. The unpacked
+// backups are returned to the caller.
 func UnpackAndRecoverSingles(singles PackedSingles,
 	keyChain keychain.KeyRing, restorer ChannelRestorer,
-	peerConnector PeerConnector) error {
+	peerConnector PeerConnector) ([]Single, error) {
 
 	chanBackups, err := singles.Unpack(keyChain)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return Recover(chanBackups, restorer, peerConnector)
+	err = Recover(chanBackups, restorer, peerConnector)
+	if err != nil {
+		return nil, err
+	}
+
+	return chanBackups, nil
 }
 
 // UnpackAndRecoverMulti is a one-shot method, that given a set of packed
@@ -109,15 +130,21 @@ func UnpackAndRecoverSingles(singles PackedSingles,
 // and also reach out to connect to any of the known node addresses for that
 // channel. It is assumes that after this method exists, if a connection we
 // able to be established, then then PeerConnector will continue to attempt to
-// re-establish a persistent connection in the background.
+// re-establish a persistent connection in the background. The unpacked
+// backups are returned to the caller.
 func UnpackAndRecoverMulti(packedMulti PackedMulti,
 	keyChain keychain.KeyRing, restorer ChannelRestorer,
-	peerConnector PeerConnector) error {
+	peerConnector PeerConnector) ([]Single, error) {
 
 	chanBackups, err := packedMulti.Unpack(keyChain)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	err = Recover(chanBackups.StaticBackups, restorer, peerConnector)
+	if err != nil {
+		return nil, err
 	}
 
-	return Recover(chanBackups.StaticBackups, restorer, peerConnector)
+	return chanBackups.StaticBackups, nil
 }
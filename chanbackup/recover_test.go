@@ -83,7 +83,7 @@ func TestUnpackAndRecoverSingles(t *testing.T) {
 	// If we make the channel restore fail, then the entire method should
 	// as well
 	chanRestorer.fail = true
-	err := UnpackAndRecoverSingles(
+	_, err := UnpackAndRecoverSingles(
 		packedBackups, keyRing, &chanRestorer, &peerConnector,
 	)
 	if err == nil {
@@ -95,7 +95,7 @@ func TestUnpackAndRecoverSingles(t *testing.T) {
 	// If we make the peer connector fail, then the entire method should as
 	// well
 	peerConnector.fail = true
-	err = UnpackAndRecoverSingles(
+	_, err = UnpackAndRecoverSingles(
 		packedBackups, keyRing, &chanRestorer, &peerConnector,
 	)
 	if err == nil {
@@ -107,10 +107,11 @@ func TestUnpackAndRecoverSingles(t *testing.T) {
 
 	// Next, we'll ensure that if all the interfaces function as expected,
 	// then the channels will properly be unpacked and restored.
-	err = UnpackAndRecoverSingles(
+	restored, err := UnpackAndRecoverSingles(
 		packedBackups, keyRing, &chanRestorer, &peerConnector,
 	)
 	require.NoError(t, err, "unable to recover chans")
+	require.Len(t, restored, len(backups))
 
 	// Both the restorer, and connector should have been called 10 times,
 	// once for each backup.
@@ -125,7 +126,7 @@ func TestUnpackAndRecoverSingles(t *testing.T) {
 
 	// If we modify the keyRing, then unpacking should fail.
 	keyRing.Fail = true
-	err = UnpackAndRecoverSingles(
+	_, err = UnpackAndRecoverSingles(
 		packedBackups, keyRing, &chanRestorer, &peerConnector,
 	)
 	if err == nil {
@@ -177,7 +178,7 @@ func TestUnpackAndRecoverMulti(t *testing.T) {
 	// If we make the channel restore fail, then the entire method should
 	// as well
 	chanRestorer.fail = true
-	err := UnpackAndRecoverMulti(
+	_, err := UnpackAndRecoverMulti(
 		packedMulti, keyRing, &chanRestorer, &peerConnector,
 	)
 	if err == nil {
@@ -189,7 +190,7 @@ func TestUnpackAndRecoverMulti(t *testing.T) {
 	// If we make the peer connector fail, then the entire method should as
 	// well
 	peerConnector.fail = true
-	err = UnpackAndRecoverMulti(
+	_, err = UnpackAndRecoverMulti(
 		packedMulti, keyRing, &chanRestorer, &peerConnector,
 	)
 	if err == nil {
@@ -201,10 +202,11 @@ func TestUnpackAndRecoverMulti(t *testing.T) {
 
 	// Next, we'll ensure that if all the interfaces function as expected,
 	// then the channels will properly be unpacked and restored.
-	err = UnpackAndRecoverMulti(
+	restored, err := UnpackAndRecoverMulti(
 		packedMulti, keyRing, &chanRestorer, &peerConnector,
 	)
 	require.NoError(t, err, "unable to recover chans")
+	require.Len(t, restored, len(backups))
 
 	// Both the restorer, and connector should have been called 10 times,
 	// once for each backup.
@@ -219,7 +221,7 @@ func TestUnpackAndRecoverMulti(t *testing.T) {
 
 	// If we modify the keyRing, then unpacking should fail.
 	keyRing.Fail = true
-	err = UnpackAndRecoverMulti(
+	_, err = UnpackAndRecoverMulti(
 		packedMulti, keyRing, &chanRestorer, &peerConnector,
 	)
 	if err == nil {
@@ -0,0 +1,231 @@
+package chanbackup
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// s3DateFormat is the format of the timestamp that is part of every
+	// signed S3 request.
+	s3DateFormat = "20060102T150405Z"
+
+	// s3ShortDateFormat is the format of the date that is part of the
+	// credential scope of a signed S3 request.
+	s3ShortDateFormat = "20060102"
+
+	// s3Algorithm is the signing algorithm we use for S3 requests.
+	s3Algorithm = "AWS4-HMAC-SHA256"
+)
+
+// S3Config houses the parameters needed to upload backups to an S3 compatible
+// object storage.
+type S3Config struct {
+	// Endpoint is the URL of the S3 API, for example
+	// https://s3.us-east-1.amazonaws.com.
+	Endpoint string
+
+	// Region is the region of the bucket.
+	Region string
+
+	// Bucket is the name of the bucket the backups are stored in.
+	Bucket string
+
+	// Prefix is an optional key prefix the backups are stored under.
+	Prefix string
+
+	// AccessKeyID is the ID of the access key used to sign requests.
+	AccessKeyID string
+
+	// SecretAccessKey is the secret of the access key used to sign
+	// requests.
+	SecretAccessKey string
+
+	// PathStyle, if true, addresses the bucket as part of the path of the
+	// request rather than as a subdomain of the endpoint. Most self-hosted
+	// S3 compatible storages require this.
+	PathStyle bool
+}
+
+// S3Sink is a BackupSink that uploads backups to an S3 compatible object
+// storage. Requests are authenticated with AWS signature version 4.
+type S3Sink struct {
+	cfg *S3Config
+
+	endpoint *url.URL
+
+	client *http.Client
+
+	// now returns the current time, used to timestamp signed requests.
+	now func() time.Time
+}
+
+// NewS3Sink creates a new S3Sink from the passed config.
+func NewS3Sink(cfg *S3Config) (*S3Sink, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %v", err)
+	}
+
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported S3 endpoint scheme: %v",
+			endpoint.Scheme)
+	}
+
+	switch {
+	case cfg.Bucket == "":
+		return nil, fmt.Errorf("S3 bucket must be set")
+
+	case cfg.Region == "":
+		return nil, fmt.Errorf("S3 region must be set")
+
+	case cfg.AccessKeyID == "" || cfg.SecretAccessKey == "":
+		return nil, fmt.Errorf("S3 access key must be set")
+	}
+
+	return &S3Sink{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{},
+		now:      time.Now,
+	}, nil
+}
+
+// Name returns a human readable name that identifies the sink.
+//
+// NOTE: This is part of the BackupSink interface.
+func (s *S3Sink) Name() string {
+	return fmt.Sprintf("s3(%s/%s)", s.endpoint.Host,
+		path.Join(s.cfg.Bucket, s.cfg.Prefix))
+}
+
+// objectURL returns the URL of the object with the given name.
+func (s *S3Sink) objectURL(objectName string) *url.URL {
+	key := path.Join(s.cfg.Prefix, objectName)
+
+	u := *s.endpoint
+	if s.cfg.PathStyle {
+		u.Path = path.Join("/", u.Path, s.cfg.Bucket, key)
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = path.Join("/", u.Path, key)
+	}
+
+	return &u
+}
+
+// Upload stores the packed multi backup under the given object name within the
+// bucket of the sink.
+//
+// NOTE: This is part of the BackupSink interface.
+func (s *S3Sink) Upload(ctx context.Context, objectName string,
+	backup PackedMulti) error {
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPut, s.objectURL(objectName).String(),
+		bytes.NewReader(backup),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	signS3Request(
+		req, backup, s.cfg.AccessKeyID, s.cfg.SecretAccessKey,
+		s.cfg.Region, s.now(),
+	)
+
+	return doUpload(s.client, req)
+}
+
+// A compile-time check to ensure S3Sink implements the BackupSink interface.
+var _ BackupSink = (*S3Sink)(nil)
+
+// signS3Request signs the request using AWS signature version 4, adding the
+// Authorization header along with the headers that are part of the signature.
+// All headers set on the request at the time of signing are signed.
+func signS3Request(req *http.Request, payload []byte, accessKeyID,
+	secretAccessKey, region string, now time.Time) {
+
+	now = now.UTC()
+	amzDate := now.Format(s3DateFormat)
+	shortDate := now.Format(s3ShortDateFormat)
+
+	payloadHash := sha256.Sum256(payload)
+	payloadHashHex := hex.EncodeToString(payloadHash[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHashHex)
+
+	// Gather the canonical headers, which always include the host.
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(
+			strings.Join(values, ","),
+		)
+	}
+	headerNames := make([]string, 0, len(headers))
+	for name := range headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHashHex,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := strings.Join(
+		[]string{shortDate, region, "s3", "aws4_request"}, "/",
+	)
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	// Derive the signing key from the secret through the chain of HMACs
+	// defined by the signature scheme.
+	signingKey := hmacSHA256([]byte("AWS4"+secretAccessKey), shortDate)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data using the given key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
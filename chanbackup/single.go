@@ -14,6 +14,7 @@ import (
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/lnencrypt"
 	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/tlv"
 )
 
 // SingleBackupVersion denotes the version of the single static channel backup.
@@ -48,6 +49,32 @@ const (
 	// commitment and HTLC outputs that pay directly to the channel
 	// initiator.
 	ScriptEnforcedLeaseVersion = 4
+
+	// RecoveryDataVersion is a version that no longer implies the
+	// commitment type of the channel through the version byte. Instead
+	// the channel type is written out explicitly, along with the
+	// commitment height, our local balance and the channel lease expiry at
+	// the time the backup was created. This extra data is appended to the
+	// end of the SCB as a TLV stream.
+	RecoveryDataVersion = 5
+)
+
+const (
+	// chanTypeType is the TLV type of the explicit channel type within
+	// the recovery data of an SCB.
+	chanTypeType tlv.Type = 0
+
+	// commitHeightType is the TLV type of the commitment height within
+	// the recovery data of an SCB.
+	commitHeightType tlv.Type = 1
+
+	// localBalanceType is the TLV type of our local balance within the
+	// recovery data of an SCB.
+	localBalanceType tlv.Type = 2
+
+	// leaseExpiryType is the TLV type of the channel lease expiry within
+	// the recovery data of an SCB.
+	leaseExpiryType tlv.Type = 3
 )
 
 // Single is a static description of an existing channel that can be used for
@@ -133,7 +160,34 @@ type Single struct {
 	// NOTE: This field will only be present for the following versions:
 	//
 	// - ScriptEnforcedLeaseVersion
+	// - RecoveryDataVersion
 	LeaseExpiry uint32
+
+	// ChanType is the channel type of the backed up channel.
+	//
+	// NOTE: This field will only be present for the following versions:
+	//
+	// - RecoveryDataVersion
+	ChanType channeldb.ChannelType
+
+	// CommitHeight is the height of our local commitment chain at the time
+	// the backup was created. During recovery we can use this to sanity
+	// check the state the remote party claims to be on.
+	//
+	// NOTE: This field will only be present for the following versions:
+	//
+	// - RecoveryDataVersion
+	CommitHeight uint64
+
+	// LocalBalance is our settled balance within the latest local
+	// commitment at the time the backup was created. This gives an
+	// estimate of the funds that can be swept once the remote party
+	// force closes the channel.
+	//
+	// NOTE: This field will only be present for the following versions:
+	//
+	// - RecoveryDataVersion
+	LocalBalance lnwire.MilliSatoshi
 }
 
 // NewSingle creates a new static channel backup based on an existing open
@@ -210,27 +264,73 @@ func NewSingle(channel *channeldb.OpenChannel,
 		LocalChanCfg:     channel.LocalChanCfg,
 		RemoteChanCfg:    channel.RemoteChanCfg,
 		ShaChainRootDesc: shaChainRootDesc,
+		Version:          RecoveryDataVersion,
+		ChanType:         channel.ChanType,
+		CommitHeight:     channel.LocalCommitment.CommitHeight,
+		LocalBalance:     channel.LocalCommitment.LocalBalance,
 	}
 
-	switch {
-	case channel.ChanType.HasLeaseExpiration():
-		single.Version = ScriptEnforcedLeaseVersion
+	if channel.ChanType.HasLeaseExpiration() {
 		single.LeaseExpiry = channel.ThawHeight
+	}
+
+	return single
+}
 
-	case channel.ChanType.ZeroHtlcTxFee():
-		single.Version = AnchorsZeroFeeHtlcTxCommitVersion
+// HasRecoveryData returns true if the backup carries the explicit channel type
+// along with the commitment height and balance of the channel.
+func (s *Single) HasRecoveryData() bool {
+	return s.Version == RecoveryDataVersion
+}
 
-	case channel.ChanType.HasAnchors():
-		single.Version = AnchorsCommitVersion
+// CommitChanType returns the channel type of the backed up channel. For legacy
+// versions the channel type is implied by the version of the backup, while
+// newer versions store it explicitly.
+func (s *Single) CommitChanType() (channeldb.ChannelType, error) {
+	var chanType channeldb.ChannelType
+	switch s.Version {
+	case DefaultSingleVersion:
+		chanType = channeldb.SingleFunderBit
 
-	case channel.ChanType.IsTweakless():
-		single.Version = TweaklessCommitVersion
+	case TweaklessCommitVersion:
+		chanType = channeldb.SingleFunderTweaklessBit
+
+	case AnchorsCommitVersion:
+		chanType = channeldb.AnchorOutputsBit
+		chanType |= channeldb.SingleFunderTweaklessBit
+
+	case AnchorsZeroFeeHtlcTxCommitVersion:
+		chanType = channeldb.ZeroHtlcTxFeeBit
+		chanType |= channeldb.AnchorOutputsBit
+		chanType |= channeldb.SingleFunderTweaklessBit
+
+	case ScriptEnforcedLeaseVersion:
+		chanType = channeldb.LeaseExpirationBit
+		chanType |= channeldb.ZeroHtlcTxFeeBit
+		chanType |= channeldb.AnchorOutputsBit
+		chanType |= channeldb.SingleFunderTweaklessBit
+
+	case RecoveryDataVersion:
+		chanType = s.ChanType
 
 	default:
-		single.Version = DefaultSingleVersion
+		return 0, fmt.Errorf("unknown Single version: %v", s.Version)
 	}
 
-	return single
+	return chanType, nil
+}
+
+// recoveryDataStream returns the TLV stream used to encode and decode the
+// recovery data of a RecoveryDataVersion backup.
+func (s *Single) recoveryDataStream(chanType, localBalance *uint64) (
+	*tlv.Stream, error) {
+
+	return tlv.NewStream(
+		tlv.MakePrimitiveRecord(chanTypeType, chanType),
+		tlv.MakePrimitiveRecord(commitHeightType, &s.CommitHeight),
+		tlv.MakePrimitiveRecord(localBalanceType, localBalance),
+		tlv.MakePrimitiveRecord(leaseExpiryType, &s.LeaseExpiry),
+	)
 }
 
 // Serialize attempts to write out the serialized version of the target
@@ -244,6 +344,7 @@ func (s *Single) Serialize(w io.Writer) error {
 	case AnchorsCommitVersion:
 	case AnchorsZeroFeeHtlcTxCommitVersion:
 	case ScriptEnforcedLeaseVersion:
+	case RecoveryDataVersion:
 	default:
 		return fmt.Errorf("unable to serialize w/ unknown "+
 			"version: %v", s.Version)
@@ -305,11 +406,23 @@ func (s *Single) Serialize(w io.Writer) error {
 	); err != nil {
 		return err
 	}
-	if s.Version == ScriptEnforcedLeaseVersion {
+	switch s.Version {
+	case ScriptEnforcedLeaseVersion:
 		err := lnwire.WriteElements(&singleBytes, s.LeaseExpiry)
 		if err != nil {
 			return err
 		}
+
+	case RecoveryDataVersion:
+		chanType := uint64(s.ChanType)
+		localBalance := uint64(s.LocalBalance)
+		tlvStream, err := s.recoveryDataStream(&chanType, &localBalance)
+		if err != nil {
+			return err
+		}
+		if err := tlvStream.Encode(&singleBytes); err != nil {
+			return err
+		}
 	}
 
 	// TODO(yy): remove the type assertion when we finished refactoring db
@@ -420,6 +533,7 @@ func (s *Single) Deserialize(r io.Reader) error {
 	case AnchorsCommitVersion:
 	case AnchorsZeroFeeHtlcTxCommitVersion:
 	case ScriptEnforcedLeaseVersion:
+	case RecoveryDataVersion:
 	default:
 		return fmt.Errorf("unable to de-serialize w/ unknown "+
 			"version: %v", s.Version)
@@ -430,6 +544,11 @@ func (s *Single) Deserialize(r io.Reader) error {
 		return err
 	}
 
+	// The recovery data is encoded as a TLV stream that extends to the
+	// end of the SCB, so we'll limit all further reads to the advertised
+	// length to ensure we don't consume any bytes of a following SCB.
+	r = io.LimitReader(r, int64(length))
+
 	err = lnwire.ReadElements(
 		r, &s.IsInitiator, s.ChainHash[:], &s.FundingOutpoint,
 		&s.ShortChannelID, &s.RemoteNodePub, &s.Addresses, &s.Capacity,
@@ -518,10 +637,24 @@ func (s *Single) Deserialize(r io.Reader) error {
 		return err
 	}
 
-	if s.Version == ScriptEnforcedLeaseVersion {
+	switch s.Version {
+	case ScriptEnforcedLeaseVersion:
 		if err := lnwire.ReadElement(r, &s.LeaseExpiry); err != nil {
 			return err
 		}
+
+	case RecoveryDataVersion:
+		var chanType, localBalance uint64
+		tlvStream, err := s.recoveryDataStream(&chanType, &localBalance)
+		if err != nil {
+			return err
+		}
+		if err := tlvStream.Decode(r); err != nil {
+			return err
+		}
+
+		s.ChanType = channeldb.ChannelType(chanType)
+		s.LocalBalance = lnwire.MilliSatoshi(localBalance)
 	}
 
 	return nil
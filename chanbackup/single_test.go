@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/davecgh/go-spew/spew"
@@ -85,6 +86,14 @@ func assertSingleEqual(t *testing.T, a, b Single) {
 			spew.Sdump(b.ShaChainRootDesc))
 	}
 
+	// The recovery data is only encoded for backups that carry it.
+	if a.HasRecoveryData() {
+		require.Equal(t, a.ChanType, b.ChanType)
+		require.Equal(t, a.CommitHeight, b.CommitHeight)
+		require.Equal(t, a.LocalBalance, b.LocalBalance)
+		require.Equal(t, a.LeaseExpiry, b.LeaseExpiry)
+	}
+
 	if len(a.Addresses) != len(b.Addresses) {
 		t.Fatalf("expected %v addrs got %v", len(a.Addresses),
 			len(b.Addresses))
@@ -126,6 +135,11 @@ func genRandomOpenChannelShell() (*channeldb.OpenChannel, error) {
 
 	chanType := channeldb.ChannelType(rand.Intn(8))
 
+	capacity := btcutil.Amount(rand.Int63n(btcutil.MaxSatoshi))
+	localBalance := lnwire.NewMSatFromSatoshis(
+		btcutil.Amount(rand.Int63n(int64(capacity) + 1)),
+	)
+
 	return &channeldb.OpenChannel{
 		ChainHash:       chainHash,
 		ChanType:        chanType,
@@ -136,6 +150,11 @@ func genRandomOpenChannelShell() (*channeldb.OpenChannel, error) {
 		),
 		ThawHeight:  rand.Uint32(),
 		IdentityPub: pub,
+		Capacity:    capacity,
+		LocalCommitment: channeldb.ChannelCommitment{
+			CommitHeight: uint64(rand.Int63()),
+			LocalBalance: localBalance,
+		},
 		LocalChanCfg: channeldb.ChannelConfig{
 			ChannelConstraints: channeldb.ChannelConstraints{
 				CsvDelay: uint16(rand.Int63()),
@@ -245,6 +264,13 @@ func TestSinglePackUnpack(t *testing.T) {
 			valid:   true,
 		},
 
+		// The new version carrying the recovery data should
+		// pack/unpack with no problem.
+		{
+			version: RecoveryDataVersion,
+			valid:   true,
+		},
+
 		// A non-default version, atm this should result in a failure.
 		{
 			version: 99,
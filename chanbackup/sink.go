@@ -0,0 +1,364 @@
+package chanbackup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/clock"
+)
+
+const (
+	// DefaultUploadTimeout is the default time we'll allow a single upload
+	// attempt to a remote backup sink to take.
+	DefaultUploadTimeout = 30 * time.Second
+
+	// DefaultMinUploadBackoff is the default time we'll wait before
+	// retrying a failed upload for the first time.
+	DefaultMinUploadBackoff = 5 * time.Second
+
+	// DefaultMaxUploadBackoff is the default maximum time we'll wait
+	// between two attempts of uploading a backup to a remote sink.
+	DefaultMaxUploadBackoff = 10 * time.Minute
+
+	// versionTimeFormat is the time format used to name the versioned
+	// copies of a backup that are uploaded to a remote sink.
+	versionTimeFormat = "20060102T150405.000000000Z"
+)
+
+// BackupSink is a remote location that the latest packed multi channel backup
+// can be uploaded to. This allows users to keep an up to date copy of their
+// static channel backups off the device lnd is running on.
+type BackupSink interface {
+	// Name returns a human readable name that identifies the sink.
+	Name() string
+
+	// Upload stores the packed multi backup under the given object name
+	// at the remote location, replacing any existing object with the same
+	// name.
+	Upload(ctx context.Context, objectName string,
+		backup PackedMulti) error
+}
+
+// SinkStatus describes the state of the uploads to a single remote backup
+// sink.
+type SinkStatus struct {
+	// Name is the name of the sink.
+	Name string
+
+	// LastAttempt is the time of the last upload attempt. This is the zero
+	// time if no upload has been attempted yet.
+	LastAttempt time.Time
+
+	// LastSuccess is the time of the last successful upload. This is the
+	// zero time if no upload has succeeded yet.
+	LastSuccess time.Time
+
+	// LastError is the error of the last upload attempt, or nil if it
+	// succeeded.
+	LastError error
+
+	// NumFailedAttempts is the number of consecutive failed upload
+	// attempts.
+	NumFailedAttempts uint32
+
+	// Pending is true if the latest backup hasn't been uploaded yet.
+	Pending bool
+
+	// LastVersion is the object name of the last versioned copy of the
+	// backup that was uploaded. This is empty if versioning is disabled.
+	LastVersion string
+}
+
+// UploadConfig houses the parameters of the uploads to remote backup sinks.
+type UploadConfig struct {
+	// Timeout is the time we allow a single upload attempt to take.
+	Timeout time.Duration
+
+	// MinBackoff is the time we'll wait before retrying a failed upload
+	// for the first time. The backoff doubles with each consecutive
+	// failure.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the maximum time we'll wait between two upload
+	// attempts.
+	MaxBackoff time.Duration
+
+	// Versioning, if true, additionally uploads each backup under a unique
+	// object name derived from the current time. This ensures that an
+	// older backup can still be retrieved if a corrupt one is uploaded.
+	Versioning bool
+
+	// Clock is the clock used to timestamp the uploads.
+	Clock clock.Clock
+}
+
+// UploadingSwapper is a Swapper that additionally uploads each new packed
+// multi backup to a set of remote backup sinks, after it has been swapped in
+// by the wrapped Swapper. Each sink is served by its own goroutine, so that a
+// slow or unreachable sink doesn't hold up the local backup or other sinks.
+type UploadingSwapper struct {
+	started sync.Once
+	stopped sync.Once
+
+	Swapper
+
+	uploaders []*sinkUploader
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewUploadingSwapper creates a new UploadingSwapper that wraps the passed
+// Swapper and uploads every backup to the given sinks.
+func NewUploadingSwapper(swapper Swapper, cfg *UploadConfig,
+	sinks ...BackupSink) *UploadingSwapper {
+
+	quit := make(chan struct{})
+	uploaders := make([]*sinkUploader, 0, len(sinks))
+	for _, sink := range sinks {
+		uploaders = append(uploaders, &sinkUploader{
+			sink:      sink,
+			cfg:       cfg,
+			newBackup: make(chan struct{}, 1),
+			status: SinkStatus{
+				Name: sink.Name(),
+			},
+			quit: quit,
+		})
+	}
+
+	return &UploadingSwapper{
+		Swapper:   swapper,
+		uploaders: uploaders,
+		quit:      quit,
+	}
+}
+
+// Start launches the upload goroutines of all sinks.
+func (u *UploadingSwapper) Start() error {
+	u.started.Do(func() {
+		log.Infof("Starting uploads to %d remote backup sink(s)",
+			len(u.uploaders))
+
+		for _, uploader := range u.uploaders {
+			u.wg.Add(1)
+			go uploader.uploadLoop(&u.wg)
+		}
+	})
+
+	return nil
+}
+
+// Stop signals all upload goroutines to exit and waits for them to do so.
+func (u *UploadingSwapper) Stop() error {
+	u.stopped.Do(func() {
+		log.Infof("Stopping uploads to remote backup sinks")
+
+		close(u.quit)
+		u.wg.Wait()
+	})
+
+	return nil
+}
+
+// UpdateAndSwap swaps in the new backup using the wrapped Swapper, and then
+// queues it for upload to all remote sinks. The upload happens in the
+// background, so a failure to upload isn't reported back to the caller but
+// reflected in the status of the sink instead.
+//
+// NOTE: This is part of the Swapper interface.
+func (u *UploadingSwapper) UpdateAndSwap(newBackup PackedMulti) error {
+	if err := u.Swapper.UpdateAndSwap(newBackup); err != nil {
+		return err
+	}
+
+	for _, uploader := range u.uploaders {
+		uploader.queue(newBackup)
+	}
+
+	return nil
+}
+
+// SinkStatuses returns the current upload status of all remote sinks.
+func (u *UploadingSwapper) SinkStatuses() []SinkStatus {
+	statuses := make([]SinkStatus, 0, len(u.uploaders))
+	for _, uploader := range u.uploaders {
+		statuses = append(statuses, uploader.currentStatus())
+	}
+
+	return statuses
+}
+
+// A compile-time check to ensure UploadingSwapper implements the Swapper
+// interface.
+var _ Swapper = (*UploadingSwapper)(nil)
+
+// sinkUploader is responsible for uploading the latest backup to a single
+// remote sink, retrying with an exponential backoff on failure.
+type sinkUploader struct {
+	sink BackupSink
+	cfg  *UploadConfig
+
+	// newBackup is signalled once a new backup has been queued.
+	newBackup chan struct{}
+
+	// mu guards pending, pendingSeq and status.
+	mu sync.Mutex
+
+	// pending is the latest backup that still needs to be uploaded. If a
+	// new backup is queued before the previous one could be uploaded, the
+	// older one is simply replaced, as only the latest state matters.
+	pending PackedMulti
+
+	// pendingSeq is incremented each time a new backup is queued. It
+	// allows us to detect whether the pending backup was replaced while
+	// an upload was in progress.
+	pendingSeq uint64
+
+	status SinkStatus
+
+	quit chan struct{}
+}
+
+// queue replaces the pending backup with the given one and wakes up the
+// upload goroutine.
+func (s *sinkUploader) queue(backup PackedMulti) {
+	s.mu.Lock()
+	s.pending = backup
+	s.pendingSeq++
+	s.status.Pending = true
+	s.mu.Unlock()
+
+	select {
+	case s.newBackup <- struct{}{}:
+	default:
+	}
+}
+
+// currentStatus returns a copy of the current status of the sink.
+func (s *sinkUploader) currentStatus() SinkStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.status
+}
+
+// uploadLoop uploads each queued backup to the sink, retrying failed uploads
+// until they succeed or a newer backup supersedes them.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *sinkUploader) uploadLoop(wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	var (
+		backoff time.Duration
+		retry   <-chan time.Time
+	)
+	for {
+		select {
+		case <-s.newBackup:
+			// A new backup resets the backoff, as it may succeed
+			// where the previous one failed.
+			backoff = 0
+
+		case <-retry:
+
+		case <-s.quit:
+			return
+		}
+
+		retry = nil
+
+		s.mu.Lock()
+		backup, seq := s.pending, s.pendingSeq
+		s.mu.Unlock()
+
+		if backup == nil {
+			continue
+		}
+
+		err := s.upload(backup, seq)
+		if err == nil {
+			continue
+		}
+
+		backoff = nextBackoff(backoff, s.cfg)
+
+		log.Errorf("Unable to upload channel backup to %v, retrying "+
+			"in %v: %v", s.sink.Name(), backoff, err)
+
+		retry = time.After(backoff)
+	}
+}
+
+// upload attempts a single upload of the backup to the sink and records the
+// outcome in the status of the sink. The passed sequence number identifies the
+// queued backup that is being uploaded.
+func (s *sinkUploader) upload(backup PackedMulti, seq uint64) error {
+	now := s.cfg.Clock.Now()
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), s.cfg.Timeout,
+	)
+	defer cancel()
+
+	err := s.sink.Upload(ctx, DefaultBackupFileName, backup)
+
+	// If versioning is enabled, we'll also store a copy of the backup
+	// under a unique name, so it won't be overwritten by any future
+	// backups.
+	var versionName string
+	if err == nil && s.cfg.Versioning {
+		versionName = versionedObjectName(now)
+		err = s.sink.Upload(ctx, versionName, backup)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.LastAttempt = now
+	s.status.LastError = err
+	if err != nil {
+		s.status.NumFailedAttempts++
+		return err
+	}
+
+	log.Infof("Uploaded channel backup to %v", s.sink.Name())
+
+	s.status.LastSuccess = now
+	s.status.NumFailedAttempts = 0
+	s.status.LastVersion = versionName
+
+	// Only clear the pending backup if no newer one was queued in the
+	// meantime.
+	if s.pendingSeq == seq {
+		s.pending = nil
+		s.status.Pending = false
+	}
+
+	return nil
+}
+
+// nextBackoff returns the backoff to wait after a failed upload attempt given
+// the backoff used for the previous attempt.
+func nextBackoff(backoff time.Duration, cfg *UploadConfig) time.Duration {
+	if backoff == 0 {
+		return cfg.MinBackoff
+	}
+
+	backoff *= 2
+	if backoff > cfg.MaxBackoff {
+		backoff = cfg.MaxBackoff
+	}
+
+	return backoff
+}
+
+// versionedObjectName returns the unique object name a versioned copy of a
+// backup taken at the given time is stored under.
+func versionedObjectName(t time.Time) string {
+	return fmt.Sprintf("channel-%s.backup",
+		t.UTC().Format(versionTimeFormat))
+}
@@ -0,0 +1,216 @@
+package chanbackup
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSink is a BackupSink that records all uploads and fails a configurable
+// number of upload attempts.
+type mockSink struct {
+	sync.Mutex
+
+	// failures is the number of upload attempts that still need to fail.
+	failures int
+
+	uploads chan string
+
+	objects map[string]PackedMulti
+}
+
+func newMockSink(failures int) *mockSink {
+	return &mockSink{
+		failures: failures,
+		uploads:  make(chan string, 10),
+		objects:  make(map[string]PackedMulti),
+	}
+}
+
+func (m *mockSink) Name() string {
+	return "mock"
+}
+
+func (m *mockSink) Upload(_ context.Context, objectName string,
+	backup PackedMulti) error {
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.failures > 0 {
+		m.failures--
+		return fmt.Errorf("fail")
+	}
+
+	m.objects[objectName] = backup
+	m.uploads <- objectName
+
+	return nil
+}
+
+// nopSwapper is a Swapper that doesn't store the backups anywhere.
+type nopSwapper struct{}
+
+func (n *nopSwapper) UpdateAndSwap(PackedMulti) error {
+	return nil
+}
+
+func (n *nopSwapper) ExtractMulti(keychain.KeyRing) (*Multi, error) {
+	return nil, nil
+}
+
+// assertUpload asserts that the sink receives an upload of the given object.
+func assertUpload(t *testing.T, sink *mockSink, objectName string) {
+	t.Helper()
+
+	select {
+	case name := <-sink.uploads:
+		require.Equal(t, objectName, name)
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("upload of %v not received", objectName)
+	}
+}
+
+// TestUploadingSwapper tests that backups are uploaded to the remote sinks,
+// and that failed uploads are retried.
+func TestUploadingSwapper(t *testing.T) {
+	t.Parallel()
+
+	testTime := time.Date(2022, 10, 18, 12, 0, 0, 0, time.UTC)
+	cfg := &UploadConfig{
+		Timeout:    time.Second,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+		Versioning: true,
+		Clock:      clock.NewTestClock(testTime),
+	}
+
+	// The first sink fails a couple of times before the upload succeeds,
+	// while the second one succeeds right away.
+	failingSink := newMockSink(3)
+	sink := newMockSink(0)
+
+	swapper := NewUploadingSwapper(&nopSwapper{}, cfg, failingSink, sink)
+	require.NoError(t, swapper.Start())
+	defer func() {
+		require.NoError(t, swapper.Stop())
+	}()
+
+	backup := PackedMulti("backup")
+	require.NoError(t, swapper.UpdateAndSwap(backup))
+
+	// Each sink should receive the latest backup along with the versioned
+	// copy.
+	versionName := versionedObjectName(testTime)
+	for _, s := range []*mockSink{failingSink, sink} {
+		assertUpload(t, s, DefaultBackupFileName)
+		assertUpload(t, s, versionName)
+
+		s.Lock()
+		require.Equal(t, backup, s.objects[DefaultBackupFileName])
+		require.Equal(t, backup, s.objects[versionName])
+		s.Unlock()
+	}
+
+	// Once the uploads are done, the status should reflect that no backup
+	// is pending anymore.
+	require.Eventually(t, func() bool {
+		for _, status := range swapper.SinkStatuses() {
+			if status.Pending || status.LastError != nil {
+				return false
+			}
+		}
+
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for _, status := range swapper.SinkStatuses() {
+		require.Equal(t, "mock", status.Name)
+		require.Equal(t, testTime, status.LastSuccess)
+		require.Equal(t, versionName, status.LastVersion)
+		require.Zero(t, status.NumFailedAttempts)
+	}
+}
+
+// TestNextBackoff tests that the upload backoff doubles up to the maximum.
+func TestNextBackoff(t *testing.T) {
+	t.Parallel()
+
+	cfg := &UploadConfig{
+		MinBackoff: time.Second,
+		MaxBackoff: 3 * time.Second,
+	}
+
+	backoff := nextBackoff(0, cfg)
+	require.Equal(t, time.Second, backoff)
+
+	backoff = nextBackoff(backoff, cfg)
+	require.Equal(t, 2*time.Second, backoff)
+
+	backoff = nextBackoff(backoff, cfg)
+	require.Equal(t, 3*time.Second, backoff)
+
+	backoff = nextBackoff(backoff, cfg)
+	require.Equal(t, 3*time.Second, backoff)
+}
+
+// TestHTTPSinkUpload tests that the HTTP sink uploads backups through an
+// authenticated PUT request, and reports failed uploads.
+func TestHTTPSinkUpload(t *testing.T) {
+	t.Parallel()
+
+	var (
+		uploadPath string
+		uploadBody []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			if !ok || user != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			require.Equal(t, http.MethodPut, r.Method)
+
+			uploadPath = r.URL.Path
+			uploadBody, _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		},
+	))
+	defer server.Close()
+
+	sink, err := NewHTTPSink(server.URL+"/dav/lnd", "user", "pass")
+	require.NoError(t, err)
+
+	backup := PackedMulti("backup")
+	err = sink.Upload(
+		context.Background(), DefaultBackupFileName, backup,
+	)
+	require.NoError(t, err)
+	require.Equal(t, "/dav/lnd/channel.backup", uploadPath)
+	require.Equal(t, []byte(backup), uploadBody)
+
+	// With the wrong credentials, the upload should fail.
+	sink, err = NewHTTPSink(server.URL+"/dav/lnd", "user", "wrong")
+	require.NoError(t, err)
+
+	err = sink.Upload(
+		context.Background(), DefaultBackupFileName, backup,
+	)
+	require.Error(t, err)
+
+	// Only http and https URLs are supported.
+	_, err = NewHTTPSink("ftp://example.com/backups", "", "")
+	require.Error(t, err)
+}
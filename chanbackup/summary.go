@@ -0,0 +1,122 @@
+package chanbackup
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/channeldb"
+)
+
+// RecoverySummary describes the funds we expect to recover from a channel once
+// it has been restored from a static channel backup and the remote party
+// force closed it through the data loss protection protocol.
+type RecoverySummary struct {
+	// ChanPoint is the funding outpoint of the restored channel.
+	ChanPoint wire.OutPoint
+
+	// RemoteNodePub is the identity public key of the channel peer.
+	RemoteNodePub *btcec.PublicKey
+
+	// Capacity is the total capacity of the channel.
+	Capacity btcutil.Amount
+
+	// ChanType is the channel type of the restored channel.
+	ChanType channeldb.ChannelType
+
+	// HasRecoveryData is true if the backup recorded the commitment
+	// height and local balance of the channel. If false, the
+	// CommitHeight and ExpectedBalance fields are unknown and left at
+	// zero.
+	HasRecoveryData bool
+
+	// CommitHeight is the height of our local commitment chain at the time
+	// the backup was created.
+	CommitHeight uint64
+
+	// ExpectedBalance is our settled balance at the time the backup was
+	// created. Funds within in-flight HTLCs are not included, and the
+	// amount swept will be lower by the fee of the sweep transaction.
+	ExpectedBalance btcutil.Amount
+
+	// CsvDelay is the number of blocks our output on the remote party's
+	// commitment is encumbered for after it confirms.
+	CsvDelay uint32
+
+	// LeaseExpiry is the absolute height until which our output is
+	// encumbered due to a channel lease. This is zero if our output isn't
+	// subject to a lease.
+	LeaseExpiry uint32
+}
+
+// NewRecoverySummary creates the expected recovery summary for a single
+// channel backup.
+func NewRecoverySummary(backup Single) (*RecoverySummary, error) {
+	chanType, err := backup.CommitChanType()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &RecoverySummary{
+		ChanPoint:       backup.FundingOutpoint,
+		RemoteNodePub:   backup.RemoteNodePub,
+		Capacity:        backup.Capacity,
+		ChanType:        chanType,
+		HasRecoveryData: backup.HasRecoveryData(),
+	}
+
+	if summary.HasRecoveryData {
+		summary.CommitHeight = backup.CommitHeight
+		summary.ExpectedBalance = backup.LocalBalance.ToSatoshis()
+	}
+
+	// Once the remote party force closes, our funds will be within the
+	// to_remote output of their commitment. For anchor channels, this
+	// output carries a CSV delay of a single block.
+	if chanType.HasAnchors() {
+		summary.CsvDelay = 1
+	}
+
+	// If this is a leased channel and we were the initiator, then our
+	// output will additionally be locked until the lease expires.
+	if chanType.HasLeaseExpiration() && backup.IsInitiator {
+		summary.LeaseExpiry = backup.LeaseExpiry
+	}
+
+	return summary, nil
+}
+
+// SanityCheck ensures that the recovery data within the backup is consistent
+// with the remaining static information of the channel.
+func (s *Single) SanityCheck() error {
+	if !s.HasRecoveryData() {
+		return nil
+	}
+
+	if s.LocalBalance.ToSatoshis() > s.Capacity {
+		return fmt.Errorf("local balance of %v exceeds channel "+
+			"capacity of %v", s.LocalBalance.ToSatoshis(),
+			s.Capacity)
+	}
+
+	if s.ChanType.HasLeaseExpiration() && s.LeaseExpiry == 0 {
+		return fmt.Errorf("leased channel is missing lease expiry")
+	}
+
+	return nil
+}
+
+// String returns a human readable description of the summary.
+func (r *RecoverySummary) String() string {
+	if !r.HasRecoveryData {
+		return fmt.Sprintf("ChannelPoint(%v): capacity=%v, "+
+			"chan_type=%v, expected balance unknown", r.ChanPoint,
+			r.Capacity, r.ChanType)
+	}
+
+	return fmt.Sprintf("ChannelPoint(%v): capacity=%v, chan_type=%v, "+
+		"commit_height=%v, expected_balance=%v, csv_delay=%v, "+
+		"lease_expiry=%v", r.ChanPoint, r.Capacity, r.ChanType,
+		r.CommitHeight, r.ExpectedBalance, r.CsvDelay, r.LeaseExpiry)
+}
@@ -0,0 +1,197 @@
+package chanbackup
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewRecoverySummary tests that we derive the expected recovery of a
+// channel from both legacy backups and backups carrying recovery data.
+func TestNewRecoverySummary(t *testing.T) {
+	t.Parallel()
+
+	leaseChanType := channeldb.LeaseExpirationBit |
+		channeldb.ZeroHtlcTxFeeBit | channeldb.AnchorOutputsBit |
+		channeldb.SingleFunderTweaklessBit
+
+	testCases := []struct {
+		name            string
+		backup          Single
+		expectedSummary RecoverySummary
+	}{
+		{
+			name: "legacy tweakless",
+			backup: Single{
+				Version:  TweaklessCommitVersion,
+				Capacity: 100_000,
+			},
+			expectedSummary: RecoverySummary{
+				Capacity: 100_000,
+				ChanType: channeldb.SingleFunderTweaklessBit,
+			},
+		},
+		{
+			name: "legacy anchors",
+			backup: Single{
+				Version:  AnchorsZeroFeeHtlcTxCommitVersion,
+				Capacity: 100_000,
+			},
+			expectedSummary: RecoverySummary{
+				Capacity: 100_000,
+				ChanType: channeldb.ZeroHtlcTxFeeBit |
+					channeldb.AnchorOutputsBit |
+					channeldb.SingleFunderTweaklessBit,
+				CsvDelay: 1,
+			},
+		},
+		{
+			name: "recovery data tweakless",
+			backup: Single{
+				Version:      RecoveryDataVersion,
+				Capacity:     100_000,
+				ChanType:     channeldb.SingleFunderTweaklessBit,
+				CommitHeight: 42,
+				LocalBalance: lnwire.NewMSatFromSatoshis(
+					40_000,
+				) + 999,
+			},
+			expectedSummary: RecoverySummary{
+				Capacity:        100_000,
+				ChanType:        channeldb.SingleFunderTweaklessBit,
+				HasRecoveryData: true,
+				CommitHeight:    42,
+				ExpectedBalance: 40_000,
+			},
+		},
+		{
+			name: "recovery data lease initiator",
+			backup: Single{
+				Version:      RecoveryDataVersion,
+				IsInitiator:  true,
+				Capacity:     100_000,
+				ChanType:     leaseChanType,
+				CommitHeight: 7,
+				LocalBalance: lnwire.NewMSatFromSatoshis(
+					btcutil.Amount(60_000),
+				),
+				LeaseExpiry: 800_000,
+			},
+			expectedSummary: RecoverySummary{
+				Capacity:        100_000,
+				ChanType:        leaseChanType,
+				HasRecoveryData: true,
+				CommitHeight:    7,
+				ExpectedBalance: 60_000,
+				CsvDelay:        1,
+				LeaseExpiry:     800_000,
+			},
+		},
+		{
+			name: "recovery data lease responder",
+			backup: Single{
+				Version:      RecoveryDataVersion,
+				Capacity:     100_000,
+				ChanType:     leaseChanType,
+				CommitHeight: 7,
+				LocalBalance: lnwire.NewMSatFromSatoshis(
+					btcutil.Amount(60_000),
+				),
+				LeaseExpiry: 800_000,
+			},
+			expectedSummary: RecoverySummary{
+				Capacity:        100_000,
+				ChanType:        leaseChanType,
+				HasRecoveryData: true,
+				CommitHeight:    7,
+				ExpectedBalance: 60_000,
+				CsvDelay:        1,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			summary, err := NewRecoverySummary(testCase.backup)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedSummary, *summary)
+		})
+	}
+
+	// A backup with an unknown version should be rejected.
+	_, err := NewRecoverySummary(Single{Version: 99})
+	require.Error(t, err)
+}
+
+// TestSingleSanityCheck tests that inconsistent recovery data within a backup
+// is detected.
+func TestSingleSanityCheck(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		backup Single
+		valid  bool
+	}{
+		{
+			name: "legacy backup",
+			backup: Single{
+				Version: ScriptEnforcedLeaseVersion,
+			},
+			valid: true,
+		},
+		{
+			name: "valid recovery data",
+			backup: Single{
+				Version:  RecoveryDataVersion,
+				Capacity: 100_000,
+				LocalBalance: lnwire.NewMSatFromSatoshis(
+					100_000,
+				),
+			},
+			valid: true,
+		},
+		{
+			name: "balance exceeds capacity",
+			backup: Single{
+				Version:  RecoveryDataVersion,
+				Capacity: 100_000,
+				LocalBalance: lnwire.NewMSatFromSatoshis(
+					100_001,
+				),
+			},
+			valid: false,
+		},
+		{
+			name: "lease without expiry",
+			backup: Single{
+				Version:  RecoveryDataVersion,
+				Capacity: 100_000,
+				ChanType: channeldb.LeaseExpirationBit,
+			},
+			valid: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := testCase.backup.SanityCheck()
+			if testCase.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -82,6 +82,11 @@ type peerLog struct {
 	// channels contains a set of currently open channels. Channels will be
 	// added and removed from this map as they are opened and closed.
 	channels map[wire.OutPoint]*channelInfo
+
+	// conn tracks the quality of our connections to the peer. Unlike our
+	// online events, it is tracked whether or not we have channels with
+	// the peer.
+	conn connQuality
 }
 
 // newPeerLog creates a log for a peer, taking its historical flap count and
@@ -132,6 +137,7 @@ func (p *peerLog) onlineEvent(online bool) {
 	p.flapCount++
 	p.lastFlap = &eventTime
 	p.online = online
+	p.conn.onlineEvent(online, eventTime)
 
 	// If we have no channels currently open with the peer, we do not want
 	// to commit resources to tracking their online state beyond a simple
@@ -256,6 +262,29 @@ func (p *peerLog) getFlapCount() (int, *time.Time) {
 	return p.flapCount, p.lastFlap
 }
 
+// recordPing records the round trip time of a ping to the peer.
+func (p *peerLog) recordPing(latency time.Duration) {
+	p.conn.recordPing(latency)
+}
+
+// recordTraffic records traffic exchanged with the peer.
+func (p *peerLog) recordTraffic(traffic Traffic) {
+	p.conn.recordTraffic(traffic)
+}
+
+// recordDisconnect records the reason that the peer was disconnected.
+func (p *peerLog) recordDisconnect(reason DisconnectReason) {
+	p.conn.recordDisconnect(reason, p.clock.Now())
+}
+
+// connQuality returns the quality metrics of our connections to the peer.
+func (p *peerLog) connQuality() *ConnectionQuality {
+	quality := p.conn.snapshot(p.clock.Now())
+	quality.FlapCount = p.flapCount
+
+	return quality
+}
+
 // listEvents returns all of the events that our event log has tracked,
 // including events that are staged for addition to our set of events but have
 // not yet been committed to (because we rate limit and store only the aggregate
@@ -59,6 +59,14 @@ type ChannelEventStore struct {
 	// peerRequests serves requests for information about a peer.
 	peerRequests chan peerRequest
 
+	// connEvents receives the ping, traffic and disconnect events that
+	// our peers report about their connections.
+	connEvents chan interface{}
+
+	// connQualityRequests serves requests for the connection quality of
+	// our peers.
+	connQualityRequests chan connQualityRequest
+
 	quit chan struct{}
 
 	wg sync.WaitGroup
@@ -122,16 +130,49 @@ type peerResponse struct {
 	err       error
 }
 
+// pingEvent reports the round trip time of a ping to a peer.
+type pingEvent struct {
+	peer    route.Vertex
+	latency time.Duration
+}
+
+// trafficEvent reports the traffic exchanged with a peer since its last
+// report.
+type trafficEvent struct {
+	peer    route.Vertex
+	traffic Traffic
+}
+
+// disconnectEvent reports the reason that a peer was disconnected.
+type disconnectEvent struct {
+	peer   route.Vertex
+	reason DisconnectReason
+}
+
+// connQualityRequest requests the connection quality of a single peer, or of
+// all peers if peer is nil.
+type connQualityRequest struct {
+	peer         *route.Vertex
+	responseChan chan connQualityResponse
+}
+
+type connQualityResponse struct {
+	quality map[route.Vertex]*ConnectionQuality
+	err     error
+}
+
 // NewChannelEventStore initializes an event store with the config provided.
 // Note that this function does not start the main event loop, Start() must be
 // called.
 func NewChannelEventStore(config *Config) *ChannelEventStore {
 	store := &ChannelEventStore{
-		cfg:              config,
-		peers:            make(map[route.Vertex]peerMonitor),
-		chanInfoRequests: make(chan channelInfoRequest),
-		peerRequests:     make(chan peerRequest),
-		quit:             make(chan struct{}),
+		cfg:                 config,
+		peers:               make(map[route.Vertex]peerMonitor),
+		chanInfoRequests:    make(chan channelInfoRequest),
+		peerRequests:        make(chan peerRequest),
+		connEvents:          make(chan interface{}),
+		connQualityRequests: make(chan connQualityRequest),
+		quit:                make(chan struct{}),
 	}
 
 	return store
@@ -300,6 +341,41 @@ func (c *ChannelEventStore) peerEvent(peer route.Vertex, online bool) {
 	peerMonitor.onlineEvent(online)
 }
 
+// connEvent records a ping, traffic or disconnect event reported by a peer,
+// creating a peer monitor for it if we do not currently have one.
+func (c *ChannelEventStore) connEvent(e interface{}) {
+	switch event := e.(type) {
+	case pingEvent:
+		peerMonitor, err := c.getPeerMonitor(event.peer)
+		if err != nil {
+			log.Errorf("could not create monitor: %v", err)
+			return
+		}
+
+		peerMonitor.recordPing(event.latency)
+
+	case trafficEvent:
+		peerMonitor, err := c.getPeerMonitor(event.peer)
+		if err != nil {
+			log.Errorf("could not create monitor: %v", err)
+			return
+		}
+
+		peerMonitor.recordTraffic(event.traffic)
+
+	case disconnectEvent:
+		peerMonitor, err := c.getPeerMonitor(event.peer)
+		if err != nil {
+			log.Errorf("could not create monitor: %v", err)
+			return
+		}
+
+		log.Debugf("Peer %v disconnected: %v", event.peer, event.reason)
+
+		peerMonitor.recordDisconnect(event.reason)
+	}
+}
+
 // subscriptions abstracts away from subscription clients to allow for mocking.
 type subscriptions struct {
 	channelUpdates <-chan interface{}
@@ -400,6 +476,17 @@ func (c *ChannelEventStore) consume(subscriptions *subscriptions) {
 			)
 			req.responseChan <- resp
 
+		// Process the connection events that our peers report.
+		case e := <-c.connEvents:
+			c.connEvent(e)
+
+		// Serve all requests for the connection quality of our peers.
+		case req := <-c.connQualityRequests:
+			var resp connQualityResponse
+
+			resp.quality, resp.err = c.connQuality(req.peer)
+			req.responseChan <- resp
+
 		case <-c.cfg.FlapCountTicker.Ticks():
 			if err := c.recordFlapCount(); err != nil {
 				log.Errorf("could not record flap "+
@@ -540,6 +627,120 @@ func (c *ChannelEventStore) flapCount(peer route.Vertex) (int, *time.Time,
 	}
 }
 
+// sendConnEvent delivers a connection event to the main event loop, returning
+// an error if the store has received the shutdown signal.
+func (c *ChannelEventStore) sendConnEvent(event interface{}) error {
+	select {
+	case c.connEvents <- event:
+		return nil
+
+	case <-c.quit:
+		return errShuttingDown
+	}
+}
+
+// RecordPingLatency records the round trip time of a ping to the peer.
+func (c *ChannelEventStore) RecordPingLatency(peer route.Vertex,
+	latency time.Duration) error {
+
+	return c.sendConnEvent(pingEvent{
+		peer:    peer,
+		latency: latency,
+	})
+}
+
+// RecordTraffic records the traffic that was exchanged with a peer since the
+// previous call for the peer.
+func (c *ChannelEventStore) RecordTraffic(peer route.Vertex,
+	traffic Traffic) error {
+
+	return c.sendConnEvent(trafficEvent{
+		peer:    peer,
+		traffic: traffic,
+	})
+}
+
+// RecordDisconnect records the reason that a peer was disconnected.
+func (c *ChannelEventStore) RecordDisconnect(peer route.Vertex,
+	reason DisconnectReason) error {
+
+	return c.sendConnEvent(disconnectEvent{
+		peer:   peer,
+		reason: reason,
+	})
+}
+
+// GetConnQuality returns the quality of our connections to a peer. If the
+// peer is not tracked by the store, ErrPeerNotFound is returned.
+func (c *ChannelEventStore) GetConnQuality(peer route.Vertex) (
+	*ConnectionQuality, error) {
+
+	quality, err := c.requestConnQuality(&peer)
+	if err != nil {
+		return nil, err
+	}
+
+	return quality[peer], nil
+}
+
+// ListConnQuality returns the quality of our connections to all peers that
+// the store tracks.
+func (c *ChannelEventStore) ListConnQuality() (
+	map[route.Vertex]*ConnectionQuality, error) {
+
+	return c.requestConnQuality(nil)
+}
+
+// requestConnQuality requests the connection quality of a single peer, or of
+// all peers if peer is nil, from the main event loop.
+func (c *ChannelEventStore) requestConnQuality(peer *route.Vertex) (
+	map[route.Vertex]*ConnectionQuality, error) {
+
+	request := connQualityRequest{
+		peer:         peer,
+		responseChan: make(chan connQualityResponse),
+	}
+
+	select {
+	case c.connQualityRequests <- request:
+	case <-c.quit:
+		return nil, errShuttingDown
+	}
+
+	select {
+	case resp := <-request.responseChan:
+		return resp.quality, resp.err
+
+	case <-c.quit:
+		return nil, errShuttingDown
+	}
+}
+
+// connQuality collects the connection quality of a single peer, or of all
+// peers if peer is nil.
+func (c *ChannelEventStore) connQuality(peer *route.Vertex) (
+	map[route.Vertex]*ConnectionQuality, error) {
+
+	quality := make(map[route.Vertex]*ConnectionQuality)
+
+	if peer != nil {
+		peerMonitor, ok := c.peers[*peer]
+		if !ok {
+			return nil, ErrPeerNotFound
+		}
+
+		quality[*peer] = peerMonitor.connQuality()
+
+		return quality, nil
+	}
+
+	for vertex, peerMonitor := range c.peers {
+		quality[vertex] = peerMonitor.connQuality()
+	}
+
+	return quality, nil
+}
+
 // recordFlapCount will record our flap count for each peer that we are
 // currently tracking, skipping peers that have a 0 flap count.
 func (c *ChannelEventStore) recordFlapCount() error {
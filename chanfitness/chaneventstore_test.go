@@ -338,3 +338,39 @@ func TestFlapCount(t *testing.T) {
 
 	ctx.stop()
 }
+
+// TestConnQuality tests that the store records the connection events that
+// peers report and serves requests for their connection quality.
+func TestConnQuality(t *testing.T) {
+	ctx := newChanEventStoreTestCtx(t)
+	ctx.start()
+
+	peer, _, _ := ctx.newChannel()
+
+	// We have no record of the peer yet.
+	_, err := ctx.store.GetConnQuality(peer)
+	require.Equal(t, ErrPeerNotFound, err)
+
+	// Reporting events for a peer creates a record for it, even without
+	// any channels.
+	require.NoError(t, ctx.store.RecordPingLatency(peer, time.Second))
+	require.NoError(t, ctx.store.RecordTraffic(peer, Traffic{MsgsSent: 2}))
+	require.NoError(t, ctx.store.RecordDisconnect(
+		peer, DisconnectReadError,
+	))
+
+	quality, err := ctx.store.GetConnQuality(peer)
+	require.NoError(t, err)
+	require.Equal(t, time.Second, quality.LastPingLatency)
+	require.Equal(t, Traffic{MsgsSent: 2}, quality.Traffic)
+	require.Equal(t, DisconnectReadError, quality.LastDisconnect)
+	require.EqualValues(t, 1, quality.Failures())
+
+	all, err := ctx.store.ListConnQuality()
+	require.NoError(t, err)
+	require.Equal(t, map[route.Vertex]*ConnectionQuality{
+		peer: quality,
+	}, all)
+
+	ctx.stop()
+}
@@ -0,0 +1,271 @@
+package chanfitness
+
+import (
+	"time"
+)
+
+const (
+	// latencyWeight is the weight that a new ping latency sample is
+	// given in the moving average of a peer's latency.
+	latencyWeight = 0.2
+)
+
+// DisconnectReason describes why the connection to a peer was torn down.
+type DisconnectReason uint8
+
+const (
+	// DisconnectUnknown is used when the reason of a disconnect is not
+	// known.
+	DisconnectUnknown DisconnectReason = iota
+
+	// DisconnectLocal is used when we deliberately disconnected the peer,
+	// for example on shutdown or when asked to over RPC.
+	DisconnectLocal
+
+	// DisconnectIdleTimeout is used when the peer did not send or accept
+	// any message for too long.
+	DisconnectIdleTimeout
+
+	// DisconnectReadError is used when we failed to read a message from
+	// the peer, which includes the peer closing the connection.
+	DisconnectReadError
+
+	// DisconnectWriteError is used when we failed to write a message to
+	// the peer.
+	DisconnectWriteError
+)
+
+// String returns a string representation of the disconnect reason.
+func (r DisconnectReason) String() string {
+	switch r {
+	case DisconnectLocal:
+		return "local"
+
+	case DisconnectIdleTimeout:
+		return "idle_timeout"
+
+	case DisconnectReadError:
+		return "read_error"
+
+	case DisconnectWriteError:
+		return "write_error"
+	}
+
+	return "unknown"
+}
+
+// Failure returns true if the disconnect was caused by the connection failing
+// rather than by us closing it deliberately.
+func (r DisconnectReason) Failure() bool {
+	switch r {
+	case DisconnectIdleTimeout, DisconnectReadError, DisconnectWriteError:
+		return true
+	}
+
+	return false
+}
+
+// Traffic is the number of messages and bytes exchanged with a peer.
+type Traffic struct {
+	// MsgsSent is the number of messages sent to the peer.
+	MsgsSent uint64
+
+	// MsgsReceived is the number of messages received from the peer.
+	MsgsReceived uint64
+
+	// BytesSent is the number of bytes sent to the peer.
+	BytesSent uint64
+
+	// BytesReceived is the number of bytes received from the peer.
+	BytesReceived uint64
+}
+
+// add adds the given traffic to our own.
+func (t *Traffic) add(other Traffic) {
+	t.MsgsSent += other.MsgsSent
+	t.MsgsReceived += other.MsgsReceived
+	t.BytesSent += other.BytesSent
+	t.BytesReceived += other.BytesReceived
+}
+
+// ConnectionQuality summarizes the quality of our connections to a peer since
+// we started tracking it. Like the rest of the event store, these metrics are
+// not persisted across restarts.
+type ConnectionQuality struct {
+	// Online is true if the peer is currently connected.
+	Online bool
+
+	// LastPingLatency is the most recent round trip time of a ping.
+	LastPingLatency time.Duration
+
+	// AvgPingLatency is a moving average of the ping round trip times,
+	// which is weighted towards recent samples.
+	AvgPingLatency time.Duration
+
+	// Traffic is the total traffic that we exchanged with the peer.
+	Traffic Traffic
+
+	// ConnectedTime is the total amount of time that the peer has been
+	// connected.
+	ConnectedTime time.Duration
+
+	// Disconnects counts the disconnects of the peer by their reason.
+	Disconnects map[DisconnectReason]uint32
+
+	// LastDisconnect is the reason of the most recent disconnect, which is
+	// only set if LastDisconnectTime is non-zero.
+	LastDisconnect DisconnectReason
+
+	// LastDisconnectTime is the time of the most recent disconnect. It is
+	// zero if we never observed the peer disconnecting.
+	LastDisconnectTime time.Time
+
+	// FlapCount is the number of times that the peer went online or
+	// offline, including the cooled down historical flap count.
+	FlapCount int
+}
+
+// Failures returns the number of disconnects that were caused by the
+// connection failing.
+func (q *ConnectionQuality) Failures() uint32 {
+	var failures uint32
+	for reason, count := range q.Disconnects {
+		if reason.Failure() {
+			failures += count
+		}
+	}
+
+	return failures
+}
+
+// TotalDisconnects returns the total number of disconnects.
+func (q *ConnectionQuality) TotalDisconnects() uint32 {
+	var total uint32
+	for _, count := range q.Disconnects {
+		total += count
+	}
+
+	return total
+}
+
+// BytesPerSecond returns the average number of bytes exchanged with the peer
+// per second that it was connected.
+func (q *ConnectionQuality) BytesPerSecond() float64 {
+	secs := q.ConnectedTime.Seconds()
+	if secs == 0 {
+		return 0
+	}
+
+	return float64(q.Traffic.BytesSent+q.Traffic.BytesReceived) / secs
+}
+
+// MsgsPerSecond returns the average number of messages exchanged with the
+// peer per second that it was connected.
+func (q *ConnectionQuality) MsgsPerSecond() float64 {
+	secs := q.ConnectedTime.Seconds()
+	if secs == 0 {
+		return 0
+	}
+
+	return float64(q.Traffic.MsgsSent+q.Traffic.MsgsReceived) / secs
+}
+
+// connQuality tracks the metrics of our connections to a peer.
+type connQuality struct {
+	// lastLatency is the most recent ping round trip time.
+	lastLatency time.Duration
+
+	// avgLatency is the moving average of the ping round trip times.
+	avgLatency time.Duration
+
+	// traffic is the total traffic exchanged with the peer.
+	traffic Traffic
+
+	// connectedSince is the time that the peer's current connection was
+	// established, or zero if it is offline.
+	connectedSince time.Time
+
+	// connectedTime is the total duration of the peer's past connections.
+	connectedTime time.Duration
+
+	// disconnects counts the peer's disconnects by their reason.
+	disconnects map[DisconnectReason]uint32
+
+	// lastDisconnect is the reason of the most recent disconnect.
+	lastDisconnect DisconnectReason
+
+	// lastDisconnectTime is the time of the most recent disconnect.
+	lastDisconnectTime time.Time
+}
+
+// onlineEvent updates the time that the peer has been connected for.
+func (c *connQuality) onlineEvent(online bool, now time.Time) {
+	switch {
+	case online && c.connectedSince.IsZero():
+		c.connectedSince = now
+
+	case !online && !c.connectedSince.IsZero():
+		c.connectedTime += now.Sub(c.connectedSince)
+		c.connectedSince = time.Time{}
+	}
+}
+
+// recordPing records the round trip time of a ping.
+func (c *connQuality) recordPing(latency time.Duration) {
+	c.lastLatency = latency
+
+	// Start our average at the first sample, so that it isn't skewed
+	// towards zero.
+	if c.avgLatency == 0 {
+		c.avgLatency = latency
+		return
+	}
+
+	c.avgLatency = time.Duration(
+		latencyWeight*float64(latency) +
+			(1-latencyWeight)*float64(c.avgLatency),
+	)
+}
+
+// recordTraffic adds the traffic exchanged with the peer since the last
+// record.
+func (c *connQuality) recordTraffic(traffic Traffic) {
+	c.traffic.add(traffic)
+}
+
+// recordDisconnect records a disconnect of the peer.
+func (c *connQuality) recordDisconnect(reason DisconnectReason,
+	now time.Time) {
+
+	if c.disconnects == nil {
+		c.disconnects = make(map[DisconnectReason]uint32)
+	}
+
+	c.disconnects[reason]++
+	c.lastDisconnect = reason
+	c.lastDisconnectTime = now
+}
+
+// snapshot returns a copy of the connection metrics at the given time.
+func (c *connQuality) snapshot(now time.Time) *ConnectionQuality {
+	quality := &ConnectionQuality{
+		Online:             !c.connectedSince.IsZero(),
+		LastPingLatency:    c.lastLatency,
+		AvgPingLatency:     c.avgLatency,
+		Traffic:            c.traffic,
+		ConnectedTime:      c.connectedTime,
+		Disconnects:        make(map[DisconnectReason]uint32),
+		LastDisconnect:     c.lastDisconnect,
+		LastDisconnectTime: c.lastDisconnectTime,
+	}
+
+	if quality.Online {
+		quality.ConnectedTime += now.Sub(c.connectedSince)
+	}
+
+	for reason, count := range c.disconnects {
+		quality.Disconnects[reason] = count
+	}
+
+	return quality
+}
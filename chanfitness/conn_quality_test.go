@@ -0,0 +1,74 @@
+package chanfitness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPeerLogConnQuality tests that a peer log tracks the latency, traffic,
+// connected time and disconnects of a peer.
+func TestPeerLogConnQuality(t *testing.T) {
+	clock := clock.NewTestClock(testNow)
+	peerLog := newPeerLog(clock, 0, nil)
+
+	quality := peerLog.connQuality()
+	require.False(t, quality.Online)
+	require.Zero(t, quality.BytesPerSecond())
+	require.True(t, quality.LastDisconnectTime.IsZero())
+
+	// The first ping sets our average, later pings are weighted.
+	peerLog.onlineEvent(true)
+	peerLog.recordPing(100 * time.Millisecond)
+	peerLog.recordPing(200 * time.Millisecond)
+
+	peerLog.recordTraffic(Traffic{
+		MsgsSent:      10,
+		MsgsReceived:  20,
+		BytesSent:     1000,
+		BytesReceived: 2000,
+	})
+	peerLog.recordTraffic(Traffic{MsgsSent: 30, BytesSent: 3000})
+
+	// The current connection counts towards our connected time.
+	clock.SetTime(testNow.Add(10 * time.Second))
+
+	quality = peerLog.connQuality()
+	require.True(t, quality.Online)
+	require.Equal(t, 200*time.Millisecond, quality.LastPingLatency)
+	require.Equal(t, 120*time.Millisecond, quality.AvgPingLatency)
+	require.Equal(t, Traffic{
+		MsgsSent:      40,
+		MsgsReceived:  20,
+		BytesSent:     4000,
+		BytesReceived: 2000,
+	}, quality.Traffic)
+	require.Equal(t, 10*time.Second, quality.ConnectedTime)
+	require.Equal(t, float64(600), quality.BytesPerSecond())
+	require.Equal(t, float64(6), quality.MsgsPerSecond())
+	require.Equal(t, 1, quality.FlapCount)
+
+	// Disconnect the peer with a failure, and check that the time that
+	// it is offline is not counted.
+	peerLog.recordDisconnect(DisconnectIdleTimeout)
+	peerLog.onlineEvent(false)
+	clock.SetTime(testNow.Add(time.Minute))
+
+	peerLog.onlineEvent(true)
+	peerLog.recordDisconnect(DisconnectLocal)
+	peerLog.onlineEvent(false)
+
+	quality = peerLog.connQuality()
+	require.False(t, quality.Online)
+	require.Equal(t, 10*time.Second, quality.ConnectedTime)
+	require.Equal(t, map[DisconnectReason]uint32{
+		DisconnectIdleTimeout: 1,
+		DisconnectLocal:       1,
+	}, quality.Disconnects)
+	require.Equal(t, DisconnectLocal, quality.LastDisconnect)
+	require.Equal(t, testNow.Add(time.Minute), quality.LastDisconnectTime)
+	require.EqualValues(t, 1, quality.Failures())
+	require.EqualValues(t, 2, quality.TotalDisconnects())
+}
@@ -31,4 +31,17 @@ type peerMonitor interface {
 	// last recorded a flap, which may be nil if we have never recorded a
 	// flap for this peer.
 	getFlapCount() (int, *time.Time)
+
+	// recordPing records the round trip time of a ping to the peer.
+	recordPing(latency time.Duration)
+
+	// recordTraffic records traffic exchanged with the peer.
+	recordTraffic(traffic Traffic)
+
+	// recordDisconnect records the reason that the peer was disconnected.
+	recordDisconnect(reason DisconnectReason)
+
+	// connQuality returns the quality metrics of our connections to the
+	// peer.
+	connQuality() *ConnectionQuality
 }
@@ -3,6 +3,7 @@ package lnd
 import (
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/wire"
@@ -20,6 +21,10 @@ type addrSource interface {
 	AddrsForNode(nodePub *btcec.PublicKey) ([]net.Addr, error)
 }
 
+// backupRefreshInterval is the interval at which the channel backups are
+// refreshed with the latest commitment height and balance of each channel.
+const backupRefreshInterval = 10 * time.Minute
+
 // channelNotifier is an implementation of the chanbackup.ChannelNotifier
 // interface using the existing channelnotifier.ChannelNotifier struct. This
 // implementation allows us to satisfy all the dependencies of the
@@ -33,6 +38,10 @@ type channelNotifier struct {
 	// us to get the latest set of addresses for a given node. We'll need
 	// this to be able to create an SCB for new channels.
 	addrs addrSource
+
+	// chanSource is used to fetch the latest state of all open channels,
+	// such that their SCBs can be refreshed periodically.
+	chanSource chanbackup.LiveChannelSource
 }
 
 // SubscribeChans requests a new channel subscription relative to the initial
@@ -94,8 +103,30 @@ func (c *channelNotifier) SubscribeChans(startingChans map[wire.OutPoint]struct{
 
 		defer chanSubscription.Cancel()
 
+		// The state of the channels changes with every update, so
+		// we'll only refresh their backups periodically.
+		refreshTicker := time.NewTicker(backupRefreshInterval)
+		defer refreshTicker.Stop()
+
 		for {
 			select {
+			// It's time to refresh the backups of all open
+			// channels with their latest state. The sub-swapper
+			// only updates the backups of channels that changed.
+			case <-refreshTicker.C:
+				chanEvent, err := c.chanRefreshEvent()
+				if err != nil {
+					ltndLog.Errorf("unable to fetch "+
+						"channels for backup refresh: "+
+						"%v", err)
+					continue
+				}
+
+				select {
+				case chanUpdates <- *chanEvent:
+				case <-quit:
+					return
+				}
 
 			// A new event has been sent by the chanNotifier, we'll
 			// filter out the events we actually care about and
@@ -191,6 +222,40 @@ func (c *channelNotifier) SubscribeChans(startingChans map[wire.OutPoint]struct{
 	}, nil
 }
 
+// chanRefreshEvent returns a ChannelEvent that carries the latest state of all
+// open channels.
+func (c *channelNotifier) chanRefreshEvent() (*chanbackup.ChannelEvent,
+	error) {
+
+	openChans, err := c.chanSource.FetchAllChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	chanEvent := &chanbackup.ChannelEvent{
+		UpdatedChans: make(
+			[]chanbackup.ChannelWithAddrs, 0, len(openChans),
+		),
+	}
+	for _, openChan := range openChans {
+		nodeAddrs, err := c.addrs.AddrsForNode(openChan.IdentityPub)
+		if err != nil {
+			pub := openChan.IdentityPub
+			ltndLog.Errorf("unable to fetch addrs for %x: %v",
+				pub.SerializeCompressed(), err)
+		}
+
+		chanEvent.UpdatedChans = append(
+			chanEvent.UpdatedChans, chanbackup.ChannelWithAddrs{
+				OpenChannel: openChan,
+				Addrs:       nodeAddrs,
+			},
+		)
+	}
+
+	return chanEvent, nil
+}
+
 // A compile-time constraint to ensure channelNotifier implements
 // chanbackup.ChannelNotifier.
 var _ chanbackup.ChannelNotifier = (*channelNotifier)(nil)
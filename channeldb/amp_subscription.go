@@ -0,0 +1,373 @@
+package channeldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+var (
+	// ampSubscriptionBucket is the top-level bucket that stores the
+	// subscriptions of AMP invoices, keyed by the payment hash of the
+	// invoice. The subscriptions are kept apart from the invoices, so the
+	// invoice format remains unchanged.
+	ampSubscriptionBucket = []byte("amp-invoice-subscriptions")
+)
+
+const (
+	// A set of tlv type definitions used to serialize AMP subscriptions.
+	ampSubPeriodAmtType    tlv.Type = 0
+	ampSubPeriodLengthType tlv.Type = 1
+	ampSubStartTimeType    tlv.Type = 2
+	ampSubNumPeriodsType   tlv.Type = 3
+	ampSubCanceledType     tlv.Type = 4
+	ampSubPeriodsType      tlv.Type = 5
+)
+
+// SubscriptionPeriodState is the state of a period of an AMP subscription.
+type SubscriptionPeriodState uint8
+
+const (
+	// SubscriptionPeriodPending is the state of the current period, as
+	// long as less than the amount per period was paid in it.
+	SubscriptionPeriodPending SubscriptionPeriodState = 0
+
+	// SubscriptionPeriodPaid is the state of a period in which at least
+	// the amount per period was paid.
+	SubscriptionPeriodPaid SubscriptionPeriodState = 1
+
+	// SubscriptionPeriodMissed is the state of a period that ended before
+	// the amount per period was paid in it.
+	SubscriptionPeriodMissed SubscriptionPeriodState = 2
+)
+
+// String returns a human readable representation of the period state.
+func (s SubscriptionPeriodState) String() string {
+	switch s {
+	case SubscriptionPeriodPending:
+		return "pending"
+
+	case SubscriptionPeriodPaid:
+		return "paid"
+
+	case SubscriptionPeriodMissed:
+		return "missed"
+
+	default:
+		return "unknown"
+	}
+}
+
+// AMPSubscription describes the recurring payments expected for an AMP
+// invoice, and records the periods in which they were paid or missed.
+type AMPSubscription struct {
+	// PeriodAmt is the amount that is expected to be paid per period.
+	PeriodAmt lnwire.MilliSatoshi
+
+	// PeriodLength is the length of a period.
+	PeriodLength time.Duration
+
+	// StartTime is the start of the first period.
+	StartTime time.Time
+
+	// NumPeriods is the number of periods of the subscription. Zero means
+	// that the subscription doesn't end.
+	NumPeriods uint32
+
+	// Canceled is true if the subscription was canceled. Periods aren't
+	// recorded anymore once it is set.
+	Canceled bool
+
+	// Periods holds the periods that started so far, indexed by the
+	// number of the period. Only the last period may be pending.
+	Periods []SubscriptionPeriod
+}
+
+// SubscriptionPeriod records the payments of a period of an AMP subscription.
+type SubscriptionPeriod struct {
+	// State is the state of the period.
+	State SubscriptionPeriodState
+
+	// AmtPaid is the amount paid in the period.
+	AmtPaid lnwire.MilliSatoshi
+
+	// SetIDs are the ids of the AMP htlc sets that were settled in the
+	// period.
+	SetIDs []SetID
+
+	// ResolveTime is the time at which the period was paid or missed.
+	ResolveTime time.Time
+}
+
+// PeriodStart returns the start of the period with the given index.
+func (s *AMPSubscription) PeriodStart(index uint32) time.Time {
+	return s.StartTime.Add(time.Duration(index) * s.PeriodLength)
+}
+
+// PeriodIndex returns the index of the period that the given time falls into.
+// Times before the start of the subscription fall into the first period. False
+// is returned if the subscription ended before the given time.
+func (s *AMPSubscription) PeriodIndex(t time.Time) (uint32, bool) {
+	if !t.After(s.StartTime) {
+		return 0, true
+	}
+
+	index := uint64(t.Sub(s.StartTime) / s.PeriodLength)
+	if s.NumPeriods != 0 && index >= uint64(s.NumPeriods) {
+		return 0, false
+	}
+
+	return uint32(index), true
+}
+
+// EndedPeriods returns the number of periods that ended at the given time.
+func (s *AMPSubscription) EndedPeriods(t time.Time) uint32 {
+	if !t.After(s.StartTime) {
+		return 0
+	}
+
+	ended := uint64(t.Sub(s.StartTime) / s.PeriodLength)
+	if s.NumPeriods != 0 && ended > uint64(s.NumPeriods) {
+		ended = uint64(s.NumPeriods)
+	}
+
+	return uint32(ended)
+}
+
+// Copy returns a deep copy of the subscription.
+func (s *AMPSubscription) Copy() *AMPSubscription {
+	c := *s
+	c.Periods = make([]SubscriptionPeriod, len(s.Periods))
+	for i, period := range s.Periods {
+		c.Periods[i] = period
+		c.Periods[i].SetIDs = append([]SetID(nil), period.SetIDs...)
+	}
+
+	return &c
+}
+
+// encodeSubscriptionPeriods serializes the periods of an AMP subscription.
+func encodeSubscriptionPeriods(w io.Writer,
+	periods []SubscriptionPeriod) error {
+
+	byteOrder := binary.BigEndian
+	if err := binary.Write(w, byteOrder, uint32(len(periods))); err != nil {
+		return err
+	}
+
+	for _, period := range periods {
+		// The zero time of pending periods is stored as zero, as it
+		// can't be represented in unix nanoseconds.
+		var resolveTime int64
+		if !period.ResolveTime.IsZero() {
+			resolveTime = period.ResolveTime.UnixNano()
+		}
+
+		fields := []interface{}{
+			uint8(period.State),
+			uint64(period.AmtPaid),
+			resolveTime,
+			uint32(len(period.SetIDs)),
+		}
+		for _, field := range fields {
+			err := binary.Write(w, byteOrder, field)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, setID := range period.SetIDs {
+			if _, err := w.Write(setID[:]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeSubscriptionPeriods deserializes the periods of an AMP subscription.
+func decodeSubscriptionPeriods(r io.Reader) ([]SubscriptionPeriod, error) {
+	byteOrder := binary.BigEndian
+
+	var numPeriods uint32
+	if err := binary.Read(r, byteOrder, &numPeriods); err != nil {
+		return nil, err
+	}
+
+	var periods []SubscriptionPeriod
+	for i := uint32(0); i < numPeriods; i++ {
+		var (
+			state       uint8
+			amtPaid     uint64
+			resolveTime int64
+			numSetIDs   uint32
+		)
+		fields := []interface{}{
+			&state, &amtPaid, &resolveTime, &numSetIDs,
+		}
+		for _, field := range fields {
+			if err := binary.Read(r, byteOrder, field); err != nil {
+				return nil, err
+			}
+		}
+
+		period := SubscriptionPeriod{
+			State:   SubscriptionPeriodState(state),
+			AmtPaid: lnwire.MilliSatoshi(amtPaid),
+		}
+		if resolveTime != 0 {
+			period.ResolveTime = time.Unix(0, resolveTime)
+		}
+
+		for j := uint32(0); j < numSetIDs; j++ {
+			var setID SetID
+			if _, err := io.ReadFull(r, setID[:]); err != nil {
+				return nil, err
+			}
+			period.SetIDs = append(period.SetIDs, setID)
+		}
+
+		periods = append(periods, period)
+	}
+
+	return periods, nil
+}
+
+// encode serializes the AMP subscription to the given writer.
+func (s *AMPSubscription) encode(w io.Writer) error {
+	var periodsBuf bytes.Buffer
+	err := encodeSubscriptionPeriods(&periodsBuf, s.Periods)
+	if err != nil {
+		return err
+	}
+
+	var (
+		periodAmt    = uint64(s.PeriodAmt)
+		periodLength = uint64(s.PeriodLength)
+		startTime    = uint64(s.StartTime.UnixNano())
+		periods      = periodsBuf.Bytes()
+		canceled     uint8
+	)
+	if s.Canceled {
+		canceled = 1
+	}
+
+	tlvStream, err := tlv.NewStream(
+		tlv.MakePrimitiveRecord(ampSubPeriodAmtType, &periodAmt),
+		tlv.MakePrimitiveRecord(ampSubPeriodLengthType, &periodLength),
+		tlv.MakePrimitiveRecord(ampSubStartTimeType, &startTime),
+		tlv.MakePrimitiveRecord(ampSubNumPeriodsType, &s.NumPeriods),
+		tlv.MakePrimitiveRecord(ampSubCanceledType, &canceled),
+		tlv.MakePrimitiveRecord(ampSubPeriodsType, &periods),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tlvStream.Encode(w)
+}
+
+// decodeAMPSubscription deserializes an AMP subscription from the given
+// reader.
+func decodeAMPSubscription(r io.Reader) (*AMPSubscription, error) {
+	var (
+		s            AMPSubscription
+		periodAmt    uint64
+		periodLength uint64
+		startTime    uint64
+		canceled     uint8
+		periods      []byte
+	)
+
+	tlvStream, err := tlv.NewStream(
+		tlv.MakePrimitiveRecord(ampSubPeriodAmtType, &periodAmt),
+		tlv.MakePrimitiveRecord(ampSubPeriodLengthType, &periodLength),
+		tlv.MakePrimitiveRecord(ampSubStartTimeType, &startTime),
+		tlv.MakePrimitiveRecord(ampSubNumPeriodsType, &s.NumPeriods),
+		tlv.MakePrimitiveRecord(ampSubCanceledType, &canceled),
+		tlv.MakePrimitiveRecord(ampSubPeriodsType, &periods),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tlvStream.Decode(r); err != nil {
+		return nil, err
+	}
+
+	s.PeriodAmt = lnwire.MilliSatoshi(periodAmt)
+	s.PeriodLength = time.Duration(periodLength)
+	s.StartTime = time.Unix(0, int64(startTime))
+	s.Canceled = canceled != 0
+
+	s.Periods, err = decodeSubscriptionPeriods(bytes.NewReader(periods))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode periods: %w", err)
+	}
+
+	return &s, nil
+}
+
+// PutAMPSubscription stores the subscription of the AMP invoice with the given
+// payment hash, replacing a subscription stored before.
+func (d *DB) PutAMPSubscription(hash lntypes.Hash,
+	sub *AMPSubscription) error {
+
+	var b bytes.Buffer
+	if err := sub.encode(&b); err != nil {
+		return err
+	}
+
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		subs, err := tx.CreateTopLevelBucket(ampSubscriptionBucket)
+		if err != nil {
+			return err
+		}
+
+		return subs.Put(hash[:], b.Bytes())
+	}, func() {})
+}
+
+// FetchAMPSubscriptions returns the subscriptions of all AMP invoices, keyed
+// by their payment hash.
+func (d *DB) FetchAMPSubscriptions() (map[lntypes.Hash]*AMPSubscription,
+	error) {
+
+	var subs map[lntypes.Hash]*AMPSubscription
+	err := kvdb.View(d, func(tx kvdb.RTx) error {
+		bucket := tx.ReadBucket(ampSubscriptionBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			hash, err := lntypes.MakeHash(k)
+			if err != nil {
+				return err
+			}
+
+			sub, err := decodeAMPSubscription(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+			subs[hash] = sub
+
+			return nil
+		})
+	}, func() {
+		subs = make(map[lntypes.Hash]*AMPSubscription)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
@@ -0,0 +1,104 @@
+package channeldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAMPSubscriptions asserts that AMP subscriptions are stored and fetched.
+func TestAMPSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	db, err := MakeTestDB(t)
+	require.NoError(t, err)
+
+	// Without any subscription stored, the bucket doesn't exist yet.
+	subs, err := db.FetchAMPSubscriptions()
+	require.NoError(t, err)
+	require.Empty(t, subs)
+
+	start := time.Unix(1600000000, 0)
+	full := &AMPSubscription{
+		PeriodAmt:    5000,
+		PeriodLength: 24 * time.Hour,
+		StartTime:    start,
+		NumPeriods:   12,
+		Canceled:     true,
+		Periods: []SubscriptionPeriod{{
+			State:       SubscriptionPeriodPaid,
+			AmtPaid:     5000,
+			SetIDs:      []SetID{{1}, {2}},
+			ResolveTime: start.Add(time.Hour),
+		}, {
+			State:       SubscriptionPeriodMissed,
+			ResolveTime: start.Add(48 * time.Hour),
+		}, {
+			State:   SubscriptionPeriodPending,
+			AmtPaid: 100,
+			SetIDs:  []SetID{{3}},
+		}},
+	}
+	empty := &AMPSubscription{
+		PeriodAmt:    1,
+		PeriodLength: time.Second,
+		StartTime:    start,
+		Periods:      []SubscriptionPeriod{},
+	}
+
+	require.NoError(t, db.PutAMPSubscription(lntypes.Hash{1}, full))
+	require.NoError(t, db.PutAMPSubscription(lntypes.Hash{2}, empty))
+
+	subs, err = db.FetchAMPSubscriptions()
+	require.NoError(t, err)
+	require.Len(t, subs, 2)
+
+	// Nil and empty periods are stored the same way.
+	empty.Periods = nil
+	require.Equal(t, empty, subs[lntypes.Hash{2}])
+	require.Equal(t, full, subs[lntypes.Hash{1}])
+
+	// Periods are recorded by replacing the subscription.
+	full.Periods = full.Periods[:1]
+	require.NoError(t, db.PutAMPSubscription(lntypes.Hash{1}, full))
+
+	subs, err = db.FetchAMPSubscriptions()
+	require.NoError(t, err)
+	require.Equal(t, full, subs[lntypes.Hash{1}])
+}
+
+// TestAMPSubscriptionPeriods asserts that times are mapped to the periods of a
+// subscription.
+func TestAMPSubscriptionPeriods(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1600000000, 0)
+	sub := &AMPSubscription{
+		PeriodLength: time.Hour,
+		StartTime:    start,
+		NumPeriods:   2,
+	}
+
+	index, ok := sub.PeriodIndex(start.Add(-time.Minute))
+	require.True(t, ok)
+	require.Zero(t, index)
+	require.Zero(t, sub.EndedPeriods(start.Add(-time.Minute)))
+
+	index, ok = sub.PeriodIndex(start.Add(90 * time.Minute))
+	require.True(t, ok)
+	require.EqualValues(t, 1, index)
+	require.EqualValues(t, 1, sub.EndedPeriods(start.Add(90*time.Minute)))
+
+	_, ok = sub.PeriodIndex(start.Add(2 * time.Hour))
+	require.False(t, ok)
+	require.EqualValues(t, 2, sub.EndedPeriods(start.Add(5*time.Hour)))
+	require.Equal(t, start.Add(time.Hour), sub.PeriodStart(1))
+
+	// Subscriptions without a number of periods don't end.
+	sub.NumPeriods = 0
+	index, ok = sub.PeriodIndex(start.Add(5 * time.Hour))
+	require.True(t, ok)
+	require.EqualValues(t, 5, index)
+}
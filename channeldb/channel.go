@@ -224,6 +224,10 @@ const (
 	// A tlv type definition used to serialize and deserialize the
 	// confirmed ShortChannelID for a zero-conf channel.
 	realScidType tlv.Type = 4
+
+	// A tlv type used to serialize and deserialize the
+	// `RestoredCommitHeight` field.
+	restoredCommitHeightType tlv.Type = 5
 )
 
 // indexStatus is an enum-like type that describes what state the
@@ -736,6 +740,13 @@ type OpenChannel struct {
 	// opening.
 	InitialRemoteBalance lnwire.MilliSatoshi
 
+	// RestoredCommitHeight is the height of our local commitment chain as
+	// recorded within the static channel backup this channel was restored
+	// from. This is only set for restored channels whose backup carried
+	// this information, and allows us to sanity check the state the
+	// remote party reports during the data loss protection protocol.
+	RestoredCommitHeight uint64
+
 	// LocalChanCfg is the channel configuration for the local node.
 	LocalChanCfg ChannelConfig
 
@@ -3705,6 +3716,9 @@ func putChanInfo(chanBucket kvdb.RwBucket, channel *OpenChannel) error {
 			initialRemoteBalanceType, &remoteBalance,
 		),
 		MakeScidRecord(realScidType, &channel.confirmedScid),
+		tlv.MakePrimitiveRecord(
+			restoredCommitHeightType, &channel.RestoredCommitHeight,
+		),
 	)
 	if err != nil {
 		return err
@@ -3922,6 +3936,9 @@ func fetchChanInfo(chanBucket kvdb.RBucket, channel *OpenChannel) error {
 			initialRemoteBalanceType, &remoteBalance,
 		),
 		MakeScidRecord(realScidType, &channel.confirmedScid),
+		tlv.MakePrimitiveRecord(
+			restoredCommitHeightType, &channel.RestoredCommitHeight,
+		),
 	)
 	if err != nil {
 		return err
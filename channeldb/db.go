@@ -24,6 +24,7 @@ import (
 	"github.com/lightningnetwork/lnd/channeldb/migration27"
 	"github.com/lightningnetwork/lnd/channeldb/migration29"
 	"github.com/lightningnetwork/lnd/channeldb/migration30"
+	"github.com/lightningnetwork/lnd/channeldb/migration31"
 	"github.com/lightningnetwork/lnd/channeldb/migration_01_to_11"
 	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/kvdb"
@@ -263,6 +264,13 @@ var (
 			number:    29,
 			migration: migration29.MigrateChanID,
 		},
+		{
+			// Index payments by creation time, status and
+			// destination, allowing ListPayments queries to be
+			// filtered without a full scan.
+			number:    30,
+			migration: migration31.MigratePaymentIndexes,
+		},
 	}
 
 	// optionalVersions stores all optional migrations that are applied
@@ -411,6 +419,10 @@ var dbTopLevelBuckets = [][]byte{
 	payAddrIndexBucket,
 	setIDIndexBucket,
 	paymentsIndexBucket,
+	paymentsTimeIndexBucket,
+	paymentsDestIndexBucket,
+	paymentsStatusIndexBucket,
+	paymentsPruneBucket,
 	peersBucket,
 	nodeInfoBucket,
 	metaBucket,
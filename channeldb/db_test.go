@@ -294,7 +294,8 @@ func genRandomChannelShell() (*ChannelShell, error) {
 			ShortChannelID: lnwire.NewShortChanIDFromInt(
 				uint64(rand.Int63()),
 			),
-			IdentityPub: pub,
+			IdentityPub:          pub,
+			RestoredCommitHeight: uint64(rand.Int63()),
 			LocalChanCfg: ChannelConfig{
 				ChannelConstraints: ChannelConstraints{
 					CsvDelay: uint16(rand.Int63()),
@@ -382,6 +383,10 @@ func TestRestoreChannelShells(t *testing.T) {
 		t.Fatalf("node has wrong status flags: %v",
 			nodeChans[0].chanStatus)
 	}
+	require.Equal(
+		t, channelShell.Chan.RestoredCommitHeight,
+		nodeChans[0].RestoredCommitHeight,
+	)
 
 	// We should also be able to find the channel if we query for it
 	// directly.
@@ -489,6 +489,7 @@ func (c *ChannelGraph) ForEachNodeChannel(tx kvdb.RTx, node route.Vertex,
 			cachedInPolicy = NewCachedPolicy(p2)
 			cachedInPolicy.ToNodePubKey = toNodeCallback
 			cachedInPolicy.ToNodeFeatures = toNodeFeatures
+			cachedInPolicy.InboundFee = inboundFee(p1)
 		}
 
 		directedChannel := &DirectedChannel{
@@ -498,6 +499,7 @@ func (c *ChannelGraph) ForEachNodeChannel(tx kvdb.RTx, node route.Vertex,
 			Capacity:     e.Capacity,
 			OutPolicySet: p1 != nil,
 			InPolicy:     cachedInPolicy,
+			InboundFee:   inboundFee(p1),
 		}
 
 		if node == e.NodeKey2Bytes {
@@ -583,6 +585,7 @@ func (c *ChannelGraph) ForEachNodeCached(cb func(node route.Vertex,
 				Capacity:     e.Capacity,
 				OutPolicySet: p1 != nil,
 				InPolicy:     cachedInPolicy,
+				InboundFee:   inboundFee(p1),
 			}
 
 			if node.PubKeyBytes == e.NodeKey2Bytes {
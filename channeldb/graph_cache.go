@@ -69,6 +69,12 @@ type CachedEdgePolicy struct {
 	// HTLCs for each millionth of a satoshi forwarded.
 	FeeProportionalMillionths lnwire.MilliSatoshi
 
+	// InboundFee is the inbound fee the to node charges for HTLCs that
+	// arrive over this channel. It is part of the to node's own policy for
+	// the channel, so it is never set while the edge is in the cache, only
+	// on the copy that is returned in ForEachChannel().
+	InboundFee lnwire.Fee
+
 	// ToNodePubKey is a function that returns the to node of a policy.
 	// Since we only ever store the inbound policy, this is always the node
 	// that we query the channels for in ForEachChannel(). Therefore, we can
@@ -117,6 +123,22 @@ func NewCachedPolicy(policy *ChannelEdgePolicy) *CachedEdgePolicy {
 	}
 }
 
+// inboundFee returns the inbound fee carried in the extra data of the given
+// policy. Policies with malformed extra data are treated as having no inbound
+// fee.
+func inboundFee(policy *ChannelEdgePolicy) lnwire.Fee {
+	if policy == nil {
+		return lnwire.Fee{}
+	}
+
+	fee, err := lnwire.ExtractInboundFee(policy.ExtraOpaqueData)
+	if err != nil {
+		return lnwire.Fee{}
+	}
+
+	return fee
+}
+
 // DirectedChannel is a type that stores the channel information as seen from
 // one side of the channel.
 type DirectedChannel struct {
@@ -143,6 +165,11 @@ type DirectedChannel struct {
 	// source, so we're always interested in the edge that arrives to us
 	// from the other node.
 	InPolicy *CachedEdgePolicy
+
+	// InboundFee is the inbound fee this node charges for HTLCs that
+	// arrive from the other node over this channel. It is taken from the
+	// outgoing policy of this node.
+	InboundFee lnwire.Fee
 }
 
 // DeepCopy creates a deep copy of the channel, including the incoming policy.
@@ -159,6 +186,10 @@ func (c *DirectedChannel) DeepCopy() *DirectedChannel {
 		// be sure they don't contain any old data.
 		channelCopy.InPolicy.ToNodePubKey = nil
 		channelCopy.InPolicy.ToNodeFeatures = nil
+
+		// The inbound fee is part of our own policy, so we attach it
+		// to the incoming policy for path finding.
+		channelCopy.InPolicy.InboundFee = c.InboundFee
 	}
 
 	return &channelCopy
@@ -324,11 +355,13 @@ func (c *GraphCache) UpdatePolicy(policy *ChannelEdgePolicy, fromNode,
 		// policy for node 1.
 		case channel.IsNode1 && edge1:
 			channel.OutPolicySet = true
+			channel.InboundFee = inboundFee(policy)
 
 		// This is node 2, and it is edge 2, so this is the outgoing
 		// policy for node 2.
 		case !channel.IsNode1 && !edge1:
 			channel.OutPolicySet = true
+			channel.InboundFee = inboundFee(policy)
 
 		// The other two cases left mean it's the inbound policy for the
 		// node.
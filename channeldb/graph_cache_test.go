@@ -70,6 +70,12 @@ func TestGraphCacheAddNode(t *testing.T) {
 			channelFlagA, channelFlagB = 1, 0
 		}
 
+		// Node A charges an inbound fee for HTLCs arriving from node
+		// B, which is carried in the extra data of its policy.
+		inboundFee := lnwire.Fee{BaseFee: -100, FeeRate: -200}
+		var extraData lnwire.ExtraOpaqueData
+		require.NoError(t, extraData.PackRecords(&inboundFee))
+
 		outPolicy1 := &ChannelEdgePolicy{
 			ChannelID:    1000,
 			ChannelFlags: lnwire.ChanUpdateChanFlags(channelFlagA),
@@ -77,6 +83,7 @@ func TestGraphCacheAddNode(t *testing.T) {
 				PubKeyBytes: nodeB,
 				Features:    lnwire.EmptyFeatureVector(),
 			},
+			ExtraOpaqueData: extraData,
 		}
 		inPolicy1 := &ChannelEdgePolicy{
 			ChannelID:    1000,
@@ -118,6 +125,14 @@ func TestGraphCacheAddNode(t *testing.T) {
 		require.Equal(t, outPolicy1 != nil, fromChannels[0].OutPolicySet)
 		assertCachedPolicyEqual(t, inPolicy1, fromChannels[0].InPolicy)
 
+		// The inbound fee of node A must be attached to the policy of
+		// node B towards node A, but not the other way around.
+		require.Equal(t, inboundFee, fromChannels[0].InboundFee)
+		require.Equal(
+			t, inboundFee, fromChannels[0].InPolicy.InboundFee,
+		)
+		require.True(t, toChannels[0].InboundFee.IsZero())
+
 		require.Equal(t, inPolicy1 != nil, toChannels[0].OutPolicySet)
 		assertCachedPolicyEqual(t, outPolicy1, toChannels[0].InPolicy)
 
@@ -0,0 +1,236 @@
+package channeldb
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+var (
+	// holdPolicyBucket is the top-level bucket that stores the policies
+	// of hold invoices, keyed by the payment hash of the invoice. The
+	// policies are kept apart from the invoices, so the invoice format
+	// remains unchanged.
+	holdPolicyBucket = []byte("invoice-hold-policies")
+)
+
+const (
+	// A set of tlv type definitions used to serialize hold policies.
+	holdPolicyCancelDeltaType     tlv.Type = 0
+	holdPolicyPreimageType        tlv.Type = 1
+	holdPolicyConfTxidType        tlv.Type = 2
+	holdPolicyConfPkScriptType    tlv.Type = 3
+	holdPolicyConfNumConfsType    tlv.Type = 4
+	holdPolicyConfHeightHintType  tlv.Type = 5
+	holdPolicySettleOnPaymentType tlv.Type = 6
+)
+
+// HoldPolicy describes how a hold invoice is resolved without being settled
+// or canceled manually.
+type HoldPolicy struct {
+	// CancelDelta is the number of blocks before the expiry of the
+	// earliest accepted htlc at which the invoice is canceled. Zero means
+	// the default of the invoice registry applies.
+	CancelDelta uint32
+
+	// Preimage is the preimage the invoice is settled with once one of its
+	// settle conditions is met. Unlike the preimage of the contract terms,
+	// it isn't revealed before the invoice is settled.
+	Preimage *lntypes.Preimage
+
+	// SettleConf, if set, settles the invoice once the transaction
+	// confirms.
+	SettleConf *HoldSettleConf
+
+	// SettleOnPayment settles the invoice with the preimage of the
+	// outgoing payment with the same payment hash once it succeeds.
+	SettleOnPayment bool
+}
+
+// HoldSettleConf describes the transaction whose confirmation settles a hold
+// invoice.
+type HoldSettleConf struct {
+	// Txid is the hash of the transaction.
+	Txid chainhash.Hash
+
+	// PkScript is an output script of the transaction, which light
+	// clients need to match the transaction.
+	PkScript []byte
+
+	// NumConfs is the number of confirmations the transaction needs.
+	NumConfs uint32
+
+	// HeightHint is the height below which the transaction can't have
+	// confirmed.
+	HeightHint uint32
+}
+
+// encode serializes the hold policy to the given writer.
+func (p *HoldPolicy) encode(w io.Writer) error {
+	records := []tlv.Record{
+		tlv.MakePrimitiveRecord(
+			holdPolicyCancelDeltaType, &p.CancelDelta,
+		),
+	}
+
+	if p.Preimage != nil {
+		preimage := [32]byte(*p.Preimage)
+		records = append(records, tlv.MakePrimitiveRecord(
+			holdPolicyPreimageType, &preimage,
+		))
+	}
+
+	if p.SettleConf != nil {
+		txid := [32]byte(p.SettleConf.Txid)
+		records = append(records,
+			tlv.MakePrimitiveRecord(holdPolicyConfTxidType, &txid),
+			tlv.MakePrimitiveRecord(
+				holdPolicyConfPkScriptType,
+				&p.SettleConf.PkScript,
+			),
+			tlv.MakePrimitiveRecord(
+				holdPolicyConfNumConfsType,
+				&p.SettleConf.NumConfs,
+			),
+			tlv.MakePrimitiveRecord(
+				holdPolicyConfHeightHintType,
+				&p.SettleConf.HeightHint,
+			),
+		)
+	}
+
+	if p.SettleOnPayment {
+		var settleOnPayment uint8 = 1
+		records = append(records, tlv.MakePrimitiveRecord(
+			holdPolicySettleOnPaymentType, &settleOnPayment,
+		))
+	}
+
+	tlvStream, err := tlv.NewStream(records...)
+	if err != nil {
+		return err
+	}
+
+	return tlvStream.Encode(w)
+}
+
+// decodeHoldPolicy deserializes a hold policy from the given reader.
+func decodeHoldPolicy(r io.Reader) (*HoldPolicy, error) {
+	var (
+		p               HoldPolicy
+		preimage        [32]byte
+		txid            [32]byte
+		conf            HoldSettleConf
+		settleOnPayment uint8
+	)
+
+	tlvStream, err := tlv.NewStream(
+		tlv.MakePrimitiveRecord(
+			holdPolicyCancelDeltaType, &p.CancelDelta,
+		),
+		tlv.MakePrimitiveRecord(holdPolicyPreimageType, &preimage),
+		tlv.MakePrimitiveRecord(holdPolicyConfTxidType, &txid),
+		tlv.MakePrimitiveRecord(
+			holdPolicyConfPkScriptType, &conf.PkScript,
+		),
+		tlv.MakePrimitiveRecord(
+			holdPolicyConfNumConfsType, &conf.NumConfs,
+		),
+		tlv.MakePrimitiveRecord(
+			holdPolicyConfHeightHintType, &conf.HeightHint,
+		),
+		tlv.MakePrimitiveRecord(
+			holdPolicySettleOnPaymentType, &settleOnPayment,
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedTypes, err := tlvStream.DecodeWithParsedTypes(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := parsedTypes[holdPolicyPreimageType]; ok {
+		p.Preimage = (*lntypes.Preimage)(&preimage)
+	}
+
+	if _, ok := parsedTypes[holdPolicyConfTxidType]; ok {
+		conf.Txid = chainhash.Hash(txid)
+		p.SettleConf = &conf
+	}
+
+	p.SettleOnPayment = settleOnPayment != 0
+
+	return &p, nil
+}
+
+// PutHoldPolicy stores the policy of the hold invoice with the given payment
+// hash.
+func (d *DB) PutHoldPolicy(hash lntypes.Hash, policy *HoldPolicy) error {
+	var b bytes.Buffer
+	if err := policy.encode(&b); err != nil {
+		return err
+	}
+
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		policies, err := tx.CreateTopLevelBucket(holdPolicyBucket)
+		if err != nil {
+			return err
+		}
+
+		return policies.Put(hash[:], b.Bytes())
+	}, func() {})
+}
+
+// FetchHoldPolicies returns the policies of all hold invoices, keyed by their
+// payment hash.
+func (d *DB) FetchHoldPolicies() (map[lntypes.Hash]*HoldPolicy, error) {
+	var policies map[lntypes.Hash]*HoldPolicy
+	err := kvdb.View(d, func(tx kvdb.RTx) error {
+		bucket := tx.ReadBucket(holdPolicyBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			hash, err := lntypes.MakeHash(k)
+			if err != nil {
+				return err
+			}
+
+			policy, err := decodeHoldPolicy(bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+			policies[hash] = policy
+
+			return nil
+		})
+	}, func() {
+		policies = make(map[lntypes.Hash]*HoldPolicy)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// DeleteHoldPolicy deletes the policy of the hold invoice with the given
+// payment hash. Deleting a policy that doesn't exist is not an error.
+func (d *DB) DeleteHoldPolicy(hash lntypes.Hash) error {
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		policies := tx.ReadWriteBucket(holdPolicyBucket)
+		if policies == nil {
+			return nil
+		}
+
+		return policies.Delete(hash[:])
+	}, func() {})
+}
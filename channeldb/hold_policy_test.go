@@ -0,0 +1,54 @@
+package channeldb
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHoldPolicies asserts that hold policies are stored, fetched and
+// deleted.
+func TestHoldPolicies(t *testing.T) {
+	t.Parallel()
+
+	db, err := MakeTestDB(t)
+	require.NoError(t, err)
+
+	// Without any policy stored, the bucket doesn't exist yet.
+	policies, err := db.FetchHoldPolicies()
+	require.NoError(t, err)
+	require.Empty(t, policies)
+	require.NoError(t, db.DeleteHoldPolicy(lntypes.Hash{1}))
+
+	preimage := lntypes.Preimage{2}
+	full := &HoldPolicy{
+		CancelDelta: 40,
+		Preimage:    &preimage,
+		SettleConf: &HoldSettleConf{
+			Txid:       chainhash.Hash{3},
+			PkScript:   []byte{0x00, 0x14, 0x01},
+			NumConfs:   3,
+			HeightHint: 700000,
+		},
+		SettleOnPayment: true,
+	}
+	empty := &HoldPolicy{}
+
+	require.NoError(t, db.PutHoldPolicy(lntypes.Hash{1}, full))
+	require.NoError(t, db.PutHoldPolicy(lntypes.Hash{2}, empty))
+
+	policies, err = db.FetchHoldPolicies()
+	require.NoError(t, err)
+	require.Equal(t, map[lntypes.Hash]*HoldPolicy{
+		{1}: full,
+		{2}: empty,
+	}, policies)
+
+	require.NoError(t, db.DeleteHoldPolicy(lntypes.Hash{1}))
+
+	policies, err = db.FetchHoldPolicies()
+	require.NoError(t, err)
+	require.Equal(t, map[lntypes.Hash]*HoldPolicy{{2}: empty}, policies)
+}
@@ -1356,6 +1356,231 @@ func TestQueryInvoices(t *testing.T) {
 	}
 }
 
+// TestQueryInvoicesFilters asserts that the filters of an invoice query are
+// applied to the returned invoices and to the total count.
+func TestQueryInvoicesFilters(t *testing.T) {
+	t.Parallel()
+
+	dbClock := clock.NewTestClock(testNow)
+	db, err := MakeTestDB(t, OptionClock(dbClock))
+	require.NoError(t, err, "unable to make test db")
+
+	// We add ten invoices that are created an hour apart, with a value of
+	// their add index in satoshis. Every third invoice is settled ten
+	// hours after its creation, the fifth one is canceled.
+	const numInvoices = 10
+	hour := func(i int) time.Time {
+		return testNow.Add(time.Duration(i) * time.Hour)
+	}
+	for i := 1; i <= numInvoices; i++ {
+		amt := lnwire.MilliSatoshi(i * 1000)
+		invoice, err := randInvoice(amt)
+		require.NoError(t, err)
+
+		invoice.CreationDate = hour(i)
+		invoice.Memo = []byte(fmt.Sprintf("Tea %d", i))
+		if i%2 == 0 {
+			invoice.Memo = []byte(fmt.Sprintf("Coffee %d", i))
+		}
+
+		paymentHash := invoice.Terms.PaymentPreimage.Hash()
+		_, err = db.AddInvoice(invoice, paymentHash)
+		require.NoError(t, err)
+
+		ref := InvoiceRefByHash(paymentHash)
+		switch {
+		case i%3 == 0:
+			dbClock.SetTime(hour(i + 10))
+			_, err = db.UpdateInvoice(
+				ref, nil, getUpdateInvoice(amt),
+			)
+			require.NoError(t, err)
+
+		case i == 5:
+			_, err = db.UpdateInvoice(ref, nil, func(
+				*Invoice) (*InvoiceUpdateDesc, error) {
+
+				return &InvoiceUpdateDesc{
+					State: &InvoiceStateUpdateDesc{
+						NewState: ContractCanceled,
+					},
+				}, nil
+			})
+			require.NoError(t, err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		query    InvoiceQuery
+		expected []uint64
+	}{
+		{
+			name:     "no filters",
+			query:    InvoiceQuery{},
+			expected: []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		},
+		{
+			name: "creation date range",
+			query: InvoiceQuery{
+				CreationDateStart: hour(3),
+				CreationDateEnd:   hour(5),
+			},
+			expected: []uint64{3, 4, 5},
+		},
+		{
+			name: "creation date end",
+			query: InvoiceQuery{
+				CreationDateEnd: hour(2),
+			},
+			expected: []uint64{1, 2},
+		},
+		{
+			name: "creation date range between invoices",
+			query: InvoiceQuery{
+				CreationDateStart: hour(3).Add(time.Minute),
+				CreationDateEnd:   hour(5).Add(time.Minute),
+			},
+			expected: []uint64{4, 5},
+		},
+		{
+			name: "creation date after all invoices",
+			query: InvoiceQuery{
+				CreationDateStart: hour(11),
+			},
+		},
+		{
+			name: "settle date start",
+			query: InvoiceQuery{
+				SettleDateStart: hour(14),
+			},
+			expected: []uint64{6, 9},
+		},
+		{
+			name: "settle date range",
+			query: InvoiceQuery{
+				SettleDateStart: hour(13),
+				SettleDateEnd:   hour(16),
+			},
+			expected: []uint64{3, 6},
+		},
+		{
+			name: "settle date end",
+			query: InvoiceQuery{
+				SettleDateEnd: hour(15),
+			},
+			expected: []uint64{3},
+		},
+		{
+			name: "creation and settle date range",
+			query: InvoiceQuery{
+				CreationDateEnd: hour(8),
+				SettleDateStart: hour(14),
+			},
+			expected: []uint64{6},
+		},
+		{
+			name: "settle date range and value",
+			query: InvoiceQuery{
+				SettleDateStart: hour(13),
+				MinValue:        4000,
+			},
+			expected: []uint64{6, 9},
+		},
+		{
+			name: "states",
+			query: InvoiceQuery{
+				States: []ContractState{
+					ContractSettled, ContractCanceled,
+				},
+			},
+			expected: []uint64{3, 5, 6, 9},
+		},
+		{
+			name: "value range",
+			query: InvoiceQuery{
+				MinValue: 2000,
+				MaxValue: 4000,
+			},
+			expected: []uint64{2, 3, 4},
+		},
+		{
+			name: "memo",
+			query: InvoiceQuery{
+				MemoContains: "coffee",
+			},
+			expected: []uint64{2, 4, 6, 8, 10},
+		},
+		{
+			name: "combined filters",
+			query: InvoiceQuery{
+				PendingOnly:  true,
+				MemoContains: "tea",
+				MinValue:     2000,
+			},
+			expected: []uint64{7},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			// The total count covers all matching invoices, even if
+			// only one of them is returned.
+			query := testCase.query
+			query.NumMaxInvoices = 1
+			query.CountTotal = true
+
+			response, err := db.QueryInvoices(query)
+			require.NoError(t, err)
+
+			require.EqualValues(
+				t, len(testCase.expected), response.TotalCount,
+			)
+			if len(testCase.expected) == 0 {
+				require.Empty(t, response.Invoices)
+				return
+			}
+			require.Len(t, response.Invoices, 1)
+			require.Equal(
+				t, testCase.expected[0],
+				response.Invoices[0].AddIndex,
+			)
+
+			query.NumMaxInvoices = numInvoices
+			response, err = db.QueryInvoices(query)
+			require.NoError(t, err)
+
+			var indices []uint64
+			for _, invoice := range response.Invoices {
+				indices = append(indices, invoice.AddIndex)
+			}
+			require.Equal(t, testCase.expected, indices)
+		})
+	}
+
+	// Finally, make sure that reversed queries start at the end of the
+	// creation date range, or at the index offset if it's within the
+	// range.
+	query := InvoiceQuery{
+		CreationDateStart: hour(3),
+		CreationDateEnd:   hour(7),
+		NumMaxInvoices:    2,
+		Reversed:          true,
+	}
+	response, err := db.QueryInvoices(query)
+	require.NoError(t, err)
+	require.EqualValues(t, 6, response.FirstIndexOffset)
+	require.EqualValues(t, 7, response.LastIndexOffset)
+
+	query.IndexOffset = 6
+	response, err = db.QueryInvoices(query)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, response.FirstIndexOffset)
+	require.EqualValues(t, 5, response.LastIndexOffset)
+}
+
 // getUpdateInvoice returns an invoice update callback that, when called,
 // settles the invoice with the given amount.
 func getUpdateInvoice(amt lnwire.MilliSatoshi) InvoiceUpdateCallback {
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strings"
 	"time"
 
@@ -1279,6 +1280,398 @@ type InvoiceQuery struct {
 	// Reversed, if set, indicates that the invoices returned should start
 	// from the IndexOffset and go backwards.
 	Reversed bool
+
+	// CreationDateStart, if set, filters out invoices created before it.
+	CreationDateStart time.Time
+
+	// CreationDateEnd, if set, filters out invoices created after it.
+	CreationDateEnd time.Time
+
+	// SettleDateStart, if set, filters out invoices that aren't settled or
+	// were settled before it.
+	SettleDateStart time.Time
+
+	// SettleDateEnd, if set, filters out invoices that aren't settled or
+	// were settled after it.
+	SettleDateEnd time.Time
+
+	// States, if set, filters out invoices whose state isn't in the list.
+	States []ContractState
+
+	// MinValue filters out invoices with a lower value.
+	MinValue lnwire.MilliSatoshi
+
+	// MaxValue, if set, filters out invoices with a higher value.
+	MaxValue lnwire.MilliSatoshi
+
+	// MemoContains, if set, filters out invoices whose memo doesn't
+	// contain it. The match is case-insensitive.
+	MemoContains string
+
+	// CountTotal, if set, counts all invoices that match the filters of the
+	// query, regardless of the index offset and the maximum number of
+	// invoices.
+	CountTotal bool
+}
+
+// hasSettleDateRange returns true if the query filters invoices by their
+// settle date.
+func (q *InvoiceQuery) hasSettleDateRange() bool {
+	return !q.SettleDateStart.IsZero() || !q.SettleDateEnd.IsZero()
+}
+
+// hasFieldFilters returns true if the query filters invoices by any of their
+// fields that can't be determined from the add and settle indexes alone.
+func (q *InvoiceQuery) hasFieldFilters() bool {
+	return q.PendingOnly || len(q.States) > 0 || q.MinValue != 0 ||
+		q.MaxValue != 0 || q.MemoContains != ""
+}
+
+// matches returns true if the invoice passes all filters of the query.
+func (q *InvoiceQuery) matches(invoice *Invoice) bool {
+	// Skip any settled or canceled invoices if the caller is only
+	// interested in pending ones.
+	if q.PendingOnly && !invoice.IsPending() {
+		return false
+	}
+
+	if len(q.States) > 0 {
+		var found bool
+		for _, state := range q.States {
+			if invoice.State == state {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if !q.CreationDateStart.IsZero() &&
+		invoice.CreationDate.Before(q.CreationDateStart) {
+
+		return false
+	}
+
+	if !q.CreationDateEnd.IsZero() &&
+		invoice.CreationDate.After(q.CreationDateEnd) {
+
+		return false
+	}
+
+	// Invoices that aren't settled have no settle date, so they are
+	// filtered out by any settle date range.
+	if !q.SettleDateStart.IsZero() || !q.SettleDateEnd.IsZero() {
+		if invoice.State != ContractSettled {
+			return false
+		}
+
+		if !q.SettleDateStart.IsZero() &&
+			invoice.SettleDate.Before(q.SettleDateStart) {
+
+			return false
+		}
+
+		if !q.SettleDateEnd.IsZero() &&
+			invoice.SettleDate.After(q.SettleDateEnd) {
+
+			return false
+		}
+	}
+
+	if invoice.Terms.Value < q.MinValue {
+		return false
+	}
+
+	if q.MaxValue != 0 && invoice.Terms.Value > q.MaxValue {
+		return false
+	}
+
+	if q.MemoContains != "" {
+		memo := strings.ToLower(string(invoice.Memo))
+		if !strings.Contains(memo, strings.ToLower(q.MemoContains)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// searchIndex returns the first key of the index bucket behind the given
+// cursor for which the predicate holds, assuming that the predicate also holds
+// for all keys following it. Zero is returned if the predicate doesn't hold
+// for any key of the index. The predicate is only evaluated for a logarithmic
+// number of index entries.
+func searchIndex(c kvdb.RCursor,
+	predicate func(indexValue []byte) (bool, error)) (uint64, error) {
+
+	firstKey, _ := c.First()
+	lastKey, _ := c.Last()
+	if firstKey == nil {
+		return 0, nil
+	}
+
+	seek := func(index uint64) ([]byte, []byte) {
+		var indexKey [8]byte
+		byteOrder.PutUint64(indexKey[:], index)
+		return c.Seek(indexKey[:])
+	}
+
+	// The predicate doesn't hold for any key below low, and it holds for
+	// the first existing key at or above high. As indexes may have gaps,
+	// we always evaluate the predicate of the first key we seek to.
+	low := byteOrder.Uint64(firstKey)
+	high := byteOrder.Uint64(lastKey) + 1
+	for low < high {
+		mid := low + (high-low)/2
+		indexKey, indexValue := seek(mid)
+
+		ok, err := predicate(indexValue)
+		if err != nil {
+			return 0, err
+		}
+
+		if ok {
+			high = mid
+		} else {
+			low = byteOrder.Uint64(indexKey) + 1
+		}
+	}
+
+	indexKey, _ := seek(low)
+	if indexKey == nil {
+		return 0, nil
+	}
+
+	return byteOrder.Uint64(indexKey), nil
+}
+
+// invoiceCandidates describes the invoices that may match the date ranges of an
+// invoice query, as determined from the add and settle indexes.
+type invoiceCandidates struct {
+	// minAddIndex is the lowest add index of an invoice created within
+	// the creation date range.
+	minAddIndex uint64
+
+	// maxAddIndex is the highest add index of an invoice created within
+	// the creation date range.
+	maxAddIndex uint64
+
+	// settled, if non-nil, is the set of invoice numbers of the invoices
+	// that were settled within the settle date range.
+	settled map[[4]byte]struct{}
+}
+
+// contains returns true if the invoice at the given add index entry may match
+// the date ranges of the query.
+func (c *invoiceCandidates) contains(addIndexKey, invoiceNum []byte) bool {
+	addIndex := byteOrder.Uint64(addIndexKey)
+	if addIndex < c.minAddIndex || addIndex > c.maxAddIndex {
+		return false
+	}
+
+	if c.settled == nil {
+		return true
+	}
+
+	var key [4]byte
+	copy(key[:], invoiceNum)
+	_, ok := c.settled[key]
+
+	return ok
+}
+
+// fetchInvoiceCandidates determines the invoices that may match the date
+// ranges of the query. As invoices are added and settled in order, their
+// creation and settle dates increase along the add and settle indexes, which
+// allows us to seek to the boundaries of the date ranges rather than having
+// to deserialize every single invoice.
+func (q *InvoiceQuery) fetchInvoiceCandidates(
+	invoices kvdb.RBucket) (*invoiceCandidates, error) {
+
+	candidates := &invoiceCandidates{
+		maxAddIndex: math.MaxUint64,
+	}
+
+	addIndex := invoices.NestedReadBucket(addIndexBucket)
+	if addIndex == nil {
+		return candidates, nil
+	}
+
+	// creationDateAfter returns a predicate that holds for all invoices
+	// of the add index that were created after the given date, or at it
+	// if inclusive is set.
+	creationDateAfter := func(date time.Time,
+		inclusive bool) func([]byte) (bool, error) {
+
+		return func(invoiceNum []byte) (bool, error) {
+			invoice, err := fetchInvoice(invoiceNum, invoices)
+			if err != nil {
+				return false, err
+			}
+
+			if inclusive && invoice.CreationDate.Equal(date) {
+				return true, nil
+			}
+
+			return invoice.CreationDate.After(date), nil
+		}
+	}
+
+	if !q.CreationDateStart.IsZero() {
+		start, err := searchIndex(
+			addIndex.ReadCursor(),
+			creationDateAfter(q.CreationDateStart, true),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// No invoice was created at or after the start of the range.
+		if start == 0 {
+			candidates.minAddIndex = math.MaxUint64
+			candidates.maxAddIndex = 0
+
+			return candidates, nil
+		}
+
+		candidates.minAddIndex = start
+	}
+
+	if !q.CreationDateEnd.IsZero() {
+		end, err := searchIndex(
+			addIndex.ReadCursor(),
+			creationDateAfter(q.CreationDateEnd, false),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Unless all invoices were created before the end of the range,
+		// the range ends right before the first invoice created after
+		// it.
+		if end != 0 {
+			candidates.maxAddIndex = end - 1
+		}
+	}
+
+	if !q.hasSettleDateRange() {
+		return candidates, nil
+	}
+
+	candidates.settled = make(map[[4]byte]struct{})
+
+	settleIndex := invoices.NestedReadBucket(settleIndexBucket)
+	if settleIndex == nil {
+		return candidates, nil
+	}
+
+	// settleDateAfter returns a predicate that holds for all entries of
+	// the settle index that were settled after the given date, or at it
+	// if inclusive is set.
+	settleDateAfter := func(date time.Time,
+		inclusive bool) func([]byte) (bool, error) {
+
+		return func(indexValue []byte) (bool, error) {
+			settleDate, err := fetchSettleDate(indexValue, invoices)
+			if err != nil {
+				return false, err
+			}
+
+			if inclusive && settleDate.Equal(date) {
+				return true, nil
+			}
+
+			return settleDate.After(date), nil
+		}
+	}
+
+	settleCursor := settleIndex.ReadCursor()
+	firstKey, _ := settleCursor.First()
+	if firstKey == nil {
+		return candidates, nil
+	}
+
+	start := byteOrder.Uint64(firstKey)
+	if !q.SettleDateStart.IsZero() {
+		var err error
+		start, err = searchIndex(
+			settleCursor, settleDateAfter(q.SettleDateStart, true),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// No invoice was settled at or after the start of the range.
+		if start == 0 {
+			return candidates, nil
+		}
+	}
+
+	end := uint64(math.MaxUint64)
+	if !q.SettleDateEnd.IsZero() {
+		firstAfter, err := searchIndex(
+			settleCursor, settleDateAfter(q.SettleDateEnd, false),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if firstAfter != 0 {
+			end = firstAfter - 1
+		}
+	}
+
+	// Collect the invoices settled within the range. AMP invoices are
+	// never settled as a whole, so we skip the entries of their settled
+	// payments.
+	var startKey [8]byte
+	byteOrder.PutUint64(startKey[:], start)
+	indexKey, indexValue := settleCursor.Seek(startKey[:])
+	for ; indexKey != nil; indexKey, indexValue = settleCursor.Next() {
+		if byteOrder.Uint64(indexKey) > end {
+			break
+		}
+
+		if len(indexValue) == invoiceSetIDKeyLen {
+			continue
+		}
+
+		var invoiceNum [4]byte
+		copy(invoiceNum[:], indexValue)
+		candidates.settled[invoiceNum] = struct{}{}
+	}
+
+	return candidates, nil
+}
+
+// fetchSettleDate returns the settle date of the given settle index entry.
+// Entries of settled AMP payments carry the set id of the payment, in which
+// case the settle date of that payment is returned.
+func fetchSettleDate(indexValue []byte,
+	invoices kvdb.RBucket) (time.Time, error) {
+
+	invoiceNum := indexValue[:4]
+	if len(indexValue) != invoiceSetIDKeyLen {
+		invoice, err := fetchInvoice(invoiceNum, invoices)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return invoice.SettleDate, nil
+	}
+
+	ampState, err := fetchInvoiceStateAMP(invoiceNum, invoices)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var setID SetID
+	copy(setID[:], indexValue[4:])
+
+	return ampState[setID].SettleDate, nil
 }
 
 // InvoiceSlice is the response to a invoice query. It includes the original
@@ -1304,6 +1697,10 @@ type InvoiceSlice struct {
 	// in the event that the slice has too many events to fit into a single
 	// response.
 	LastIndexOffset uint64
+
+	// TotalCount is the number of invoices that match the filters of the
+	// query. It is only set if the CountTotal field of the query is set.
+	TotalCount uint64
 }
 
 // QueryInvoices allows a caller to query the invoice database for invoices
@@ -1326,10 +1723,33 @@ func (d *DB) QueryInvoices(q InvoiceQuery) (InvoiceSlice, error) {
 			return ErrNoInvoicesCreated
 		}
 
+		// Use the add and settle indexes to narrow down the invoices
+		// that can match the date ranges of the query.
+		candidates, err := q.fetchInvoiceCandidates(invoices)
+		if err != nil {
+			return err
+		}
+
+		// Skip straight to the add index range of the invoices created
+		// within the creation date range.
+		indexOffset := q.IndexOffset
+		switch {
+		case !q.Reversed && candidates.minAddIndex > 0 &&
+			indexOffset < candidates.minAddIndex-1:
+
+			indexOffset = candidates.minAddIndex - 1
+
+		case q.Reversed && candidates.maxAddIndex != math.MaxUint64 &&
+			(indexOffset == 0 ||
+				indexOffset > candidates.maxAddIndex+1):
+
+			indexOffset = candidates.maxAddIndex + 1
+		}
+
 		// Create a paginator which reads from our add index bucket with
 		// the parameters provided by the invoice query.
 		paginator := newPaginator(
-			invoiceAddIndex.ReadCursor(), q.Reversed, q.IndexOffset,
+			invoiceAddIndex.ReadCursor(), q.Reversed, indexOffset,
 			q.NumMaxInvoices,
 		)
 
@@ -1337,15 +1757,23 @@ func (d *DB) QueryInvoices(q InvoiceQuery) (InvoiceSlice, error) {
 		// are given, adds it to our set of invoices if it has the right
 		// characteristics for our query and returns the number of items
 		// we have added to our set of invoices.
-		accumulateInvoices := func(_, indexValue []byte) (bool, error) {
+		accumulateInvoices := func(indexKey, indexValue []byte) (bool,
+			error) {
+
+			// Skip any invoices outside of the date ranges without
+			// having to deserialize them.
+			if !candidates.contains(indexKey, indexValue) {
+				return false, nil
+			}
+
 			invoice, err := fetchInvoice(indexValue, invoices)
 			if err != nil {
 				return false, err
 			}
 
-			// Skip any settled or canceled invoices if the caller
-			// is only interested in pending ones.
-			if q.PendingOnly && !invoice.IsPending() {
+			// Skip any invoices that don't pass the filters of the
+			// query.
+			if !q.matches(&invoice) {
 				return false, nil
 			}
 
@@ -1373,6 +1801,44 @@ func (d *DB) QueryInvoices(q InvoiceQuery) (InvoiceSlice, error) {
 			}
 		}
 
+		if !q.CountTotal {
+			return nil
+		}
+
+		// Count the invoices within the add index range of the creation
+		// date range. Unless the query filters by fields other than the
+		// dates, the indexes are enough, so we don't need to
+		// deserialize the invoices.
+		filtered := q.hasFieldFilters()
+		var startKey [8]byte
+		byteOrder.PutUint64(startKey[:], candidates.minAddIndex)
+
+		cursor := invoiceAddIndex.ReadCursor()
+		indexKey, indexValue := cursor.Seek(startKey[:])
+		for ; indexKey != nil; indexKey, indexValue = cursor.Next() {
+			if byteOrder.Uint64(indexKey) > candidates.maxAddIndex {
+				break
+			}
+
+			if !candidates.contains(indexKey, indexValue) {
+				continue
+			}
+
+			if !filtered {
+				resp.TotalCount++
+				continue
+			}
+
+			invoice, err := fetchInvoice(indexValue, invoices)
+			if err != nil {
+				return err
+			}
+
+			if q.matches(&invoice) {
+				resp.TotalCount++
+			}
+		}
+
 		return nil
 	}, func() {
 		resp = InvoiceSlice{
@@ -9,6 +9,7 @@ import (
 	"github.com/lightningnetwork/lnd/channeldb/migration16"
 	"github.com/lightningnetwork/lnd/channeldb/migration24"
 	"github.com/lightningnetwork/lnd/channeldb/migration30"
+	"github.com/lightningnetwork/lnd/channeldb/migration31"
 	"github.com/lightningnetwork/lnd/channeldb/migration_01_to_11"
 	"github.com/lightningnetwork/lnd/kvdb"
 )
@@ -40,5 +41,6 @@ func UseLogger(logger btclog.Logger) {
 	migration16.UseLogger(logger)
 	migration24.UseLogger(logger)
 	migration30.UseLogger(logger)
+	migration31.UseLogger(logger)
 	kvdb.UseLogger(logger)
 }
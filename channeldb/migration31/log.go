@@ -0,0 +1,12 @@
+package migration31
+
+import "github.com/btcsuite/btclog"
+
+// log is a logger that is initialized as disabled. This means the package will
+// not perform any logging by default until a logger is set.
+var log = btclog.Disabled
+
+// UseLogger uses a specific Logger to output package logging info.
+func UseLogger(logger btclog.Logger) {
+	log = logger
+}
@@ -0,0 +1,331 @@
+package migration31
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	paymentsRootBucket = []byte("payments-root-bucket")
+
+	paymentsIndexBucket = []byte("payments-index-bucket")
+
+	paymentsTimeIndexBucket = []byte("payments-time-index-bucket")
+
+	paymentsDestIndexBucket = []byte("payments-dest-index-bucket")
+
+	paymentsStatusIndexBucket = []byte("payments-status-index-bucket")
+
+	paymentSequenceKey = []byte("payment-sequence-key")
+
+	paymentCreationInfoKey = []byte("payment-creation-info")
+
+	paymentFailInfoKey = []byte("payment-fail-info")
+
+	paymentDestinationKey = []byte("payment-destination")
+
+	paymentHtlcsBucket = []byte("payment-htlcs-bucket")
+
+	htlcAttemptInfoKey = []byte("ai")
+
+	htlcSettleInfoKey = []byte("si")
+
+	htlcFailInfoKey = []byte("fi")
+
+	byteOrder = binary.BigEndian
+)
+
+const (
+	statusInFlight  byte = 1
+	statusSucceeded byte = 2
+	statusFailed    byte = 3
+
+	// creationTimeOffset is the offset of the creation time in the
+	// serialized creation info, after the payment identifier and value.
+	creationTimeOffset = 32 + 8
+
+	// sessionKeyLen is the length of the session key at the start of the
+	// serialized htlc attempt info.
+	sessionKeyLen = 32
+
+	// maxOnionPayloadSize is the largest size of a tlv record of a hop.
+	maxOnionPayloadSize = 1300
+)
+
+// paymentIndexEntries holds the entries of a payment in the new indexes.
+type paymentIndexEntries struct {
+	// paymentHash is the key of the payment in the payments root bucket.
+	paymentHash []byte
+
+	// value is the entry of the payment in the payments index, which is
+	// used as the value in the new indexes as well.
+	value []byte
+
+	timeKey   []byte
+	statusKey []byte
+
+	// dest is the destination of the payment, nil if it has no htlc
+	// attempts.
+	dest    []byte
+	destKey []byte
+}
+
+// MigratePaymentIndexes creates the indexes of payments by creation time,
+// status and destination and adds all existing payments to them. Legacy
+// duplicate payments aren't indexed. The destination of each payment is also
+// stored in its bucket, so that it survives the deletion of failed htlc
+// attempts.
+func MigratePaymentIndexes(tx kvdb.RwTx) error {
+	log.Infof("Migrating payments to add time, status and destination " +
+		"indexes")
+
+	entries, err := fetchPaymentIndexEntries(tx)
+	if err != nil {
+		return err
+	}
+
+	timeIndex, err := tx.CreateTopLevelBucket(paymentsTimeIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	statusIndex, err := tx.CreateTopLevelBucket(paymentsStatusIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	destIndex, err := tx.CreateTopLevelBucket(paymentsDestIndexBucket)
+	if err != nil {
+		return err
+	}
+
+	payments := tx.ReadWriteBucket(paymentsRootBucket)
+	for _, entry := range entries {
+		err := timeIndex.Put(entry.timeKey, entry.value)
+		if err != nil {
+			return err
+		}
+
+		err = statusIndex.Put(entry.statusKey, entry.value)
+		if err != nil {
+			return err
+		}
+
+		if entry.dest == nil {
+			continue
+		}
+
+		err = destIndex.Put(entry.destKey, entry.value)
+		if err != nil {
+			return err
+		}
+
+		bucket := payments.NestedReadWriteBucket(entry.paymentHash)
+		err = bucket.Put(paymentDestinationKey, entry.dest)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Added %d payments to the payment indexes", len(entries))
+
+	return nil
+}
+
+// fetchPaymentIndexEntries gets the index entries of all payments. This is
+// needed, because no modifications are allowed inside a Bucket.ForEach loop.
+func fetchPaymentIndexEntries(tx kvdb.RTx) ([]*paymentIndexEntries, error) {
+	payments := tx.ReadBucket(paymentsRootBucket)
+	if payments == nil {
+		return nil, nil
+	}
+
+	indexes := tx.ReadBucket(paymentsIndexBucket)
+	if indexes == nil {
+		return nil, fmt.Errorf("index bucket does not exist")
+	}
+
+	var entries []*paymentIndexEntries
+	err := payments.ForEach(func(k, _ []byte) error {
+		bucket := payments.NestedReadBucket(k)
+		if bucket == nil {
+			return fmt.Errorf("non bucket element in payments " +
+				"bucket")
+		}
+
+		seqBytes := bucket.Get(paymentSequenceKey)
+		if seqBytes == nil {
+			return fmt.Errorf("nil sequence number bytes")
+		}
+
+		value := indexes.Get(seqBytes)
+		if value == nil {
+			return fmt.Errorf("payment %x not indexed", seqBytes)
+		}
+
+		creationInfo := bucket.Get(paymentCreationInfoKey)
+		if len(creationInfo) < creationTimeOffset+8 {
+			return fmt.Errorf("invalid creation info of payment %x",
+				k)
+		}
+		creationTime := creationInfo[creationTimeOffset:][:8]
+
+		status, dest, err := fetchStatusAndDest(bucket)
+		if err != nil {
+			return err
+		}
+
+		entry := &paymentIndexEntries{
+			paymentHash: k,
+			value:       value,
+			timeKey:     concat(creationTime, seqBytes),
+			statusKey:   concat([]byte{status}, seqBytes),
+			dest:        dest,
+		}
+		if dest != nil {
+			entry.destKey = concat(dest, seqBytes)
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// fetchStatusAndDest determines the status of the payment found in the given
+// bucket, as well as its destination, which is taken from the route of its
+// first htlc attempt.
+func fetchStatusAndDest(bucket kvdb.RBucket) (byte, []byte, error) {
+	var (
+		inflight, settled bool
+		dest              []byte
+	)
+
+	htlcs := bucket.NestedReadBucket(paymentHtlcsBucket)
+	if htlcs != nil {
+		err := htlcs.ForEach(func(k, v []byte) error {
+			if !bytes.HasPrefix(k, htlcAttemptInfoKey) {
+				return nil
+			}
+			aid := k[len(htlcAttemptInfoKey):]
+
+			if dest == nil {
+				var err error
+				dest, err = readAttemptDest(v)
+				if err != nil {
+					return err
+				}
+			}
+
+			switch {
+			case htlcs.Get(concat(htlcFailInfoKey, aid)) != nil:
+
+			case htlcs.Get(concat(htlcSettleInfoKey, aid)) != nil:
+				settled = true
+
+			default:
+				inflight = true
+			}
+
+			return nil
+		})
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	failed := bucket.Get(paymentFailInfoKey) != nil
+
+	switch {
+	case !inflight && settled:
+		return statusSucceeded, dest, nil
+
+	case !inflight && failed:
+		return statusFailed, dest, nil
+
+	default:
+		return statusInFlight, dest, nil
+	}
+}
+
+// readAttemptDest reads the pubkey of the final hop from a serialized htlc
+// attempt info.
+func readAttemptDest(b []byte) ([]byte, error) {
+	r := bytes.NewReader(b)
+
+	// Skip the session key, as well as the total time lock and the total
+	// amount of the route.
+	if _, err := r.Seek(sessionKeyLen+4+8, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// Skip the source pubkey.
+	if _, err := wire.ReadVarBytes(r, 0, 33, "pubkey"); err != nil {
+		return nil, err
+	}
+
+	var numHops uint32
+	if err := binary.Read(r, byteOrder, &numHops); err != nil {
+		return nil, err
+	}
+
+	if numHops == 0 {
+		return nil, fmt.Errorf("route without hops")
+	}
+
+	var pubKey []byte
+	for i := uint32(0); i < numHops; i++ {
+		var err error
+		pubKey, err = wire.ReadVarBytes(r, 0, 33, "pubkey")
+		if err != nil {
+			return nil, err
+		}
+
+		// Skip the channel id, outgoing time lock, amount to forward
+		// and legacy payload flag.
+		if _, err := r.Seek(8+4+8+1, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+
+		var numRecords uint32
+		err = binary.Read(r, byteOrder, &numRecords)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := uint32(0); j < numRecords; j++ {
+			// Skip the record type.
+			_, err := r.Seek(8, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = wire.ReadVarBytes(
+				r, 0, maxOnionPayloadSize, "tlv",
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return pubKey, nil
+}
+
+// concat returns the concatenation of the prefix and the id.
+func concat(prefix, id []byte) []byte {
+	key := make([]byte, len(prefix)+len(id))
+	copy(key, prefix)
+	copy(key[len(prefix):], id)
+	return key
+}
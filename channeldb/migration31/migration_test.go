@@ -0,0 +1,194 @@
+package migration31
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb/migtest"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	hexStr = migtest.Hex
+
+	hash1 = hexStr(strings.Repeat("11", 32))
+	hash2 = hexStr(strings.Repeat("22", 32))
+	hash3 = hexStr(strings.Repeat("33", 32))
+
+	seq1 = hexStr("0000000000000001")
+	seq2 = hexStr("0000000000000002")
+	seq3 = hexStr("0000000000000003")
+
+	// The payments index values are only copied by the migration.
+	index1 = hexStr("0001")
+	index2 = hexStr("0002")
+	index3 = hexStr("0003")
+
+	time1 = hexStr("000000000000000a")
+	time2 = hexStr("000000000000000b")
+	time3 = hexStr("000000000000000c")
+
+	source = hexStr("02" + strings.Repeat("aa", 32))
+	hop    = hexStr("02" + strings.Repeat("bb", 32))
+	dest   = hexStr("02" + strings.Repeat("cc", 32))
+
+	// attempt is a serialized htlc attempt info with a two hop route to
+	// dest. The final hop carries a tlv record.
+	attempt = hexStr(strings.Repeat("00", 32)+
+		"00000064"+"0000000000000fa0"+"21") + source +
+		hexStr("00000002") +
+		hexStr("21") + hop + hexStr("0000000000000001"+"00000050"+
+		"00000000000003e8"+"01"+"00000000") +
+		hexStr("21") + dest + hexStr("0000000000000002"+"00000028"+
+		"00000000000003e8"+"00"+"00000001"+"0000000000010000"+
+		"02ffff") +
+		hexStr("0000000000000001")
+
+	attemptID = hexStr("0000000000000000")
+
+	// creationInfo returns a serialized creation info with the given
+	// creation time.
+	creationInfo = func(time string) string {
+		return hexStr(strings.Repeat("00", 32)+"00000000000003e8") +
+			time + hexStr("00000000")
+	}
+
+	paymentsIndex = map[string]interface{}{
+		seq1: index1,
+		seq2: index2,
+		seq3: index3,
+	}
+
+	// payment1 succeeded, payment2 failed without any htlcs and payment3
+	// is in flight.
+	paymentsBefore = map[string]interface{}{
+		hash1: map[string]interface{}{
+			string(paymentSequenceKey):     seq1,
+			string(paymentCreationInfoKey): creationInfo(time1),
+			string(paymentHtlcsBucket): map[string]interface{}{
+				"ai" + attemptID: attempt,
+				"si" + attemptID: hexStr("00"),
+			},
+		},
+		hash2: map[string]interface{}{
+			string(paymentSequenceKey):     seq2,
+			string(paymentCreationInfoKey): creationInfo(time2),
+			string(paymentFailInfoKey):     hexStr("01"),
+		},
+		hash3: map[string]interface{}{
+			string(paymentSequenceKey):     seq3,
+			string(paymentCreationInfoKey): creationInfo(time3),
+			string(paymentHtlcsBucket): map[string]interface{}{
+				"ai" + attemptID: attempt,
+			},
+		},
+	}
+
+	paymentsAfter = map[string]interface{}{
+		hash1: map[string]interface{}{
+			string(paymentSequenceKey):     seq1,
+			string(paymentCreationInfoKey): creationInfo(time1),
+			string(paymentDestinationKey):  dest,
+			string(paymentHtlcsBucket): map[string]interface{}{
+				"ai" + attemptID: attempt,
+				"si" + attemptID: hexStr("00"),
+			},
+		},
+		hash2: map[string]interface{}{
+			string(paymentSequenceKey):     seq2,
+			string(paymentCreationInfoKey): creationInfo(time2),
+			string(paymentFailInfoKey):     hexStr("01"),
+		},
+		hash3: map[string]interface{}{
+			string(paymentSequenceKey):     seq3,
+			string(paymentCreationInfoKey): creationInfo(time3),
+			string(paymentDestinationKey):  dest,
+			string(paymentHtlcsBucket): map[string]interface{}{
+				"ai" + attemptID: attempt,
+			},
+		},
+	}
+
+	timeIndex = map[string]interface{}{
+		time1 + seq1: index1,
+		time2 + seq2: index2,
+		time3 + seq3: index3,
+	}
+
+	statusIndex = map[string]interface{}{
+		hexStr("02") + seq1: index1,
+		hexStr("03") + seq2: index2,
+		hexStr("01") + seq3: index3,
+	}
+
+	destIndex = map[string]interface{}{
+		dest + seq1: index1,
+		dest + seq3: index3,
+	}
+)
+
+// TestMigratePaymentIndexes asserts that existing payments are added to the
+// time, status and destination indexes.
+func TestMigratePaymentIndexes(t *testing.T) {
+	before := func(tx kvdb.RwTx) error {
+		err := migtest.RestoreDB(tx, paymentsRootBucket, paymentsBefore)
+		if err != nil {
+			return err
+		}
+
+		return migtest.RestoreDB(tx, paymentsIndexBucket, paymentsIndex)
+	}
+
+	after := func(tx kvdb.RwTx) error {
+		err := migtest.VerifyDB(tx, paymentsRootBucket, paymentsAfter)
+		if err != nil {
+			return err
+		}
+
+		err = migtest.VerifyDB(tx, paymentsIndexBucket, paymentsIndex)
+		if err != nil {
+			return err
+		}
+
+		err = migtest.VerifyDB(tx, paymentsTimeIndexBucket, timeIndex)
+		if err != nil {
+			return err
+		}
+
+		err = migtest.VerifyDB(
+			tx, paymentsStatusIndexBucket, statusIndex,
+		)
+		if err != nil {
+			return err
+		}
+
+		return migtest.VerifyDB(tx, paymentsDestIndexBucket, destIndex)
+	}
+
+	migtest.ApplyMigration(t, before, after, MigratePaymentIndexes, false)
+}
+
+// TestMigratePaymentIndexesEmpty asserts that the indexes are created if
+// there are no payments yet.
+func TestMigratePaymentIndexesEmpty(t *testing.T) {
+	empty := map[string]interface{}{}
+
+	after := func(tx kvdb.RwTx) error {
+		for _, bucket := range [][]byte{
+			paymentsTimeIndexBucket, paymentsStatusIndexBucket,
+			paymentsDestIndexBucket,
+		} {
+			err := migtest.VerifyDB(tx, bucket, empty)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	migtest.ApplyMigration(
+		t, func(kvdb.RwTx) error { return nil }, after,
+		MigratePaymentIndexes, false,
+	)
+}
@@ -174,6 +174,10 @@ type MPPayment struct {
 
 	// Status is the current PaymentStatus of this payment.
 	Status PaymentStatus
+
+	// Metrics holds the metrics recorded while sending the payment. It
+	// is nil if no metrics were recorded.
+	Metrics *PaymentMetrics
 }
 
 // TerminalInfo returns any HTLC settle info recorded. If no settle info is
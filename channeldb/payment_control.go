@@ -182,6 +182,25 @@ func (p *PaymentControl) InitPayment(paymentHash lntypes.Hash,
 			}
 		}
 
+		// The previous attempt is removed from the secondary indexes as
+		// well, including its destination which is set again once the
+		// first htlc is registered.
+		if paymentStatus != StatusUnknown {
+			keys, err := fetchPaymentIndexKeys(bucket)
+			if err != nil {
+				return err
+			}
+
+			if err := deletePaymentIndexKeys(tx, keys); err != nil {
+				return err
+			}
+
+			err = bucket.Delete(paymentDestinationKey)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Once we have obtained a sequence number, we add an entry
 		// to our index bucket which will map the sequence number to
 		// our payment identifier.
@@ -192,6 +211,11 @@ func (p *PaymentControl) InitPayment(paymentHash lntypes.Hash,
 			return err
 		}
 
+		err = createSecondaryIndexEntries(tx, sequenceNum, info)
+		if err != nil {
+			return err
+		}
+
 		err = bucket.Put(paymentSequenceKey, sequenceNum)
 		if err != nil {
 			return err
@@ -212,6 +236,12 @@ func (p *PaymentControl) InitPayment(paymentHash lntypes.Hash,
 			return err
 		}
 
+		// The metrics of the earlier attempt are removed as well.
+		err = bucket.Delete(paymentMetricsKey)
+		if err != nil {
+			return err
+		}
+
 		// Also delete any lingering failure info now that we are
 		// re-attempting.
 		return bucket.Delete(paymentFailInfoKey)
@@ -390,6 +420,25 @@ func (p *PaymentControl) RegisterAttempt(paymentHash lntypes.Hash,
 			return err
 		}
 
+		// The payment is added to the destination index with its first
+		// attempt.
+		if bucket.Get(paymentDestinationKey) == nil {
+			dest := attempt.Route.FinalHop().PubKeyBytes
+			err := bucket.Put(paymentDestinationKey, dest[:])
+			if err != nil {
+				return err
+			}
+
+			seqBytes := bucket.Get(paymentSequenceKey)
+			err = putPaymentIndexKey(
+				tx, paymentsDestIndexBucket,
+				htlcBucketKey(dest[:], seqBytes), seqBytes,
+			)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Retrieve attempt info for the notification.
 		payment, err = fetchPayment(bucket)
 		return err
@@ -489,7 +538,14 @@ func (p *PaymentControl) updateHtlcKey(paymentHash lntypes.Hash,
 
 		// Retrieve attempt info for the notification.
 		payment, err = fetchPayment(bucket)
-		return err
+		if err != nil {
+			return err
+		}
+
+		return updateStatusIndex(
+			tx, bucket.Get(paymentSequenceKey), p.Status,
+			payment.Status,
+		)
 	})
 	if err != nil {
 		return nil, err
@@ -551,7 +607,10 @@ func (p *PaymentControl) Fail(paymentHash lntypes.Hash,
 			return err
 		}
 
-		return nil
+		return updateStatusIndex(
+			tx, bucket.Get(paymentSequenceKey), paymentStatus,
+			payment.Status,
+		)
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,314 @@
+package channeldb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// paymentsTimeIndexBucket is the name of the top-level bucket that
+	// indexes payments by their creation time. The value of each entry is
+	// the same as in the payments index bucket.
+	// payments-time-index-bucket
+	// 	|--<creation unix nano><sequence-number>: <payment hash>
+	// 	|--...
+	paymentsTimeIndexBucket = []byte("payments-time-index-bucket")
+
+	// paymentsDestIndexBucket is the name of the top-level bucket that
+	// indexes payments by their destination. The value of each entry is
+	// the same as in the payments index bucket.
+	// payments-dest-index-bucket
+	// 	|--<destination pubkey><sequence-number>: <payment hash>
+	// 	|--...
+	paymentsDestIndexBucket = []byte("payments-dest-index-bucket")
+
+	// paymentsStatusIndexBucket is the name of the top-level bucket that
+	// indexes payments by their status. The value of each entry is the
+	// same as in the payments index bucket.
+	// payments-status-index-bucket
+	// 	|--<status><sequence-number>: <payment hash>
+	// 	|--...
+	paymentsStatusIndexBucket = []byte("payments-status-index-bucket")
+
+	// paymentDestinationKey is a key used in the payment's sub-bucket to
+	// store the destination of the payment once its first htlc attempt is
+	// registered. It is kept separately from the attempts, as failed
+	// attempts may be deleted.
+	paymentDestinationKey = []byte("payment-destination")
+)
+
+// errNoPaymentIndex is returned if one of the secondary payment indexes
+// doesn't exist.
+var errNoPaymentIndex = errors.New("payment index bucket does not exist")
+
+// paymentIndexKeys holds the keys of a payment in the secondary payment
+// indexes.
+type paymentIndexKeys struct {
+	// time is the key of the payment in the time index.
+	time []byte
+
+	// status is the key of the payment in the status index.
+	status []byte
+
+	// dest is the key of the payment in the destination index. It is nil
+	// if no htlc attempt was registered for the payment yet.
+	dest []byte
+}
+
+// timeIndexKey returns the key of a payment in the time index.
+func timeIndexKey(creationTime time.Time, sequenceNumber []byte) []byte {
+	var unixNano uint64
+	if !creationTime.IsZero() {
+		unixNano = uint64(creationTime.UnixNano())
+	}
+
+	var prefix [8]byte
+	byteOrder.PutUint64(prefix[:], unixNano)
+
+	return htlcBucketKey(prefix[:], sequenceNumber)
+}
+
+// statusIndexKey returns the key of a payment in the status index.
+func statusIndexKey(status PaymentStatus, sequenceNumber []byte) []byte {
+	return htlcBucketKey([]byte{byte(status)}, sequenceNumber)
+}
+
+// fetchPaymentIndexKeys returns the secondary index keys of the payment found
+// in the given bucket. Legacy duplicate payments aren't part of the secondary
+// indexes.
+func fetchPaymentIndexKeys(bucket kvdb.RBucket) (*paymentIndexKeys, error) {
+	payment, err := fetchPayment(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var seqBytes [8]byte
+	byteOrder.PutUint64(seqBytes[:], payment.SequenceNum)
+
+	keys := &paymentIndexKeys{
+		time:   timeIndexKey(payment.Info.CreationTime, seqBytes[:]),
+		status: statusIndexKey(payment.Status, seqBytes[:]),
+	}
+
+	if dest := bucket.Get(paymentDestinationKey); dest != nil {
+		keys.dest = htlcBucketKey(dest, seqBytes[:])
+	}
+
+	return keys, nil
+}
+
+// deletePaymentIndexKeys removes a payment from the secondary payment
+// indexes.
+func deletePaymentIndexKeys(tx kvdb.RwTx, keys *paymentIndexKeys) error {
+	timeIndex := tx.ReadWriteBucket(paymentsTimeIndexBucket)
+	if err := timeIndex.Delete(keys.time); err != nil {
+		return err
+	}
+
+	statusIndex := tx.ReadWriteBucket(paymentsStatusIndexBucket)
+	if err := statusIndex.Delete(keys.status); err != nil {
+		return err
+	}
+
+	if keys.dest == nil {
+		return nil
+	}
+
+	destIndex := tx.ReadWriteBucket(paymentsDestIndexBucket)
+	return destIndex.Delete(keys.dest)
+}
+
+// putPaymentIndexKey adds a payment to the given secondary index. The value
+// of the entry is copied from the payments index.
+func putPaymentIndexKey(tx kvdb.RwTx, indexBucket, key,
+	sequenceNumber []byte) error {
+
+	value := tx.ReadWriteBucket(paymentsIndexBucket).Get(sequenceNumber)
+	if value == nil {
+		return fmt.Errorf("payment with sequence number %x not "+
+			"indexed", sequenceNumber)
+	}
+
+	return tx.ReadWriteBucket(indexBucket).Put(key, value)
+}
+
+// createSecondaryIndexEntries adds a newly initiated payment to the time and
+// status indexes. The payment must already be part of the payments index.
+func createSecondaryIndexEntries(tx kvdb.RwTx, sequenceNumber []byte,
+	info *PaymentCreationInfo) error {
+
+	err := putPaymentIndexKey(
+		tx, paymentsTimeIndexBucket,
+		timeIndexKey(info.CreationTime, sequenceNumber), sequenceNumber,
+	)
+	if err != nil {
+		return err
+	}
+
+	return putPaymentIndexKey(
+		tx, paymentsStatusIndexBucket,
+		statusIndexKey(StatusInFlight, sequenceNumber), sequenceNumber,
+	)
+}
+
+// updateStatusIndex moves a payment to its new status in the status index.
+func updateStatusIndex(tx kvdb.RwTx, sequenceNumber []byte, oldStatus,
+	newStatus PaymentStatus) error {
+
+	if oldStatus == newStatus {
+		return nil
+	}
+
+	statusIndex := tx.ReadWriteBucket(paymentsStatusIndexBucket)
+	err := statusIndex.Delete(statusIndexKey(oldStatus, sequenceNumber))
+	if err != nil {
+		return err
+	}
+
+	return putPaymentIndexKey(
+		tx, paymentsStatusIndexBucket,
+		statusIndexKey(newStatus, sequenceNumber), sequenceNumber,
+	)
+}
+
+// seqIndexCursor iterates over the entries of a payment index that share a
+// common prefix followed by the payment sequence number, limited to a range
+// of sequence numbers. It returns the sequence numbers as keys, so that it
+// can be paginated like the payments index.
+type seqIndexCursor struct {
+	// cursor is the cursor of the underlying index bucket.
+	cursor kvdb.RCursor
+
+	// prefix is the prefix of all entries that are iterated over.
+	prefix []byte
+
+	// minSeq and maxSeq are the inclusive bounds of the sequence numbers
+	// that are iterated over.
+	minSeq uint64
+	maxSeq uint64
+}
+
+// A compile time check to ensure seqIndexCursor implements the kvdb.RCursor
+// interface.
+var _ kvdb.RCursor = (*seqIndexCursor)(nil)
+
+// newSeqIndexCursor creates a cursor over all entries of the bucket with the
+// given prefix.
+func newSeqIndexCursor(bucket kvdb.RBucket, prefix []byte) *seqIndexCursor {
+	return &seqIndexCursor{
+		cursor: bucket.ReadCursor(),
+		prefix: prefix,
+		maxSeq: math.MaxUint64,
+	}
+}
+
+// key returns the key of the underlying bucket for a sequence number.
+func (c *seqIndexCursor) key(seq uint64) []byte {
+	var seqBytes [8]byte
+	byteOrder.PutUint64(seqBytes[:], seq)
+
+	return htlcBucketKey(c.prefix, seqBytes[:])
+}
+
+// entry converts an entry of the underlying bucket, returning nil if it is
+// out of the range of the cursor.
+func (c *seqIndexCursor) entry(k, v []byte) ([]byte, []byte) {
+	if len(k) != len(c.prefix)+8 || !bytes.HasPrefix(k, c.prefix) {
+		return nil, nil
+	}
+
+	seqBytes := k[len(c.prefix):]
+	seq := byteOrder.Uint64(seqBytes)
+	if seq < c.minSeq || seq > c.maxSeq {
+		return nil, nil
+	}
+
+	return seqBytes, v
+}
+
+// First positions the cursor at the lowest sequence number in range.
+func (c *seqIndexCursor) First() ([]byte, []byte) {
+	return c.entry(c.cursor.Seek(c.key(c.minSeq)))
+}
+
+// Last positions the cursor at the highest sequence number in range.
+func (c *seqIndexCursor) Last() ([]byte, []byte) {
+	maxKey := c.key(c.maxSeq)
+
+	k, v := c.cursor.Seek(maxKey)
+	switch {
+	// There is no key at or after the upper bound, so the last key of the
+	// bucket is the closest one below it.
+	case k == nil:
+		k, v = c.cursor.Last()
+
+	// Otherwise we landed after the upper bound and step back.
+	case !bytes.Equal(k, maxKey):
+		k, v = c.cursor.Prev()
+	}
+
+	return c.entry(k, v)
+}
+
+// Next moves the cursor to the next sequence number in range.
+func (c *seqIndexCursor) Next() ([]byte, []byte) {
+	return c.entry(c.cursor.Next())
+}
+
+// Prev moves the cursor to the previous sequence number in range.
+func (c *seqIndexCursor) Prev() ([]byte, []byte) {
+	return c.entry(c.cursor.Prev())
+}
+
+// Seek positions the cursor at the given sequence number, or the next one in
+// range if it doesn't exist.
+func (c *seqIndexCursor) Seek(seek []byte) ([]byte, []byte) {
+	seq := byteOrder.Uint64(seek)
+	if seq < c.minSeq {
+		seq = c.minSeq
+	}
+
+	return c.entry(c.cursor.Seek(c.key(seq)))
+}
+
+// timeIndexSeqRange returns the range of sequence numbers of the payments
+// created within the given time range, scanning the keys of the time index.
+// False is returned if no payment was created within the range.
+func timeIndexSeqRange(timeIndex kvdb.RBucket, start,
+	end time.Time) (uint64, uint64, bool) {
+
+	startKey := timeIndexKey(start, make([]byte, 8))
+
+	endNano := uint64(math.MaxUint64)
+	if !end.IsZero() {
+		endNano = uint64(end.UnixNano())
+	}
+
+	var (
+		minSeq uint64 = math.MaxUint64
+		maxSeq uint64
+		found  bool
+		cursor = timeIndex.ReadCursor()
+	)
+	for k, _ := cursor.Seek(startKey); k != nil; k, _ = cursor.Next() {
+		if byteOrder.Uint64(k[:8]) > endNano {
+			break
+		}
+
+		seq := byteOrder.Uint64(k[8:])
+		if seq < minSeq {
+			minSeq = seq
+		}
+		if seq > maxSeq {
+			maxSeq = seq
+		}
+		found = true
+	}
+
+	return minSeq, maxSeq, found
+}
@@ -0,0 +1,102 @@
+package channeldb
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lntypes"
+)
+
+var (
+	// paymentMetricsKey is a key used in the payment's sub-bucket to store
+	// the metrics recorded while sending the payment.
+	paymentMetricsKey = []byte("payment-metrics")
+)
+
+// PaymentMetrics holds metrics about the time it took to send a payment.
+type PaymentMetrics struct {
+	// NumAttempts is the number of htlc attempts that were launched.
+	NumAttempts uint32
+
+	// PathfindingTime is the total time spent finding routes.
+	PathfindingTime time.Duration
+
+	// ResultWaitTime is the total time spent waiting for the results of
+	// htlc attempts.
+	ResultWaitTime time.Duration
+}
+
+// add adds the given metrics to the metrics.
+func (m *PaymentMetrics) add(other *PaymentMetrics) {
+	m.NumAttempts += other.NumAttempts
+	m.PathfindingTime += other.PathfindingTime
+	m.ResultWaitTime += other.ResultWaitTime
+}
+
+func serializePaymentMetrics(w io.Writer, m *PaymentMetrics) error {
+	return WriteElements(
+		w, m.NumAttempts, uint64(m.PathfindingTime),
+		uint64(m.ResultWaitTime),
+	)
+}
+
+func deserializePaymentMetrics(r io.Reader) (*PaymentMetrics, error) {
+	var (
+		m                               PaymentMetrics
+		pathfindingTime, resultWaitTime uint64
+	)
+	err := ReadElements(
+		r, &m.NumAttempts, &pathfindingTime, &resultWaitTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m.PathfindingTime = time.Duration(pathfindingTime)
+	m.ResultWaitTime = time.Duration(resultWaitTime)
+
+	return &m, nil
+}
+
+// fetchPaymentMetrics returns the metrics stored in the given payment bucket,
+// or nil if no metrics were recorded.
+func fetchPaymentMetrics(bucket kvdb.RBucket) (*PaymentMetrics, error) {
+	b := bucket.Get(paymentMetricsKey)
+	if b == nil {
+		return nil, nil
+	}
+
+	return deserializePaymentMetrics(bytes.NewReader(b))
+}
+
+// AddPaymentMetrics adds the given metrics to the metrics stored for the
+// payment. Metrics are added rather than replaced, so that the metrics of a
+// payment that is resumed after a restart cover all of its attempts.
+func (p *PaymentControl) AddPaymentMetrics(paymentHash lntypes.Hash,
+	metrics *PaymentMetrics) error {
+
+	return kvdb.Update(p.db.Backend, func(tx kvdb.RwTx) error {
+		bucket, err := fetchPaymentBucketUpdate(tx, paymentHash)
+		if err != nil {
+			return err
+		}
+
+		total, err := fetchPaymentMetrics(bucket)
+		if err != nil {
+			return err
+		}
+		if total == nil {
+			total = &PaymentMetrics{}
+		}
+		total.add(metrics)
+
+		var b bytes.Buffer
+		if err := serializePaymentMetrics(&b, total); err != nil {
+			return err
+		}
+
+		return bucket.Put(paymentMetricsKey, b.Bytes())
+	}, func() {})
+}
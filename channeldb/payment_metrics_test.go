@@ -0,0 +1,63 @@
+package channeldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestPaymentMetrics tests that payment metrics add up, and that they are
+// reset when a failed payment is retried.
+func TestPaymentMetrics(t *testing.T) {
+	t.Parallel()
+
+	db, err := MakeTestDB(t)
+	require.NoError(t, err, "unable to init db")
+
+	pControl := NewPaymentControl(db)
+
+	info, _, _, err := genInfo()
+	require.NoError(t, err, "unable to generate htlc message")
+
+	// Metrics can't be added to unknown payments.
+	err = pControl.AddPaymentMetrics(
+		info.PaymentIdentifier, &PaymentMetrics{},
+	)
+	require.ErrorIs(t, err, ErrPaymentNotInitiated)
+
+	require.NoError(t, pControl.InitPayment(info.PaymentIdentifier, info))
+
+	payment, err := pControl.FetchPayment(info.PaymentIdentifier)
+	require.NoError(t, err)
+	require.Nil(t, payment.Metrics)
+
+	metrics := &PaymentMetrics{
+		NumAttempts:     2,
+		PathfindingTime: time.Millisecond,
+		ResultWaitTime:  time.Second,
+	}
+	for i := 0; i < 2; i++ {
+		err := pControl.AddPaymentMetrics(
+			info.PaymentIdentifier, metrics,
+		)
+		require.NoError(t, err)
+	}
+
+	payment, err = pControl.FetchPayment(info.PaymentIdentifier)
+	require.NoError(t, err)
+	require.Equal(t, &PaymentMetrics{
+		NumAttempts:     4,
+		PathfindingTime: 2 * time.Millisecond,
+		ResultWaitTime:  2 * time.Second,
+	}, payment.Metrics)
+
+	// Once the payment failed and is retried, its metrics start over.
+	_, err = pControl.Fail(info.PaymentIdentifier, FailureReasonNoRoute)
+	require.NoError(t, err)
+	require.NoError(t, pControl.InitPayment(info.PaymentIdentifier, info))
+
+	payment, err = pControl.FetchPayment(info.PaymentIdentifier)
+	require.NoError(t, err)
+	require.Nil(t, payment.Metrics)
+}
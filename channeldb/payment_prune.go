@@ -0,0 +1,333 @@
+package channeldb
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// paymentsPruneBucket is the name of the top-level bucket that stores
+	// the time index key up to which PrunePayments has finished walking
+	// the payments, per combination of the kinds of pruning it performs.
+	// payments-prune-bucket
+	// 	|--<prune flags>: <time index key>
+	// 	|--...
+	paymentsPruneBucket = []byte("payments-prune-bucket")
+)
+
+const (
+	// pruneFailedPayments is the prune flag set if failed payments are
+	// deleted.
+	pruneFailedPayments byte = 1 << 0
+
+	// pruneFailedHtlcs is the prune flag set if the failed htlc attempts
+	// of succeeded payments are deleted.
+	pruneFailedHtlcs byte = 1 << 1
+)
+
+// PaymentPruneQuery determines which payments are deleted by PrunePayments.
+type PaymentPruneQuery struct {
+	// FailedBefore, if set, deletes all failed payments that were created
+	// before it.
+	FailedBefore time.Time
+
+	// FailedHtlcsBefore, if set, deletes the failed htlc attempts of all
+	// succeeded payments that were created before it.
+	FailedHtlcsBefore time.Time
+
+	// MaxPayments is the maximum number of payments that are pruned in a
+	// single call, which limits the size of the database transaction. If
+	// zero, all selected payments are pruned at once.
+	MaxPayments uint64
+
+	// Archive, if set, is called with the pruned payments before they are
+	// deleted. Payments of which only the failed htlc attempts are
+	// deleted only contain those attempts. If an error is returned,
+	// nothing is deleted. It is called outside of any database
+	// transaction, so it is called once per selection of payments.
+	Archive func([]*MPPayment) error
+}
+
+// prunedPayment is a payment that is selected for pruning.
+type prunedPayment struct {
+	// payment is the payment, only containing the htlc attempts that are
+	// pruned if the payment itself isn't deleted.
+	payment *MPPayment
+
+	// failedHtlcs are the IDs of the htlc attempts to delete. If nil, the
+	// whole payment is deleted.
+	failedHtlcs [][]byte
+}
+
+// pruneKey returns the key of the resume point of the query within the
+// payments prune bucket. Each combination of the kinds of pruning has its own
+// resume point, as a payment that is done for one of them may still need to
+// be pruned by another.
+func (q *PaymentPruneQuery) pruneKey() []byte {
+	var flags byte
+	if !q.FailedBefore.IsZero() {
+		flags |= pruneFailedPayments
+	}
+	if !q.FailedHtlcsBefore.IsZero() {
+		flags |= pruneFailedHtlcs
+	}
+
+	return []byte{flags}
+}
+
+// PrunePayments deletes old failed payments and the failed htlc attempts of
+// old succeeded payments, as selected by the query, walking the payments in
+// the order of their creation time. It returns the number of payments that
+// were pruned, which is at most the MaxPayments of the query. Legacy
+// duplicate payments aren't pruned.
+//
+// The walk resumes after the last payment of the previous walks that can't be
+// pruned any further, so payments that remain in the database after being
+// pruned aren't read again. This assumes that new payments are created with
+// an increasing creation time.
+//
+// NOTE: Calls must not be made concurrently, as they would select and archive
+// the same payments.
+func (d *DB) PrunePayments(query PaymentPruneQuery) (uint64, error) {
+	// Payments are looked at up to the later of both cutoffs.
+	cutoff := query.FailedBefore
+	if query.FailedHtlcsBefore.After(cutoff) {
+		cutoff = query.FailedHtlcsBefore
+	}
+	if cutoff.IsZero() {
+		return 0, nil
+	}
+
+	// The payments are selected in a read transaction, so they are
+	// archived only once, and not again whenever the transaction that
+	// deletes them is retried.
+	var (
+		pruneKey  = query.pruneKey()
+		pruned    []*prunedPayment
+		resumeKey []byte
+	)
+	err := kvdb.View(d, func(tx kvdb.RTx) error {
+		var startKey []byte
+		pruneBucket := tx.ReadBucket(paymentsPruneBucket)
+		if pruneBucket != nil {
+			startKey = pruneBucket.Get(pruneKey)
+		}
+
+		var err error
+		pruned, resumeKey, err = selectPrunedPayments(
+			tx, query, cutoff, startKey,
+		)
+
+		return err
+	}, func() {
+		pruned = nil
+		resumeKey = nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(pruned) > 0 && query.Archive != nil {
+		payments := make([]*MPPayment, len(pruned))
+		for i, p := range pruned {
+			payments[i] = p.payment
+		}
+
+		if err := query.Archive(payments); err != nil {
+			return 0, err
+		}
+	}
+
+	var numPruned uint64
+	err = kvdb.Update(d, func(tx kvdb.RwTx) error {
+		numPruned = 0
+
+		pruneBucket, err := tx.CreateTopLevelBucket(
+			paymentsPruneBucket,
+		)
+		if err != nil {
+			return err
+		}
+
+		if resumeKey != nil {
+			err := pruneBucket.Put(pruneKey, resumeKey)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, p := range pruned {
+			deleted, err := deletePrunedPayment(tx, p)
+			if err != nil {
+				return err
+			}
+
+			if deleted {
+				numPruned++
+			}
+		}
+
+		return nil
+	}, func() {
+		numPruned = 0
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return numPruned, nil
+}
+
+// deletePrunedPayment deletes the given pruned payment, or its failed htlc
+// attempts. As the payment was selected in an earlier transaction, it is only
+// deleted if it wasn't initiated again since then, and false is returned
+// otherwise.
+func deletePrunedPayment(tx kvdb.RwTx, p *prunedPayment) (bool, error) {
+	hash := p.payment.Info.PaymentIdentifier
+	bucket, err := fetchPaymentBucketUpdate(tx, hash)
+	switch {
+	case err == ErrPaymentNotInitiated:
+		return false, nil
+
+	case err != nil:
+		return false, err
+	}
+
+	var seqNum [8]byte
+	byteOrder.PutUint64(seqNum[:], p.payment.SequenceNum)
+	if !bytes.Equal(bucket.Get(paymentSequenceKey), seqNum[:]) {
+		log.Debugf("Not pruning payment %v, as it was initiated "+
+			"again", hash)
+
+		return false, nil
+	}
+
+	if p.failedHtlcs == nil {
+		return true, deletePaymentBucket(tx, hash[:])
+	}
+
+	return true, deleteHtlcAttempts(bucket, p.failedHtlcs)
+}
+
+// selectPrunedPayments walks the time index from after the given resume key up
+// to the cutoff and returns the payments that are pruned by the query. It also
+// returns the key of the last payment of the walk up to which no payment can
+// be pruned any further by queries of the same kind, or nil if the resume
+// point doesn't advance.
+func selectPrunedPayments(tx kvdb.RTx, query PaymentPruneQuery,
+	cutoff time.Time, resumeKey []byte) ([]*prunedPayment, []byte, error) {
+
+	timeIndex := tx.ReadBucket(paymentsTimeIndexBucket)
+	if timeIndex == nil {
+		return nil, nil, errNoPaymentIndex
+	}
+
+	var (
+		pruned    []*prunedPayment
+		cutoffKey = timeIndexKey(cutoff, make([]byte, 8))
+		cursor    = timeIndex.ReadCursor()
+
+		// newResumeKey is the key of the last payment of the walk
+		// that, along with all payments before it, is done.
+		newResumeKey []byte
+		blocked      bool
+	)
+
+	k, v := cursor.First()
+	if resumeKey != nil {
+		k, v = cursor.Seek(resumeKey)
+		if bytes.Equal(k, resumeKey) {
+			k, v = cursor.Next()
+		}
+	}
+
+	for ; k != nil; k, v = cursor.Next() {
+		if bytes.Compare(k, cutoffKey) >= 0 {
+			break
+		}
+
+		if query.MaxPayments != 0 &&
+			uint64(len(pruned)) >= query.MaxPayments {
+
+			break
+		}
+
+		paymentHash, err := deserializePaymentIndex(
+			bytes.NewReader(v),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		payment, err := fetchPaymentWithSequenceNumber(
+			tx, paymentHash, k[8:],
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// A payment is done once it was pruned or, as it is in a final
+		// state, this kind of query will never prune it. Payments that
+		// still have htlc attempts in flight or aren't old enough yet
+		// may need to be pruned later on.
+		var done bool
+		creationTime := payment.Info.CreationTime
+		succeeded := payment.Status == StatusSucceeded
+
+		switch {
+		case payment.Status == StatusFailed &&
+			creationTime.Before(query.FailedBefore):
+
+			pruned = append(pruned, &prunedPayment{
+				payment: payment,
+			})
+			done = true
+
+		case payment.Status == StatusFailed:
+			done = query.FailedBefore.IsZero()
+
+		case succeeded && creationTime.Before(query.FailedHtlcsBefore):
+			done = len(payment.InFlightHTLCs()) == 0
+
+			var (
+				failedHtlcs []HTLCAttempt
+				htlcIDs     [][]byte
+			)
+			for _, htlc := range payment.HTLCs {
+				if htlc.Failure == nil {
+					continue
+				}
+
+				failedHtlcs = append(failedHtlcs, htlc)
+
+				var aid [8]byte
+				byteOrder.PutUint64(aid[:], htlc.AttemptID)
+				htlcIDs = append(htlcIDs, aid[:])
+			}
+
+			if len(failedHtlcs) == 0 {
+				break
+			}
+
+			payment.HTLCs = failedHtlcs
+			pruned = append(pruned, &prunedPayment{
+				payment:     payment,
+				failedHtlcs: htlcIDs,
+			})
+
+		case succeeded:
+			done = query.FailedHtlcsBefore.IsZero()
+		}
+
+		if !done {
+			blocked = true
+		}
+		if !blocked {
+			newResumeKey = append([]byte(nil), k...)
+		}
+	}
+
+	return pruned, newResumeKey, nil
+}
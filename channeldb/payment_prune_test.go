@@ -0,0 +1,336 @@
+package channeldb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPrunePayments tests that old failed payments and the failed htlc
+// attempts of old succeeded payments are pruned and archived.
+func TestPrunePayments(t *testing.T) {
+	t.Parallel()
+
+	db, err := MakeTestDB(t)
+	require.NoError(t, err)
+
+	pControl := NewPaymentControl(db)
+
+	// Create a failed, a succeeded and an in-flight payment per hour. The
+	// succeeded payments have a failed htlc attempt as well.
+	startTime := time.Unix(1_000_000, 0)
+	var failed, succeeded []lntypes.Hash
+	for i := 0; i < 3; i++ {
+		creationTime := startTime.Add(time.Duration(i) * time.Hour)
+
+		for _, status := range []PaymentStatus{
+			StatusFailed, StatusSucceeded, StatusInFlight,
+		} {
+			info, attempt, preimg, err := genInfo()
+			require.NoError(t, err)
+
+			hash := info.PaymentIdentifier
+			info.CreationTime = creationTime
+			require.NoError(t, pControl.InitPayment(hash, info))
+
+			_, err = pControl.RegisterAttempt(hash, attempt)
+			require.NoError(t, err)
+
+			if status == StatusInFlight {
+				continue
+			}
+
+			_, err = pControl.FailAttempt(
+				hash, attempt.AttemptID,
+				&HTLCFailInfo{Reason: HTLCFailUnreadable},
+			)
+			require.NoError(t, err)
+
+			if status == StatusFailed {
+				_, err = pControl.Fail(
+					hash, FailureReasonNoRoute,
+				)
+				require.NoError(t, err)
+
+				failed = append(failed, hash)
+				continue
+			}
+
+			attempt.AttemptID++
+			_, err = pControl.RegisterAttempt(hash, attempt)
+			require.NoError(t, err)
+
+			_, err = pControl.SettleAttempt(
+				hash, attempt.AttemptID,
+				&HTLCSettleInfo{Preimage: preimg},
+			)
+			require.NoError(t, err)
+
+			succeeded = append(succeeded, hash)
+		}
+	}
+
+	// A failing archive prevents the deletion.
+	errArchive := errors.New("archive failed")
+	_, err = db.PrunePayments(PaymentPruneQuery{
+		FailedBefore: startTime.Add(2 * time.Hour),
+		Archive: func([]*MPPayment) error {
+			return errArchive
+		},
+	})
+	require.ErrorIs(t, err, errArchive)
+
+	payments, err := db.FetchPayments()
+	require.NoError(t, err)
+	require.Len(t, payments, 9)
+
+	// Prune the failed payments of the first two hours, one at a time.
+	var archived []*MPPayment
+	query := PaymentPruneQuery{
+		FailedBefore: startTime.Add(2 * time.Hour),
+		MaxPayments:  1,
+		Archive: func(payments []*MPPayment) error {
+			archived = append(archived, payments...)
+			return nil
+		},
+	}
+	for i := 0; i < 2; i++ {
+		numPruned, err := db.PrunePayments(query)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, numPruned)
+	}
+
+	numPruned, err := db.PrunePayments(query)
+	require.NoError(t, err)
+	require.Zero(t, numPruned)
+
+	require.Len(t, archived, 2)
+	for i, payment := range archived {
+		require.Equal(t, failed[i], payment.Info.PaymentIdentifier)
+		require.Equal(t, StatusFailed, payment.Status)
+	}
+
+	for i, hash := range failed {
+		_, err := pControl.FetchPayment(hash)
+		if i < 2 {
+			require.ErrorIs(t, err, ErrPaymentNotInitiated)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+
+	// Prune the failed htlc attempts of the succeeded payments of the
+	// first hour. The payment itself remains.
+	archived = nil
+	numPruned, err = db.PrunePayments(PaymentPruneQuery{
+		FailedHtlcsBefore: startTime.Add(time.Hour),
+		Archive: func(payments []*MPPayment) error {
+			archived = append(archived, payments...)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, numPruned)
+
+	require.Len(t, archived, 1)
+	require.Len(t, archived[0].HTLCs, 1)
+	require.NotNil(t, archived[0].HTLCs[0].Failure)
+
+	payment, err := pControl.FetchPayment(succeeded[0])
+	require.NoError(t, err)
+	require.Equal(t, StatusSucceeded, payment.Status)
+	require.Len(t, payment.HTLCs, 1)
+	require.NotNil(t, payment.HTLCs[0].Settle)
+
+	// In-flight payments are never pruned.
+	_, err = db.PrunePayments(PaymentPruneQuery{
+		FailedBefore:      startTime.Add(10 * time.Hour),
+		FailedHtlcsBefore: startTime.Add(10 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	payments, err = db.FetchPayments()
+	require.NoError(t, err)
+	require.Len(t, payments, 6)
+	for _, payment := range payments {
+		require.NotEqual(t, StatusFailed, payment.Status)
+		for _, htlc := range payment.HTLCs {
+			require.Nil(t, htlc.Failure)
+		}
+	}
+}
+
+// TestPrunePaymentsResume tests that pruning resumes after the payments that
+// can't be pruned any further, and that payments still in flight hold back the
+// resume point until they are pruned.
+func TestPrunePaymentsResume(t *testing.T) {
+	t.Parallel()
+
+	db, err := MakeTestDB(t)
+	require.NoError(t, err)
+
+	pControl := NewPaymentControl(db)
+
+	// Create three payments an hour apart, each with a failed htlc
+	// attempt. The first and the last one succeed, the second one remains
+	// in flight.
+	startTime := time.Unix(1_000_000, 0)
+	var (
+		hashes   []lntypes.Hash
+		attempts []*HTLCAttemptInfo
+		preimgs  []lntypes.Preimage
+	)
+	for i := 0; i < 3; i++ {
+		info, attempt, preimg, err := genInfo()
+		require.NoError(t, err)
+
+		hash := info.PaymentIdentifier
+		info.CreationTime = startTime.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, pControl.InitPayment(hash, info))
+
+		_, err = pControl.RegisterAttempt(hash, attempt)
+		require.NoError(t, err)
+
+		_, err = pControl.FailAttempt(
+			hash, attempt.AttemptID,
+			&HTLCFailInfo{Reason: HTLCFailUnreadable},
+		)
+		require.NoError(t, err)
+
+		attempt.AttemptID++
+		_, err = pControl.RegisterAttempt(hash, attempt)
+		require.NoError(t, err)
+
+		hashes = append(hashes, hash)
+		attempts = append(attempts, attempt)
+		preimgs = append(preimgs, preimg)
+	}
+
+	settle := func(i int) {
+		_, err := pControl.SettleAttempt(
+			hashes[i], attempts[i].AttemptID,
+			&HTLCSettleInfo{Preimage: preimgs[i]},
+		)
+		require.NoError(t, err)
+	}
+	settle(0)
+	settle(2)
+
+	// resumeKey returns the stored resume point of the query.
+	resumeKey := func(query PaymentPruneQuery) []byte {
+		var key []byte
+		err := kvdb.View(db, func(tx kvdb.RTx) error {
+			bucket := tx.ReadBucket(paymentsPruneBucket)
+			if bucket == nil {
+				return nil
+			}
+
+			key = bucket.Get(query.pruneKey())
+
+			return nil
+		}, func() {
+			key = nil
+		})
+		require.NoError(t, err)
+
+		return key
+	}
+
+	// paymentKey returns the time index key of the given payment.
+	paymentKey := func(i int) []byte {
+		payment, err := pControl.FetchPayment(hashes[i])
+		require.NoError(t, err)
+
+		var seqNum [8]byte
+		byteOrder.PutUint64(seqNum[:], payment.SequenceNum)
+
+		return timeIndexKey(payment.Info.CreationTime, seqNum[:])
+	}
+
+	// The failed attempts of both succeeded payments are pruned, but the
+	// payment in flight holds back the resume point.
+	query := PaymentPruneQuery{
+		FailedHtlcsBefore: startTime.Add(10 * time.Hour),
+	}
+	numPruned, err := db.PrunePayments(query)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, numPruned)
+	require.Equal(t, paymentKey(0), resumeKey(query))
+
+	// Queries pruning other kinds of payments don't share the resume
+	// point.
+	require.Nil(t, resumeKey(PaymentPruneQuery{
+		FailedBefore: startTime.Add(10 * time.Hour),
+	}))
+
+	// Once the second payment succeeds, its failed attempt is pruned and
+	// the resume point moves past all payments.
+	settle(1)
+
+	numPruned, err = db.PrunePayments(query)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, numPruned)
+	require.Equal(t, paymentKey(2), resumeKey(query))
+
+	for _, hash := range hashes {
+		payment, err := pControl.FetchPayment(hash)
+		require.NoError(t, err)
+		require.Len(t, payment.HTLCs, 1)
+		require.NotNil(t, payment.HTLCs[0].Settle)
+	}
+
+	numPruned, err = db.PrunePayments(query)
+	require.NoError(t, err)
+	require.Zero(t, numPruned)
+}
+
+// TestPrunePaymentsReinitiated tests that a payment that is initiated again
+// while the pruned payments are archived isn't deleted.
+func TestPrunePaymentsReinitiated(t *testing.T) {
+	t.Parallel()
+
+	db, err := MakeTestDB(t)
+	require.NoError(t, err)
+
+	pControl := NewPaymentControl(db)
+
+	info, attempt, _, err := genInfo()
+	require.NoError(t, err)
+
+	hash := info.PaymentIdentifier
+	info.CreationTime = time.Unix(1_000_000, 0)
+	require.NoError(t, pControl.InitPayment(hash, info))
+
+	_, err = pControl.RegisterAttempt(hash, attempt)
+	require.NoError(t, err)
+	_, err = pControl.FailAttempt(
+		hash, attempt.AttemptID,
+		&HTLCFailInfo{Reason: HTLCFailUnreadable},
+	)
+	require.NoError(t, err)
+	_, err = pControl.Fail(hash, FailureReasonNoRoute)
+	require.NoError(t, err)
+
+	// The failed payment is paid again while it is being archived, so
+	// the archived payment is no longer the one in the database.
+	var archived []*MPPayment
+	numPruned, err := db.PrunePayments(PaymentPruneQuery{
+		FailedBefore: info.CreationTime.Add(time.Hour),
+		Archive: func(payments []*MPPayment) error {
+			archived = append(archived, payments...)
+			return pControl.InitPayment(hash, info)
+		},
+	})
+	require.NoError(t, err)
+	require.Zero(t, numPruned)
+	require.Len(t, archived, 1)
+
+	payment, err := pControl.FetchPayment(hash)
+	require.NoError(t, err)
+	require.Equal(t, StatusInFlight, payment.Status)
+	require.NotEqual(t, archived[0].SequenceNum, payment.SequenceNum)
+}
@@ -32,6 +32,8 @@ var (
 	//      |        |--sequence-key: <sequence number>
 	//      |        |--creation-info-key: <creation info>
 	//      |        |--fail-info-key: <(optional) fail info>
+	//      |        |--payment-destination: <(optional) destination>
+	//      |        |--payment-metrics: <(optional) metrics>
 	//      |        |
 	//      |        |--payment-htlcs-bucket (shard-bucket)
 	//      |        |        |
@@ -335,6 +337,11 @@ func fetchPayment(bucket kvdb.RBucket) (*MPPayment, error) {
 		failureReason = &reason
 	}
 
+	metrics, err := fetchPaymentMetrics(bucket)
+	if err != nil {
+		return nil, err
+	}
+
 	// Go through all HTLCs for this payment, noting whether we have any
 	// settled HTLC, and any still in-flight.
 	var inflight, settled bool
@@ -378,6 +385,7 @@ func fetchPayment(bucket kvdb.RBucket) (*MPPayment, error) {
 		HTLCs:         htlcs,
 		FailureReason: failureReason,
 		Status:        paymentStatus,
+		Metrics:       metrics,
 	}, nil
 }
 
@@ -534,8 +542,170 @@ type PaymentsQuery struct {
 	IncludeIncomplete bool
 
 	// CountTotal indicates that all payments currently present in the
-	// payment index (complete and incomplete) should be counted.
+	// payment index (complete and incomplete) should be counted. If any of
+	// the filters below are set, only the matching payments are counted.
 	CountTotal bool
+
+	// CreationDateStart, if set, filters out all payments that were
+	// created before it.
+	CreationDateStart time.Time
+
+	// CreationDateEnd, if set, filters out all payments that were created
+	// after it.
+	CreationDateEnd time.Time
+
+	// Statuses, if set, filters out all payments that don't have one of
+	// the given statuses. IncludeIncomplete is ignored if it is set.
+	Statuses []PaymentStatus
+
+	// Destination, if set, filters out all payments that aren't sent to
+	// the given node. Payments without any htlc attempt don't have a
+	// destination yet.
+	Destination *route.Vertex
+
+	// FeeStats indicates that statistics about the amounts and fees of
+	// all matching payments should be computed.
+	FeeStats bool
+}
+
+// hasFilters returns true if the query restricts the payments returned beyond
+// the IncludeIncomplete flag.
+func (q *PaymentsQuery) hasFilters() bool {
+	return !q.CreationDateStart.IsZero() || !q.CreationDateEnd.IsZero() ||
+		len(q.Statuses) > 0 || q.Destination != nil
+}
+
+// matches returns true if the payment passes the filters of the query. The
+// destination filter is applied by the choice of index instead.
+func (q *PaymentsQuery) matches(payment *MPPayment) bool {
+	if len(q.Statuses) > 0 {
+		found := false
+		for _, status := range q.Statuses {
+			if payment.Status == status {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	} else if payment.Status != StatusSucceeded && !q.IncludeIncomplete {
+		// To keep compatibility with the old API, we only return
+		// non-succeeded payments if requested.
+		return false
+	}
+
+	creationTime := payment.Info.CreationTime
+	if !q.CreationDateStart.IsZero() &&
+		creationTime.Before(q.CreationDateStart) {
+
+		return false
+	}
+
+	if !q.CreationDateEnd.IsZero() &&
+		creationTime.After(q.CreationDateEnd) {
+
+		return false
+	}
+
+	return true
+}
+
+// indexCursor returns a cursor over the sequence numbers of the candidate
+// payments of the query, using the most selective payment index. False is
+// returned if no payment can match the query.
+func (q *PaymentsQuery) indexCursor(tx kvdb.RTx,
+	indexes kvdb.RBucket) (*seqIndexCursor, bool, error) {
+
+	var cursor *seqIndexCursor
+	switch {
+	case q.Destination != nil:
+		destIndex := tx.ReadBucket(paymentsDestIndexBucket)
+		if destIndex == nil {
+			return nil, false, errNoPaymentIndex
+		}
+		cursor = newSeqIndexCursor(destIndex, q.Destination[:])
+
+	case len(q.Statuses) == 1:
+		statusIndex := tx.ReadBucket(paymentsStatusIndexBucket)
+		if statusIndex == nil {
+			return nil, false, errNoPaymentIndex
+		}
+		cursor = newSeqIndexCursor(
+			statusIndex, []byte{byte(q.Statuses[0])},
+		)
+
+	default:
+		cursor = newSeqIndexCursor(indexes, nil)
+	}
+
+	if q.CreationDateStart.IsZero() && q.CreationDateEnd.IsZero() {
+		return cursor, true, nil
+	}
+
+	// Payments are created in the order of their sequence numbers, so the
+	// time range limits the range of sequence numbers that needs to be
+	// looked at. The creation time of each candidate is still checked, as
+	// the clock isn't guaranteed to be monotonic.
+	timeIndex := tx.ReadBucket(paymentsTimeIndexBucket)
+	if timeIndex == nil {
+		return nil, false, errNoPaymentIndex
+	}
+
+	minSeq, maxSeq, ok := timeIndexSeqRange(
+		timeIndex, q.CreationDateStart, q.CreationDateEnd,
+	)
+	cursor.minSeq, cursor.maxSeq = minSeq, maxSeq
+
+	return cursor, ok, nil
+}
+
+// PaymentFeeStats summarizes the payments that match a payments query.
+type PaymentFeeStats struct {
+	// NumSucceeded is the number of matching payments that succeeded.
+	NumSucceeded uint64
+
+	// NumFailed is the number of matching payments that failed.
+	NumFailed uint64
+
+	// NumInFlight is the number of matching payments that are in flight.
+	NumInFlight uint64
+
+	// AmountSent is the total amount that the matching succeeded payments
+	// delivered to their destinations, excluding fees.
+	AmountSent lnwire.MilliSatoshi
+
+	// FeesPaid is the total amount of fees paid for the matching succeeded
+	// payments.
+	FeesPaid lnwire.MilliSatoshi
+
+	// MaxFeePaid is the highest fee paid for a single matching succeeded
+	// payment.
+	MaxFeePaid lnwire.MilliSatoshi
+}
+
+// addPayment adds a payment to the statistics.
+func (s *PaymentFeeStats) addPayment(payment *MPPayment) {
+	switch payment.Status {
+	case StatusSucceeded:
+		s.NumSucceeded++
+
+	case StatusFailed:
+		s.NumFailed++
+		return
+
+	default:
+		s.NumInFlight++
+		return
+	}
+
+	sent, fees := payment.SentAmt()
+	s.AmountSent += sent
+	s.FeesPaid += fees
+	if fees > s.MaxFeePaid {
+		s.MaxFeePaid = fees
+	}
 }
 
 // PaymentsResponse contains the result of a query to the payments database.
@@ -561,9 +731,15 @@ type PaymentsResponse struct {
 	LastIndexOffset uint64
 
 	// TotalCount represents the total number of payments that are currently
-	// stored in the payment database. This will only be set if the
-	// CountTotal field in the query was set to true.
+	// stored in the payment database, or the number of matching payments
+	// if the query has filters. This will only be set if the CountTotal
+	// field in the query was set to true.
 	TotalCount uint64
+
+	// FeeStats summarizes all payments that match the query, independent
+	// of the maximum number of payments returned. This will only be set if
+	// the FeeStats field in the query was set to true.
+	FeeStats *PaymentFeeStats
 }
 
 // QueryPayments is a query to the payments database which is restricted
@@ -587,31 +763,55 @@ func (d *DB) QueryPayments(query PaymentsQuery) (PaymentsResponse, error) {
 			return fmt.Errorf("index bucket does not exist")
 		}
 
-		// accumulatePayments gets payments with the sequence number
-		// and hash provided and adds them to our list of payments if
-		// they meet the criteria of our query. It returns the number
-		// of payments that were added.
-		accumulatePayments := func(sequenceKey, hash []byte) (bool,
-			error) {
+		// Pick the index to iterate over. If no payment can match the
+		// query, we're done.
+		cursor, ok, err := query.indexCursor(tx, indexes)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if query.FeeStats {
+				resp.FeeStats = &PaymentFeeStats{}
+			}
+
+			return nil
+		}
+
+		// fetchMatchingPayment gets the payment with the sequence
+		// number and hash provided, returning nil if it doesn't meet
+		// the criteria of our query.
+		fetchMatchingPayment := func(sequenceKey, hash []byte) (
+			*MPPayment, error) {
 
 			r := bytes.NewReader(hash)
 			paymentHash, err := deserializePaymentIndex(r)
 			if err != nil {
-				return false, err
+				return nil, err
 			}
 
 			payment, err := fetchPaymentWithSequenceNumber(
 				tx, paymentHash, sequenceKey,
 			)
 			if err != nil {
-				return false, err
+				return nil, err
 			}
 
-			// To keep compatibility with the old API, we only
-			// return non-succeeded payments if requested.
-			if payment.Status != StatusSucceeded &&
-				!query.IncludeIncomplete {
+			if !query.matches(payment) {
+				return nil, nil
+			}
+
+			return payment, nil
+		}
+
+		// accumulatePayments gets payments with the sequence number
+		// and hash provided and adds them to our list of payments if
+		// they meet the criteria of our query. It returns the number
+		// of payments that were added.
+		accumulatePayments := func(sequenceKey, hash []byte) (bool,
+			error) {
 
+			payment, err := fetchMatchingPayment(sequenceKey, hash)
+			if err != nil || payment == nil {
 				return false, err
 			}
 
@@ -624,7 +824,7 @@ func (d *DB) QueryPayments(query PaymentsQuery) (PaymentsResponse, error) {
 		// Create a paginator which reads from our sequence index bucket
 		// with the parameters provided by the payments query.
 		paginator := newPaginator(
-			indexes.ReadCursor(), query.Reversed, query.IndexOffset,
+			cursor, query.Reversed, query.IndexOffset,
 			query.MaxPayments,
 		)
 
@@ -636,7 +836,7 @@ func (d *DB) QueryPayments(query PaymentsQuery) (PaymentsResponse, error) {
 		// Counting the total number of payments is expensive, since we
 		// literally have to traverse the cursor linearly, which can
 		// take quite a while. So it's an optional query parameter.
-		if query.CountTotal {
+		if query.CountTotal && !query.hasFilters() && !query.FeeStats {
 			var (
 				totalPayments uint64
 				err           error
@@ -660,6 +860,39 @@ func (d *DB) QueryPayments(query PaymentsQuery) (PaymentsResponse, error) {
 			}
 
 			resp.TotalCount = totalPayments
+
+			return nil
+		}
+
+		if !query.CountTotal && !query.FeeStats {
+			return nil
+		}
+
+		// With filters or fee statistics, all candidates of the index
+		// need to be fetched. This pass isn't limited by the maximum
+		// number of payments returned.
+		var (
+			totalPayments uint64
+			stats         PaymentFeeStats
+		)
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			payment, err := fetchMatchingPayment(k, v)
+			if err != nil {
+				return err
+			}
+			if payment == nil {
+				continue
+			}
+
+			totalPayments++
+			stats.addPayment(payment)
+		}
+
+		if query.CountTotal {
+			resp.TotalCount = totalPayments
+		}
+		if query.FeeStats {
+			resp.FeeStats = &stats
 		}
 
 		return nil
@@ -805,54 +1038,72 @@ func (d *DB) DeletePayment(paymentHash lntypes.Hash,
 				return err
 			}
 
-			htlcsBucket := bucket.NestedReadWriteBucket(
-				paymentHtlcsBucket,
-			)
+			return deleteHtlcAttempts(bucket, toDelete)
+		}
 
-			for _, htlcID := range toDelete {
-				err = htlcsBucket.Delete(
-					htlcBucketKey(htlcAttemptInfoKey, htlcID),
-				)
-				if err != nil {
-					return err
-				}
+		return deletePaymentBucket(tx, paymentHash[:])
+	}, func() {})
+}
 
-				err = htlcsBucket.Delete(
-					htlcBucketKey(htlcFailInfoKey, htlcID),
-				)
-				if err != nil {
-					return err
-				}
+// deletePaymentBucket deletes the payment with the given hash, including its
+// duplicates, and removes it from all payment indexes.
+func deletePaymentBucket(tx kvdb.RwTx, paymentHash []byte) error {
+	payments := tx.ReadWriteBucket(paymentsRootBucket)
+	bucket := payments.NestedReadBucket(paymentHash)
+	if bucket == nil {
+		return ErrPaymentNotInitiated
+	}
 
-				err = htlcsBucket.Delete(
-					htlcBucketKey(htlcSettleInfoKey, htlcID),
-				)
-				if err != nil {
-					return err
-				}
-			}
+	seqNrs, err := fetchSequenceNumbers(bucket)
+	if err != nil {
+		return err
+	}
 
-			return nil
+	indexKeys, err := fetchPaymentIndexKeys(bucket)
+	if err != nil {
+		return err
+	}
+
+	if err := payments.DeleteNestedBucket(paymentHash); err != nil {
+		return err
+	}
+
+	indexBucket := tx.ReadWriteBucket(paymentsIndexBucket)
+	for _, k := range seqNrs {
+		if err := indexBucket.Delete(k); err != nil {
+			return err
 		}
+	}
 
-		seqNrs, err := fetchSequenceNumbers(bucket)
-		if err != nil {
+	return deletePaymentIndexKeys(tx, indexKeys)
+}
+
+// deleteHtlcAttempts deletes the htlc attempts with the given IDs from the
+// payment found in the given bucket.
+func deleteHtlcAttempts(bucket kvdb.RwBucket, htlcIDs [][]byte) error {
+	htlcsBucket := bucket.NestedReadWriteBucket(paymentHtlcsBucket)
+
+	for _, aid := range htlcIDs {
+		if err := htlcsBucket.Delete(
+			htlcBucketKey(htlcAttemptInfoKey, aid),
+		); err != nil {
 			return err
 		}
 
-		if err := payments.DeleteNestedBucket(paymentHash[:]); err != nil {
+		if err := htlcsBucket.Delete(
+			htlcBucketKey(htlcFailInfoKey, aid),
+		); err != nil {
 			return err
 		}
 
-		indexBucket := tx.ReadWriteBucket(paymentsIndexBucket)
-		for _, k := range seqNrs {
-			if err := indexBucket.Delete(k); err != nil {
-				return err
-			}
+		if err := htlcsBucket.Delete(
+			htlcBucketKey(htlcSettleInfoKey, aid),
+		); err != nil {
+			return err
 		}
+	}
 
-		return nil
-	}, func() {})
+	return nil
 }
 
 // DeletePayments deletes all completed and failed payments from the DB. If
@@ -875,6 +1126,10 @@ func (d *DB) DeletePayments(failedOnly, failedHtlcsOnly bool) error {
 			// payments that need to be deleted.
 			deleteIndexes [][]byte
 
+			// deleteIndexKeys is the set of secondary index keys of
+			// these payments that need to be deleted.
+			deleteIndexKeys []*paymentIndexKeys
+
 			// deleteHtlcs maps a payment hash to the HTLC IDs we
 			// want to delete for that payment.
 			deleteHtlcs = make(map[lntypes.Hash][][]byte)
@@ -936,6 +1191,13 @@ func (d *DB) DeletePayments(failedOnly, failedHtlcsOnly bool) error {
 			}
 
 			deleteIndexes = append(deleteIndexes, seqNrs...)
+
+			indexKeys, err := fetchPaymentIndexKeys(bucket)
+			if err != nil {
+				return err
+			}
+
+			deleteIndexKeys = append(deleteIndexKeys, indexKeys)
 			return nil
 		})
 		if err != nil {
@@ -945,28 +1207,9 @@ func (d *DB) DeletePayments(failedOnly, failedHtlcsOnly bool) error {
 		// Delete the failed HTLC attempts we found.
 		for hash, htlcIDs := range deleteHtlcs {
 			bucket := payments.NestedReadWriteBucket(hash[:])
-			htlcsBucket := bucket.NestedReadWriteBucket(
-				paymentHtlcsBucket,
-			)
-
-			for _, aid := range htlcIDs {
-				if err := htlcsBucket.Delete(
-					htlcBucketKey(htlcAttemptInfoKey, aid),
-				); err != nil {
-					return err
-				}
-
-				if err := htlcsBucket.Delete(
-					htlcBucketKey(htlcFailInfoKey, aid),
-				); err != nil {
-					return err
-				}
-
-				if err := htlcsBucket.Delete(
-					htlcBucketKey(htlcSettleInfoKey, aid),
-				); err != nil {
-					return err
-				}
+			err := deleteHtlcAttempts(bucket, htlcIDs)
+			if err != nil {
+				return err
 			}
 		}
 
@@ -985,6 +1228,12 @@ func (d *DB) DeletePayments(failedOnly, failedHtlcsOnly bool) error {
 			}
 		}
 
+		for _, keys := range deleteIndexKeys {
+			if err := deletePaymentIndexKeys(tx, keys); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	}, func() {})
 }
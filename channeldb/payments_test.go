@@ -507,6 +507,246 @@ func TestQueryPayments(t *testing.T) {
 	}
 }
 
+// TestQueryPaymentsFilters tests that payments can be queried by creation
+// time, status and destination using the secondary payment indexes, and that
+// the indexes are kept up to date when payments are retried and deleted.
+func TestQueryPaymentsFilters(t *testing.T) {
+	t.Parallel()
+
+	db, err := MakeTestDB(t)
+	require.NoError(t, err)
+
+	pControl := NewPaymentControl(db)
+
+	otherDest := route.Vertex{1, 2, 3}
+	otherRoute := testRoute.Copy()
+	otherRoute.Hops[len(otherRoute.Hops)-1].PubKeyBytes = otherDest
+
+	// Create six payments, one per hour, alternating between the two
+	// destinations. The payments are succeeded, failed and in flight in
+	// turn.
+	startTime := time.Unix(1_000_000, 0)
+	laterTime := startTime.Add(10 * time.Hour)
+	hashes := make([]lntypes.Hash, 6)
+	for i := range hashes {
+		info, attempt, preimg, err := genInfo()
+		require.NoError(t, err)
+
+		hash := info.PaymentIdentifier
+		hashes[i] = hash
+
+		info.CreationTime = startTime.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, pControl.InitPayment(hash, info))
+
+		if i%2 == 1 {
+			attempt.Route = *otherRoute.Copy()
+		}
+		_, err = pControl.RegisterAttempt(hash, attempt)
+		require.NoError(t, err)
+
+		switch i % 3 {
+		case 0:
+			_, err = pControl.SettleAttempt(
+				hash, attempt.AttemptID,
+				&HTLCSettleInfo{Preimage: preimg},
+			)
+			require.NoError(t, err)
+
+		case 1:
+			_, err = pControl.FailAttempt(
+				hash, attempt.AttemptID,
+				&HTLCFailInfo{Reason: HTLCFailUnreadable},
+			)
+			require.NoError(t, err)
+
+			_, err = pControl.Fail(hash, FailureReasonNoRoute)
+			require.NoError(t, err)
+		}
+	}
+
+	fees := testRoute.TotalFees()
+	tests := []struct {
+		name  string
+		query PaymentsQuery
+
+		// expectedSeqNrs contains the set of sequence numbers we
+		// expect our query to return.
+		expectedSeqNrs []uint64
+
+		// expectedStats are the expected fee statistics, if requested.
+		expectedStats *PaymentFeeStats
+	}{
+		{
+			name: "failed payments",
+			query: PaymentsQuery{
+				MaxPayments: 10,
+				Statuses:    []PaymentStatus{StatusFailed},
+				CountTotal:  true,
+			},
+			expectedSeqNrs: []uint64{2, 5},
+		},
+		{
+			name: "succeeded and in-flight payments",
+			query: PaymentsQuery{
+				MaxPayments: 10,
+				Statuses: []PaymentStatus{
+					StatusSucceeded, StatusInFlight,
+				},
+				CountTotal: true,
+			},
+			expectedSeqNrs: []uint64{1, 3, 4, 6},
+		},
+		{
+			name: "destination",
+			query: PaymentsQuery{
+				MaxPayments:       10,
+				IncludeIncomplete: true,
+				Destination:       &otherDest,
+				CountTotal:        true,
+			},
+			expectedSeqNrs: []uint64{2, 4, 6},
+		},
+		{
+			name: "destination and status",
+			query: PaymentsQuery{
+				MaxPayments: 10,
+				Destination: &otherDest,
+				Statuses:    []PaymentStatus{StatusSucceeded},
+				CountTotal:  true,
+			},
+			expectedSeqNrs: []uint64{4},
+		},
+		{
+			name: "creation time range",
+			query: PaymentsQuery{
+				MaxPayments:       10,
+				IncludeIncomplete: true,
+				CreationDateStart: startTime.Add(time.Hour),
+				CreationDateEnd:   startTime.Add(3 * time.Hour),
+				CountTotal:        true,
+			},
+			expectedSeqNrs: []uint64{2, 3, 4},
+		},
+		{
+			name: "creation time range, paginated backwards",
+			query: PaymentsQuery{
+				IndexOffset:       4,
+				MaxPayments:       1,
+				Reversed:          true,
+				IncludeIncomplete: true,
+				CreationDateStart: startTime.Add(time.Hour),
+				CountTotal:        true,
+			},
+			expectedSeqNrs: []uint64{3},
+		},
+		{
+			name: "creation time range without payments",
+			query: PaymentsQuery{
+				MaxPayments:       10,
+				IncludeIncomplete: true,
+				CreationDateStart: laterTime,
+				CountTotal:        true,
+				FeeStats:          true,
+			},
+			expectedStats: &PaymentFeeStats{},
+		},
+		{
+			name: "fee stats",
+			query: PaymentsQuery{
+				MaxPayments:       1,
+				IncludeIncomplete: true,
+				FeeStats:          true,
+			},
+			expectedSeqNrs: []uint64{1},
+			expectedStats: &PaymentFeeStats{
+				NumSucceeded: 2,
+				NumFailed:    2,
+				NumInFlight:  2,
+				AmountSent:   2 * testRoute.ReceiverAmt(),
+				FeesPaid:     2 * fees,
+				MaxFeePaid:   fees,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := db.QueryPayments(test.query)
+			require.NoError(t, err)
+
+			var seqNrs []uint64
+			for _, payment := range resp.Payments {
+				seqNrs = append(seqNrs, payment.SequenceNum)
+			}
+			require.Equal(t, test.expectedSeqNrs, seqNrs)
+			require.Equal(t, test.expectedStats, resp.FeeStats)
+
+			if test.query.CountTotal && !test.query.Reversed {
+				require.EqualValues(
+					t, len(test.expectedSeqNrs),
+					resp.TotalCount,
+				)
+			}
+		})
+	}
+
+	// Retrying the second payment moves it to a new sequence number and
+	// to the in-flight status.
+	info, _, _, err := genInfo()
+	require.NoError(t, err)
+	info.PaymentIdentifier = hashes[1]
+	require.NoError(t, pControl.InitPayment(hashes[1], info))
+
+	resp, err := db.QueryPayments(PaymentsQuery{
+		MaxPayments: 10,
+		Statuses:    []PaymentStatus{StatusFailed, StatusInFlight},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Payments, 4)
+	require.EqualValues(t, 7, resp.Payments[3].SequenceNum)
+	require.Equal(t, StatusInFlight, resp.Payments[3].Status)
+
+	// The retried payment has no destination until its first attempt is
+	// registered.
+	resp, err = db.QueryPayments(PaymentsQuery{
+		MaxPayments:       10,
+		IncludeIncomplete: true,
+		Destination:       &otherDest,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Payments, 2)
+
+	// Deleting all completed payments removes them from the secondary
+	// indexes.
+	require.NoError(t, db.DeletePayments(false, false))
+
+	err = kvdb.View(db, func(tx kvdb.RTx) error {
+		for _, index := range [][]byte{
+			paymentsTimeIndexBucket, paymentsStatusIndexBucket,
+		} {
+			var numEntries int
+			err := tx.ReadBucket(index).ForEach(
+				func(_, _ []byte) error {
+					numEntries++
+					return nil
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			// Only the in-flight payments remain, including the
+			// retried one.
+			require.Equal(t, 3, numEntries)
+		}
+
+		return nil
+	}, func() {})
+	require.NoError(t, err)
+}
+
 // TestFetchPaymentWithSequenceNumber tests lookup of payments with their
 // sequence number. It sets up one payment with no duplicates, and another with
 // two duplicates in its duplicates bucket then uses these payments to test the
@@ -0,0 +1,238 @@
+package channeldb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+var (
+	// peerBanBucket is the top-level bucket that stores the nodes and IP
+	// ranges that are banned from connecting to us. Bans are keyed by
+	// their target, which is a node public key or an IP range prefixed by
+	// the type of the ban.
+	peerBanBucket = []byte("peer-bans")
+
+	// ErrPeerBanNotFound is returned when a ban that doesn't exist is
+	// deleted.
+	ErrPeerBanNotFound = errors.New("peer ban not found")
+)
+
+const (
+	// peerBanNodeKey is the key prefix of bans of a node public key.
+	peerBanNodeKey byte = 0
+
+	// peerBanIPNetKey is the key prefix of bans of an IP range.
+	peerBanIPNetKey byte = 1
+)
+
+const (
+	// A set of tlv type definitions used to serialize peer bans.
+	peerBanReasonType    tlv.Type = 0
+	peerBanCreatedAtType tlv.Type = 1
+	peerBanExpiryType    tlv.Type = 2
+)
+
+// PeerBan describes a node or an IP range that is banned from connecting to
+// us. Exactly one of Node and IPNet is set.
+type PeerBan struct {
+	// Node is the public key of the banned node.
+	Node *route.Vertex
+
+	// IPNet is the banned IP range.
+	IPNet *net.IPNet
+
+	// Reason is the free form reason for the ban.
+	Reason string
+
+	// CreatedAt is the time at which the ban was created.
+	CreatedAt time.Time
+
+	// Expiry is the time at which the ban expires. A zero time means the
+	// ban doesn't expire.
+	Expiry time.Time
+}
+
+// Expired returns true if the ban has expired at the given time.
+func (b *PeerBan) Expired(now time.Time) bool {
+	return !b.Expiry.IsZero() && !now.Before(b.Expiry)
+}
+
+// String returns the target of the ban.
+func (b *PeerBan) String() string {
+	if b.Node != nil {
+		return b.Node.String()
+	}
+
+	return b.IPNet.String()
+}
+
+// key returns the key of the ban within the peer ban bucket.
+func (b *PeerBan) key() ([]byte, error) {
+	switch {
+	case b.Node != nil && b.IPNet == nil:
+		return append([]byte{peerBanNodeKey}, b.Node[:]...), nil
+
+	case b.IPNet != nil && b.Node == nil:
+		return append(
+			[]byte{peerBanIPNetKey}, []byte(b.IPNet.String())...,
+		), nil
+
+	default:
+		return nil, errors.New("peer ban must have either a node or " +
+			"an IP range")
+	}
+}
+
+// encode serializes the ban, without its target, to the given writer.
+func (b *PeerBan) encode(w io.Writer) error {
+	reason := []byte(b.Reason)
+	createdAt := uint64(b.CreatedAt.UnixNano())
+
+	var expiry uint64
+	if !b.Expiry.IsZero() {
+		expiry = uint64(b.Expiry.UnixNano())
+	}
+
+	tlvStream, err := tlv.NewStream(
+		tlv.MakePrimitiveRecord(peerBanReasonType, &reason),
+		tlv.MakePrimitiveRecord(peerBanCreatedAtType, &createdAt),
+		tlv.MakePrimitiveRecord(peerBanExpiryType, &expiry),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tlvStream.Encode(w)
+}
+
+// decodePeerBan deserializes the ban with the given key from the given reader.
+func decodePeerBan(k []byte, r io.Reader) (*PeerBan, error) {
+	var (
+		b         PeerBan
+		reason    []byte
+		createdAt uint64
+		expiry    uint64
+	)
+
+	if len(k) == 0 {
+		return nil, errors.New("empty peer ban key")
+	}
+
+	switch k[0] {
+	case peerBanNodeKey:
+		node, err := route.NewVertexFromBytes(k[1:])
+		if err != nil {
+			return nil, err
+		}
+		b.Node = &node
+
+	case peerBanIPNetKey:
+		_, ipNet, err := net.ParseCIDR(string(k[1:]))
+		if err != nil {
+			return nil, err
+		}
+		b.IPNet = ipNet
+
+	default:
+		return nil, fmt.Errorf("unknown peer ban type %v", k[0])
+	}
+
+	tlvStream, err := tlv.NewStream(
+		tlv.MakePrimitiveRecord(peerBanReasonType, &reason),
+		tlv.MakePrimitiveRecord(peerBanCreatedAtType, &createdAt),
+		tlv.MakePrimitiveRecord(peerBanExpiryType, &expiry),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tlvStream.Decode(r); err != nil {
+		return nil, err
+	}
+
+	b.Reason = string(reason)
+	b.CreatedAt = time.Unix(0, int64(createdAt))
+	if expiry != 0 {
+		b.Expiry = time.Unix(0, int64(expiry))
+	}
+
+	return &b, nil
+}
+
+// PutPeerBan stores the ban, replacing any existing ban of the same target.
+func (d *DB) PutPeerBan(ban *PeerBan) error {
+	key, err := ban.key()
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err := ban.encode(&b); err != nil {
+		return err
+	}
+
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		bans, err := tx.CreateTopLevelBucket(peerBanBucket)
+		if err != nil {
+			return err
+		}
+
+		return bans.Put(key, b.Bytes())
+	}, func() {})
+}
+
+// FetchPeerBans returns all stored bans, including the ones that have
+// expired.
+func (d *DB) FetchPeerBans() ([]*PeerBan, error) {
+	var bans []*PeerBan
+	err := kvdb.View(d, func(tx kvdb.RTx) error {
+		bucket := tx.ReadBucket(peerBanBucket)
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			ban, err := decodePeerBan(k, bytes.NewReader(v))
+			if err != nil {
+				return err
+			}
+			bans = append(bans, ban)
+
+			return nil
+		})
+	}, func() {
+		bans = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bans, nil
+}
+
+// DeletePeerBan deletes the ban of the target of the given ban. Only the Node
+// or IPNet of the ban need to be set. ErrPeerBanNotFound is returned if the
+// target isn't banned.
+func (d *DB) DeletePeerBan(ban *PeerBan) error {
+	key, err := ban.key()
+	if err != nil {
+		return err
+	}
+
+	return kvdb.Update(d, func(tx kvdb.RwTx) error {
+		bans := tx.ReadWriteBucket(peerBanBucket)
+		if bans == nil || bans.Get(key) == nil {
+			return ErrPeerBanNotFound
+		}
+
+		return bans.Delete(key)
+	}, func() {})
+}
@@ -0,0 +1,61 @@
+package channeldb
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPeerBans asserts that bans of nodes and IP ranges are stored, fetched
+// and deleted.
+func TestPeerBans(t *testing.T) {
+	t.Parallel()
+
+	db, err := MakeTestDB(t)
+	require.NoError(t, err)
+
+	// Without any ban stored, the bucket doesn't exist yet.
+	bans, err := db.FetchPeerBans()
+	require.NoError(t, err)
+	require.Empty(t, bans)
+
+	node := route.Vertex{2, 1}
+	nodeBan := &PeerBan{
+		Node:      &node,
+		Reason:    "spam",
+		CreatedAt: time.Unix(100, 0),
+	}
+	require.ErrorIs(t, db.DeletePeerBan(nodeBan), ErrPeerBanNotFound)
+
+	_, ipNet, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	ipNetBan := &PeerBan{
+		IPNet:     ipNet,
+		CreatedAt: time.Unix(200, 0),
+		Expiry:    time.Unix(300, 0),
+	}
+
+	require.NoError(t, db.PutPeerBan(nodeBan))
+	require.NoError(t, db.PutPeerBan(ipNetBan))
+
+	bans, err = db.FetchPeerBans()
+	require.NoError(t, err)
+	require.Equal(t, []*PeerBan{nodeBan, ipNetBan}, bans)
+
+	// A ban needs exactly one target.
+	require.Error(t, db.PutPeerBan(&PeerBan{}))
+	require.Error(t, db.PutPeerBan(&PeerBan{Node: &node, IPNet: ipNet}))
+
+	require.NoError(t, db.DeletePeerBan(&PeerBan{Node: &node}))
+
+	bans, err = db.FetchPeerBans()
+	require.NoError(t, err)
+	require.Equal(t, []*PeerBan{ipNetBan}, bans)
+
+	require.True(t, ipNetBan.Expired(time.Unix(300, 0)))
+	require.False(t, ipNetBan.Expired(time.Unix(299, 0)))
+	require.False(t, nodeBan.Expired(time.Unix(1<<40, 0)))
+}
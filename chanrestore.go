@@ -131,31 +131,9 @@ func (c *chanDBRestorer) openChannelShell(backup chanbackup.Single) (
 
 	shaChainProducer := shachain.NewRevocationProducer(*revRoot)
 
-	var chanType channeldb.ChannelType
-	switch backup.Version {
-	case chanbackup.DefaultSingleVersion:
-		chanType = channeldb.SingleFunderBit
-
-	case chanbackup.TweaklessCommitVersion:
-		chanType = channeldb.SingleFunderTweaklessBit
-
-	case chanbackup.AnchorsCommitVersion:
-		chanType = channeldb.AnchorOutputsBit
-		chanType |= channeldb.SingleFunderTweaklessBit
-
-	case chanbackup.AnchorsZeroFeeHtlcTxCommitVersion:
-		chanType = channeldb.ZeroHtlcTxFeeBit
-		chanType |= channeldb.AnchorOutputsBit
-		chanType |= channeldb.SingleFunderTweaklessBit
-
-	case chanbackup.ScriptEnforcedLeaseVersion:
-		chanType = channeldb.LeaseExpirationBit
-		chanType |= channeldb.ZeroHtlcTxFeeBit
-		chanType |= channeldb.AnchorOutputsBit
-		chanType |= channeldb.SingleFunderTweaklessBit
-
-	default:
-		return nil, fmt.Errorf("unknown Single version: %v", err)
+	chanType, err := backup.CommitChanType()
+	if err != nil {
+		return nil, err
 	}
 
 	ltndLog.Infof("SCB Recovery: created channel shell for ChannelPoint"+
@@ -178,6 +156,7 @@ func (c *chanDBRestorer) openChannelShell(backup chanbackup.Single) (
 			RevocationStore:         shachain.NewRevocationStore(),
 			RevocationProducer:      shaChainProducer,
 			ThawHeight:              backup.LeaseExpiry,
+			RestoredCommitHeight:    backup.CommitHeight,
 		},
 	}
 
@@ -113,3 +113,8 @@ func (e *etcdLeaderElector) Campaign(ctx context.Context) error {
 func (e *etcdLeaderElector) Resign() error {
 	return e.election.Resign(context.Background())
 }
+
+// Done returns a channel that is closed once the leadership is lost.
+func (e *etcdLeaderElector) Done() <-chan struct{} {
+	return e.session.Done()
+}
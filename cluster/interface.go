@@ -8,6 +8,10 @@ const (
 	// EtcdLeaderElector is the id used when constructing an
 	// etcdLeaderElector instance through the factory.
 	EtcdLeaderElector = "etcd"
+
+	// PostgresLeaderElector is the id used when constructing a
+	// postgresLeaderElector instance through the factory.
+	PostgresLeaderElector = "postgres"
 )
 
 // LeaderElector is a general interface implementing basic leader elections
@@ -23,4 +27,10 @@ type LeaderElector interface {
 
 	// Leader returns the leader value for the current election.
 	Leader(ctx context.Context) (string, error)
+
+	// Done returns a channel that is closed once the leadership is lost,
+	// for example because the session with the election governor expired.
+	// A leader that lost its leadership must shut down, as another member
+	// may take over.
+	Done() <-chan struct{}
 }
@@ -0,0 +1,386 @@
+//go:build kvdb_postgres
+// +build kvdb_postgres
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/lightningnetwork/lnd/kvdb/postgres"
+)
+
+const (
+	// postgresCampaignInterval is the interval at which a candidate tries
+	// to acquire the election lock.
+	postgresCampaignInterval = time.Second
+
+	// postgresKeepAliveCount is the number of unanswered TCP keepalive
+	// probes after which the server considers the connection of a
+	// candidate dead and releases its lock.
+	postgresKeepAliveCount = 3
+
+	// leaderQuery returns the id of the candidate holding the election
+	// lock. An advisory lock on a bigint key is stored in pg_locks with the
+	// high 32 bits of the key as classid and the low 32 bits as objid.
+	leaderQuery = `
+SELECT a.application_name
+FROM pg_locks l
+JOIN pg_stat_activity a ON a.pid = l.pid
+WHERE l.locktype = 'advisory' AND l.granted AND l.objsubid = 1
+	AND l.database = (
+		SELECT oid FROM pg_database WHERE datname = current_database()
+	)
+	AND l.classid::bigint = $1 AND l.objid::bigint = $2`
+
+	// holdsLockQuery returns whether the current session holds the
+	// election lock.
+	holdsLockQuery = `
+SELECT EXISTS (
+	SELECT 1 FROM pg_locks
+	WHERE locktype = 'advisory' AND granted AND objsubid = 1
+		AND pid = pg_backend_pid()
+		AND classid::bigint = $1 AND objid::bigint = $2
+)`
+)
+
+var (
+	// errNoLeader is returned by Leader if no candidate holds the
+	// election lock.
+	errNoLeader = errors.New("no leader elected")
+)
+
+// Enforce that postgresLeaderElector implements the LeaderElector interface.
+var _ LeaderElector = (*postgresLeaderElector)(nil)
+
+// postgresLeaderElector is an implementation of LeaderElector using a
+// Postgres session level advisory lock as the election governor. The
+// candidate that holds the lock is the leader. The lock is released by the
+// server as soon as the leader's session ends, either because it resigned or
+// because its connection died.
+//
+// To fence a stale leader, the leader checks that it still holds the lock
+// every third of the session TTL and closes its Done channel once it can't
+// confirm that anymore. The server in turn only considers the connection of
+// an unreachable leader dead after the session TTL plus a number of keepalive
+// probes, so the stale leader notices the loss before anyone else can take
+// over.
+type postgresLeaderElector struct {
+	id         string
+	ctx        context.Context
+	cfg        *postgres.Config
+	lockKey    int64
+	sessionTTL time.Duration
+
+	// connMtx guards conn, as a connection can't be used concurrently.
+	connMtx sync.Mutex
+	conn    *pgx.Conn
+
+	done      chan struct{}
+	doneOnce  sync.Once
+	quit      chan struct{}
+	startOnce sync.Once
+	quitOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// newPostgresLeaderElector constructs a new postgresLeaderElector. All
+// candidates that use the same election key on the same database compete
+// for the same leadership.
+func newPostgresLeaderElector(ctx context.Context, id, electionKey string,
+	leaderSessionTTL int, cfg *postgres.Config) (*postgresLeaderElector,
+	error) {
+
+	if leaderSessionTTL <= 0 {
+		return nil, fmt.Errorf("invalid leader session TTL: %v",
+			leaderSessionTTL)
+	}
+
+	return &postgresLeaderElector{
+		id:         id,
+		ctx:        ctx,
+		cfg:        cfg,
+		lockKey:    electionLockKey(electionKey),
+		sessionTTL: time.Duration(leaderSessionTTL) * time.Second,
+		done:       make(chan struct{}),
+		quit:       make(chan struct{}),
+	}, nil
+}
+
+// electionLockKey maps the given election key to the key of the advisory
+// lock.
+func electionLockKey(electionKey string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(electionKey))
+
+	return int64(h.Sum64())
+}
+
+// lockKeyParts returns the high and low 32 bits of the lock key as they
+// appear in pg_locks.
+func (e *postgresLeaderElector) lockKeyParts() (int64, int64) {
+	key := uint64(e.lockKey)
+	return int64(key >> 32), int64(key & 0xffffffff)
+}
+
+// connect opens the dedicated connection of the candidate. The candidate id
+// is used as the application name of the session, which is how other
+// candidates learn the id of the leader. The server side keepalive settings
+// make sure that the lock of an unreachable leader is only released after
+// the leader noticed that it lost the connection.
+func (e *postgresLeaderElector) connect(ctx context.Context) error {
+	connCfg, err := pgx.ParseConfig(e.cfg.Dsn)
+	if err != nil {
+		return err
+	}
+
+	if e.cfg.Timeout != 0 {
+		connCfg.ConnectTimeout = e.cfg.Timeout
+	}
+
+	ttl := int(e.sessionTTL.Seconds())
+	probeInterval := ttl / postgresKeepAliveCount
+	if probeInterval == 0 {
+		probeInterval = 1
+	}
+
+	params := connCfg.RuntimeParams
+	params["application_name"] = e.id
+	params["tcp_keepalives_idle"] = strconv.Itoa(ttl)
+	params["tcp_keepalives_interval"] = strconv.Itoa(probeInterval)
+	params["tcp_keepalives_count"] = strconv.Itoa(postgresKeepAliveCount)
+
+	conn, err := pgx.ConnectConfig(ctx, connCfg)
+	if err != nil {
+		return err
+	}
+
+	e.conn = conn
+
+	return nil
+}
+
+// closeConn closes the connection of the candidate, which releases the lock
+// if it is held. The caller must hold connMtx.
+func (e *postgresLeaderElector) closeConn() {
+	if e.conn == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(
+		context.Background(), e.sessionTTL/postgresKeepAliveCount,
+	)
+	defer cancel()
+
+	if err := e.conn.Close(ctx); err != nil {
+		log.Debugf("Unable to close election connection: %v", err)
+	}
+	e.conn = nil
+}
+
+// tryLock makes a single attempt to acquire the election lock, connecting
+// first if needed.
+func (e *postgresLeaderElector) tryLock(ctx context.Context) (bool, error) {
+	e.connMtx.Lock()
+	defer e.connMtx.Unlock()
+
+	if e.conn == nil {
+		if err := e.connect(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	var locked bool
+	err := e.conn.QueryRow(
+		ctx, "SELECT pg_try_advisory_lock($1)", e.lockKey,
+	).Scan(&locked)
+	if err != nil {
+		// The connection may be unusable after an error, so we'll
+		// start over with a new one.
+		e.closeConn()
+		return false, err
+	}
+
+	return locked, nil
+}
+
+// Leader returns the leader value for the current election.
+func (e *postgresLeaderElector) Leader(ctx context.Context) (string, error) {
+	e.connMtx.Lock()
+	defer e.connMtx.Unlock()
+
+	if e.conn == nil {
+		if err := e.connect(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	high, low := e.lockKeyParts()
+
+	var leader string
+	err := e.conn.QueryRow(ctx, leaderQuery, high, low).Scan(&leader)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return "", errNoLeader
+
+	case err != nil:
+		return "", err
+	}
+
+	return leader, nil
+}
+
+// Campaign will start a new leader election campaign. Campaign will block until
+// the elector context is canceled or the the caller is elected as the leader.
+func (e *postgresLeaderElector) Campaign(ctx context.Context) error {
+	ticker := time.NewTicker(postgresCampaignInterval)
+	defer ticker.Stop()
+
+	for {
+		locked, err := e.tryLock(ctx)
+		switch {
+		case err != nil:
+			log.Warnf("Unable to acquire election lock: %v", err)
+
+		case locked:
+			e.startOnce.Do(func() {
+				e.wg.Add(1)
+				go e.monitorLock()
+			})
+
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-e.ctx.Done():
+			return e.ctx.Err()
+		}
+	}
+}
+
+// monitorLock periodically checks that the leader still holds the election
+// lock. Once that can't be confirmed, the leader is considered stale: the
+// connection is closed and the Done channel is closed so lnd shuts down.
+//
+// NOTE: This must be run as a goroutine.
+func (e *postgresLeaderElector) monitorLock() {
+	defer e.wg.Done()
+
+	interval := e.sessionTTL / postgresKeepAliveCount
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+
+		case <-e.quit:
+			return
+
+		case <-e.ctx.Done():
+			e.lostLeadership(e.ctx.Err())
+			return
+		}
+
+		if err := e.checkLock(interval); err != nil {
+			e.lostLeadership(err)
+			return
+		}
+	}
+}
+
+// checkLock confirms that the election lock is still held within the given
+// timeout.
+func (e *postgresLeaderElector) checkLock(timeout time.Duration) error {
+	e.connMtx.Lock()
+	defer e.connMtx.Unlock()
+
+	if e.conn == nil {
+		return errors.New("connection closed")
+	}
+
+	ctx, cancel := context.WithTimeout(e.ctx, timeout)
+	defer cancel()
+
+	high, low := e.lockKeyParts()
+
+	var held bool
+	err := e.conn.QueryRow(ctx, holdsLockQuery, high, low).Scan(&held)
+	switch {
+	case err != nil:
+		return err
+
+	case !held:
+		return errors.New("election lock not held")
+	}
+
+	return nil
+}
+
+// lostLeadership closes the connection and signals that the leadership is
+// lost.
+func (e *postgresLeaderElector) lostLeadership(reason error) {
+	log.Errorf("Lost leadership (%v): %v", e.id, reason)
+
+	e.connMtx.Lock()
+	e.closeConn()
+	e.connMtx.Unlock()
+
+	e.doneOnce.Do(func() {
+		close(e.done)
+	})
+}
+
+// Resign resigns the leader role allowing other election members to take
+// the place.
+func (e *postgresLeaderElector) Resign() error {
+	e.quitOnce.Do(func() {
+		close(e.quit)
+	})
+	e.wg.Wait()
+
+	e.connMtx.Lock()
+	defer e.connMtx.Unlock()
+
+	// Closing the session releases the lock as well, but unlocking it
+	// explicitly lets us report whether it was still held.
+	var err error
+	if e.conn != nil {
+		ctx, cancel := context.WithTimeout(
+			context.Background(),
+			e.sessionTTL/postgresKeepAliveCount,
+		)
+		defer cancel()
+
+		var unlocked bool
+		err = e.conn.QueryRow(
+			ctx, "SELECT pg_advisory_unlock($1)", e.lockKey,
+		).Scan(&unlocked)
+		if err == nil && !unlocked {
+			err = errors.New("election lock not held")
+		}
+	}
+	e.closeConn()
+
+	e.doneOnce.Do(func() {
+		close(e.done)
+	})
+
+	return err
+}
+
+// Done returns a channel that is closed once the leadership is lost.
+func (e *postgresLeaderElector) Done() <-chan struct{} {
+	return e.done
+}
@@ -0,0 +1,57 @@
+//go:build kvdb_postgres
+// +build kvdb_postgres
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/kvdb/postgres"
+)
+
+// makePostgresElector will construct a new postgresLeaderElector. It expects a
+// cancel context a unique (in the cluster) LND id, the election key, the
+// session TTL and a *postgres.Config as arguments.
+func makePostgresElector(ctx context.Context, args ...interface{}) (
+	LeaderElector, error) {
+
+	if len(args) != 4 {
+		return nil, fmt.Errorf("invalid number of arguments to "+
+			"cluster.makePostgresElector(): expected 4, got %v",
+			len(args))
+	}
+
+	id, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid argument (0) to " +
+			"cluster.makePostgresElector(), expected: string")
+	}
+
+	electionKey, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid argument (1) to " +
+			"cluster.makePostgresElector(), expected: string")
+	}
+
+	leaderSessionTTL, ok := args[2].(int)
+	if !ok {
+		return nil, fmt.Errorf("invalid argument (2) to " +
+			"cluster.makePostgresElector(), expected: int")
+	}
+
+	pgCfg, ok := args[3].(*postgres.Config)
+	if !ok {
+		return nil, fmt.Errorf("invalid argument (3) to " +
+			"cluster.makePostgresElector(), expected: " +
+			"*postgres.Config")
+	}
+
+	return newPostgresLeaderElector(
+		ctx, id, electionKey, leaderSessionTTL, pgCfg,
+	)
+}
+
+func init() {
+	RegisterLeaderElectorFactory(PostgresLeaderElector, makePostgresElector)
+}
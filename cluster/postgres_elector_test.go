@@ -0,0 +1,152 @@
+//go:build kvdb_postgres
+// +build kvdb_postgres
+
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/lightningnetwork/lnd/kvdb/postgres"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testElectionKey = "test-leader"
+	testTimeout     = 10 * time.Second
+)
+
+// newPostgresTestConfig starts an embedded postgres instance and returns the
+// config of a fresh database on it.
+func newPostgresTestConfig(t *testing.T) *postgres.Config {
+	stop, err := postgres.StartEmbeddedPostgres()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, stop())
+	})
+
+	f, err := postgres.NewFixture("")
+	require.NoError(t, err)
+
+	return &postgres.Config{
+		Dsn:     f.Dsn,
+		Timeout: time.Minute,
+	}
+}
+
+// campaign runs a campaign in the background and returns a channel that
+// receives its result.
+func campaign(e *postgresLeaderElector) <-chan error {
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- e.Campaign(context.Background())
+	}()
+
+	return errChan
+}
+
+// TestPostgresElector tests that two candidates competing for leadership works
+// as expected and that the elected leader can resign and allow the other one
+// to take on.
+func TestPostgresElector(t *testing.T) {
+	cfg := newPostgresTestConfig(t)
+	ctx := context.Background()
+
+	e1, err := newPostgresLeaderElector(
+		ctx, "e1", testElectionKey, 3, cfg,
+	)
+	require.NoError(t, err)
+
+	e2, err := newPostgresLeaderElector(
+		ctx, "e2", testElectionKey, 3, cfg,
+	)
+	require.NoError(t, err)
+
+	_, err = e1.Leader(ctx)
+	require.ErrorIs(t, err, errNoLeader)
+
+	require.NoError(t, e1.Campaign(ctx))
+
+	// The second candidate is blocked as long as the first one leads.
+	e2Elected := campaign(e2)
+	select {
+	case err := <-e2Elected:
+		t.Fatalf("second candidate elected while first one leads: %v",
+			err)
+
+	case <-time.After(2 * postgresCampaignInterval):
+	}
+
+	leader, err := e2.Leader(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "e1", leader)
+
+	// Once the first candidate resigns, the second one takes over.
+	require.NoError(t, e1.Resign())
+
+	select {
+	case err := <-e2Elected:
+		require.NoError(t, err)
+
+	case <-time.After(testTimeout):
+		t.Fatalf("second candidate not elected")
+	}
+
+	leader, err = e1.Leader(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "e2", leader)
+
+	// Resigning doesn't count as losing leadership unexpectedly, but
+	// closes the Done channel all the same.
+	require.NoError(t, e2.Resign())
+	select {
+	case <-e2.Done():
+	default:
+		t.Fatalf("done channel not closed after resigning")
+	}
+}
+
+// TestPostgresElectorFencing tests that a leader whose session is terminated
+// notices that it lost the leadership before the session TTL elapses.
+func TestPostgresElectorFencing(t *testing.T) {
+	cfg := newPostgresTestConfig(t)
+	ctx := context.Background()
+
+	const ttl = 3
+	e1, err := newPostgresLeaderElector(
+		ctx, "e1", testElectionKey, ttl, cfg,
+	)
+	require.NoError(t, err)
+	require.NoError(t, e1.Campaign(ctx))
+
+	// Terminate the session of the leader from the outside, as an
+	// administrator or a failing network would.
+	conn, err := pgx.Connect(ctx, cfg.Dsn)
+	require.NoError(t, err)
+	defer conn.Close(ctx)
+
+	var terminated bool
+	err = conn.QueryRow(
+		ctx, "SELECT pg_terminate_backend(pid) FROM pg_stat_activity "+
+			"WHERE application_name = $1", "e1",
+	).Scan(&terminated)
+	require.NoError(t, err)
+	require.True(t, terminated)
+
+	select {
+	case <-e1.Done():
+
+	case <-time.After(ttl * time.Second):
+		t.Fatalf("stale leader didn't notice it lost leadership")
+	}
+
+	// Another candidate can now take over.
+	e2, err := newPostgresLeaderElector(
+		ctx, "e2", testElectionKey, ttl, cfg,
+	)
+	require.NoError(t, err)
+	require.NoError(t, e2.Campaign(ctx))
+	require.NoError(t, e2.Resign())
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/urfave/cli"
@@ -231,6 +232,52 @@ var listInvoicesCommand = cli.Command{
 			Usage: "if set, invoices succeeding the " +
 				"index_offset will be returned",
 		},
+		cli.Uint64Flag{
+			Name: "creation_date_start",
+			Usage: "if set, only invoices created at or after " +
+				"this unix timestamp are returned",
+		},
+		cli.Uint64Flag{
+			Name: "creation_date_end",
+			Usage: "if set, only invoices created at or before " +
+				"this unix timestamp are returned",
+		},
+		cli.Uint64Flag{
+			Name: "settle_date_start",
+			Usage: "if set, only invoices settled at or after " +
+				"this unix timestamp are returned",
+		},
+		cli.Uint64Flag{
+			Name: "settle_date_end",
+			Usage: "if set, only invoices settled at or before " +
+				"this unix timestamp are returned",
+		},
+		cli.StringSliceFlag{
+			Name: "state",
+			Usage: "if set, only invoices in this state are " +
+				"returned, one of open, settled, canceled or " +
+				"accepted; can be specified multiple times",
+		},
+		cli.Int64Flag{
+			Name: "min_amt_msat",
+			Usage: "if set, only invoices with at least this " +
+				"value are returned",
+		},
+		cli.Int64Flag{
+			Name: "max_amt_msat",
+			Usage: "if set, only invoices with at most this " +
+				"value are returned",
+		},
+		cli.StringFlag{
+			Name: "memo",
+			Usage: "if set, only invoices whose memo contains " +
+				"this string are returned",
+		},
+		cli.BoolFlag{
+			Name: "count_total",
+			Usage: "if set, the total number of invoices that " +
+				"match the filters is returned",
+		},
 	},
 	Action: actionDecorator(listInvoices),
 }
@@ -241,10 +288,29 @@ func listInvoices(ctx *cli.Context) error {
 	defer cleanUp()
 
 	req := &lnrpc.ListInvoiceRequest{
-		PendingOnly:    ctx.Bool("pending_only"),
-		IndexOffset:    ctx.Uint64("index_offset"),
-		NumMaxInvoices: ctx.Uint64("max_invoices"),
-		Reversed:       !ctx.Bool("paginate-forwards"),
+		PendingOnly:        ctx.Bool("pending_only"),
+		IndexOffset:        ctx.Uint64("index_offset"),
+		NumMaxInvoices:     ctx.Uint64("max_invoices"),
+		Reversed:           !ctx.Bool("paginate-forwards"),
+		CreationDateStart:  ctx.Uint64("creation_date_start"),
+		CreationDateEnd:    ctx.Uint64("creation_date_end"),
+		SettleDateStart:    ctx.Uint64("settle_date_start"),
+		SettleDateEnd:      ctx.Uint64("settle_date_end"),
+		MinValueMsat:       ctx.Int64("min_amt_msat"),
+		MaxValueMsat:       ctx.Int64("max_amt_msat"),
+		MemoContains:       ctx.String("memo"),
+		CountTotalInvoices: ctx.Bool("count_total"),
+	}
+
+	for _, state := range ctx.StringSlice("state") {
+		name := strings.ToUpper(state)
+		rpcState, ok := lnrpc.Invoice_InvoiceState_value[name]
+		if !ok {
+			return fmt.Errorf("unknown invoice state: %v", state)
+		}
+		req.States = append(
+			req.States, lnrpc.Invoice_InvoiceState(rpcState),
+		)
 	}
 
 	invoices, err := client.ListInvoices(ctxc, req)
@@ -8,12 +8,16 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/golang/protobuf/proto"
 	"github.com/lightningnetwork/lnd/lncfg"
 	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/macaroons"
+	"github.com/lightningnetwork/lnd/routing/route"
 	"github.com/urfave/cli"
 	"gopkg.in/macaroon-bakery.v2/bakery"
 	"gopkg.in/macaroon.v2"
@@ -38,6 +42,35 @@ var (
 		Usage: "the condition of the custom caveat to add, can be " +
 			"empty if custom caveat doesn't need a value",
 	}
+	macMaxPaymentFlag = cli.Uint64Flag{
+		Name: "max_payment_msat",
+		Usage: "the maximum amount in millisatoshis of each " +
+			"off-chain payment made with the macaroon",
+	}
+	macPaymentBudgetFlag = cli.Uint64Flag{
+		Name: "payment_budget_msat",
+		Usage: "the total amount in millisatoshis of off-chain " +
+			"payments that may be made with the macaroon in each " +
+			"budget window",
+	}
+	macBudgetWindowFlag = cli.DurationFlag{
+		Name: "budget_window",
+		Usage: "the length of a budget window, for example 24h; " +
+			"a window starts with the first payment after the " +
+			"previous window ended",
+		Value: 24 * time.Hour,
+	}
+	macAllowedDestsFlag = cli.StringSliceFlag{
+		Name: "allowed_dest",
+		Usage: "the public key of a node that off-chain payments " +
+			"made with the macaroon may be sent to, can be " +
+			"specified multiple times",
+	}
+	macMaxOnChainSendFlag = cli.Int64Flag{
+		Name: "max_onchain_send_sat",
+		Usage: "the maximum amount in satoshis of each on-chain " +
+			"send made with the macaroon",
+	}
 )
 
 var bakeMacaroonCommand = cli.Command{
@@ -47,6 +80,9 @@ var bakeMacaroonCommand = cli.Command{
 		"and restrictions.",
 	ArgsUsage: "[--save_to=] [--timeout=] [--ip_address=] " +
 		"[--custom_caveat_name= [--custom_caveat_condition=]] " +
+		"[--max_payment_msat=] [--payment_budget_msat= " +
+		"[--budget_window=]] [--allowed_dest=...] " +
+		"[--max_onchain_send_sat=] " +
 		"[--root_key_id=] [--allow_external_permissions] " +
 		"permissions...",
 	Description: `
@@ -72,6 +108,16 @@ var bakeMacaroonCommand = cli.Command{
 	The macaroon created by this command would only be allowed to use the
 	"lncli getinfo" and "lncli version" commands.
 
+	The funds that can be spent with a macaroon can be limited by lnd
+	itself: --max_payment_msat limits the amount of each off-chain payment,
+	--payment_budget_msat limits the total amount of off-chain payments in
+	each --budget_window, --allowed_dest limits the destinations of
+	off-chain payments and --max_onchain_send_sat limits the amount of each
+	on-chain send. Payments are charged to the budget when they are
+	dispatched, so failed payments count against it too. A macaroon with
+	any of these limits can't be used to open or close channels, or to
+	fund, sign or publish arbitrary transactions.
+
 	To get a list of all available URIs and permissions, use the
 	"lncli listpermissions" command.
 	`,
@@ -85,6 +131,11 @@ var bakeMacaroonCommand = cli.Command{
 		macIPAddressFlag,
 		macCustomCaveatNameFlag,
 		macCustomCaveatConditionFlag,
+		macMaxPaymentFlag,
+		macPaymentBudgetFlag,
+		macBudgetWindowFlag,
+		macAllowedDestsFlag,
+		macMaxOnChainSendFlag,
 		cli.Uint64Flag{
 			Name: "root_key_id",
 			Usage: "the numerical root key ID used to create the " +
@@ -416,17 +467,28 @@ var constrainMacaroonCommand = cli.Command{
 	Category: "Macaroons",
 	Usage:    "Adds one or more restriction(s) to an existing macaroon",
 	ArgsUsage: "[--timeout=] [--ip_address=] [--custom_caveat_name= " +
-		"[--custom_caveat_condition=]] input-macaroon-file " +
-		"constrained-macaroon-file",
+		"[--custom_caveat_condition=]] [--max_payment_msat=] " +
+		"[--payment_budget_msat= [--budget_window=]] " +
+		"[--allowed_dest=...] [--max_onchain_send_sat=] " +
+		"input-macaroon-file constrained-macaroon-file",
 	Description: `
 	Add one or more first-party caveat(s) (a.k.a. constraints/restrictions)
 	to an existing macaroon.
+
+	The spending limits are enforced by lnd in addition to the limits
+	already present on the macaroon. A payment budget added to a macaroon
+	is shared by all macaroons that are derived from it.
 	`,
 	Flags: []cli.Flag{
 		macTimeoutFlag,
 		macIPAddressFlag,
 		macCustomCaveatNameFlag,
 		macCustomCaveatConditionFlag,
+		macMaxPaymentFlag,
+		macPaymentBudgetFlag,
+		macBudgetWindowFlag,
+		macAllowedDestsFlag,
+		macMaxOnChainSendFlag,
 	},
 	Action: actionDecorator(constrainMacaroon),
 }
@@ -548,6 +610,12 @@ func applyMacaroonConstraints(ctx *cli.Context,
 		)
 	}
 
+	spendingConstraints, err := parseSpendingConstraints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	macConstraints = append(macConstraints, spendingConstraints...)
+
 	constrainedMac, err := macaroons.AddConstraints(mac, macConstraints...)
 	if err != nil {
 		return nil, fmt.Errorf("error adding constraints: %v", err)
@@ -556,6 +624,67 @@ func applyMacaroonConstraints(ctx *cli.Context,
 	return constrainedMac, nil
 }
 
+// parseSpendingConstraints parses the spending limit flags from the command
+// line into macaroon constraints.
+func parseSpendingConstraints(ctx *cli.Context) ([]macaroons.Constraint,
+	error) {
+
+	var macConstraints []macaroons.Constraint
+
+	if ctx.IsSet(macMaxPaymentFlag.Name) {
+		amt := lnwire.MilliSatoshi(ctx.Uint64(macMaxPaymentFlag.Name))
+		macConstraints = append(
+			macConstraints, macaroons.MaxPaymentConstraint(amt),
+		)
+	}
+
+	if ctx.IsSet(macPaymentBudgetFlag.Name) {
+		amt := lnwire.MilliSatoshi(
+			ctx.Uint64(macPaymentBudgetFlag.Name),
+		)
+		window := ctx.Duration(macBudgetWindowFlag.Name)
+		macConstraints = append(
+			macConstraints,
+			macaroons.PaymentBudgetConstraint(amt, window),
+		)
+	} else if ctx.IsSet(macBudgetWindowFlag.Name) {
+		return nil, fmt.Errorf("%s requires %s",
+			macBudgetWindowFlag.Name, macPaymentBudgetFlag.Name)
+	}
+
+	if ctx.IsSet(macAllowedDestsFlag.Name) {
+		var dests []route.Vertex
+		hexDests := ctx.StringSlice(macAllowedDestsFlag.Name)
+		for _, hexDest := range hexDests {
+			dest, err := route.NewVertexFromStr(hexDest)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse "+
+					"allowed_dest %s: %v", hexDest, err)
+			}
+			dests = append(dests, dest)
+		}
+
+		macConstraints = append(
+			macConstraints,
+			macaroons.AllowedDestinationsConstraint(dests),
+		)
+	}
+
+	if ctx.IsSet(macMaxOnChainSendFlag.Name) {
+		amt := btcutil.Amount(ctx.Int64(macMaxOnChainSendFlag.Name))
+		if amt < 0 {
+			return nil, fmt.Errorf("max_onchain_send_sat must " +
+				"not be negative")
+		}
+
+		macConstraints = append(
+			macConstraints, macaroons.MaxOnChainSendConstraint(amt),
+		)
+	}
+
+	return macConstraints, nil
+}
+
 // containsWhiteSpace returns true if the given string contains any character
 // that is considered to be a white space or non-printable character such as
 // space, tabulator, newline, carriage return and some more exotic ones.
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"runtime"
@@ -100,6 +101,20 @@ var (
 		Name:  "time_pref",
 		Usage: "(optional) expresses time preference (range -1 to 1)",
 	}
+
+	splitStrategyFlag = cli.StringFlag{
+		Name: "split_strategy",
+		Usage: "(optional) the strategy used to split the payment " +
+			"into multiple shards, either 'halving' or " +
+			"'probability'",
+		Value: "halving",
+	}
+
+	maxShardsInFlightFlag = cli.UintFlag{
+		Name: "max_shards_in_flight",
+		Usage: "(optional) the maximum number of shards that may be " +
+			"in flight at the same time, 0 means no limit",
+	}
 )
 
 // paymentFlags returns common flags for sendpayment and payinvoice.
@@ -145,7 +160,7 @@ func paymentFlags() []cli.Flag {
 		},
 		dataFlag, inflightUpdatesFlag, maxPartsFlag, jsonFlag,
 		maxShardSizeSatFlag, maxShardSizeMsatFlag, ampFlag,
-		timePrefFlag,
+		timePrefFlag, splitStrategyFlag, maxShardsInFlightFlag,
 	}
 }
 
@@ -448,6 +463,7 @@ func sendPaymentRequest(ctx *cli.Context,
 	req.AllowSelfPayment = ctx.Bool("allow_self_payment")
 
 	req.MaxParts = uint32(ctx.Uint(maxPartsFlag.Name))
+	req.MaxShardsInFlight = uint32(ctx.Uint(maxShardsInFlightFlag.Name))
 
 	switch {
 	// If the max shard size is specified, then it should either be in sat
@@ -466,6 +482,18 @@ func sendPaymentRequest(ctx *cli.Context,
 		))
 	}
 
+	switch ctx.String(splitStrategyFlag.Name) {
+	case "halving":
+		req.SplitStrategy = routerrpc.PaymentSplitStrategy_HALVING
+
+	case "probability":
+		req.SplitStrategy = routerrpc.PaymentSplitStrategy_PROBABILITY
+
+	default:
+		return fmt.Errorf("unknown split strategy: %v",
+			ctx.String(splitStrategyFlag.Name))
+	}
+
 	// Parse custom data records.
 	data := ctx.String(dataFlag.Name)
 	if data != "" {
@@ -1191,6 +1219,15 @@ var listPaymentsCommand = cli.Command{
 	time on systems with many payments, the count is not returned by
 	default. That feature can be turned on with the --count_total_payments
 	flag.
+
+	Payments can be filtered by their creation time, status and
+	destination. The creation times are expressed in seconds since the Unix
+	epoch or relative to now, e.g. "-1w". For example, all payments that
+	succeeded within the last week, together with the fees paid for them,
+	are listed with:
+
+	lncli listpayments --creation_date_start=-1w --status=succeeded \
+		--fee_stats
 	`,
 	Flags: []cli.Flag{
 		cli.BoolFlag{
@@ -1229,6 +1266,34 @@ var listPaymentsCommand = cli.Command{
 				"be counted; can take a long time on systems " +
 				"with many payments",
 		},
+		cli.StringFlag{
+			Name: "creation_date_start",
+			Usage: "if set, only payments created at or after " +
+				"this time are returned, as unix timestamp " +
+				`or relative e.g. "-1w"`,
+		},
+		cli.StringFlag{
+			Name: "creation_date_end",
+			Usage: "if set, only payments created at or before " +
+				"this time are returned, as unix timestamp " +
+				`or relative e.g. "-1d"`,
+		},
+		cli.StringSliceFlag{
+			Name: "status",
+			Usage: "if set, only payments with this status are " +
+				"returned, one of in_flight, succeeded or " +
+				"failed; can be specified multiple times",
+		},
+		cli.StringFlag{
+			Name: "dest",
+			Usage: "if set, only payments to the node with this " +
+				"hex-encoded public key are returned",
+		},
+		cli.BoolFlag{
+			Name: "fee_stats",
+			Usage: "if set, statistics about the amounts and " +
+				"fees of all matching payments are returned",
+		},
 	},
 	Action: actionDecorator(listPayments),
 }
@@ -1244,6 +1309,47 @@ func listPayments(ctx *cli.Context) error {
 		MaxPayments:        uint64(ctx.Uint("max_payments")),
 		Reversed:           !ctx.Bool("paginate_forwards"),
 		CountTotalPayments: ctx.Bool("count_total_payments"),
+		FeeStats:           ctx.Bool("fee_stats"),
+	}
+
+	now := time.Now()
+	if ctx.IsSet("creation_date_start") {
+		startTime, err := parseTime(
+			ctx.String("creation_date_start"), now,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to decode "+
+				"creation_date_start: %v", err)
+		}
+		req.CreationDateStart = startTime
+	}
+
+	if ctx.IsSet("creation_date_end") {
+		endTime, err := parseTime(ctx.String("creation_date_end"), now)
+		if err != nil {
+			return fmt.Errorf("unable to decode "+
+				"creation_date_end: %v", err)
+		}
+		req.CreationDateEnd = endTime
+	}
+
+	for _, status := range ctx.StringSlice("status") {
+		name := strings.ToUpper(status)
+		rpcStatus, ok := lnrpc.Payment_PaymentStatus_value[name]
+		if !ok {
+			return fmt.Errorf("unknown payment status: %v", status)
+		}
+		req.Statuses = append(
+			req.Statuses, lnrpc.Payment_PaymentStatus(rpcStatus),
+		)
+	}
+
+	if ctx.IsSet("dest") {
+		dest, err := hex.DecodeString(ctx.String("dest"))
+		if err != nil {
+			return fmt.Errorf("unable to decode dest: %v", err)
+		}
+		req.Destination = dest
 	}
 
 	payments, err := client.ListPayments(ctxc, req)
@@ -1487,6 +1593,308 @@ func buildRoute(ctx *cli.Context) error {
 	return nil
 }
 
+var probeDestCommand = cli.Command{
+	Name:     "probedest",
+	Category: "Payments",
+	Usage:    "Probe routes to a destination without paying it.",
+	Description: `
+	Send probes, htlcs with a random payment hash that the destination can't
+	settle, along routes to the destination until a probe reaches it. Probes
+	aren't stored as payments, but mission control learns from their
+	outcome.`,
+	ArgsUsage: "dest amt",
+	Action:    actionDecorator(probeDest),
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "dest",
+			Usage: "the hex pubkey of the destination",
+		},
+		cli.Int64Flag{
+			Name:  "amt",
+			Usage: "the amount to probe with expressed in satoshis",
+		},
+		cli.Int64Flag{
+			Name: "fee_limit",
+			Usage: "the maximum fee of the probed routes " +
+				"expressed in satoshis; if not set, the fee " +
+				"is not limited",
+		},
+		cli.Int64Flag{
+			Name: "final_cltv_delta",
+			Usage: "number of blocks the last hop has to reveal " +
+				"the preimage",
+		},
+		cli.Uint64Flag{
+			Name:  "max_probes",
+			Usage: "the maximum number of probes to send",
+		},
+	},
+}
+
+func probeDest(ctx *cli.Context) error {
+	ctxc := getContext()
+	conn := getClientConn(ctx, false)
+	defer conn.Close()
+
+	client := routerrpc.NewRouterClient(conn)
+
+	args := ctx.Args()
+
+	var destStr string
+	switch {
+	case ctx.IsSet("dest"):
+		destStr = ctx.String("dest")
+	case args.Present():
+		destStr = args.First()
+		args = args.Tail()
+	default:
+		return errors.New("dest required")
+	}
+
+	dest, err := route.NewVertexFromStr(destStr)
+	if err != nil {
+		return fmt.Errorf("error parsing dest: %v", err)
+	}
+
+	var amt int64
+	switch {
+	case ctx.IsSet("amt"):
+		amt = ctx.Int64("amt")
+	case args.Present():
+		amt, err = strconv.ParseInt(args.First(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("unable to decode amt: %v", err)
+		}
+	default:
+		return errors.New("amt required")
+	}
+
+	req := &routerrpc.ProbeDestinationRequest{
+		Dest:           dest[:],
+		AmtMsat:        amt * 1000,
+		FeeLimitMsat:   ctx.Int64("fee_limit") * 1000,
+		FinalCltvDelta: int32(ctx.Int64("final_cltv_delta")),
+		MaxProbes:      uint32(ctx.Uint64("max_probes")),
+	}
+
+	resp, err := client.ProbeDestination(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
+
+var probeLiquidityCommand = cli.Command{
+	Name:     "probeliquidity",
+	Category: "Payments",
+	Usage:    "Find the liquidity of a channel by probing it.",
+	Description: `
+	Narrow down the liquidity of the last channel of a route, given as a
+	list of hop pubkeys, by a binary search over the amount of probes sent
+	along the route. Returns a bound on the amount the channel can forward
+	in a single htlc.`,
+	Action: actionDecorator(probeLiquidity),
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "hops",
+			Usage: "comma separated hex pubkeys",
+		},
+		cli.Uint64Flag{
+			Name: "outgoing_chan_id",
+			Usage: "short channel id of the outgoing channel to " +
+				"use for the first hop of the probes",
+		},
+		cli.Int64Flag{
+			Name: "final_cltv_delta",
+			Usage: "number of blocks the last hop has to reveal " +
+				"the preimage",
+		},
+		cli.Int64Flag{
+			Name: "min_amt",
+			Usage: "a known lower bound of the liquidity " +
+				"expressed in satoshis",
+		},
+		cli.Int64Flag{
+			Name: "max_amt",
+			Usage: "a known upper bound of the liquidity, such " +
+				"as the capacity of the channel, expressed " +
+				"in satoshis",
+		},
+		cli.Int64Flag{
+			Name: "precision",
+			Usage: "the width of the range the liquidity is " +
+				"narrowed down to expressed in satoshis",
+		},
+		cli.Uint64Flag{
+			Name:  "max_probes",
+			Usage: "the maximum number of probes to send",
+		},
+	},
+}
+
+func probeLiquidity(ctx *cli.Context) error {
+	ctxc := getContext()
+	conn := getClientConn(ctx, false)
+	defer conn.Close()
+
+	client := routerrpc.NewRouterClient(conn)
+
+	if !ctx.IsSet("hops") {
+		return errors.New("hops required")
+	}
+	if !ctx.IsSet("max_amt") {
+		return errors.New("max_amt required")
+	}
+
+	hops := strings.Split(ctx.String("hops"), ",")
+	rpcHops := make([][]byte, 0, len(hops))
+	for _, k := range hops {
+		pubkey, err := route.NewVertexFromStr(k)
+		if err != nil {
+			return fmt.Errorf("error parsing %v: %v", k, err)
+		}
+		rpcHops = append(rpcHops, pubkey[:])
+	}
+
+	req := &routerrpc.ProbeLiquidityRequest{
+		HopPubkeys:     rpcHops,
+		OutgoingChanId: ctx.Uint64("outgoing_chan_id"),
+		FinalCltvDelta: int32(ctx.Int64("final_cltv_delta")),
+		MinAmtMsat:     ctx.Int64("min_amt") * 1000,
+		MaxAmtMsat:     ctx.Int64("max_amt") * 1000,
+		PrecisionMsat:  ctx.Int64("precision") * 1000,
+		MaxProbes:      uint32(ctx.Uint64("max_probes")),
+	}
+
+	resp, err := client.ProbeLiquidity(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
+
+var sendBatchCommand = cli.Command{
+	Name:     "sendbatch",
+	Category: "Payments",
+	Usage:    "Pay several destinations in one call.",
+	Description: `
+	Send keysend or AMP payments to several destinations at once. Each
+	recipient is given as an argument of the form pubkey=amt, with the
+	amount expressed in satoshis. The fee limit is divided across the
+	recipients in proportion to their amounts. The updates of all payments
+	are printed, each tagged with the index of its recipient.
+
+	With --all_or_nothing, all recipients are probed first and no payment
+	is sent if one of them can't be reached. As the recipients settle their
+	payments independently, this doesn't guarantee that either all or none
+	of the payments succeed.`,
+	ArgsUsage: "pubkey=amt [pubkey=amt...]",
+	Action:    actionDecorator(sendBatch),
+	Flags: []cli.Flag{
+		cli.Int64Flag{
+			Name: "fee_limit",
+			Usage: "the maximum combined fee of all payments " +
+				"expressed in satoshis",
+		},
+		cli.DurationFlag{
+			Name: "timeout",
+			Usage: "the maximum amount of time we should spend " +
+				"trying to fulfill each payment",
+			Value: paymentTimeout,
+		},
+		cli.BoolFlag{
+			Name:  "amp",
+			Usage: "send AMP payments instead of keysend payments",
+		},
+		maxPartsFlag,
+		cli.UintFlag{
+			Name: "max_concurrent",
+			Usage: "the maximum number of payments in flight at " +
+				"the same time",
+		},
+		cli.BoolFlag{
+			Name: "all_or_nothing",
+			Usage: "probe all recipients first and don't pay any " +
+				"of them if one can't be reached",
+		},
+		cli.BoolFlag{
+			Name: "inflight_updates",
+			Usage: "if set, intermediate payment state updates " +
+				"are printed as well",
+		},
+	},
+}
+
+func sendBatch(ctx *cli.Context) error {
+	ctxc := getContext()
+	conn := getClientConn(ctx, false)
+	defer conn.Close()
+
+	client := routerrpc.NewRouterClient(conn)
+
+	if !ctx.Args().Present() {
+		return errors.New("recipients required")
+	}
+
+	var recipients []*routerrpc.BatchRecipient
+	for _, arg := range ctx.Args() {
+		parts := strings.Split(arg, "=")
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid recipient %v, expected "+
+				"pubkey=amt", arg)
+		}
+
+		dest, err := route.NewVertexFromStr(parts[0])
+		if err != nil {
+			return fmt.Errorf("error parsing dest: %v", err)
+		}
+
+		amt, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("unable to decode amt: %v", err)
+		}
+
+		recipients = append(recipients, &routerrpc.BatchRecipient{
+			Dest:    dest[:],
+			AmtMsat: amt * 1000,
+		})
+	}
+
+	req := &routerrpc.SendPaymentBatchRequest{
+		Recipients:            recipients,
+		FeeLimitMsat:          ctx.Int64("fee_limit") * 1000,
+		TimeoutSeconds:        int32(ctx.Duration("timeout").Seconds()),
+		Amp:                   ctx.Bool("amp"),
+		MaxParts:              uint32(ctx.Uint(maxPartsFlag.Name)),
+		MaxConcurrentPayments: uint32(ctx.Uint("max_concurrent")),
+		AllOrNothing:          ctx.Bool("all_or_nothing"),
+		NoInflightUpdates:     !ctx.Bool("inflight_updates"),
+	}
+
+	stream, err := client.SendPaymentBatch(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		update, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		printRespJSON(update)
+	}
+}
+
 var deletePaymentsCommand = cli.Command{
 	Name:     "deletepayments",
 	Category: "Payments",
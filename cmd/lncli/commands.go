@@ -1246,6 +1246,33 @@ func parseChannelPoint(ctx *cli.Context) (*lnrpc.ChannelPoint, error) {
 	return channelPoint, nil
 }
 
+var reloadChanAcceptRulesCommand = cli.Command{
+	Name:     "reloadchanacceptrules",
+	Category: "Channels",
+	Usage:    "Reload the channel accept rules from the rules file.",
+	Description: `
+	Reloads the rules that inbound channels are checked against from the
+	file configured with the chan-accept-rules option. If the file can't be
+	read or contains invalid rules, the current rules stay in place.
+	`,
+	Action: actionDecorator(reloadChanAcceptRules),
+}
+
+func reloadChanAcceptRules(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	req := &lnrpc.ReloadChannelAcceptRulesRequest{}
+	resp, err := client.ReloadChannelAcceptRules(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
 var listPeersCommand = cli.Command{
 	Name:     "listpeers",
 	Category: "Peers",
@@ -1278,6 +1305,188 @@ func listPeers(ctx *cli.Context) error {
 	return nil
 }
 
+var listGossipScoresCommand = cli.Command{
+	Name:     "listgossipscores",
+	Category: "Peers",
+	Usage:    "List the gossip accounting and ban scores of all peers.",
+	Action:   actionDecorator(listGossipScores),
+}
+
+func listGossipScores(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	req := &lnrpc.ListGossipScoresRequest{}
+	resp, err := client.ListGossipScores(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var banPeerCommand = cli.Command{
+	Name:      "banpeer",
+	Category:  "Peers",
+	Usage:     "Ban a node or an IP range from connecting to us.",
+	ArgsUsage: "[--node_key=<pubkey> | --ip_range=<cidr>] [--duration=<d>]",
+	Description: `
+	Ban a node identified by its public key, or all addresses within an IP
+	range, from connecting to us. Banned nodes are disconnected, and we stop
+	reconnecting to them even if we have channels with them. Bans of nodes
+	also apply to peers that connect through Tor.
+
+	Without a duration the ban doesn't expire. An existing ban of the same
+	node or IP range is replaced.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "node_key",
+			Usage: "the hex-encoded compressed public key of the " +
+				"node to ban",
+		},
+		cli.StringFlag{
+			Name: "ip_range",
+			Usage: "the IP range to ban in CIDR notation, or a " +
+				"single IP address",
+		},
+		cli.DurationFlag{
+			Name: "duration",
+			Usage: "the duration of the ban, e.g. 24h; if not " +
+				"set, the ban doesn't expire",
+		},
+		cli.StringFlag{
+			Name:  "reason",
+			Usage: "an optional reason for the ban",
+		},
+	},
+	Action: actionDecorator(banPeer),
+}
+
+func banPeer(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	duration := ctx.Duration("duration")
+	if duration < 0 {
+		return fmt.Errorf("duration must not be negative")
+	}
+
+	req := &lnrpc.BanPeerRequest{
+		PubKey:      ctx.String("node_key"),
+		IpRange:     ctx.String("ip_range"),
+		DurationSec: uint64(duration.Seconds()),
+		Reason:      ctx.String("reason"),
+	}
+
+	resp, err := client.BanPeer(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var unbanPeerCommand = cli.Command{
+	Name:      "unbanpeer",
+	Category:  "Peers",
+	Usage:     "Lift the ban of a node or an IP range.",
+	ArgsUsage: "[--node_key=<pubkey> | --ip_range=<cidr>]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "node_key",
+			Usage: "the hex-encoded compressed public key of the " +
+				"node to unban",
+		},
+		cli.StringFlag{
+			Name: "ip_range",
+			Usage: "the banned IP range to unban, which must " +
+				"match the range of the ban",
+		},
+	},
+	Action: actionDecorator(unbanPeer),
+}
+
+func unbanPeer(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	req := &lnrpc.UnbanPeerRequest{
+		PubKey:  ctx.String("node_key"),
+		IpRange: ctx.String("ip_range"),
+	}
+
+	resp, err := client.UnbanPeer(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var listBansCommand = cli.Command{
+	Name:     "listbans",
+	Category: "Peers",
+	Usage:    "List the nodes and IP ranges that are banned.",
+	Action:   actionDecorator(listBans),
+}
+
+func listBans(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	req := &lnrpc.ListBansRequest{}
+	resp, err := client.ListBans(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
+var listPeerQualityCommand = cli.Command{
+	Name:     "listpeerquality",
+	Category: "Peers",
+	Usage: "List the ping latency, throughput and disconnects of our " +
+		"connections to our peers.",
+	ArgsUsage: "[--node_key=<pubkey>]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name: "node_key",
+			Usage: "if set, only list the connection quality of " +
+				"the peer with this hex-encoded compressed " +
+				"public key",
+		},
+	},
+	Action: actionDecorator(listPeerQuality),
+}
+
+func listPeerQuality(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getClient(ctx)
+	defer cleanUp()
+
+	req := &lnrpc.ListPeerQualityRequest{
+		PubKey: ctx.String("node_key"),
+	}
+
+	resp, err := client.ListPeerQuality(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+	return nil
+}
+
 var walletBalanceCommand = cli.Command{
 	Name:     "walletbalance",
 	Category: "Wallet",
@@ -2036,6 +2245,23 @@ var updateChannelPolicyCommand = cli.Command{
 				"to all forwarded HTLCs. If unset, the max HTLC " +
 				"is left unchanged.",
 		},
+		cli.Int64Flag{
+			Name: "inbound_base_fee_msat",
+			Usage: "the base fee in milli-satoshis that will be " +
+				"charged for each HTLC arriving over the " +
+				"channel, a negative value is a discount. " +
+				"If neither inbound flag is set, the " +
+				"inbound fee is left unchanged.",
+		},
+		cli.Int64Flag{
+			Name: "inbound_fee_rate_ppm",
+			Usage: "the fee rate ppm (parts per million) that " +
+				"will be charged proportionally for each " +
+				"HTLC arriving over the channel, a negative " +
+				"value is a discount. If neither inbound " +
+				"flag is set, the inbound fee is left " +
+				"unchanged.",
+		},
 		cli.StringFlag{
 			Name: "chan_point",
 			Usage: "The channel whose fee policy should be " +
@@ -2176,6 +2402,29 @@ func updateChannelPolicy(ctx *cli.Context) error {
 		req.FeeRatePpm = uint32(feeRatePpm)
 	}
 
+	if ctx.IsSet("inbound_base_fee_msat") ||
+		ctx.IsSet("inbound_fee_rate_ppm") {
+
+		inboundBaseFee := ctx.Int64("inbound_base_fee_msat")
+		if inboundBaseFee < math.MinInt32 ||
+			inboundBaseFee > math.MaxInt32 {
+
+			return fmt.Errorf("inbound_base_fee_msat out of range")
+		}
+
+		inboundFeeRate := ctx.Int64("inbound_fee_rate_ppm")
+		if inboundFeeRate < math.MinInt32 ||
+			inboundFeeRate > math.MaxInt32 {
+
+			return fmt.Errorf("inbound_fee_rate_ppm out of range")
+		}
+
+		req.InboundFee = &lnrpc.InboundFee{
+			BaseFeeMsat: int32(inboundBaseFee),
+			FeeRatePpm:  int32(inboundFeeRate),
+		}
+	}
+
 	resp, err := client.UpdateChannelPolicy(ctxc, req)
 	if err != nil {
 		return err
@@ -2578,10 +2827,12 @@ func restoreChanBackup(ctx *cli.Context) error {
 
 	req.Backup = backups.Backup
 
-	_, err = client.RestoreChannelBackups(ctxc, &req)
+	resp, err := client.RestoreChannelBackups(ctxc, &req)
 	if err != nil {
 		return fmt.Errorf("unable to restore chan backups: %v", err)
 	}
 
+	printRespJSON(resp)
+
 	return nil
 }
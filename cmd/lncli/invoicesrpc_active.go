@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/lightningnetwork/lnd/lnrpc/invoicesrpc"
 	"github.com/urfave/cli"
 )
@@ -18,6 +19,10 @@ func invoicesCommands() []cli.Command {
 		cancelInvoiceCommand,
 		addHoldInvoiceCommand,
 		settleInvoiceCommand,
+		addAMPSubscriptionCommand,
+		lookupAMPSubscriptionCommand,
+		listAMPSubscriptionsCommand,
+		cancelAMPSubscriptionCommand,
 	}
 }
 
@@ -190,6 +195,44 @@ var addHoldInvoiceCommand = cli.Command{
 				"private channels in order to assist the " +
 				"payer in reaching you",
 		},
+		cli.Uint64Flag{
+			Name: "cancel_expiry_delta",
+			Usage: "the number of blocks before the expiry of " +
+				"the earliest accepted htlc at which the " +
+				"invoice is canceled automatically, if not " +
+				"set the node's default is used",
+		},
+		cli.StringFlag{
+			Name: "settle_preimage",
+			Usage: "the preimage the invoice is settled with " +
+				"automatically once settle_txid confirms",
+		},
+		cli.StringFlag{
+			Name: "settle_txid",
+			Usage: "the id of the transaction whose confirmation " +
+				"settles the invoice",
+		},
+		cli.StringFlag{
+			Name: "settle_pkscript",
+			Usage: "an output script of the settle transaction, " +
+				"which light clients need to match it",
+		},
+		cli.Uint64Flag{
+			Name: "settle_num_confs",
+			Usage: "the number of confirmations the settle " +
+				"transaction needs",
+			Value: 1,
+		},
+		cli.Uint64Flag{
+			Name: "settle_height_hint",
+			Usage: "the height below which the settle " +
+				"transaction can't have confirmed",
+		},
+		cli.BoolFlag{
+			Name: "settle_on_payment",
+			Usage: "settle the invoice once an outgoing payment " +
+				"with the same hash succeeds",
+		},
 	},
 	Action: actionDecorator(addHoldInvoice),
 }
@@ -241,6 +284,37 @@ func addHoldInvoice(ctx *cli.Context) error {
 		FallbackAddr:    ctx.String("fallback_addr"),
 		Expiry:          ctx.Int64("expiry"),
 		Private:         ctx.Bool("private"),
+
+		CancelExpiryDelta: uint32(ctx.Uint64("cancel_expiry_delta")),
+		SettleOnPayment:   ctx.Bool("settle_on_payment"),
+	}
+
+	invoice.SettlePreimage, err = hex.DecodeString(
+		ctx.String("settle_preimage"),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to parse settle_preimage: %v", err)
+	}
+
+	if ctx.IsSet("settle_txid") {
+		txid, err := chainhash.NewHashFromStr(ctx.String("settle_txid"))
+		if err != nil {
+			return fmt.Errorf("unable to parse settle_txid: %v",
+				err)
+		}
+
+		pkScript, err := hex.DecodeString(ctx.String("settle_pkscript"))
+		if err != nil {
+			return fmt.Errorf("unable to parse settle_pkscript: %v",
+				err)
+		}
+
+		invoice.SettleOnConf = &invoicesrpc.SettleOnConf{
+			Txid:       txid[:],
+			PkScript:   pkScript,
+			NumConfs:   uint32(ctx.Uint64("settle_num_confs")),
+			HeightHint: uint32(ctx.Uint64("settle_height_hint")),
+		}
 	}
 
 	resp, err := client.AddHoldInvoice(ctxc, invoice)
@@ -252,3 +326,195 @@ func addHoldInvoice(ctx *cli.Context) error {
 
 	return nil
 }
+
+// paymentHashFlag is the flag of the subscription commands that specifies the
+// AMP invoice.
+var paymentHashFlag = cli.StringFlag{
+	Name:  "paymenthash",
+	Usage: "the hex-encoded payment hash (32 byte) of the AMP invoice.",
+}
+
+// parsePaymentHash parses the payment hash of the AMP invoice from the flag or
+// the first argument.
+func parsePaymentHash(ctx *cli.Context) ([]byte, error) {
+	var hash string
+	switch {
+	case ctx.IsSet("paymenthash"):
+		hash = ctx.String("paymenthash")
+
+	case ctx.Args().Present():
+		hash = ctx.Args().First()
+
+	default:
+		return nil, fmt.Errorf("payment hash argument missing")
+	}
+
+	paymentHash, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse payment hash: %v", err)
+	}
+
+	return paymentHash, nil
+}
+
+var addAMPSubscriptionCommand = cli.Command{
+	Name:     "addampsubscription",
+	Category: "Invoices",
+	Usage:    "Add a subscription to an AMP invoice.",
+	Description: `
+	Add a subscription to an open AMP invoice, which expects the amount per
+	period to be paid to the invoice every period. Payments are accounted to
+	the period in which they are settled. A period is recorded as paid once
+	its amount was paid, and as missed if it ends before.`,
+	ArgsUsage: "paymenthash",
+	Flags: []cli.Flag{
+		paymentHashFlag,
+		cli.Uint64Flag{
+			Name: "amt_msat",
+			Usage: "the amount expected per period in " +
+				"millisatoshis",
+		},
+		cli.Uint64Flag{
+			Name:  "period",
+			Usage: "the length of a period in seconds",
+		},
+		cli.Int64Flag{
+			Name: "start_time",
+			Usage: "the start of the first period as unix " +
+				"timestamp; if not set, the first period " +
+				"starts now",
+		},
+		cli.Uint64Flag{
+			Name: "num_periods",
+			Usage: "the number of periods of the subscription; " +
+				"if not set, the subscription doesn't end",
+		},
+	},
+	Action: actionDecorator(addAMPSubscription),
+}
+
+func addAMPSubscription(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getInvoicesClient(ctx)
+	defer cleanUp()
+
+	paymentHash, err := parsePaymentHash(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.AddAMPSubscription(
+		ctxc, &invoicesrpc.AddAMPSubscriptionRequest{
+			PaymentHash:   paymentHash,
+			PeriodAmtMsat: ctx.Uint64("amt_msat"),
+			PeriodSeconds: ctx.Uint64("period"),
+			StartTime:     ctx.Int64("start_time"),
+			NumPeriods:    uint32(ctx.Uint64("num_periods")),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
+
+var lookupAMPSubscriptionCommand = cli.Command{
+	Name:      "lookupampsubscription",
+	Category:  "Invoices",
+	Usage:     "Look up the subscription of an AMP invoice.",
+	ArgsUsage: "paymenthash",
+	Flags: []cli.Flag{
+		paymentHashFlag,
+	},
+	Action: actionDecorator(lookupAMPSubscription),
+}
+
+func lookupAMPSubscription(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getInvoicesClient(ctx)
+	defer cleanUp()
+
+	paymentHash, err := parsePaymentHash(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.LookupAMPSubscription(
+		ctxc, &invoicesrpc.LookupAMPSubscriptionRequest{
+			PaymentHash: paymentHash,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
+
+var listAMPSubscriptionsCommand = cli.Command{
+	Name:     "listampsubscriptions",
+	Category: "Invoices",
+	Usage:    "List the subscriptions of all AMP invoices.",
+	Action:   actionDecorator(listAMPSubscriptions),
+}
+
+func listAMPSubscriptions(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getInvoicesClient(ctx)
+	defer cleanUp()
+
+	resp, err := client.ListAMPSubscriptions(
+		ctxc, &invoicesrpc.ListAMPSubscriptionsRequest{},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
+
+var cancelAMPSubscriptionCommand = cli.Command{
+	Name:     "cancelampsubscription",
+	Category: "Invoices",
+	Usage:    "Cancel the subscription of an AMP invoice.",
+	Description: `
+	Cancel the subscription of an AMP invoice. The periods recorded so far
+	are kept, but no further periods are recorded. The invoice itself
+	remains open.`,
+	ArgsUsage: "paymenthash",
+	Flags: []cli.Flag{
+		paymentHashFlag,
+	},
+	Action: actionDecorator(cancelAMPSubscription),
+}
+
+func cancelAMPSubscription(ctx *cli.Context) error {
+	ctxc := getContext()
+	client, cleanUp := getInvoicesClient(ctx)
+	defer cleanUp()
+
+	paymentHash, err := parsePaymentHash(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.CancelAMPSubscription(
+		ctxc, &invoicesrpc.CancelAMPSubscriptionRequest{
+			PaymentHash: paymentHash,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(resp)
+
+	return nil
+}
@@ -440,7 +440,13 @@ func main() {
 		closeChannelCommand,
 		closeAllChannelsCommand,
 		abandonChannelCommand,
+		reloadChanAcceptRulesCommand,
 		listPeersCommand,
+		listGossipScoresCommand,
+		banPeerCommand,
+		unbanPeerCommand,
+		listBansCommand,
+		listPeerQualityCommand,
 		walletBalanceCommand,
 		channelBalanceCommand,
 		getInfoCommand,
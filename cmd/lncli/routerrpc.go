@@ -10,6 +10,9 @@ func routerCommands() []cli.Command {
 		queryProbCommand,
 		resetMissionControlCommand,
 		buildRouteCommand,
+		probeDestCommand,
+		probeLiquidityCommand,
+		sendBatchCommand,
 		getCfgCommand,
 		setCfgCommand,
 		updateChanStatusCommand,
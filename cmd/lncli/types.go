@@ -43,7 +43,7 @@ func NewProtoOutPoint(op string) (*lnrpc.OutPoint, error) {
 }
 
 // Utxo displays information about an unspent output, including its address,
-// amount, pkscript, and confirmations.
+// amount, pkscript, confirmations, label and frozen state.
 type Utxo struct {
 	Type          lnrpc.AddressType `json:"address_type"`
 	Address       string            `json:"address"`
@@ -51,6 +51,8 @@ type Utxo struct {
 	PkScript      string            `json:"pk_script"`
 	OutPoint      OutPoint          `json:"outpoint"`
 	Confirmations int64             `json:"confirmations"`
+	Label         string            `json:"label"`
+	Frozen        bool              `json:"frozen"`
 }
 
 // NewUtxoFromProto creates a display Utxo from the Utxo proto. This filters out
@@ -64,6 +66,8 @@ func NewUtxoFromProto(utxo *lnrpc.Utxo) *Utxo {
 		PkScript:      utxo.PkScript,
 		OutPoint:      NewOutPointFromProto(utxo.Outpoint),
 		Confirmations: utxo.Confirmations,
+		Label:         utxo.Label,
+		Frozen:        utxo.Frozen,
 	}
 }
 
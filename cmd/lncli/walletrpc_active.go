@@ -74,6 +74,10 @@ func walletCommands() []cli.Command {
 				releaseOutputCommand,
 				leaseOutputCommand,
 				listLeasesCommand,
+				listWalletUnspentCommand,
+				freezeOutputCommand,
+				unfreezeOutputCommand,
+				labelOutputCommand,
 				psbtCommand,
 				accountsCommand,
 				requiredReserveCommand,
@@ -1043,6 +1047,209 @@ func listLeases(ctx *cli.Context) error {
 	return nil
 }
 
+var listWalletUnspentCommand = cli.Command{
+	Name:  "listunspent",
+	Usage: "List utxos of the wallet, including their labels.",
+	Description: `
+	Lists all utxos of the wallet with a number of confirmations between
+	min_confs and max_confs together with their label. Frozen utxos are
+	excluded unless the --include_frozen flag is set.
+	`,
+	Flags: []cli.Flag{
+		cli.Int64Flag{
+			Name:  "min_confs",
+			Usage: "the minimum number of confirmations for a utxo",
+		},
+		cli.Int64Flag{
+			Name:  "max_confs",
+			Usage: "the maximum number of confirmations for a utxo",
+		},
+		cli.StringFlag{
+			Name: "account",
+			Usage: "(optional) only list utxos belonging to this " +
+				"account",
+		},
+		cli.BoolFlag{
+			Name:  "include_frozen",
+			Usage: "also list utxos that have been frozen",
+		},
+	},
+	Action: actionDecorator(listWalletUnspent),
+}
+
+func listWalletUnspent(ctx *cli.Context) error {
+	ctxc := getContext()
+
+	walletClient, cleanUp := getWalletClient(ctx)
+	defer cleanUp()
+
+	req := &walletrpc.ListUnspentRequest{
+		MinConfs:      int32(ctx.Int64("min_confs")),
+		MaxConfs:      int32(ctx.Int64("max_confs")),
+		Account:       ctx.String("account"),
+		IncludeFrozen: ctx.Bool("include_frozen"),
+	}
+	resp, err := walletClient.ListUnspent(ctxc, req)
+	if err != nil {
+		return err
+	}
+
+	var listUnspentResp = struct {
+		Utxos []*Utxo `json:"utxos"`
+	}{
+		Utxos: make([]*Utxo, 0, len(resp.Utxos)),
+	}
+	for _, protoUtxo := range resp.Utxos {
+		utxo := NewUtxoFromProto(protoUtxo)
+		listUnspentResp.Utxos = append(listUnspentResp.Utxos, utxo)
+	}
+
+	printJSON(listUnspentResp)
+
+	return nil
+}
+
+var freezeOutputCommand = cli.Command{
+	Name:      "freezeoutput",
+	Usage:     "Freeze an output indefinitely.",
+	ArgsUsage: "outpoint",
+	Description: `
+	The freezeoutput command freezes an output, excluding it from any
+	automatic coin selection such as channel funding, sweeping or sending
+	coins. Contrary to a lease, a freeze never expires and is kept across
+	restarts until the output is unfrozen with the unfreezeoutput command.
+	`,
+	Action: actionDecorator(freezeOutput),
+}
+
+func freezeOutput(ctx *cli.Context) error {
+	ctxc := getContext()
+
+	// Display the command's help message if we do not have the expected
+	// number of arguments/flags.
+	if ctx.NArg() != 1 {
+		return cli.ShowCommandHelp(ctx, "freezeoutput")
+	}
+
+	outpoint, err := NewProtoOutPoint(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("error parsing outpoint: %v", err)
+	}
+
+	walletClient, cleanUp := getWalletClient(ctx)
+	defer cleanUp()
+
+	response, err := walletClient.FreezeOutput(
+		ctxc, &walletrpc.FreezeOutputRequest{
+			Outpoint: outpoint,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(response)
+
+	return nil
+}
+
+var unfreezeOutputCommand = cli.Command{
+	Name:      "unfreezeoutput",
+	Usage:     "Unfreeze a previously frozen output.",
+	ArgsUsage: "outpoint",
+	Description: `
+	The unfreezeoutput command removes the freeze of an output, making it
+	available for coin selection again if it remains unspent.
+	`,
+	Action: actionDecorator(unfreezeOutput),
+}
+
+func unfreezeOutput(ctx *cli.Context) error {
+	ctxc := getContext()
+
+	// Display the command's help message if we do not have the expected
+	// number of arguments/flags.
+	if ctx.NArg() != 1 {
+		return cli.ShowCommandHelp(ctx, "unfreezeoutput")
+	}
+
+	outpoint, err := NewProtoOutPoint(ctx.Args().First())
+	if err != nil {
+		return fmt.Errorf("error parsing outpoint: %v", err)
+	}
+
+	walletClient, cleanUp := getWalletClient(ctx)
+	defer cleanUp()
+
+	response, err := walletClient.UnfreezeOutput(
+		ctxc, &walletrpc.UnfreezeOutputRequest{
+			Outpoint: outpoint,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	printRespJSON(response)
+
+	return nil
+}
+
+var labelOutputCommand = cli.Command{
+	Name:      "labeloutput",
+	Usage:     "Adds a label to an output.",
+	ArgsUsage: "outpoint label",
+	Description: `
+	Add a persistent label to an output of the wallet. If the output already
+	has a label, this call will fail unless the overwrite option is set. The
+	label is limited to 500 characters. Note that multi word labels must be
+	contained in quotation marks ("").
+	`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "overwrite",
+			Usage: "set to overwrite existing labels",
+		},
+	},
+	Action: actionDecorator(labelOutput),
+}
+
+func labelOutput(ctx *cli.Context) error {
+	ctxc := getContext()
+
+	// Display the command's help message if we do not have the expected
+	// number of arguments/flags.
+	if ctx.NArg() != 2 {
+		return cli.ShowCommandHelp(ctx, "labeloutput")
+	}
+
+	outpointStr := ctx.Args().Get(0)
+	outpoint, err := NewProtoOutPoint(outpointStr)
+	if err != nil {
+		return fmt.Errorf("error parsing outpoint: %v", err)
+	}
+
+	label := ctx.Args().Get(1)
+
+	walletClient, cleanUp := getWalletClient(ctx)
+	defer cleanUp()
+
+	_, err = walletClient.LabelOutput(
+		ctxc, &walletrpc.LabelOutputRequest{
+			Outpoint:  outpoint,
+			Label:     label,
+			Overwrite: ctx.Bool("overwrite"),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Output: %v labelled with: %v\n", outpointStr, label)
+
+	return nil
+}
+
 var listAccountsCommand = cli.Command{
 	Name:  "list",
 	Usage: "Retrieve information of existing on-chain wallet accounts.",
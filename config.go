@@ -5,6 +5,7 @@
 package lnd
 
 import (
+	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -39,7 +40,9 @@ import (
 	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/signrpc"
 	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/paymentretention"
 	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/routing/localchans"
 	"github.com/lightningnetwork/lnd/signal"
 	"github.com/lightningnetwork/lnd/sweep"
 	"github.com/lightningnetwork/lnd/tor"
@@ -361,6 +364,7 @@ type Config struct {
 	Color                         string        `long:"color" description:"The color of the node in hex format (i.e. '#3399FF'). Used to customize node appearance in intelligence services"`
 	MinChanSize                   int64         `long:"minchansize" description:"The smallest channel size (in satoshis) that we should accept. Incoming channels smaller than this will be rejected"`
 	MaxChanSize                   int64         `long:"maxchansize" description:"The largest channel size (in satoshis) that we should accept. Incoming channels larger than this will be rejected"`
+	ChanAcceptRules               string        `long:"chan-accept-rules" description:"The path of a JSON file with rules that inbound channels are checked against, independent of any RPC channel acceptor. The rules can be reloaded with the ReloadChannelAcceptRules RPC."`
 	CoopCloseTargetConfs          uint32        `long:"coop-close-target-confs" description:"The target number of blocks that a cooperative channel close transaction should confirm in. This is used to estimate the fee to use as the lower bound during fee negotiation for the channel closure."`
 
 	ChannelCommitInterval time.Duration `long:"channel-commit-interval" description:"The maximum time that is allowed to pass between receiving a channel state update and signing the next commitment. Setting this to a longer duration allows for more efficient channel operations at the cost of latency."`
@@ -386,6 +390,10 @@ type Config struct {
 	// registered regardless of whether the RPC is called or not.
 	RequireInterceptor bool `long:"requireinterceptor" description:"Whether to always intercept HTLCs, even if no stream is attached"`
 
+	// RequirePaymentInterceptor determines whether outgoing payments are
+	// rejected while no payment interceptor is registered.
+	RequirePaymentInterceptor bool `long:"requirepaymentinterceptor" description:"Whether to reject outgoing payments while no payment interceptor stream is attached"`
+
 	StaggerInitialReconnect bool `long:"stagger-initial-reconnect" description:"If true, will apply a randomized staggering between 0s and 30s when reconnecting to persistent peers on startup. The first 10 reconnections will be attempted instantly, regardless of the flag's value"`
 
 	MaxOutgoingCltvExpiry uint32 `long:"max-cltv-expiry" description:"The maximum number of blocks funds could be locked up for when forwarding payments."`
@@ -442,8 +450,20 @@ type Config struct {
 
 	RemoteSigner *lncfg.RemoteSigner `group:"remotesigner" namespace:"remotesigner"`
 
+	WatchOnlyNode *lncfg.WatchOnlyNode `group:"watchonlynode" namespace:"watchonlynode"`
+
 	Sweeper *lncfg.Sweeper `group:"sweeper" namespace:"sweeper"`
 
+	RemoteBackup *lncfg.RemoteBackup `group:"remotebackup" namespace:"remotebackup"`
+
+	FeeAutopilot *lncfg.FeeAutopilot `group:"feeautopilot" namespace:"feeautopilot"`
+
+	SignPolicy *lncfg.SignPolicy `group:"signpolicy" namespace:"signpolicy"`
+
+	Replication *lncfg.Replication `group:"replication" namespace:"replication"`
+
+	PaymentRetention *lncfg.PaymentRetention `group:"paymentretention" namespace:"paymentretention"`
+
 	// LogWriter is the root logger that all of the daemon's subloggers are
 	// hooked up to.
 	LogWriter *build.RotatingLogWriter
@@ -618,6 +638,8 @@ func DefaultConfig() Config {
 		Gossip: &lncfg.Gossip{
 			MaxChannelUpdateBurst: discovery.DefaultMaxChannelUpdateBurst,
 			ChannelUpdateInterval: discovery.DefaultChannelUpdateInterval,
+			BanThreshold:          discovery.DefaultBanThreshold,
+			BanDuration:           discovery.DefaultBanDuration,
 		},
 		Invoices: &lncfg.Invoices{
 			HoldExpiryDelta: lncfg.DefaultHoldInvoiceExpiryDelta,
@@ -638,11 +660,31 @@ func DefaultConfig() Config {
 		CoinSelectionStrategy:     defaultCoinSelectionStrategy,
 		KeepFailedPaymentAttempts: defaultKeepFailedPaymentAttempts,
 		RemoteSigner: &lncfg.RemoteSigner{
-			Timeout: lncfg.DefaultRemoteSignerRPCTimeout,
+			Timeout:       lncfg.DefaultRemoteSignerRPCTimeout,
+			CheckInterval: lncfg.DefaultRemoteSignerCheckInterval,
+		},
+		WatchOnlyNode: &lncfg.WatchOnlyNode{
+			RetryInterval: lncfg.DefaultWatchOnlyNodeRetryInterval,
 		},
 		Sweeper: &lncfg.Sweeper{
 			BatchWindowDuration: sweep.DefaultBatchWindowDuration,
 		},
+		RemoteBackup: &lncfg.RemoteBackup{
+			Timeout:    lncfg.DefaultRemoteBackupTimeout,
+			MinBackoff: lncfg.DefaultRemoteBackupMinBackoff,
+			MaxBackoff: lncfg.DefaultRemoteBackupMaxBackoff,
+			HTTP:       &lncfg.HTTPBackupSink{},
+			S3:         &lncfg.S3BackupSink{},
+		},
+		FeeAutopilot: &lncfg.FeeAutopilot{
+			Interval: localchans.DefaultFeeAutopilotInterval,
+			Lookback: localchans.DefaultFeeAutopilotLookback,
+		},
+		SignPolicy:  &lncfg.SignPolicy{},
+		Replication: lncfg.DefaultReplication(),
+		PaymentRetention: &lncfg.PaymentRetention{
+			Interval: paymentretention.DefaultInterval,
+		},
 	}
 }
 
@@ -871,6 +913,12 @@ func ValidateConfig(cfg Config, interceptor signal.Interceptor, fileParser,
 	cfg.ReadMacPath = CleanAndExpandPath(cfg.ReadMacPath)
 	cfg.InvoiceMacPath = CleanAndExpandPath(cfg.InvoiceMacPath)
 	cfg.LogDir = CleanAndExpandPath(cfg.LogDir)
+	cfg.Replication.TLSCertPath = CleanAndExpandPath(
+		cfg.Replication.TLSCertPath,
+	)
+	cfg.PaymentRetention.ArchiveFile = CleanAndExpandPath(
+		cfg.PaymentRetention.ArchiveFile,
+	)
 	cfg.BtcdMode.Dir = CleanAndExpandPath(cfg.BtcdMode.Dir)
 	cfg.LtcdMode.Dir = CleanAndExpandPath(cfg.LtcdMode.Dir)
 	cfg.BitcoindMode.Dir = CleanAndExpandPath(cfg.BitcoindMode.Dir)
@@ -889,6 +937,7 @@ func ValidateConfig(cfg Config, interceptor signal.Interceptor, fileParser,
 	cfg.Tor.WatchtowerKeyPath = CleanAndExpandPath(cfg.Tor.WatchtowerKeyPath)
 	cfg.Watchtower.TowerDir = CleanAndExpandPath(cfg.Watchtower.TowerDir)
 	cfg.BackupFilePath = CleanAndExpandPath(cfg.BackupFilePath)
+	cfg.ChanAcceptRules = CleanAndExpandPath(cfg.ChanAcceptRules)
 	cfg.WalletUnlockPasswordFile = CleanAndExpandPath(
 		cfg.WalletUnlockPasswordFile,
 	)
@@ -1659,12 +1708,46 @@ func ValidateConfig(cfg Config, interceptor signal.Interceptor, fileParser,
 		cfg.HealthChecks,
 		cfg.RPCMiddleware,
 		cfg.RemoteSigner,
+		cfg.WatchOnlyNode,
 		cfg.Sweeper,
+		cfg.RemoteBackup,
+		cfg.FeeAutopilot,
+		cfg.SignPolicy,
+		cfg.Replication,
+		cfg.PaymentRetention,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	// The replication records the changes of bolt transactions, the other
+	// backends replicate the databases themselves.
+	if cfg.Replication.Active() && cfg.DB.Backend != lncfg.BoltBackend {
+		return nil, mkErr("replication requires the %v db backend",
+			lncfg.BoltBackend)
+	}
+
+	// A node that uses a remote signer can't be the remote signer of a
+	// watch-only node itself.
+	if cfg.RemoteSigner.Enable && cfg.WatchOnlyNode.Enable {
+		return nil, mkErr("remotesigner.enable and " +
+			"watchonlynode.enable cannot be set at the same time")
+	}
+
+	// The signing policy protects the keys of a remote signer, a node
+	// that doesn't hold its keys itself has nothing to enforce it on.
+	if cfg.RemoteSigner.Enable && cfg.SignPolicy.Enable {
+		return nil, mkErr("remotesigner.enable and " +
+			"signpolicy.enable cannot be set at the same time")
+	}
+
+	_, err = cfg.SignPolicy.ParseAllowedAddresses(
+		cfg.ActiveNetParams.Params,
+	)
+	if err != nil {
+		return nil, mkErr("%v", err)
+	}
+
 	// Finally, ensure that the user's color is correctly formatted,
 	// otherwise the server will not be able to start after the unlocking
 	// the wallet.
@@ -1686,6 +1769,19 @@ func (c *Config) graphDatabaseDir() string {
 	)
 }
 
+// getDatabaseBackends opens the database backends as set in the config.
+func (c *Config) getDatabaseBackends(
+	ctx context.Context) (*lncfg.DatabaseBackends, error) {
+
+	return c.DB.GetBackends(
+		ctx, c.graphDatabaseDir(), c.networkDir, filepath.Join(
+			c.Watchtower.TowerDir,
+			c.registeredChains.PrimaryChain().String(),
+			lncfg.NormalizeNetwork(c.ActiveNetParams.Name),
+		), c.WtClient.Active, c.Watchtower.Active,
+	)
+}
+
 // ImplementationConfig returns the configuration of what actual implementations
 // should be used when creating the main lnd instance.
 func (c *Config) ImplementationConfig(
@@ -33,6 +33,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnwallet/btcwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/rpcwallet"
 	"github.com/lightningnetwork/lnd/macaroons"
+	"github.com/lightningnetwork/lnd/replication"
 	"github.com/lightningnetwork/lnd/rpcperms"
 	"github.com/lightningnetwork/lnd/signal"
 	"github.com/lightningnetwork/lnd/walletunlocker"
@@ -402,6 +403,10 @@ func (d *DefaultWalletImpl) BuildWalletConfig(ctx context.Context,
 			rootKeyStore, "lnd", walletInitParams.StatelessInit,
 			macaroons.IPLockChecker,
 			macaroons.CustomChecker(interceptorChain),
+			macaroons.MaxPaymentChecker,
+			macaroons.PaymentBudgetChecker,
+			macaroons.AllowedDestinationsChecker,
+			macaroons.MaxOnChainSendChecker,
 		)
 		if err != nil {
 			err := fmt.Errorf("unable to set up macaroon "+
@@ -782,6 +787,10 @@ type DatabaseInstances struct {
 	// WalletDB is the configuration for loading the wallet database using
 	// the btcwallet's loader.
 	WalletDB btcwallet.LoaderOption
+
+	// Replication is the primary side of the replication to a standby.
+	// This is nil if the node isn't a replication primary.
+	Replication *replication.Primary
 }
 
 // DefaultDatabaseBuilder is a type that builds the default database backends
@@ -821,18 +830,48 @@ func (d *DefaultDatabaseBuilder) BuildDatabase(
 
 	startOpenTime := time.Now()
 
-	databaseBackends, err := cfg.DB.GetBackends(
-		ctx, cfg.graphDatabaseDir(), cfg.networkDir, filepath.Join(
-			cfg.Watchtower.TowerDir,
-			cfg.registeredChains.PrimaryChain().String(),
-			lncfg.NormalizeNetwork(cfg.ActiveNetParams.Name),
-		), cfg.WtClient.Active, cfg.Watchtower.Active,
-	)
+	databaseBackends, err := cfg.getDatabaseBackends(ctx)
 	if err != nil {
 		return nil, nil, fmt.Errorf("unable to obtain database "+
 			"backends: %v", err)
 	}
 
+	closeBackends := func() {
+		for name, closeFunc := range databaseBackends.CloseFuncs {
+			if err := closeFunc(); err != nil {
+				d.logger.Errorf("Error closing %s "+
+					"database: %v", name, err)
+			}
+		}
+	}
+
+	// The bolt databases may belong to a replication standby, which we
+	// only take over if it was in sync with its primary. On the primary,
+	// all transactions go through the replication from now on.
+	var primary *replication.Primary
+	if cfg.DB.Backend == lncfg.BoltBackend {
+		err := checkReplicationPromotion(cfg, databaseBackends)
+		if err != nil {
+			closeBackends()
+			return nil, nil, err
+		}
+
+		if cfg.Replication.Role == lncfg.ReplicationRolePrimary {
+			primary, err = newReplicationPrimary(
+				cfg, databaseBackends,
+			)
+			if err != nil {
+				closeBackends()
+				return nil, nil, err
+			}
+
+			if err := primary.Start(); err != nil {
+				closeBackends()
+				return nil, nil, err
+			}
+		}
+	}
+
 	// With the full remote mode we made sure both the graph and channel
 	// state DB point to the same local or remote DB and the same namespace
 	// within that DB.
@@ -841,8 +880,18 @@ func (d *DefaultDatabaseBuilder) BuildDatabase(
 		MacaroonDB:   databaseBackends.MacaroonDB,
 		DecayedLogDB: databaseBackends.DecayedLogDB,
 		WalletDB:     databaseBackends.WalletDB,
+		Replication:  primary,
 	}
 	cleanUp := func() {
+		// The standby must be detached before the databases are
+		// closed, so it knows it's no longer in sync.
+		if primary != nil {
+			if err := primary.Stop(); err != nil {
+				d.logger.Errorf("Error stopping replication: "+
+					"%v", err)
+			}
+		}
+
 		// We can just close the returned close functions directly. Even
 		// if we decorate the channel DB with an additional struct, its
 		// close function still just points to the kvdb backend.
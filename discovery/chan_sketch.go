@@ -0,0 +1,264 @@
+package discovery
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+const (
+	// sketchHashes is the number of cells that each short channel ID is
+	// added to. The cells of a sketch are split into this many partitions,
+	// so that the cells of an ID never collide with each other.
+	sketchHashes = 3
+
+	// sketchCellSize is the serialized size of a single sketch cell.
+	sketchCellSize = 4 + 8 + 4
+
+	// defaultSketchCells is the number of cells of the first sketch that we
+	// request from a peer. It is sized for the regular sync of the most
+	// recent blocks, where we expect only a few new channels.
+	defaultSketchCells = 96
+
+	// maxSketchCells is the maximum number of cells of a sketch, chosen so
+	// that the sketch fits into a single ReplyChannelSketch message.
+	maxSketchCells = 3840
+
+	// sketchGrowthFactor is the factor by which we increase the number of
+	// cells of the sketch we request after failing to decode a sketch.
+	sketchGrowthFactor = 4
+)
+
+var (
+	// errSketchUndecodable is returned when the set difference can't be
+	// recovered from a sketch because it has too few cells.
+	errSketchUndecodable = errors.New("sketch has too few cells to " +
+		"decode the set difference")
+
+	// errSketchMalformed is returned when a sketch decodes to an ID more
+	// than once, which can't happen for the difference of two honestly
+	// created sketches.
+	errSketchMalformed = errors.New("sketch is malformed")
+)
+
+// validSketchCells returns true if a sketch can be created with the given
+// number of cells.
+func validSketchCells(numCells uint16) bool {
+	return numCells > 0 && numCells <= maxSketchCells &&
+		numCells%sketchHashes == 0
+}
+
+// sketchDecodeCapacity returns the size of the set difference that can
+// reliably be decoded from a sketch with the given number of cells.
+func sketchDecodeCapacity(numCells uint16) uint32 {
+	return uint32(numCells) * 2 / 3
+}
+
+// sketchCell is a single cell of a chanSketch.
+type sketchCell struct {
+	// count is the number of IDs added to the cell minus the number of
+	// IDs removed from it.
+	count int32
+
+	// idSum is the XOR of all IDs in the cell.
+	idSum uint64
+
+	// hashSum is the XOR of the check hashes of all IDs in the cell.
+	hashSum uint32
+}
+
+// chanSketch is an invertible bloom lookup table over a set of short channel
+// IDs. Subtracting the sketch of one set from the sketch of another leaves a
+// sketch of their symmetric difference, which can be decoded as long as the
+// difference is small compared to the number of cells. The size of a sketch
+// therefore only depends on the number of channels two nodes disagree on,
+// not on the number of channels they know of.
+type chanSketch struct {
+	// salt is mixed into the hashes of the IDs. Sketches can only be
+	// subtracted from each other if they use the same salt.
+	salt uint64
+
+	cells []sketchCell
+}
+
+// newChanSketch creates an empty sketch with the given number of cells, which
+// must be valid according to validSketchCells.
+func newChanSketch(numCells uint16, salt uint64) *chanSketch {
+	return &chanSketch{
+		salt:  salt,
+		cells: make([]sketchCell, numCells),
+	}
+}
+
+// hash returns the indexes of the cells of the given ID and its check hash.
+func (s *chanSketch) hash(id uint64) ([sketchHashes]int, uint32) {
+	var preimage [16]byte
+	binary.BigEndian.PutUint64(preimage[:8], s.salt)
+	binary.BigEndian.PutUint64(preimage[8:], id)
+	digest := sha256.Sum256(preimage[:])
+
+	partitionSize := uint32(len(s.cells) / sketchHashes)
+
+	var indexes [sketchHashes]int
+	for i := range indexes {
+		offset := binary.BigEndian.Uint32(digest[i*4:])
+		indexes[i] = i*int(partitionSize) + int(offset%partitionSize)
+	}
+
+	check := binary.BigEndian.Uint32(digest[sketchHashes*4:])
+
+	return indexes, check
+}
+
+// toggle adds the given count of the ID to its cells.
+func (s *chanSketch) toggle(id uint64, count int32) {
+	indexes, check := s.hash(id)
+	for _, i := range indexes {
+		s.cells[i].count += count
+		s.cells[i].idSum ^= id
+		s.cells[i].hashSum ^= check
+	}
+}
+
+// add adds the short channel ID to the sketch.
+func (s *chanSketch) add(chanID lnwire.ShortChannelID) {
+	s.toggle(chanID.ToUint64(), 1)
+}
+
+// subtract removes the set of the other sketch from this sketch, leaving a
+// sketch of the symmetric difference of both sets.
+func (s *chanSketch) subtract(other *chanSketch) error {
+	if s.salt != other.salt || len(s.cells) != len(other.cells) {
+		return errors.New("sketches of different salt or size")
+	}
+
+	for i := range s.cells {
+		s.cells[i].count -= other.cells[i].count
+		s.cells[i].idSum ^= other.cells[i].idSum
+		s.cells[i].hashSum ^= other.cells[i].hashSum
+	}
+
+	return nil
+}
+
+// pure returns true if the cell holds a single ID.
+func (s *chanSketch) pure(cell *sketchCell) bool {
+	if cell.count != 1 && cell.count != -1 {
+		return false
+	}
+
+	_, check := s.hash(cell.idSum)
+
+	return cell.hashSum == check
+}
+
+// decode recovers the symmetric difference from a sketch produced by
+// subtract. It returns the IDs that are only in the set of this sketch and
+// the IDs that are only in the set of the subtracted sketch, both in
+// ascending order. The sketch is emptied in the process. If the difference
+// is too large for the sketch, errSketchUndecodable is returned.
+//
+// As the sketch may have been crafted by a peer, decoding is bounded: peeling
+// an ID empties the cell it was peeled from for good, so an honest sketch
+// never decodes to more IDs than it has cells, or to the same ID twice. If it
+// does, errSketchMalformed is returned.
+func (s *chanSketch) decode() ([]lnwire.ShortChannelID,
+	[]lnwire.ShortChannelID, error) {
+
+	var added, removed []lnwire.ShortChannelID
+	decoded := make(map[uint64]struct{})
+
+	// Peel off the IDs of pure cells until none are left. Removing an ID
+	// from its other cells may make those pure in turn.
+	for peeled := true; peeled; {
+		peeled = false
+
+		for i := range s.cells {
+			cell := &s.cells[i]
+			if !s.pure(cell) {
+				continue
+			}
+
+			id := cell.idSum
+			if _, ok := decoded[id]; ok {
+				return nil, nil, errSketchMalformed
+			}
+			if len(decoded) >= len(s.cells) {
+				return nil, nil, errSketchMalformed
+			}
+			decoded[id] = struct{}{}
+
+			if cell.count == 1 {
+				added = append(added, lnwire.NewShortChanIDFromInt(id))
+			} else {
+				removed = append(
+					removed, lnwire.NewShortChanIDFromInt(id),
+				)
+			}
+
+			s.toggle(id, -cell.count)
+			peeled = true
+		}
+	}
+
+	// Any cell that isn't empty now holds IDs that we couldn't separate.
+	for _, cell := range s.cells {
+		if cell != (sketchCell{}) {
+			return nil, nil, errSketchUndecodable
+		}
+	}
+
+	sortChanIDs(added)
+	sortChanIDs(removed)
+
+	return added, removed, nil
+}
+
+// serialize encodes the sketch for a ReplyChannelSketch message.
+func (s *chanSketch) serialize() lnwire.ChannelSketch {
+	sketch := make(lnwire.ChannelSketch, 0, len(s.cells)*sketchCellSize)
+
+	var b [sketchCellSize]byte
+	for _, cell := range s.cells {
+		binary.BigEndian.PutUint32(b[:4], uint32(cell.count))
+		binary.BigEndian.PutUint64(b[4:12], cell.idSum)
+		binary.BigEndian.PutUint32(b[12:], cell.hashSum)
+		sketch = append(sketch, b[:]...)
+	}
+
+	return sketch
+}
+
+// deserializeChanSketch decodes a sketch that was created with the given
+// number of cells and salt.
+func deserializeChanSketch(sketch lnwire.ChannelSketch, numCells uint16,
+	salt uint64) (*chanSketch, error) {
+
+	if len(sketch) != int(numCells)*sketchCellSize {
+		return nil, fmt.Errorf("sketch of %v bytes doesn't have %v "+
+			"cells", len(sketch), numCells)
+	}
+
+	s := newChanSketch(numCells, salt)
+	for i := range s.cells {
+		b := sketch[i*sketchCellSize:]
+		s.cells[i] = sketchCell{
+			count:   int32(binary.BigEndian.Uint32(b[:4])),
+			idSum:   binary.BigEndian.Uint64(b[4:12]),
+			hashSum: binary.BigEndian.Uint32(b[12:16]),
+		}
+	}
+
+	return s, nil
+}
+
+// sortChanIDs sorts the short channel IDs in ascending order.
+func sortChanIDs(chanIDs []lnwire.ShortChannelID) {
+	sort.Slice(chanIDs, func(i, j int) bool {
+		return chanIDs[i].ToUint64() < chanIDs[j].ToUint64()
+	})
+}
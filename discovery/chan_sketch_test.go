@@ -0,0 +1,196 @@
+package discovery
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/stretchr/testify/require"
+)
+
+// randChanIDs returns the given number of random short channel IDs.
+func randChanIDs(r *rand.Rand, num int) []lnwire.ShortChannelID {
+	chanIDs := make([]lnwire.ShortChannelID, 0, num)
+	for i := 0; i < num; i++ {
+		chanIDs = append(chanIDs, lnwire.ShortChannelID{
+			BlockHeight: uint32(r.Int31n(700_000)),
+			TxIndex:     uint32(r.Int31n(3000)),
+			TxPosition:  uint16(r.Int31n(10)),
+		})
+	}
+	sortChanIDs(chanIDs)
+
+	return chanIDs
+}
+
+// TestChanSketch asserts that the symmetric difference of two sets of short
+// channel IDs can be recovered from their sketches, as long as the sketches
+// have enough cells.
+func TestChanSketch(t *testing.T) {
+	t.Parallel()
+
+	r := rand.New(rand.NewSource(1))
+
+	common := randChanIDs(r, 5000)
+	onlyLocal := randChanIDs(r, 20)
+	onlyRemote := randChanIDs(r, 40)
+
+	newSketch := func(numCells uint16, salt uint64,
+		sets ...[]lnwire.ShortChannelID) *chanSketch {
+
+		sketch := newChanSketch(numCells, salt)
+		for _, set := range sets {
+			for _, chanID := range set {
+				sketch.add(chanID)
+			}
+		}
+
+		return sketch
+	}
+
+	localSketch := newSketch(defaultSketchCells, 7, common, onlyLocal)
+	remoteSketch := newSketch(defaultSketchCells, 7, common, onlyRemote)
+
+	// The remote sketch is sent over the wire.
+	remoteSketch, err := deserializeChanSketch(
+		remoteSketch.serialize(), defaultSketchCells, 7,
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, remoteSketch.subtract(localSketch))
+	added, removed, err := remoteSketch.decode()
+	require.NoError(t, err)
+	require.Equal(t, onlyRemote, added)
+	require.Equal(t, onlyLocal, removed)
+
+	// Identical sets have an empty difference.
+	localSketch = newSketch(defaultSketchCells, 7, common)
+	remoteSketch = newSketch(defaultSketchCells, 7, common)
+	require.NoError(t, remoteSketch.subtract(localSketch))
+	added, removed, err = remoteSketch.decode()
+	require.NoError(t, err)
+	require.Empty(t, added)
+	require.Empty(t, removed)
+
+	// A difference larger than the sketch can't be decoded.
+	onlyRemote = randChanIDs(r, defaultSketchCells)
+	localSketch = newSketch(defaultSketchCells, 7, common)
+	remoteSketch = newSketch(defaultSketchCells, 7, common, onlyRemote)
+	require.NoError(t, remoteSketch.subtract(localSketch))
+	_, _, err = remoteSketch.decode()
+	require.ErrorIs(t, err, errSketchUndecodable)
+
+	// Sketches of different salts or sizes can't be subtracted.
+	require.Error(t, newSketch(96, 7).subtract(newSketch(96, 8)))
+	require.Error(t, newSketch(96, 7).subtract(newSketch(99, 7)))
+
+	// A serialized sketch must have the requested number of cells.
+	_, err = deserializeChanSketch(
+		newSketch(96, 7).serialize(), 99, 7,
+	)
+	require.Error(t, err)
+}
+
+// TestChanSketchAdversarial asserts that decoding a sketch crafted by a peer
+// terminates, and that sketches which decode to the same ID repeatedly are
+// rejected.
+func TestChanSketchAdversarial(t *testing.T) {
+	t.Parallel()
+
+	// A single cell that claims to hold an ID at the first of its indexes
+	// is pure. Peeling it leaves the ID negated in its two other cells,
+	// and peeling those restores the first cell, so without a bound the
+	// same ID would be decoded forever.
+	const id = 0x0a0b0c0d0e0f
+	sketch := newChanSketch(defaultSketchCells, 7)
+	indexes, check := sketch.hash(id)
+	sketch.cells[indexes[0]] = sketchCell{
+		count:   1,
+		idSum:   id,
+		hashSum: check,
+	}
+
+	_, _, err := sketch.decode()
+	require.ErrorIs(t, err, errSketchMalformed)
+
+	// Sketches of random cells, some of which are pure, must neither hang
+	// nor decode to more IDs than they have cells.
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		sketch := newChanSketch(maxSketchCells, r.Uint64())
+		for j := range sketch.cells {
+			id := r.Uint64()
+			_, check := sketch.hash(id)
+			sketch.cells[j] = sketchCell{
+				count:   int32(r.Intn(3) - 1),
+				idSum:   id,
+				hashSum: check,
+			}
+		}
+
+		added, removed, err := sketch.decode()
+		if err == nil {
+			require.LessOrEqual(
+				t, len(added)+len(removed), maxSketchCells,
+			)
+		}
+	}
+}
+
+// TestNextSketchCells asserts that the sketches we request grow until they
+// can decode the lower bound of the set difference, and that we give up once
+// no sketch that fits into a message can.
+func TestNextSketchCells(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		numCells uint16
+		minDiff  uint32
+		next     uint16
+		ok       bool
+	}{
+		{
+			name:     "grow by factor",
+			numCells: defaultSketchCells,
+			next:     defaultSketchCells * sketchGrowthFactor,
+			ok:       true,
+		},
+		{
+			name:     "grow to lower bound",
+			numCells: defaultSketchCells,
+			minDiff:  1000,
+			next:     1503,
+			ok:       true,
+		},
+		{
+			name:     "capped at max",
+			numCells: 1536,
+			minDiff:  2000,
+			next:     maxSketchCells,
+			ok:       true,
+		},
+		{
+			name:     "already at max",
+			numCells: maxSketchCells,
+		},
+		{
+			name:     "difference too large",
+			numCells: defaultSketchCells,
+			minDiff:  sketchDecodeCapacity(maxSketchCells) + 1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			next, ok := nextSketchCells(test.numCells, test.minDiff)
+			require.Equal(t, test.ok, ok)
+			require.Equal(t, test.next, next)
+
+			if ok {
+				require.True(t, validSketchCells(next))
+			}
+		})
+	}
+}
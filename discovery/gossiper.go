@@ -62,6 +62,10 @@ var (
 	// the remote peer.
 	ErrGossipSyncerNotFound = errors.New("gossip syncer not found")
 
+	// errInvalidNodeAnn is returned when a node announcement fails
+	// validation.
+	errInvalidNodeAnn = errors.New("unable to validate node announcement")
+
 	// emptyPubkey is used to compare compressed pubkeys against an empty
 	// byte array.
 	emptyPubkey [33]byte
@@ -311,6 +315,28 @@ type Config struct {
 	// ChannelID. This is used to sign updates for them if the channel has
 	// no AuthProof and the option-scid-alias feature bit was negotiated.
 	GetAlias func(lnwire.ChannelID) (lnwire.ShortChannelID, error)
+
+	// BanThreshold is the ban score at which the gossip of a peer is
+	// banned. Messages with invalid signatures or that are strictly
+	// older than the ones we know add to the score of the peer that sent
+	// them. A zero value disables banning.
+	BanThreshold uint32
+
+	// BanDuration is the duration for which the gossip of a peer is
+	// banned once its ban score reached the BanThreshold.
+	BanDuration time.Duration
+
+	// PeerBandwidth is the number of bytes of gossip per second that we
+	// accept from a single peer. Messages that exceed it are dropped. A
+	// zero value disables the limit.
+	PeerBandwidth uint64
+
+	// IsChannelPeer returns true if we have channels with the given peer.
+	// Banned peers are only disconnected if we don't.
+	IsChannelPeer func(peer *btcec.PublicKey) (bool, error)
+
+	// DisconnectPeer disconnects the given peer.
+	DisconnectPeer func(peer *btcec.PublicKey) error
 }
 
 // processedNetworkMsg is a wrapper around networkMsg and a boolean. It is
@@ -461,6 +487,10 @@ type AuthenticatedGossiper struct {
 	// AuthenticatedGossiper lock.
 	chanUpdateRateLimiter map[uint64][2]*rate.Limiter
 
+	// peerScores keeps the gossip accounting of our peers and decides
+	// which of them are banned.
+	peerScores *peerScoreboard
+
 	sync.Mutex
 }
 
@@ -479,6 +509,9 @@ func New(cfg Config, selfKeyDesc *keychain.KeyDescriptor) *AuthenticatedGossiper
 		channelMtx:              multimutex.NewMutex(),
 		recentRejects:           lru.NewCache(maxRejectedUpdates),
 		chanUpdateRateLimiter:   make(map[uint64][2]*rate.Limiter),
+		peerScores: newPeerScoreboard(
+			cfg.BanThreshold, cfg.BanDuration, cfg.PeerBandwidth,
+		),
 	}
 
 	gossiper.syncMgr = newSyncManager(&SyncManagerCfg{
@@ -691,7 +724,9 @@ func (d *AuthenticatedGossiper) ProcessRemoteAnnouncement(msg lnwire.Message,
 	case *lnwire.QueryShortChanIDs,
 		*lnwire.QueryChannelRange,
 		*lnwire.ReplyChannelRange,
-		*lnwire.ReplyShortChanIDsEnd:
+		*lnwire.ReplyShortChanIDsEnd,
+		*lnwire.QueryChannelSketch,
+		*lnwire.ReplyChannelSketch:
 
 		syncer, ok := d.syncMgr.GossipSyncer(peer.PubKey())
 		if !ok {
@@ -751,6 +786,35 @@ func (d *AuthenticatedGossiper) ProcessRemoteAnnouncement(msg lnwire.Message,
 		}
 	}
 
+	// We drop the gossip of banned peers and of peers that exceed their
+	// gossip bandwidth before spending any resources on validating it.
+	// Announcement signatures and the updates of our own channels are
+	// exempt, as we need them to use our channels with the peer.
+	if _, ok := msg.(*lnwire.AnnounceSignatures); !ok {
+		peerPub := route.Vertex(peer.PubKey())
+
+		switch {
+		case d.peerScores.banned(peerPub) &&
+			!d.isOwnChannelUpdate(msg):
+
+			log.Tracef("Dropping %v from banned peer=%x",
+				msg.MsgType(), peer.PubKey())
+
+			errChan <- ErrGossipBanned
+			return errChan
+
+		case !d.peerScores.allowBandwidth(peerPub, msg) &&
+			!d.isOwnChannelUpdate(msg):
+
+			log.Debugf("Dropping %v from peer=%x exceeding its "+
+				"gossip bandwidth", msg.MsgType(),
+				peer.PubKey())
+
+			errChan <- ErrGossipBandwidthExceeded
+			return errChan
+		}
+	}
+
 	nMsg := &networkMsg{
 		msg:      msg,
 		isRemote: true,
@@ -1350,6 +1414,10 @@ func (d *AuthenticatedGossiper) networkHandler() {
 					"announcements: %v", err)
 			}
 
+			// We'll also drop the gossip accounting of
+			// disconnected peers whose ban score decayed.
+			d.peerScores.prune()
+
 		// The gossiper has been signalled to exit, to we exit our
 		// main loop so the wait group can be decremented.
 		case <-d.quit:
@@ -1373,6 +1441,144 @@ func (d *AuthenticatedGossiper) InitSyncState(syncPeer lnpeer.Peer) {
 // existing GossipSyncer assigned to the peer and free up resources.
 func (d *AuthenticatedGossiper) PruneSyncState(peer route.Vertex) {
 	d.syncMgr.PruneSyncState(peer)
+	d.peerScores.forget(peer)
+}
+
+// PeerGossipScores returns the gossip accounting of our peers, sorted by their
+// ban score in descending order.
+func (d *AuthenticatedGossiper) PeerGossipScores() []PeerGossipScore {
+	return d.peerScores.scores()
+}
+
+// ShouldDisconnect returns true if the gossip of the given peer is banned and
+// we don't have any channels with it. Connections to such peers should be
+// refused until the ban ends.
+func (d *AuthenticatedGossiper) ShouldDisconnect(
+	peer *btcec.PublicKey) (bool, error) {
+
+	if !d.peerScores.banned(route.NewVertex(peer)) {
+		return false, nil
+	}
+
+	isChanPeer, err := d.cfg.IsChannelPeer(peer)
+	if err != nil {
+		return false, err
+	}
+
+	return !isChanPeer, nil
+}
+
+// penalize accounts the offense to the remote peer that sent the message. If
+// the gossip of the peer gets banned as a result, the peer is disconnected
+// unless we have channels with it.
+func (d *AuthenticatedGossiper) penalize(nMsg *networkMsg,
+	offense gossipOffense) {
+
+	if !nMsg.isRemote || nMsg.peer == nil {
+		return
+	}
+
+	// The updates of our own channels are never held against a peer, so
+	// that a buggy channel peer can't get the updates of our shared
+	// channels dropped.
+	if d.isOwnChannelUpdate(nMsg.msg) {
+		return
+	}
+
+	peerPub := nMsg.peer.IdentityKey()
+	if !d.peerScores.record(route.NewVertex(peerPub), offense) {
+		return
+	}
+
+	log.Warnf("Banning gossip of peer=%x for %v",
+		peerPub.SerializeCompressed(), d.cfg.BanDuration)
+
+	shouldDisconnect, err := d.ShouldDisconnect(peerPub)
+	if err != nil {
+		log.Errorf("Unable to determine whether to disconnect "+
+			"peer=%x: %v", peerPub.SerializeCompressed(), err)
+		return
+	}
+	if !shouldDisconnect {
+		return
+	}
+
+	// We disconnect the peer in a goroutine, as disconnecting waits for
+	// the peer to shut down, which may be waiting for us.
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		if err := d.cfg.DisconnectPeer(peerPub); err != nil {
+			log.Debugf("Unable to disconnect banned peer=%x: %v",
+				peerPub.SerializeCompressed(), err)
+		}
+	}()
+}
+
+// isOwnChannelUpdate returns true if the message is a channel update of one of
+// our own channels, including private ones.
+func (d *AuthenticatedGossiper) isOwnChannelUpdate(msg lnwire.Message) bool {
+	upd, ok := msg.(*lnwire.ChannelUpdate)
+	if !ok {
+		return false
+	}
+
+	// Aliases are only assigned to our own channels.
+	if d.cfg.IsAlias(upd.ShortChannelID) {
+		return true
+	}
+
+	chanInfo, _, _, err := d.cfg.Router.GetChannelByID(upd.ShortChannelID)
+	if err != nil {
+		return false
+	}
+
+	self := route.NewVertex(d.selfKey)
+
+	return chanInfo.NodeKey1Bytes == self || chanInfo.NodeKey2Bytes == self
+}
+
+// isOlderNodeAnn returns true if the remote node announcement is strictly
+// older than the announcement of the node that we know. Honest peers relay
+// announcements that we already have, so only those count as stale.
+func (d *AuthenticatedGossiper) isOlderNodeAnn(nMsg *networkMsg,
+	node route.Vertex, timestamp time.Time) bool {
+
+	if !nMsg.isRemote {
+		return false
+	}
+
+	known, err := d.cfg.Router.FetchLightningNode(node)
+	if err != nil {
+		return false
+	}
+
+	return timestamp.Before(known.LastUpdate)
+}
+
+// isOlderEdgePolicy returns true if the remote channel update is strictly
+// older than the policy of the same direction that we know. Honest peers relay
+// updates that we already have, so only those count as stale.
+func (d *AuthenticatedGossiper) isOlderEdgePolicy(nMsg *networkMsg,
+	scid lnwire.ShortChannelID, timestamp time.Time,
+	flags lnwire.ChanUpdateChanFlags) bool {
+
+	if !nMsg.isRemote {
+		return false
+	}
+
+	_, e1, e2, err := d.cfg.Router.GetChannelByID(scid)
+	if err != nil {
+		return false
+	}
+
+	known := e1
+	if flags&lnwire.ChanUpdateDirection == 1 {
+		known = e2
+	}
+
+	return known != nil && timestamp.Before(known.LastUpdate)
 }
 
 // isRecentlyRejectedMsg returns true if we recently rejected a message, and
@@ -1731,8 +1937,7 @@ func (d *AuthenticatedGossiper) addNode(msg *lnwire.NodeAnnouncement,
 	op ...batch.SchedulerOption) error {
 
 	if err := routing.ValidateNodeAnn(msg); err != nil {
-		return fmt.Errorf("unable to validate node announcement: %v",
-			err)
+		return fmt.Errorf("%w: %v", errInvalidNodeAnn, err)
 	}
 
 	timestamp := time.Unix(int64(msg.Timestamp), 0)
@@ -2148,6 +2353,9 @@ func (d *AuthenticatedGossiper) handleNodeAnnouncement(nMsg *networkMsg,
 	// this node so we can skip validating signatures if not required.
 	if d.cfg.Router.IsStaleNode(nodeAnn.NodeID, timestamp) {
 		log.Debugf("Skipped processing stale node: %x", nodeAnn.NodeID)
+		if d.isOlderNodeAnn(nMsg, nodeAnn.NodeID, timestamp) {
+			d.penalize(nMsg, gossipStale)
+		}
 		nMsg.err <- nil
 		return nil, true
 	}
@@ -2156,6 +2364,16 @@ func (d *AuthenticatedGossiper) handleNodeAnnouncement(nMsg *networkMsg,
 		log.Debugf("Adding node: %x got error: %v", nodeAnn.NodeID,
 			err)
 
+		switch {
+		case errors.Is(err, errInvalidNodeAnn):
+			d.penalize(nMsg, gossipInvalid)
+
+		case routing.IsError(err, routing.ErrOutdated) &&
+			d.isOlderNodeAnn(nMsg, nodeAnn.NodeID, timestamp):
+
+			d.penalize(nMsg, gossipStale)
+		}
+
 		if !routing.IsError(
 			err,
 			routing.ErrOutdated,
@@ -2259,6 +2477,7 @@ func (d *AuthenticatedGossiper) handleChanAnnouncement(nMsg *networkMsg,
 	// At this point, we'll now ask the router if this is a zombie/known
 	// edge. If so we can skip all the processing below.
 	if d.cfg.Router.IsKnownEdge(ann.ShortChannelID) {
+		d.penalize(nMsg, gossipDuplicate)
 		nMsg.err <- nil
 		return nil, true
 	}
@@ -2270,6 +2489,7 @@ func (d *AuthenticatedGossiper) handleChanAnnouncement(nMsg *networkMsg,
 		if err := routing.ValidateChannelAnn(ann); err != nil {
 			err := fmt.Errorf("unable to validate announcement: "+
 				"%v", err)
+			d.penalize(nMsg, gossipInvalid)
 
 			key := newRejectCacheKey(
 				ann.ShortChannelID.ToUint64(),
@@ -2368,6 +2588,7 @@ func (d *AuthenticatedGossiper) handleChanAnnouncement(nMsg *networkMsg,
 
 			// Otherwise, this is just a regular rejected edge.
 			log.Debugf("Router rejected channel edge: %v", err)
+			d.penalize(nMsg, gossipDuplicate)
 		} else {
 			log.Debugf("Router rejected channel edge: %v", err)
 
@@ -2528,6 +2749,11 @@ func (d *AuthenticatedGossiper) handleChanUpdate(nMsg *networkMsg,
 			nMsg.isRemote,
 		)
 
+		if d.isOlderEdgePolicy(nMsg, graphScid, timestamp,
+			upd.ChannelFlags) {
+
+			d.penalize(nMsg, gossipStale)
+		}
 		nMsg.err <- nil
 		return nil, true
 	}
@@ -2658,6 +2884,7 @@ func (d *AuthenticatedGossiper) handleChanUpdate(nMsg *networkMsg,
 			spew.Sdump(upd.ShortChannelID), err)
 
 		log.Error(rErr)
+		d.penalize(nMsg, gossipInvalid)
 		nMsg.err <- rErr
 		return nil, false
 	}
@@ -2675,6 +2902,7 @@ func (d *AuthenticatedGossiper) handleChanUpdate(nMsg *networkMsg,
 				log.Debugf("Ignoring keep alive update not "+
 					"within %v period for channel %v",
 					d.cfg.RebroadcastInterval, shortChanID)
+				d.penalize(nMsg, gossipRateLimited)
 				nMsg.err <- nil
 				return nil, false
 			}
@@ -2708,6 +2936,7 @@ func (d *AuthenticatedGossiper) handleChanUpdate(nMsg *networkMsg,
 				log.Debugf("Rate limiting update for channel "+
 					"%v from direction %x", shortChanID,
 					pubKey.SerializeCompressed())
+				d.penalize(nMsg, gossipRateLimited)
 				nMsg.err <- nil
 				return nil, false
 			}
@@ -2743,6 +2972,16 @@ func (d *AuthenticatedGossiper) handleChanUpdate(nMsg *networkMsg,
 		) {
 
 			log.Debug(err)
+
+			// Only updates that are strictly older than the one
+			// we know count as stale, as honest peers relay the
+			// updates that we already have.
+			if routing.IsError(err, routing.ErrOutdated) &&
+				edgeToUpdate != nil &&
+				timestamp.Before(edgeToUpdate.LastUpdate) {
+
+				d.penalize(nMsg, gossipStale)
+			}
 		} else {
 			// Since we know the stored SCID in the graph, we'll
 			// cache that SCID.
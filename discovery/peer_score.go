@@ -0,0 +1,356 @@
+package discovery
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/connmgr"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultBanThreshold is the default ban score at which the gossip of a
+	// peer is banned.
+	DefaultBanThreshold = 1000
+
+	// DefaultBanDuration is the default duration of a gossip ban.
+	DefaultBanDuration = 24 * time.Hour
+
+	// invalidPenalty is the ban score added for a gossip message that
+	// fails validation, e.g. because of an invalid signature.
+	invalidPenalty = 25
+
+	// stalePenalty is the ban score added for a gossip message that is
+	// strictly older than what we already know.
+	stalePenalty = 2
+
+	// scorePruneAge is the time the ban score of a disconnected peer must
+	// have been left unchanged for before its accounting is pruned. As the
+	// transient ban score decays within minutes, the score of such a peer
+	// is back at zero long before, unless the peer is banned.
+	scorePruneAge = time.Hour
+)
+
+var (
+	// ErrGossipBanned is returned for gossip messages of peers whose
+	// gossip is banned.
+	ErrGossipBanned = errors.New("peer gossip is banned")
+
+	// ErrGossipBandwidthExceeded is returned for gossip messages that
+	// exceed the gossip bandwidth of the peer that sent them.
+	ErrGossipBandwidthExceeded = errors.New("peer gossip bandwidth " +
+		"exceeded")
+)
+
+// gossipOffense is the category of a gossip message that counts towards the
+// ban score of the peer that sent it.
+type gossipOffense uint8
+
+const (
+	// gossipInvalid is a message that failed validation.
+	gossipInvalid gossipOffense = iota
+
+	// gossipStale is a message that is strictly older than what we
+	// already know.
+	gossipStale
+
+	// gossipDuplicate is a message that we already know. It is only
+	// counted, as honest peers relay messages that we already received
+	// from other peers.
+	gossipDuplicate
+
+	// gossipRateLimited is a channel update that exceeds the rate limit of
+	// its channel. It is only counted, as honest peers relay the updates
+	// of channels that are updated too often by their owners.
+	gossipRateLimited
+)
+
+// PeerGossipScore is a snapshot of the gossip accounting of a peer.
+type PeerGossipScore struct {
+	// Peer is the public key of the peer.
+	Peer route.Vertex
+
+	// Invalid is the number of messages of the peer that failed
+	// validation.
+	Invalid uint64
+
+	// Stale is the number of messages of the peer that were older than
+	// what we already knew.
+	Stale uint64
+
+	// Duplicate is the number of messages of the peer that we already
+	// knew.
+	Duplicate uint64
+
+	// RateLimited is the number of channel updates of the peer that
+	// exceeded the rate limit of their channel.
+	RateLimited uint64
+
+	// BandwidthDropped is the number of messages of the peer that were
+	// dropped because they exceeded the gossip bandwidth of the peer.
+	BandwidthDropped uint64
+
+	// BanScore is the current ban score of the peer. The transient part
+	// of the score decays over time.
+	BanScore uint32
+
+	// BannedUntil is the time at which the gossip ban of the peer ends. It
+	// is zero if the peer isn't banned.
+	BannedUntil time.Time
+}
+
+// peerScore is the gossip accounting of a single peer.
+type peerScore struct {
+	invalid          uint64
+	stale            uint64
+	duplicate        uint64
+	rateLimited      uint64
+	bandwidthDropped uint64
+
+	banScore    connmgr.DynamicBanScore
+	bannedUntil time.Time
+
+	// scoreChanged is the time the ban score was last raised or reset.
+	scoreChanged time.Time
+
+	// disconnected is true once the peer disconnected, until we account
+	// gossip of it again.
+	disconnected bool
+
+	// bandwidth limits the bytes of gossip that we accept from the peer.
+	// It is nil if the bandwidth isn't limited.
+	bandwidth *rate.Limiter
+}
+
+// peerScoreboard keeps the gossip accounting of our peers, and decides which
+// peers are banned from gossiping with us.
+type peerScoreboard struct {
+	// banThreshold is the ban score at which a peer is banned. A zero
+	// value disables banning.
+	banThreshold uint32
+
+	// banDuration is the duration of a ban.
+	banDuration time.Duration
+
+	// bandwidth is the number of bytes of gossip per second that we accept
+	// from a single peer. A zero value disables the limit.
+	bandwidth uint64
+
+	// now returns the current time.
+	now func() time.Time
+
+	peers map[route.Vertex]*peerScore
+	mu    sync.Mutex
+}
+
+// newPeerScoreboard creates a new peerScoreboard.
+func newPeerScoreboard(banThreshold uint32, banDuration time.Duration,
+	bandwidth uint64) *peerScoreboard {
+
+	return &peerScoreboard{
+		banThreshold: banThreshold,
+		banDuration:  banDuration,
+		bandwidth:    bandwidth,
+		now:          time.Now,
+		peers:        make(map[route.Vertex]*peerScore),
+	}
+}
+
+// score returns the accounting of the given peer, creating it if necessary.
+//
+// NOTE: The mutex must be held.
+func (p *peerScoreboard) score(peer route.Vertex) *peerScore {
+	score, ok := p.peers[peer]
+	if !ok {
+		score = &peerScore{}
+		p.peers[peer] = score
+	}
+	score.disconnected = false
+
+	return score
+}
+
+// record accounts the offense to the given peer and returns true if the peer
+// got banned as a result.
+func (p *peerScoreboard) record(peer route.Vertex,
+	offense gossipOffense) bool {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	score := p.score(peer)
+
+	var penalty uint32
+	switch offense {
+	case gossipInvalid:
+		score.invalid++
+		penalty = invalidPenalty
+
+	case gossipStale:
+		score.stale++
+		penalty = stalePenalty
+
+	case gossipDuplicate:
+		score.duplicate++
+
+	case gossipRateLimited:
+		score.rateLimited++
+	}
+
+	if penalty == 0 {
+		return false
+	}
+
+	banScore := score.banScore.Increase(0, penalty)
+	score.scoreChanged = p.now()
+	if p.banThreshold == 0 || banScore < p.banThreshold ||
+		p.isBanned(score) {
+
+		return false
+	}
+
+	score.bannedUntil = p.now().Add(p.banDuration)
+
+	return true
+}
+
+// isBanned returns true if the peer with the given accounting is banned. An
+// expired ban is lifted and the ban score is reset.
+//
+// NOTE: The mutex must be held.
+func (p *peerScoreboard) isBanned(score *peerScore) bool {
+	if score.bannedUntil.IsZero() {
+		return false
+	}
+
+	if p.now().Before(score.bannedUntil) {
+		return true
+	}
+
+	score.bannedUntil = time.Time{}
+	score.banScore.Reset()
+	score.scoreChanged = p.now()
+
+	return false
+}
+
+// banned returns true if the gossip of the given peer is banned.
+func (p *peerScoreboard) banned(peer route.Vertex) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	score, ok := p.peers[peer]
+	if !ok {
+		return false
+	}
+
+	return p.isBanned(score)
+}
+
+// allowBandwidth returns true if the message fits into the gossip bandwidth
+// of the peer.
+func (p *peerScoreboard) allowBandwidth(peer route.Vertex,
+	msg lnwire.Message) bool {
+
+	if p.bandwidth == 0 {
+		return true
+	}
+
+	var b bytes.Buffer
+	if _, err := lnwire.WriteMessage(&b, msg, 0); err != nil {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	score := p.score(peer)
+	if score.bandwidth == nil {
+		// The burst must at least fit the largest message.
+		burst := int(p.bandwidth)
+		if burst < lnwire.MaxMsgBody {
+			burst = lnwire.MaxMsgBody
+		}
+
+		score.bandwidth = rate.NewLimiter(
+			rate.Limit(p.bandwidth), burst,
+		)
+	}
+
+	if score.bandwidth.AllowN(p.now(), b.Len()) {
+		return true
+	}
+
+	score.bandwidthDropped++
+
+	return false
+}
+
+// forget marks the given peer as disconnected. Its accounting is kept, so
+// that it doesn't get rid of its ban score by reconnecting, until it is pruned
+// once the score decayed.
+func (p *peerScoreboard) forget(peer route.Vertex) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if score, ok := p.peers[peer]; ok {
+		score.disconnected = true
+	}
+}
+
+// prune removes the accounting of disconnected peers that aren't banned and
+// whose ban score has been at zero for at least scorePruneAge.
+func (p *peerScoreboard) prune() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for peer, score := range p.peers {
+		if !score.disconnected || p.isBanned(score) {
+			continue
+		}
+
+		if score.banScore.Int() != 0 ||
+			now.Sub(score.scoreChanged) < scorePruneAge {
+
+			continue
+		}
+
+		delete(p.peers, peer)
+	}
+}
+
+// scores returns the accounting of all peers, sorted by their ban score in
+// descending order.
+func (p *peerScoreboard) scores() []PeerGossipScore {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	scores := make([]PeerGossipScore, 0, len(p.peers))
+	for peer, score := range p.peers {
+		// Lift expired bans before taking the snapshot.
+		p.isBanned(score)
+
+		scores = append(scores, PeerGossipScore{
+			Peer:             peer,
+			Invalid:          score.invalid,
+			Stale:            score.stale,
+			Duplicate:        score.duplicate,
+			RateLimited:      score.rateLimited,
+			BandwidthDropped: score.bandwidthDropped,
+			BanScore:         score.banScore.Int(),
+			BannedUntil:      score.bannedUntil,
+		})
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].BanScore > scores[j].BanScore
+	})
+
+	return scores
+}
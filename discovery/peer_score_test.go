@@ -0,0 +1,325 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPeerScoreboard asserts that peers are banned once their ban score
+// reaches the threshold, and that bans expire.
+func TestPeerScoreboard(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1600000000, 0)
+	scoreboard := newPeerScoreboard(50, time.Hour, 0)
+	scoreboard.now = func() time.Time {
+		return now
+	}
+
+	peer := route.Vertex{1}
+
+	// Stale and duplicate messages are penalized less than invalid ones.
+	require.False(t, scoreboard.record(peer, gossipStale))
+	require.False(t, scoreboard.record(peer, gossipDuplicate))
+	require.False(t, scoreboard.record(peer, gossipInvalid))
+	require.False(t, scoreboard.banned(peer))
+
+	// The second invalid message reaches the threshold, later offenses
+	// don't ban the peer again.
+	require.True(t, scoreboard.record(peer, gossipInvalid))
+	require.True(t, scoreboard.banned(peer))
+	require.False(t, scoreboard.record(peer, gossipInvalid))
+
+	scores := scoreboard.scores()
+	require.Len(t, scores, 1)
+	require.Equal(t, peer, scores[0].Peer)
+	require.EqualValues(t, 3, scores[0].Invalid)
+	require.EqualValues(t, 1, scores[0].Stale)
+	require.EqualValues(t, 1, scores[0].Duplicate)
+	require.Equal(t, now.Add(time.Hour), scores[0].BannedUntil)
+
+	// The accounting of banned peers is kept when they disconnect.
+	scoreboard.forget(peer)
+	require.True(t, scoreboard.banned(peer))
+
+	// Once the ban expired, the ban score is reset.
+	now = now.Add(time.Hour)
+	require.False(t, scoreboard.banned(peer))
+	require.Zero(t, scoreboard.scores()[0].BanScore)
+
+	// The accounting of a disconnected peer whose ban score didn't decay
+	// yet is kept, so the peer can't get rid of it by reconnecting.
+	otherPeer := route.Vertex{2}
+	require.False(t, scoreboard.record(otherPeer, gossipStale))
+	scoreboard.forget(otherPeer)
+
+	// A connected peer is never pruned, while the disconnected peer is
+	// only pruned once its ban score has been at zero for a while.
+	connectedPeer := route.Vertex{3}
+	require.False(t, scoreboard.record(connectedPeer, gossipDuplicate))
+
+	scoreboard.forget(peer)
+	scoreboard.prune()
+	require.Len(t, scoreboard.scores(), 3)
+
+	now = now.Add(scorePruneAge)
+	scoreboard.prune()
+	scores = scoreboard.scores()
+	require.Len(t, scores, 2)
+	require.Equal(t, otherPeer, scores[0].Peer)
+	require.EqualValues(t, stalePenalty, scores[0].BanScore)
+	require.Equal(t, connectedPeer, scores[1].Peer)
+
+	// Without a threshold, peers are never banned.
+	scoreboard = newPeerScoreboard(0, time.Hour, 0)
+	for i := 0; i < 10; i++ {
+		require.False(t, scoreboard.record(peer, gossipInvalid))
+	}
+	require.False(t, scoreboard.banned(peer))
+}
+
+// TestPeerScoreboardBandwidth asserts that messages that exceed the gossip
+// bandwidth of a peer are dropped.
+func TestPeerScoreboardBandwidth(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1600000000, 0)
+	scoreboard := newPeerScoreboard(0, 0, 1000)
+	scoreboard.now = func() time.Time {
+		return now
+	}
+
+	peer := route.Vertex{1}
+	msg := &lnwire.NodeAnnouncement{
+		Features: lnwire.NewRawFeatureVector(),
+	}
+
+	// The burst fits the largest message, after which the bandwidth is
+	// exceeded until enough time passed.
+	var allowed int
+	for scoreboard.allowBandwidth(peer, msg) {
+		allowed++
+		require.Less(t, allowed, lnwire.MaxMsgBody)
+	}
+	require.Greater(t, allowed, 1)
+	require.EqualValues(t, 1, scoreboard.scores()[0].BandwidthDropped)
+
+	now = now.Add(time.Second)
+	require.True(t, scoreboard.allowBandwidth(peer, msg))
+
+	// Other peers have their own bandwidth.
+	require.True(t, scoreboard.allowBandwidth(route.Vertex{2}, msg))
+}
+
+// TestGossipBanDisconnectsPeer asserts that a peer sending invalid gossip is
+// banned and disconnected, and that its gossip is dropped while it is banned.
+func TestGossipBanDisconnectsPeer(t *testing.T) {
+	t.Parallel()
+
+	ctx, err := createTestCtx(t, 0)
+	require.NoError(t, err, "unable to create test context")
+
+	disconnected := make(chan *btcec.PublicKey, 1)
+	ctx.gossiper.peerScores = newPeerScoreboard(60, time.Hour, 0)
+	ctx.gossiper.cfg.BanDuration = time.Hour
+	ctx.gossiper.cfg.IsChannelPeer = func(*btcec.PublicKey) (bool, error) {
+		return false, nil
+	}
+	ctx.gossiper.cfg.DisconnectPeer = func(peer *btcec.PublicKey) error {
+		disconnected <- peer
+		return nil
+	}
+
+	remotePeer := &mockPeer{pk: remoteKeyPriv2.PubKey()}
+
+	process := func(msg lnwire.Message) error {
+		t.Helper()
+
+		select {
+		case err := <-ctx.gossiper.ProcessRemoteAnnouncement(
+			msg, remotePeer,
+		):
+			return err
+
+		case <-time.After(time.Second):
+			t.Fatal("announcement not processed")
+			return nil
+		}
+	}
+
+	// Changing the channel id of an announcement invalidates its
+	// signatures.
+	for i := 0; i < 3; i++ {
+		ann, err := createRemoteChannelAnnouncement(0)
+		require.NoError(t, err)
+		ann.ShortChannelID.TxIndex = uint32(i + 1)
+
+		require.ErrorContains(t, process(ann), "unable to validate")
+	}
+
+	select {
+	case peer := <-disconnected:
+		require.Equal(t, remotePeer.pk, peer)
+
+	case <-time.After(time.Second):
+		t.Fatal("banned peer not disconnected")
+	}
+
+	shouldDisconnect, err := ctx.gossiper.ShouldDisconnect(remotePeer.pk)
+	require.NoError(t, err)
+	require.True(t, shouldDisconnect)
+
+	// The gossip of the banned peer is dropped without being validated.
+	ann, err := createRemoteChannelAnnouncement(0)
+	require.NoError(t, err)
+	require.ErrorIs(t, process(ann), ErrGossipBanned)
+
+	scores := ctx.gossiper.PeerGossipScores()
+	require.Len(t, scores, 1)
+	require.EqualValues(t, 3, scores[0].Invalid)
+	require.False(t, scores[0].BannedUntil.IsZero())
+
+	// Channel peers aren't disconnected.
+	ctx.gossiper.cfg.IsChannelPeer = func(*btcec.PublicKey) (bool, error) {
+		return true, nil
+	}
+	shouldDisconnect, err = ctx.gossiper.ShouldDisconnect(remotePeer.pk)
+	require.NoError(t, err)
+	require.False(t, shouldDisconnect)
+}
+
+// TestGossipHonestRelayNotBanned asserts that the traffic of an honest peer
+// relaying gossip that we already know never gets it banned, that only
+// strictly older gossip adds to its ban score, and that the updates of our own
+// channels are accepted from banned peers.
+func TestGossipHonestRelayNotBanned(t *testing.T) {
+	t.Parallel()
+
+	ctx, err := createTestCtx(t, 0)
+	require.NoError(t, err, "unable to create test context")
+
+	ctx.gossiper.peerScores = newPeerScoreboard(
+		DefaultBanThreshold, DefaultBanDuration, 0,
+	)
+	ctx.gossiper.cfg.BanDuration = DefaultBanDuration
+	ctx.gossiper.cfg.IsChannelPeer = func(*btcec.PublicKey) (bool, error) {
+		return true, nil
+	}
+
+	// Drain the broadcasts, so that the gossiper never blocks on them.
+	quit := make(chan struct{})
+	t.Cleanup(func() {
+		close(quit)
+	})
+	go func() {
+		for {
+			select {
+			case <-ctx.broadcastedMessage:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	remotePeer := &mockPeer{pk: remoteKeyPriv2.PubKey()}
+
+	process := func(msg lnwire.Message) error {
+		t.Helper()
+
+		select {
+		case err := <-ctx.gossiper.ProcessRemoteAnnouncement(
+			msg, remotePeer,
+		):
+			return err
+
+		case <-time.After(time.Second):
+			t.Fatal("announcement not processed")
+			return nil
+		}
+	}
+
+	batch, err := createRemoteAnnouncements(0)
+	require.NoError(t, err)
+
+	relayed := []lnwire.Message{
+		batch.chanAnn, batch.chanUpdAnn1, batch.chanUpdAnn2,
+		batch.nodeAnn1, batch.nodeAnn2,
+	}
+	for _, msg := range relayed {
+		require.NoError(t, process(msg))
+	}
+
+	// An honest peer relays the gossip that we already received from other
+	// peers, and the updates of channels that are updated more often than
+	// our rate limit allows.
+	for i := 0; i < 200; i++ {
+		for _, msg := range relayed {
+			require.NoError(t, process(msg))
+		}
+	}
+	for i := 0; i < 200; i++ {
+		upd, err := createUpdateAnnouncement(
+			0, 0, remoteKeyPriv1, testTimestamp+uint32(i)+1,
+		)
+		require.NoError(t, err)
+		require.NoError(t, process(upd))
+	}
+
+	scores := ctx.gossiper.PeerGossipScores()
+	require.Len(t, scores, 1)
+	require.NotZero(t, scores[0].Duplicate)
+	require.NotZero(t, scores[0].RateLimited)
+	require.Zero(t, scores[0].Stale)
+	require.Zero(t, scores[0].Invalid)
+	require.Zero(t, scores[0].BanScore)
+	require.True(t, scores[0].BannedUntil.IsZero())
+
+	// An update that is older than the one we know is stale.
+	upd, err := createUpdateAnnouncement(
+		0, 1, remoteKeyPriv2, testTimestamp-1,
+	)
+	require.NoError(t, err)
+	require.NoError(t, process(upd))
+
+	scores = ctx.gossiper.PeerGossipScores()
+	require.EqualValues(t, 1, scores[0].Stale)
+	require.EqualValues(t, stalePenalty, scores[0].BanScore)
+
+	// Once the peer is banned, its gossip is dropped except for the updates
+	// of our own channels.
+	peerPub := route.NewVertex(remotePeer.pk)
+	for !ctx.gossiper.peerScores.banned(peerPub) {
+		ctx.gossiper.peerScores.record(peerPub, gossipInvalid)
+	}
+
+	const ownHeight = 1
+	ownChan := createAnnouncementWithoutProof(
+		ownHeight, selfKeyPriv.PubKey(), remoteKeyPriv2.PubKey(),
+	)
+	err = ctx.router.AddEdge(&channeldb.ChannelEdgeInfo{
+		ChannelID:        ownChan.ShortChannelID.ToUint64(),
+		NodeKey1Bytes:    ownChan.NodeID1,
+		NodeKey2Bytes:    ownChan.NodeID2,
+		BitcoinKey1Bytes: ownChan.BitcoinKey1,
+		BitcoinKey2Bytes: ownChan.BitcoinKey2,
+	})
+	require.NoError(t, err)
+
+	ownUpd, err := createUpdateAnnouncement(
+		ownHeight, 1, remoteKeyPriv2, testTimestamp,
+	)
+	require.NoError(t, err)
+	require.NoError(t, process(ownUpd))
+
+	upd, err = createUpdateAnnouncement(
+		0, 1, remoteKeyPriv2, testTimestamp+1,
+	)
+	require.NoError(t, err)
+	require.ErrorIs(t, process(upd), ErrGossipBanned)
+}
@@ -492,6 +492,7 @@ func (m *SyncManager) createGossipSyncer(peer lnpeer.Peer) *GossipSyncer {
 		bestHeight:                m.cfg.BestHeight,
 		markGraphSynced:           m.markGraphSynced,
 		maxQueryChanRangeReplies:  maxQueryChanRangeReplies,
+		setRecon:                  supportsSetRecon(peer),
 	})
 
 	// Gossip syncers are initialized by default in a PassiveSync type
@@ -506,6 +507,19 @@ func (m *SyncManager) createGossipSyncer(peer lnpeer.Peer) *GossipSyncer {
 	return s
 }
 
+// supportsSetRecon returns true if both we and the peer signal support for
+// channel graph syncs based on set reconciliation.
+func supportsSetRecon(peer lnpeer.Peer) bool {
+	localFeatures := peer.LocalFeatures()
+	remoteFeatures := peer.RemoteFeatures()
+	if localFeatures == nil || remoteFeatures == nil {
+		return false
+	}
+
+	return localFeatures.HasFeature(lnwire.GossipSetReconOptional) &&
+		remoteFeatures.HasFeature(lnwire.GossipSetReconOptional)
+}
+
 // removeGossipSyncer removes all internal references to the disconnected peer's
 // GossipSyncer and stops it. In the event of an active GossipSyncer being
 // disconnected, a passive GossipSyncer, if any, will take its place.
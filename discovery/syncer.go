@@ -1,6 +1,8 @@
 package discovery
 
 import (
+	crand "crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math"
@@ -96,6 +98,14 @@ const (
 	// chan ID's to us.
 	waitingQueryRangeReply
 
+	// waitingQuerySketchReply is the alternative to waitingQueryRangeReply
+	// for peers that support set reconciliation. We enter this state after
+	// we send out a QueryChannelSketch, and stay in it until the remote
+	// party sends us a sketch that we can decode. If we can't recover the
+	// channels we're missing from their sketches, we'll fall back to
+	// waitingQueryRangeReply.
+	waitingQuerySketchReply
+
 	// queryNewChannels is the third main phase of the GossipSyncer.  In
 	// this phase we'll send out all of our QueryShortChanIDs messages in
 	// response to the new channels that we don't yet know about.
@@ -132,6 +142,9 @@ func (s syncerState) String() string {
 	case waitingQueryRangeReply:
 		return "waitingQueryRangeReply"
 
+	case waitingQuerySketchReply:
+		return "waitingQuerySketchReply"
+
 	case queryNewChannels:
 		return "queryNewChannels"
 
@@ -287,6 +300,12 @@ type gossipSyncerCfg struct {
 	// maxQueryChanRangeReplies is the maximum number of replies we'll allow
 	// for a single QueryChannelRange request.
 	maxQueryChanRangeReplies uint32
+
+	// setRecon is true if both we and the remote peer support channel
+	// graph syncs based on set reconciliation. If set, we'll query the
+	// remote peer for sketches of its channels before falling back to
+	// QueryChannelRange, and reply to its QueryChannelSketch messages.
+	setRecon bool
 }
 
 // GossipSyncer is a struct that handles synchronizing the channel graph state
@@ -363,6 +382,11 @@ type GossipSyncer struct {
 	// buffer all the chunked response to our query.
 	bufferedChanRangeReplies []lnwire.ShortChannelID
 
+	// curQuerySketchMsg keeps track of the latest QueryChannelSketch
+	// message we've sent to a peer. This field is only used within the
+	// waitingQuerySketchReply state.
+	curQuerySketchMsg *lnwire.QueryChannelSketch
+
 	// numChanRangeRepliesRcvd is used to track the number of replies
 	// received as part of a QueryChannelRange. This field is primarily used
 	// within the waitingQueryChanReply state.
@@ -486,6 +510,23 @@ func (g *GossipSyncer) channelGraphSyncer() {
 				return
 			}
 
+			// If the remote peer supports set reconciliation,
+			// we'll first try to find the channels we don't know
+			// of from a sketch of its channels in the same range.
+			if g.cfg.setRecon {
+				sent, err := g.startChanSketchQuery()
+				if err != nil {
+					log.Errorf("Unable to send chan "+
+						"sketch query: %v", err)
+					return
+				}
+
+				if sent {
+					g.setSyncState(waitingQuerySketchReply)
+					continue
+				}
+			}
+
 			err = g.cfg.sendToPeer(queryRangeMsg)
 			if err != nil {
 				log.Errorf("Unable to send chan range "+
@@ -531,6 +572,32 @@ func (g *GossipSyncer) channelGraphSyncer() {
 				return
 			}
 
+		// In this state, we've sent out a sketch query and are waiting
+		// for the sketch of the remote peer. We'll either decode the
+		// channels they know of that we don't from it, query for a
+		// larger sketch or fall back to a channel range query.
+		case waitingQuerySketchReply:
+			select {
+			case msg := <-g.gossipMsgs:
+				reply, ok := msg.(*lnwire.ReplyChannelSketch)
+				if ok {
+					err := g.processChanSketchReply(reply)
+					if err != nil {
+						log.Errorf("Unable to "+
+							"process chan sketch "+
+							"reply: %v", err)
+						return
+					}
+					continue
+				}
+
+				log.Warnf("Unexpected message: %T in state=%v",
+					msg, state)
+
+			case <-g.quit:
+				return
+			}
+
 		// We'll enter this state once we've discovered which channels
 		// the remote party knows of that we don't yet know of
 		// ourselves.
@@ -847,17 +914,9 @@ func (g *GossipSyncer) processChanRangeReply(msg *lnwire.ReplyChannelRange) erro
 		}
 	}
 
-	log.Infof("GossipSyncer(%x): filtering through %v chans",
-		g.cfg.peerPub[:], len(g.bufferedChanRangeReplies))
-
 	// Otherwise, this is the final response, so we'll now check to see
 	// which channels they know of that we don't.
-	newChans, err := g.cfg.channelSeries.FilterKnownChanIDs(
-		g.cfg.chainHash, g.bufferedChanRangeReplies,
-	)
-	if err != nil {
-		return fmt.Errorf("unable to filter chan ids: %v", err)
-	}
+	chanIDs := g.bufferedChanRangeReplies
 
 	// As we've received the entirety of the reply, we no longer need to
 	// hold on to the set of buffered replies or the original query that
@@ -867,6 +926,23 @@ func (g *GossipSyncer) processChanRangeReply(msg *lnwire.ReplyChannelRange) erro
 	g.bufferedChanRangeReplies = nil
 	g.numChanRangeRepliesRcvd = 0
 
+	return g.queueNewChans(chanIDs)
+}
+
+// queueNewChans filters the channels known to the remote peer for the ones
+// that we don't know of, and transitions to querying for them. If there are
+// no such channels, we'll transition to our terminal state instead.
+func (g *GossipSyncer) queueNewChans(chanIDs []lnwire.ShortChannelID) error {
+	log.Infof("GossipSyncer(%x): filtering through %v chans",
+		g.cfg.peerPub[:], len(chanIDs))
+
+	newChans, err := g.cfg.channelSeries.FilterKnownChanIDs(
+		g.cfg.chainHash, chanIDs,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to filter chan ids: %v", err)
+	}
+
 	// If there aren't any channels that we don't know of, then we can
 	// switch straight to our terminal state.
 	if len(newChans) == 0 {
@@ -893,6 +969,220 @@ func (g *GossipSyncer) processChanRangeReply(msg *lnwire.ReplyChannelRange) erro
 	return nil
 }
 
+// chansInRange returns the short channel IDs of the channels that we know of
+// within the given block range.
+func (g *GossipSyncer) chansInRange(startHeight,
+	endHeight uint32) ([]lnwire.ShortChannelID, error) {
+
+	channelRanges, err := g.cfg.channelSeries.FilterChannelRange(
+		g.cfg.chainHash, startHeight, endHeight,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var chanIDs []lnwire.ShortChannelID
+	for _, channelRange := range channelRanges {
+		chanIDs = append(chanIDs, channelRange.Channels...)
+	}
+
+	return chanIDs, nil
+}
+
+// startChanSketchQuery sends the first sketch query for the range of our
+// current channel range query. It returns false without sending a query if we
+// expect a reply to the range query to be smaller than the sketch.
+func (g *GossipSyncer) startChanSketchQuery() (bool, error) {
+	rangeQuery := g.curQueryRangeMsg
+
+	// We expect the remote peer to know of about as many channels in the
+	// range as we do.
+	localChans, err := g.chansInRange(
+		rangeQuery.FirstBlockHeight, rangeQuery.LastBlockHeight(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return g.sendChanSketchQuery(
+		defaultSketchCells, uint32(len(localChans)),
+	)
+}
+
+// sendChanSketchQuery queries the remote peer for a sketch with the given
+// number of cells of its channels in the range of our current channel range
+// query. It returns false without sending a query if a reply to the range
+// query for the given number of channels is expected to be smaller than the
+// sketch.
+func (g *GossipSyncer) sendChanSketchQuery(numCells uint16,
+	numChans uint32) (bool, error) {
+
+	// A reply to a range query carries eight bytes per channel, so a
+	// sketch only saves bandwidth if it's smaller than that.
+	if uint64(numChans)*8 <= uint64(numCells)*sketchCellSize {
+		return false, nil
+	}
+
+	var salt [8]byte
+	if _, err := crand.Read(salt[:]); err != nil {
+		return false, err
+	}
+
+	rangeQuery := g.curQueryRangeMsg
+	query := &lnwire.QueryChannelSketch{
+		ChainHash:        rangeQuery.ChainHash,
+		FirstBlockHeight: rangeQuery.FirstBlockHeight,
+		NumBlocks:        rangeQuery.NumBlocks,
+		NumCells:         numCells,
+		Salt:             binary.BigEndian.Uint64(salt[:]),
+	}
+
+	log.Infof("GossipSyncer(%x): requesting chan sketch of %v cells "+
+		"from height=%v and %v blocks after", g.cfg.peerPub[:],
+		numCells, query.FirstBlockHeight, query.NumBlocks)
+
+	if err := g.cfg.sendToPeer(query); err != nil {
+		return false, err
+	}
+	g.curQuerySketchMsg = query
+
+	return true, nil
+}
+
+// nextSketchCells returns the number of cells of the sketch we'll request
+// after failing to decode a sketch with the given number of cells, given a
+// lower bound on the size of the set difference. It returns false if no sketch
+// that fits into a single message can be decoded.
+func nextSketchCells(numCells uint16, minDiff uint32) (uint16, bool) {
+	if numCells >= maxSketchCells ||
+		minDiff > sketchDecodeCapacity(maxSketchCells) {
+
+		return 0, false
+	}
+
+	next := uint32(numCells) * sketchGrowthFactor
+
+	// Make sure that the next sketch can at least decode the lower bound
+	// of the difference.
+	if minCells := minDiff*3/2 + 1; minCells > next {
+		next = minCells
+	}
+
+	// Round up to the next valid number of cells.
+	next += (sketchHashes - next%sketchHashes) % sketchHashes
+	if next > maxSketchCells {
+		next = maxSketchCells
+	}
+
+	if sketchDecodeCapacity(uint16(next)) < minDiff {
+		return 0, false
+	}
+
+	return uint16(next), true
+}
+
+// processChanSketchReply is called when the GossipSyncer receives the sketch
+// of the channels of the remote peer. If we can decode the channels we don't
+// know of from the sketch, we'll query for them. Otherwise, we'll request a
+// larger sketch or fall back to a channel range query.
+func (g *GossipSyncer) processChanSketchReply(
+	msg *lnwire.ReplyChannelSketch) error {
+
+	query := g.curQuerySketchMsg
+	switch {
+	case msg.ChainHash != query.ChainHash ||
+		msg.FirstBlockHeight != query.FirstBlockHeight ||
+		msg.NumBlocks != query.NumBlocks:
+
+		return g.fallBackToRangeQuery("sketch doesn't match query")
+
+	// The remote peer signals that it can't reply to our query with an
+	// empty sketch.
+	case len(msg.Sketch) == 0:
+		return g.fallBackToRangeQuery("remote peer declined query")
+	}
+
+	remoteSketch, err := deserializeChanSketch(
+		msg.Sketch, query.NumCells, query.Salt,
+	)
+	if err != nil {
+		return g.fallBackToRangeQuery(err.Error())
+	}
+
+	localChans, err := g.chansInRange(
+		query.FirstBlockHeight, query.LastBlockHeight(),
+	)
+	if err != nil {
+		return err
+	}
+
+	localSketch := newChanSketch(query.NumCells, query.Salt)
+	for _, chanID := range localChans {
+		localSketch.add(chanID)
+	}
+
+	if err := remoteSketch.subtract(localSketch); err != nil {
+		return err
+	}
+
+	newChans, _, err := remoteSketch.decode()
+	switch {
+	case err == nil:
+		log.Infof("GossipSyncer(%x): decoded chan sketch of %v "+
+			"cells, remote peer knows of %v chans in range",
+			g.cfg.peerPub[:], query.NumCells, msg.NumChannels)
+
+		g.curQuerySketchMsg = nil
+		g.curQueryRangeMsg = nil
+
+		return g.queueNewChans(newChans)
+
+	// A malformed sketch can't be fixed by requesting a larger one.
+	case errors.Is(err, errSketchMalformed):
+		return g.fallBackToRangeQuery(err.Error())
+	}
+
+	// The difference is too large for the sketch. As the difference of
+	// the sizes of both sets is a lower bound of the size of their
+	// difference, we'll request a sketch that can at least decode that.
+	numLocal := uint32(len(localChans))
+	minDiff := msg.NumChannels - numLocal
+	if numLocal > msg.NumChannels {
+		minDiff = numLocal - msg.NumChannels
+	}
+
+	numCells, ok := nextSketchCells(query.NumCells, minDiff)
+	if !ok {
+		return g.fallBackToRangeQuery("set difference too large")
+	}
+
+	sent, err := g.sendChanSketchQuery(numCells, msg.NumChannels)
+	if err != nil {
+		return err
+	}
+	if !sent {
+		return g.fallBackToRangeQuery("range query is smaller")
+	}
+
+	return nil
+}
+
+// fallBackToRangeQuery abandons the sketch query and sends our current channel
+// range query instead.
+func (g *GossipSyncer) fallBackToRangeQuery(reason string) error {
+	log.Infof("GossipSyncer(%x): falling back to chan range query: %v",
+		g.cfg.peerPub[:], reason)
+
+	g.curQuerySketchMsg = nil
+
+	if err := g.cfg.sendToPeer(g.curQueryRangeMsg); err != nil {
+		return err
+	}
+	g.setSyncState(waitingQueryRangeReply)
+
+	return nil
+}
+
 // genChanRangeQuery generates the initial message we'll send to the remote
 // party when we're kicking off the channel graph synchronization upon
 // connection. The historicalQuery boolean can be used to generate a query from
@@ -979,6 +1269,11 @@ func (g *GossipSyncer) replyPeerQueries(msg lnwire.Message) error {
 	case *lnwire.QueryShortChanIDs:
 		return g.replyShortChanIDs(msg)
 
+	// If the remote peer supports set reconciliation, it'll query us for
+	// sketches instead of channel ranges.
+	case *lnwire.QueryChannelSketch:
+		return g.replyChanSketchQuery(msg)
+
 	default:
 		return fmt.Errorf("unknown message: %T", msg)
 	}
@@ -1119,6 +1414,61 @@ func (g *GossipSyncer) replyChanRangeQuery(query *lnwire.QueryChannelRange) erro
 	)
 }
 
+// replyChanSketchQuery will be dispatched in response to a sketch query by the
+// remote node. We'll reply with a sketch of the channels that we know of in
+// the queried range, or with an empty sketch if we can't reply to the query.
+func (g *GossipSyncer) replyChanSketchQuery(
+	query *lnwire.QueryChannelSketch) error {
+
+	// We only reply to sketch queries of peers that negotiated set
+	// reconciliation with us.
+	if !g.cfg.setRecon {
+		return fmt.Errorf("received %T without negotiating set "+
+			"reconciliation", query)
+	}
+
+	reply := &lnwire.ReplyChannelSketch{
+		ChainHash:        query.ChainHash,
+		FirstBlockHeight: query.FirstBlockHeight,
+		NumBlocks:        query.NumBlocks,
+	}
+
+	switch {
+	case g.cfg.chainHash != query.ChainHash:
+		log.Warnf("Remote peer requested QueryChannelSketch for "+
+			"chain=%v, we're on chain=%v", query.ChainHash,
+			g.cfg.chainHash)
+
+		return g.cfg.sendToPeerSync(reply)
+
+	case !validSketchCells(query.NumCells):
+		log.Warnf("Remote peer requested QueryChannelSketch with "+
+			"invalid number of cells %v", query.NumCells)
+
+		return g.cfg.sendToPeerSync(reply)
+	}
+
+	chanIDs, err := g.chansInRange(
+		query.FirstBlockHeight, query.LastBlockHeight(),
+	)
+	if err != nil {
+		return err
+	}
+
+	sketch := newChanSketch(query.NumCells, query.Salt)
+	for _, chanID := range chanIDs {
+		sketch.add(chanID)
+	}
+
+	log.Infof("GossipSyncer(%x): sending chan sketch of %v cells for "+
+		"%v chans", g.cfg.peerPub[:], query.NumCells, len(chanIDs))
+
+	reply.NumChannels = uint32(len(chanIDs))
+	reply.Sketch = sketch.serialize()
+
+	return g.cfg.sendToPeerSync(reply)
+}
+
 // replyShortChanIDs will be dispatched in response to a query by the remote
 // node for information concerning a set of short channel ID's. Our response
 // will be sent in a streaming chunked manner to ensure that we remain below
@@ -1369,7 +1719,9 @@ func (g *GossipSyncer) FilterGossipMsgs(msgs ...msgWithSenders) {
 func (g *GossipSyncer) ProcessQueryMsg(msg lnwire.Message, peerQuit <-chan struct{}) error {
 	var msgChan chan lnwire.Message
 	switch msg.(type) {
-	case *lnwire.QueryChannelRange, *lnwire.QueryShortChanIDs:
+	case *lnwire.QueryChannelRange, *lnwire.QueryShortChanIDs,
+		*lnwire.QueryChannelSketch:
+
 		msgChan = g.queryMsgs
 
 	// Reply messages should only be expected in states where we're waiting
@@ -1384,6 +1736,13 @@ func (g *GossipSyncer) ProcessQueryMsg(msg lnwire.Message, peerQuit <-chan struc
 		}
 		msgChan = g.gossipMsgs
 
+	case *lnwire.ReplyChannelSketch:
+		if g.syncState() != waitingQuerySketchReply {
+			return fmt.Errorf("received unexpected query reply "+
+				"message %T", msg)
+		}
+		msgChan = g.gossipMsgs
+
 	default:
 		msgChan = g.gossipMsgs
 	}
@@ -2182,6 +2182,8 @@ func TestGossipSyncerSyncTransitions(t *testing.T) {
 	}
 
 	for _, test := range tests {
+		test := test
+
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
@@ -2205,6 +2207,17 @@ func TestGossipSyncerSyncTransitions(t *testing.T) {
 			syncer.Start()
 			defer syncer.Stop()
 
+			// An active syncer sends its update horizon once it
+			// reaches chansSynced, before it processes any sync
+			// transition.
+			if test.entrySyncType.IsActiveSync() {
+				select {
+				case <-msgChan:
+				case <-time.After(time.Second):
+					t.Fatal("expected initial update horizon")
+				}
+			}
+
 			syncer.ProcessSyncTransition(test.finalSyncType)
 
 			// The syncer should now have the expected final
@@ -2396,3 +2409,220 @@ func TestGossipSyncerMaxChannelRangeReplies(t *testing.T) {
 		},
 	}, nil))
 }
+
+// TestGossipSyncerSetRecon tests that two syncers that negotiated set
+// reconciliation find the channels they're missing from sketches, and that
+// they request larger sketches until they can decode them.
+func TestGossipSyncerSetRecon(t *testing.T) {
+	t.Parallel()
+
+	highestID := lnwire.ShortChannelID{
+		BlockHeight: latestKnownHeight,
+	}
+	msgChan1, syncer1, chanSeries1 := newTestSyncer(
+		highestID, defaultEncoding, defaultChunkSize, true, false,
+	)
+	syncer1.cfg.setRecon = true
+	syncer1.Start()
+	defer syncer1.Stop()
+
+	msgChan2, syncer2, chanSeries2 := newTestSyncer(
+		highestID, defaultEncoding, defaultChunkSize, false, true,
+	)
+	syncer2.cfg.setRecon = true
+	syncer2.Start()
+	defer syncer2.Stop()
+
+	// The syncers share most channels, but syncer2 knows of more channels
+	// than the first sketch can decode.
+	var syncer1Chans, newChans []lnwire.ShortChannelID
+	for i := uint32(0); i < 2000; i++ {
+		syncer1Chans = append(syncer1Chans, lnwire.ShortChannelID{
+			BlockHeight: i % latestKnownHeight,
+			TxIndex:     i,
+		})
+	}
+	for i := uint32(0); i < 100; i++ {
+		newChans = append(newChans, lnwire.ShortChannelID{
+			BlockHeight: i,
+			TxIndex:     5000 + i,
+		})
+	}
+	sortChanIDs(newChans)
+	syncer2Chans := append(newChans, syncer1Chans[1:]...)
+
+	filterRange := func(chanSeries *mockChannelGraphTimeSeries,
+		chans []lnwire.ShortChannelID) {
+
+		select {
+		case <-chanSeries.filterRangeReqs:
+			chanSeries.filterRangeResp <- chans
+
+		case <-time.After(time.Second):
+			t.Fatal("no filter range request")
+		}
+	}
+
+	// forward passes the message sent by a syncer to the other syncer, and
+	// returns it.
+	forward := func(msgChan chan []lnwire.Message,
+		dest chan lnwire.Message) lnwire.Message {
+
+		var msgs []lnwire.Message
+		select {
+		case msgs = <-msgChan:
+		case <-time.After(time.Second):
+			t.Fatal("no message sent")
+		}
+		require.Len(t, msgs, 1)
+
+		select {
+		case dest <- msgs[0]:
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+
+		return msgs[0]
+	}
+
+	// syncer1 looks up its channels to decide whether to use a sketch,
+	// then queries syncer2 for one.
+	filterRange(chanSeries1, syncer1Chans)
+	query := forward(msgChan1, syncer2.queryMsgs)
+	require.IsType(t, &lnwire.QueryChannelSketch{}, query)
+	require.EqualValues(
+		t, defaultSketchCells, query.(*lnwire.QueryChannelSketch).NumCells,
+	)
+
+	filterRange(chanSeries2, syncer2Chans)
+	reply := forward(msgChan2, syncer1.gossipMsgs)
+	require.IsType(t, &lnwire.ReplyChannelSketch{}, reply)
+	require.EqualValues(
+		t, len(syncer2Chans), reply.(*lnwire.ReplyChannelSketch).NumChannels,
+	)
+
+	// The sketch is too small, so syncer1 queries for a larger one.
+	filterRange(chanSeries1, syncer1Chans)
+	query = forward(msgChan1, syncer2.queryMsgs)
+	require.EqualValues(
+		t, defaultSketchCells*sketchGrowthFactor,
+		query.(*lnwire.QueryChannelSketch).NumCells,
+	)
+
+	filterRange(chanSeries2, syncer2Chans)
+	forward(msgChan2, syncer1.gossipMsgs)
+	filterRange(chanSeries1, syncer1Chans)
+
+	// syncer1 decoded the channels that it doesn't know of, and queries
+	// for them.
+	select {
+	case chans := <-chanSeries1.filterReq:
+		require.Equal(t, newChans, chans)
+		chanSeries1.filterResp <- chans
+
+	case <-time.After(time.Second):
+		t.Fatal("no filter request")
+	}
+
+	select {
+	case msgs := <-msgChan1:
+		require.Len(t, msgs, 1)
+		require.IsType(t, &lnwire.QueryShortChanIDs{}, msgs[0])
+		queryChans := msgs[0].(*lnwire.QueryShortChanIDs).ShortChanIDs
+		require.Equal(t, newChans, queryChans)
+
+	case <-time.After(time.Second):
+		t.Fatal("no query sent")
+	}
+}
+
+// TestGossipSyncerSetReconFallback tests that a syncer falls back to a channel
+// range query if it can't use set reconciliation.
+func TestGossipSyncerSetReconFallback(t *testing.T) {
+	t.Parallel()
+
+	highestID := lnwire.ShortChannelID{
+		BlockHeight: latestKnownHeight,
+	}
+	msgChan, syncer, chanSeries := newTestSyncer(
+		highestID, defaultEncoding, defaultChunkSize, true, false,
+	)
+	syncer.cfg.setRecon = true
+	syncer.Start()
+	defer syncer.Stop()
+
+	var chans []lnwire.ShortChannelID
+	for i := uint32(0); i < 1000; i++ {
+		chans = append(chans, lnwire.ShortChannelID{
+			BlockHeight: i,
+		})
+	}
+
+	receiveMsg := func() lnwire.Message {
+		select {
+		case msgs := <-msgChan:
+			require.Len(t, msgs, 1)
+			return msgs[0]
+
+		case <-time.After(time.Second):
+			t.Fatal("no message sent")
+			return nil
+		}
+	}
+
+	// The remote peer declines our sketch query with an empty sketch, so
+	// we fall back to a channel range query.
+	select {
+	case <-chanSeries.filterRangeReqs:
+		chanSeries.filterRangeResp <- chans
+
+	case <-time.After(time.Second):
+		t.Fatal("no filter range request")
+	}
+
+	query, ok := receiveMsg().(*lnwire.QueryChannelSketch)
+	require.True(t, ok)
+
+	err := syncer.ProcessQueryMsg(&lnwire.ReplyChannelSketch{
+		ChainHash:        query.ChainHash,
+		FirstBlockHeight: query.FirstBlockHeight,
+		NumBlocks:        query.NumBlocks,
+	}, nil)
+	require.NoError(t, err)
+
+	rangeQuery, ok := receiveMsg().(*lnwire.QueryChannelRange)
+	require.True(t, ok)
+	require.Equal(t, query.FirstBlockHeight, rangeQuery.FirstBlockHeight)
+	require.Equal(t, query.NumBlocks, rangeQuery.NumBlocks)
+
+	// Sketch replies are no longer accepted.
+	err = syncer.ProcessQueryMsg(&lnwire.ReplyChannelSketch{}, nil)
+	require.Error(t, err)
+
+	// Without set reconciliation, we reply to sketch queries with an
+	// error, and without any channels in range we use a range query right
+	// away.
+	msgChan, syncer, chanSeries = newTestSyncer(
+		highestID, defaultEncoding, defaultChunkSize, false, true,
+	)
+	err = syncer.replyChanSketchQuery(&lnwire.QueryChannelSketch{
+		NumCells: defaultSketchCells,
+	})
+	require.Error(t, err)
+
+	syncer.cfg.setRecon = true
+	syncer.cfg.noSyncChannels = false
+	syncer.Start()
+	defer syncer.Stop()
+
+	select {
+	case <-chanSeries.filterRangeReqs:
+		chanSeries.filterRangeResp <- nil
+
+	case <-time.After(time.Second):
+		t.Fatal("no filter range request")
+	}
+
+	_, ok = receiveMsg().(*lnwire.QueryChannelRange)
+	require.True(t, ok)
+}
@@ -83,4 +83,8 @@ var defaultSetDesc = setDesc{
 		SetInit:    {}, // I
 		SetNodeAnn: {}, // N
 	},
+	lnwire.GossipSetReconOptional: {
+		SetInit:    {}, // I
+		SetNodeAnn: {}, // N
+	},
 }
@@ -44,6 +44,10 @@ type Config struct {
 	// NoAnySegwit unsets any bits that signal support for using other
 	// segwit witness versions for co-op closes.
 	NoAnySegwit bool
+
+	// NoGossipSetRecon unsets any bits signalling support for channel
+	// graph syncs based on set reconciliation.
+	NoGossipSetRecon bool
 }
 
 // Manager is responsible for generating feature vectors for different requested
@@ -150,6 +154,10 @@ func newManager(cfg Config, desc setDesc) (*Manager, error) {
 			raw.Unset(lnwire.ShutdownAnySegwitOptional)
 			raw.Unset(lnwire.ShutdownAnySegwitRequired)
 		}
+		if cfg.NoGossipSetRecon {
+			raw.Unset(lnwire.GossipSetReconOptional)
+			raw.Unset(lnwire.GossipSetReconRequired)
+		}
 
 		// Ensure that all of our feature sets properly set any
 		// dependent features.
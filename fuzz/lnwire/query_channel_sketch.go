@@ -0,0 +1,17 @@
+//go:build gofuzz
+// +build gofuzz
+
+package lnwirefuzz
+
+import (
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+// Fuzz_query_channel_sketch is used by go-fuzz.
+func Fuzz_query_channel_sketch(data []byte) int {
+	// Prefix with MsgQueryChannelSketch.
+	data = prefixWithMsgType(data, lnwire.MsgQueryChannelSketch)
+
+	// Pass the message into our general fuzz harness for wire messages!
+	return harness(data)
+}
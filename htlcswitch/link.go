@@ -86,6 +86,11 @@ type ForwardingPolicy struct {
 	// used to compute the required fee for a given HTLC.
 	FeeRate lnwire.MilliSatoshi
 
+	// InboundFee is the fee, possibly negative, that is charged for HTLCs
+	// arriving over this channel. It is added to the fee required by the
+	// outgoing channel, but never turns the total fee negative.
+	InboundFee lnwire.Fee
+
 	// TimeLockDelta is the absolute time-lock value, expressed in blocks,
 	// that will be subtracted from an incoming HTLC's timelock value to
 	// create the time-lock value for the forwarded outgoing HTLC. The
@@ -1048,6 +1053,24 @@ func (l *channelLink) htlcManager() {
 						"data loss: %v", err)
 				}
 
+				// If the remote reported a commit height
+				// below the one recorded in the backup we
+				// restored from, it may be attempting to close
+				// the channel with an outdated state. We mark
+				// the channel borked, so we won't process its
+				// channel reestablish messages any longer.
+				if !errDataLoss.OutdatedRemoteState() {
+					break
+				}
+
+				l.log.Errorf("remote reported outdated state "+
+					"for restored channel: %v", errDataLoss)
+
+				if err := l.channel.MarkBorked(); err != nil {
+					l.log.Errorf("unable to mark channel "+
+						"borked: %v", err)
+				}
+
 			// We determined the commit chains were not possible to
 			// sync. We cautiously fail the channel, but don't
 			// force close.
@@ -2506,7 +2529,7 @@ func (l *channelLink) UpdateForwardingPolicy(newPolicy ForwardingPolicy) {
 // NOTE: Part of the ChannelLink interface.
 func (l *channelLink) CheckHtlcForward(payHash [32]byte,
 	incomingHtlcAmt, amtToForward lnwire.MilliSatoshi,
-	incomingTimeout, outgoingTimeout uint32,
+	incomingTimeout, outgoingTimeout uint32, inboundFee lnwire.Fee,
 	heightNow uint32, originalScid lnwire.ShortChannelID) *LinkError {
 
 	l.RLock()
@@ -2524,15 +2547,19 @@ func (l *channelLink) CheckHtlcForward(payHash [32]byte,
 
 	// Next, using the amount of the incoming HTLC, we'll calculate the
 	// expected fee this incoming HTLC must carry in order to satisfy the
-	// constraints of the outgoing link.
-	expectedFee := ExpectedFee(policy, amtToForward)
+	// constraints of the outgoing link. The inbound fee of the incoming
+	// link is charged on top of that, based on the amount including the
+	// outbound fee.
+	outFee := ExpectedFee(policy, amtToForward)
+	inFee := inboundFee.CalcFee(amtToForward + outFee)
+	expectedFee := int64(outFee) + inFee
 
 	// If the actual fee is less than our expected fee, then we'll reject
 	// this HTLC as it didn't provide a sufficient amount of fees, or the
 	// values have been tampered with, or the send used incorrect/dated
 	// information to construct the forwarding information for this hop. In
 	// any case, we'll cancel this HTLC.
-	actualFee := incomingHtlcAmt - amtToForward
+	actualFee := int64(incomingHtlcAmt) - int64(amtToForward)
 	if incomingHtlcAmt < amtToForward || actualFee < expectedFee {
 		l.log.Warnf("outgoing htlc(%x) has insufficient fee: "+
 			"expected %v, got %v",
@@ -2919,6 +2946,12 @@ func (l *channelLink) processRemoteAdds(fwdPkg *channeldb.FwdPkg,
 		return
 	}
 
+	// The inbound fee of this link applies to all HTLCs forwarded from
+	// it, so we fetch it once for the whole batch.
+	l.RLock()
+	inboundFee := l.cfg.FwrdingPolicy.InboundFee
+	l.RUnlock()
+
 	var switchPackets []*htlcPacket
 
 	for i, pd := range lockedInHtlcs {
@@ -3076,6 +3109,7 @@ func (l *channelLink) processRemoteAdds(fwdPkg *channeldb.FwdPkg,
 					obfuscator:      obfuscator,
 					incomingTimeout: pd.Timeout,
 					outgoingTimeout: fwdInfo.OutgoingCTLV,
+					inboundFee:      inboundFee,
 					customRecords:   pld.CustomRecords(),
 				}
 				switchPackets = append(
@@ -3140,6 +3174,7 @@ func (l *channelLink) processRemoteAdds(fwdPkg *channeldb.FwdPkg,
 					obfuscator:      obfuscator,
 					incomingTimeout: pd.Timeout,
 					outgoingTimeout: fwdInfo.OutgoingCTLV,
+					inboundFee:      inboundFee,
 					customRecords:   pld.CustomRecords(),
 				}
 
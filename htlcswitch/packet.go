@@ -88,6 +88,11 @@ type htlcPacket struct {
 	// is the timeout of the HTLC applied to the incoming link.
 	incomingTimeout uint32
 
+	// inboundFee is the inbound fee of the incoming link at the time the
+	// HTLC was received. It is charged on top of the fee of the outgoing
+	// link.
+	inboundFee lnwire.Fee
+
 	// outgoingTimeout is the timeout of the proposed outgoing HTLC. This
 	// will be extracted from the hop payload received by the incoming
 	// link.
@@ -0,0 +1,407 @@
+package invoices
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+const (
+	// MinSubscriptionPeriod is the minimum length of a period of an AMP
+	// subscription.
+	MinSubscriptionPeriod = time.Second
+)
+
+var (
+	// ErrAMPSubscriptionExists is returned when a subscription is added
+	// to an invoice that already has one.
+	ErrAMPSubscriptionExists = errors.New("invoice already has a " +
+		"subscription")
+
+	// ErrAMPSubscriptionNotFound is returned when the invoice doesn't
+	// have a subscription.
+	ErrAMPSubscriptionNotFound = errors.New("invoice has no " +
+		"subscription")
+
+	// ErrAMPSubscriptionNotAMP is returned when a subscription is added
+	// to an invoice that can't be paid with AMP.
+	ErrAMPSubscriptionNotAMP = errors.New("subscriptions require an AMP " +
+		"invoice")
+)
+
+// validateAMPSubscription checks that the subscription can be added.
+func validateAMPSubscription(sub *channeldb.AMPSubscription) error {
+	switch {
+	case sub.PeriodAmt == 0:
+		return errors.New("amount per period must be positive")
+
+	case sub.PeriodLength < MinSubscriptionPeriod:
+		return fmt.Errorf("period length must be at least %v",
+			MinSubscriptionPeriod)
+	}
+
+	return nil
+}
+
+// AddAMPSubscription adds a subscription to the open AMP invoice with the
+// given payment hash. If the subscription has no start time, the first period
+// starts now. Payments to the invoice are accounted to the period in which
+// their htlc set is settled.
+func (i *InvoiceRegistry) AddAMPSubscription(hash lntypes.Hash,
+	sub *channeldb.AMPSubscription) error {
+
+	if err := validateAMPSubscription(sub); err != nil {
+		return err
+	}
+
+	invoice, err := i.cdb.LookupInvoice(channeldb.InvoiceRefByHash(hash))
+	if err != nil {
+		return err
+	}
+
+	if !invoice.Terms.Features.HasFeature(lnwire.AMPOptional) {
+		return ErrAMPSubscriptionNotAMP
+	}
+
+	if invoice.State != channeldb.ContractOpen {
+		return fmt.Errorf("invoice in state %v can't be paid",
+			invoice.State)
+	}
+
+	sub = sub.Copy()
+	sub.Canceled = false
+	sub.Periods = nil
+	if sub.StartTime.IsZero() {
+		sub.StartTime = i.cfg.Clock.Now()
+	}
+
+	i.ampSubsMtx.Lock()
+	defer i.ampSubsMtx.Unlock()
+
+	if _, ok := i.ampSubs[hash]; ok {
+		return ErrAMPSubscriptionExists
+	}
+
+	if err := i.cdb.PutAMPSubscription(hash, sub); err != nil {
+		return err
+	}
+
+	i.ampSubs[hash] = sub
+	i.ampSubsByAddr[invoice.Terms.PaymentAddr] = hash
+
+	log.Debugf("Invoice(%v): added subscription of %v every %v, "+
+		"starting at %v", hash, sub.PeriodAmt, sub.PeriodLength,
+		sub.StartTime)
+
+	i.signalAMPSubscriptions()
+
+	return nil
+}
+
+// LookupAMPSubscription returns the subscription of the AMP invoice with the
+// given payment hash.
+func (i *InvoiceRegistry) LookupAMPSubscription(
+	hash lntypes.Hash) (*channeldb.AMPSubscription, error) {
+
+	i.ampSubsMtx.Lock()
+	defer i.ampSubsMtx.Unlock()
+
+	sub, ok := i.ampSubs[hash]
+	if !ok {
+		return nil, ErrAMPSubscriptionNotFound
+	}
+
+	return sub.Copy(), nil
+}
+
+// AMPSubscriptions returns all subscriptions of AMP invoices, keyed by the
+// payment hash of their invoice.
+func (i *InvoiceRegistry) AMPSubscriptions() map[lntypes.Hash]*channeldb.AMPSubscription {
+	i.ampSubsMtx.Lock()
+	defer i.ampSubsMtx.Unlock()
+
+	subs := make(
+		map[lntypes.Hash]*channeldb.AMPSubscription, len(i.ampSubs),
+	)
+	for hash, sub := range i.ampSubs {
+		subs[hash] = sub.Copy()
+	}
+
+	return subs
+}
+
+// CancelAMPSubscription cancels the subscription of the AMP invoice with the
+// given payment hash. The periods recorded so far are kept, but no further
+// periods are recorded. Canceling a canceled subscription is not an error.
+func (i *InvoiceRegistry) CancelAMPSubscription(hash lntypes.Hash) error {
+	i.ampSubsMtx.Lock()
+	defer i.ampSubsMtx.Unlock()
+
+	sub, ok := i.ampSubs[hash]
+	if !ok {
+		return ErrAMPSubscriptionNotFound
+	}
+
+	if sub.Canceled {
+		return nil
+	}
+
+	// Record the periods that ended before the cancellation, as they
+	// wouldn't be recorded anymore afterwards.
+	updated := sub.Copy()
+	resolveAMPPeriods(updated, i.cfg.Clock.Now())
+	updated.Canceled = true
+
+	if err := i.cdb.PutAMPSubscription(hash, updated); err != nil {
+		return err
+	}
+	i.ampSubs[hash] = updated
+
+	log.Debugf("Invoice(%v): canceled subscription", hash)
+
+	i.signalAMPSubscriptions()
+
+	return nil
+}
+
+// loadAMPSubscriptions restores the subscriptions of all AMP invoices.
+func (i *InvoiceRegistry) loadAMPSubscriptions() error {
+	subs, err := i.cdb.FetchAMPSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	i.ampSubsMtx.Lock()
+	defer i.ampSubsMtx.Unlock()
+
+	for hash, sub := range subs {
+		invoice, err := i.cdb.LookupInvoice(
+			channeldb.InvoiceRefByHash(hash),
+		)
+		switch {
+		// The subscription is kept for its history, even if its
+		// invoice was deleted.
+		case err == channeldb.ErrInvoiceNotFound ||
+			err == channeldb.ErrNoInvoicesCreated:
+
+			log.Warnf("Invoice(%v): invoice of subscription not "+
+				"found", hash)
+
+		case err != nil:
+			return err
+
+		default:
+			i.ampSubsByAddr[invoice.Terms.PaymentAddr] = hash
+		}
+
+		i.ampSubs[hash] = sub
+	}
+
+	log.Debugf("Loaded %d AMP subscriptions", len(i.ampSubs))
+
+	return nil
+}
+
+// signalAMPSubscriptions wakes up the subscription loop to take added or
+// canceled subscriptions into account.
+func (i *InvoiceRegistry) signalAMPSubscriptions() {
+	select {
+	case i.ampSubsUpdated <- struct{}{}:
+	default:
+	}
+}
+
+// resolveAMPPeriods marks the periods of the subscription that ended at the
+// given time as missed, unless they were paid. True is returned if a period
+// was resolved.
+func resolveAMPPeriods(sub *channeldb.AMPSubscription, now time.Time) bool {
+	ended := sub.EndedPeriods(now)
+
+	var resolved bool
+
+	// All periods but the last one are resolved already, as they ended
+	// before the last one started.
+	if n := len(sub.Periods); n > 0 && uint32(n) <= ended {
+		last := &sub.Periods[n-1]
+		if last.State == channeldb.SubscriptionPeriodPending {
+			last.State = channeldb.SubscriptionPeriodMissed
+			last.ResolveTime = sub.PeriodStart(uint32(n))
+			resolved = true
+		}
+	}
+
+	for index := uint32(len(sub.Periods)); index < ended; index++ {
+		sub.Periods = append(sub.Periods, channeldb.SubscriptionPeriod{
+			State:       channeldb.SubscriptionPeriodMissed,
+			ResolveTime: sub.PeriodStart(index + 1),
+		})
+		resolved = true
+	}
+
+	return resolved
+}
+
+// recordAMPPayment accounts the amount of the htlc set that was settled just
+// now to the current period of the subscription of the AMP invoice, if it has
+// one.
+func (i *InvoiceRegistry) recordAMPPayment(invoice *channeldb.Invoice,
+	setID channeldb.SetID) {
+
+	now := i.cfg.Clock.Now()
+
+	i.ampSubsMtx.Lock()
+	hash, ok := i.ampSubsByAddr[invoice.Terms.PaymentAddr]
+	if !ok {
+		i.ampSubsMtx.Unlock()
+		return
+	}
+
+	sub := i.ampSubs[hash]
+	index, ok := sub.PeriodIndex(now)
+	if sub.Canceled || !ok {
+		i.ampSubsMtx.Unlock()
+
+		log.Infof("Invoice(%v): htlc set %x settled outside of "+
+			"subscription", hash, setID[:])
+
+		return
+	}
+
+	updated := sub.Copy()
+	resolveAMPPeriods(updated, now)
+	if uint32(len(updated.Periods)) == index {
+		updated.Periods = append(
+			updated.Periods, channeldb.SubscriptionPeriod{},
+		)
+	}
+
+	period := &updated.Periods[index]
+	period.AmtPaid += invoice.AMPState[setID].AmtPaid
+	period.SetIDs = append(period.SetIDs, setID)
+
+	if period.State == channeldb.SubscriptionPeriodPending &&
+		period.AmtPaid >= updated.PeriodAmt {
+
+		period.State = channeldb.SubscriptionPeriodPaid
+		period.ResolveTime = now
+
+		log.Infof("Invoice(%v): subscription period %v paid", hash,
+			index)
+	}
+
+	// The payment was settled already, so it's only logged if it can't
+	// be recorded.
+	err := i.cdb.PutAMPSubscription(hash, updated)
+	if err != nil {
+		log.Errorf("Invoice(%v): unable to record payment of htlc "+
+			"set %x: %v", hash, setID[:], err)
+	} else {
+		i.ampSubs[hash] = updated
+	}
+	i.ampSubsMtx.Unlock()
+
+	i.notifyAMPSubscription(hash, invoice)
+}
+
+// resolveAllAMPPeriods resolves the periods of all subscriptions that ended at
+// the given time and notifies the subscribers of their invoices. It returns
+// the time at which the next period ends, and false if no period ends
+// anymore.
+func (i *InvoiceRegistry) resolveAllAMPPeriods(now time.Time) (time.Time,
+	bool) {
+
+	var (
+		resolved []lntypes.Hash
+		nextEnd  time.Time
+	)
+
+	i.ampSubsMtx.Lock()
+	for hash, sub := range i.ampSubs {
+		if sub.Canceled {
+			continue
+		}
+
+		updated := sub.Copy()
+		if resolveAMPPeriods(updated, now) {
+			err := i.cdb.PutAMPSubscription(hash, updated)
+			if err != nil {
+				log.Errorf("Invoice(%v): unable to record "+
+					"missed periods: %v", hash, err)
+				continue
+			}
+
+			i.ampSubs[hash] = updated
+			resolved = append(resolved, hash)
+		}
+
+		ended := updated.EndedPeriods(now)
+		if updated.NumPeriods != 0 && ended >= updated.NumPeriods {
+			continue
+		}
+
+		end := updated.PeriodStart(ended + 1)
+		if nextEnd.IsZero() || end.Before(nextEnd) {
+			nextEnd = end
+		}
+	}
+	i.ampSubsMtx.Unlock()
+
+	for _, hash := range resolved {
+		invoice, err := i.cdb.LookupInvoice(
+			channeldb.InvoiceRefByHash(hash),
+		)
+		if err != nil {
+			log.Debugf("Invoice(%v): not notifying about missed "+
+				"period: %v", hash, err)
+			continue
+		}
+
+		i.notifyAMPSubscription(hash, &invoice)
+	}
+
+	return nextEnd, !nextEnd.IsZero()
+}
+
+// notifyAMPSubscription notifies the subscribers of the invoice with the given
+// payment hash that a period of its subscription was paid or missed.
+func (i *InvoiceRegistry) notifyAMPSubscription(hash lntypes.Hash,
+	invoice *channeldb.Invoice) {
+
+	event := &invoiceEvent{
+		hash:               hash,
+		invoice:            invoice,
+		subscriptionUpdate: true,
+	}
+
+	select {
+	case i.invoiceEvents <- event:
+	case <-i.quit:
+	}
+}
+
+// ampSubscriptionLoop marks the periods of subscriptions as missed once they
+// end without being paid.
+//
+// NOTE: This MUST be run as a goroutine.
+func (i *InvoiceRegistry) ampSubscriptionLoop() {
+	defer i.wg.Done()
+
+	for {
+		var nextTick <-chan time.Time
+		nextEnd, ok := i.resolveAllAMPPeriods(i.cfg.Clock.Now())
+		if ok {
+			nextTick = i.tickAt(nextEnd)
+		}
+
+		select {
+		case <-nextTick:
+		case <-i.ampSubsUpdated:
+		case <-i.quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package invoices
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/amp"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
+	"github.com/stretchr/testify/require"
+)
+
+// addAMPInvoice adds an AMP invoice with a random payment hash and address.
+func addAMPInvoice(t *testing.T, ctx *testContext) (lntypes.Hash, [32]byte) {
+	var (
+		hash    lntypes.Hash
+		payAddr [32]byte
+	)
+	_, err := rand.Read(hash[:])
+	require.NoError(t, err)
+	_, err = rand.Read(payAddr[:])
+	require.NoError(t, err)
+
+	features := lnwire.NewFeatureVector(
+		lnwire.NewRawFeatureVector(
+			lnwire.TLVOnionPayloadOptional,
+			lnwire.PaymentAddrRequired,
+			lnwire.AMPRequired,
+		), lnwire.Features,
+	)
+
+	invoice := &channeldb.Invoice{
+		CreationDate: testTime,
+		Terms: channeldb.ContractTerm{
+			FinalCltvDelta: int32(testInvoiceCltvDelta),
+			Expiry:         24 * time.Hour,
+			PaymentAddr:    payAddr,
+			Features:       features,
+		},
+	}
+	_, err = ctx.registry.AddInvoice(invoice, hash)
+	require.NoError(t, err)
+
+	return hash, payAddr
+}
+
+// payAMPInvoice settles a single shard AMP htlc set of the given amount to
+// the invoice with the payment address, returning its set id.
+func payAMPInvoice(t *testing.T, ctx *testContext, payAddr [32]byte,
+	amt lnwire.MilliSatoshi, htlcID uint64) channeldb.SetID {
+
+	var setID [32]byte
+	_, err := rand.Read(setID[:])
+	require.NoError(t, err)
+
+	sharer, err := amp.NewSeedSharer()
+	require.NoError(t, err)
+	child := sharer.Child(0)
+
+	resolution, err := ctx.registry.NotifyExitHopHtlc(
+		child.Hash, amt, testHtlcExpiry, testCurrentHeight,
+		getCircuitKey(htlcID), make(chan interface{}, 1),
+		&mockPayload{
+			mpp: record.NewMPP(amt, payAddr),
+			amp: record.NewAMP(child.Share, setID, 0),
+		},
+	)
+	require.NoError(t, err)
+	checkSettleResolution(t, resolution, child.Preimage)
+
+	return setID
+}
+
+// TestAMPSubscription asserts that the payments to an AMP invoice are
+// accounted to the periods of its subscription, and that its subscribers are
+// notified about paid and missed periods.
+func TestAMPSubscription(t *testing.T) {
+	defer timeout()()
+
+	ctx := newTestContext(t)
+
+	hash, payAddr := addAMPInvoice(t, ctx)
+
+	// Subscriptions require an AMP invoice.
+	preimage := lntypes.Preimage{1}
+	_, err := ctx.registry.AddInvoice(
+		newTestInvoice(t, preimage, testTime, 0), preimage.Hash(),
+	)
+	require.NoError(t, err)
+
+	sub := &channeldb.AMPSubscription{
+		PeriodAmt:    1000,
+		PeriodLength: time.Hour,
+		NumPeriods:   3,
+	}
+	err = ctx.registry.AddAMPSubscription(preimage.Hash(), sub)
+	require.ErrorIs(t, err, ErrAMPSubscriptionNotAMP)
+
+	sub.PeriodLength = time.Millisecond
+	err = ctx.registry.AddAMPSubscription(hash, sub)
+	require.Error(t, err)
+
+	sub.PeriodLength = time.Hour
+	require.NoError(t, ctx.registry.AddAMPSubscription(hash, sub))
+	err = ctx.registry.AddAMPSubscription(hash, sub)
+	require.ErrorIs(t, err, ErrAMPSubscriptionExists)
+
+	invoiceSub, err := ctx.registry.SubscribeSingleInvoice(hash)
+	require.NoError(t, err)
+	defer invoiceSub.Cancel()
+
+	// The current state of the invoice is sent first.
+	<-invoiceSub.Updates
+
+	// A partial payment leaves the first period pending, and the second
+	// one pays it.
+	setID1 := payAMPInvoice(t, ctx, payAddr, 400, 0)
+	<-invoiceSub.Updates
+
+	stored, err := ctx.registry.LookupAMPSubscription(hash)
+	require.NoError(t, err)
+	require.Equal(t, testTime, stored.StartTime)
+	require.Equal(t, []channeldb.SubscriptionPeriod{{
+		State:   channeldb.SubscriptionPeriodPending,
+		AmtPaid: 400,
+		SetIDs:  []channeldb.SetID{setID1},
+	}}, stored.Periods)
+
+	setID2 := payAMPInvoice(t, ctx, payAddr, 600, 1)
+	<-invoiceSub.Updates
+
+	stored, err = ctx.registry.LookupAMPSubscription(hash)
+	require.NoError(t, err)
+	require.Equal(t, []channeldb.SubscriptionPeriod{{
+		State:       channeldb.SubscriptionPeriodPaid,
+		AmtPaid:     1000,
+		SetIDs:      []channeldb.SetID{setID1, setID2},
+		ResolveTime: testTime,
+	}}, stored.Periods)
+
+	// Once the second period ends without a payment, it is missed.
+	ctx.clock.SetTime(testTime.Add(2*time.Hour + time.Minute))
+	<-invoiceSub.Updates
+
+	stored, err = ctx.registry.LookupAMPSubscription(hash)
+	require.NoError(t, err)
+	require.Len(t, stored.Periods, 2)
+	require.Equal(t, channeldb.SubscriptionPeriod{
+		State:       channeldb.SubscriptionPeriodMissed,
+		ResolveTime: testTime.Add(2 * time.Hour),
+	}, stored.Periods[1])
+
+	// After the cancellation, payments aren't recorded anymore.
+	require.NoError(t, ctx.registry.CancelAMPSubscription(hash))
+	payAMPInvoice(t, ctx, payAddr, 1000, 2)
+
+	subs := ctx.registry.AMPSubscriptions()
+	require.Len(t, subs, 1)
+	require.True(t, subs[hash].Canceled)
+	require.Len(t, subs[hash].Periods, 2)
+
+	// The subscription is stored in the database.
+	dbSubs, err := ctx.cdb.FetchAMPSubscriptions()
+	require.NoError(t, err)
+	require.True(t, dbSubs[hash].Canceled)
+	require.True(t, dbSubs[hash].StartTime.Equal(testTime))
+	require.Len(t, dbSubs[hash].Periods, 2)
+	require.Equal(t, []channeldb.SetID{setID1, setID2},
+		dbSubs[hash].Periods[0].SetIDs)
+}
@@ -0,0 +1,337 @@
+package invoices
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lntypes"
+)
+
+var (
+	// ErrHoldPolicyNotHodl is returned when a hold policy is attached to
+	// an invoice that isn't a hold invoice.
+	ErrHoldPolicyNotHodl = errors.New("hold policies require a hold " +
+		"invoice")
+
+	// ErrHoldPolicyPreimageMismatch is returned when the preimage of a
+	// hold policy doesn't match the payment hash of the invoice.
+	ErrHoldPolicyPreimageMismatch = errors.New("hold policy preimage " +
+		"doesn't match payment hash")
+
+	// ErrHoldPolicyNoPreimage is returned when a hold policy settles on a
+	// confirmation, but doesn't specify the preimage to settle with.
+	ErrHoldPolicyNoPreimage = errors.New("settling on a confirmation " +
+		"requires a preimage")
+
+	// ErrHoldPolicyUnsupported is returned when a hold policy uses a
+	// settle condition that the registry can't watch for.
+	ErrHoldPolicyUnsupported = errors.New("hold policy settle condition " +
+		"not supported")
+)
+
+// PaymentPreimageNotifier notifies about the preimages of outgoing payments.
+type PaymentPreimageNotifier interface {
+	// SubscribePaymentPreimage returns a channel that receives the
+	// preimage of the outgoing payment with the given hash once it
+	// succeeds, including a payment that already succeeded. The returned
+	// closure cancels the subscription.
+	SubscribePaymentPreimage(hash lntypes.Hash) (<-chan lntypes.Preimage,
+		func(), error)
+}
+
+// validateHoldPolicy checks that the policy can be applied to the invoice.
+func (i *InvoiceRegistry) validateHoldPolicy(invoice *channeldb.Invoice,
+	hash lntypes.Hash, policy *channeldb.HoldPolicy) error {
+
+	if !invoice.HodlInvoice {
+		return ErrHoldPolicyNotHodl
+	}
+
+	if policy.Preimage != nil && policy.Preimage.Hash() != hash {
+		return ErrHoldPolicyPreimageMismatch
+	}
+
+	if policy.SettleConf != nil {
+		if policy.Preimage == nil {
+			return ErrHoldPolicyNoPreimage
+		}
+
+		if i.cfg.ChainNotifier == nil {
+			return fmt.Errorf("%w: no chain notifier",
+				ErrHoldPolicyUnsupported)
+		}
+
+		if policy.SettleConf.NumConfs == 0 {
+			return fmt.Errorf("settling on a confirmation " +
+				"requires at least one confirmation")
+		}
+	}
+
+	if policy.SettleOnPayment && i.cfg.PaymentNotifier == nil {
+		return fmt.Errorf("%w: no payment notifier",
+			ErrHoldPolicyUnsupported)
+	}
+
+	return nil
+}
+
+// AddHoldInvoice adds a hold invoice together with the policy that settles or
+// cancels it automatically. Apart from that it behaves like AddInvoice.
+func (i *InvoiceRegistry) AddHoldInvoice(invoice *channeldb.Invoice,
+	paymentHash lntypes.Hash, policy *channeldb.HoldPolicy) (uint64,
+	error) {
+
+	err := i.validateHoldPolicy(invoice, paymentHash, policy)
+	if err != nil {
+		return 0, err
+	}
+
+	addIndex, err := i.AddInvoice(invoice, paymentHash)
+	if err != nil {
+		return 0, err
+	}
+
+	// The policy is stored after the invoice, so a duplicate invoice can't
+	// overwrite the policy of the existing one. If storing the policy
+	// fails, the invoice is canceled as it wouldn't be resolved the way
+	// the caller expects.
+	if err := i.cdb.PutHoldPolicy(paymentHash, policy); err != nil {
+		log.Errorf("Invoice(%v): unable to store hold policy: %v",
+			paymentHash, err)
+
+		if err := i.CancelInvoice(paymentHash); err != nil {
+			log.Errorf("Invoice(%v): unable to cancel: %v",
+				paymentHash, err)
+		}
+
+		return 0, err
+	}
+
+	log.Debugf("Invoice(%v): added hold policy: cancel_delta=%v, "+
+		"settle_on_conf=%v, settle_on_payment=%v", paymentHash,
+		policy.CancelDelta, policy.SettleConf != nil,
+		policy.SettleOnPayment)
+
+	i.startHoldPolicy(paymentHash, policy)
+
+	return addIndex, nil
+}
+
+// loadHoldPolicies restores the policies of all pending hold invoices and
+// deletes the policies of invoices that are already settled or canceled.
+func (i *InvoiceRegistry) loadHoldPolicies() error {
+	policies, err := i.cdb.FetchHoldPolicies()
+	if err != nil {
+		return err
+	}
+
+	for hash, policy := range policies {
+		invoice, err := i.cdb.LookupInvoice(
+			channeldb.InvoiceRefByHash(hash),
+		)
+		switch {
+		case err == nil && invoice.IsPending():
+			i.startHoldPolicy(hash, policy)
+			continue
+
+		case err != nil && err != channeldb.ErrInvoiceNotFound &&
+			err != channeldb.ErrNoInvoicesCreated:
+
+			return err
+		}
+
+		log.Debugf("Invoice(%v): deleting hold policy of resolved "+
+			"invoice", hash)
+
+		if err := i.cdb.DeleteHoldPolicy(hash); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Loaded %d hold policies", len(i.holdPolicies))
+
+	return nil
+}
+
+// startHoldPolicy tracks the policy of a pending hold invoice and launches a
+// goroutine that watches its settle conditions, if it has any.
+func (i *InvoiceRegistry) startHoldPolicy(hash lntypes.Hash,
+	policy *channeldb.HoldPolicy) {
+
+	i.holdPoliciesMtx.Lock()
+	i.holdPolicies[hash] = policy
+	i.holdPoliciesMtx.Unlock()
+
+	if policy.SettleConf == nil && !policy.SettleOnPayment {
+		return
+	}
+
+	i.wg.Add(1)
+	go i.watchHoldPolicy(hash, policy)
+}
+
+// removeHoldPolicy deletes the policy of a hold invoice that was settled or
+// canceled. A running watcher exits by itself once it learns about the final
+// state of the invoice.
+func (i *InvoiceRegistry) removeHoldPolicy(hash lntypes.Hash) {
+	i.holdPoliciesMtx.Lock()
+	_, ok := i.holdPolicies[hash]
+	delete(i.holdPolicies, hash)
+	i.holdPoliciesMtx.Unlock()
+
+	if !ok {
+		return
+	}
+
+	// A policy that remains in the database is deleted on the next start
+	// of the registry, so the error isn't returned.
+	if err := i.cdb.DeleteHoldPolicy(hash); err != nil {
+		log.Warnf("Invoice(%v): unable to delete hold policy: %v",
+			hash, err)
+	}
+}
+
+// invoiceExpiry returns the expiry of the invoice, taking the cancel delta of
+// its hold policy into account.
+func (i *InvoiceRegistry) invoiceExpiry(hash lntypes.Hash,
+	invoice *channeldb.Invoice) invoiceExpiry {
+
+	expiry := makeInvoiceExpiry(hash, invoice)
+
+	heightExpiry, ok := expiry.(*invoiceExpiryHeight)
+	if !ok {
+		return expiry
+	}
+
+	i.holdPoliciesMtx.Lock()
+	if policy, ok := i.holdPolicies[hash]; ok {
+		heightExpiry.delta = policy.CancelDelta
+	}
+	i.holdPoliciesMtx.Unlock()
+
+	return heightExpiry
+}
+
+// watchHoldPolicy settles a hold invoice once a settle condition of its
+// policy is met and the invoice is accepted. It exits once the invoice is
+// settled or canceled.
+//
+// NOTE: This MUST be run as a goroutine.
+func (i *InvoiceRegistry) watchHoldPolicy(hash lntypes.Hash,
+	policy *channeldb.HoldPolicy) {
+
+	defer i.wg.Done()
+
+	invoiceSub, err := i.SubscribeSingleInvoice(hash)
+	if err != nil {
+		log.Errorf("Invoice(%v): unable to subscribe: %v", hash, err)
+		return
+	}
+	defer invoiceSub.Cancel()
+
+	var confChan chan *chainntnfs.TxConfirmation
+	if policy.SettleConf != nil {
+		conf := policy.SettleConf
+		confEvent, err := i.cfg.ChainNotifier.RegisterConfirmationsNtfn(
+			&conf.Txid, conf.PkScript, conf.NumConfs,
+			conf.HeightHint,
+		)
+		if err != nil {
+			log.Errorf("Invoice(%v): unable to register for "+
+				"confirmation of %v: %v", hash, conf.Txid, err)
+			return
+		}
+		defer confEvent.Cancel()
+
+		confChan = confEvent.Confirmed
+	}
+
+	var preimageChan <-chan lntypes.Preimage
+	if policy.SettleOnPayment {
+		var cancel func()
+		preimageChan, cancel, err =
+			i.cfg.PaymentNotifier.SubscribePaymentPreimage(hash)
+		if err != nil {
+			log.Errorf("Invoice(%v): unable to subscribe to "+
+				"payment: %v", hash, err)
+			return
+		}
+		defer cancel()
+	}
+
+	var (
+		preimage *lntypes.Preimage
+		accepted bool
+	)
+	for {
+		select {
+		case _, ok := <-confChan:
+			if !ok {
+				return
+			}
+
+			log.Infof("Invoice(%v): settle transaction %v "+
+				"confirmed", hash, policy.SettleConf.Txid)
+
+			preimage = policy.Preimage
+			confChan = nil
+
+		case p, ok := <-preimageChan:
+			if !ok {
+				return
+			}
+
+			log.Infof("Invoice(%v): linked payment succeeded", hash)
+
+			preimage = &p
+			preimageChan = nil
+
+		case invoice, ok := <-invoiceSub.Updates:
+			if !ok {
+				return
+			}
+
+			switch invoice.State {
+			case channeldb.ContractSettled,
+				channeldb.ContractCanceled:
+
+				return
+			}
+
+			accepted = invoice.State == channeldb.ContractAccepted
+
+		case <-i.quit:
+			return
+		}
+
+		if preimage == nil || !accepted {
+			continue
+		}
+
+		err := i.SettleHodlInvoice(*preimage)
+		switch {
+		// The htlcs were canceled in the meantime, so we wait for the
+		// invoice to be accepted again.
+		case errors.Is(err, channeldb.ErrInvoiceStillOpen):
+			accepted = false
+
+		// The invoice was resolved in the meantime, which we'll also
+		// learn from the subscription.
+		case errors.Is(err, channeldb.ErrInvoiceAlreadySettled),
+			errors.Is(err, channeldb.ErrInvoiceAlreadyCanceled):
+
+			return
+
+		case err != nil:
+			log.Errorf("Invoice(%v): unable to settle: %v", hash,
+				err)
+			return
+
+		default:
+			log.Infof("Invoice(%v): settled by hold policy", hash)
+			return
+		}
+	}
+}
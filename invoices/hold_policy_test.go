@@ -0,0 +1,346 @@
+package invoices
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/stretchr/testify/require"
+)
+
+// mockConfNotifier extends the mock chain notifier with confirmation
+// notifications.
+type mockConfNotifier struct {
+	*mockChainNotifier
+
+	confChan chan *chainntnfs.TxConfirmation
+}
+
+// RegisterConfirmationsNtfn delivers confirmations over the mock's
+// confirmation channel.
+func (m *mockConfNotifier) RegisterConfirmationsNtfn(*chainhash.Hash, []byte,
+	uint32, uint32, ...chainntnfs.NotifierOption) (
+	*chainntnfs.ConfirmationEvent, error) {
+
+	return &chainntnfs.ConfirmationEvent{
+		Confirmed: m.confChan,
+		Cancel:    func() {},
+	}, nil
+}
+
+// mockPaymentNotifier delivers payment preimages over its preimage channel.
+type mockPaymentNotifier struct {
+	preimageChan chan lntypes.Preimage
+}
+
+// SubscribePaymentPreimage returns the mock's preimage channel.
+func (m *mockPaymentNotifier) SubscribePaymentPreimage(lntypes.Hash) (
+	<-chan lntypes.Preimage, func(), error) {
+
+	return m.preimageChan, func() {}, nil
+}
+
+// holdPolicyTest holds a registry with mocked chain and payment notifiers.
+type holdPolicyTest struct {
+	cdb             *channeldb.DB
+	registry        *InvoiceRegistry
+	chainNotifier   *mockConfNotifier
+	paymentNotifier *mockPaymentNotifier
+}
+
+// newHoldPolicyTest starts a registry on the given database.
+func newHoldPolicyTest(t *testing.T, cdb *channeldb.DB) *holdPolicyTest {
+	chainNotifier := &mockConfNotifier{
+		mockChainNotifier: newMockNotifier(),
+		confChan:          make(chan *chainntnfs.TxConfirmation),
+	}
+	test := &holdPolicyTest{
+		cdb:           cdb,
+		chainNotifier: chainNotifier,
+		paymentNotifier: &mockPaymentNotifier{
+			preimageChan: make(chan lntypes.Preimage, 1),
+		},
+	}
+
+	cfg := RegistryConfig{
+		FinalCltvRejectDelta: testFinalCltvRejectDelta,
+		Clock:                clock.NewTestClock(testTime),
+		ChainNotifier:        test.chainNotifier,
+		PaymentNotifier:      test.paymentNotifier,
+	}
+	expiryWatcher := NewInvoiceExpiryWatcher(
+		cfg.Clock, 0, uint32(testCurrentHeight), nil,
+		test.chainNotifier,
+	)
+	test.registry = NewRegistry(cdb, expiryWatcher, &cfg)
+
+	require.NoError(t, test.registry.Start())
+
+	return test
+}
+
+// addHoldInvoice adds a copy of the test hold invoice with the given policy.
+func (h *holdPolicyTest) addHoldInvoice(t *testing.T,
+	policy *channeldb.HoldPolicy) {
+
+	invoice := *testHodlInvoice
+	_, err := h.registry.AddHoldInvoice(
+		&invoice, testInvoicePaymentHash, policy,
+	)
+	require.NoError(t, err)
+}
+
+// acceptHtlc accepts an htlc with the given expiry, returning the channel
+// over which its resolution is delivered.
+func (h *holdPolicyTest) acceptHtlc(t *testing.T,
+	expiry uint32) chan interface{} {
+
+	hodlChan := make(chan interface{}, 1)
+	resolution, err := h.registry.NotifyExitHopHtlc(
+		testInvoicePaymentHash, testInvoiceAmt, expiry,
+		testCurrentHeight, getCircuitKey(0), hodlChan, testPayload,
+	)
+	require.NoError(t, err)
+	require.Nil(t, resolution)
+
+	return hodlChan
+}
+
+// assertResolution waits for the resolution of the htlc.
+func assertResolution(t *testing.T,
+	hodlChan chan interface{}) HtlcResolution {
+
+	select {
+	case resolution := <-hodlChan:
+		return resolution.(HtlcResolution)
+
+	case <-time.After(testTimeout):
+		t.Fatal("htlc not resolved")
+	}
+
+	return nil
+}
+
+// assertNoResolution asserts that the htlc isn't resolved.
+func assertNoResolution(t *testing.T, hodlChan chan interface{}) {
+	select {
+	case resolution := <-hodlChan:
+		t.Fatalf("unexpected resolution: %v", resolution)
+
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestHoldPolicyValidation asserts that hold policies that can't be applied
+// are rejected.
+func TestHoldPolicyValidation(t *testing.T) {
+	t.Parallel()
+
+	wrongPreimage := lntypes.Preimage{2}
+	settleConf := &channeldb.HoldSettleConf{
+		PkScript: []byte{0x00},
+		NumConfs: 1,
+	}
+
+	tests := []struct {
+		name    string
+		invoice *channeldb.Invoice
+		policy  *channeldb.HoldPolicy
+		cfg     RegistryConfig
+		err     error
+	}{
+		{
+			name:    "not a hold invoice",
+			invoice: testInvoice,
+			policy:  &channeldb.HoldPolicy{CancelDelta: 10},
+			err:     ErrHoldPolicyNotHodl,
+		},
+		{
+			name:    "preimage mismatch",
+			invoice: testHodlInvoice,
+			policy: &channeldb.HoldPolicy{
+				Preimage: &wrongPreimage,
+			},
+			err: ErrHoldPolicyPreimageMismatch,
+		},
+		{
+			name:    "settle on conf without preimage",
+			invoice: testHodlInvoice,
+			policy: &channeldb.HoldPolicy{
+				SettleConf: settleConf,
+			},
+			cfg: RegistryConfig{
+				ChainNotifier: newMockNotifier(),
+			},
+			err: ErrHoldPolicyNoPreimage,
+		},
+		{
+			name:    "settle on conf without notifier",
+			invoice: testHodlInvoice,
+			policy: &channeldb.HoldPolicy{
+				Preimage:   &testInvoicePreimage,
+				SettleConf: settleConf,
+			},
+			err: ErrHoldPolicyUnsupported,
+		},
+		{
+			name:    "settle on payment without notifier",
+			invoice: testHodlInvoice,
+			policy: &channeldb.HoldPolicy{
+				SettleOnPayment: true,
+			},
+			err: ErrHoldPolicyUnsupported,
+		},
+		{
+			name:    "settle on conf",
+			invoice: testHodlInvoice,
+			policy: &channeldb.HoldPolicy{
+				Preimage:   &testInvoicePreimage,
+				SettleConf: settleConf,
+			},
+			cfg: RegistryConfig{
+				ChainNotifier: newMockNotifier(),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			registry := NewRegistry(nil, nil, &test.cfg)
+			err := registry.validateHoldPolicy(
+				test.invoice, testInvoicePaymentHash,
+				test.policy,
+			)
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+// TestHoldPolicySettleOnConf asserts that a hold invoice is settled once the
+// settle transaction confirms and the invoice is accepted.
+func TestHoldPolicySettleOnConf(t *testing.T) {
+	t.Parallel()
+	defer timeout()()
+
+	cdb, err := newTestChannelDB(t, clock.NewTestClock(time.Time{}))
+	require.NoError(t, err)
+
+	test := newHoldPolicyTest(t, cdb)
+	defer func() {
+		require.NoError(t, test.registry.Stop())
+	}()
+
+	test.addHoldInvoice(t, &channeldb.HoldPolicy{
+		Preimage: &testInvoicePreimage,
+		SettleConf: &channeldb.HoldSettleConf{
+			PkScript: []byte{0x00},
+			NumConfs: 1,
+		},
+	})
+
+	// The confirmation arrives before the htlc, so the invoice is only
+	// settled once the htlc is accepted.
+	test.chainNotifier.confChan <- &chainntnfs.TxConfirmation{}
+
+	hodlChan := test.acceptHtlc(t, testHtlcExpiry)
+	resolution := assertResolution(t, hodlChan)
+	checkSettleResolution(t, resolution, testInvoicePreimage)
+
+	invoice, err := test.registry.LookupInvoice(testInvoicePaymentHash)
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractSettled, invoice.State)
+
+	// The policy of the settled invoice is deleted.
+	policies, err := cdb.FetchHoldPolicies()
+	require.NoError(t, err)
+	require.Empty(t, policies)
+}
+
+// TestHoldPolicySettleOnPayment asserts that a hold invoice is settled with
+// the preimage of the linked payment, also after a restart of the registry.
+func TestHoldPolicySettleOnPayment(t *testing.T) {
+	t.Parallel()
+	defer timeout()()
+
+	cdb, err := newTestChannelDB(t, clock.NewTestClock(time.Time{}))
+	require.NoError(t, err)
+
+	test := newHoldPolicyTest(t, cdb)
+	test.addHoldInvoice(t, &channeldb.HoldPolicy{
+		SettleOnPayment: true,
+	})
+	test.acceptHtlc(t, testHtlcExpiry)
+
+	// Restart the registry. The policy is loaded from the database.
+	require.NoError(t, test.registry.Stop())
+	test = newHoldPolicyTest(t, cdb)
+	defer func() {
+		require.NoError(t, test.registry.Stop())
+	}()
+
+	// Resubscribe to the htlc like the link does after a restart. The htlc
+	// isn't resolved before the payment succeeds.
+	hodlChan := test.acceptHtlc(t, testHtlcExpiry)
+	assertNoResolution(t, hodlChan)
+
+	test.paymentNotifier.preimageChan <- testInvoicePreimage
+
+	resolution := assertResolution(t, hodlChan)
+	checkSettleResolution(t, resolution, testInvoicePreimage)
+}
+
+// TestHoldPolicyCancelDelta asserts that an accepted hold invoice is canceled
+// at the cancel delta of its policy before the htlc expires.
+func TestHoldPolicyCancelDelta(t *testing.T) {
+	t.Parallel()
+	defer timeout()()
+
+	const (
+		htlcExpiry  = 50
+		cancelDelta = 10
+	)
+
+	cdb, err := newTestChannelDB(t, clock.NewTestClock(time.Time{}))
+	require.NoError(t, err)
+
+	test := newHoldPolicyTest(t, cdb)
+	defer func() {
+		require.NoError(t, test.registry.Stop())
+	}()
+
+	test.addHoldInvoice(t, &channeldb.HoldPolicy{
+		CancelDelta: cancelDelta,
+	})
+	hodlChan := test.acceptHtlc(t, htlcExpiry)
+
+	announceBlock := func(height uint32) {
+		select {
+		case test.chainNotifier.blockChan <- &chainntnfs.BlockEpoch{
+			Height: int32(height),
+		}:
+
+		case <-time.After(testTimeout):
+			t.Fatalf("block %v not consumed", height)
+		}
+	}
+
+	// One block before the cancel height, the htlc remains held.
+	announceBlock(htlcExpiry - cancelDelta - 1)
+	assertNoResolution(t, hodlChan)
+
+	announceBlock(htlcExpiry - cancelDelta)
+	resolution := assertResolution(t, hodlChan)
+	checkFailResolution(t, resolution, ResultCanceled)
+
+	invoice, err := test.registry.LookupInvoice(testInvoicePaymentHash)
+	require.NoError(t, err)
+	require.Equal(t, channeldb.ContractCanceled, invoice.State)
+}
@@ -44,18 +44,28 @@ var _ invoiceExpiry = (*invoiceExpiryHeight)(nil)
 type invoiceExpiryHeight struct {
 	paymentHash  lntypes.Hash
 	expiryHeight uint32
+
+	// delta is the number of blocks before the expiry height at which the
+	// invoice is canceled. If it is zero when the entry is added to the
+	// watcher, the watcher's default delta is used.
+	delta uint32
+}
+
+// cancelHeight returns the height at which the invoice is canceled.
+func (b invoiceExpiryHeight) cancelHeight() int64 {
+	return int64(b.expiryHeight) - int64(b.delta)
 }
 
 // Less implements PriorityQueueItem.Less such that the top item in the
-// priority queue is the lowest block height.
+// priority queue is the one that is canceled at the lowest block height.
 func (b invoiceExpiryHeight) Less(other queue.PriorityQueueItem) bool {
-	return b.expiryHeight < other.(*invoiceExpiryHeight).expiryHeight
+	return b.cancelHeight() < other.(*invoiceExpiryHeight).cancelHeight()
 }
 
 // expired returns a boolean that indicates whether this entry has expired,
-// taking our expiry delta into account.
-func (b invoiceExpiryHeight) expired(currentHeight, delta uint32) bool {
-	return currentHeight+delta >= b.expiryHeight
+// taking its expiry delta into account.
+func (b invoiceExpiryHeight) expired(currentHeight uint32) bool {
+	return int64(currentHeight) >= b.cancelHeight()
 }
 
 // InvoiceExpiryWatcher handles automatic invoice cancellation of expired
@@ -294,7 +304,7 @@ func (ew *InvoiceExpiryWatcher) nextHeightExpiry() <-chan uint32 {
 	}
 
 	top := ew.blockExpiryQueue.Top().(*invoiceExpiryHeight)
-	if !top.expired(ew.currentHeight, ew.blockExpiryDelta) {
+	if !top.expired(ew.currentHeight) {
 		return nil
 	}
 
@@ -331,7 +341,7 @@ func (ew *InvoiceExpiryWatcher) cancelNextHeightExpiredInvoice() {
 	}
 
 	top := ew.blockExpiryQueue.Top().(*invoiceExpiryHeight)
-	if !top.expired(ew.currentHeight, ew.blockExpiryDelta) {
+	if !top.expired(ew.currentHeight) {
 		return
 	}
 
@@ -377,6 +387,9 @@ func (ew *InvoiceExpiryWatcher) pushInvoices(invoices []invoiceExpiry) {
 
 		case *invoiceExpiryHeight:
 			if expiry != nil {
+				if expiry.delta == 0 {
+					expiry.delta = ew.blockExpiryDelta
+				}
 				ew.blockExpiryQueue.Push(expiry)
 			}
 
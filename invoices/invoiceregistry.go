@@ -7,6 +7,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/lightningnetwork/lnd/chainntnfs"
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/clock"
 	"github.com/lightningnetwork/lnd/lntypes"
@@ -72,6 +73,15 @@ type RegistryConfig struct {
 	// KeysendHoldTime indicates for how long we want to accept and hold
 	// spontaneous keysend payments.
 	KeysendHoldTime time.Duration
+
+	// ChainNotifier is used to settle hold invoices once a transaction
+	// confirms. If it is nil, such hold policies are rejected.
+	ChainNotifier chainntnfs.ChainNotifier
+
+	// PaymentNotifier is used to settle hold invoices once a linked
+	// outgoing payment succeeds. If it is nil, such hold policies are
+	// rejected.
+	PaymentNotifier PaymentPreimageNotifier
 }
 
 // htlcReleaseEvent describes an htlc auto-release event. It is used to release
@@ -143,6 +153,28 @@ type InvoiceRegistry struct {
 
 	expiryWatcher *InvoiceExpiryWatcher
 
+	// holdPoliciesMtx locks holdPolicies.
+	holdPoliciesMtx sync.Mutex
+
+	// holdPolicies holds the policies of pending hold invoices, keyed by
+	// their payment hash.
+	holdPolicies map[lntypes.Hash]*channeldb.HoldPolicy
+
+	// ampSubsMtx locks ampSubs and ampSubsByAddr.
+	ampSubsMtx sync.Mutex
+
+	// ampSubs holds the subscriptions of AMP invoices, keyed by the
+	// payment hash of their invoice.
+	ampSubs map[lntypes.Hash]*channeldb.AMPSubscription
+
+	// ampSubsByAddr maps the payment address of an AMP invoice with a
+	// subscription to the payment hash of the invoice.
+	ampSubsByAddr map[[32]byte]lntypes.Hash
+
+	// ampSubsUpdated wakes up the subscription loop once a subscription
+	// is added or canceled.
+	ampSubsUpdated chan struct{}
+
 	wg   sync.WaitGroup
 	quit chan struct{}
 }
@@ -164,6 +196,10 @@ func NewRegistry(cdb *channeldb.DB, expiryWatcher *InvoiceExpiryWatcher,
 		cfg:                       cfg,
 		htlcAutoReleaseChan:       make(chan *htlcReleaseEvent),
 		expiryWatcher:             expiryWatcher,
+		holdPolicies:              make(map[lntypes.Hash]*channeldb.HoldPolicy),
+		ampSubs:                   make(map[lntypes.Hash]*channeldb.AMPSubscription),
+		ampSubsByAddr:             make(map[[32]byte]lntypes.Hash),
+		ampSubsUpdated:            make(chan struct{}, 1),
 		quit:                      make(chan struct{}),
 	}
 }
@@ -191,7 +227,7 @@ func (i *InvoiceRegistry) scanInvoicesOnStart() error {
 		invoice *channeldb.Invoice) error {
 
 		if invoice.IsPending() {
-			expiryRef := makeInvoiceExpiry(paymentHash, invoice)
+			expiryRef := i.invoiceExpiry(paymentHash, invoice)
 			if expiryRef != nil {
 				pending = append(pending, expiryRef)
 			}
@@ -254,6 +290,13 @@ func (i *InvoiceRegistry) Start() error {
 	i.wg.Add(1)
 	go i.invoiceEventLoop()
 
+	// Load the hold policies before scanning the invoices, so the expiry
+	// of accepted hold invoices takes their cancel delta into account.
+	if err := i.loadHoldPolicies(); err != nil {
+		_ = i.Stop()
+		return err
+	}
+
 	// Now scan all pending and removable invoices to the expiry watcher or
 	// delete them.
 	err = i.scanInvoicesOnStart()
@@ -262,6 +305,14 @@ func (i *InvoiceRegistry) Start() error {
 		return err
 	}
 
+	if err := i.loadAMPSubscriptions(); err != nil {
+		_ = i.Stop()
+		return err
+	}
+
+	i.wg.Add(1)
+	go i.ampSubscriptionLoop()
+
 	return nil
 }
 
@@ -284,6 +335,12 @@ type invoiceEvent struct {
 	hash    lntypes.Hash
 	invoice *channeldb.Invoice
 	setID   *[32]byte
+
+	// subscriptionUpdate is set if a period of the subscription of the
+	// AMP invoice was paid or missed, rather than the invoice itself
+	// being updated. Such events are only sent to the subscribers of the
+	// single invoice.
+	subscriptionUpdate bool
 }
 
 // tickAt returns a channel that ticks at the specified time. If the time has
@@ -318,7 +375,8 @@ func (i *InvoiceRegistry) invoiceEventLoop() {
 			// For backwards compatibility, do not notify all
 			// invoice subscribers of cancel and accept events.
 			state := event.invoice.State
-			if state != channeldb.ContractCanceled &&
+			if !event.subscriptionUpdate &&
+				state != channeldb.ContractCanceled &&
 				state != channeldb.ContractAccepted {
 
 				i.dispatchToClients(event)
@@ -590,7 +648,7 @@ func (i *InvoiceRegistry) AddInvoice(invoice *channeldb.Invoice,
 	// InvoiceExpiryWatcher.AddInvoice must not be locked by InvoiceRegistry
 	// to avoid deadlock when a new invoice is added while an other is being
 	// canceled.
-	invoiceExpiryRef := makeInvoiceExpiry(paymentHash, invoice)
+	invoiceExpiryRef := i.invoiceExpiry(paymentHash, invoice)
 	if invoiceExpiryRef != nil {
 		i.expiryWatcher.AddInvoices(invoiceExpiryRef)
 	}
@@ -1195,7 +1253,7 @@ func (i *InvoiceRegistry) notifyExitHopHtlcLocked(
 		// possible that we MppTimeout the htlcs, and then our relevant
 		// expiry height could change.
 		if res.outcome == resultAccepted {
-			invoiceToExpire = makeInvoiceExpiry(ctx.hash, invoice)
+			invoiceToExpire = i.invoiceExpiry(ctx.hash, invoice)
 		}
 
 		i.hodlSubscribe(hodlChan, ctx.circuitKey)
@@ -1216,6 +1274,12 @@ func (i *InvoiceRegistry) notifyExitHopHtlcLocked(
 		}
 
 		i.notifyClients(ctx.hash, invoice, setID)
+
+		// Account the payment to the subscription of the AMP invoice,
+		// if it has one.
+		if setID != nil && ctx.amp != nil {
+			i.recordAMPPayment(invoice, *setID)
+		}
 	}
 
 	return resolution, invoiceToExpire, nil
@@ -1259,6 +1323,8 @@ func (i *InvoiceRegistry) SettleHodlInvoice(preimage lntypes.Preimage) error {
 	log.Debugf("Invoice%v: settled with preimage %v", invoiceRef,
 		invoice.Terms.PaymentPreimage)
 
+	i.removeHoldPolicy(hash)
+
 	// In the callback, we marked the invoice as settled. UpdateInvoice will
 	// have seen this and should have moved all htlcs that were accepted to
 	// the settled state. In the loop below, we go through all of these and
@@ -1353,6 +1419,8 @@ func (i *InvoiceRegistry) cancelInvoiceImpl(payHash lntypes.Hash,
 
 	log.Debugf("Invoice%v: canceled", ref)
 
+	i.removeHoldPolicy(payHash)
+
 	// In the callback, some htlcs may have been moved to the canceled
 	// state. We now go through all of these and notify links and resolvers
 	// that are waiting for resolution. Any htlcs that were already canceled
@@ -12,16 +12,22 @@ const (
 	// DefaultEtcdElectionPrefix is used as election prefix if none is provided
 	// through the config.
 	DefaultEtcdElectionPrefix = "/leader/"
+
+	// DefaultPostgresElectionKey is used as election key if none is
+	// provided through the config.
+	DefaultPostgresElectionKey = "lnd-leader"
 )
 
 // Cluster holds configuration for clustered LND.
 type Cluster struct {
 	EnableLeaderElection bool `long:"enable-leader-election" description:"Enables leader election if set."`
 
-	LeaderElector string `long:"leader-elector" choice:"etcd" description:"Leader elector to use. Valid values: \"etcd\"."`
+	LeaderElector string `long:"leader-elector" choice:"etcd" choice:"postgres" description:"Leader elector to use. Valid values: \"etcd\" and \"postgres\"."`
 
 	EtcdElectionPrefix string `long:"etcd-election-prefix" description:"Election key prefix when using etcd leader elector."`
 
+	PostgresElectionKey string `long:"postgres-election-key" description:"Election key when using the postgres leader elector. All nodes using the same key on the same database compete for leadership."`
+
 	ID string `long:"id" description:"Identifier for this node inside the cluster (used in leader election). Defaults to the hostname."`
 
 	LeaderSessionTTL int `long:"leader-session-ttl" description:"The TTL in seconds to use for the leader election session."`
@@ -31,10 +37,11 @@ type Cluster struct {
 func DefaultCluster() *Cluster {
 	hostname, _ := os.Hostname()
 	return &Cluster{
-		LeaderElector:      cluster.EtcdLeaderElector,
-		EtcdElectionPrefix: DefaultEtcdElectionPrefix,
-		LeaderSessionTTL:   60,
-		ID:                 hostname,
+		LeaderElector:       cluster.EtcdLeaderElector,
+		EtcdElectionPrefix:  DefaultEtcdElectionPrefix,
+		PostgresElectionKey: DefaultPostgresElectionKey,
+		LeaderSessionTTL:    60,
+		ID:                  hostname,
 	}
 }
 
@@ -43,11 +50,26 @@ func DefaultCluster() *Cluster {
 func (c *Cluster) MakeLeaderElector(electionCtx context.Context, db *DB) (
 	cluster.LeaderElector, error) {
 
-	if c.LeaderElector == cluster.EtcdLeaderElector {
+	switch c.LeaderElector {
+	case cluster.EtcdLeaderElector:
 		return cluster.MakeLeaderElector(
 			electionCtx, c.LeaderElector, c.ID,
 			c.EtcdElectionPrefix, c.LeaderSessionTTL, db.Etcd,
 		)
+
+	// The postgres leader elector uses the database of the postgres
+	// backend, as its whole point is not having to run a separate
+	// election governor.
+	case cluster.PostgresLeaderElector:
+		if db.Backend != PostgresBackend {
+			return nil, fmt.Errorf("postgres leader elector "+
+				"requires the %v db backend", PostgresBackend)
+		}
+
+		return cluster.MakeLeaderElector(
+			electionCtx, c.LeaderElector, c.ID,
+			c.PostgresElectionKey, c.LeaderSessionTTL, db.Postgres,
+		)
 	}
 
 	return nil, fmt.Errorf("unsupported leader elector")
@@ -66,9 +88,24 @@ func (c *Cluster) Validate() error {
 		}
 		return nil
 
+	case cluster.PostgresLeaderElector:
+		if c.PostgresElectionKey == "" {
+			return fmt.Errorf("postgres-election-key must be set")
+		}
+
+		// The leader checks its lock every third of the TTL, so the
+		// TTL needs to be long enough to allow for a round trip.
+		if c.LeaderSessionTTL < 3 {
+			return fmt.Errorf("leader-session-ttl must be at " +
+				"least 3 seconds when using the postgres " +
+				"leader elector")
+		}
+		return nil
+
 	default:
 		return fmt.Errorf("unknown leader elector, valid values are: "+
-			"\"%v\"", cluster.EtcdLeaderElector)
+			"\"%v\", \"%v\"", cluster.EtcdLeaderElector,
+			cluster.PostgresLeaderElector)
 	}
 }
 
@@ -0,0 +1,31 @@
+package lncfg
+
+import (
+	"fmt"
+	"time"
+)
+
+// FeeAutopilot holds the configuration options for the fee autopilot, which
+// periodically adjusts the forwarding fees of our channels according to the
+// rules configured through the RPC interface.
+//
+// nolint:lll
+type FeeAutopilot struct {
+	Active   bool          `long:"active" description:"Periodically apply the fee autopilot rules to all channels. The rules can be configured and dry-run through the router RPC server regardless of this option."`
+	Interval time.Duration `long:"interval" description:"The interval at which the fee autopilot rules are applied. Valid time units are {s, m, h}."`
+	Lookback time.Duration `long:"lookback" description:"The time window of forwarding history that is taken into account when matching the fee autopilot rules. Valid time units are {s, m, h}."`
+}
+
+// Validate checks the values configured for the fee autopilot.
+func (f *FeeAutopilot) Validate() error {
+	if f.Interval < time.Minute {
+		return fmt.Errorf("feeautopilot: interval must be at least "+
+			"%v", time.Minute)
+	}
+
+	if f.Lookback <= 0 {
+		return fmt.Errorf("feeautopilot: lookback must be positive")
+	}
+
+	return nil
+}
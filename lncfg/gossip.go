@@ -16,6 +16,12 @@ type Gossip struct {
 	MaxChannelUpdateBurst int `long:"max-channel-update-burst" description:"The maximum number of updates for a specific channel and direction that lnd will accept over the channel update interval."`
 
 	ChannelUpdateInterval time.Duration `long:"channel-update-interval" description:"The interval used to determine how often lnd should allow a burst of new updates for a specific channel and direction."`
+
+	BanThreshold uint32 `long:"ban-threshold" description:"The ban score at which the gossip of a peer is banned. Announcements with invalid signatures or that are older than the ones we know add to the score of the peer that sent them, while the score decays over time. Banned peers are disconnected unless they have channels with us. Set to 0 to disable banning."`
+
+	BanDuration time.Duration `long:"ban-duration" description:"The duration for which the gossip of a peer is banned once its ban score reached the ban threshold."`
+
+	PeerBandwidth uint64 `long:"peer-bandwidth" description:"The number of bytes of gossip per second that lnd accepts from a single peer. Announcements that exceed it are dropped. Set to 0 to disable the limit."`
 }
 
 // Parse the pubkeys for the pinned syncers.
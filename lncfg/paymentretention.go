@@ -0,0 +1,38 @@
+package lncfg
+
+import (
+	"fmt"
+	"time"
+)
+
+// PaymentRetention holds the configuration options of the payment retention
+// policy, which periodically deletes old failed payments and failed htlc
+// attempts from the database.
+//
+// nolint:lll
+type PaymentRetention struct {
+	FailedPaymentsMaxAge time.Duration `long:"failed-payments-max-age" description:"Delete failed payments that were created longer ago than this. Zero keeps all failed payments. Valid time units are {s, m, h}."`
+	FailedHtlcsMaxAge    time.Duration `long:"failed-htlcs-max-age" description:"Delete the failed htlc attempts of succeeded payments that were created longer ago than this. Those attempts are only kept if keep-failed-payment-attempts is set. Zero keeps all failed htlc attempts. Valid time units are {s, m, h}."`
+	Interval             time.Duration `long:"interval" description:"The interval at which old payments are pruned. Valid time units are {s, m, h}."`
+	ArchiveFile          string        `long:"archive-file" description:"If set, pruned payments are appended to this file as JSON records, one per line, before they are deleted."`
+}
+
+// Active returns true if any part of the retention policy is enabled.
+func (p *PaymentRetention) Active() bool {
+	return p.FailedPaymentsMaxAge > 0 || p.FailedHtlcsMaxAge > 0
+}
+
+// Validate checks the values configured for the payment retention policy.
+func (p *PaymentRetention) Validate() error {
+	if p.FailedPaymentsMaxAge < 0 || p.FailedHtlcsMaxAge < 0 {
+		return fmt.Errorf("paymentretention: max ages must not be " +
+			"negative")
+	}
+
+	if p.Active() && p.Interval < time.Minute {
+		return fmt.Errorf("paymentretention: interval must be at "+
+			"least %v", time.Minute)
+	}
+
+	return nil
+}
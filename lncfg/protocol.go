@@ -44,6 +44,11 @@ type ProtocolOptions struct {
 	// NoOptionAnySegwit should be set to true if we don't want to use any
 	// Taproot (and beyond) addresses for co-op closing.
 	NoOptionAnySegwit bool `long:"no-any-segwit" description:"disallow using any segiwt witness version as a co-op close address"`
+
+	// GossipSetReconciliation should be set if we want to signal the
+	// experimental gossip-set-recon feature bit, and sync the channel
+	// graph using set reconciliation with peers that support it.
+	GossipSetReconciliation bool `long:"gossip-set-recon" description:"enable experimental channel graph syncs based on set reconciliation with peers that support it"`
 }
 
 // Wumbo returns true if lnd should permit the creation and acceptance of wumbo
@@ -79,3 +84,9 @@ func (l *ProtocolOptions) ZeroConf() bool {
 func (l *ProtocolOptions) NoAnySegwit() bool {
 	return l.NoOptionAnySegwit
 }
+
+// GossipSetRecon returns true if we have enabled the experimental
+// gossip-set-recon feature bit.
+func (l *ProtocolOptions) GossipSetRecon() bool {
+	return l.GossipSetReconciliation
+}
@@ -0,0 +1,102 @@
+package lncfg
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultRemoteBackupTimeout is the default timeout of a single upload
+	// of the channel backup to a remote backup sink.
+	DefaultRemoteBackupTimeout = 30 * time.Second
+
+	// DefaultRemoteBackupMinBackoff is the default time we wait before
+	// retrying a failed upload to a remote backup sink.
+	DefaultRemoteBackupMinBackoff = 5 * time.Second
+
+	// DefaultRemoteBackupMaxBackoff is the default maximum time we wait
+	// between two upload attempts to a remote backup sink.
+	DefaultRemoteBackupMaxBackoff = 10 * time.Minute
+)
+
+// RemoteBackup holds the configuration options for uploading the channel
+// backup to remote backup sinks.
+//
+// nolint:lll
+type RemoteBackup struct {
+	Versioning bool          `long:"versioning" description:"In addition to replacing the latest channel backup, upload each backup under a unique, timestamped name so older backups remain available."`
+	Timeout    time.Duration `long:"timeout" description:"The timeout of a single upload attempt. Valid time units are {s, m, h}."`
+	MinBackoff time.Duration `long:"minbackoff" description:"The time to wait before retrying a failed upload. The backoff doubles with each consecutive failure. Valid time units are {s, m, h}."`
+	MaxBackoff time.Duration `long:"maxbackoff" description:"The maximum time to wait between two upload attempts. Valid time units are {s, m, h}."`
+
+	HTTP *HTTPBackupSink `group:"http" namespace:"http"`
+	S3   *S3BackupSink   `group:"s3" namespace:"s3"`
+}
+
+// HTTPBackupSink holds the configuration options for uploading the channel
+// backup through HTTP PUT requests, for example to a WebDAV server.
+//
+// nolint:lll
+type HTTPBackupSink struct {
+	URL      string `long:"url" description:"The URL of the collection (directory) the channel backup should be uploaded to, for example https://dav.example.com/lnd/. The collection must already exist."`
+	User     string `long:"user" description:"The user name used for HTTP basic authentication."`
+	Password string `long:"password" description:"The password used for HTTP basic authentication."`
+}
+
+// S3BackupSink holds the configuration options for uploading the channel
+// backup to an S3 compatible object storage.
+//
+// nolint:lll
+type S3BackupSink struct {
+	Endpoint        string `long:"endpoint" description:"The URL of the S3 API endpoint, for example https://s3.us-east-1.amazonaws.com."`
+	Region          string `long:"region" description:"The region of the bucket."`
+	Bucket          string `long:"bucket" description:"The name of the bucket the channel backup should be uploaded to."`
+	Prefix          string `long:"prefix" description:"An optional key prefix the channel backup is stored under."`
+	AccessKeyID     string `long:"accesskeyid" description:"The ID of the access key used to authenticate with the S3 API."`
+	SecretAccessKey string `long:"secretaccesskey" description:"The secret of the access key used to authenticate with the S3 API."`
+	PathStyle       bool   `long:"pathstyle" description:"Address the bucket as part of the request path instead of as a subdomain of the endpoint. Required by most self-hosted S3 compatible storages."`
+}
+
+// HTTPEnabled returns true if uploads through HTTP PUT are configured.
+func (r *RemoteBackup) HTTPEnabled() bool {
+	return r.HTTP != nil && r.HTTP.URL != ""
+}
+
+// S3Enabled returns true if uploads to an S3 compatible storage are
+// configured.
+func (r *RemoteBackup) S3Enabled() bool {
+	return r.S3 != nil && r.S3.Endpoint != ""
+}
+
+// Validate checks the values configured for the remote backup sinks.
+func (r *RemoteBackup) Validate() error {
+	if r.Timeout <= 0 {
+		return fmt.Errorf("remotebackup: timeout must be positive")
+	}
+
+	if r.MinBackoff <= 0 {
+		return fmt.Errorf("remotebackup: minbackoff must be positive")
+	}
+
+	if r.MaxBackoff < r.MinBackoff {
+		return fmt.Errorf("remotebackup: maxbackoff of %v cannot be "+
+			"smaller than minbackoff of %v", r.MaxBackoff,
+			r.MinBackoff)
+	}
+
+	if r.S3Enabled() {
+		switch {
+		case r.S3.Bucket == "":
+			return fmt.Errorf("remotebackup: s3.bucket must be set")
+
+		case r.S3.Region == "":
+			return fmt.Errorf("remotebackup: s3.region must be set")
+
+		case r.S3.AccessKeyID == "" || r.S3.SecretAccessKey == "":
+			return fmt.Errorf("remotebackup: s3.accesskeyid and " +
+				"s3.secretaccesskey must be set")
+		}
+	}
+
+	return nil
+}
@@ -9,18 +9,79 @@ const (
 	// DefaultRemoteSignerRPCTimeout is the default timeout that is used
 	// when forwarding a request to the remote signer through RPC.
 	DefaultRemoteSignerRPCTimeout = 5 * time.Second
+
+	// DefaultRemoteSignerCheckInterval is the default interval at which
+	// the connections to all remote signers are checked.
+	DefaultRemoteSignerCheckInterval = 30 * time.Second
+
+	// DefaultWatchOnlyNodeRetryInterval is the default interval at which a
+	// remote signer retries to connect to its watch-only node.
+	DefaultWatchOnlyNodeRetryInterval = 5 * time.Second
 )
 
 // RemoteSigner holds the configuration options for a remote RPC signer.
 //
 // nolint:lll
 type RemoteSigner struct {
-	Enable           bool          `long:"enable" description:"Use a remote signer for signing any on-chain related transactions or messages. Only recommended if local wallet is initialized as watch-only. Remote signer must use the same seed/root key as the local watch-only wallet but must have private keys."`
-	RPCHost          string        `long:"rpchost" description:"The remote signer's RPC host:port"`
-	MacaroonPath     string        `long:"macaroonpath" description:"The macaroon to use for authenticating with the remote signer"`
-	TLSCertPath      string        `long:"tlscertpath" description:"The TLS certificate to use for establishing the remote signer's identity"`
-	Timeout          time.Duration `long:"timeout" description:"The timeout for connecting to and signing requests with the remote signer. Valid time units are {s, m, h}."`
-	MigrateWatchOnly bool          `long:"migrate-wallet-to-watch-only" description:"If a wallet with private key material already exists, migrate it into a watch-only wallet on first startup. WARNING: This cannot be undone! Make sure you have backed up your seed before you use this flag! All private keys will be purged from the wallet after first unlock with this flag!"`
+	Enable                bool          `long:"enable" description:"Use a remote signer for signing any on-chain related transactions or messages. Only recommended if local wallet is initialized as watch-only. Remote signer must use the same seed/root key as the local watch-only wallet but must have private keys."`
+	RPCHost               string        `long:"rpchost" description:"The remote signer's RPC host:port"`
+	MacaroonPath          string        `long:"macaroonpath" description:"The macaroon to use for authenticating with the remote signer"`
+	TLSCertPath           string        `long:"tlscertpath" description:"The TLS certificate to use for establishing the remote signer's identity"`
+	Timeout               time.Duration `long:"timeout" description:"The timeout for connecting to and signing requests with the remote signer. Valid time units are {s, m, h}."`
+	MigrateWatchOnly      bool          `long:"migrate-wallet-to-watch-only" description:"If a wallet with private key material already exists, migrate it into a watch-only wallet on first startup. WARNING: This cannot be undone! Make sure you have backed up your seed before you use this flag! All private keys will be purged from the wallet after first unlock with this flag!"`
+	FallbackRPCHosts      []string      `long:"fallbackrpchost" description:"The RPC host:port of a fallback remote signer that is used if all signers before it are unreachable. Can be specified multiple times, the signers are used in the given order. Each fallback signer needs a fallbackmacaroonpath and a fallbacktlscertpath in the same position."`
+	FallbackMacaroonPaths []string      `long:"fallbackmacaroonpath" description:"The macaroon to use for authenticating with the fallback remote signer in the same position. Can be specified multiple times."`
+	FallbackTLSCertPaths  []string      `long:"fallbacktlscertpath" description:"The TLS certificate of the fallback remote signer in the same position. Can be specified multiple times."`
+	CheckInterval         time.Duration `long:"checkinterval" description:"The interval at which the connections to all remote signers are checked. Signing requests go to the first reachable signer, so a preferred signer is used again once it becomes reachable. Valid time units are {s, m, h}."`
+	InboundListen         string        `long:"inboundlisten" description:"If set, the primary remote signer is not dialed. Instead it connects to this host:port itself, so that the signer machine doesn't need any open ports. The signer must be configured with watchonlynode.enable. Its TLS certificate must be valid for localhost. Cannot be used together with rpchost."`
+}
+
+// RemoteSignerEndpoint describes how a single remote signer is reached and
+// authenticated.
+type RemoteSignerEndpoint struct {
+	// RPCHost is the host:port of the signer. It is empty for an inbound
+	// signer.
+	RPCHost string
+
+	// MacaroonPath is the path of the macaroon to authenticate with.
+	MacaroonPath string
+
+	// TLSCertPath is the path of the TLS certificate of the signer.
+	TLSCertPath string
+
+	// Inbound is true if the signer connects to us instead of being
+	// dialed.
+	Inbound bool
+}
+
+// String returns a human readable description of the endpoint.
+func (e RemoteSignerEndpoint) String() string {
+	if e.Inbound {
+		return "inbound signer"
+	}
+
+	return e.RPCHost
+}
+
+// Endpoints returns all configured remote signers in the order they should be
+// used in, starting with the primary one.
+func (r *RemoteSigner) Endpoints() []RemoteSignerEndpoint {
+	endpoints := []RemoteSignerEndpoint{{
+		RPCHost:      r.RPCHost,
+		MacaroonPath: r.MacaroonPath,
+		TLSCertPath:  r.TLSCertPath,
+		Inbound:      r.InboundListen != "",
+	}}
+
+	for idx, host := range r.FallbackRPCHosts {
+		endpoints = append(endpoints, RemoteSignerEndpoint{
+			RPCHost:      host,
+			MacaroonPath: r.FallbackMacaroonPaths[idx],
+			TLSCertPath:  r.FallbackTLSCertPaths[idx],
+		})
+	}
+
+	return endpoints
 }
 
 // Validate checks the values configured for our remote RPC signer.
@@ -41,5 +102,55 @@ func (r *RemoteSigner) Validate() error {
 			"enabled")
 	}
 
+	if r.InboundListen != "" && r.RPCHost != "" {
+		return fmt.Errorf("remote signer: rpchost and inboundlisten " +
+			"cannot be set at the same time")
+	}
+
+	numFallbacks := len(r.FallbackRPCHosts)
+	if len(r.FallbackMacaroonPaths) != numFallbacks ||
+		len(r.FallbackTLSCertPaths) != numFallbacks {
+
+		return fmt.Errorf("remote signer: each fallbackrpchost needs " +
+			"exactly one fallbackmacaroonpath and " +
+			"fallbacktlscertpath")
+	}
+
+	if r.CheckInterval < time.Second {
+		return fmt.Errorf("remote signer: check interval of %v is "+
+			"invalid, cannot be smaller than %v", r.CheckInterval,
+			time.Second)
+	}
+
+	return nil
+}
+
+// WatchOnlyNode holds the configuration options of a remote signer that
+// connects to its watch-only node, instead of being dialed by it.
+//
+// nolint:lll
+type WatchOnlyNode struct {
+	Enable        bool          `long:"enable" description:"Connect to the watch-only node that uses this node as its remote signer, and serve the RPC interface over that connection. The watch-only node must be configured with remotesigner.inboundlisten."`
+	RPCHost       string        `long:"rpchost" description:"The host:port the watch-only node listens on for its remote signer"`
+	RetryInterval time.Duration `long:"retryinterval" description:"The interval at which the connection to the watch-only node is retried if it can't be established or is lost. Valid time units are {s, m, h}."`
+}
+
+// Validate checks the values configured for the connection to the watch-only
+// node.
+func (w *WatchOnlyNode) Validate() error {
+	if !w.Enable {
+		return nil
+	}
+
+	if w.RPCHost == "" {
+		return fmt.Errorf("watch-only node: rpchost must be set")
+	}
+
+	if w.RetryInterval < time.Second {
+		return fmt.Errorf("watch-only node: retry interval of %v is "+
+			"invalid, cannot be smaller than %v", w.RetryInterval,
+			time.Second)
+	}
+
 	return nil
 }
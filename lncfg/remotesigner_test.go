@@ -0,0 +1,154 @@
+package lncfg_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lncfg"
+	"github.com/stretchr/testify/require"
+)
+
+// validRemoteSigner returns a remote signer config that passes validation.
+func validRemoteSigner() *lncfg.RemoteSigner {
+	return &lncfg.RemoteSigner{
+		Enable:        true,
+		RPCHost:       "signer:10009",
+		MacaroonPath:  "signer.macaroon",
+		TLSCertPath:   "signer.cert",
+		Timeout:       lncfg.DefaultRemoteSignerRPCTimeout,
+		CheckInterval: lncfg.DefaultRemoteSignerCheckInterval,
+	}
+}
+
+// TestValidateRemoteSigner asserts that invalid remote signer configurations
+// are rejected.
+func TestValidateRemoteSigner(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*lncfg.RemoteSigner)
+		valid  bool
+	}{
+		{
+			name:   "valid",
+			modify: func(*lncfg.RemoteSigner) {},
+			valid:  true,
+		},
+		{
+			name: "disabled",
+			modify: func(r *lncfg.RemoteSigner) {
+				r.Enable = false
+				r.Timeout = 0
+			},
+			valid: true,
+		},
+		{
+			name: "timeout too small",
+			modify: func(r *lncfg.RemoteSigner) {
+				r.Timeout = time.Microsecond
+			},
+		},
+		{
+			name: "fallbacks",
+			modify: func(r *lncfg.RemoteSigner) {
+				r.FallbackRPCHosts = []string{"a:1", "b:1"}
+				r.FallbackMacaroonPaths = []string{"a", "b"}
+				r.FallbackTLSCertPaths = []string{"a", "b"}
+			},
+			valid: true,
+		},
+		{
+			name: "fallback without macaroon",
+			modify: func(r *lncfg.RemoteSigner) {
+				r.FallbackRPCHosts = []string{"a:1", "b:1"}
+				r.FallbackMacaroonPaths = []string{"a"}
+				r.FallbackTLSCertPaths = []string{"a", "b"}
+			},
+		},
+		{
+			name: "fallback without tls cert",
+			modify: func(r *lncfg.RemoteSigner) {
+				r.FallbackRPCHosts = []string{"a:1"}
+				r.FallbackMacaroonPaths = []string{"a"}
+			},
+		},
+		{
+			name: "inbound",
+			modify: func(r *lncfg.RemoteSigner) {
+				r.RPCHost = ""
+				r.InboundListen = "0.0.0.0:10019"
+			},
+			valid: true,
+		},
+		{
+			name: "inbound and rpchost",
+			modify: func(r *lncfg.RemoteSigner) {
+				r.InboundListen = "0.0.0.0:10019"
+			},
+		},
+		{
+			name: "check interval too small",
+			modify: func(r *lncfg.RemoteSigner) {
+				r.CheckInterval = time.Millisecond
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			cfg := validRemoteSigner()
+			test.modify(cfg)
+
+			err := cfg.Validate()
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+// TestRemoteSignerEndpoints asserts that the primary signer is returned first,
+// followed by the fallback signers in their configured order.
+func TestRemoteSignerEndpoints(t *testing.T) {
+	cfg := validRemoteSigner()
+	cfg.RPCHost = ""
+	cfg.InboundListen = "0.0.0.0:10019"
+	cfg.FallbackRPCHosts = []string{"a:1", "b:1"}
+	cfg.FallbackMacaroonPaths = []string{"a.macaroon", "b.macaroon"}
+	cfg.FallbackTLSCertPaths = []string{"a.cert", "b.cert"}
+
+	require.Equal(t, []lncfg.RemoteSignerEndpoint{{
+		MacaroonPath: "signer.macaroon",
+		TLSCertPath:  "signer.cert",
+		Inbound:      true,
+	}, {
+		RPCHost:      "a:1",
+		MacaroonPath: "a.macaroon",
+		TLSCertPath:  "a.cert",
+	}, {
+		RPCHost:      "b:1",
+		MacaroonPath: "b.macaroon",
+		TLSCertPath:  "b.cert",
+	}}, cfg.Endpoints())
+}
+
+// TestValidateWatchOnlyNode asserts that a remote signer connecting to its
+// watch-only node needs the node's address and a sane retry interval.
+func TestValidateWatchOnlyNode(t *testing.T) {
+	cfg := &lncfg.WatchOnlyNode{
+		RetryInterval: lncfg.DefaultWatchOnlyNodeRetryInterval,
+	}
+	require.NoError(t, cfg.Validate())
+
+	cfg.Enable = true
+	require.Error(t, cfg.Validate())
+
+	cfg.RPCHost = "watchonly:10019"
+	require.NoError(t, cfg.Validate())
+
+	cfg.RetryInterval = time.Millisecond
+	require.Error(t, cfg.Validate())
+}
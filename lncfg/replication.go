@@ -0,0 +1,114 @@
+package lncfg
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+const (
+	// ReplicationRolePrimary is the role of a node that replicates its
+	// databases to a standby.
+	ReplicationRolePrimary = "primary"
+
+	// ReplicationRoleStandby is the role of a node that keeps a copy of
+	// the databases of a primary, ready to take over.
+	ReplicationRoleStandby = "standby"
+
+	// DefaultReplicationAckTimeout is the default time a synced standby
+	// has to acknowledge a transaction.
+	DefaultReplicationAckTimeout = 10 * time.Second
+
+	// minReplicationSecretLen is the minimum length of the shared secret
+	// of primary and standby in bytes.
+	minReplicationSecretLen = 16
+
+	// maxReplicationSecretLen is the maximum length of the shared secret
+	// of primary and standby in bytes.
+	maxReplicationSecretLen = 64
+)
+
+// Replication holds the configuration options of the hot standby replication
+// of the bolt databases.
+//
+// nolint:lll
+type Replication struct {
+	Role         string        `long:"role" choice:"primary" choice:"standby" description:"The role of this node in the replication. A primary replicates its databases to a standby synchronously, a standby keeps a copy of them and takes over once the primary is lost. Only supported with the bolt db backend. The wallet database isn't replicated, the wallet of the standby must be created from the same seed and with the same password."`
+	Listen       string        `long:"listen" description:"The address the primary accepts the standby on. The connection is encrypted with the TLS certificate of the RPC server."`
+	Primary      string        `long:"primary" description:"The address of the primary the standby connects to."`
+	TLSCertPath  string        `long:"tlscertpath" description:"The path to the TLS certificate of the primary, which the standby authenticates the primary with."`
+	Secret       string        `long:"secret" description:"The hex encoded shared secret the standby authenticates with at the primary, 16 to 64 bytes long."`
+	AckTimeout   time.Duration `long:"acktimeout" description:"The time a synced standby has to acknowledge a transaction. If the primary loses its synced standby, it shuts down instead of continuing without it."`
+	PromoteAfter time.Duration `long:"promoteafter" description:"The time after which a synced standby takes over once it lost its primary. Must exceed twice the ack timeout of the primary. 0 disables automatic promotion, in which case the standby is promoted by restarting it without a role."`
+	ForcePromote bool          `long:"forcepromote" description:"Start a node on the databases of a standby even if the standby wasn't in sync with its primary. The databases may lack channel updates then, which can lead to a loss of funds."`
+}
+
+// DefaultReplication returns the default replication configuration.
+func DefaultReplication() *Replication {
+	return &Replication{
+		AckTimeout: DefaultReplicationAckTimeout,
+	}
+}
+
+// Active returns true if the node takes part in the replication.
+func (r *Replication) Active() bool {
+	return r.Role != ""
+}
+
+// ParseSecret decodes the shared secret of primary and standby.
+func (r *Replication) ParseSecret() ([]byte, error) {
+	secret, err := hex.DecodeString(r.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("replication: invalid secret: %v", err)
+	}
+
+	if len(secret) < minReplicationSecretLen ||
+		len(secret) > maxReplicationSecretLen {
+
+		return nil, fmt.Errorf("replication: secret must be between "+
+			"%d and %d bytes long", minReplicationSecretLen,
+			maxReplicationSecretLen)
+	}
+
+	return secret, nil
+}
+
+// Validate checks the values configured for the replication.
+func (r *Replication) Validate() error {
+	switch r.Role {
+	case "":
+		return nil
+
+	case ReplicationRolePrimary:
+		if r.Listen == "" {
+			return fmt.Errorf("replication: listen must be set " +
+				"for the primary")
+		}
+
+		if r.AckTimeout <= 0 {
+			return fmt.Errorf("replication: acktimeout must be " +
+				"positive")
+		}
+
+	case ReplicationRoleStandby:
+		if r.Primary == "" || r.TLSCertPath == "" {
+			return fmt.Errorf("replication: primary and " +
+				"tlscertpath must be set for the standby")
+		}
+
+		if r.PromoteAfter < 0 {
+			return fmt.Errorf("replication: promoteafter cannot " +
+				"be negative")
+		}
+
+	default:
+		return fmt.Errorf("replication: invalid role %v", r.Role)
+	}
+
+	_, err := r.ParseSecret()
+	return err
+}
+
+// Compile-time constraint to ensure Replication implements the Validator
+// interface.
+var _ Validator = (*Replication)(nil)
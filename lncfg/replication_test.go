@@ -0,0 +1,101 @@
+package lncfg_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/lncfg"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateReplication asserts that invalid replication configurations are
+// rejected.
+func TestValidateReplication(t *testing.T) {
+	secret := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name  string
+		cfg   lncfg.Replication
+		valid bool
+	}{
+		{
+			name:  "disabled",
+			valid: true,
+		},
+		{
+			name: "primary",
+			cfg: lncfg.Replication{
+				Role:       lncfg.ReplicationRolePrimary,
+				Listen:     "0.0.0.0:10019",
+				Secret:     secret,
+				AckTimeout: lncfg.DefaultReplicationAckTimeout,
+			},
+			valid: true,
+		},
+		{
+			name: "primary without listen",
+			cfg: lncfg.Replication{
+				Role:       lncfg.ReplicationRolePrimary,
+				Secret:     secret,
+				AckTimeout: lncfg.DefaultReplicationAckTimeout,
+			},
+		},
+		{
+			name: "primary without ack timeout",
+			cfg: lncfg.Replication{
+				Role:   lncfg.ReplicationRolePrimary,
+				Listen: "0.0.0.0:10019",
+				Secret: secret,
+			},
+		},
+		{
+			name: "standby",
+			cfg: lncfg.Replication{
+				Role:        lncfg.ReplicationRoleStandby,
+				Primary:     "primary:10019",
+				TLSCertPath: "primary.cert",
+				Secret:      secret,
+			},
+			valid: true,
+		},
+		{
+			name: "standby without certificate",
+			cfg: lncfg.Replication{
+				Role:    lncfg.ReplicationRoleStandby,
+				Primary: "primary:10019",
+				Secret:  secret,
+			},
+		},
+		{
+			name: "secret too short",
+			cfg: lncfg.Replication{
+				Role:        lncfg.ReplicationRoleStandby,
+				Primary:     "primary:10019",
+				TLSCertPath: "primary.cert",
+				Secret:      "abcd",
+			},
+		},
+		{
+			name: "secret not hex",
+			cfg: lncfg.Replication{
+				Role:        lncfg.ReplicationRoleStandby,
+				Primary:     "primary:10019",
+				TLSCertPath: "primary.cert",
+				Secret:      strings.Repeat("x", 32),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := test.cfg.Validate()
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
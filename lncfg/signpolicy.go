@@ -0,0 +1,52 @@
+package lncfg
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// SignPolicy holds the configuration options of the signing policy that a
+// remote signer enforces for its watch-only node.
+//
+// nolint:lll
+type SignPolicy struct {
+	Enable           bool     `long:"enable" description:"Check all requests to sign transactions against the signing policy. Commitment transactions of revoked channel states are never signed, and wallet funds can only be sent to the configured addresses within the daily limit. Meant for remote signers. Channel states that were revoked before the policy was enabled can't be detected."`
+	AllowedAddresses []string `long:"allowedaddress" description:"An address wallet or channel funds may be sent to, apart from the wallet itself. Can be specified multiple times. If none is set, funds may be sent to any address. Channels can't be funded from the wallet and can only be closed cooperatively if the remote party's output pays to an allowed address while an allowlist is set."`
+	DailyLimit       int64    `long:"dailylimit" description:"The maximum amount in satoshis of wallet or channel funds that may be sent to other addresses than the wallet within 24 hours. The remote party's output of a cooperative close counts too. 0 means no limit."`
+	AllowMuSig2      bool     `long:"allowmusig2" description:"Allow MuSig2 partial signatures. Their message is opaque to the signer, so they can't be checked against the policy."`
+}
+
+// Validate checks the values configured for the signing policy.
+func (s *SignPolicy) Validate() error {
+	if s.DailyLimit < 0 {
+		return fmt.Errorf("signpolicy: daily limit cannot be negative")
+	}
+
+	return nil
+}
+
+// ParseAllowedAddresses decodes the allowed addresses for the given network.
+func (s *SignPolicy) ParseAllowedAddresses(
+	params *chaincfg.Params) ([]btcutil.Address, error) {
+
+	addrs := make([]btcutil.Address, 0, len(s.AllowedAddresses))
+	for _, addrStr := range s.AllowedAddresses {
+		addr, err := btcutil.DecodeAddress(addrStr, params)
+		if err != nil {
+			return nil, fmt.Errorf("signpolicy: invalid allowed "+
+				"address %v: %v", addrStr, err)
+		}
+
+		if !addr.IsForNet(params) {
+			return nil, fmt.Errorf("signpolicy: allowed address "+
+				"%v is not for network %v", addrStr,
+				params.Name)
+		}
+
+		addrs = append(addrs, addr)
+	}
+
+	return addrs, nil
+}
@@ -19,6 +19,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcutil"
 	proxy "github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/lightningnetwork/lnd/autopilot"
@@ -30,8 +31,10 @@ import (
 	"github.com/lightningnetwork/lnd/lncfg"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/rpcwallet"
 	"github.com/lightningnetwork/lnd/macaroons"
 	"github.com/lightningnetwork/lnd/monitoring"
+	"github.com/lightningnetwork/lnd/routing/route"
 	"github.com/lightningnetwork/lnd/rpcperms"
 	"github.com/lightningnetwork/lnd/signal"
 	"github.com/lightningnetwork/lnd/tor"
@@ -216,6 +219,22 @@ func Main(cfg *Config, lisCfg ListenerCfg, implCfg *ImplementationCfg,
 		return mkErr("error initializing DBs: %v", err)
 	}
 
+	// A replication standby only keeps a copy of the databases of its
+	// primary until it takes over, in which case we start up on them.
+	if cfg.Replication.Role == lncfg.ReplicationRoleStandby {
+		promoted, err := runReplicationStandby(
+			ctx, cfg, interceptor.ShutdownChannel(),
+		)
+		if err != nil {
+			return mkErr("replication standby failed: %v", err)
+		}
+		if !promoted {
+			return nil
+		}
+
+		ltndLog.Infof("Promoted replication standby, starting up")
+	}
+
 	// Only process macaroons if --no-macaroons isn't set.
 	serverOpts, restDialOpts, restListen, cleanUp, err := getTLSConfig(cfg)
 	if err != nil {
@@ -249,6 +268,22 @@ func Main(cfg *Config, lisCfg ListenerCfg, implCfg *ImplementationCfg,
 		}
 	}
 
+	// If this node is the remote signer of a watch-only node that doesn't
+	// dial us, we connect to it ourselves and serve the RPC interface over
+	// that connection.
+	if cfg.WatchOnlyNode.Enable {
+		lis := rpcwallet.NewOutboundListener(
+			cfg.WatchOnlyNode.RPCHost,
+			cfg.WatchOnlyNode.RetryInterval,
+		)
+		defer lis.Close()
+
+		grpcListeners = append(grpcListeners, &ListenerWithSignal{
+			Listener: lis,
+			Ready:    make(chan struct{}),
+		})
+	}
+
 	// Create a new RPC interceptor that we'll add to the GRPC server. This
 	// will be used to log the API calls invoked on the GRPC server.
 	interceptorChain := rpcperms.NewInterceptorChain(
@@ -350,6 +385,19 @@ func Main(cfg *Config, lisCfg ListenerCfg, implCfg *ImplementationCfg,
 
 		elected = true
 		ltndLog.Infof("Elected as leader (%v)", cfg.Cluster.ID)
+
+		// Another member may take over once we lose the leadership, so
+		// we must shut down to not act on stale state.
+		go func() {
+			select {
+			case <-leaderElector.Done():
+				ltndLog.Errorf("Lost leadership (%v), "+
+					"shutting down", cfg.Cluster.ID)
+				interceptor.RequestShutdown()
+
+			case <-interceptor.ShutdownChannel():
+			}
+		}()
 	}
 
 	dbs, cleanUp, err := implCfg.DatabaseBuilder.BuildDatabase(ctx)
@@ -363,6 +411,36 @@ func Main(cfg *Config, lisCfg ListenerCfg, implCfg *ImplementationCfg,
 
 	defer cleanUp()
 
+	// A replication primary that lost its synced standby refuses to
+	// commit anything, so we have to shut down.
+	if dbs.Replication != nil {
+		go func() {
+			select {
+			case <-dbs.Replication.Fenced():
+				ltndLog.Errorf("Lost synced replication " +
+					"standby, shutting down")
+				interceptor.RequestShutdown()
+
+			case <-interceptor.ShutdownChannel():
+			}
+		}()
+
+		// If we lost the standby before, it may have taken over in the
+		// meantime. We only continue once it follows us again.
+		if dbs.Replication.NeedsStandby() {
+			ltndLog.Infof("Waiting for replication standby to be " +
+				"in sync")
+
+			err := dbs.Replication.WaitForStandby(
+				interceptor.ShutdownChannel(),
+			)
+			if err != nil {
+				return mkErr("unable to sync replication "+
+					"standby: %v", err)
+			}
+		}
+	}
+
 	partialChainControl, walletConfig, cleanUp, err := implCfg.BuildWalletConfig(
 		ctx, dbs, interceptorChain, grpcListeners,
 	)
@@ -508,6 +586,18 @@ func Main(cfg *Config, lisCfg ListenerCfg, implCfg *ImplementationCfg,
 		multiAcceptor = chanacceptor.NewChainedAcceptor()
 	}
 
+	// If channel accept rules are configured, they are applied to all
+	// inbound channels in addition to any RPC acceptors.
+	var chanRuleAcceptor *chanacceptor.RuleAcceptor
+	if cfg.ChanAcceptRules != "" {
+		chanRuleAcceptor, err = newChanRuleAcceptor(cfg, dbs)
+		if err != nil {
+			return mkErr("unable to load channel accept rules: %v",
+				err)
+		}
+		multiAcceptor.AddAcceptor(chanRuleAcceptor)
+	}
+
 	// Set up the core server which will listen for incoming peer
 	// connections.
 	server, err := newServer(
@@ -544,6 +634,7 @@ func Main(cfg *Config, lisCfg ListenerCfg, implCfg *ImplementationCfg,
 	err = rpcServer.addDeps(
 		server, interceptorChain.MacaroonService(), cfg.SubRPCServers,
 		atplManager, server.invoices, tower, multiAcceptor,
+		chanRuleAcceptor,
 	)
 	if err != nil {
 		return mkErr("unable to add deps to RPC server: %v", err)
@@ -801,6 +892,66 @@ func getTLSConfig(cfg *Config) ([]grpc.ServerOption, []grpc.DialOption,
 	return serverOpts, restDialOpts, restListen, cleanUp, nil
 }
 
+// newChanRuleAcceptor creates the channel acceptor that applies the channel
+// accept rules of the configured file, using the graph to look up the peers.
+func newChanRuleAcceptor(cfg *Config,
+	dbs *DatabaseInstances) (*chanacceptor.RuleAcceptor, error) {
+
+	graph := dbs.GraphDB.ChannelGraph()
+
+	fetchNodeInfo := func(node route.Vertex) (*chanacceptor.NodeInfo,
+		error) {
+
+		info := &chanacceptor.NodeInfo{}
+		err := graph.ForEachNodeChannel(nil, node,
+			func(channel *channeldb.DirectedChannel) error {
+				info.NumChannels++
+				info.Capacity += channel.Capacity
+
+				return nil
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		lightningNode, err := graph.FetchLightningNode(node)
+		switch {
+		case errors.Is(err, channeldb.ErrGraphNodeNotFound):
+			return info, nil
+
+		case err != nil:
+			return nil, err
+		}
+		info.Addresses = lightningNode.Addresses
+
+		return info, nil
+	}
+
+	numPendingChannels := func(peer *btcec.PublicKey) (int, error) {
+		pendingChannels, err := dbs.ChanStateDB.ChannelStateDB().
+			FetchPendingChannels()
+		if err != nil {
+			return 0, err
+		}
+
+		var numPending int
+		for _, channel := range pendingChannels {
+			if channel.IdentityPub.IsEqual(peer) {
+				numPending++
+			}
+		}
+
+		return numPending, nil
+	}
+
+	return chanacceptor.NewRuleAcceptor(&chanacceptor.RuleAcceptorConfig{
+		RulesFile:          cfg.ChanAcceptRules,
+		FetchNodeInfo:      fetchNodeInfo,
+		NumPendingChannels: numPendingChannels,
+	})
+}
+
 // fileExists reports whether the named file or directory exists.
 // This function is taken from https://github.com/btcsuite/btcd
 func fileExists(name string) bool {
@@ -49,6 +49,12 @@ type AddInvoiceConfig struct {
 	AddInvoice func(invoice *channeldb.Invoice, paymentHash lntypes.Hash) (
 		uint64, error)
 
+	// AddHoldInvoice is called to add a hold invoice with a policy to the
+	// registry. It is only required if hold policies are used.
+	AddHoldInvoice func(invoice *channeldb.Invoice,
+		paymentHash lntypes.Hash, policy *channeldb.HoldPolicy) (uint64,
+		error)
+
 	// IsChannelActive is used to generate valid hop hints.
 	IsChannelActive func(chanID lnwire.ChannelID) bool
 
@@ -127,6 +133,10 @@ type AddInvoiceData struct {
 	// immediately upon receiving the payment.
 	HodlInvoice bool
 
+	// HoldPolicy is an optional policy that settles or cancels a hold
+	// invoice automatically.
+	HoldPolicy *channeldb.HoldPolicy
+
 	// Amp signals whether or not to create an AMP invoice.
 	//
 	// NOTE: Preimage should always be set to nil when this value is true.
@@ -470,7 +480,13 @@ func AddInvoice(ctx context.Context, cfg *AddInvoiceConfig,
 	)
 
 	// With all sanity checks passed, write the invoice to the database.
-	_, err = cfg.AddInvoice(newInvoice, paymentHash)
+	if invoice.HoldPolicy != nil {
+		_, err = cfg.AddHoldInvoice(
+			newInvoice, paymentHash, invoice.HoldPolicy,
+		)
+	} else {
+		_, err = cfg.AddInvoice(newInvoice, paymentHash)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -36,6 +36,21 @@ type InvoicesClient interface {
 	// LookupInvoiceV2 attempts to look up at invoice. An invoice can be refrenced
 	// using either its payment hash, payment address, or set ID.
 	LookupInvoiceV2(ctx context.Context, in *LookupInvoiceMsg, opts ...grpc.CallOption) (*lnrpc.Invoice, error)
+	// AddAMPSubscription adds a subscription to an open AMP invoice, which
+	// expects an amount to be paid to the invoice every period. The payments are
+	// accounted to the period in which they are settled. Periods are recorded as
+	// paid once their amount was paid, and as missed if they end before. The
+	// subscribers of the invoice are notified about paid and missed periods.
+	AddAMPSubscription(ctx context.Context, in *AddAMPSubscriptionRequest, opts ...grpc.CallOption) (*lnrpc.AMPSubscription, error)
+	// LookupAMPSubscription returns the subscription of an AMP invoice, including
+	// the periods recorded so far.
+	LookupAMPSubscription(ctx context.Context, in *LookupAMPSubscriptionRequest, opts ...grpc.CallOption) (*lnrpc.AMPSubscription, error)
+	// ListAMPSubscriptions returns the subscriptions of all AMP invoices.
+	ListAMPSubscriptions(ctx context.Context, in *ListAMPSubscriptionsRequest, opts ...grpc.CallOption) (*ListAMPSubscriptionsResponse, error)
+	// CancelAMPSubscription cancels the subscription of an AMP invoice. The
+	// periods recorded so far are kept, but no further periods are recorded. The
+	// invoice itself remains open.
+	CancelAMPSubscription(ctx context.Context, in *CancelAMPSubscriptionRequest, opts ...grpc.CallOption) (*CancelAMPSubscriptionResponse, error)
 }
 
 type invoicesClient struct {
@@ -114,6 +129,42 @@ func (c *invoicesClient) LookupInvoiceV2(ctx context.Context, in *LookupInvoiceM
 	return out, nil
 }
 
+func (c *invoicesClient) AddAMPSubscription(ctx context.Context, in *AddAMPSubscriptionRequest, opts ...grpc.CallOption) (*lnrpc.AMPSubscription, error) {
+	out := new(lnrpc.AMPSubscription)
+	err := c.cc.Invoke(ctx, "/invoicesrpc.Invoices/AddAMPSubscription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *invoicesClient) LookupAMPSubscription(ctx context.Context, in *LookupAMPSubscriptionRequest, opts ...grpc.CallOption) (*lnrpc.AMPSubscription, error) {
+	out := new(lnrpc.AMPSubscription)
+	err := c.cc.Invoke(ctx, "/invoicesrpc.Invoices/LookupAMPSubscription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *invoicesClient) ListAMPSubscriptions(ctx context.Context, in *ListAMPSubscriptionsRequest, opts ...grpc.CallOption) (*ListAMPSubscriptionsResponse, error) {
+	out := new(ListAMPSubscriptionsResponse)
+	err := c.cc.Invoke(ctx, "/invoicesrpc.Invoices/ListAMPSubscriptions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *invoicesClient) CancelAMPSubscription(ctx context.Context, in *CancelAMPSubscriptionRequest, opts ...grpc.CallOption) (*CancelAMPSubscriptionResponse, error) {
+	out := new(CancelAMPSubscriptionResponse)
+	err := c.cc.Invoke(ctx, "/invoicesrpc.Invoices/CancelAMPSubscription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // InvoicesServer is the server API for Invoices service.
 // All implementations must embed UnimplementedInvoicesServer
 // for forward compatibility
@@ -135,6 +186,21 @@ type InvoicesServer interface {
 	// LookupInvoiceV2 attempts to look up at invoice. An invoice can be refrenced
 	// using either its payment hash, payment address, or set ID.
 	LookupInvoiceV2(context.Context, *LookupInvoiceMsg) (*lnrpc.Invoice, error)
+	// AddAMPSubscription adds a subscription to an open AMP invoice, which
+	// expects an amount to be paid to the invoice every period. The payments are
+	// accounted to the period in which they are settled. Periods are recorded as
+	// paid once their amount was paid, and as missed if they end before. The
+	// subscribers of the invoice are notified about paid and missed periods.
+	AddAMPSubscription(context.Context, *AddAMPSubscriptionRequest) (*lnrpc.AMPSubscription, error)
+	// LookupAMPSubscription returns the subscription of an AMP invoice, including
+	// the periods recorded so far.
+	LookupAMPSubscription(context.Context, *LookupAMPSubscriptionRequest) (*lnrpc.AMPSubscription, error)
+	// ListAMPSubscriptions returns the subscriptions of all AMP invoices.
+	ListAMPSubscriptions(context.Context, *ListAMPSubscriptionsRequest) (*ListAMPSubscriptionsResponse, error)
+	// CancelAMPSubscription cancels the subscription of an AMP invoice. The
+	// periods recorded so far are kept, but no further periods are recorded. The
+	// invoice itself remains open.
+	CancelAMPSubscription(context.Context, *CancelAMPSubscriptionRequest) (*CancelAMPSubscriptionResponse, error)
 	mustEmbedUnimplementedInvoicesServer()
 }
 
@@ -157,6 +223,18 @@ func (UnimplementedInvoicesServer) SettleInvoice(context.Context, *SettleInvoice
 func (UnimplementedInvoicesServer) LookupInvoiceV2(context.Context, *LookupInvoiceMsg) (*lnrpc.Invoice, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LookupInvoiceV2 not implemented")
 }
+func (UnimplementedInvoicesServer) AddAMPSubscription(context.Context, *AddAMPSubscriptionRequest) (*lnrpc.AMPSubscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddAMPSubscription not implemented")
+}
+func (UnimplementedInvoicesServer) LookupAMPSubscription(context.Context, *LookupAMPSubscriptionRequest) (*lnrpc.AMPSubscription, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupAMPSubscription not implemented")
+}
+func (UnimplementedInvoicesServer) ListAMPSubscriptions(context.Context, *ListAMPSubscriptionsRequest) (*ListAMPSubscriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAMPSubscriptions not implemented")
+}
+func (UnimplementedInvoicesServer) CancelAMPSubscription(context.Context, *CancelAMPSubscriptionRequest) (*CancelAMPSubscriptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelAMPSubscription not implemented")
+}
 func (UnimplementedInvoicesServer) mustEmbedUnimplementedInvoicesServer() {}
 
 // UnsafeInvoicesServer may be embedded to opt out of forward compatibility for this service.
@@ -263,6 +341,78 @@ func _Invoices_LookupInvoiceV2_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Invoices_AddAMPSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddAMPSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoicesServer).AddAMPSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/invoicesrpc.Invoices/AddAMPSubscription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoicesServer).AddAMPSubscription(ctx, req.(*AddAMPSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Invoices_LookupAMPSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupAMPSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoicesServer).LookupAMPSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/invoicesrpc.Invoices/LookupAMPSubscription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoicesServer).LookupAMPSubscription(ctx, req.(*LookupAMPSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Invoices_ListAMPSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAMPSubscriptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoicesServer).ListAMPSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/invoicesrpc.Invoices/ListAMPSubscriptions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoicesServer).ListAMPSubscriptions(ctx, req.(*ListAMPSubscriptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Invoices_CancelAMPSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelAMPSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoicesServer).CancelAMPSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/invoicesrpc.Invoices/CancelAMPSubscription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoicesServer).CancelAMPSubscription(ctx, req.(*CancelAMPSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Invoices_ServiceDesc is the grpc.ServiceDesc for Invoices service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -286,6 +436,22 @@ var Invoices_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "LookupInvoiceV2",
 			Handler:    _Invoices_LookupInvoiceV2_Handler,
 		},
+		{
+			MethodName: "AddAMPSubscription",
+			Handler:    _Invoices_AddAMPSubscription_Handler,
+		},
+		{
+			MethodName: "LookupAMPSubscription",
+			Handler:    _Invoices_LookupAMPSubscription_Handler,
+		},
+		{
+			MethodName: "ListAMPSubscriptions",
+			Handler:    _Invoices_ListAMPSubscriptions_Handler,
+		},
+		{
+			MethodName: "CancelAMPSubscription",
+			Handler:    _Invoices_CancelAMPSubscription_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
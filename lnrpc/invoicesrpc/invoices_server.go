@@ -4,16 +4,23 @@
 package invoicesrpc
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/invoices"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/macaroons"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -65,6 +72,22 @@ var (
 			Entity: "invoices",
 			Action: "write",
 		}},
+		"/invoicesrpc.Invoices/AddAMPSubscription": {{
+			Entity: "invoices",
+			Action: "write",
+		}},
+		"/invoicesrpc.Invoices/LookupAMPSubscription": {{
+			Entity: "invoices",
+			Action: "read",
+		}},
+		"/invoicesrpc.Invoices/ListAMPSubscriptions": {{
+			Entity: "invoices",
+			Action: "read",
+		}},
+		"/invoicesrpc.Invoices/CancelAMPSubscription": {{
+			Entity: "invoices",
+			Action: "write",
+		}},
 	}
 
 	// DefaultInvoicesMacFilename is the default name of the invoices
@@ -254,6 +277,7 @@ func (s *Server) SubscribeSingleInvoice(req *SubscribeSingleInvoiceRequest,
 			if err != nil {
 				return err
 			}
+			s.addAMPSubscription(hash, rpcInvoice)
 
 			if err := updateStream.Send(rpcInvoice); err != nil {
 				return err
@@ -323,6 +347,7 @@ func (s *Server) AddHoldInvoice(ctx context.Context,
 
 	addInvoiceCfg := &AddInvoiceConfig{
 		AddInvoice:            s.cfg.InvoiceRegistry.AddInvoice,
+		AddHoldInvoice:        s.cfg.InvoiceRegistry.AddHoldInvoice,
 		IsChannelActive:       s.cfg.IsChannelActive,
 		ChainParams:           s.cfg.ChainParams,
 		NodeSigner:            s.cfg.NodeSigner,
@@ -349,6 +374,12 @@ func (s *Server) AddHoldInvoice(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+
+	holdPolicy, err := unmarshallHoldPolicy(invoice)
+	if err != nil {
+		return nil, err
+	}
+
 	addInvoiceData := &AddInvoiceData{
 		Memo:            invoice.Memo,
 		Hash:            &hash,
@@ -359,6 +390,7 @@ func (s *Server) AddHoldInvoice(ctx context.Context,
 		CltvExpiry:      invoice.CltvExpiry,
 		Private:         invoice.Private,
 		HodlInvoice:     true,
+		HoldPolicy:      holdPolicy,
 		Preimage:        nil,
 		RouteHints:      routeHints,
 	}
@@ -375,6 +407,52 @@ func (s *Server) AddHoldInvoice(ctx context.Context,
 	}, nil
 }
 
+// unmarshallHoldPolicy returns the hold policy of the request or nil if it
+// doesn't specify one.
+func unmarshallHoldPolicy(
+	req *AddHoldInvoiceRequest) (*channeldb.HoldPolicy, error) {
+
+	if req.CancelExpiryDelta == 0 && len(req.SettlePreimage) == 0 &&
+		req.SettleOnConf == nil && !req.SettleOnPayment {
+
+		return nil, nil
+	}
+
+	policy := &channeldb.HoldPolicy{
+		CancelDelta:     req.CancelExpiryDelta,
+		SettleOnPayment: req.SettleOnPayment,
+	}
+
+	if len(req.SettlePreimage) > 0 {
+		preimage, err := lntypes.MakePreimage(req.SettlePreimage)
+		if err != nil {
+			return nil, err
+		}
+		policy.Preimage = &preimage
+	}
+
+	if req.SettleOnConf != nil {
+		txid, err := chainhash.NewHash(req.SettleOnConf.Txid)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(req.SettleOnConf.PkScript) == 0 {
+			return nil, fmt.Errorf("settle_on_conf requires a " +
+				"pk_script")
+		}
+
+		policy.SettleConf = &channeldb.HoldSettleConf{
+			Txid:       *txid,
+			PkScript:   req.SettleOnConf.PkScript,
+			NumConfs:   req.SettleOnConf.NumConfs,
+			HeightHint: req.SettleOnConf.HeightHint,
+		}
+	}
+
+	return policy, nil
+}
+
 // LookupInvoiceV2 attempts to look up at invoice. An invoice can be referenced
 // using either its payment hash, payment address, or set ID.
 func (s *Server) LookupInvoiceV2(ctx context.Context,
@@ -440,5 +518,159 @@ func (s *Server) LookupInvoiceV2(ctx context.Context,
 		return nil, err
 	}
 
-	return CreateRPCInvoice(&invoice, s.cfg.ChainParams)
+	rpcInvoice, err := CreateRPCInvoice(&invoice, s.cfg.ChainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := lntypes.MakeHash(rpcInvoice.RHash)
+	if err == nil {
+		s.addAMPSubscription(hash, rpcInvoice)
+	}
+
+	return rpcInvoice, nil
+}
+
+// addAMPSubscription adds the subscription of the AMP invoice with the given
+// payment hash to its rpc representation, if it has one.
+func (s *Server) addAMPSubscription(hash lntypes.Hash,
+	rpcInvoice *lnrpc.Invoice) {
+
+	if !rpcInvoice.IsAmp {
+		return
+	}
+
+	sub, err := s.cfg.InvoiceRegistry.LookupAMPSubscription(hash)
+	if err != nil {
+		return
+	}
+
+	rpcInvoice.AmpSubscription = CreateRPCAMPSubscription(hash, sub)
+}
+
+// ampSubscriptionError converts errors of the subscription calls of the
+// invoice registry to rpc errors.
+func ampSubscriptionError(err error) error {
+	switch {
+	case errors.Is(err, invoices.ErrAMPSubscriptionNotFound),
+		errors.Is(err, channeldb.ErrInvoiceNotFound),
+		errors.Is(err, channeldb.ErrNoInvoicesCreated):
+
+		return status.Error(codes.NotFound, err.Error())
+
+	case errors.Is(err, invoices.ErrAMPSubscriptionExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+
+	case errors.Is(err, invoices.ErrAMPSubscriptionNotAMP):
+		return status.Error(codes.FailedPrecondition, err.Error())
+
+	default:
+		return err
+	}
+}
+
+// AddAMPSubscription adds a subscription to an open AMP invoice, which
+// expects an amount to be paid to the invoice every period.
+func (s *Server) AddAMPSubscription(ctx context.Context,
+	req *AddAMPSubscriptionRequest) (*lnrpc.AMPSubscription, error) {
+
+	hash, err := lntypes.MakeHash(req.PaymentHash)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if req.PeriodAmtMsat == 0 || req.PeriodSeconds == 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount and "+
+			"length of a period must be positive")
+	}
+
+	sub := &channeldb.AMPSubscription{
+		PeriodAmt:    lnwire.MilliSatoshi(req.PeriodAmtMsat),
+		PeriodLength: time.Duration(req.PeriodSeconds) * time.Second,
+		NumPeriods:   req.NumPeriods,
+	}
+	if req.StartTime != 0 {
+		sub.StartTime = time.Unix(req.StartTime, 0)
+	}
+
+	err = s.cfg.InvoiceRegistry.AddAMPSubscription(hash, sub)
+	if err != nil {
+		return nil, ampSubscriptionError(err)
+	}
+
+	log.Infof("Added subscription to AMP invoice %v", hash)
+
+	sub, err = s.cfg.InvoiceRegistry.LookupAMPSubscription(hash)
+	if err != nil {
+		return nil, ampSubscriptionError(err)
+	}
+
+	return CreateRPCAMPSubscription(hash, sub), nil
+}
+
+// LookupAMPSubscription returns the subscription of an AMP invoice, including
+// the periods recorded so far.
+func (s *Server) LookupAMPSubscription(ctx context.Context,
+	req *LookupAMPSubscriptionRequest) (*lnrpc.AMPSubscription, error) {
+
+	hash, err := lntypes.MakeHash(req.PaymentHash)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	sub, err := s.cfg.InvoiceRegistry.LookupAMPSubscription(hash)
+	if err != nil {
+		return nil, ampSubscriptionError(err)
+	}
+
+	return CreateRPCAMPSubscription(hash, sub), nil
+}
+
+// ListAMPSubscriptions returns the subscriptions of all AMP invoices, ordered
+// by their start time.
+func (s *Server) ListAMPSubscriptions(ctx context.Context,
+	_ *ListAMPSubscriptionsRequest) (*ListAMPSubscriptionsResponse, error) {
+
+	subs := s.cfg.InvoiceRegistry.AMPSubscriptions()
+
+	resp := &ListAMPSubscriptionsResponse{
+		Subscriptions: make([]*lnrpc.AMPSubscription, 0, len(subs)),
+	}
+	for hash, sub := range subs {
+		resp.Subscriptions = append(
+			resp.Subscriptions, CreateRPCAMPSubscription(hash, sub),
+		)
+	}
+
+	sort.Slice(resp.Subscriptions, func(i, j int) bool {
+		a, b := resp.Subscriptions[i], resp.Subscriptions[j]
+		if a.StartTime != b.StartTime {
+			return a.StartTime < b.StartTime
+		}
+
+		return bytes.Compare(a.PaymentHash, b.PaymentHash) < 0
+	})
+
+	return resp, nil
+}
+
+// CancelAMPSubscription cancels the subscription of an AMP invoice. The
+// periods recorded so far are kept, but no further periods are recorded.
+func (s *Server) CancelAMPSubscription(ctx context.Context,
+	req *CancelAMPSubscriptionRequest) (*CancelAMPSubscriptionResponse,
+	error) {
+
+	hash, err := lntypes.MakeHash(req.PaymentHash)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = s.cfg.InvoiceRegistry.CancelAMPSubscription(hash)
+	if err != nil {
+		return nil, ampSubscriptionError(err)
+	}
+
+	log.Infof("Canceled subscription of AMP invoice %v", hash)
+
+	return &CancelAMPSubscriptionResponse{}, nil
 }
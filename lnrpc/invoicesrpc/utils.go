@@ -3,11 +3,13 @@ package invoicesrpc
 import (
 	"encoding/hex"
 	"fmt"
+	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/zpay32"
 )
@@ -215,6 +217,25 @@ func CreateRPCInvoice(invoice *channeldb.Invoice,
 	return rpcInvoice, nil
 }
 
+// UnmarshallInvoiceState converts an rpc invoice state to its database
+// representation.
+func UnmarshallInvoiceState(
+	state lnrpc.Invoice_InvoiceState) (channeldb.ContractState, error) {
+
+	switch state {
+	case lnrpc.Invoice_OPEN:
+		return channeldb.ContractOpen, nil
+	case lnrpc.Invoice_SETTLED:
+		return channeldb.ContractSettled, nil
+	case lnrpc.Invoice_CANCELED:
+		return channeldb.ContractCanceled, nil
+	case lnrpc.Invoice_ACCEPTED:
+		return channeldb.ContractAccepted, nil
+	default:
+		return 0, fmt.Errorf("unknown invoice state %v", state)
+	}
+}
+
 // CreateRPCFeatures maps a feature vector into a list of lnrpc.Features.
 func CreateRPCFeatures(fv *lnwire.FeatureVector) map[uint32]*lnrpc.Feature {
 	if fv == nil {
@@ -291,3 +312,58 @@ func CreateZpay32HopHints(routeHints []*lnrpc.RouteHint) ([][]zpay32.HopHint, er
 	}
 	return res, nil
 }
+
+// CreateRPCAMPSubscription converts the subscription of the AMP invoice with
+// the given payment hash to its rpc representation.
+func CreateRPCAMPSubscription(hash lntypes.Hash,
+	sub *channeldb.AMPSubscription) *lnrpc.AMPSubscription {
+
+	rpcSub := &lnrpc.AMPSubscription{
+		PaymentHash:   hash[:],
+		PeriodAmtMsat: uint64(sub.PeriodAmt),
+		PeriodSeconds: uint64(sub.PeriodLength / time.Second),
+		StartTime:     sub.StartTime.Unix(),
+		NumPeriods:    sub.NumPeriods,
+		Canceled:      sub.Canceled,
+		Periods: make(
+			[]*lnrpc.AMPSubscriptionPeriod, 0, len(sub.Periods),
+		),
+	}
+
+	for i, period := range sub.Periods {
+		var state lnrpc.AMPSubscriptionPeriod_PeriodState
+		switch period.State {
+		case channeldb.SubscriptionPeriodPaid:
+			state = lnrpc.AMPSubscriptionPeriod_PAID
+
+		case channeldb.SubscriptionPeriodMissed:
+			state = lnrpc.AMPSubscriptionPeriod_MISSED
+
+		default:
+			state = lnrpc.AMPSubscriptionPeriod_PENDING
+		}
+
+		setIDs := make([][]byte, 0, len(period.SetIDs))
+		for _, setID := range period.SetIDs {
+			setID := setID
+			setIDs = append(setIDs, setID[:])
+		}
+
+		var resolveTime int64
+		if !period.ResolveTime.IsZero() {
+			resolveTime = period.ResolveTime.Unix()
+		}
+
+		rpcSub.Periods = append(
+			rpcSub.Periods, &lnrpc.AMPSubscriptionPeriod{
+				Index:       uint32(i),
+				State:       state,
+				AmtPaidMsat: uint64(period.AmtPaid),
+				SetIds:      setIDs,
+				ResolveTime: resolveTime,
+			},
+		)
+	}
+
+	return rpcSub
+}
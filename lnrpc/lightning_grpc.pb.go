@@ -95,6 +95,34 @@ type LightningClient interface {
 	// the client in which any events relevant to the state of peers are sent
 	// over. Events include peers going online and offline.
 	SubscribePeerEvents(ctx context.Context, in *PeerEventSubscription, opts ...grpc.CallOption) (Lightning_SubscribePeerEventsClient, error)
+	// lncli: `listgossipscores`
+	// ListGossipScores returns the gossip accounting of our peers, sorted by their
+	// ban score in descending order. Announcements with invalid signatures or
+	// that are older than the ones we know add to the ban score of the peer that
+	// sent them. Once the score reaches the configured threshold, the gossip of
+	// the peer is banned and the peer is disconnected unless it has channels with
+	// us.
+	ListGossipScores(ctx context.Context, in *ListGossipScoresRequest, opts ...grpc.CallOption) (*ListGossipScoresResponse, error)
+	// lncli: `banpeer`
+	// BanPeer bans a node or an IP range from connecting to us, either for a
+	// limited duration or permanently. Banned nodes are disconnected, and we stop
+	// reconnecting to them even if we have channels with them. Unlike firewall
+	// rules, bans of nodes also apply to peers that connect through Tor. An
+	// existing ban of the same node or IP range is replaced.
+	BanPeer(ctx context.Context, in *BanPeerRequest, opts ...grpc.CallOption) (*BanPeerResponse, error)
+	// lncli: `unbanpeer`
+	// UnbanPeer lifts the ban of a node or an IP range.
+	UnbanPeer(ctx context.Context, in *UnbanPeerRequest, opts ...grpc.CallOption) (*UnbanPeerResponse, error)
+	// lncli: `listbans`
+	// ListBans returns all nodes and IP ranges that are currently banned from
+	// connecting to us.
+	ListBans(ctx context.Context, in *ListBansRequest, opts ...grpc.CallOption) (*ListBansResponse, error)
+	// lncli: `listpeerquality`
+	// ListPeerQuality returns the quality of our connections to our peers since
+	// lnd started, consisting of their ping latency, message throughput and the
+	// reasons of their disconnects. These metrics are used to back off faster
+	// when reconnecting to unreliable persistent peers.
+	ListPeerQuality(ctx context.Context, in *ListPeerQualityRequest, opts ...grpc.CallOption) (*ListPeerQualityResponse, error)
 	// lncli: `getinfo`
 	// GetInfo returns general information concerning the lightning node including
 	// it's identity pubkey, alias, the chains it is connected to, and information
@@ -161,6 +189,12 @@ type LightningClient interface {
 	// node operators to specify their own criteria for accepting inbound channels
 	// through a single persistent connection.
 	ChannelAcceptor(ctx context.Context, opts ...grpc.CallOption) (Lightning_ChannelAcceptorClient, error)
+	// lncli: `reloadchanacceptrules`
+	// ReloadChannelAcceptRules reloads the channel accept rules from the file
+	// configured with the chan-accept-rules option. If the file can't be read or
+	// contains invalid rules, an error is returned and the current rules stay in
+	// place.
+	ReloadChannelAcceptRules(ctx context.Context, in *ReloadChannelAcceptRulesRequest, opts ...grpc.CallOption) (*ReloadChannelAcceptRulesResponse, error)
 	// lncli: `closechannel`
 	// CloseChannel attempts to close an active channel identified by its channel
 	// outpoint (ChannelPoint). The actions of this method can additionally be
@@ -590,6 +624,51 @@ func (x *lightningSubscribePeerEventsClient) Recv() (*PeerEvent, error) {
 	return m, nil
 }
 
+func (c *lightningClient) ListGossipScores(ctx context.Context, in *ListGossipScoresRequest, opts ...grpc.CallOption) (*ListGossipScoresResponse, error) {
+	out := new(ListGossipScoresResponse)
+	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/ListGossipScores", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) BanPeer(ctx context.Context, in *BanPeerRequest, opts ...grpc.CallOption) (*BanPeerResponse, error) {
+	out := new(BanPeerResponse)
+	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/BanPeer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) UnbanPeer(ctx context.Context, in *UnbanPeerRequest, opts ...grpc.CallOption) (*UnbanPeerResponse, error) {
+	out := new(UnbanPeerResponse)
+	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/UnbanPeer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) ListBans(ctx context.Context, in *ListBansRequest, opts ...grpc.CallOption) (*ListBansResponse, error) {
+	out := new(ListBansResponse)
+	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/ListBans", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lightningClient) ListPeerQuality(ctx context.Context, in *ListPeerQualityRequest, opts ...grpc.CallOption) (*ListPeerQualityResponse, error) {
+	out := new(ListPeerQualityResponse)
+	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/ListPeerQuality", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *lightningClient) GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*GetInfoResponse, error) {
 	out := new(GetInfoResponse)
 	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/GetInfo", in, out, opts...)
@@ -757,6 +836,15 @@ func (x *lightningChannelAcceptorClient) Recv() (*ChannelAcceptRequest, error) {
 	return m, nil
 }
 
+func (c *lightningClient) ReloadChannelAcceptRules(ctx context.Context, in *ReloadChannelAcceptRulesRequest, opts ...grpc.CallOption) (*ReloadChannelAcceptRulesResponse, error) {
+	out := new(ReloadChannelAcceptRulesResponse)
+	err := c.cc.Invoke(ctx, "/lnrpc.Lightning/ReloadChannelAcceptRules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *lightningClient) CloseChannel(ctx context.Context, in *CloseChannelRequest, opts ...grpc.CallOption) (Lightning_CloseChannelClient, error) {
 	stream, err := c.cc.NewStream(ctx, &Lightning_ServiceDesc.Streams[5], "/lnrpc.Lightning/CloseChannel", opts...)
 	if err != nil {
@@ -1390,6 +1478,34 @@ type LightningServer interface {
 	// the client in which any events relevant to the state of peers are sent
 	// over. Events include peers going online and offline.
 	SubscribePeerEvents(*PeerEventSubscription, Lightning_SubscribePeerEventsServer) error
+	// lncli: `listgossipscores`
+	// ListGossipScores returns the gossip accounting of our peers, sorted by their
+	// ban score in descending order. Announcements with invalid signatures or
+	// that are older than the ones we know add to the ban score of the peer that
+	// sent them. Once the score reaches the configured threshold, the gossip of
+	// the peer is banned and the peer is disconnected unless it has channels with
+	// us.
+	ListGossipScores(context.Context, *ListGossipScoresRequest) (*ListGossipScoresResponse, error)
+	// lncli: `banpeer`
+	// BanPeer bans a node or an IP range from connecting to us, either for a
+	// limited duration or permanently. Banned nodes are disconnected, and we stop
+	// reconnecting to them even if we have channels with them. Unlike firewall
+	// rules, bans of nodes also apply to peers that connect through Tor. An
+	// existing ban of the same node or IP range is replaced.
+	BanPeer(context.Context, *BanPeerRequest) (*BanPeerResponse, error)
+	// lncli: `unbanpeer`
+	// UnbanPeer lifts the ban of a node or an IP range.
+	UnbanPeer(context.Context, *UnbanPeerRequest) (*UnbanPeerResponse, error)
+	// lncli: `listbans`
+	// ListBans returns all nodes and IP ranges that are currently banned from
+	// connecting to us.
+	ListBans(context.Context, *ListBansRequest) (*ListBansResponse, error)
+	// lncli: `listpeerquality`
+	// ListPeerQuality returns the quality of our connections to our peers since
+	// lnd started, consisting of their ping latency, message throughput and the
+	// reasons of their disconnects. These metrics are used to back off faster
+	// when reconnecting to unreliable persistent peers.
+	ListPeerQuality(context.Context, *ListPeerQualityRequest) (*ListPeerQualityResponse, error)
 	// lncli: `getinfo`
 	// GetInfo returns general information concerning the lightning node including
 	// it's identity pubkey, alias, the chains it is connected to, and information
@@ -1456,6 +1572,12 @@ type LightningServer interface {
 	// node operators to specify their own criteria for accepting inbound channels
 	// through a single persistent connection.
 	ChannelAcceptor(Lightning_ChannelAcceptorServer) error
+	// lncli: `reloadchanacceptrules`
+	// ReloadChannelAcceptRules reloads the channel accept rules from the file
+	// configured with the chan-accept-rules option. If the file can't be read or
+	// contains invalid rules, an error is returned and the current rules stay in
+	// place.
+	ReloadChannelAcceptRules(context.Context, *ReloadChannelAcceptRulesRequest) (*ReloadChannelAcceptRulesResponse, error)
 	// lncli: `closechannel`
 	// CloseChannel attempts to close an active channel identified by its channel
 	// outpoint (ChannelPoint). The actions of this method can additionally be
@@ -1746,6 +1868,21 @@ func (UnimplementedLightningServer) ListPeers(context.Context, *ListPeersRequest
 func (UnimplementedLightningServer) SubscribePeerEvents(*PeerEventSubscription, Lightning_SubscribePeerEventsServer) error {
 	return status.Errorf(codes.Unimplemented, "method SubscribePeerEvents not implemented")
 }
+func (UnimplementedLightningServer) ListGossipScores(context.Context, *ListGossipScoresRequest) (*ListGossipScoresResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGossipScores not implemented")
+}
+func (UnimplementedLightningServer) BanPeer(context.Context, *BanPeerRequest) (*BanPeerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BanPeer not implemented")
+}
+func (UnimplementedLightningServer) UnbanPeer(context.Context, *UnbanPeerRequest) (*UnbanPeerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnbanPeer not implemented")
+}
+func (UnimplementedLightningServer) ListBans(context.Context, *ListBansRequest) (*ListBansResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBans not implemented")
+}
+func (UnimplementedLightningServer) ListPeerQuality(context.Context, *ListPeerQualityRequest) (*ListPeerQualityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPeerQuality not implemented")
+}
 func (UnimplementedLightningServer) GetInfo(context.Context, *GetInfoRequest) (*GetInfoResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
 }
@@ -1779,6 +1916,9 @@ func (UnimplementedLightningServer) FundingStateStep(context.Context, *FundingTr
 func (UnimplementedLightningServer) ChannelAcceptor(Lightning_ChannelAcceptorServer) error {
 	return status.Errorf(codes.Unimplemented, "method ChannelAcceptor not implemented")
 }
+func (UnimplementedLightningServer) ReloadChannelAcceptRules(context.Context, *ReloadChannelAcceptRulesRequest) (*ReloadChannelAcceptRulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadChannelAcceptRules not implemented")
+}
 func (UnimplementedLightningServer) CloseChannel(*CloseChannelRequest, Lightning_CloseChannelServer) error {
 	return status.Errorf(codes.Unimplemented, "method CloseChannel not implemented")
 }
@@ -2191,6 +2331,96 @@ func (x *lightningSubscribePeerEventsServer) Send(m *PeerEvent) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Lightning_ListGossipScores_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGossipScoresRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).ListGossipScores(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/ListGossipScores",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).ListGossipScores(ctx, req.(*ListGossipScoresRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_BanPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BanPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).BanPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/BanPeer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).BanPeer(ctx, req.(*BanPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_UnbanPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnbanPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).UnbanPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/UnbanPeer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).UnbanPeer(ctx, req.(*UnbanPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_ListBans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBansRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).ListBans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/ListBans",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).ListBans(ctx, req.(*ListBansRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lightning_ListPeerQuality_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPeerQualityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).ListPeerQuality(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/ListPeerQuality",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).ListPeerQuality(ctx, req.(*ListPeerQualityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Lightning_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetInfoRequest)
 	if err := dec(in); err != nil {
@@ -2403,6 +2633,24 @@ func (x *lightningChannelAcceptorServer) Recv() (*ChannelAcceptResponse, error)
 	return m, nil
 }
 
+func _Lightning_ReloadChannelAcceptRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadChannelAcceptRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LightningServer).ReloadChannelAcceptRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lnrpc.Lightning/ReloadChannelAcceptRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LightningServer).ReloadChannelAcceptRules(ctx, req.(*ReloadChannelAcceptRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Lightning_CloseChannel_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(CloseChannelRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -3239,6 +3487,26 @@ var Lightning_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListPeers",
 			Handler:    _Lightning_ListPeers_Handler,
 		},
+		{
+			MethodName: "ListGossipScores",
+			Handler:    _Lightning_ListGossipScores_Handler,
+		},
+		{
+			MethodName: "BanPeer",
+			Handler:    _Lightning_BanPeer_Handler,
+		},
+		{
+			MethodName: "UnbanPeer",
+			Handler:    _Lightning_UnbanPeer_Handler,
+		},
+		{
+			MethodName: "ListBans",
+			Handler:    _Lightning_ListBans_Handler,
+		},
+		{
+			MethodName: "ListPeerQuality",
+			Handler:    _Lightning_ListPeerQuality_Handler,
+		},
 		{
 			MethodName: "GetInfo",
 			Handler:    _Lightning_GetInfo_Handler,
@@ -3271,6 +3539,10 @@ var Lightning_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "FundingStateStep",
 			Handler:    _Lightning_FundingStateStep_Handler,
 		},
+		{
+			MethodName: "ReloadChannelAcceptRules",
+			Handler:    _Lightning_ReloadChannelAcceptRules_Handler,
+		},
 		{
 			MethodName: "AbandonChannel",
 			Handler:    _Lightning_AbandonChannel_Handler,
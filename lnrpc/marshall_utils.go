@@ -118,6 +118,8 @@ func MarshalUtxos(utxos []*lnwallet.Utxo, activeNetParams *chaincfg.Params) (
 			PkScript:      hex.EncodeToString(utxo.PkScript),
 			Outpoint:      outpoint,
 			Confirmations: utxo.Confirmations,
+			Label:         utxo.Label,
+			Frozen:        utxo.Frozen,
 		}
 
 		// Finally, we'll attempt to extract the raw address from the
@@ -0,0 +1,194 @@
+package routerrpc
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
+	"github.com/lightningnetwork/lnd/routing"
+)
+
+const (
+	// maxBatchRecipients is the maximum number of recipients of a single
+	// payment batch.
+	maxBatchRecipients = 1000
+
+	// defaultMaxConcurrentBatchPayments is the default number of payments
+	// of a batch that are in flight at the same time.
+	defaultMaxConcurrentBatchPayments = 10
+)
+
+// batchFeeLimits divides the fee limit across the amounts in proportion to
+// them. The fee limits are rounded down, so that their sum never exceeds the
+// fee limit.
+func batchFeeLimits(feeLimit lnwire.MilliSatoshi,
+	amts []lnwire.MilliSatoshi) []lnwire.MilliSatoshi {
+
+	total := new(big.Int)
+	for _, amt := range amts {
+		total.Add(total, new(big.Int).SetUint64(uint64(amt)))
+	}
+
+	feeLimits := make([]lnwire.MilliSatoshi, len(amts))
+	if total.Sign() == 0 {
+		return feeLimits
+	}
+
+	// The product of the fee limit and an amount may overflow 64 bits, so
+	// the division is done with big integers.
+	for i, amt := range amts {
+		share := new(big.Int).SetUint64(uint64(feeLimit))
+		share.Mul(share, new(big.Int).SetUint64(uint64(amt)))
+		share.Div(share, total)
+
+		feeLimits[i] = lnwire.MilliSatoshi(share.Uint64())
+	}
+
+	return feeLimits
+}
+
+// extractIntentsFromBatchRequest converts a batch payment request into a
+// payment intent for each of its recipients. Keysend payments get a random
+// preimage each. All recipients are validated before any intent is returned.
+func (r *RouterBackend) extractIntentsFromBatchRequest(
+	req *SendPaymentBatchRequest) ([]*routing.LightningPayment, error) {
+
+	switch {
+	case len(req.Recipients) == 0:
+		return nil, errors.New("no recipients specified")
+
+	case len(req.Recipients) > maxBatchRecipients:
+		return nil, fmt.Errorf("number of recipients %v exceeds "+
+			"maximum of %v", len(req.Recipients),
+			maxBatchRecipients)
+
+	case req.FeeLimitMsat < 0:
+		return nil, errors.New("fee limit must not be negative")
+	}
+
+	amts := make([]lnwire.MilliSatoshi, len(req.Recipients))
+	for i, recipient := range req.Recipients {
+		if recipient.AmtMsat <= 0 {
+			return nil, fmt.Errorf("recipient %v: amount must be "+
+				"positive", i)
+		}
+
+		amts[i] = lnwire.MilliSatoshi(recipient.AmtMsat)
+	}
+
+	feeLimits := batchFeeLimits(
+		lnwire.MilliSatoshi(req.FeeLimitMsat), amts,
+	)
+
+	payments := make([]*routing.LightningPayment, len(req.Recipients))
+	for i, recipient := range req.Recipients {
+		customRecords := make(
+			map[uint64][]byte, len(recipient.DestCustomRecords)+1,
+		)
+		for typ, value := range recipient.DestCustomRecords {
+			customRecords[typ] = value
+		}
+
+		sendReq := &SendPaymentRequest{
+			Dest:              recipient.Dest,
+			AmtMsat:           recipient.AmtMsat,
+			FeeLimitMsat:      int64(feeLimits[i]),
+			TimeoutSeconds:    req.TimeoutSeconds,
+			Amp:               req.Amp,
+			MaxParts:          req.MaxParts,
+			DestCustomRecords: customRecords,
+		}
+
+		// Keysend payments carry their preimage in a custom record.
+		if !req.Amp {
+			var preimage lntypes.Preimage
+			if _, err := rand.Read(preimage[:]); err != nil {
+				return nil, err
+			}
+			hash := preimage.Hash()
+
+			customRecords[record.KeySendType] = preimage[:]
+			sendReq.PaymentHash = hash[:]
+		}
+
+		payment, err := r.extractIntentFromSendRequest(sendReq)
+		if err != nil {
+			return nil, fmt.Errorf("recipient %v: %w", i, err)
+		}
+
+		payments[i] = payment
+	}
+
+	return payments, nil
+}
+
+// runConcurrent calls f for the indexes from zero to n-1, with at most limit
+// calls running at the same time. Once a call failed or the context is done,
+// no further calls are started. The first error is returned once all running
+// calls returned.
+func runConcurrent(ctx context.Context, n int, limit uint32,
+	quit <-chan struct{},
+	f func(ctx context.Context, idx int) error) error {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	slots := make(chan struct{}, limit)
+
+launch:
+	for i := 0; i < n; i++ {
+		select {
+		case slots <- struct{}{}:
+
+		case <-ctx.Done():
+			fail(ctx.Err())
+			break launch
+
+		case <-quit:
+			fail(errServerShuttingDown)
+			break launch
+		}
+
+		// A failing call releases its slot only after cancelling the
+		// context, so no call is started after it.
+		if ctx.Err() != nil {
+			<-slots
+			fail(ctx.Err())
+			break
+		}
+
+		wg.Add(1)
+		go func(idx int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			if err := f(ctx, idx); err != nil {
+				fail(err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
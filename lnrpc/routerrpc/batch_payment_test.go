@@ -0,0 +1,167 @@
+package routerrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBatchFeeLimits tests that the fee limit of a batch is divided in
+// proportion to the amounts, without exceeding it.
+func TestBatchFeeLimits(t *testing.T) {
+	t.Parallel()
+
+	feeLimits := batchFeeLimits(1000, []lnwire.MilliSatoshi{
+		100, 300, 600,
+	})
+	require.Equal(t, []lnwire.MilliSatoshi{100, 300, 600}, feeLimits)
+
+	// Fee limits are rounded down.
+	feeLimits = batchFeeLimits(100, []lnwire.MilliSatoshi{1, 1, 1})
+	require.Equal(t, []lnwire.MilliSatoshi{33, 33, 33}, feeLimits)
+
+	// Large amounts don't overflow.
+	feeLimits = batchFeeLimits(
+		lnwire.MaxMilliSatoshi/2, []lnwire.MilliSatoshi{
+			lnwire.MaxMilliSatoshi / 2, lnwire.MaxMilliSatoshi / 2,
+		},
+	)
+	require.Equal(t, []lnwire.MilliSatoshi{
+		lnwire.MaxMilliSatoshi / 4, lnwire.MaxMilliSatoshi / 4,
+	}, feeLimits)
+}
+
+// TestExtractIntentsFromBatchRequest tests that batch requests are converted
+// into a payment intent for each recipient.
+func TestExtractIntentsFromBatchRequest(t *testing.T) {
+	t.Parallel()
+
+	dest, err := hex.DecodeString(destKey)
+	require.NoError(t, err)
+
+	backend := &RouterBackend{
+		MaxTotalTimelock:      1000,
+		DefaultFinalCltvDelta: 40,
+	}
+
+	req := &SendPaymentBatchRequest{
+		Recipients: []*BatchRecipient{
+			{
+				Dest:    dest,
+				AmtMsat: 1000,
+			},
+			{
+				Dest:    dest,
+				AmtMsat: 3000,
+				DestCustomRecords: map[uint64][]byte{
+					65536: {1},
+				},
+			},
+		},
+		FeeLimitMsat:   100,
+		TimeoutSeconds: 60,
+		MaxParts:       4,
+	}
+
+	// Keysend payments each get their own preimage.
+	payments, err := backend.extractIntentsFromBatchRequest(req)
+	require.NoError(t, err)
+	require.Len(t, payments, 2)
+
+	require.EqualValues(t, 1000, payments[0].Amount)
+	require.EqualValues(t, 25, payments[0].FeeLimit)
+	require.EqualValues(t, 3000, payments[1].Amount)
+	require.EqualValues(t, 75, payments[1].FeeLimit)
+	require.EqualValues(t, 4, payments[1].MaxParts)
+	require.Equal(t, []byte{1}, payments[1].DestCustomRecords[65536])
+
+	for _, payment := range payments {
+		preimage, err := lntypes.MakePreimage(
+			payment.DestCustomRecords[record.KeySendType],
+		)
+		require.NoError(t, err)
+		require.Equal(
+			t, preimage.Hash(), lntypes.Hash(payment.Identifier()),
+		)
+	}
+	require.NotEqual(t, payments[0].Identifier(), payments[1].Identifier())
+
+	// The custom records of the request are left untouched.
+	require.Len(t, req.Recipients[1].DestCustomRecords, 1)
+
+	// AMP payments get a payment address, but don't carry a keysend
+	// record.
+	req.Amp = true
+	payments, err = backend.extractIntentsFromBatchRequest(req)
+	require.NoError(t, err)
+	for _, payment := range payments {
+		require.NotNil(t, payment.PaymentAddr)
+		require.NotContains(
+			t, payment.DestCustomRecords, record.KeySendType,
+		)
+	}
+
+	// Invalid recipients fail the whole batch.
+	req.Recipients[1].AmtMsat = 0
+	_, err = backend.extractIntentsFromBatchRequest(req)
+	require.ErrorContains(t, err, "recipient 1")
+
+	_, err = backend.extractIntentsFromBatchRequest(
+		&SendPaymentBatchRequest{},
+	)
+	require.Error(t, err)
+}
+
+// TestRunConcurrent tests that calls are limited to the given number of
+// concurrent calls and that no calls are started after one failed.
+func TestRunConcurrent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mtx            sync.Mutex
+		running, peak  int
+		calls          int
+		quit           = make(chan struct{})
+		errTest        = errors.New("test error")
+		failAt         = -1
+		trackedCallsFn = func(_ context.Context, idx int) error {
+			mtx.Lock()
+			calls++
+			running++
+			if running > peak {
+				peak = running
+			}
+			mtx.Unlock()
+
+			defer func() {
+				mtx.Lock()
+				running--
+				mtx.Unlock()
+			}()
+
+			if idx == failAt {
+				return errTest
+			}
+
+			return nil
+		}
+	)
+
+	err := runConcurrent(context.Background(), 50, 3, quit, trackedCallsFn)
+	require.NoError(t, err)
+	require.Equal(t, 50, calls)
+	require.LessOrEqual(t, peak, 3)
+
+	// With a single slot, no call is started after the failing one.
+	calls, failAt = 0, 5
+	err = runConcurrent(context.Background(), 50, 1, quit, trackedCallsFn)
+	require.ErrorIs(t, err, errTest)
+	require.Equal(t, 6, calls)
+}
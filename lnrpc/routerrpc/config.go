@@ -3,6 +3,7 @@ package routerrpc
 import (
 	"github.com/lightningnetwork/lnd/macaroons"
 	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/routing/localchans"
 )
 
 // Config is the main configuration file for the router RPC server. It contains
@@ -37,6 +38,10 @@ type Config struct {
 	// RouterBackend contains shared logic between this sub server and the
 	// main rpc server.
 	RouterBackend *RouterBackend
+
+	// FeeAutopilot is the fee autopilot whose rules are managed through
+	// this RPC server.
+	FeeAutopilot *localchans.FeeAutopilot
 }
 
 // DefaultConfig defines the config defaults.
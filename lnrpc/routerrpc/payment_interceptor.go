@@ -0,0 +1,215 @@
+package routerrpc
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrPaymentInterceptorClosed is returned for payments that are
+	// pending when the payment interceptor stream closes.
+	ErrPaymentInterceptorClosed = errors.New("payment interceptor closed")
+)
+
+// paymentInterceptorRegistry is the interface of the router that the payment
+// interceptor registers itself with.
+type paymentInterceptorRegistry interface {
+	// SetPaymentInterceptor sets the interceptor that decides about
+	// outgoing payments. A nil argument unregisters the current
+	// interceptor.
+	SetPaymentInterceptor(interceptor routing.PaymentInterceptor)
+}
+
+// paymentInterceptor is a helper struct that handles the lifecycle of an rpc
+// payment interceptor streaming session. It is created when the stream opens
+// and rejects all pending payments when the stream closes.
+type paymentInterceptor struct {
+	// stream is the bidirectional RPC stream.
+	stream Router_PaymentInterceptorServer
+
+	// marshallRoute converts the route of intercepted payments to its rpc
+	// representation.
+	marshallRoute func(*route.Route) (*lnrpc.Route, error)
+
+	// sendMtx serializes sends on the stream, as payments are intercepted
+	// concurrently.
+	sendMtx sync.Mutex
+
+	// pendingMtx guards nextID and pending.
+	pendingMtx sync.Mutex
+
+	// nextID is the id of the next intercepted payment.
+	nextID uint64
+
+	// pending holds the channels that deliver the decisions about the
+	// intercepted payments, keyed by their id.
+	pending map[uint64]chan *PaymentInterceptResponse
+
+	// quit is closed when the stream closes.
+	quit chan struct{}
+
+	// serverQuit is closed when the server shuts down.
+	serverQuit chan struct{}
+}
+
+// A compile time check to ensure that paymentInterceptor implements the
+// routing.PaymentInterceptor interface.
+var _ routing.PaymentInterceptor = (*paymentInterceptor)(nil)
+
+// newPaymentInterceptor creates a new paymentInterceptor.
+func newPaymentInterceptor(stream Router_PaymentInterceptorServer,
+	marshallRoute func(*route.Route) (*lnrpc.Route, error),
+	serverQuit chan struct{}) *paymentInterceptor {
+
+	return &paymentInterceptor{
+		stream:        stream,
+		marshallRoute: marshallRoute,
+		pending:       make(map[uint64]chan *PaymentInterceptResponse),
+		quit:          make(chan struct{}),
+		serverQuit:    serverQuit,
+	}
+}
+
+// run registers the interceptor with the router and delivers the decisions
+// received from the client to the intercepted payments, until the stream
+// closes. Payments that are pending at that point are rejected.
+func (p *paymentInterceptor) run(router paymentInterceptorRegistry) error {
+	router.SetPaymentInterceptor(p)
+	defer router.SetPaymentInterceptor(nil)
+	defer close(p.quit)
+
+	for {
+		resp, err := p.stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if err := p.resolveFromClient(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// resolveFromClient delivers a decision that arrived from the client to the
+// intercepted payment.
+func (p *paymentInterceptor) resolveFromClient(
+	resp *PaymentInterceptResponse) error {
+
+	log.Tracef("Resolving intercepted payment %v", resp)
+
+	switch resp.Action {
+	case PaymentInterceptAction_APPROVE, PaymentInterceptAction_REJECT:
+
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown action %v",
+			resp.Action)
+	}
+
+	if resp.FeeLimitMsat < 0 {
+		return status.Errorf(codes.InvalidArgument, "fee limit must "+
+			"not be negative")
+	}
+
+	p.pendingMtx.Lock()
+	decisionChan, ok := p.pending[resp.InterceptId]
+	delete(p.pending, resp.InterceptId)
+	p.pendingMtx.Unlock()
+
+	if !ok {
+		return status.Errorf(codes.NotFound, "intercepted payment %v "+
+			"not found", resp.InterceptId)
+	}
+
+	// The channel is buffered, so delivering the decision never blocks.
+	decisionChan <- resp
+
+	return nil
+}
+
+// InterceptPayment sends the payment to the client, and blocks until the
+// client decided about it or the stream closed.
+//
+// NOTE: Part of the routing.PaymentInterceptor interface.
+func (p *paymentInterceptor) InterceptPayment(
+	payment *routing.InterceptedPayment) (*routing.PaymentInterceptDecision,
+	error) {
+
+	req := &PaymentInterceptRequest{
+		PaymentHash:       payment.Identifier[:],
+		Dest:              payment.Target[:],
+		AmtMsat:           int64(payment.Amount),
+		FeeLimitMsat:      int64(payment.FeeLimit),
+		OutgoingChanIds:   payment.OutgoingChannelIDs,
+		PaymentRequest:    string(payment.PaymentRequest),
+		IsKeysend:         payment.Keysend(),
+		IsAmp:             payment.AMP,
+		DestCustomRecords: payment.DestCustomRecords,
+	}
+
+	if payment.Route != nil {
+		rpcRoute, err := p.marshallRoute(payment.Route)
+		if err != nil {
+			return nil, err
+		}
+		req.Route = rpcRoute
+	}
+
+	decisionChan := make(chan *PaymentInterceptResponse, 1)
+
+	p.pendingMtx.Lock()
+	req.InterceptId = p.nextID
+	p.nextID++
+	p.pending[req.InterceptId] = decisionChan
+	p.pendingMtx.Unlock()
+
+	defer func() {
+		p.pendingMtx.Lock()
+		delete(p.pending, req.InterceptId)
+		p.pendingMtx.Unlock()
+	}()
+
+	log.Tracef("Sending intercepted payment to client %v", req)
+
+	p.sendMtx.Lock()
+	err := p.stream.Send(req)
+	p.sendMtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *PaymentInterceptResponse
+	select {
+	case resp = <-decisionChan:
+
+	case <-p.quit:
+		return nil, ErrPaymentInterceptorClosed
+
+	case <-p.serverQuit:
+		return nil, errServerShuttingDown
+	}
+
+	if resp.Action == PaymentInterceptAction_REJECT {
+		return &routing.PaymentInterceptDecision{
+			Action: routing.PaymentInterceptReject,
+			Reason: resp.RejectReason,
+		}, nil
+	}
+
+	decision := &routing.PaymentInterceptDecision{
+		Action:             routing.PaymentInterceptApprove,
+		OutgoingChannelIDs: resp.OutgoingChanIds,
+	}
+	if resp.FeeLimitMsat != 0 {
+		feeLimit := lnwire.MilliSatoshi(resp.FeeLimitMsat)
+		decision.FeeLimit = &feeLimit
+	}
+
+	return decision, nil
+}
@@ -0,0 +1,183 @@
+package routerrpc
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type paymentInterceptorStreamMock struct {
+	grpc.ServerStream
+	requests  chan *PaymentInterceptRequest
+	responses chan *PaymentInterceptResponse
+}
+
+func (m *paymentInterceptorStreamMock) Send(
+	req *PaymentInterceptRequest) error {
+
+	m.requests <- req
+	return nil
+}
+
+func (m *paymentInterceptorStreamMock) Recv() (*PaymentInterceptResponse,
+	error) {
+
+	resp, ok := <-m.responses
+	if !ok {
+		return nil, io.EOF
+	}
+
+	return resp, nil
+}
+
+type paymentInterceptorRegistryMock struct {
+	sync.Mutex
+	interceptor routing.PaymentInterceptor
+}
+
+func (m *paymentInterceptorRegistryMock) SetPaymentInterceptor(
+	interceptor routing.PaymentInterceptor) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.interceptor = interceptor
+}
+
+func (m *paymentInterceptorRegistryMock) get() routing.PaymentInterceptor {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.interceptor
+}
+
+type interceptResult struct {
+	decision *routing.PaymentInterceptDecision
+	err      error
+}
+
+// TestPaymentInterceptor asserts that intercepted payments are sent to the
+// client, and that its decisions are delivered back to the payments.
+func TestPaymentInterceptor(t *testing.T) {
+	t.Parallel()
+
+	stream := &paymentInterceptorStreamMock{
+		requests:  make(chan *PaymentInterceptRequest),
+		responses: make(chan *PaymentInterceptResponse),
+	}
+	registry := &paymentInterceptorRegistryMock{}
+	marshallRoute := func(*route.Route) (*lnrpc.Route, error) {
+		return &lnrpc.Route{TotalFeesMsat: 7}, nil
+	}
+
+	interceptor := newPaymentInterceptor(
+		stream, marshallRoute, make(chan struct{}),
+	)
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- interceptor.run(registry)
+	}()
+
+	require.Eventually(t, func() bool {
+		return registry.get() != nil
+	}, time.Second, 10*time.Millisecond)
+
+	intercept := func(
+		payment *routing.InterceptedPayment) chan interceptResult {
+
+		results := make(chan interceptResult, 1)
+		go func() {
+			registered := registry.get()
+			decision, err := registered.InterceptPayment(payment)
+			results <- interceptResult{decision, err}
+		}()
+
+		return results
+	}
+
+	// An approval may replace the fee limit and outgoing channels.
+	results := intercept(&routing.InterceptedPayment{
+		Identifier: lntypes.Hash{1},
+		Target:     route.Vertex{2},
+		Amount:     1000,
+		FeeLimit:   10,
+		Route:      &route.Route{},
+	})
+
+	req := <-stream.requests
+	require.Equal(t, lntypes.Hash{1}, *(*lntypes.Hash)(req.PaymentHash))
+	require.Equal(t, route.Vertex{2}, *(*route.Vertex)(req.Dest))
+	require.EqualValues(t, 1000, req.AmtMsat)
+	require.EqualValues(t, 10, req.FeeLimitMsat)
+	require.EqualValues(t, 7, req.Route.TotalFeesMsat)
+
+	stream.responses <- &PaymentInterceptResponse{
+		InterceptId:     req.InterceptId,
+		Action:          PaymentInterceptAction_APPROVE,
+		FeeLimitMsat:    20,
+		OutgoingChanIds: []uint64{3},
+	}
+
+	result := <-results
+	require.NoError(t, result.err)
+	require.Equal(
+		t, routing.PaymentInterceptApprove, result.decision.Action,
+	)
+	require.Equal(t, lnwire.MilliSatoshi(20), *result.decision.FeeLimit)
+	require.Equal(t, []uint64{3}, result.decision.OutgoingChannelIDs)
+
+	// A rejection carries the reason.
+	results = intercept(&routing.InterceptedPayment{})
+	req = <-stream.requests
+	require.Nil(t, req.Route)
+
+	stream.responses <- &PaymentInterceptResponse{
+		InterceptId:  req.InterceptId,
+		Action:       PaymentInterceptAction_REJECT,
+		RejectReason: "denied",
+	}
+
+	result = <-results
+	require.NoError(t, result.err)
+	require.Equal(t, routing.PaymentInterceptReject, result.decision.Action)
+	require.Equal(t, "denied", result.decision.Reason)
+
+	// Payments that are pending when the stream closes are rejected, and
+	// the interceptor unregisters itself.
+	results = intercept(&routing.InterceptedPayment{})
+	<-stream.requests
+	close(stream.responses)
+
+	result = <-results
+	require.ErrorIs(t, result.err, ErrPaymentInterceptorClosed)
+	require.ErrorIs(t, <-runErr, io.EOF)
+	require.Nil(t, registry.get())
+}
+
+// TestPaymentInterceptorUnknownID asserts that a decision about an unknown
+// payment closes the stream.
+func TestPaymentInterceptorUnknownID(t *testing.T) {
+	t.Parallel()
+
+	interceptor := newPaymentInterceptor(nil, nil, make(chan struct{}))
+
+	err := interceptor.resolveFromClient(&PaymentInterceptResponse{
+		InterceptId: 5,
+	})
+	require.ErrorContains(t, err, "not found")
+
+	err = interceptor.resolveFromClient(&PaymentInterceptResponse{
+		FeeLimitMsat: -1,
+	})
+	require.ErrorContains(t, err, "negative")
+}
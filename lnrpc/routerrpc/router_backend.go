@@ -619,6 +619,20 @@ func (r *RouterBackend) extractIntentFromSendRequest(
 		payIntent.MaxShardAmt = &shardAmtMsat
 	}
 
+	payIntent.MaxShardsInFlight = rpcPayReq.MaxShardsInFlight
+
+	switch rpcPayReq.SplitStrategy {
+	case PaymentSplitStrategy_HALVING:
+		payIntent.SplitStrategy = routing.SplitHalving
+
+	case PaymentSplitStrategy_PROBABILITY:
+		payIntent.SplitStrategy = routing.SplitProbability
+
+	default:
+		return nil, fmt.Errorf("unknown split strategy: %v",
+			rpcPayReq.SplitStrategy)
+	}
+
 	// Take fee limit from request.
 	payIntent.FeeLimit, err = lnrpc.UnmarshallAmt(
 		rpcPayReq.FeeLimitSat, rpcPayReq.FeeLimitMsat,
@@ -1382,9 +1396,25 @@ func (r *RouterBackend) MarshallPayment(payment *channeldb.MPPayment) (
 		Htlcs:           htlcs,
 		PaymentIndex:    payment.SequenceNum,
 		FailureReason:   failureReason,
+		Metrics:         marshallPaymentMetrics(payment.Metrics),
 	}, nil
 }
 
+// marshallPaymentMetrics converts payment metrics to their rpc representation.
+func marshallPaymentMetrics(
+	metrics *channeldb.PaymentMetrics) *lnrpc.PaymentMetrics {
+
+	if metrics == nil {
+		return nil
+	}
+
+	return &lnrpc.PaymentMetrics{
+		NumAttempts:       metrics.NumAttempts,
+		PathfindingTimeNs: int64(metrics.PathfindingTime),
+		ResultWaitTimeNs:  int64(metrics.ResultWaitTime),
+	}
+}
+
 // convertPaymentStatus converts a channeldb.PaymentStatus to the type expected
 // by the RPC.
 func convertPaymentStatus(dbStatus channeldb.PaymentStatus) (
@@ -1408,6 +1438,26 @@ func convertPaymentStatus(dbStatus channeldb.PaymentStatus) (
 	}
 }
 
+// UnmarshallPaymentStatus converts an rpc payment status to its database
+// representation.
+func UnmarshallPaymentStatus(status lnrpc.Payment_PaymentStatus) (
+	channeldb.PaymentStatus, error) {
+
+	switch status {
+	case lnrpc.Payment_IN_FLIGHT:
+		return channeldb.StatusInFlight, nil
+
+	case lnrpc.Payment_SUCCEEDED:
+		return channeldb.StatusSucceeded, nil
+
+	case lnrpc.Payment_FAILED:
+		return channeldb.StatusFailed, nil
+
+	default:
+		return 0, fmt.Errorf("unknown payment status %v", status)
+	}
+}
+
 // marshallPaymentFailureReason marshalls the failure reason to the corresponding rpc
 // type.
 func marshallPaymentFailureReason(reason *channeldb.FailureReason) (
@@ -98,6 +98,42 @@ type RouterClient interface {
 	// channel to stay disabled until a subsequent manual request of either
 	// "enable" or "auto".
 	UpdateChanStatus(ctx context.Context, in *UpdateChanStatusRequest, opts ...grpc.CallOption) (*UpdateChanStatusResponse, error)
+	// GetFeeAutopilotRules returns the rules the fee autopilot currently uses to
+	// set the forwarding fees of our channels.
+	GetFeeAutopilotRules(ctx context.Context, in *GetFeeAutopilotRulesRequest, opts ...grpc.CallOption) (*GetFeeAutopilotRulesResponse, error)
+	// SetFeeAutopilotRules replaces the rules of the fee autopilot. An empty list
+	// of rules stops the fee autopilot from adjusting any channels.
+	SetFeeAutopilotRules(ctx context.Context, in *SetFeeAutopilotRulesRequest, opts ...grpc.CallOption) (*SetFeeAutopilotRulesResponse, error)
+	// DryRunFeeAutopilot returns the fee changes the fee autopilot would make
+	// to our channels if the given rules were applied now, without changing any
+	// channel policies.
+	DryRunFeeAutopilot(ctx context.Context, in *DryRunFeeAutopilotRequest, opts ...grpc.CallOption) (*DryRunFeeAutopilotResponse, error)
+	// ProbeRoute sends a probe along the given route. A probe is an HTLC with a
+	// random payment hash that the destination can't settle. Probes aren't
+	// stored as payments, but their outcome is fed into mission control.
+	ProbeRoute(ctx context.Context, in *ProbeRouteRequest, opts ...grpc.CallOption) (*ProbeResult, error)
+	// ProbeDestination probes routes to the destination until a probe reaches
+	// it, the destination fails a probe, no further route is found or the
+	// maximum number of probes is sent. As mission control learns from each
+	// failed probe, each probe takes a different route.
+	ProbeDestination(ctx context.Context, in *ProbeDestinationRequest, opts ...grpc.CallOption) (*ProbeDestinationResponse, error)
+	// ProbeLiquidity narrows down the liquidity of the last channel of a route
+	// by a binary search over the amount of probes sent along the route. It
+	// returns a bound on the amount the channel can forward in a single HTLC.
+	ProbeLiquidity(ctx context.Context, in *ProbeLiquidityRequest, opts ...grpc.CallOption) (*ProbeLiquidityResponse, error)
+	// SendPaymentBatch pays several destinations in one call, using keysend or
+	// AMP. The combined fee limit is divided across the recipients in proportion
+	// to their amounts. The call returns a stream of payment updates, each tagged
+	// with the index of the recipient it belongs to, and ends once all payments
+	// are resolved.
+	SendPaymentBatch(ctx context.Context, in *SendPaymentBatchRequest, opts ...grpc.CallOption) (Router_SendPaymentBatchClient, error)
+	// PaymentInterceptor dispatches a bi-directional streaming RPC in which
+	// outgoing payments are sent to the client before they are dispatched, and
+	// the client responds with a decision to approve, modify or reject each
+	// payment. Payments from SendPaymentV2, SendToRouteV2, keysend and any other
+	// client are intercepted. Only one interceptor can be registered at a time.
+	// Payments that are pending when the stream closes are rejected.
+	PaymentInterceptor(ctx context.Context, opts ...grpc.CallOption) (Router_PaymentInterceptorClient, error)
 }
 
 type routerClient struct {
@@ -433,6 +469,123 @@ func (c *routerClient) UpdateChanStatus(ctx context.Context, in *UpdateChanStatu
 	return out, nil
 }
 
+func (c *routerClient) GetFeeAutopilotRules(ctx context.Context, in *GetFeeAutopilotRulesRequest, opts ...grpc.CallOption) (*GetFeeAutopilotRulesResponse, error) {
+	out := new(GetFeeAutopilotRulesResponse)
+	err := c.cc.Invoke(ctx, "/routerrpc.Router/GetFeeAutopilotRules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) SetFeeAutopilotRules(ctx context.Context, in *SetFeeAutopilotRulesRequest, opts ...grpc.CallOption) (*SetFeeAutopilotRulesResponse, error) {
+	out := new(SetFeeAutopilotRulesResponse)
+	err := c.cc.Invoke(ctx, "/routerrpc.Router/SetFeeAutopilotRules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) DryRunFeeAutopilot(ctx context.Context, in *DryRunFeeAutopilotRequest, opts ...grpc.CallOption) (*DryRunFeeAutopilotResponse, error) {
+	out := new(DryRunFeeAutopilotResponse)
+	err := c.cc.Invoke(ctx, "/routerrpc.Router/DryRunFeeAutopilot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) ProbeRoute(ctx context.Context, in *ProbeRouteRequest, opts ...grpc.CallOption) (*ProbeResult, error) {
+	out := new(ProbeResult)
+	err := c.cc.Invoke(ctx, "/routerrpc.Router/ProbeRoute", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) ProbeDestination(ctx context.Context, in *ProbeDestinationRequest, opts ...grpc.CallOption) (*ProbeDestinationResponse, error) {
+	out := new(ProbeDestinationResponse)
+	err := c.cc.Invoke(ctx, "/routerrpc.Router/ProbeDestination", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) ProbeLiquidity(ctx context.Context, in *ProbeLiquidityRequest, opts ...grpc.CallOption) (*ProbeLiquidityResponse, error) {
+	out := new(ProbeLiquidityResponse)
+	err := c.cc.Invoke(ctx, "/routerrpc.Router/ProbeLiquidity", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *routerClient) SendPaymentBatch(ctx context.Context, in *SendPaymentBatchRequest, opts ...grpc.CallOption) (Router_SendPaymentBatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Router_ServiceDesc.Streams[7], "/routerrpc.Router/SendPaymentBatch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &routerSendPaymentBatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Router_SendPaymentBatchClient interface {
+	Recv() (*PaymentBatchUpdate, error)
+	grpc.ClientStream
+}
+
+type routerSendPaymentBatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *routerSendPaymentBatchClient) Recv() (*PaymentBatchUpdate, error) {
+	m := new(PaymentBatchUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *routerClient) PaymentInterceptor(ctx context.Context, opts ...grpc.CallOption) (Router_PaymentInterceptorClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Router_ServiceDesc.Streams[8], "/routerrpc.Router/PaymentInterceptor", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &routerPaymentInterceptorClient{stream}
+	return x, nil
+}
+
+type Router_PaymentInterceptorClient interface {
+	Send(*PaymentInterceptResponse) error
+	Recv() (*PaymentInterceptRequest, error)
+	grpc.ClientStream
+}
+
+type routerPaymentInterceptorClient struct {
+	grpc.ClientStream
+}
+
+func (x *routerPaymentInterceptorClient) Send(m *PaymentInterceptResponse) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *routerPaymentInterceptorClient) Recv() (*PaymentInterceptRequest, error) {
+	m := new(PaymentInterceptRequest)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // RouterServer is the server API for Router service.
 // All implementations must embed UnimplementedRouterServer
 // for forward compatibility
@@ -516,6 +669,42 @@ type RouterServer interface {
 	// channel to stay disabled until a subsequent manual request of either
 	// "enable" or "auto".
 	UpdateChanStatus(context.Context, *UpdateChanStatusRequest) (*UpdateChanStatusResponse, error)
+	// GetFeeAutopilotRules returns the rules the fee autopilot currently uses to
+	// set the forwarding fees of our channels.
+	GetFeeAutopilotRules(context.Context, *GetFeeAutopilotRulesRequest) (*GetFeeAutopilotRulesResponse, error)
+	// SetFeeAutopilotRules replaces the rules of the fee autopilot. An empty list
+	// of rules stops the fee autopilot from adjusting any channels.
+	SetFeeAutopilotRules(context.Context, *SetFeeAutopilotRulesRequest) (*SetFeeAutopilotRulesResponse, error)
+	// DryRunFeeAutopilot returns the fee changes the fee autopilot would make
+	// to our channels if the given rules were applied now, without changing any
+	// channel policies.
+	DryRunFeeAutopilot(context.Context, *DryRunFeeAutopilotRequest) (*DryRunFeeAutopilotResponse, error)
+	// ProbeRoute sends a probe along the given route. A probe is an HTLC with a
+	// random payment hash that the destination can't settle. Probes aren't
+	// stored as payments, but their outcome is fed into mission control.
+	ProbeRoute(context.Context, *ProbeRouteRequest) (*ProbeResult, error)
+	// ProbeDestination probes routes to the destination until a probe reaches
+	// it, the destination fails a probe, no further route is found or the
+	// maximum number of probes is sent. As mission control learns from each
+	// failed probe, each probe takes a different route.
+	ProbeDestination(context.Context, *ProbeDestinationRequest) (*ProbeDestinationResponse, error)
+	// ProbeLiquidity narrows down the liquidity of the last channel of a route
+	// by a binary search over the amount of probes sent along the route. It
+	// returns a bound on the amount the channel can forward in a single HTLC.
+	ProbeLiquidity(context.Context, *ProbeLiquidityRequest) (*ProbeLiquidityResponse, error)
+	// SendPaymentBatch pays several destinations in one call, using keysend or
+	// AMP. The combined fee limit is divided across the recipients in proportion
+	// to their amounts. The call returns a stream of payment updates, each tagged
+	// with the index of the recipient it belongs to, and ends once all payments
+	// are resolved.
+	SendPaymentBatch(*SendPaymentBatchRequest, Router_SendPaymentBatchServer) error
+	// PaymentInterceptor dispatches a bi-directional streaming RPC in which
+	// outgoing payments are sent to the client before they are dispatched, and
+	// the client responds with a decision to approve, modify or reject each
+	// payment. Payments from SendPaymentV2, SendToRouteV2, keysend and any other
+	// client are intercepted. Only one interceptor can be registered at a time.
+	// Payments that are pending when the stream closes are rejected.
+	PaymentInterceptor(Router_PaymentInterceptorServer) error
 	mustEmbedUnimplementedRouterServer()
 }
 
@@ -577,6 +766,30 @@ func (UnimplementedRouterServer) HtlcInterceptor(Router_HtlcInterceptorServer) e
 func (UnimplementedRouterServer) UpdateChanStatus(context.Context, *UpdateChanStatusRequest) (*UpdateChanStatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateChanStatus not implemented")
 }
+func (UnimplementedRouterServer) GetFeeAutopilotRules(context.Context, *GetFeeAutopilotRulesRequest) (*GetFeeAutopilotRulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFeeAutopilotRules not implemented")
+}
+func (UnimplementedRouterServer) SetFeeAutopilotRules(context.Context, *SetFeeAutopilotRulesRequest) (*SetFeeAutopilotRulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFeeAutopilotRules not implemented")
+}
+func (UnimplementedRouterServer) DryRunFeeAutopilot(context.Context, *DryRunFeeAutopilotRequest) (*DryRunFeeAutopilotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DryRunFeeAutopilot not implemented")
+}
+func (UnimplementedRouterServer) ProbeRoute(context.Context, *ProbeRouteRequest) (*ProbeResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProbeRoute not implemented")
+}
+func (UnimplementedRouterServer) ProbeDestination(context.Context, *ProbeDestinationRequest) (*ProbeDestinationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProbeDestination not implemented")
+}
+func (UnimplementedRouterServer) ProbeLiquidity(context.Context, *ProbeLiquidityRequest) (*ProbeLiquidityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProbeLiquidity not implemented")
+}
+func (UnimplementedRouterServer) SendPaymentBatch(*SendPaymentBatchRequest, Router_SendPaymentBatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method SendPaymentBatch not implemented")
+}
+func (UnimplementedRouterServer) PaymentInterceptor(Router_PaymentInterceptorServer) error {
+	return status.Errorf(codes.Unimplemented, "method PaymentInterceptor not implemented")
+}
 func (UnimplementedRouterServer) mustEmbedUnimplementedRouterServer() {}
 
 // UnsafeRouterServer may be embedded to opt out of forward compatibility for this service.
@@ -940,6 +1153,161 @@ func _Router_UpdateChanStatus_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Router_GetFeeAutopilotRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFeeAutopilotRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).GetFeeAutopilotRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/routerrpc.Router/GetFeeAutopilotRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).GetFeeAutopilotRules(ctx, req.(*GetFeeAutopilotRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_SetFeeAutopilotRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetFeeAutopilotRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).SetFeeAutopilotRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/routerrpc.Router/SetFeeAutopilotRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).SetFeeAutopilotRules(ctx, req.(*SetFeeAutopilotRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_DryRunFeeAutopilot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DryRunFeeAutopilotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).DryRunFeeAutopilot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/routerrpc.Router/DryRunFeeAutopilot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).DryRunFeeAutopilot(ctx, req.(*DryRunFeeAutopilotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_ProbeRoute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProbeRouteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).ProbeRoute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/routerrpc.Router/ProbeRoute",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).ProbeRoute(ctx, req.(*ProbeRouteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_ProbeDestination_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProbeDestinationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).ProbeDestination(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/routerrpc.Router/ProbeDestination",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).ProbeDestination(ctx, req.(*ProbeDestinationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_ProbeLiquidity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProbeLiquidityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouterServer).ProbeLiquidity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/routerrpc.Router/ProbeLiquidity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouterServer).ProbeLiquidity(ctx, req.(*ProbeLiquidityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Router_SendPaymentBatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SendPaymentBatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RouterServer).SendPaymentBatch(m, &routerSendPaymentBatchServer{stream})
+}
+
+type Router_SendPaymentBatchServer interface {
+	Send(*PaymentBatchUpdate) error
+	grpc.ServerStream
+}
+
+type routerSendPaymentBatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *routerSendPaymentBatchServer) Send(m *PaymentBatchUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Router_PaymentInterceptor_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RouterServer).PaymentInterceptor(&routerPaymentInterceptorServer{stream})
+}
+
+type Router_PaymentInterceptorServer interface {
+	Send(*PaymentInterceptRequest) error
+	Recv() (*PaymentInterceptResponse, error)
+	grpc.ServerStream
+}
+
+type routerPaymentInterceptorServer struct {
+	grpc.ServerStream
+}
+
+func (x *routerPaymentInterceptorServer) Send(m *PaymentInterceptRequest) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *routerPaymentInterceptorServer) Recv() (*PaymentInterceptResponse, error) {
+	m := new(PaymentInterceptResponse)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Router_ServiceDesc is the grpc.ServiceDesc for Router service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -991,6 +1359,30 @@ var Router_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateChanStatus",
 			Handler:    _Router_UpdateChanStatus_Handler,
 		},
+		{
+			MethodName: "GetFeeAutopilotRules",
+			Handler:    _Router_GetFeeAutopilotRules_Handler,
+		},
+		{
+			MethodName: "SetFeeAutopilotRules",
+			Handler:    _Router_SetFeeAutopilotRules_Handler,
+		},
+		{
+			MethodName: "DryRunFeeAutopilot",
+			Handler:    _Router_DryRunFeeAutopilot_Handler,
+		},
+		{
+			MethodName: "ProbeRoute",
+			Handler:    _Router_ProbeRoute_Handler,
+		},
+		{
+			MethodName: "ProbeDestination",
+			Handler:    _Router_ProbeDestination_Handler,
+		},
+		{
+			MethodName: "ProbeLiquidity",
+			Handler:    _Router_ProbeLiquidity_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1029,6 +1421,17 @@ var Router_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "SendPaymentBatch",
+			Handler:       _Router_SendPaymentBatch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "PaymentInterceptor",
+			Handler:       _Router_PaymentInterceptor_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "routerrpc/router.proto",
 }
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/macaroons"
 	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/routing/localchans"
 	"github.com/lightningnetwork/lnd/routing/route"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -128,6 +131,38 @@ var (
 			Entity: "offchain",
 			Action: "write",
 		}},
+		"/routerrpc.Router/GetFeeAutopilotRules": {{
+			Entity: "offchain",
+			Action: "read",
+		}},
+		"/routerrpc.Router/SetFeeAutopilotRules": {{
+			Entity: "offchain",
+			Action: "write",
+		}},
+		"/routerrpc.Router/DryRunFeeAutopilot": {{
+			Entity: "offchain",
+			Action: "read",
+		}},
+		"/routerrpc.Router/ProbeRoute": {{
+			Entity: "offchain",
+			Action: "write",
+		}},
+		"/routerrpc.Router/ProbeDestination": {{
+			Entity: "offchain",
+			Action: "write",
+		}},
+		"/routerrpc.Router/ProbeLiquidity": {{
+			Entity: "offchain",
+			Action: "write",
+		}},
+		"/routerrpc.Router/SendPaymentBatch": {{
+			Entity: "offchain",
+			Action: "write",
+		}},
+		"/routerrpc.Router/PaymentInterceptor": {{
+			Entity: "offchain",
+			Action: "write",
+		}},
 	}
 
 	// DefaultRouterMacFilename is the default name of the router macaroon
@@ -149,6 +184,7 @@ type Server struct {
 	started                  int32 // To be used atomically.
 	shutdown                 int32 // To be used atomically.
 	forwardInterceptorActive int32 // To be used atomically.
+	paymentInterceptorActive int32 // To be used atomically.
 
 	// Required by the grpc-gateway/v2 library for forward compatibility.
 	// Must be after the atomically used variables to not break struct
@@ -318,6 +354,13 @@ func (s *Server) SendPaymentV2(req *SendPaymentRequest,
 		return err
 	}
 
+	err = s.checkSpendingLimits(
+		stream.Context(), payment.Target, maxPaymentSpend(payment),
+	)
+	if err != nil {
+		return err
+	}
+
 	err = s.cfg.Router.SendPaymentAsync(payment)
 	if err != nil {
 		// Transform user errors to grpc code.
@@ -341,6 +384,188 @@ func (s *Server) SendPaymentV2(req *SendPaymentRequest,
 	return s.trackPayment(payment.Identifier(), stream, req.NoInflightUpdates)
 }
 
+// checkSpendingLimits checks a payment against the spending limits of the
+// macaroon of the request in the given context, and charges it to the budgets
+// of the macaroon.
+func (s *Server) checkSpendingLimits(ctx context.Context, dest route.Vertex,
+	amt lnwire.MilliSatoshi) error {
+
+	if s.cfg.MacService == nil {
+		return nil
+	}
+
+	err := s.cfg.MacService.CheckPayment(ctx, dest, amt)
+	if err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return nil
+}
+
+// maxPaymentSpend returns the maximum amount that the given payment can spend,
+// which includes the fees that it may pay.
+func maxPaymentSpend(payment *routing.LightningPayment) lnwire.MilliSatoshi {
+	spend := payment.Amount + payment.FeeLimit
+
+	// Don't let an excessive fee limit overflow the amount.
+	if spend < payment.Amount {
+		return math.MaxUint64
+	}
+
+	return spend
+}
+
+// SendPaymentBatch pays several destinations in one call, using keysend or
+// AMP. Updates of all payments are streamed back to the client, tagged with
+// the index of their recipient.
+func (s *Server) SendPaymentBatch(req *SendPaymentBatchRequest,
+	stream Router_SendPaymentBatchServer) error {
+
+	payments, err := s.cfg.RouterBackend.extractIntentsFromBatchRequest(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	maxConcurrent := uint32(defaultMaxConcurrentBatchPayments)
+	if req.MaxConcurrentPayments != 0 {
+		maxConcurrent = req.MaxConcurrentPayments
+	}
+
+	// If requested, make sure that all recipients can be reached before
+	// sending any payment.
+	if req.AllOrNothing {
+		err := s.probeBatch(stream.Context(), payments, maxConcurrent)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Updates of the payments are sent concurrently, while the stream
+	// doesn't allow concurrent sends.
+	var sendMtx sync.Mutex
+	send := func(update *PaymentBatchUpdate) error {
+		sendMtx.Lock()
+		defer sendMtx.Unlock()
+
+		return stream.Send(update)
+	}
+
+	err = runConcurrent(
+		stream.Context(), len(payments), maxConcurrent, s.quit,
+		func(ctx context.Context, idx int) error {
+			return s.sendBatchPayment(
+				ctx, uint32(idx), payments[idx],
+				req.NoInflightUpdates, send,
+			)
+		},
+	)
+	if errors.Is(err, context.Canceled) {
+		log.Debugf("Payment batch stream canceled")
+	}
+
+	return err
+}
+
+// sendBatchPayment sends the payment to a recipient of a batch and streams its
+// updates until it is resolved. If the payment can't be started, this is
+// reported in an update rather than failing the batch.
+func (s *Server) sendBatchPayment(ctx context.Context, idx uint32,
+	payment *routing.LightningPayment, noInflightUpdates bool,
+	send func(*PaymentBatchUpdate) error) error {
+
+	err := s.checkSpendingLimits(
+		ctx, payment.Target, maxPaymentSpend(payment),
+	)
+	if err == nil {
+		err = s.cfg.Router.SendPaymentAsync(payment)
+	}
+	if err != nil {
+		log.Debugf("SendPaymentBatch async result for payment %x: %v",
+			payment.Identifier(), err)
+
+		return send(&PaymentBatchUpdate{
+			RecipientIndex: idx,
+			Error:          err.Error(),
+		})
+	}
+
+	subscription, err := s.cfg.RouterBackend.Tower.SubscribePayment(
+		payment.Identifier(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.trackPaymentStream(
+		ctx, subscription, noInflightUpdates,
+		func(rpcPayment *lnrpc.Payment) error {
+			return send(&PaymentBatchUpdate{
+				RecipientIndex: idx,
+				Payment:        rpcPayment,
+			})
+		},
+	)
+}
+
+// probeBatch probes the recipients of a payment batch with their full amount
+// and returns an error listing the recipients that can't be reached.
+func (s *Server) probeBatch(ctx context.Context,
+	payments []*routing.LightningPayment, maxConcurrent uint32) error {
+
+	reachable := make([]bool, len(payments))
+	mc := s.cfg.RouterBackend.MissionControl
+
+	err := runConcurrent(
+		ctx, len(payments), maxConcurrent, s.quit,
+		func(_ context.Context, idx int) error {
+			payment := payments[idx]
+			results, err := s.cfg.Router.ProbeDestination(
+				payment.Target, payment.Amount,
+				&routing.RestrictParams{
+					FeeLimit:          payment.FeeLimit,
+					CltvLimit:         payment.CltvLimit,
+					ProbabilitySource: mc.GetProbability,
+					DestFeatures:      payment.DestFeatures,
+				}, payment.FinalCLTVDelta,
+				defaultMaxDestinationProbes,
+			)
+
+			// A recipient without any route is unreachable.
+			if err != nil {
+				log.Debugf("Unable to probe recipient %v: %v",
+					idx, err)
+
+				return nil
+			}
+
+			for _, result := range results {
+				reachable[idx] = reachable[idx] ||
+					result.Success
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	var unreachable []int
+	for idx, ok := range reachable {
+		if !ok {
+			unreachable = append(unreachable, idx)
+		}
+	}
+
+	if len(unreachable) > 0 {
+		return status.Errorf(codes.FailedPrecondition, "recipients "+
+			"%v can't be reached, no payment was sent",
+			unreachable)
+	}
+
+	return nil
+}
+
 // EstimateRouteFee allows callers to obtain a lower bound w.r.t how much it
 // may cost to send an HTLC to the target end destination.
 func (s *Server) EstimateRouteFee(ctx context.Context,
@@ -403,6 +628,14 @@ func (s *Server) SendToRouteV2(ctx context.Context,
 		return nil, err
 	}
 
+	err = s.checkSpendingLimits(
+		ctx, route.Hops[len(route.Hops)-1].PubKeyBytes,
+		route.TotalAmount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	var attempt *channeldb.HTLCAttempt
 
 	// Pass route to the router. This call returns the full htlc attempt
@@ -959,6 +1192,24 @@ func (s *Server) HtlcInterceptor(stream Router_HtlcInterceptorServer) error {
 	).run()
 }
 
+// PaymentInterceptor is a bidirectional stream for streaming outgoing
+// payments to the caller before they are dispatched. Only one payment
+// interceptor can be active at a time, and payments that are pending when the
+// stream closes are rejected.
+func (s *Server) PaymentInterceptor(
+	stream Router_PaymentInterceptorServer) error {
+
+	// We ensure there is only one interceptor at a time.
+	if !atomic.CompareAndSwapInt32(&s.paymentInterceptorActive, 0, 1) {
+		return ErrInterceptorAlreadyExists
+	}
+	defer atomic.CompareAndSwapInt32(&s.paymentInterceptorActive, 1, 0)
+
+	return newPaymentInterceptor(
+		stream, s.cfg.RouterBackend.MarshallRoute, s.quit,
+	).run(s.cfg.Router)
+}
+
 func extractOutPoint(req *UpdateChanStatusRequest) (*wire.OutPoint, error) {
 	chanPoint := req.GetChanPoint()
 	txid, err := lnrpc.GetChanPointFundingTxid(chanPoint)
@@ -999,3 +1250,300 @@ func (s *Server) UpdateChanStatus(ctx context.Context,
 	}
 	return &UpdateChanStatusResponse{}, nil
 }
+
+// GetFeeAutopilotRules returns the rules the fee autopilot currently uses.
+func (s *Server) GetFeeAutopilotRules(ctx context.Context,
+	req *GetFeeAutopilotRulesRequest) (*GetFeeAutopilotRulesResponse,
+	error) {
+
+	rules := s.cfg.FeeAutopilot.Rules()
+
+	rpcRules := make([]*FeeAutopilotRule, 0, len(rules))
+	for _, rule := range rules {
+		rpcRules = append(rpcRules, marshallFeeRule(rule))
+	}
+
+	return &GetFeeAutopilotRulesResponse{
+		Rules: rpcRules,
+	}, nil
+}
+
+// SetFeeAutopilotRules replaces the rules of the fee autopilot.
+func (s *Server) SetFeeAutopilotRules(ctx context.Context,
+	req *SetFeeAutopilotRulesRequest) (*SetFeeAutopilotRulesResponse,
+	error) {
+
+	rules := unmarshallFeeRules(req.Rules)
+	if err := s.cfg.FeeAutopilot.SetRules(rules); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &SetFeeAutopilotRulesResponse{}, nil
+}
+
+// DryRunFeeAutopilot returns the fee adjustments the fee autopilot would make
+// if the given rules, or the active rules if none are given, were applied.
+func (s *Server) DryRunFeeAutopilot(ctx context.Context,
+	req *DryRunFeeAutopilotRequest) (*DryRunFeeAutopilotResponse, error) {
+
+	rules := unmarshallFeeRules(req.Rules)
+	if len(rules) == 0 {
+		rules = s.cfg.FeeAutopilot.Rules()
+	}
+
+	adjustments, err := s.cfg.FeeAutopilot.DryRun(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcAdjustments := make([]*FeeAdjustment, 0, len(adjustments))
+	for _, adj := range adjustments {
+		txid := &lnrpc.ChannelPoint_FundingTxidBytes{
+			FundingTxidBytes: adj.ChanPoint.Hash[:],
+		}
+
+		rpcAdjustments = append(rpcAdjustments, &FeeAdjustment{
+			ChanPoint: &lnrpc.ChannelPoint{
+				FundingTxid: txid,
+				OutputIndex: adj.ChanPoint.Index,
+			},
+			RuleIndex:      uint32(adj.Rule),
+			RuleName:       rules[adj.Rule].Name,
+			LocalPercent:   adj.LocalPercent,
+			NumForwards:    adj.NumForwards,
+			OldBaseFeeMsat: uint64(adj.OldBaseFee),
+			OldFeeRatePpm:  adj.OldFeeRate,
+			NewBaseFeeMsat: uint64(adj.NewBaseFee),
+			NewFeeRatePpm:  adj.NewFeeRate,
+			OldInboundFee:  marshallInboundFee(adj.OldInboundFee),
+			NewInboundFee:  marshallInboundFee(adj.NewInboundFee),
+		})
+	}
+
+	return &DryRunFeeAutopilotResponse{
+		Adjustments: rpcAdjustments,
+	}, nil
+}
+
+// marshallFeeRule converts a fee rule into its RPC counterpart.
+func marshallFeeRule(rule localchans.FeeRule) *FeeAutopilotRule {
+	return &FeeAutopilotRule{
+		Name:            rule.Name,
+		MinLocalPercent: rule.MinLocalPercent,
+		MaxLocalPercent: rule.MaxLocalPercent,
+		MinForwards:     rule.MinForwards,
+		ForwardsBelow:   rule.ForwardsBelow,
+		BaseFeeMsat:     uint64(rule.BaseFee),
+		FeeRatePpm:      rule.FeeRate,
+		InboundFee:      marshallInboundFee(rule.InboundFee),
+	}
+}
+
+// marshallInboundFee converts an inbound fee into its RPC counterpart.
+func marshallInboundFee(fee lnwire.Fee) *lnrpc.InboundFee {
+	return &lnrpc.InboundFee{
+		BaseFeeMsat: fee.BaseFee,
+		FeeRatePpm:  fee.FeeRate,
+	}
+}
+
+// unmarshallFeeRules converts a list of RPC fee rules into their internal
+// representation.
+func unmarshallFeeRules(rpcRules []*FeeAutopilotRule) []localchans.FeeRule {
+	rules := make([]localchans.FeeRule, 0, len(rpcRules))
+	for _, rule := range rpcRules {
+		rules = append(rules, localchans.FeeRule{
+			Name:            rule.Name,
+			MinLocalPercent: rule.MinLocalPercent,
+			MaxLocalPercent: rule.MaxLocalPercent,
+			MinForwards:     rule.MinForwards,
+			ForwardsBelow:   rule.ForwardsBelow,
+			BaseFee:         lnwire.MilliSatoshi(rule.BaseFeeMsat),
+			FeeRate:         rule.FeeRatePpm,
+			InboundFee: lnwire.Fee{
+				BaseFee: rule.GetInboundFee().GetBaseFeeMsat(),
+				FeeRate: rule.GetInboundFee().GetFeeRatePpm(),
+			},
+		})
+	}
+
+	return rules
+}
+
+const (
+	// defaultMaxDestinationProbes is the default maximum number of probes
+	// sent by ProbeDestination.
+	defaultMaxDestinationProbes = 10
+
+	// defaultMaxLiquidityProbes is the default maximum number of probes
+	// sent by ProbeLiquidity.
+	defaultMaxLiquidityProbes = 20
+
+	// defaultLiquidityPrecision is the default width of the range the
+	// liquidity of a channel is narrowed down to by ProbeLiquidity.
+	defaultLiquidityPrecision = lnwire.MilliSatoshi(1000)
+)
+
+// ProbeRoute sends a probe along the given route.
+func (s *Server) ProbeRoute(ctx context.Context,
+	req *ProbeRouteRequest) (*ProbeResult, error) {
+
+	if req.Route == nil {
+		return nil, errors.New("unable to probe, no route provided")
+	}
+
+	rt, err := s.cfg.RouterBackend.UnmarshallRoute(req.Route)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.cfg.Router.ProbeRoute(rt)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.marshallProbeResult(result)
+}
+
+// ProbeDestination probes routes to the destination until a probe reaches
+// it.
+func (s *Server) ProbeDestination(ctx context.Context,
+	req *ProbeDestinationRequest) (*ProbeDestinationResponse, error) {
+
+	dest, err := route.NewVertexFromBytes(req.Dest)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AmtMsat <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	feeLimit := lnwire.MaxMilliSatoshi
+	if req.FeeLimitMsat > 0 {
+		feeLimit = lnwire.MilliSatoshi(req.FeeLimitMsat)
+	}
+
+	finalCltvDelta := s.cfg.RouterBackend.DefaultFinalCltvDelta
+	if req.FinalCltvDelta != 0 {
+		finalCltvDelta = uint16(req.FinalCltvDelta)
+	}
+
+	maxProbes := uint32(defaultMaxDestinationProbes)
+	if req.MaxProbes != 0 {
+		maxProbes = req.MaxProbes
+	}
+
+	mc := s.cfg.RouterBackend.MissionControl
+	results, err := s.cfg.Router.ProbeDestination(
+		dest, lnwire.MilliSatoshi(req.AmtMsat),
+		&routing.RestrictParams{
+			FeeLimit:          feeLimit,
+			CltvLimit:         s.cfg.RouterBackend.MaxTotalTimelock,
+			ProbabilitySource: mc.GetProbability,
+		}, finalCltvDelta, maxProbes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ProbeDestinationResponse{}
+	for _, result := range results {
+		rpcResult, err := s.marshallProbeResult(result)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Success = resp.Success || result.Success
+		resp.Probes = append(resp.Probes, rpcResult)
+	}
+
+	return resp, nil
+}
+
+// ProbeLiquidity narrows down the liquidity of the last channel of a route by
+// probing it.
+func (s *Server) ProbeLiquidity(ctx context.Context,
+	req *ProbeLiquidityRequest) (*ProbeLiquidityResponse, error) {
+
+	hops := make([]route.Vertex, len(req.HopPubkeys))
+	for i, pubkeyBytes := range req.HopPubkeys {
+		pubkey, err := route.NewVertexFromBytes(pubkeyBytes)
+		if err != nil {
+			return nil, err
+		}
+		hops[i] = pubkey
+	}
+
+	if req.MaxAmtMsat <= 0 {
+		return nil, errors.New("maximum amount must be positive")
+	}
+
+	probe := &routing.LiquidityProbe{
+		Hops:           hops,
+		FinalCltvDelta: req.FinalCltvDelta,
+		MinAmt:         lnwire.MilliSatoshi(req.MinAmtMsat),
+		MaxAmt:         lnwire.MilliSatoshi(req.MaxAmtMsat),
+		Precision:      lnwire.MilliSatoshi(req.PrecisionMsat),
+		MaxProbes:      req.MaxProbes,
+	}
+
+	if req.OutgoingChanId != 0 {
+		probe.OutgoingChan = &req.OutgoingChanId
+	}
+	if probe.FinalCltvDelta == 0 {
+		probe.FinalCltvDelta = int32(
+			s.cfg.RouterBackend.DefaultFinalCltvDelta,
+		)
+	}
+	if probe.Precision == 0 {
+		probe.Precision = defaultLiquidityPrecision
+	}
+	if probe.MaxProbes == 0 {
+		probe.MaxProbes = defaultMaxLiquidityProbes
+	}
+
+	bound, err := s.cfg.Router.ProbeLiquidity(probe)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ProbeLiquidityResponse{
+		MinLiquidityMsat: int64(bound.MinAmt),
+		MaxLiquidityMsat: int64(bound.MaxAmt),
+	}
+	for _, result := range bound.Probes {
+		rpcResult, err := s.marshallProbeResult(result)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Probes = append(resp.Probes, rpcResult)
+	}
+
+	return resp, nil
+}
+
+// marshallProbeResult converts a probe result into its RPC counterpart.
+func (s *Server) marshallProbeResult(
+	result *routing.ProbeResult) (*ProbeResult, error) {
+
+	rpcRoute, err := s.cfg.RouterBackend.MarshallRoute(result.Route)
+	if err != nil {
+		return nil, err
+	}
+
+	rpcResult := &ProbeResult{
+		Route:   rpcRoute,
+		Success: result.Success,
+	}
+
+	if result.Failure != nil {
+		rpcResult.Failure, err = marshallHtlcFailure(result.Failure)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rpcResult, nil
+}
@@ -2,6 +2,7 @@ package routerrpc
 
 import (
 	"context"
+	"math"
 	"testing"
 	"time"
 
@@ -214,3 +215,19 @@ func TestTrackPaymentsNoInflightUpdates(t *testing.T) {
 	payment := <-stream.sentFromServer
 	require.Equal(t, lnrpc.Payment_SUCCEEDED, payment.Status)
 }
+
+// TestMaxPaymentSpend asserts that the fee limit of a payment is charged to
+// the spending limits along with its amount.
+func TestMaxPaymentSpend(t *testing.T) {
+	t.Parallel()
+
+	payment := &routing.LightningPayment{
+		Amount:   100_000,
+		FeeLimit: 1_000,
+	}
+	require.EqualValues(t, 101_000, maxPaymentSpend(payment))
+
+	// An excessive fee limit doesn't overflow the amount.
+	payment.FeeLimit = math.MaxUint64
+	require.EqualValues(t, uint64(math.MaxUint64), maxPaymentSpend(payment))
+}
@@ -6,6 +6,7 @@ package signrpc
 import (
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/lnwallet/signpolicy"
 	"github.com/lightningnetwork/lnd/macaroons"
 )
 
@@ -36,4 +37,9 @@ type Config struct {
 	// KeyRing is an interface that the signer will use to derive any keys
 	// for signing messages.
 	KeyRing keychain.SecretKeyRing
+
+	// SignPolicy is the signing policy all requests to sign transactions
+	// are checked against before they are handed to the signer. If it is
+	// nil, any request is signed.
+	SignPolicy *signpolicy.Enforcer
 }
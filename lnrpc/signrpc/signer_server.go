@@ -22,6 +22,7 @@ import (
 	"github.com/lightningnetwork/lnd/input"
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwallet/signpolicy"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/macaroons"
 	"google.golang.org/grpc"
@@ -464,6 +465,22 @@ func (s *Server) SignOutputRaw(_ context.Context, in *SignReq) (*SignResp,
 		}
 	}
 
+	// Before anything is signed, make sure the signing policy allows it.
+	// The wallet signs for any of its keys, so each input is checked for
+	// whether it spends wallet funds.
+	if s.cfg.SignPolicy != nil {
+		req := signPolicyRequest(&txToSign, signDescs, false)
+		for i, signDesc := range signDescs {
+			req.Inputs[i].Wallet = s.cfg.SignPolicy.IsWalletInput(
+				&signDesc.KeyDesc, signDesc.Output.PkScript,
+			)
+		}
+
+		if err := s.cfg.SignPolicy.CheckSign(req); err != nil {
+			return nil, err
+		}
+	}
+
 	// Now that we've mapped all the proper sign descriptors, we can
 	// request signatures for each of them, passing in the transaction to
 	// be signed.
@@ -566,6 +583,17 @@ func (s *Server) ComputeInputScript(ctx context.Context,
 		})
 	}
 
+	// This method only signs for outputs of the wallet, so the signing
+	// policy treats them as wallet spends.
+	if s.cfg.SignPolicy != nil {
+		err := s.cfg.SignPolicy.CheckSign(
+			signPolicyRequest(&txToSign, signDescs, true),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// With all of our signDescs assembled, we can now generate a valid
 	// input script for each of them, and collate the responses to return
 	// back to the caller.
@@ -1009,6 +1037,14 @@ func (s *Server) MuSig2Sign(_ context.Context,
 	}
 	copy(msg[:], in.MessageDigest)
 
+	// The message is opaque to us, so the signing policy can only allow
+	// or deny MuSig2 signing as a whole.
+	if s.cfg.SignPolicy != nil {
+		if err := s.cfg.SignPolicy.CheckMuSig2(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create our own partial signature with the local signing key.
 	partialSig, err := s.cfg.Signer.MuSig2Sign(sessionID, msg, in.Cleanup)
 	if err != nil {
@@ -1086,6 +1122,27 @@ func (s *Server) MuSig2Cleanup(_ context.Context,
 	return &MuSig2CleanupResponse{}, nil
 }
 
+// signPolicyRequest creates the signing policy request for the given sign
+// descriptors. If wallet is true, the inputs are spent with keys of the
+// on-chain wallet.
+func signPolicyRequest(tx *wire.MsgTx, signDescs []*input.SignDescriptor,
+	wallet bool) *signpolicy.Request {
+
+	req := &signpolicy.Request{
+		Tx: tx,
+	}
+	for _, signDesc := range signDescs {
+		req.Inputs = append(req.Inputs, signpolicy.Input{
+			Index:         signDesc.InputIndex,
+			WitnessScript: signDesc.WitnessScript,
+			HashType:      signDesc.HashType,
+			Wallet:        wallet,
+		})
+	}
+
+	return req
+}
+
 // parseRawKeyBytes checks that the provided raw public key is valid and returns
 // the public key. A nil public key is returned if the length of the rawKeyBytes
 // is zero.
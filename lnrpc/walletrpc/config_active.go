@@ -8,6 +8,7 @@ import (
 	"github.com/lightningnetwork/lnd/keychain"
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwallet/signpolicy"
 	"github.com/lightningnetwork/lnd/macaroons"
 	"github.com/lightningnetwork/lnd/sweep"
 )
@@ -71,4 +72,8 @@ type Config struct {
 	// CurrentNumAnchorChans returns the current number of non-private
 	// anchor channels the wallet should be ready to fee bump if needed.
 	CurrentNumAnchorChans func() (int, error)
+
+	// SignPolicy is the signing policy all PSBTs are checked against
+	// before they are signed. If it is nil, any PSBT is signed.
+	SignPolicy *signpolicy.Enforcer
 }
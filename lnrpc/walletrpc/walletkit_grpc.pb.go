@@ -36,6 +36,19 @@ type WalletKitClient interface {
 	ReleaseOutput(ctx context.Context, in *ReleaseOutputRequest, opts ...grpc.CallOption) (*ReleaseOutputResponse, error)
 	// ListLeases lists all currently locked utxos.
 	ListLeases(ctx context.Context, in *ListLeasesRequest, opts ...grpc.CallOption) (*ListLeasesResponse, error)
+	// FreezeOutput freezes an output indefinitely, preventing it from being
+	// selected by any automatic coin selection, for example when funding a
+	// channel, sweeping or sending coins. Unlike a lease, a freeze does not
+	// expire and persists across restarts until the output is explicitly
+	// unfrozen through `UnfreezeOutput`.
+	FreezeOutput(ctx context.Context, in *FreezeOutputRequest, opts ...grpc.CallOption) (*FreezeOutputResponse, error)
+	// UnfreezeOutput removes the freeze of an output, making it available for
+	// coin selection again if it remains unspent.
+	UnfreezeOutput(ctx context.Context, in *UnfreezeOutputRequest, opts ...grpc.CallOption) (*UnfreezeOutputResponse, error)
+	// LabelOutput attaches a persistent label to an output of the wallet. If the
+	// output already has a label the call will fail unless the overwrite bool is
+	// set. Labels must not be empty, and cannot exceed 500 characters.
+	LabelOutput(ctx context.Context, in *LabelOutputRequest, opts ...grpc.CallOption) (*LabelOutputResponse, error)
 	// DeriveNextKey attempts to derive the *next* key within the key family
 	// (account in BIP43) specified. This method should return the next external
 	// child within this branch.
@@ -249,6 +262,33 @@ func (c *walletKitClient) ListLeases(ctx context.Context, in *ListLeasesRequest,
 	return out, nil
 }
 
+func (c *walletKitClient) FreezeOutput(ctx context.Context, in *FreezeOutputRequest, opts ...grpc.CallOption) (*FreezeOutputResponse, error) {
+	out := new(FreezeOutputResponse)
+	err := c.cc.Invoke(ctx, "/walletrpc.WalletKit/FreezeOutput", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletKitClient) UnfreezeOutput(ctx context.Context, in *UnfreezeOutputRequest, opts ...grpc.CallOption) (*UnfreezeOutputResponse, error) {
+	out := new(UnfreezeOutputResponse)
+	err := c.cc.Invoke(ctx, "/walletrpc.WalletKit/UnfreezeOutput", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletKitClient) LabelOutput(ctx context.Context, in *LabelOutputRequest, opts ...grpc.CallOption) (*LabelOutputResponse, error) {
+	out := new(LabelOutputResponse)
+	err := c.cc.Invoke(ctx, "/walletrpc.WalletKit/LabelOutput", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *walletKitClient) DeriveNextKey(ctx context.Context, in *KeyReq, opts ...grpc.CallOption) (*signrpc.KeyDescriptor, error) {
 	out := new(signrpc.KeyDescriptor)
 	err := c.cc.Invoke(ctx, "/walletrpc.WalletKit/DeriveNextKey", in, out, opts...)
@@ -441,6 +481,19 @@ type WalletKitServer interface {
 	ReleaseOutput(context.Context, *ReleaseOutputRequest) (*ReleaseOutputResponse, error)
 	// ListLeases lists all currently locked utxos.
 	ListLeases(context.Context, *ListLeasesRequest) (*ListLeasesResponse, error)
+	// FreezeOutput freezes an output indefinitely, preventing it from being
+	// selected by any automatic coin selection, for example when funding a
+	// channel, sweeping or sending coins. Unlike a lease, a freeze does not
+	// expire and persists across restarts until the output is explicitly
+	// unfrozen through `UnfreezeOutput`.
+	FreezeOutput(context.Context, *FreezeOutputRequest) (*FreezeOutputResponse, error)
+	// UnfreezeOutput removes the freeze of an output, making it available for
+	// coin selection again if it remains unspent.
+	UnfreezeOutput(context.Context, *UnfreezeOutputRequest) (*UnfreezeOutputResponse, error)
+	// LabelOutput attaches a persistent label to an output of the wallet. If the
+	// output already has a label the call will fail unless the overwrite bool is
+	// set. Labels must not be empty, and cannot exceed 500 characters.
+	LabelOutput(context.Context, *LabelOutputRequest) (*LabelOutputResponse, error)
 	// DeriveNextKey attempts to derive the *next* key within the key family
 	// (account in BIP43) specified. This method should return the next external
 	// child within this branch.
@@ -627,6 +680,15 @@ func (UnimplementedWalletKitServer) ReleaseOutput(context.Context, *ReleaseOutpu
 func (UnimplementedWalletKitServer) ListLeases(context.Context, *ListLeasesRequest) (*ListLeasesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListLeases not implemented")
 }
+func (UnimplementedWalletKitServer) FreezeOutput(context.Context, *FreezeOutputRequest) (*FreezeOutputResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FreezeOutput not implemented")
+}
+func (UnimplementedWalletKitServer) UnfreezeOutput(context.Context, *UnfreezeOutputRequest) (*UnfreezeOutputResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnfreezeOutput not implemented")
+}
+func (UnimplementedWalletKitServer) LabelOutput(context.Context, *LabelOutputRequest) (*LabelOutputResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LabelOutput not implemented")
+}
 func (UnimplementedWalletKitServer) DeriveNextKey(context.Context, *KeyReq) (*signrpc.KeyDescriptor, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeriveNextKey not implemented")
 }
@@ -769,6 +831,60 @@ func _WalletKit_ListLeases_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WalletKit_FreezeOutput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FreezeOutputRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletKitServer).FreezeOutput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletKit/FreezeOutput",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletKitServer).FreezeOutput(ctx, req.(*FreezeOutputRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletKit_UnfreezeOutput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnfreezeOutputRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletKitServer).UnfreezeOutput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletKit/UnfreezeOutput",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletKitServer).UnfreezeOutput(ctx, req.(*UnfreezeOutputRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletKit_LabelOutput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LabelOutputRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletKitServer).LabelOutput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/walletrpc.WalletKit/LabelOutput",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletKitServer).LabelOutput(ctx, req.(*LabelOutputRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _WalletKit_DeriveNextKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(KeyReq)
 	if err := dec(in); err != nil {
@@ -1134,6 +1250,18 @@ var WalletKit_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListLeases",
 			Handler:    _WalletKit_ListLeases_Handler,
 		},
+		{
+			MethodName: "FreezeOutput",
+			Handler:    _WalletKit_FreezeOutput_Handler,
+		},
+		{
+			MethodName: "UnfreezeOutput",
+			Handler:    _WalletKit_UnfreezeOutput_Handler,
+		},
+		{
+			MethodName: "LabelOutput",
+			Handler:    _WalletKit_LabelOutput_Handler,
+		},
 		{
 			MethodName: "DeriveNextKey",
 			Handler:    _WalletKit_DeriveNextKey_Handler,
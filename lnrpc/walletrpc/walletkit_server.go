@@ -36,6 +36,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/btcwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/lightningnetwork/lnd/lnwallet/signpolicy"
 	"github.com/lightningnetwork/lnd/macaroons"
 	"github.com/lightningnetwork/lnd/sweep"
 	"google.golang.org/grpc"
@@ -118,6 +119,18 @@ var (
 			Entity: "onchain",
 			Action: "read",
 		}},
+		"/walletrpc.WalletKit/FreezeOutput": {{
+			Entity: "onchain",
+			Action: "write",
+		}},
+		"/walletrpc.WalletKit/UnfreezeOutput": {{
+			Entity: "onchain",
+			Action: "write",
+		}},
+		"/walletrpc.WalletKit/LabelOutput": {{
+			Entity: "onchain",
+			Action: "write",
+		}},
 		"/walletrpc.WalletKit/ListUnspent": {{
 			Entity: "onchain",
 			Action: "read",
@@ -182,6 +195,10 @@ var (
 // an empty label.
 var ErrZeroLabel = errors.New("cannot label transaction with empty label")
 
+// ErrZeroOutputLabel is returned when an attempt is made to label an output
+// with an empty label.
+var ErrZeroOutputLabel = errors.New("cannot label output with empty label")
+
 // ServerShell is a shell struct holding a reference to the actual sub-server.
 // It is used to register the gRPC sub-server with the root server before we
 // have the necessary dependencies to populate the actual sub-server.
@@ -341,9 +358,10 @@ func (w *WalletKit) internalScope() waddrmgr.KeyScope {
 // ListUnspent returns useful information about each unspent output owned by
 // the wallet, as reported by the underlying `ListUnspentWitness`; the
 // information returned is: outpoint, amount in satoshis, address, address
-// type, scriptPubKey in hex and number of confirmations. The result is
-// filtered to contain outputs whose number of confirmations is between a
-// minimum and maximum number of confirmations specified by the user.
+// type, scriptPubKey in hex, number of confirmations, label and whether the
+// output is frozen. The result is filtered to contain outputs whose number of
+// confirmations is between a minimum and maximum number of confirmations
+// specified by the user. Frozen outputs are only included if requested.
 func (w *WalletKit) ListUnspent(ctx context.Context,
 	req *ListUnspentRequest) (*ListUnspentResponse, error) {
 
@@ -378,8 +396,19 @@ func (w *WalletKit) ListUnspent(ctx context.Context,
 		utxos, err = w.cfg.Wallet.ListUnspentWitness(
 			minConfs, maxConfs, req.Account,
 		)
+		if err != nil || !req.IncludeFrozen {
+			return err
+		}
 
-		return err
+		frozen, err := w.cfg.Wallet.ListFrozenOutputs(
+			minConfs, maxConfs, req.Account,
+		)
+		if err != nil {
+			return err
+		}
+		utxos = append(utxos, frozen...)
+
+		return nil
 	})
 	if err != nil {
 		return nil, err
@@ -496,6 +525,77 @@ func (w *WalletKit) ListLeases(ctx context.Context,
 	}, nil
 }
 
+// FreezeOutput freezes an output indefinitely, preventing it from being
+// selected by any automatic coin selection until it is unfrozen again. The
+// freeze is persisted and survives restarts.
+func (w *WalletKit) FreezeOutput(ctx context.Context,
+	req *FreezeOutputRequest) (*FreezeOutputResponse, error) {
+
+	op, err := unmarshallOutPoint(req.Outpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// Acquire the global coin selection lock to make sure the output isn't
+	// concurrently being selected by another process.
+	err = w.cfg.CoinSelectionLocker.WithCoinSelectLock(func() error {
+		return w.cfg.Wallet.FreezeOutput(*op)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &FreezeOutputResponse{}, nil
+}
+
+// UnfreezeOutput removes the freeze of an output, making it available for coin
+// selection again if it remains unspent.
+func (w *WalletKit) UnfreezeOutput(ctx context.Context,
+	req *UnfreezeOutputRequest) (*UnfreezeOutputResponse, error) {
+
+	op, err := unmarshallOutPoint(req.Outpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.cfg.CoinSelectionLocker.WithCoinSelectLock(func() error {
+		return w.cfg.Wallet.UnfreezeOutput(*op)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UnfreezeOutputResponse{}, nil
+}
+
+// LabelOutput attaches a persistent label to an output of the wallet.
+func (w *WalletKit) LabelOutput(ctx context.Context,
+	req *LabelOutputRequest) (*LabelOutputResponse, error) {
+
+	// Check that the label provided in non-zero.
+	if len(req.Label) == 0 {
+		return nil, ErrZeroOutputLabel
+	}
+
+	// Output labels follow the same length restrictions as transaction
+	// labels.
+	if _, err := labels.ValidateAPI(req.Label); err != nil {
+		return nil, err
+	}
+
+	op, err := unmarshallOutPoint(req.Outpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.cfg.Wallet.LabelOutput(*op, req.Label, req.Overwrite)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LabelOutputResponse{}, nil
+}
+
 // DeriveNextKey attempts to derive the *next* key within the key family
 // (account in BIP43) specified. This method should return the next external
 // child within this branch.
@@ -623,11 +723,22 @@ func (w *WalletKit) SendOutputs(ctx context.Context,
 	// amp the protos back into the format that the internal wallet will
 	// recognize.
 	outputsToCreate := make([]*wire.TxOut, 0, len(req.Outputs))
+	var totalAmt btcutil.Amount
 	for _, output := range req.Outputs {
 		outputsToCreate = append(outputsToCreate, &wire.TxOut{
 			Value:    output.Value,
 			PkScript: output.PkScript,
 		})
+		totalAmt += btcutil.Amount(output.Value)
+	}
+
+	// Make sure the total amount doesn't exceed the spending limits of the
+	// macaroon.
+	if w.cfg.MacService != nil {
+		err := w.cfg.MacService.CheckOnChainSend(ctx, totalAmt)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Then, we'll extract the minimum number of confirmations that each
@@ -1269,6 +1380,16 @@ func (w *WalletKit) SignPsbt(_ context.Context, req *SignPsbtRequest) (
 		}
 	}
 
+	// Before anything is signed, make sure the signing policy allows it.
+	if w.cfg.SignPolicy != nil {
+		err := w.cfg.SignPolicy.CheckSign(
+			signpolicy.RequestFromPsbt(packet),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Let the wallet do the heavy lifting. This will sign all inputs that
 	// we have the UTXO for. If some inputs can't be signed and don't have
 	// witness data attached, they will just be skipped.
@@ -210,6 +210,28 @@ func (w *WalletController) ListLeasedOutputs() ([]*base.ListLeasedOutputResult,
 	return nil, nil
 }
 
+// FreezeOutput currently does nothing.
+func (w *WalletController) FreezeOutput(wire.OutPoint) error {
+	return nil
+}
+
+// UnfreezeOutput currently does nothing.
+func (w *WalletController) UnfreezeOutput(wire.OutPoint) error {
+	return nil
+}
+
+// ListFrozenOutputs currently returns dummy values.
+func (w *WalletController) ListFrozenOutputs(int32, int32,
+	string) ([]*lnwallet.Utxo, error) {
+
+	return nil, nil
+}
+
+// LabelOutput currently does nothing.
+func (w *WalletController) LabelOutput(wire.OutPoint, string, bool) error {
+	return nil
+}
+
 // FundPsbt currently does nothing.
 func (w *WalletController) FundPsbt(*psbt.Packet, int32, chainfee.SatPerKWeight,
 	string) (int32, error) {
@@ -111,6 +111,12 @@ type BtcWallet struct {
 
 	musig2Sessions    map[input.MuSig2SessionID]*muSig2State
 	musig2SessionsMtx sync.Mutex
+
+	// frozenOutputs is the set of outputs that have been frozen by the
+	// user. These are kept locked within the internal wallet so they're
+	// never considered for coin selection.
+	frozenOutputs map[wire.OutPoint]struct{}
+	frozenMtx     sync.Mutex
 }
 
 // A compile time check to ensure that BtcWallet implements the
@@ -181,6 +187,7 @@ func New(cfg Config, blockCache *blockcache.BlockCache) (*BtcWallet, error) {
 		chainKeyScope:  chainKeyScope,
 		blockCache:     blockCache,
 		musig2Sessions: make(map[input.MuSig2SessionID]*muSig2State),
+		frozenOutputs:  make(map[wire.OutPoint]struct{}),
 	}, nil
 }
 
@@ -434,6 +441,12 @@ func (b *BtcWallet) Start() error {
 		return err
 	}
 
+	// Lock all outputs the user has frozen so they're excluded from any
+	// coin selection from the very start.
+	if err := b.loadFrozenOutputs(); err != nil {
+		return err
+	}
+
 	// Establish an RPC connection in addition to starting the goroutines
 	// in the underlying wallet.
 	if err := b.chain.Start(); err != nil {
@@ -488,7 +501,16 @@ func (b *BtcWallet) ConfirmedBalance(confs int32,
 		balance += witnessOutput.Value
 	}
 
-	return balance, nil
+	// Frozen outputs aren't available for coin selection, but they're
+	// still part of the wallet's balance.
+	frozenBalance, err := b.frozenBalance(
+		confs, math.MaxInt32, accountFilter,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance + frozenBalance, nil
 }
 
 // keyScopeForAccountAddr determines the appropriate key scope of an account
@@ -1000,12 +1022,17 @@ func (b *BtcWallet) LockOutpoint(o wire.OutPoint) {
 }
 
 // UnlockOutpoint unlocks a previously locked output, marking it eligible for
-// coin selection.
+// coin selection. Frozen outputs stay locked until they're unfrozen.
 //
 // NOTE: This method requires the global coin selection lock to be held.
 //
 // This is a part of the WalletController interface.
 func (b *BtcWallet) UnlockOutpoint(o wire.OutPoint) {
+	if b.isFrozen(o) {
+		log.Warnf("Not unlocking frozen output %v", o)
+		return
+	}
+
 	b.wallet.UnlockOutpoint(o)
 }
 
@@ -1092,6 +1119,12 @@ func (b *BtcWallet) ListUnspentWitness(minConfs, maxConfs int32,
 		return nil, err
 	}
 
+	// We'll also attach any labels the user has given to the outputs.
+	metas, err := b.fetchOutputMeta()
+	if err != nil {
+		return nil, err
+	}
+
 	// Next, we'll run through all the regular outputs, only saving those
 	// which are p2wkh outputs or a p2wsh output nested within a p2sh output.
 	witnessOutputs := make([]*lnwallet.Utxo, 0, len(unspentOutputs))
@@ -1101,47 +1134,38 @@ func (b *BtcWallet) ListUnspentWitness(minConfs, maxConfs int32,
 			return nil, err
 		}
 
-		addressType := lnwallet.UnknownAddressType
-		if txscript.IsPayToWitnessPubKeyHash(pkScript) {
-			addressType = lnwallet.WitnessPubKey
-		} else if txscript.IsPayToScriptHash(pkScript) {
-			// TODO(roasbeef): This assumes all p2sh outputs returned by the
-			// wallet are nested p2pkh. We can't check the redeem script because
-			// the btcwallet service does not include it.
-			addressType = lnwallet.NestedWitnessPubKey
-		} else if txscript.IsPayToTaproot(pkScript) {
-			addressType = lnwallet.TaprootPubkey
+		addressType := scriptAddressType(pkScript)
+		if addressType == lnwallet.UnknownAddressType {
+			continue
 		}
 
-		if addressType == lnwallet.WitnessPubKey ||
-			addressType == lnwallet.NestedWitnessPubKey ||
-			addressType == lnwallet.TaprootPubkey {
-
-			txid, err := chainhash.NewHashFromStr(output.TxID)
-			if err != nil {
-				return nil, err
-			}
+		txid, err := chainhash.NewHashFromStr(output.TxID)
+		if err != nil {
+			return nil, err
+		}
 
-			// We'll ensure we properly convert the amount given in
-			// BTC to satoshis.
-			amt, err := btcutil.NewAmount(output.Amount)
-			if err != nil {
-				return nil, err
-			}
+		// We'll ensure we properly convert the amount given in BTC to
+		// satoshis.
+		amt, err := btcutil.NewAmount(output.Amount)
+		if err != nil {
+			return nil, err
+		}
 
-			utxo := &lnwallet.Utxo{
-				AddressType: addressType,
-				Value:       amt,
-				PkScript:    pkScript,
-				OutPoint: wire.OutPoint{
-					Hash:  *txid,
-					Index: output.Vout,
-				},
-				Confirmations: output.Confirmations,
-			}
-			witnessOutputs = append(witnessOutputs, utxo)
+		utxo := &lnwallet.Utxo{
+			AddressType: addressType,
+			Value:       amt,
+			PkScript:    pkScript,
+			OutPoint: wire.OutPoint{
+				Hash:  *txid,
+				Index: output.Vout,
+			},
+			Confirmations: output.Confirmations,
+		}
+		if meta, ok := metas[utxo.OutPoint]; ok {
+			utxo.Label = string(meta.label)
 		}
 
+		witnessOutputs = append(witnessOutputs, utxo)
 	}
 
 	return witnessOutputs, nil
@@ -0,0 +1,465 @@
+package btcwallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+var (
+	// outputMetaBucket is the sub-bucket of the wallet meta bucket that
+	// stores the coin control metadata (labels and freezes) of individual
+	// wallet outputs, keyed by their serialized outpoint.
+	outputMetaBucket = []byte("output-meta")
+)
+
+const (
+	// outputLabelType is the TLV type of an output's label.
+	outputLabelType tlv.Type = 0
+
+	// outputFrozenType is the TLV type of an output's frozen flag.
+	outputFrozenType tlv.Type = 1
+)
+
+// outputMeta is the coin control metadata that is persisted for a single
+// wallet output.
+type outputMeta struct {
+	label  []byte
+	frozen uint8
+}
+
+// encode serializes the output metadata as a TLV stream.
+func (m *outputMeta) encode(w io.Writer) error {
+	stream, err := tlv.NewStream(
+		tlv.MakePrimitiveRecord(outputLabelType, &m.label),
+		tlv.MakePrimitiveRecord(outputFrozenType, &m.frozen),
+	)
+	if err != nil {
+		return err
+	}
+
+	return stream.Encode(w)
+}
+
+// decode deserializes the output metadata from a TLV stream.
+func (m *outputMeta) decode(r io.Reader) error {
+	stream, err := tlv.NewStream(
+		tlv.MakePrimitiveRecord(outputLabelType, &m.label),
+		tlv.MakePrimitiveRecord(outputFrozenType, &m.frozen),
+	)
+	if err != nil {
+		return err
+	}
+
+	return stream.Decode(r)
+}
+
+// outpointKey returns the database key of the given outpoint.
+func outpointKey(op wire.OutPoint) []byte {
+	var key [36]byte
+	copy(key[:32], op.Hash[:])
+	binary.BigEndian.PutUint32(key[32:], op.Index)
+
+	return key[:]
+}
+
+// fetchOutputMeta reads the metadata of all outputs that have any coin control
+// metadata attached to them.
+func (b *BtcWallet) fetchOutputMeta() (map[wire.OutPoint]*outputMeta, error) {
+	metas := make(map[wire.OutPoint]*outputMeta)
+	err := walletdb.View(b.db, func(tx walletdb.ReadTx) error {
+		metaBucket := tx.ReadBucket([]byte(walletMetaBucket))
+		if metaBucket == nil {
+			return nil
+		}
+
+		outputs := metaBucket.NestedReadBucket(outputMetaBucket)
+		if outputs == nil {
+			return nil
+		}
+
+		return outputs.ForEach(func(k, v []byte) error {
+			var op wire.OutPoint
+			copy(op.Hash[:], k[:32])
+			op.Index = binary.BigEndian.Uint32(k[32:])
+
+			meta := &outputMeta{}
+			if err := meta.decode(bytes.NewReader(v)); err != nil {
+				return err
+			}
+			metas[op] = meta
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return metas, nil
+}
+
+// updateOutputMeta applies the given modification to the metadata of an
+// output within a single database transaction. If the modification leaves the
+// output without a label and not frozen, its entry is removed.
+func (b *BtcWallet) updateOutputMeta(op wire.OutPoint,
+	modify func(tx walletdb.ReadWriteTx, meta *outputMeta) error) error {
+
+	return walletdb.Update(b.db, func(tx walletdb.ReadWriteTx) error {
+		metaBucket, err := tx.CreateTopLevelBucket(
+			[]byte(walletMetaBucket),
+		)
+		if err != nil {
+			return err
+		}
+
+		outputs, err := metaBucket.CreateBucketIfNotExists(
+			outputMetaBucket,
+		)
+		if err != nil {
+			return err
+		}
+
+		key := outpointKey(op)
+		meta := &outputMeta{}
+		if v := outputs.Get(key); v != nil {
+			if err := meta.decode(bytes.NewReader(v)); err != nil {
+				return err
+			}
+		}
+
+		if err := modify(tx, meta); err != nil {
+			return err
+		}
+
+		if len(meta.label) == 0 && meta.frozen == 0 {
+			return outputs.Delete(key)
+		}
+
+		var buf bytes.Buffer
+		if err := meta.encode(&buf); err != nil {
+			return err
+		}
+
+		return outputs.Put(key, buf.Bytes())
+	})
+}
+
+// loadFrozenOutputs reads all frozen outputs from the database and locks them
+// within the internal wallet so they are excluded from coin selection.
+func (b *BtcWallet) loadFrozenOutputs() error {
+	metas, err := b.fetchOutputMeta()
+	if err != nil {
+		return err
+	}
+
+	b.frozenMtx.Lock()
+	defer b.frozenMtx.Unlock()
+
+	for op, meta := range metas {
+		if meta.frozen == 0 {
+			continue
+		}
+
+		b.frozenOutputs[op] = struct{}{}
+		b.wallet.LockOutpoint(op)
+	}
+
+	if len(b.frozenOutputs) > 0 {
+		log.Infof("Loaded %d frozen outputs", len(b.frozenOutputs))
+	}
+
+	return nil
+}
+
+// isFrozen returns true if the given outpoint is currently frozen.
+func (b *BtcWallet) isFrozen(op wire.OutPoint) bool {
+	b.frozenMtx.Lock()
+	defer b.frozenMtx.Unlock()
+
+	_, ok := b.frozenOutputs[op]
+	return ok
+}
+
+// fetchUnspentCredit returns the unspent wallet credit of the given outpoint.
+// If the output is unknown or already spent, wtxmgr.ErrUnknownOutput is
+// returned.
+func (b *BtcWallet) fetchUnspentCredit(tx walletdb.ReadTx,
+	op wire.OutPoint) (*wtxmgr.Credit, error) {
+
+	txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+	unspent, err := b.wallet.TxStore.UnspentOutputs(txmgrNs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range unspent {
+		if unspent[i].OutPoint == op {
+			return &unspent[i], nil
+		}
+	}
+
+	return nil, wtxmgr.ErrUnknownOutput
+}
+
+// FreezeOutput freezes an output of the wallet indefinitely, excluding it from
+// any future coin selection attempts. Contrary to a lease, the freeze doesn't
+// expire and is persisted across restarts.
+//
+// If the output is not known, wtxmgr.ErrUnknownOutput is returned. If the
+// output is currently leased or locked, then wtxmgr.ErrOutputAlreadyLocked or
+// lnwallet.ErrOutputLocked is returned.
+//
+// NOTE: This method requires the global coin selection lock to be held.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) FreezeOutput(op wire.OutPoint) error {
+	// Freezing an output twice is a no-op.
+	if b.isFrozen(op) {
+		return nil
+	}
+
+	// An output that is locked in memory is currently being used by an
+	// ongoing coin selection attempt, so we can't freeze it.
+	if b.wallet.LockedOutpoint(op) {
+		return lnwallet.ErrOutputLocked
+	}
+
+	leases, err := b.wallet.ListLeasedOutputs()
+	if err != nil {
+		return err
+	}
+	for _, lease := range leases {
+		if lease.Outpoint == op {
+			return wtxmgr.ErrOutputAlreadyLocked
+		}
+	}
+
+	err = b.updateOutputMeta(op, func(tx walletdb.ReadWriteTx,
+		meta *outputMeta) error {
+
+		if _, err := b.fetchUnspentCredit(tx, op); err != nil {
+			return err
+		}
+
+		meta.frozen = 1
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b.frozenMtx.Lock()
+	b.frozenOutputs[op] = struct{}{}
+	b.frozenMtx.Unlock()
+
+	b.wallet.LockOutpoint(op)
+
+	return nil
+}
+
+// UnfreezeOutput removes the freeze of an output, allowing it to be selected
+// for coin selection again if it remains unspent.
+//
+// NOTE: This method requires the global coin selection lock to be held.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) UnfreezeOutput(op wire.OutPoint) error {
+	if !b.isFrozen(op) {
+		return lnwallet.ErrOutputNotFrozen
+	}
+
+	err := b.updateOutputMeta(op, func(_ walletdb.ReadWriteTx,
+		meta *outputMeta) error {
+
+		meta.frozen = 0
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b.frozenMtx.Lock()
+	delete(b.frozenOutputs, op)
+	b.frozenMtx.Unlock()
+
+	b.wallet.UnlockOutpoint(op)
+
+	return nil
+}
+
+// ListFrozenOutputs returns all unspent witness outputs of the wallet that are
+// currently frozen and would otherwise be returned by ListUnspentWitness for
+// the same set of arguments.
+//
+// NOTE: This method requires the global coin selection lock to be held.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) ListFrozenOutputs(minConfs, maxConfs int32,
+	accountFilter string) ([]*lnwallet.Utxo, error) {
+
+	b.frozenMtx.Lock()
+	numFrozen := len(b.frozenOutputs)
+	b.frozenMtx.Unlock()
+
+	// Avoid reading the full set of unspent outputs if there's nothing to
+	// look for.
+	if numFrozen == 0 {
+		return nil, nil
+	}
+
+	metas, err := b.fetchOutputMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	var frozen []*lnwallet.Utxo
+	err = walletdb.View(b.db, func(tx walletdb.ReadTx) error {
+		addrmgrNs := tx.ReadBucket(waddrmgrNamespaceKey)
+		txmgrNs := tx.ReadBucket(wtxmgrNamespaceKey)
+
+		syncHeight := b.wallet.Manager.SyncedTo().Height
+
+		unspent, err := b.wallet.TxStore.UnspentOutputs(txmgrNs)
+		if err != nil {
+			return err
+		}
+
+		for _, credit := range unspent {
+			if !b.isFrozen(credit.OutPoint) {
+				continue
+			}
+
+			var confs int32
+			if credit.Height != -1 && credit.Height <= syncHeight {
+				confs = syncHeight - credit.Height + 1
+			}
+			if confs < minConfs || confs > maxConfs {
+				continue
+			}
+
+			addressType := scriptAddressType(credit.PkScript)
+			if addressType == lnwallet.UnknownAddressType {
+				continue
+			}
+
+			if accountFilter != "" {
+				_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+					credit.PkScript, b.netParams,
+				)
+				if err != nil || len(addrs) == 0 {
+					continue
+				}
+
+				smgr, acct, err := b.wallet.Manager.AddrAccount(
+					addrmgrNs, addrs[0],
+				)
+				if err != nil {
+					continue
+				}
+				name, err := smgr.AccountName(addrmgrNs, acct)
+				if err != nil || name != accountFilter {
+					continue
+				}
+			}
+
+			utxo := &lnwallet.Utxo{
+				AddressType:   addressType,
+				Value:         credit.Amount,
+				Confirmations: int64(confs),
+				PkScript:      credit.PkScript,
+				OutPoint:      credit.OutPoint,
+				Frozen:        true,
+			}
+			if meta, ok := metas[credit.OutPoint]; ok {
+				utxo.Label = string(meta.label)
+			}
+
+			frozen = append(frozen, utxo)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return frozen, nil
+}
+
+// LabelOutput attaches a label to an output of the wallet. If the output
+// already has a label, this call will fail with lnwallet.ErrOutputLabelExists
+// unless the overwrite parameter is set. Labels must not be empty, and they
+// are limited to 500 chars.
+//
+// This is a part of the WalletController interface.
+func (b *BtcWallet) LabelOutput(op wire.OutPoint, label string,
+	overwrite bool) error {
+
+	return b.updateOutputMeta(op, func(tx walletdb.ReadWriteTx,
+		meta *outputMeta) error {
+
+		// We only allow labelling outputs that belong to the wallet.
+		if _, err := b.fetchUnspentCredit(tx, op); err != nil {
+			return err
+		}
+
+		if len(meta.label) != 0 && !overwrite {
+			return lnwallet.ErrOutputLabelExists
+		}
+
+		meta.label = []byte(label)
+
+		return nil
+	})
+}
+
+// scriptAddressType returns the wallet address type of the given output
+// script, or lnwallet.UnknownAddressType if the script is not one of the
+// witness output types we support for coin selection.
+func scriptAddressType(pkScript []byte) lnwallet.AddressType {
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		return lnwallet.WitnessPubKey
+
+	// TODO(roasbeef): This assumes all p2sh outputs returned by the wallet
+	// are nested p2pkh. We can't check the redeem script because the
+	// btcwallet service does not include it.
+	case txscript.IsPayToScriptHash(pkScript):
+		return lnwallet.NestedWitnessPubKey
+
+	case txscript.IsPayToTaproot(pkScript):
+		return lnwallet.TaprootPubkey
+
+	default:
+		return lnwallet.UnknownAddressType
+	}
+}
+
+// frozenBalance returns the total value of all frozen outputs that match the
+// given confirmation and account constraints.
+func (b *BtcWallet) frozenBalance(minConfs, maxConfs int32,
+	accountFilter string) (btcutil.Amount, error) {
+
+	frozen, err := b.ListFrozenOutputs(minConfs, maxConfs, accountFilter)
+	if err != nil {
+		return 0, err
+	}
+
+	var balance btcutil.Amount
+	for _, utxo := range frozen {
+		balance += utxo.Value
+	}
+
+	return balance, nil
+}
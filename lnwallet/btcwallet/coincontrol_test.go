@@ -0,0 +1,208 @@
+package btcwallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/chain"
+	"github.com/btcsuite/btcwallet/wtxmgr"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOutputMetaEncoding makes sure the coin control metadata of an output
+// survives a serialization round trip.
+func TestOutputMetaEncoding(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		meta outputMeta
+	}{{
+		name: "label only",
+		meta: outputMeta{label: []byte("cold storage")},
+	}, {
+		name: "frozen only",
+		meta: outputMeta{label: []byte{}, frozen: 1},
+	}, {
+		name: "label and frozen",
+		meta: outputMeta{label: []byte("escrow"), frozen: 1},
+	}}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			require.NoError(t, tc.meta.encode(&b))
+
+			decoded := outputMeta{}
+			require.NoError(t, decoded.decode(&b))
+			require.Equal(t, tc.meta, decoded)
+		})
+	}
+}
+
+// TestOutpointKey asserts that outpoints are serialized as their txid followed
+// by their big endian output index.
+func TestOutpointKey(t *testing.T) {
+	t.Parallel()
+
+	op := wire.OutPoint{
+		Hash:  chainhash.Hash{1, 2, 3},
+		Index: 7,
+	}
+
+	key := outpointKey(op)
+	require.Len(t, key, 36)
+	require.Equal(t, op.Hash[:], key[:32])
+	require.Equal(t, op.Index, binary.BigEndian.Uint32(key[32:]))
+}
+
+// TestFreezeOutput tests freezing and unfreezing outputs of a real wallet,
+// making sure frozen outputs are excluded from coin selection, still count
+// towards the confirmed balance and stay frozen across a restart.
+func TestFreezeOutput(t *testing.T) {
+	netParams := &chaincfg.RegressionNetParams
+	chainBackend, miner, backendCleanup := getChainBackend(t, netParams)
+	t.Cleanup(backendCleanup)
+
+	dbDir := t.TempDir()
+	w := startTestWallet(t, netParams, seedBytes, chainBackend, dbDir)
+
+	// Fund the wallet with two confirmed outputs.
+	addr, err := w.NewAddress(
+		lnwallet.WitnessPubKey, false, lnwallet.DefaultAccountName,
+	)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	_, err = miner.SendOutputs([]*wire.TxOut{{
+		Value:    btcutil.SatoshiPerBitcoin,
+		PkScript: pkScript,
+	}, {
+		Value:    2 * btcutil.SatoshiPerBitcoin,
+		PkScript: pkScript,
+	}}, 1)
+	require.NoError(t, err)
+	_, err = miner.Client.Generate(1)
+	require.NoError(t, err)
+
+	var utxos []*lnwallet.Utxo
+	require.Eventually(t, func() bool {
+		utxos, err = w.ListUnspentWitness(1, math.MaxInt32, "")
+		require.NoError(t, err)
+
+		return len(utxos) == 2
+	}, time.Minute, 50*time.Millisecond)
+
+	const totalBalance = 3 * btcutil.SatoshiPerBitcoin
+	balance, err := w.ConfirmedBalance(1, "")
+	require.NoError(t, err)
+	require.EqualValues(t, totalBalance, balance)
+
+	frozenOp, otherOp := utxos[0].OutPoint, utxos[1].OutPoint
+	frozenValue := utxos[0].Value
+
+	// Neither unknown outputs nor outputs that aren't frozen can be
+	// frozen or unfrozen.
+	unknownOp := wire.OutPoint{Hash: chainhash.Hash{1}, Index: 1}
+	require.ErrorIs(t, w.FreezeOutput(unknownOp), wtxmgr.ErrUnknownOutput)
+	require.ErrorIs(
+		t, w.UnfreezeOutput(frozenOp), lnwallet.ErrOutputNotFrozen,
+	)
+
+	// Leased outputs and outputs locked by an ongoing coin selection
+	// can't be frozen either.
+	lockID := wtxmgr.LockID{1}
+	_, _, _, err = w.LeaseOutput(lockID, otherOp, time.Hour)
+	require.NoError(t, err)
+	require.ErrorIs(
+		t, w.FreezeOutput(otherOp), wtxmgr.ErrOutputAlreadyLocked,
+	)
+	require.NoError(t, w.ReleaseOutput(lockID, otherOp))
+
+	w.LockOutpoint(otherOp)
+	require.ErrorIs(t, w.FreezeOutput(otherOp), lnwallet.ErrOutputLocked)
+	w.UnlockOutpoint(otherOp)
+
+	// assertFrozen makes sure the frozen output is only returned as a
+	// frozen output, while still being part of the confirmed balance.
+	assertFrozen := func(w *BtcWallet) {
+		t.Helper()
+
+		unspent, err := w.ListUnspentWitness(1, math.MaxInt32, "")
+		require.NoError(t, err)
+		require.Len(t, unspent, 1)
+		require.Equal(t, otherOp, unspent[0].OutPoint)
+
+		frozen, err := w.ListFrozenOutputs(1, math.MaxInt32, "")
+		require.NoError(t, err)
+		require.Len(t, frozen, 1)
+		require.Equal(t, frozenOp, frozen[0].OutPoint)
+		require.Equal(t, frozenValue, frozen[0].Value)
+		require.True(t, frozen[0].Frozen)
+
+		balance, err := w.ConfirmedBalance(1, "")
+		require.NoError(t, err)
+		require.EqualValues(t, totalBalance, balance)
+	}
+
+	// Freezing an output removes it from coin selection. Freezing it a
+	// second time is a no-op.
+	require.NoError(t, w.FreezeOutput(frozenOp))
+	require.NoError(t, w.FreezeOutput(frozenOp))
+	assertFrozen(w)
+
+	// Releasing the lock of a coin selection attempt must not unlock a
+	// frozen output.
+	w.UnlockOutpoint(frozenOp)
+	assertFrozen(w)
+
+	// Restart the wallet on top of the same database. The output must
+	// still be frozen.
+	require.NoError(t, w.Stop())
+	require.NoError(t, w.InternalWallet().Database().Close())
+
+	rpcConfig := miner.RPCConfig()
+	chainBackend, err = chain.NewRPCClient(
+		netParams, rpcConfig.Host, rpcConfig.User, rpcConfig.Pass,
+		rpcConfig.Certificates, false, 20,
+	)
+	require.NoError(t, err)
+
+	w = startTestWallet(t, netParams, seedBytes, chainBackend, dbDir)
+	t.Cleanup(func() {
+		require.NoError(t, w.Stop())
+	})
+	assertFrozen(w)
+
+	// Once unfrozen, the output is available for coin selection again.
+	require.NoError(t, w.UnfreezeOutput(frozenOp))
+	require.ErrorIs(
+		t, w.UnfreezeOutput(frozenOp), lnwallet.ErrOutputNotFrozen,
+	)
+
+	unspent, err := w.ListUnspentWitness(1, math.MaxInt32, "")
+	require.NoError(t, err)
+	require.Len(t, unspent, 2)
+
+	frozen, err := w.ListFrozenOutputs(1, math.MaxInt32, "")
+	require.NoError(t, err)
+	require.Empty(t, frozen)
+
+	balance, err = w.ConfirmedBalance(1, "")
+	require.NoError(t, err)
+	require.EqualValues(t, totalBalance, balance)
+}
@@ -201,6 +201,19 @@ func (b *BtcWallet) deriveKeyByBIP32Path(path []uint32) (*btcec.PrivateKey,
 	return privKey, nil
 }
 
+// DerivePubKeyByBIP32Path derives the public key described by a BIP32 path.
+// The path must be in one of the key scopes the wallet can sign for.
+func (b *BtcWallet) DerivePubKeyByBIP32Path(path []uint32) (*btcec.PublicKey,
+	error) {
+
+	privKey, err := b.deriveKeyByBIP32Path(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return privKey.PubKey(), nil
+}
+
 // assertHardened makes sure each given element is >= 2^31.
 func assertHardened(elements ...uint32) error {
 	for idx, element := range elements {
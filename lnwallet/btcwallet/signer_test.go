@@ -287,7 +287,20 @@ func newTestWallet(t *testing.T, netParams *chaincfg.Params,
 	chainBackend, miner, backendCleanup := getChainBackend(t, netParams)
 	t.Cleanup(backendCleanup)
 
-	loaderOpt := LoaderWithLocalWalletDB(t.TempDir(), false, time.Minute)
+	w := startTestWallet(
+		t, netParams, seedBytes, chainBackend, t.TempDir(),
+	)
+
+	return w, miner
+}
+
+// startTestWallet creates or opens the wallet stored in the given directory
+// and starts it on top of the given chain backend.
+func startTestWallet(t *testing.T, netParams *chaincfg.Params,
+	seedBytes []byte, chainBackend chain.Interface,
+	dbDir string) *BtcWallet {
+
+	loaderOpt := LoaderWithLocalWalletDB(dbDir, false, time.Minute)
 	config := Config{
 		PrivatePass: []byte("some-pass"),
 		HdSeed:      seedBytes,
@@ -309,7 +322,7 @@ func newTestWallet(t *testing.T, netParams *chaincfg.Params,
 		t.Fatalf("starting wallet failed: %v", err)
 	}
 
-	return w, miner
+	return w
 }
 
 // getChainBackend returns a simple btcd based chain backend to back the wallet.
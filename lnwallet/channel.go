@@ -124,10 +124,37 @@ type ErrCommitSyncLocalDataLoss struct {
 	// CommitPoint is the last unrevoked commit point, sent to us by the
 	// remote when we determined we had lost state.
 	CommitPoint *btcec.PublicKey
+
+	// RemoteCommitTailHeight is the height of our local commitment chain
+	// as reported by the remote party.
+	RemoteCommitTailHeight uint64
+
+	// RestoredCommitHeight is the height of our local commitment chain as
+	// recorded within the static channel backup the channel was restored
+	// from. This is zero if the channel wasn't restored from a backup
+	// that carried this information.
+	RestoredCommitHeight uint64
+}
+
+// OutdatedRemoteState returns true if the remote party reported a height for
+// our local commitment chain that is below the one recorded in the channel
+// backup we restored from. This means the remote is likely attempting to
+// close the channel with an outdated state.
+func (e *ErrCommitSyncLocalDataLoss) OutdatedRemoteState() bool {
+	return e.RemoteCommitTailHeight < e.RestoredCommitHeight
 }
 
 // Error returns a string representation of the local data loss error.
 func (e *ErrCommitSyncLocalDataLoss) Error() string {
+	if e.OutdatedRemoteState() {
+		return fmt.Sprintf("ChannelPoint(%v) with CommitPoint(%x) "+
+			"had possible local commitment state data loss, "+
+			"remote reported commit height %v below the backup "+
+			"height %v", e.ChannelPoint,
+			e.CommitPoint.SerializeCompressed(),
+			e.RemoteCommitTailHeight, e.RestoredCommitHeight)
+	}
+
 	return fmt.Sprintf("ChannelPoint(%v) with CommitPoint(%x) had "+
 		"possible local commitment state data loss", e.ChannelPoint,
 		e.CommitPoint.SerializeCompressed())
@@ -3947,12 +3974,21 @@ func (lc *LightningChannel) ProcessChanSyncMsg(
 		}
 
 		// In this case, we've likely lost data and shouldn't proceed
-		// with channel updates.
-		return nil, nil, nil, &ErrCommitSyncLocalDataLoss{
-			ChannelPoint: lc.channelState.FundingOutpoint,
-			CommitPoint:  msg.LocalUnrevokedCommitPoint,
+		// with channel updates. If the backup we restored from
+		// recorded our commitment height, we also pass it along so the
+		// caller can detect a remote reporting an outdated state.
+		dataLossErr := &ErrCommitSyncLocalDataLoss{
+			ChannelPoint:           lc.channelState.FundingOutpoint,
+			CommitPoint:            msg.LocalUnrevokedCommitPoint,
+			RemoteCommitTailHeight: msg.RemoteCommitTailHeight,
+		}
+		if isRestoredChan {
+			dataLossErr.RestoredCommitHeight =
+				lc.channelState.RestoredCommitHeight
 		}
 
+		return nil, nil, nil, dataLossErr
+
 	// If the height of our commitment chain reported by the remote party
 	// is behind our view of the chain, then they probably lost some state,
 	// and we'll force close the channel.
@@ -4514,6 +4514,49 @@ func TestChanSyncInvalidLastSecret(t *testing.T) {
 	}
 }
 
+// TestChanSyncRestoredOutdatedRemote tests that a restored channel reports
+// whether the remote party claims a height for our commitment chain that is
+// below the one recorded in the backup the channel was restored from.
+func TestChanSyncRestoredOutdatedRemote(t *testing.T) {
+	t.Parallel()
+
+	aliceChannel, bobChannel, err := CreateTestChannels(
+		t, channeldb.SingleFunderBit,
+	)
+	require.NoError(t, err, "unable to create test channels")
+
+	// Advance the commitment chains by one state, such that Bob believes
+	// Alice's commitment chain to be at height one.
+	require.NoError(t, ForceStateTransition(aliceChannel, bobChannel))
+
+	bobChanSync, err := bobChannel.channelState.ChanSyncMsg()
+	require.NoError(t, err, "unable to generate chan sync msg")
+	require.EqualValues(t, 1, bobChanSync.RemoteCommitTailHeight)
+
+	err = aliceChannel.channelState.ApplyChanStatus(
+		channeldb.ChanStatusRestored,
+	)
+	require.NoError(t, err, "unable to apply channel state")
+
+	// If the backup recorded the same height Bob reports, the remote
+	// state isn't considered outdated.
+	aliceChannel.channelState.RestoredCommitHeight = 1
+	_, _, _, err = aliceChannel.ProcessChanSyncMsg(bobChanSync)
+	var dataLossErr *ErrCommitSyncLocalDataLoss
+	require.ErrorAs(t, err, &dataLossErr)
+	require.False(t, dataLossErr.OutdatedRemoteState())
+
+	// If the backup recorded a higher height, Bob is likely using an
+	// outdated state, which must be reported.
+	aliceChannel.channelState.RestoredCommitHeight = 2
+	_, _, _, err = aliceChannel.ProcessChanSyncMsg(bobChanSync)
+	require.ErrorAs(t, err, &dataLossErr)
+	require.True(t, dataLossErr.OutdatedRemoteState())
+	require.EqualValues(t, 1, dataLossErr.RemoteCommitTailHeight)
+	require.EqualValues(t, 2, dataLossErr.RestoredCommitHeight)
+	require.Contains(t, err.Error(), "below the backup height 2")
+}
+
 // TestChanAvailableBandwidth tests the accuracy of the AvailableBalance()
 // method. The value returned from this message should reflect the value
 // returned within the commitment state of a channel after the transition is
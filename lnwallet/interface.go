@@ -69,6 +69,19 @@ var (
 	// ErrNotMine is an error denoting that a WalletController instance is
 	// unable to spend a specified output.
 	ErrNotMine = errors.New("the passed output doesn't belong to the wallet")
+
+	// ErrOutputLabelExists is returned from LabelOutput if the output
+	// already has a label and the overwrite flag was not set.
+	ErrOutputLabelExists = errors.New("output already has a label")
+
+	// ErrOutputLocked is returned from FreezeOutput if the output is
+	// currently locked by an ongoing coin selection attempt.
+	ErrOutputLocked = errors.New("output is currently locked for coin " +
+		"selection")
+
+	// ErrOutputNotFrozen is returned from UnfreezeOutput if the output
+	// isn't frozen.
+	ErrOutputNotFrozen = errors.New("output is not frozen")
 )
 
 // ErrNoOutputs is returned if we try to create a transaction with no outputs
@@ -115,6 +128,14 @@ type Utxo struct {
 	wire.OutPoint
 	Derivation *psbt.Bip32Derivation
 	PrevTx     *wire.MsgTx
+
+	// Label is the optional label that was attached to the output through
+	// LabelOutput.
+	Label string
+
+	// Frozen indicates that the output has been frozen through
+	// FreezeOutput and won't be considered for coin selection.
+	Frozen bool
 }
 
 // OutputDetail contains additional information on a destination address.
@@ -415,6 +436,37 @@ type WalletController interface {
 	// ListLeasedOutputs returns a list of all currently locked outputs.
 	ListLeasedOutputs() ([]*base.ListLeasedOutputResult, error)
 
+	// FreezeOutput freezes an output of the wallet indefinitely, excluding
+	// it from any future coin selection attempts. Contrary to a lease, the
+	// freeze doesn't expire and is persisted across restarts.
+	//
+	// If the output is not known, wtxmgr.ErrUnknownOutput is returned. If
+	// the output is currently leased or locked, then
+	// wtxmgr.ErrOutputAlreadyLocked or ErrOutputLocked is returned.
+	//
+	// NOTE: This method requires the global coin selection lock to be held.
+	FreezeOutput(op wire.OutPoint) error
+
+	// UnfreezeOutput removes the freeze of an output, allowing it to be
+	// selected for coin selection again if it remains unspent.
+	//
+	// NOTE: This method requires the global coin selection lock to be held.
+	UnfreezeOutput(op wire.OutPoint) error
+
+	// ListFrozenOutputs returns all unspent witness outputs of the wallet
+	// that are currently frozen and would otherwise be returned by
+	// ListUnspentWitness for the same set of arguments.
+	//
+	// NOTE: This method requires the global coin selection lock to be held.
+	ListFrozenOutputs(minConfs, maxConfs int32,
+		accountFilter string) ([]*Utxo, error)
+
+	// LabelOutput attaches a label to an output of the wallet. If the
+	// output already has a label, this call will fail with
+	// ErrOutputLabelExists unless the overwrite parameter is set. Labels
+	// must not be empty, and they are limited to 500 chars.
+	LabelOutput(op wire.OutPoint, label string, overwrite bool) error
+
 	// PublishTransaction performs cursory validation (dust checks, etc),
 	// then finally broadcasts the passed transaction to the Bitcoin network.
 	// If the transaction is rejected because it is conflicting with an
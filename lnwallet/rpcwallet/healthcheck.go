@@ -1,33 +1,39 @@
 package rpcwallet
 
 import (
+	"context"
 	"fmt"
 	"time"
 
-	"github.com/lightningnetwork/lnd/lncfg"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"google.golang.org/grpc"
 )
 
-// HealthCheck returns a health check function for the given remote signing
-// configuration.
-func HealthCheck(cfg *lncfg.RemoteSigner, timeout time.Duration) func() error {
-	return func() error {
-		conn, err := connectRPC(
-			cfg.RPCHost, cfg.TLSCertPath, cfg.MacaroonPath, timeout,
-		)
-		if err != nil {
-			return fmt.Errorf("error connecting to the remote "+
-				"signing node through RPC: %v", err)
-		}
-
-		defer func() {
-			err = conn.Close()
-			if err != nil {
-				log.Warnf("Failed to close health check "+
-					"connection to remote signing node: %v",
-					err)
-			}
-		}()
+// checkSigner checks that the given remote signer can be reached and is ready
+// to sign. The connection is re-established if necessary.
+func checkSigner(ctx context.Context, signer *remoteSigner) error {
+	resp, err := signer.stateClient.GetState(
+		ctx, &lnrpc.GetStateRequest{}, grpc.WaitForReady(true),
+	)
+	if err != nil {
+		return fmt.Errorf("error connecting to the remote signing "+
+			"node %v through RPC: %v", signer.endpoint, err)
+	}
 
+	switch resp.State {
+	case lnrpc.WalletState_RPC_ACTIVE, lnrpc.WalletState_SERVER_ACTIVE:
 		return nil
+
+	default:
+		return fmt.Errorf("remote signing node %v is not ready, "+
+			"wallet state is %v", signer.endpoint, resp.State)
+	}
+}
+
+// HealthCheck returns a health check function for the remote signers of the
+// key ring. It only fails if none of the signers is reachable.
+func (r *RPCKeyRing) HealthCheck(timeout time.Duration) func() error {
+	return func() error {
+		return r.signers.checkSigners(timeout)
 	}
 }
@@ -0,0 +1,237 @@
+package rpcwallet
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// outboundDialTimeout is the timeout for a single attempt of a remote
+	// signer to connect to its watch-only node.
+	outboundDialTimeout = 10 * time.Second
+)
+
+// inboundListener accepts the connections a remote signer opens to the
+// watch-only node. The gRPC client of the watch-only node uses them as if it
+// had dialed the signer itself, so TLS and macaroon authentication work the
+// same way as for an outbound connection.
+type inboundListener struct {
+	listener net.Listener
+
+	conns chan net.Conn
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newInboundListener starts listening for remote signer connections on the
+// given address.
+func newInboundListener(addr string) (*inboundListener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &inboundListener{
+		listener: listener,
+		conns:    make(chan net.Conn),
+		quit:     make(chan struct{}),
+	}
+
+	l.wg.Add(1)
+	go l.acceptConnections()
+
+	log.Infof("Listening for remote signer connections on %v",
+		listener.Addr())
+
+	return l, nil
+}
+
+// acceptConnections accepts connections until the listener is closed and
+// hands each of them to the next dial attempt.
+//
+// NOTE: This MUST be run as a goroutine.
+func (l *inboundListener) acceptConnections() {
+	defer l.wg.Done()
+
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			select {
+			case <-l.quit:
+				return
+			default:
+			}
+
+			log.Errorf("Unable to accept remote signer "+
+				"connection: %v", err)
+
+			select {
+			case <-time.After(time.Second):
+				continue
+			case <-l.quit:
+				return
+			}
+		}
+
+		log.Debugf("Accepted remote signer connection from %v",
+			conn.RemoteAddr())
+
+		select {
+		case l.conns <- conn:
+		case <-l.quit:
+			_ = conn.Close()
+			return
+		}
+	}
+}
+
+// dial returns the next connection opened by the remote signer, waiting for
+// one if necessary. It is used as the context dialer of the gRPC client.
+func (l *inboundListener) dial(ctx context.Context, _ string) (net.Conn,
+	error) {
+
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case <-l.quit:
+		return nil, net.ErrClosed
+	}
+}
+
+// close stops accepting connections.
+func (l *inboundListener) close() error {
+	close(l.quit)
+	err := l.listener.Close()
+	l.wg.Wait()
+
+	return err
+}
+
+// OutboundListener is a net.Listener for the RPC server of a remote signer
+// that connects to its watch-only node instead of being dialed by it. Rather
+// than accepting connections, it dials the watch-only node and returns the
+// established connection. Only one connection is kept open at a time, once it
+// is closed the next call to Accept connects again.
+type OutboundListener struct {
+	addr          string
+	retryInterval time.Duration
+
+	// done is closed once the current connection is closed. It is only
+	// accessed by Accept, which the RPC server calls from a single
+	// goroutine.
+	done chan struct{}
+
+	quit      chan struct{}
+	closeOnce sync.Once
+}
+
+// A compile-time check to ensure OutboundListener implements net.Listener.
+var _ net.Listener = (*OutboundListener)(nil)
+
+// NewOutboundListener creates a listener that connects to the watch-only node
+// at the given address, retrying at the given interval.
+func NewOutboundListener(addr string,
+	retryInterval time.Duration) *OutboundListener {
+
+	return &OutboundListener{
+		addr:          addr,
+		retryInterval: retryInterval,
+		quit:          make(chan struct{}),
+	}
+}
+
+// Accept waits for the current connection to be closed and then connects to
+// the watch-only node again.
+//
+// NOTE: This is part of the net.Listener interface.
+func (l *OutboundListener) Accept() (net.Conn, error) {
+	select {
+	case <-l.quit:
+		return nil, net.ErrClosed
+	default:
+	}
+
+	if l.done != nil {
+		select {
+		case <-l.done:
+		case <-l.quit:
+			return nil, net.ErrClosed
+		}
+	}
+
+	for {
+		conn, err := net.DialTimeout("tcp", l.addr, outboundDialTimeout)
+		if err == nil {
+			log.Infof("Connected to watch-only node at %v", l.addr)
+
+			l.done = make(chan struct{})
+			return &notifyConn{Conn: conn, done: l.done}, nil
+		}
+
+		log.Warnf("Unable to connect to watch-only node at %v, "+
+			"retrying in %v: %v", l.addr, l.retryInterval, err)
+
+		select {
+		case <-time.After(l.retryInterval):
+		case <-l.quit:
+			return nil, net.ErrClosed
+		}
+	}
+}
+
+// Close stops connecting to the watch-only node. The current connection is
+// left open, it is closed by the RPC server.
+//
+// NOTE: This is part of the net.Listener interface.
+func (l *OutboundListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.quit)
+	})
+
+	return nil
+}
+
+// Addr returns the address of the watch-only node.
+//
+// NOTE: This is part of the net.Listener interface.
+func (l *OutboundListener) Addr() net.Addr {
+	return outboundAddr(l.addr)
+}
+
+// outboundAddr is the net.Addr of an OutboundListener.
+type outboundAddr string
+
+// Network returns the name of the network.
+func (a outboundAddr) Network() string {
+	return "tcp"
+}
+
+// String returns the address of the watch-only node.
+func (a outboundAddr) String() string {
+	return string(a)
+}
+
+// notifyConn is a net.Conn that signals when it is closed.
+type notifyConn struct {
+	net.Conn
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Close closes the connection and signals that it is closed.
+func (c *notifyConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+
+	return err
+}
@@ -8,7 +8,6 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
@@ -60,10 +59,7 @@ type RPCKeyRing struct {
 
 	netParams *chaincfg.Params
 
-	rpcTimeout time.Duration
-
-	signerClient signrpc.SignerClient
-	walletClient walletrpc.WalletKitClient
+	signers *signerSet
 }
 
 var _ keychain.SecretKeyRing = (*RPCKeyRing)(nil)
@@ -74,30 +70,50 @@ var _ lnwallet.WalletController = (*RPCKeyRing)(nil)
 // NewRPCKeyRing creates a new remote signing secret key ring that uses the
 // given watch-only base wallet to keep track of addresses and transactions but
 // delegates any signing or ECDH operations to the remove signer through RPC.
+// If multiple remote signers are configured, requests are sent to the first
+// reachable one.
 func NewRPCKeyRing(watchOnlyKeyRing keychain.SecretKeyRing,
 	watchOnlyWalletController lnwallet.WalletController,
 	remoteSigner *lncfg.RemoteSigner,
 	netParams *chaincfg.Params) (*RPCKeyRing, error) {
 
-	rpcConn, err := connectRPC(
-		remoteSigner.RPCHost, remoteSigner.TLSCertPath,
-		remoteSigner.MacaroonPath, remoteSigner.Timeout,
-	)
+	signers, err := newSignerSet(remoteSigner)
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to the remote "+
-			"signing node through RPC: %v", err)
+		return nil, err
 	}
 
 	return &RPCKeyRing{
 		WalletController: watchOnlyWalletController,
 		watchOnlyKeyRing: watchOnlyKeyRing,
 		netParams:        netParams,
-		rpcTimeout:       remoteSigner.Timeout,
-		signerClient:     signrpc.NewSignerClient(rpcConn),
-		walletClient:     walletrpc.NewWalletKitClient(rpcConn),
+		signers:          signers,
 	}, nil
 }
 
+// Start starts the watch-only wallet and the periodic health checks of the
+// remote signers.
+//
+// NOTE: This is a part of the WalletController interface.
+func (r *RPCKeyRing) Start() error {
+	if err := r.WalletController.Start(); err != nil {
+		return err
+	}
+
+	r.signers.start()
+
+	return nil
+}
+
+// Stop stops the health checks, closes the connections to all remote signers
+// and stops the watch-only wallet.
+//
+// NOTE: This is a part of the WalletController interface.
+func (r *RPCKeyRing) Stop() error {
+	r.signers.stop()
+
+	return r.WalletController.Stop()
+}
+
 // NewAddress returns the next external or internal address for the
 // wallet dictated by the value of the `change` parameter. If change is
 // true, then an internal address should be used, otherwise an external
@@ -189,19 +205,26 @@ func (r *RPCKeyRing) SendOutputs(outputs []*wire.TxOut,
 // input/output/fee value validation, PSBT finalization). Any input that is
 // incomplete will be skipped.
 func (r *RPCKeyRing) SignPsbt(packet *psbt.Packet) ([]uint32, error) {
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
+	r.addOutputDerivations(packet)
 
 	var buf bytes.Buffer
 	if err := packet.Serialize(&buf); err != nil {
 		return nil, fmt.Errorf("error serializing PSBT: %v", err)
 	}
 
-	resp, err := r.walletClient.SignPsbt(ctxt, &walletrpc.SignPsbtRequest{
-		FundedPsbt: buf.Bytes(),
+	var resp *walletrpc.SignPsbtResponse
+	_, err := r.signers.call(func(ctx context.Context,
+		signer *remoteSigner) error {
+
+		var err error
+		resp, err = signer.walletClient.SignPsbt(
+			ctx, &walletrpc.SignPsbtRequest{
+				FundedPsbt: buf.Bytes(),
+			},
+		)
+		return err
 	})
 	if err != nil {
-		considerShutdown(err)
 		return nil, fmt.Errorf("error signing PSBT in remote signer "+
 			"instance: %v", err)
 	}
@@ -390,9 +413,6 @@ func (r *RPCKeyRing) DeriveKey(
 func (r *RPCKeyRing) ECDH(keyDesc keychain.KeyDescriptor,
 	pubKey *btcec.PublicKey) ([32]byte, error) {
 
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
-
 	key := [32]byte{}
 	req := &signrpc.SharedKeyRequest{
 		EphemeralPubkey: pubKey.SerializeCompressed(),
@@ -408,9 +428,15 @@ func (r *RPCKeyRing) ECDH(keyDesc keychain.KeyDescriptor,
 		req.KeyDesc.RawKeyBytes = keyDesc.PubKey.SerializeCompressed()
 	}
 
-	resp, err := r.signerClient.DeriveSharedKey(ctxt, req)
+	var resp *signrpc.SharedKeyResponse
+	_, err := r.signers.call(func(ctx context.Context,
+		signer *remoteSigner) error {
+
+		var err error
+		resp, err = signer.signerClient.DeriveSharedKey(ctx, req)
+		return err
+	})
 	if err != nil {
-		considerShutdown(err)
 		return key, fmt.Errorf("error deriving shared key in remote "+
 			"signer instance: %v", err)
 	}
@@ -428,19 +454,24 @@ func (r *RPCKeyRing) ECDH(keyDesc keychain.KeyDescriptor,
 func (r *RPCKeyRing) SignMessage(keyLoc keychain.KeyLocator,
 	msg []byte, doubleHash bool) (*ecdsa.Signature, error) {
 
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
-
-	resp, err := r.signerClient.SignMessage(ctxt, &signrpc.SignMessageReq{
+	req := &signrpc.SignMessageReq{
 		Msg: msg,
 		KeyLoc: &signrpc.KeyLocator{
 			KeyFamily: int32(keyLoc.Family),
 			KeyIndex:  int32(keyLoc.Index),
 		},
 		DoubleHash: doubleHash,
+	}
+
+	var resp *signrpc.SignMessageResp
+	_, err := r.signers.call(func(ctx context.Context,
+		signer *remoteSigner) error {
+
+		var err error
+		resp, err = signer.signerClient.SignMessage(ctx, req)
+		return err
 	})
 	if err != nil {
-		considerShutdown(err)
 		return nil, fmt.Errorf("error signing message in remote "+
 			"signer instance: %v", err)
 	}
@@ -465,10 +496,7 @@ func (r *RPCKeyRing) SignMessageCompact(keyLoc keychain.KeyLocator,
 			"locator %v, can only sign with node key", keyLoc)
 	}
 
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
-
-	resp, err := r.signerClient.SignMessage(ctxt, &signrpc.SignMessageReq{
+	req := &signrpc.SignMessageReq{
 		Msg: msg,
 		KeyLoc: &signrpc.KeyLocator{
 			KeyFamily: int32(keyLoc.Family),
@@ -476,9 +504,17 @@ func (r *RPCKeyRing) SignMessageCompact(keyLoc keychain.KeyLocator,
 		},
 		DoubleHash: doubleHash,
 		CompactSig: true,
+	}
+
+	var resp *signrpc.SignMessageResp
+	_, err := r.signers.call(func(ctx context.Context,
+		signer *remoteSigner) error {
+
+		var err error
+		resp, err = signer.signerClient.SignMessage(ctx, req)
+		return err
 	})
 	if err != nil {
-		considerShutdown(err)
 		return nil, fmt.Errorf("error signing message in remote "+
 			"signer instance: %v", err)
 	}
@@ -498,10 +534,7 @@ func (r *RPCKeyRing) SignMessageSchnorr(keyLoc keychain.KeyLocator,
 	msg []byte, doubleHash bool, taprootTweak []byte) (*schnorr.Signature,
 	error) {
 
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
-
-	resp, err := r.signerClient.SignMessage(ctxt, &signrpc.SignMessageReq{
+	req := &signrpc.SignMessageReq{
 		Msg: msg,
 		KeyLoc: &signrpc.KeyLocator{
 			KeyFamily: int32(keyLoc.Family),
@@ -510,9 +543,17 @@ func (r *RPCKeyRing) SignMessageSchnorr(keyLoc keychain.KeyLocator,
 		DoubleHash:         doubleHash,
 		SchnorrSig:         true,
 		SchnorrSigTapTweak: taprootTweak,
+	}
+
+	var resp *signrpc.SignMessageResp
+	_, err := r.signers.call(func(ctx context.Context,
+		signer *remoteSigner) error {
+
+		var err error
+		resp, err = signer.signerClient.SignMessage(ctx, req)
+		return err
 	})
 	if err != nil {
-		considerShutdown(err)
 		return nil, fmt.Errorf("error signing message in remote "+
 			"signer instance: %v", err)
 	}
@@ -674,12 +715,15 @@ func (r *RPCKeyRing) MuSig2CreateSession(keyLoc keychain.KeyLocator,
 		}
 	}
 
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
+	var resp *signrpc.MuSig2SessionResponse
+	signer, err := r.signers.call(func(ctx context.Context,
+		signer *remoteSigner) error {
 
-	resp, err := r.signerClient.MuSig2CreateSession(ctxt, req)
+		var err error
+		resp, err = signer.signerClient.MuSig2CreateSession(ctx, req)
+		return err
+	})
 	if err != nil {
-		considerShutdown(err)
 		return nil, fmt.Errorf("error creating MuSig2 session in "+
 			"remote signer instance: %v", err)
 	}
@@ -692,6 +736,10 @@ func (r *RPCKeyRing) MuSig2CreateSession(keyLoc keychain.KeyLocator,
 	copy(info.SessionID[:], resp.SessionId)
 	copy(info.PublicNonce[:], resp.LocalPublicNonces)
 
+	// All further requests of the session need to go to the signer that
+	// holds its state.
+	r.signers.addSession(info.SessionID, signer)
+
 	info.CombinedKey, err = schnorr.ParsePubKey(resp.CombinedKey)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing combined key: %v", err)
@@ -727,12 +775,15 @@ func (r *RPCKeyRing) MuSig2RegisterNonces(sessionID input.MuSig2SessionID,
 		copy(req.OtherSignerPublicNonces[idx], nonce[:])
 	}
 
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
+	var resp *signrpc.MuSig2RegisterNoncesResponse
+	err := r.signers.callSession(sessionID, func(ctx context.Context,
+		signer *remoteSigner) error {
 
-	resp, err := r.signerClient.MuSig2RegisterNonces(ctxt, req)
+		var err error
+		resp, err = signer.signerClient.MuSig2RegisterNonces(ctx, req)
+		return err
+	})
 	if err != nil {
-		considerShutdown(err)
 		return false, fmt.Errorf("error registering MuSig2 nonces in "+
 			"remote signer instance: %v", err)
 	}
@@ -758,16 +809,23 @@ func (r *RPCKeyRing) MuSig2Sign(sessionID input.MuSig2SessionID,
 		Cleanup:       cleanUp,
 	}
 
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
+	var resp *signrpc.MuSig2SignResponse
+	err := r.signers.callSession(sessionID, func(ctx context.Context,
+		signer *remoteSigner) error {
 
-	resp, err := r.signerClient.MuSig2Sign(ctxt, req)
+		var err error
+		resp, err = signer.signerClient.MuSig2Sign(ctx, req)
+		return err
+	})
 	if err != nil {
-		considerShutdown(err)
 		return nil, fmt.Errorf("error signing MuSig2 session in "+
 			"remote signer instance: %v", err)
 	}
 
+	if cleanUp {
+		r.signers.removeSession(sessionID)
+	}
+
 	partialSig, err := input.DeserializePartialSignature(
 		resp.LocalPartialSignature,
 	)
@@ -802,12 +860,15 @@ func (r *RPCKeyRing) MuSig2CombineSig(sessionID input.MuSig2SessionID,
 		req.OtherPartialSignatures[idx] = rawSig[:]
 	}
 
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
+	var resp *signrpc.MuSig2CombineSigResponse
+	err := r.signers.callSession(sessionID, func(ctx context.Context,
+		signer *remoteSigner) error {
 
-	resp, err := r.signerClient.MuSig2CombineSig(ctxt, req)
+		var err error
+		resp, err = signer.signerClient.MuSig2CombineSig(ctx, req)
+		return err
+	})
 	if err != nil {
-		considerShutdown(err)
 		return nil, false, fmt.Errorf("error combining MuSig2 "+
 			"signatures in remote signer instance: %v", err)
 	}
@@ -818,6 +879,10 @@ func (r *RPCKeyRing) MuSig2CombineSig(sessionID input.MuSig2SessionID,
 		return nil, resp.HaveAllSignatures, nil
 	}
 
+	// The signer removes the session once the final signature is
+	// combined.
+	r.signers.removeSession(sessionID)
+
 	finalSig, err := schnorr.ParseSignature(resp.FinalSignature)
 	if err != nil {
 		return nil, false, fmt.Errorf("error parsing final signature: "+
@@ -833,12 +898,14 @@ func (r *RPCKeyRing) MuSig2Cleanup(sessionID input.MuSig2SessionID) error {
 		SessionId: sessionID[:],
 	}
 
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
+	err := r.signers.callSession(sessionID, func(ctx context.Context,
+		signer *remoteSigner) error {
 
-	_, err := r.signerClient.MuSig2Cleanup(ctxt, req)
+		_, err := signer.signerClient.MuSig2Cleanup(ctx, req)
+		return err
+	})
+	r.signers.removeSession(sessionID)
 	if err != nil {
-		considerShutdown(err)
 		return fmt.Errorf("error cleaning up MuSig2 session in remote "+
 			"signer instance: %v", err)
 	}
@@ -1114,19 +1181,26 @@ func (r *RPCKeyRing) remoteSign(tx *wire.MsgTx, signDesc *input.SignDescriptor,
 	}
 
 	// Okay, let's sign the input by the remote signer now.
-	ctxt, cancel := context.WithTimeout(context.Background(), r.rpcTimeout)
-	defer cancel()
+	r.addOutputDerivations(packet)
 
 	var buf bytes.Buffer
 	if err := packet.Serialize(&buf); err != nil {
 		return nil, fmt.Errorf("error serializing PSBT: %v", err)
 	}
 
-	resp, err := r.walletClient.SignPsbt(
-		ctxt, &walletrpc.SignPsbtRequest{FundedPsbt: buf.Bytes()},
-	)
+	var resp *walletrpc.SignPsbtResponse
+	_, err = r.signers.call(func(ctx context.Context,
+		signer *remoteSigner) error {
+
+		var err error
+		resp, err = signer.walletClient.SignPsbt(
+			ctx, &walletrpc.SignPsbtRequest{
+				FundedPsbt: buf.Bytes(),
+			},
+		)
+		return err
+	})
 	if err != nil {
-		considerShutdown(err)
 		return nil, fmt.Errorf("error signing PSBT in remote signer "+
 			"instance: %v", err)
 	}
@@ -1218,8 +1292,11 @@ func extractSignature(in *psbt.PInput,
 
 // connectRPC tries to establish an RPC connection to the given host:port with
 // the supplied certificate and macaroon.
-func connectRPC(hostPort, tlsCertPath, macaroonPath string,
-	timeout time.Duration) (*grpc.ClientConn, error) {
+func connectRPC(endpoint lncfg.RemoteSignerEndpoint,
+	inbound *inboundListener) (*grpc.ClientConn, error) {
+
+	tlsCertPath := endpoint.TLSCertPath
+	macaroonPath := endpoint.MacaroonPath
 
 	certBytes, err := ioutil.ReadFile(tlsCertPath)
 	if err != nil {
@@ -1253,11 +1330,21 @@ func connectRPC(hostPort, tlsCertPath, macaroonPath string,
 			cp, "",
 		)),
 		grpc.WithPerRPCCredentials(macCred),
-		grpc.WithBlock(),
 	}
-	ctxt, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	conn, err := grpc.DialContext(ctxt, hostPort, opts...)
+
+	// An inbound signer isn't dialed, instead we use the connections it
+	// opens to us. Its TLS certificate is verified for localhost, which
+	// lnd always includes in its certificate.
+	target := endpoint.RPCHost
+	if endpoint.Inbound {
+		target = "localhost"
+		opts = append(opts, grpc.WithContextDialer(inbound.dial))
+	}
+
+	// We don't block until the connection is established. The connection
+	// is checked by the health checks, and gRPC keeps re-establishing it
+	// in the background.
+	conn, err := grpc.Dial(target, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to connect to RPC server: %v",
 			err)
@@ -1266,6 +1353,46 @@ func connectRPC(hostPort, tlsCertPath, macaroonPath string,
 	return conn, nil
 }
 
+// addOutputDerivations adds the BIP32 derivation information to all outputs of
+// the packet that pay back to our wallet. A remote signer that enforces a
+// signing policy uses it to verify that these outputs are change and not sent
+// to someone else.
+func (r *RPCKeyRing) addOutputDerivations(packet *psbt.Packet) {
+	for idx, txOut := range packet.UnsignedTx.TxOut {
+		out := &packet.Outputs[idx]
+		if len(out.Bip32Derivation) > 0 ||
+			len(out.TaprootBip32Derivation) > 0 {
+
+			continue
+		}
+
+		addr, _, _, err := r.WalletController.ScriptForOutput(txOut)
+		if err != nil {
+			continue
+		}
+
+		scope, path, ok := addr.DerivationInfo()
+		if !ok {
+			continue
+		}
+
+		// The account number of the watch-only wallet's accounts is
+		// already hardened.
+		pubKey := addr.PubKey().SerializeCompressed()
+		out.Bip32Derivation = []*psbt.Bip32Derivation{{
+			PubKey:               pubKey,
+			MasterKeyFingerprint: path.MasterKeyFingerprint,
+			Bip32Path: []uint32{
+				scope.Purpose + hdkeychain.HardenedKeyStart,
+				scope.Coin + hdkeychain.HardenedKeyStart,
+				path.Account,
+				path.Branch,
+				path.Index,
+			},
+		}}
+	}
+}
+
 // packetFromTx creates a PSBT from a tx that potentially already contains
 // signed inputs.
 func packetFromTx(original *wire.MsgTx) (*psbt.Packet, error) {
@@ -1305,6 +1432,31 @@ func packetFromTx(original *wire.MsgTx) (*psbt.Packet, error) {
 	return packet, nil
 }
 
+// isConnectionError returns true if the error looks like a connection or
+// general availability error and not some application specific problem.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	statusErr, isStatusErr := status.FromError(err)
+	if !isStatusErr {
+		return false
+	}
+
+	switch statusErr.Code() {
+	case codes.DeadlineExceeded, codes.Unavailable:
+		return true
+
+	default:
+		return false
+	}
+}
+
 // considerShutdown inspects the error and issues a shutdown (through logging
 // a critical error, which will cause the logger to issue a clean shutdown
 // request) if the error looks like a connection or general availability error
@@ -0,0 +1,356 @@
+package rpcwallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lncfg"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/signrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"google.golang.org/grpc"
+)
+
+var (
+	// ErrUnknownMuSig2Session is returned if a MuSig2 session is used that
+	// wasn't created through the RPC key ring.
+	ErrUnknownMuSig2Session = errors.New("unknown MuSig2 session")
+)
+
+// remoteSigner is a single remote signer together with the RPC clients of its
+// connection.
+type remoteSigner struct {
+	endpoint lncfg.RemoteSignerEndpoint
+
+	conn *grpc.ClientConn
+
+	stateClient  lnrpc.StateClient
+	signerClient signrpc.SignerClient
+	walletClient walletrpc.WalletKitClient
+}
+
+// signerSet manages the connections to an ordered list of remote signers.
+// Requests are sent to the first signer that is considered healthy, and fail
+// over to the next one if the signer can't be reached. A signer is considered
+// unhealthy once a request to it fails with a connection error or its health
+// check fails, and healthy again once its health check succeeds. The
+// connections themselves are re-established by gRPC automatically.
+type signerSet struct {
+	signers []*remoteSigner
+
+	// inbound is the listener for the connections of an inbound signer.
+	// It is nil if no signer connects to us.
+	inbound *inboundListener
+
+	rpcTimeout    time.Duration
+	checkInterval time.Duration
+
+	mu sync.Mutex
+
+	// healthy holds the health of each signer.
+	healthy []bool
+
+	// active is the index of the signer that handled the last request.
+	active int
+
+	// sessions maps MuSig2 sessions to the signer that holds their state.
+	// Such requests can't fail over to another signer.
+	sessions map[input.MuSig2SessionID]*remoteSigner
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newSignerSet connects to all remote signers of the given configuration. An
+// error is returned if none of them can be reached within the configured
+// timeout.
+func newSignerSet(cfg *lncfg.RemoteSigner) (*signerSet, error) {
+	s := &signerSet{
+		rpcTimeout:    cfg.Timeout,
+		checkInterval: cfg.CheckInterval,
+		active:        -1,
+		sessions:      make(map[input.MuSig2SessionID]*remoteSigner),
+		quit:          make(chan struct{}),
+	}
+
+	if cfg.InboundListen != "" {
+		inbound, err := newInboundListener(cfg.InboundListen)
+		if err != nil {
+			return nil, fmt.Errorf("unable to listen for remote "+
+				"signer connections: %v", err)
+		}
+		s.inbound = inbound
+	}
+
+	for _, endpoint := range cfg.Endpoints() {
+		conn, err := connectRPC(endpoint, s.inbound)
+		if err != nil {
+			s.close()
+			return nil, fmt.Errorf("error connecting to the "+
+				"remote signing node %v through RPC: %v",
+				endpoint, err)
+		}
+
+		s.signers = append(s.signers, &remoteSigner{
+			endpoint:     endpoint,
+			conn:         conn,
+			stateClient:  lnrpc.NewStateClient(conn),
+			signerClient: signrpc.NewSignerClient(conn),
+			walletClient: walletrpc.NewWalletKitClient(conn),
+		})
+	}
+	s.healthy = make([]bool, len(s.signers))
+
+	if err := s.checkSigners(cfg.Timeout); err != nil {
+		s.close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// start starts the periodic health checks of all signers.
+func (s *signerSet) start() {
+	s.wg.Add(1)
+	go s.monitor()
+}
+
+// stop stops the health checks and closes all connections.
+func (s *signerSet) stop() {
+	close(s.quit)
+	s.wg.Wait()
+
+	s.close()
+}
+
+// close closes all connections.
+func (s *signerSet) close() {
+	for _, signer := range s.signers {
+		if err := signer.conn.Close(); err != nil {
+			log.Warnf("Unable to close connection to remote "+
+				"signer %v: %v", signer.endpoint, err)
+		}
+	}
+
+	if s.inbound != nil {
+		if err := s.inbound.close(); err != nil {
+			log.Warnf("Unable to close remote signer listener: %v",
+				err)
+		}
+	}
+}
+
+// monitor checks the health of all signers at the configured interval.
+//
+// NOTE: This MUST be run as a goroutine.
+func (s *signerSet) monitor() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.checkSigners(s.rpcTimeout); err != nil {
+				log.Errorf("Remote signer health check "+
+					"failed: %v", err)
+			}
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// checkSigners checks the health of all signers concurrently, with the given
+// timeout for each check. An error is returned if none of them is healthy.
+func (s *signerSet) checkSigners(timeout time.Duration) error {
+	errs := make([]error, len(s.signers))
+
+	var wg sync.WaitGroup
+	for idx, signer := range s.signers {
+		wg.Add(1)
+		go func(idx int, signer *remoteSigner) {
+			defer wg.Done()
+
+			ctxt, cancel := context.WithTimeout(
+				context.Background(), timeout,
+			)
+			defer cancel()
+
+			errs[idx] = checkSigner(ctxt, signer)
+		}(idx, signer)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	anyHealthy := false
+	for idx, err := range errs {
+		healthy := err == nil
+		anyHealthy = anyHealthy || healthy
+
+		switch {
+		case healthy && !s.healthy[idx]:
+			log.Infof("Remote signer %v is reachable",
+				s.signers[idx].endpoint)
+
+		case !healthy && s.healthy[idx]:
+			log.Warnf("Remote signer %v is unreachable: %v",
+				s.signers[idx].endpoint, err)
+		}
+
+		s.healthy[idx] = healthy
+	}
+
+	if !anyHealthy {
+		return fmt.Errorf("none of the remote signers is reachable: "+
+			"%v", errs[0])
+	}
+
+	return nil
+}
+
+// candidates returns all signers in the order they should be tried in. The
+// healthy signers come first, followed by the unhealthy ones in case they
+// became reachable again since their last check.
+func (s *signerSet) candidates() []*remoteSigner {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var healthy, unhealthy []*remoteSigner
+	for idx, signer := range s.signers {
+		if s.healthy[idx] {
+			healthy = append(healthy, signer)
+		} else {
+			unhealthy = append(unhealthy, signer)
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+// index returns the position of the given signer.
+func (s *signerSet) index(signer *remoteSigner) int {
+	for idx := range s.signers {
+		if s.signers[idx] == signer {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// succeeded records that the given signer handled a request.
+func (s *signerSet) succeeded(signer *remoteSigner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.index(signer)
+	s.healthy[idx] = true
+
+	if idx != s.active {
+		log.Infof("Using remote signer %v", signer.endpoint)
+		s.active = idx
+	}
+}
+
+// failed records that a request to the given signer failed. If it failed
+// because the signer couldn't be reached and no other signer is healthy
+// either, a shutdown is requested.
+func (s *signerSet) failed(signer *remoteSigner, err error) {
+	if !isConnectionError(err) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.index(signer)
+	if s.healthy[idx] {
+		log.Warnf("Remote signer %v is unreachable: %v",
+			signer.endpoint, err)
+	}
+	s.healthy[idx] = false
+
+	for _, healthy := range s.healthy {
+		if healthy {
+			return
+		}
+	}
+
+	considerShutdown(err)
+}
+
+// call sends a request to the first reachable signer. If a signer can't be
+// reached, the request is sent to the next one. The signer that handled the
+// request is returned.
+func (s *signerSet) call(fn func(context.Context,
+	*remoteSigner) error) (*remoteSigner, error) {
+
+	var err error
+	for _, signer := range s.candidates() {
+		ctxt, cancel := context.WithTimeout(
+			context.Background(), s.rpcTimeout,
+		)
+		err = fn(ctxt, signer)
+		cancel()
+
+		if !isConnectionError(err) {
+			s.succeeded(signer)
+			return signer, err
+		}
+
+		s.failed(signer, err)
+	}
+
+	return nil, err
+}
+
+// callSession sends a request for the given MuSig2 session to the signer that
+// holds the session.
+func (s *signerSet) callSession(sessionID input.MuSig2SessionID,
+	fn func(context.Context, *remoteSigner) error) error {
+
+	s.mu.Lock()
+	signer, ok := s.sessions[sessionID]
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrUnknownMuSig2Session
+	}
+
+	ctxt, cancel := context.WithTimeout(context.Background(), s.rpcTimeout)
+	defer cancel()
+
+	if err := fn(ctxt, signer); err != nil {
+		s.failed(signer, err)
+		return err
+	}
+
+	return nil
+}
+
+// addSession records that the given signer holds the MuSig2 session.
+func (s *signerSet) addSession(sessionID input.MuSig2SessionID,
+	signer *remoteSigner) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[sessionID] = signer
+}
+
+// removeSession forgets the given MuSig2 session.
+func (s *signerSet) removeSession(sessionID input.MuSig2SessionID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, sessionID)
+}
@@ -0,0 +1,217 @@
+package rpcwallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/lncfg"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestSignerSet creates a signer set with the given number of signers that
+// are all considered healthy. The signers don't have any connection.
+func newTestSignerSet(numSigners int) *signerSet {
+	s := &signerSet{
+		rpcTimeout: time.Second,
+		healthy:    make([]bool, numSigners),
+		active:     -1,
+		sessions:   make(map[input.MuSig2SessionID]*remoteSigner),
+		quit:       make(chan struct{}),
+	}
+
+	for idx := 0; idx < numSigners; idx++ {
+		s.signers = append(s.signers, &remoteSigner{
+			endpoint: lncfg.RemoteSignerEndpoint{
+				RPCHost: fmt.Sprintf("signer%d:10009", idx),
+			},
+		})
+		s.healthy[idx] = true
+	}
+
+	return s
+}
+
+// TestSignerSetFailover asserts that requests fail over to the next signer
+// only if a signer can't be reached, and that unreachable signers are tried
+// last.
+func TestSignerSetFailover(t *testing.T) {
+	s := newTestSignerSet(3)
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	// The first signer handles the request if it is reachable.
+	var tried []*remoteSigner
+	signer, err := s.call(func(_ context.Context, r *remoteSigner) error {
+		tried = append(tried, r)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, s.signers[0], signer)
+	require.Equal(t, s.signers[:1], tried)
+
+	// If the first signer is unreachable, the request is sent to the
+	// second one and the first one is marked as unhealthy.
+	tried = nil
+	signer, err = s.call(func(_ context.Context, r *remoteSigner) error {
+		tried = append(tried, r)
+		if r == s.signers[0] {
+			return unavailable
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, s.signers[1], signer)
+	require.Equal(t, s.signers[:2], tried)
+	require.Equal(t, []bool{false, true, true}, s.healthy)
+	require.Equal(t, 1, s.active)
+
+	// Any other error is returned to the caller without failing over.
+	errSign := errors.New("unable to sign")
+	tried = nil
+	signer, err = s.call(func(_ context.Context, r *remoteSigner) error {
+		tried = append(tried, r)
+		return errSign
+	})
+	require.ErrorIs(t, err, errSign)
+	require.Equal(t, s.signers[1], signer)
+	require.Equal(t, s.signers[1:2], tried)
+
+	// The unhealthy signer is tried last, and the connection error is
+	// returned if none of the signers can be reached.
+	tried = nil
+	_, err = s.call(func(_ context.Context, r *remoteSigner) error {
+		tried = append(tried, r)
+		return unavailable
+	})
+	require.ErrorIs(t, err, unavailable)
+	require.Equal(t, []*remoteSigner{
+		s.signers[1], s.signers[2], s.signers[0],
+	}, tried)
+	require.Equal(t, []bool{false, false, false}, s.healthy)
+
+	// Once a signer is reachable again, it is used.
+	signer, err = s.call(func(_ context.Context, r *remoteSigner) error {
+		if r == s.signers[0] {
+			return nil
+		}
+		return unavailable
+	})
+	require.NoError(t, err)
+	require.Equal(t, s.signers[0], signer)
+	require.Equal(t, []bool{true, false, false}, s.healthy)
+}
+
+// TestSignerSetSessions asserts that MuSig2 session requests always go to the
+// signer that created the session.
+func TestSignerSetSessions(t *testing.T) {
+	s := newTestSignerSet(2)
+
+	var sessionID input.MuSig2SessionID
+	sessionID[0] = 1
+
+	err := s.callSession(
+		sessionID, func(context.Context, *remoteSigner) error {
+			return nil
+		},
+	)
+	require.ErrorIs(t, err, ErrUnknownMuSig2Session)
+
+	s.addSession(sessionID, s.signers[1])
+
+	// The session stays with its signer even if that signer is
+	// unreachable.
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	var tried []*remoteSigner
+	err = s.callSession(
+		sessionID, func(_ context.Context, r *remoteSigner) error {
+			tried = append(tried, r)
+			return unavailable
+		},
+	)
+	require.ErrorIs(t, err, unavailable)
+	require.Equal(t, s.signers[1:], tried)
+	require.Equal(t, []bool{true, false}, s.healthy)
+
+	s.removeSession(sessionID)
+	err = s.callSession(
+		sessionID, func(context.Context, *remoteSigner) error {
+			return nil
+		},
+	)
+	require.ErrorIs(t, err, ErrUnknownMuSig2Session)
+}
+
+// TestIsConnectionError asserts that only errors caused by an unreachable
+// signer are treated as connection errors.
+func TestIsConnectionError(t *testing.T) {
+	require.True(t, isConnectionError(context.DeadlineExceeded))
+	require.True(t, isConnectionError(
+		status.Error(codes.DeadlineExceeded, "timeout"),
+	))
+	require.True(t, isConnectionError(
+		status.Error(codes.Unavailable, "unavailable"),
+	))
+
+	require.False(t, isConnectionError(nil))
+	require.False(t, isConnectionError(errors.New("other")))
+	require.False(t, isConnectionError(
+		status.Error(codes.PermissionDenied, "denied"),
+	))
+}
+
+// TestInboundConnection asserts that a connection opened by an outbound
+// listener of a signer is handed to the dialer of the watch-only node, and
+// that the signer connects again once the connection is closed.
+func TestInboundConnection(t *testing.T) {
+	inbound, err := newInboundListener("127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, inbound.close())
+	}()
+
+	outbound := NewOutboundListener(
+		inbound.listener.Addr().String(), time.Second,
+	)
+	defer outbound.Close()
+
+	ctxt, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		accepted := make(chan error, 1)
+		go func() {
+			signerConn, err := outbound.Accept()
+			if err != nil {
+				accepted <- err
+				return
+			}
+
+			_, err = signerConn.Write([]byte{byte(i)})
+			if err == nil {
+				err = signerConn.Close()
+			}
+			accepted <- err
+		}()
+
+		conn, err := inbound.dial(ctxt, "")
+		require.NoError(t, err)
+
+		buf := make([]byte, 1)
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		require.Equal(t, byte(i), buf[0])
+		require.NoError(t, <-accepted)
+		require.NoError(t, conn.Close())
+	}
+
+	// Once closed, the outbound listener doesn't connect anymore.
+	require.NoError(t, outbound.Close())
+	_, err = outbound.Accept()
+	require.Error(t, err)
+}
@@ -0,0 +1,466 @@
+package signpolicy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnwallet"
+)
+
+const (
+	// spendWindow is the window within which the amounts sent from the
+	// wallet are added up and compared to the daily limit.
+	spendWindow = 24 * time.Hour
+
+	// multiSigScriptLen is the length of a 2-of-2 multisig script as used
+	// for channel funding outputs.
+	multiSigScriptLen = 71
+)
+
+var (
+	// ErrRevokedState is returned if a commitment transaction is to be
+	// signed that was superseded by a more recent one.
+	ErrRevokedState = errors.New("refusing to sign revoked channel state")
+
+	// ErrAddressNotAllowed is returned if a transaction spending wallet
+	// funds pays to an address outside the allowlist.
+	ErrAddressNotAllowed = errors.New("refusing to send to address " +
+		"outside the allowlist")
+
+	// ErrDailyLimitExceeded is returned if a transaction spending wallet
+	// funds would exceed the daily limit.
+	ErrDailyLimitExceeded = errors.New("refusing to exceed daily " +
+		"spending limit")
+
+	// ErrSigHashNotAllowed is returned if a wallet input is to be signed
+	// with a sighash type that doesn't commit to all outputs.
+	ErrSigHashNotAllowed = errors.New("refusing to sign wallet input " +
+		"with sighash type that doesn't cover all outputs")
+
+	// ErrMuSig2NotAllowed is returned if a MuSig2 partial signature is
+	// requested while MuSig2 signing is disabled.
+	ErrMuSig2NotAllowed = errors.New("MuSig2 signing is disabled by the " +
+		"signing policy")
+)
+
+// Config holds the parameters of the signing policy.
+type Config struct {
+	// DB is the database the channel states and wallet spends are stored
+	// in.
+	DB kvdb.Backend
+
+	// AllowedAddresses is the list of addresses wallet or channel funds
+	// may be sent to, apart from the wallet itself. If it is empty, funds
+	// may be sent to any address.
+	AllowedAddresses []btcutil.Address
+
+	// DailyLimit is the maximum amount of wallet or channel funds that
+	// may be sent to other addresses than the wallet within 24 hours.
+	// Zero means no limit.
+	DailyLimit btcutil.Amount
+
+	// AllowMuSig2 indicates whether MuSig2 partial signatures may be
+	// created. Their message is opaque, so they can't be checked.
+	AllowMuSig2 bool
+
+	// DeriveWalletKey derives the public key of the wallet at the given
+	// BIP32 path. It is used to verify that an output pays back to the
+	// wallet.
+	DeriveWalletKey func(path []uint32) (*btcec.PublicKey, error)
+
+	// AddressScope returns the key scope of the given address if it
+	// belongs to the wallet. It is used to tell the inputs spent with keys
+	// of the on-chain wallet apart from those spent with channel keys.
+	AddressScope func(addr btcutil.Address) (waddrmgr.KeyScope, bool)
+
+	// Clock is the time source of the daily limit.
+	Clock clock.Clock
+}
+
+// Input is an input of a transaction that is to be signed.
+type Input struct {
+	// Index is the index of the input in the transaction.
+	Index int
+
+	// WitnessScript is the witness script of the output that is spent,
+	// if any.
+	WitnessScript []byte
+
+	// HashType is the sighash type of the signature.
+	HashType txscript.SigHashType
+
+	// Wallet is true if the input is signed with a key of the on-chain
+	// wallet, as opposed to one of the channel keys.
+	Wallet bool
+}
+
+// Request is a request to sign one or more inputs of a transaction.
+type Request struct {
+	// Tx is the transaction to sign.
+	Tx *wire.MsgTx
+
+	// Inputs are the inputs of the transaction that are to be signed.
+	Inputs []Input
+
+	// OutputPaths holds the BIP32 derivation paths of the outputs that
+	// claim to pay back to the wallet, by output index. Each claim is
+	// verified by deriving the key.
+	OutputPaths map[int][]uint32
+}
+
+// Enforcer checks signing requests against the signing policy before they
+// are handed to the keychain. It keeps its own record of the commitments
+// signed for each channel and of the amounts sent from the wallet, so that
+// a compromised node can't use the signer to broadcast a revoked channel
+// state or to drain the wallet.
+//
+// The commitments of both parties are tracked separately. Every commitment of
+// the remote party is signed when it is proposed, so a commitment that shares
+// the state hint of one of them but is a different transaction is our own. The
+// most recent commitment of each party is its current state, and every one of
+// the same party before it is revoked. The revocation of our own commitments
+// isn't visible to the signer, so they are refused once they lag too far
+// behind the remote party's chain.
+type Enforcer struct {
+	cfg *Config
+
+	store *store
+
+	// allowed holds the pk scripts of the allowed addresses.
+	allowed map[string]struct{}
+}
+
+// New creates a new enforcer of the given signing policy.
+func New(cfg *Config) (*Enforcer, error) {
+	s, err := newStore(cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]struct{}, len(cfg.AllowedAddresses))
+	for _, addr := range cfg.AllowedAddresses {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed address %v: %v",
+				addr, err)
+		}
+		allowed[string(pkScript)] = struct{}{}
+	}
+
+	return &Enforcer{
+		cfg:     cfg,
+		store:   s,
+		allowed: allowed,
+	}, nil
+}
+
+// CheckSign checks that the given inputs of a transaction may be signed. If
+// a commitment transaction is signed, it is recorded as the most recent state
+// of its channel. Any other transaction that spends wallet or channel funds may
+// only pay to the wallet or to allowed addresses, and the amount it sends
+// elsewhere is counted towards the daily limit.
+func (e *Enforcer) CheckSign(req *Request) error {
+	tx := req.Tx
+
+	var (
+		checkOutputs bool
+		exempt       = make(map[int]struct{})
+	)
+	for _, in := range req.Inputs {
+		if in.Index < 0 || in.Index >= len(tx.TxIn) {
+			return fmt.Errorf("invalid input index %d", in.Index)
+		}
+
+		switch {
+		// A spend of a channel funding output. Commitment transactions
+		// are checked against the state of the channel, any other
+		// spend is a cooperative close.
+		case isMultiSigScript(in.WitnessScript):
+			if !isCommitmentTx(tx) {
+				checkOutputs = true
+				continue
+			}
+
+			chanPoint := tx.TxIn[in.Index].PreviousOutPoint
+			err := e.store.addCommitment(
+				chanPoint, stateHint(tx), tx.TxHash(),
+			)
+			if err != nil {
+				log.Warnf("Refused to sign commitment of "+
+					"channel %v: %v", chanPoint, err)
+				return err
+			}
+
+		case in.Wallet:
+			// A signature that doesn't commit to all outputs
+			// could be used in a transaction with other outputs.
+			if in.HashType != txscript.SigHashAll &&
+				in.HashType != txscript.SigHashDefault {
+
+				return fmt.Errorf("%w: input %d uses %v",
+					ErrSigHashNotAllowed, in.Index,
+					in.HashType)
+			}
+
+			checkOutputs = true
+
+		// Second-level HTLC transactions are signed along with every
+		// commitment and pay to a P2WSH script of the channel. Their
+		// output shares the index of the HTLC input.
+		case isHtlcScript(in.WitnessScript):
+			if in.Index < len(tx.TxOut) {
+				pkScript := tx.TxOut[in.Index].PkScript
+				if txscript.IsPayToWitnessScriptHash(pkScript) {
+					exempt[in.Index] = struct{}{}
+				}
+			}
+			checkOutputs = true
+
+		// Any other spend of a channel output, such as a sweep, must
+		// pay to the wallet as well.
+		default:
+			checkOutputs = true
+		}
+	}
+
+	if !checkOutputs {
+		return nil
+	}
+
+	if err := e.checkSpend(req, exempt); err != nil {
+		log.Warnf("Refused to sign transaction %v: %v", tx.TxHash(),
+			err)
+		return err
+	}
+
+	return nil
+}
+
+// checkSpend checks that a transaction only pays to the wallet itself or to
+// allowed addresses, and that it doesn't exceed the daily limit. The outputs
+// of the given indexes are exempt.
+func (e *Enforcer) checkSpend(req *Request, exempt map[int]struct{}) error {
+	var sent btcutil.Amount
+	for idx, txOut := range req.Tx.TxOut {
+		if _, ok := exempt[idx]; ok {
+			continue
+		}
+
+		path, ok := req.OutputPaths[idx]
+		if ok && e.isWalletOutput(txOut.PkScript, path) {
+			continue
+		}
+
+		if e.isWalletScript(txOut.PkScript) {
+			continue
+		}
+
+		if len(e.allowed) > 0 {
+			_, ok := e.allowed[string(txOut.PkScript)]
+			if !ok {
+				return fmt.Errorf("%w: output %d pays to %x",
+					ErrAddressNotAllowed, idx,
+					txOut.PkScript)
+			}
+		}
+
+		sent += btcutil.Amount(txOut.Value)
+	}
+
+	if sent == 0 {
+		return nil
+	}
+
+	return e.store.addSpend(
+		req.Tx.TxHash(), sent, e.cfg.Clock.Now(), spendWindow,
+		e.cfg.DailyLimit,
+	)
+}
+
+// isWalletOutput returns true if the given pk script pays to the wallet key
+// at the given BIP32 path.
+func (e *Enforcer) isWalletOutput(pkScript []byte, path []uint32) bool {
+	// Keys of lnd's internal key scope are never used for addresses.
+	if !isWalletPath(path) {
+		return false
+	}
+
+	pubKey, err := e.cfg.DeriveWalletKey(path)
+	if err != nil {
+		log.Debugf("Unable to derive key of output claiming to pay to "+
+			"the wallet: %v", err)
+		return false
+	}
+
+	for _, script := range walletScripts(pubKey) {
+		if bytes.Equal(script, pkScript) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsWalletInput returns true if the spend of the output with the given pk
+// script is signed with a key of the on-chain wallet. That is the case if the
+// output pays to the wallet, or if the key is only identified by its public
+// key, which the wallet signs for with any of its keys.
+func (e *Enforcer) IsWalletInput(keyDesc *keychain.KeyDescriptor,
+	pkScript []byte) bool {
+
+	// The network is irrelevant for the lookup of the addresses.
+	params := &chaincfg.MainNetParams
+
+	if keyDesc.KeyLocator.IsEmpty() && keyDesc.PubKey != nil {
+		keyHash := btcutil.Hash160(keyDesc.PubKey.SerializeCompressed())
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(
+			keyHash, params,
+		)
+		if err == nil && e.isWalletAddress(addr) {
+			return true
+		}
+	}
+
+	return e.isWalletScript(pkScript)
+}
+
+// isWalletScript returns true if the given pk script pays to an address of the
+// on-chain wallet.
+func (e *Enforcer) isWalletScript(pkScript []byte) bool {
+	// The network is irrelevant for the lookup of the addresses.
+	params := &chaincfg.MainNetParams
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, params)
+	if err != nil || len(addrs) != 1 {
+		return false
+	}
+
+	return e.isWalletAddress(addrs[0])
+}
+
+// isWalletAddress returns true if the given address belongs to the on-chain
+// wallet. The addresses of lnd's internal key scope are held by the wallet
+// too, but they belong to channel keys.
+func (e *Enforcer) isWalletAddress(addr btcutil.Address) bool {
+	if e.cfg.AddressScope == nil {
+		return false
+	}
+
+	scope, ok := e.cfg.AddressScope(addr)
+
+	return ok && scope.Purpose != keychain.BIP0043Purpose
+}
+
+// CheckMuSig2 checks that a MuSig2 partial signature may be created.
+func (e *Enforcer) CheckMuSig2() error {
+	if !e.cfg.AllowMuSig2 {
+		return ErrMuSig2NotAllowed
+	}
+
+	return nil
+}
+
+// walletScripts returns the pk scripts of all address types the wallet uses
+// for the given key.
+func walletScripts(pubKey *btcec.PublicKey) [][]byte {
+	// The network is irrelevant for the resulting scripts.
+	params := &chaincfg.MainNetParams
+
+	var scripts [][]byte
+	keyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+	p2wkh, err := btcutil.NewAddressWitnessPubKeyHash(keyHash, params)
+	if err == nil {
+		script, err := txscript.PayToAddrScript(p2wkh)
+		if err == nil {
+			scripts = append(scripts, script)
+		}
+
+		// The nested variant wraps the p2wkh script in p2sh.
+		np2wkh, err := btcutil.NewAddressScriptHash(script, params)
+		if err == nil {
+			script, err := txscript.PayToAddrScript(np2wkh)
+			if err == nil {
+				scripts = append(scripts, script)
+			}
+		}
+	}
+
+	taprootKey := txscript.ComputeTaprootKeyNoScript(pubKey)
+	p2tr, err := btcutil.NewAddressTaproot(
+		schnorr.SerializePubKey(taprootKey), params,
+	)
+	if err == nil {
+		script, err := txscript.PayToAddrScript(p2tr)
+		if err == nil {
+			scripts = append(scripts, script)
+		}
+	}
+
+	return scripts
+}
+
+// isMultiSigScript returns true if the given script is a 2-of-2 multisig
+// script of two compressed public keys, as used for channel funding outputs.
+func isMultiSigScript(script []byte) bool {
+	return len(script) == multiSigScriptLen &&
+		script[0] == txscript.OP_2 &&
+		script[1] == txscript.OP_DATA_33 &&
+		script[35] == txscript.OP_DATA_33 &&
+		script[69] == txscript.OP_2 &&
+		script[70] == txscript.OP_CHECKMULTISIG
+}
+
+// isHtlcScript returns true if the given script is the witness script of an
+// HTLC output of a commitment transaction. Both the offered and the accepted
+// HTLC scripts check the size of the payment preimage.
+func isHtlcScript(script []byte) bool {
+	const scriptVersion = 0
+
+	var prevOps [2]byte
+	tokenizer := txscript.MakeScriptTokenizer(scriptVersion, script)
+	for tokenizer.Next() {
+		op := tokenizer.Opcode()
+		if op == txscript.OP_EQUAL &&
+			prevOps[0] == txscript.OP_SIZE &&
+			prevOps[1] == txscript.OP_DATA_1 {
+
+			return true
+		}
+
+		// Only a push of the preimage size counts.
+		if op == txscript.OP_DATA_1 && tokenizer.Data()[0] != 32 {
+			op = txscript.OP_INVALIDOPCODE
+		}
+
+		prevOps[0], prevOps[1] = prevOps[1], op
+	}
+
+	return false
+}
+
+// isCommitmentTx returns true if the given transaction encodes a state hint in
+// its lock time and sequence the way commitment transactions do.
+func isCommitmentTx(tx *wire.MsgTx) bool {
+	return len(tx.TxIn) == 1 &&
+		tx.LockTime&^0xFFFFFF == lnwallet.TimelockShift &&
+		tx.TxIn[0].Sequence&^0xFFFFFF == wire.SequenceLockTimeDisabled
+}
+
+// stateHint returns the obfuscated state number of a commitment transaction.
+// Our commitment and the remote party's commitment of the same height share
+// the same state hint.
+func stateHint(tx *wire.MsgTx) uint64 {
+	return lnwallet.GetStateNumHint(tx, [lnwallet.StateHintSize]byte{})
+}
@@ -0,0 +1,592 @@
+package signpolicy
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/waddrmgr"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/input"
+	"github.com/lightningnetwork/lnd/keychain"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testTime = time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC)
+
+	// changePath is the BIP32 path of the wallet's change key.
+	changePath = []uint32{
+		84 + hdkeychain.HardenedKeyStart,
+		hdkeychain.HardenedKeyStart,
+		hdkeychain.HardenedKeyStart,
+		1, 0,
+	}
+)
+
+// newTestKey returns a new random private key.
+func newTestKey(t *testing.T) *btcec.PrivateKey {
+	key, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	return key
+}
+
+// p2wkhScript returns the p2wkh pk script of the given key.
+func p2wkhScript(t *testing.T, pubKey *btcec.PublicKey) []byte {
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(pubKey.SerializeCompressed()),
+		&chaincfg.RegressionNetParams,
+	)
+	require.NoError(t, err)
+
+	script, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	return script
+}
+
+type testContext struct {
+	enforcer  *Enforcer
+	clock     *clock.TestClock
+	changeKey *btcec.PublicKey
+	walletKey *btcec.PublicKey
+}
+
+// newTestContext creates an enforcer backed by a fresh database. The wallet
+// only knows the change key and the address of the wallet key.
+func newTestContext(t *testing.T, allowed []btcutil.Address,
+	limit btcutil.Amount) *testContext {
+
+	db, err := kvdb.Create(
+		kvdb.BoltBackendName, filepath.Join(t.TempDir(), "policy.db"),
+		true, kvdb.DefaultDBTimeout,
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	ctx := &testContext{
+		clock:     clock.NewTestClock(testTime),
+		changeKey: newTestKey(t).PubKey(),
+		walletKey: newTestKey(t).PubKey(),
+	}
+	walletScript := p2wkhScript(t, ctx.walletKey)
+
+	ctx.enforcer, err = New(&Config{
+		DB:               db,
+		AllowedAddresses: allowed,
+		DailyLimit:       limit,
+		DeriveWalletKey: func(path []uint32) (*btcec.PublicKey,
+			error) {
+
+			require.Equal(t, changePath, path)
+			return ctx.changeKey, nil
+		},
+		AddressScope: func(addr btcutil.Address) (waddrmgr.KeyScope,
+			bool) {
+
+			script, err := txscript.PayToAddrScript(addr)
+			require.NoError(t, err)
+
+			if string(script) != string(walletScript) {
+				return waddrmgr.KeyScope{}, false
+			}
+
+			return waddrmgr.KeyScopeBIP0084, true
+		},
+		Clock: ctx.clock,
+	})
+	require.NoError(t, err)
+
+	return ctx
+}
+
+// TestCheckCommitment asserts that commitments of revoked channel states are
+// refused, while cooperative closes and other channels are unaffected.
+func TestCheckCommitment(t *testing.T) {
+	ctx := newTestContext(t, nil, 0)
+
+	fundingScript, err := input.GenMultiSigScript(
+		newTestKey(t).PubKey().SerializeCompressed(),
+		newTestKey(t).PubKey().SerializeCompressed(),
+	)
+	require.NoError(t, err)
+
+	obfuscator := [lnwallet.StateHintSize]byte{1, 2, 3, 4, 5, 6}
+	chanPoint := wire.OutPoint{Index: 1}
+	otherChanPoint := wire.OutPoint{Index: 2}
+
+	sign := func(op wire.OutPoint, height uint64) error {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: op})
+		tx.AddTxOut(&wire.TxOut{Value: 1000})
+		err := lnwallet.SetStateNumHint(tx, height, obfuscator)
+		require.NoError(t, err)
+
+		return ctx.enforcer.CheckSign(&Request{
+			Tx: tx,
+			Inputs: []Input{{
+				WitnessScript: fundingScript,
+				HashType:      txscript.SigHashAll,
+			}},
+		})
+	}
+
+	require.NoError(t, sign(chanPoint, 0))
+	require.NoError(t, sign(chanPoint, 1))
+
+	// The most recent state can be signed again, the ones before it are
+	// revoked.
+	require.NoError(t, sign(chanPoint, 1))
+	require.ErrorIs(t, sign(chanPoint, 0), ErrRevokedState)
+
+	require.NoError(t, sign(chanPoint, 2))
+	require.ErrorIs(t, sign(chanPoint, 1), ErrRevokedState)
+	require.ErrorIs(t, sign(chanPoint, 0), ErrRevokedState)
+
+	// Other channels have their own states.
+	require.NoError(t, sign(otherChanPoint, 0))
+	require.NoError(t, sign(otherChanPoint, 1))
+
+	// A cooperative close doesn't encode a state hint and is signed as
+	// is.
+	closeTx := wire.NewMsgTx(2)
+	closeTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: chanPoint,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	closeTx.AddTxOut(&wire.TxOut{Value: 1000})
+	require.NoError(t, ctx.enforcer.CheckSign(&Request{
+		Tx: closeTx,
+		Inputs: []Input{{
+			WitnessScript: fundingScript,
+			HashType:      txscript.SigHashAll,
+		}},
+	}))
+}
+
+// TestCheckLocalCommitment asserts that our own commitment is tracked apart
+// from the remote party's commitment of the same height, so that it can be
+// signed on force close after the remote commitment moved on.
+func TestCheckLocalCommitment(t *testing.T) {
+	ctx := newTestContext(t, nil, 0)
+
+	fundingScript, err := input.GenMultiSigScript(
+		newTestKey(t).PubKey().SerializeCompressed(),
+		newTestKey(t).PubKey().SerializeCompressed(),
+	)
+	require.NoError(t, err)
+
+	obfuscator := [lnwallet.StateHintSize]byte{1, 2, 3, 4, 5, 6}
+	chanPoint := wire.OutPoint{Index: 1}
+
+	// The commitments of both parties share the state hint of their
+	// height, but pay to different outputs.
+	sign := func(height uint64, local bool) error {
+		value := int64(1000)
+		if local {
+			value = 2000
+		}
+
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: chanPoint})
+		tx.AddTxOut(&wire.TxOut{Value: value})
+		err := lnwallet.SetStateNumHint(tx, height, obfuscator)
+		require.NoError(t, err)
+
+		return ctx.enforcer.CheckSign(&Request{
+			Tx: tx,
+			Inputs: []Input{{
+				WitnessScript: fundingScript,
+				HashType:      txscript.SigHashAll,
+			}},
+		})
+	}
+
+	// Our own commitment of the funding height is signed once the channel
+	// confirmed.
+	require.NoError(t, sign(0, false))
+	require.NoError(t, sign(0, true))
+
+	// The remote commitment moves ahead of ours, which doesn't revoke our
+	// commitment of the same height.
+	require.NoError(t, sign(1, false))
+	require.NoError(t, sign(2, false))
+	require.ErrorIs(t, sign(1, false), ErrRevokedState)
+
+	// We force close with our current commitment, which is behind the
+	// remote one and shares the state hint of a revoked remote commitment.
+	require.NoError(t, sign(1, true))
+	require.NoError(t, sign(1, true))
+
+	// Our commitments before it are revoked.
+	require.ErrorIs(t, sign(0, true), ErrRevokedState)
+	require.ErrorIs(t, sign(0, false), ErrRevokedState)
+
+	// The remote chain is unaffected by our own commitments.
+	require.NoError(t, sign(2, false))
+}
+
+// TestCheckRevokedLocalCommitment asserts that our own commitments that were
+// never signed before are refused once they lag too far behind the remote
+// chain, as they must have been revoked.
+func TestCheckRevokedLocalCommitment(t *testing.T) {
+	ctx := newTestContext(t, nil, 0)
+
+	fundingScript, err := input.GenMultiSigScript(
+		newTestKey(t).PubKey().SerializeCompressed(),
+		newTestKey(t).PubKey().SerializeCompressed(),
+	)
+	require.NoError(t, err)
+
+	obfuscator := [lnwallet.StateHintSize]byte{1, 2, 3, 4, 5, 6}
+	chanPoint := wire.OutPoint{Index: 1}
+
+	sign := func(height uint64, local bool) error {
+		value := int64(1000)
+		if local {
+			value = 2000
+		}
+
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: chanPoint})
+		tx.AddTxOut(&wire.TxOut{Value: value})
+		err := lnwallet.SetStateNumHint(tx, height, obfuscator)
+		require.NoError(t, err)
+
+		return ctx.enforcer.CheckSign(&Request{
+			Tx: tx,
+			Inputs: []Input{{
+				WitnessScript: fundingScript,
+				HashType:      txscript.SigHashAll,
+			}},
+		})
+	}
+
+	// Sign the remote commitments up to height ten.
+	const height = 10
+	for i := uint64(0); i <= height; i++ {
+		require.NoError(t, sign(i, false))
+	}
+
+	// Our own commitment three heights behind is revoked, even though it
+	// was never signed before.
+	require.ErrorIs(t, sign(height-3, true), ErrRevokedState)
+	require.ErrorIs(t, sign(0, true), ErrRevokedState)
+
+	// Our commitment may still be current if it lags by up to two
+	// heights. Once it was signed, older ones are refused.
+	require.NoError(t, sign(height-2, true))
+	require.NoError(t, sign(height-1, true))
+	require.ErrorIs(t, sign(height-2, true), ErrRevokedState)
+}
+
+// TestCheckWalletSpend asserts that wallet funds can only be sent to allowed
+// addresses and back to the wallet, within the daily limit.
+func TestCheckWalletSpend(t *testing.T) {
+	allowedAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		make([]byte, 20), &chaincfg.RegressionNetParams,
+	)
+	require.NoError(t, err)
+	allowedScript, err := txscript.PayToAddrScript(allowedAddr)
+	require.NoError(t, err)
+
+	ctx := newTestContext(t, []btcutil.Address{allowedAddr}, 100_000)
+	changeScript := p2wkhScript(t, ctx.changeKey)
+	otherScript := p2wkhScript(t, newTestKey(t).PubKey())
+
+	newSpend := func(prevIndex uint32, outputs ...*wire.TxOut) *Request {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Index: prevIndex},
+		})
+		tx.TxOut = outputs
+
+		return &Request{
+			Tx: tx,
+			Inputs: []Input{{
+				HashType: txscript.SigHashAll,
+				Wallet:   true,
+			}},
+			OutputPaths: make(map[int][]uint32),
+		}
+	}
+
+	// Sending to an address outside the allowlist is refused.
+	req := newSpend(0, &wire.TxOut{Value: 1000, PkScript: otherScript})
+	err = ctx.enforcer.CheckSign(req)
+	require.ErrorIs(t, err, ErrAddressNotAllowed)
+
+	// Claiming that the output is change doesn't help if it doesn't pay
+	// to the key at the claimed path.
+	req.OutputPaths[0] = changePath
+	err = ctx.enforcer.CheckSign(req)
+	require.ErrorIs(t, err, ErrAddressNotAllowed)
+
+	// Change is not counted towards the limit.
+	req = newSpend(
+		1, &wire.TxOut{Value: 60_000, PkScript: allowedScript},
+		&wire.TxOut{Value: 500_000, PkScript: changeScript},
+	)
+	req.OutputPaths[1] = changePath
+	require.NoError(t, ctx.enforcer.CheckSign(req))
+
+	// Signing another input of the same transaction doesn't count it
+	// again.
+	require.NoError(t, ctx.enforcer.CheckSign(req))
+
+	// A signature that doesn't cover all outputs is refused.
+	req = newSpend(2, &wire.TxOut{Value: 1000, PkScript: allowedScript})
+	req.Inputs[0].HashType = txscript.SigHashNone
+	err = ctx.enforcer.CheckSign(req)
+	require.ErrorIs(t, err, ErrSigHashNotAllowed)
+
+	// The second spend would exceed the limit.
+	req = newSpend(3, &wire.TxOut{Value: 50_000, PkScript: allowedScript})
+	err = ctx.enforcer.CheckSign(req)
+	require.ErrorIs(t, err, ErrDailyLimitExceeded)
+
+	// But a smaller one still fits.
+	req = newSpend(4, &wire.TxOut{Value: 40_000, PkScript: allowedScript})
+	require.NoError(t, ctx.enforcer.CheckSign(req))
+
+	// A day later, the earlier spends no longer count.
+	ctx.clock.SetTime(testTime.Add(spendWindow))
+	req = newSpend(3, &wire.TxOut{Value: 50_000, PkScript: allowedScript})
+	require.NoError(t, ctx.enforcer.CheckSign(req))
+}
+
+// TestCheckCoopClose asserts that cooperative closes may only pay to the wallet
+// and to allowed addresses, within the daily limit.
+func TestCheckCoopClose(t *testing.T) {
+	allowedAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		make([]byte, 20), &chaincfg.RegressionNetParams,
+	)
+	require.NoError(t, err)
+	allowedScript, err := txscript.PayToAddrScript(allowedAddr)
+	require.NoError(t, err)
+
+	ctx := newTestContext(t, []btcutil.Address{allowedAddr}, 100_000)
+	walletScript := p2wkhScript(t, ctx.walletKey)
+	otherScript := p2wkhScript(t, newTestKey(t).PubKey())
+
+	fundingScript, err := input.GenMultiSigScript(
+		newTestKey(t).PubKey().SerializeCompressed(),
+		newTestKey(t).PubKey().SerializeCompressed(),
+	)
+	require.NoError(t, err)
+
+	closeChannel := func(prevIndex uint32, outputs ...*wire.TxOut) error {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Index: prevIndex},
+			Sequence:         wire.MaxTxInSequenceNum,
+		})
+		tx.TxOut = outputs
+
+		return ctx.enforcer.CheckSign(&Request{
+			Tx: tx,
+			Inputs: []Input{{
+				WitnessScript: fundingScript,
+				HashType:      txscript.SigHashAll,
+			}},
+		})
+	}
+
+	// Closing to an address outside the allowlist is refused.
+	err = closeChannel(0, &wire.TxOut{Value: 1000, PkScript: otherScript})
+	require.ErrorIs(t, err, ErrAddressNotAllowed)
+
+	// Closing to the wallet isn't counted towards the limit, the output
+	// of the remote party is.
+	require.NoError(t, closeChannel(
+		1, &wire.TxOut{Value: 500_000, PkScript: walletScript},
+		&wire.TxOut{Value: 60_000, PkScript: allowedScript},
+	))
+
+	err = closeChannel(
+		2, &wire.TxOut{Value: 50_000, PkScript: allowedScript},
+	)
+	require.ErrorIs(t, err, ErrDailyLimitExceeded)
+}
+
+// TestCheckChannelSweep asserts that spends of channel outputs, such as
+// sweeps, may only pay to the wallet and to allowed addresses, while
+// second-level HTLC transactions pay to the channel.
+func TestCheckChannelSweep(t *testing.T) {
+	allowedAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		make([]byte, 20), &chaincfg.RegressionNetParams,
+	)
+	require.NoError(t, err)
+
+	ctx := newTestContext(t, []btcutil.Address{allowedAddr}, 100_000)
+	walletScript := p2wkhScript(t, ctx.walletKey)
+	otherScript := p2wkhScript(t, newTestKey(t).PubKey())
+
+	delayScript, err := input.CommitScriptToSelf(
+		144, newTestKey(t).PubKey(), newTestKey(t).PubKey(),
+	)
+	require.NoError(t, err)
+
+	var paymentHash [32]byte
+	htlcScript, err := input.SenderHTLCScript(
+		newTestKey(t).PubKey(), newTestKey(t).PubKey(),
+		newTestKey(t).PubKey(), paymentHash[:], false,
+	)
+	require.NoError(t, err)
+	secondLevelScript, err := input.WitnessScriptHash(delayScript)
+	require.NoError(t, err)
+
+	sweep := func(witnessScript []byte, pkScript []byte) error {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(&wire.TxIn{})
+		tx.AddTxOut(&wire.TxOut{Value: 1000, PkScript: pkScript})
+
+		return ctx.enforcer.CheckSign(&Request{
+			Tx: tx,
+			Inputs: []Input{{
+				WitnessScript: witnessScript,
+				HashType:      txscript.SigHashAll,
+			}},
+		})
+	}
+
+	// Sweeping our output of a commitment to a foreign address is
+	// refused, sweeping it to the wallet isn't.
+	err = sweep(delayScript, otherScript)
+	require.ErrorIs(t, err, ErrAddressNotAllowed)
+	require.NoError(t, sweep(delayScript, walletScript))
+
+	// A second-level HTLC transaction pays to a script of the channel,
+	// but an HTLC can't be swept to a foreign address either.
+	require.NoError(t, sweep(htlcScript, secondLevelScript))
+	err = sweep(htlcScript, otherScript)
+	require.ErrorIs(t, err, ErrAddressNotAllowed)
+}
+
+// TestCheckMuSig2 asserts that MuSig2 signing is only allowed if enabled.
+func TestCheckMuSig2(t *testing.T) {
+	enforcer := &Enforcer{cfg: &Config{}}
+	require.ErrorIs(t, enforcer.CheckMuSig2(), ErrMuSig2NotAllowed)
+
+	enforcer.cfg.AllowMuSig2 = true
+	require.NoError(t, enforcer.CheckMuSig2())
+}
+
+// TestIsWalletInput asserts that inputs are recognized as wallet spends if
+// their key or the output they spend belongs to the wallet, but not if they
+// are spent with a channel key.
+func TestIsWalletInput(t *testing.T) {
+	walletKey := newTestKey(t).PubKey()
+	walletOutputKey := newTestKey(t).PubKey()
+	channelKey := newTestKey(t).PubKey()
+
+	scopes := map[string]waddrmgr.KeyScope{
+		string(p2wkhScript(t, walletKey)):       waddrmgr.KeyScopeBIP0084,
+		string(p2wkhScript(t, walletOutputKey)): waddrmgr.KeyScopeBIP0086,
+		string(p2wkhScript(t, channelKey)): {
+			Purpose: keychain.BIP0043Purpose,
+		},
+	}
+
+	enforcer := &Enforcer{cfg: &Config{}}
+
+	// Without address lookups, no input is a wallet input.
+	keyDesc := &keychain.KeyDescriptor{PubKey: walletKey}
+	require.False(t, enforcer.IsWalletInput(
+		keyDesc, p2wkhScript(t, walletKey),
+	))
+
+	enforcer.cfg.AddressScope = func(addr btcutil.Address) (
+		waddrmgr.KeyScope, bool) {
+
+		script, err := txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+
+		scope, ok := scopes[string(script)]
+		return scope, ok
+	}
+
+	unknownScript := p2wkhScript(t, newTestKey(t).PubKey())
+
+	// The wallet signs for its keys given only the public key, whatever
+	// output is spent.
+	require.True(t, enforcer.IsWalletInput(keyDesc, unknownScript))
+
+	// Keys with a key locator are derived from lnd's key scope instead.
+	keyDesc.KeyLocator = keychain.KeyLocator{
+		Family: keychain.KeyFamilyPaymentBase,
+	}
+	require.False(t, enforcer.IsWalletInput(keyDesc, unknownScript))
+
+	// Spending an output of the wallet is a wallet spend, whatever key is
+	// used.
+	require.True(t, enforcer.IsWalletInput(
+		keyDesc, p2wkhScript(t, walletOutputKey),
+	))
+
+	// Channel keys are held by the wallet too, but aren't wallet funds.
+	keyDesc = &keychain.KeyDescriptor{PubKey: channelKey}
+	require.False(t, enforcer.IsWalletInput(
+		keyDesc, p2wkhScript(t, channelKey),
+	))
+}
+
+// TestRequestFromPsbt asserts that the inputs the wallet would sign and the
+// derivation paths of the outputs are extracted from a PSBT.
+func TestRequestFromPsbt(t *testing.T) {
+	tx := wire.NewMsgTx(2)
+	for i := 0; i < 3; i++ {
+		tx.AddTxIn(&wire.TxIn{})
+	}
+	tx.AddTxOut(&wire.TxOut{Value: 1000})
+	tx.AddTxOut(&wire.TxOut{Value: 2000})
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	require.NoError(t, err)
+
+	channelPath := []uint32{
+		keychain.BIP0043Purpose + hdkeychain.HardenedKeyStart,
+		hdkeychain.HardenedKeyStart,
+		hdkeychain.HardenedKeyStart,
+		0, 0,
+	}
+
+	// The first input is a wallet input, the second one is signed with a
+	// channel key and the third one isn't signed at all.
+	packet.Inputs[0].WitnessUtxo = &wire.TxOut{}
+	packet.Inputs[0].SighashType = txscript.SigHashAll
+	packet.Inputs[0].Bip32Derivation = []*psbt.Bip32Derivation{{
+		Bip32Path: changePath,
+	}}
+	packet.Inputs[1].WitnessUtxo = &wire.TxOut{}
+	packet.Inputs[1].WitnessScript = []byte{1, 2, 3}
+	packet.Inputs[1].Bip32Derivation = []*psbt.Bip32Derivation{{
+		Bip32Path: channelPath,
+	}}
+	packet.Outputs[1].Bip32Derivation = []*psbt.Bip32Derivation{{
+		Bip32Path: changePath,
+	}}
+
+	req := RequestFromPsbt(packet)
+	require.Equal(t, []Input{{
+		Index:    0,
+		HashType: txscript.SigHashAll,
+		Wallet:   true,
+	}, {
+		Index:         1,
+		WitnessScript: []byte{1, 2, 3},
+	}}, req.Inputs)
+	require.Equal(t, map[int][]uint32{1: changePath}, req.OutputPaths)
+}
@@ -0,0 +1,55 @@
+package signpolicy
+
+import (
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/lightningnetwork/lnd/keychain"
+)
+
+// RequestFromPsbt creates the signing request for the inputs of the given
+// packet that the wallet's PSBT signer would sign. These are all inputs that
+// have UTXO and BIP32 derivation information but aren't finalized yet.
+func RequestFromPsbt(packet *psbt.Packet) *Request {
+	req := &Request{
+		Tx:          packet.UnsignedTx,
+		OutputPaths: make(map[int][]uint32),
+	}
+
+	for idx := range packet.Inputs {
+		in := &packet.Inputs[idx]
+		if in.WitnessUtxo == nil || len(in.FinalScriptWitness) > 0 ||
+			len(in.Bip32Derivation) == 0 {
+
+			continue
+		}
+
+		path := in.Bip32Derivation[0].Bip32Path
+		req.Inputs = append(req.Inputs, Input{
+			Index:         idx,
+			WitnessScript: in.WitnessScript,
+			HashType:      in.SighashType,
+			Wallet:        isWalletPath(path),
+		})
+	}
+
+	for idx := range packet.Outputs {
+		out := &packet.Outputs[idx]
+		switch {
+		case len(out.Bip32Derivation) > 0:
+			req.OutputPaths[idx] = out.Bip32Derivation[0].Bip32Path
+
+		case len(out.TaprootBip32Derivation) > 0:
+			req.OutputPaths[idx] =
+				out.TaprootBip32Derivation[0].Bip32Path
+		}
+	}
+
+	return req
+}
+
+// isWalletPath returns true if the given BIP32 path belongs to a key of the
+// on-chain wallet, as opposed to lnd's internal key scope.
+func isWalletPath(path []uint32) bool {
+	return len(path) > 0 &&
+		path[0] != keychain.BIP0043Purpose+hdkeychain.HardenedKeyStart
+}
@@ -0,0 +1,294 @@
+package signpolicy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// signPolicyBucket is the top-level bucket that holds all state of the
+	// signing policy.
+	signPolicyBucket = []byte("sign-policy")
+
+	// channelsBucket is the sub-bucket that holds one bucket per channel,
+	// keyed by the channel's funding outpoint.
+	channelsBucket = []byte("channels")
+
+	// remoteTipKey is the key of a channel bucket under which the txid of
+	// the most recent commitment of the remote party is stored.
+	remoteTipKey = []byte("remote-tip")
+
+	// localTipKey is the key of a channel bucket under which the txid of
+	// the most recent commitment of our own is stored.
+	localTipKey = []byte("local-tip")
+
+	// remoteHeightKey is the key of a channel bucket under which the
+	// height of the most recent commitment of the remote party is stored.
+	remoteHeightKey = []byte("remote-height")
+
+	// localHeightKey is the key of a channel bucket under which the height
+	// of the most recent commitment of our own is stored.
+	localHeightKey = []byte("local-height")
+
+	// remoteHintsBucket is the sub-bucket of a channel bucket that maps
+	// the state hints of all commitments of the remote party that were
+	// signed to their height.
+	remoteHintsBucket = []byte("remote-hints")
+
+	// revokedBucket is the sub-bucket of a channel bucket that holds the
+	// txids of all commitments that were superseded by a more recent one
+	// of the same party.
+	revokedBucket = []byte("revoked")
+
+	// spendsBucket is the sub-bucket that holds the wallet spends of the
+	// last day, keyed by their txid.
+	spendsBucket = []byte("spends")
+
+	// byteOrder is the byte order of all integers in the database.
+	byteOrder = binary.BigEndian
+)
+
+// maxLocalLag is the number of heights our own current commitment may lag
+// behind the most recent commitment of the remote party. The remote party
+// sends us a new commitment in every round of updates in which we sign one for
+// them, but we may sign their next commitment after receiving their
+// revocation, before their signature for our own commitment arrives.
+const maxLocalLag = 2
+
+// store persists the channel states and wallet spends the signing policy is
+// based on.
+type store struct {
+	db kvdb.Backend
+}
+
+// newStore creates the buckets of the signing policy if they don't exist yet.
+func newStore(db kvdb.Backend) (*store, error) {
+	err := kvdb.Update(db, func(tx kvdb.RwTx) error {
+		policyBucket, err := tx.CreateTopLevelBucket(signPolicyBucket)
+		if err != nil {
+			return err
+		}
+
+		_, err = policyBucket.CreateBucketIfNotExists(channelsBucket)
+		if err != nil {
+			return err
+		}
+
+		_, err = policyBucket.CreateBucketIfNotExists(spendsBucket)
+		return err
+	}, func() {})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create sign policy buckets: "+
+			"%v", err)
+	}
+
+	return &store{db: db}, nil
+}
+
+// outpointKey returns the database key of the given outpoint.
+func outpointKey(op wire.OutPoint) []byte {
+	var key [36]byte
+	copy(key[:32], op.Hash[:])
+	byteOrder.PutUint32(key[32:], op.Index)
+
+	return key[:]
+}
+
+// addCommitment records that the commitment with the given state hint and
+// txid is about to be signed for the channel with the given funding outpoint.
+// ErrRevokedState is returned if the commitment was superseded by a more
+// recent one of the same party.
+//
+// Our own commitment and the remote party's commitment of the same height
+// share the same state hint, but they are different transactions. As we sign
+// every commitment of the remote party, a commitment of a known state hint
+// but an unknown txid is our own. Any other new commitment is the next one of
+// the remote party. The state hints are obfuscated, so the heights are
+// counted from the first commitment of the remote party that was signed.
+//
+// Our own commitments are only signed on force close, so we can't see them
+// being revoked. Instead, one of them is refused if its height lags more than
+// maxLocalLag behind the remote party's most recent commitment.
+func (s *store) addCommitment(chanPoint wire.OutPoint, stateHint uint64,
+	txid chainhash.Hash) error {
+
+	var hintKey [8]byte
+	byteOrder.PutUint64(hintKey[:], stateHint)
+
+	revokedErr := func() error {
+		return fmt.Errorf("%w: commitment %v with state hint %d of "+
+			"channel %v", ErrRevokedState, txid, stateHint,
+			chanPoint)
+	}
+
+	return kvdb.Update(s.db, func(tx kvdb.RwTx) error {
+		chans := tx.ReadWriteBucket(signPolicyBucket).
+			NestedReadWriteBucket(channelsBucket)
+
+		chanBucket, err := chans.CreateBucketIfNotExists(
+			outpointKey(chanPoint),
+		)
+		if err != nil {
+			return err
+		}
+
+		// Signing the most recent commitment of either party again is
+		// always fine.
+		remoteTip := chanBucket.Get(remoteTipKey)
+		localTip := chanBucket.Get(localTipKey)
+		if bytes.Equal(remoteTip, txid[:]) ||
+			bytes.Equal(localTip, txid[:]) {
+
+			return nil
+		}
+
+		revoked, err := chanBucket.CreateBucketIfNotExists(
+			revokedBucket,
+		)
+		if err != nil {
+			return err
+		}
+
+		if revoked.Get(txid[:]) != nil {
+			return revokedErr()
+		}
+
+		remoteHints, err := chanBucket.CreateBucketIfNotExists(
+			remoteHintsBucket,
+		)
+		if err != nil {
+			return err
+		}
+
+		var (
+			tipKey, heightKey = remoteTipKey, remoteHeightKey
+			prevTip           = remoteTip
+			height            uint64
+		)
+		knownHeight := remoteHints.Get(hintKey[:])
+		if knownHeight != nil {
+			tipKey, heightKey = localTipKey, localHeightKey
+			prevTip = localTip
+			height = byteOrder.Uint64(knownHeight)
+
+			// Our commitment is revoked if it lags too far behind
+			// the remote chain, or if it's older than our own
+			// commitment signed before.
+			remoteHeight := byteOrder.Uint64(
+				chanBucket.Get(remoteHeightKey),
+			)
+			if height+maxLocalLag < remoteHeight {
+				return revokedErr()
+			}
+
+			localHeight := chanBucket.Get(localHeightKey)
+			if localHeight != nil &&
+				height < byteOrder.Uint64(localHeight) {
+
+				return revokedErr()
+			}
+		} else {
+			if remoteTip != nil {
+				height = byteOrder.Uint64(
+					chanBucket.Get(remoteHeightKey),
+				) + 1
+			}
+
+			var heightBytes [8]byte
+			byteOrder.PutUint64(heightBytes[:], height)
+			err := remoteHints.Put(hintKey[:], heightBytes[:])
+			if err != nil {
+				return err
+			}
+		}
+
+		if prevTip != nil {
+			err := revoked.Put(
+				append([]byte(nil), prevTip...), []byte{},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		var heightBytes [8]byte
+		byteOrder.PutUint64(heightBytes[:], height)
+		if err := chanBucket.Put(heightKey, heightBytes[:]); err != nil {
+			return err
+		}
+
+		return chanBucket.Put(tipKey, txid[:])
+	}, func() {})
+}
+
+// addSpend records that the wallet sends the given amount in the transaction
+// with the given txid, unless that transaction was already recorded. Spends
+// older than the given window are removed. ErrDailyLimitExceeded is returned
+// and nothing is recorded if the spend would push the total amount sent
+// within the window over the limit. A limit of zero disables the check.
+func (s *store) addSpend(txid chainhash.Hash, amt btcutil.Amount,
+	now time.Time, window time.Duration, limit btcutil.Amount) error {
+
+	return kvdb.Update(s.db, func(tx kvdb.RwTx) error {
+		spends := tx.ReadWriteBucket(signPolicyBucket).
+			NestedReadWriteBucket(spendsBucket)
+
+		// All inputs of a transaction may be signed in separate
+		// requests, so it is only counted once.
+		if spends.Get(txid[:]) != nil {
+			return nil
+		}
+
+		var (
+			total   btcutil.Amount
+			expired [][]byte
+		)
+		err := spends.ForEach(func(k, v []byte) error {
+			if len(v) != 16 {
+				return fmt.Errorf("invalid spend entry %x", k)
+			}
+
+			timestamp := time.Unix(
+				0, int64(byteOrder.Uint64(v[:8])),
+			)
+			if now.Sub(timestamp) >= window {
+				expired = append(
+					expired, append([]byte(nil), k...),
+				)
+				return nil
+			}
+
+			total += btcutil.Amount(byteOrder.Uint64(v[8:]))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, k := range expired {
+			if err := spends.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		if limit != 0 && total+amt > limit {
+			return fmt.Errorf("%w: sending %v would bring the "+
+				"total sent within %v to %v, limit is %v",
+				ErrDailyLimitExceeded, amt, window, total+amt,
+				limit)
+		}
+
+		var v [16]byte
+		byteOrder.PutUint64(v[:8], uint64(now.UnixNano()))
+		byteOrder.PutUint64(v[8:], uint64(amt))
+
+		return spends.Put(txid[:], v[:])
+	}, func() {})
+}
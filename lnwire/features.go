@@ -221,6 +221,22 @@ const (
 	// TODO: Decide on actual feature bit value.
 	ScriptEnforcedLeaseOptional FeatureBit = 2023
 
+	// GossipSetReconRequired is a required feature bit that signals that
+	// the node requires channel graph syncs based on set reconciliation
+	// using the QueryChannelSketch and ReplyChannelSketch messages.
+	//
+	// NOTE: This is an experimental feature bit that is only signalled if
+	// enabled with the protocol.gossip-set-recon option.
+	GossipSetReconRequired FeatureBit = 2030
+
+	// GossipSetReconOptional is an optional feature bit that signals that
+	// the node supports channel graph syncs based on set reconciliation
+	// using the QueryChannelSketch and ReplyChannelSketch messages.
+	//
+	// NOTE: This is an experimental feature bit that is only signalled if
+	// enabled with the protocol.gossip-set-recon option.
+	GossipSetReconOptional FeatureBit = 2031
+
 	// maxAllowedSize is a maximum allowed size of feature vector.
 	//
 	// NOTE: Within the protocol, the maximum allowed message size is 65535
@@ -280,6 +296,8 @@ var Features = map[FeatureBit]string{
 	ZeroConfOptional:              "zero-conf",
 	ShutdownAnySegwitRequired:     "shutdown-any-segwit",
 	ShutdownAnySegwitOptional:     "shutdown-any-segwit",
+	GossipSetReconRequired:        "gossip-set-recon",
+	GossipSetReconOptional:        "gossip-set-recon",
 }
 
 // RawFeatureVector represents a set of feature bits as defined in BOLT-09.  A
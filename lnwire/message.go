@@ -51,6 +51,14 @@ const (
 	MsgQueryChannelRange                   = 263
 	MsgReplyChannelRange                   = 264
 	MsgGossipTimestampRange                = 265
+
+	// MsgQueryChannelSketch and MsgReplyChannelSketch are experimental
+	// messages used for channel graph syncs based on set reconciliation.
+	// They are odd, so peers that don't understand them ignore them. They
+	// are taken from the experimental range and only sent to peers that
+	// signal the gossip-set-recon feature bit.
+	MsgQueryChannelSketch = 32761
+	MsgReplyChannelSketch = 32763
 )
 
 // ErrorEncodeMessage is used when failed to encode the message payload.
@@ -134,6 +142,10 @@ func (t MessageType) String() string {
 		return "ReplyChannelRange"
 	case MsgGossipTimestampRange:
 		return "GossipTimestampRange"
+	case MsgQueryChannelSketch:
+		return "QueryChannelSketch"
+	case MsgReplyChannelSketch:
+		return "ReplyChannelSketch"
 	default:
 		return "<unknown>"
 	}
@@ -236,6 +248,10 @@ func makeEmptyMessage(msgType MessageType) (Message, error) {
 		msg = &ReplyChannelRange{}
 	case MsgGossipTimestampRange:
 		msg = &GossipTimestampRange{}
+	case MsgQueryChannelSketch:
+		msg = &QueryChannelSketch{}
+	case MsgReplyChannelSketch:
+		msg = &ReplyChannelSketch{}
 	default:
 		if msgType < CustomTypeStart {
 			return nil, &UnknownMessage{msgType}
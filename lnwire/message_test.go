@@ -289,6 +289,8 @@ func makeAllMessages(t testing.TB, r *rand.Rand) []lnwire.Message {
 	msgAll = append(msgAll, newMsgGossipTimestampRange(t, r))
 	msgAll = append(msgAll, newMsgQueryShortChanIDsZlib(t, r))
 	msgAll = append(msgAll, newMsgReplyChannelRangeZlib(t, r))
+	msgAll = append(msgAll, newMsgQueryChannelSketch(t, r))
+	msgAll = append(msgAll, newMsgReplyChannelSketch(t, r))
 
 	return msgAll
 }
@@ -841,6 +843,46 @@ func newMsgReplyChannelRangeZlib(t testing.TB,
 	return msg
 }
 
+func newMsgQueryChannelSketch(t testing.TB,
+	r *rand.Rand) *lnwire.QueryChannelSketch {
+
+	t.Helper()
+
+	msg := lnwire.NewQueryChannelSketch()
+
+	_, err := rand.Read(msg.ChainHash[:])
+	require.NoError(t, err, "unable to read chain hash")
+
+	msg.FirstBlockHeight = r.Uint32()
+	msg.NumBlocks = r.Uint32()
+	msg.NumCells = uint16(r.Int31n(math.MaxUint16))
+	msg.Salt = r.Uint64()
+	msg.ExtraData = createExtraData(t, r)
+
+	return msg
+}
+
+func newMsgReplyChannelSketch(t testing.TB,
+	r *rand.Rand) *lnwire.ReplyChannelSketch {
+
+	t.Helper()
+
+	msg := lnwire.NewReplyChannelSketch()
+
+	_, err := rand.Read(msg.ChainHash[:])
+	require.NoError(t, err, "unable to read chain hash")
+
+	msg.FirstBlockHeight = r.Uint32()
+	msg.NumBlocks = r.Uint32()
+	msg.NumChannels = r.Uint32()
+	msg.Sketch = make(lnwire.ChannelSketch, 16*r.Int31n(1000))
+	_, err = r.Read(msg.Sketch)
+	require.NoError(t, err, "unable to read sketch")
+	msg.ExtraData = createExtraData(t, r)
+
+	return msg
+}
+
 func newMsgGossipTimestampRange(t testing.TB,
 	r *rand.Rand) *lnwire.GossipTimestampRange {
 
@@ -0,0 +1,117 @@
+package lnwire
+
+import (
+	"bytes"
+	"io"
+	"math"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// QueryChannelSketch is an experimental message sent by a node in order to
+// request a sketch of the set of short channel IDs that the receiving node
+// knows of within a block range. Unlike the reply to a QueryChannelRange, the
+// size of the sketch only depends on the number of channels that the two nodes
+// disagree on, which allows syncing the channel graph without transferring
+// every short channel ID. This message may only be sent to peers that signal
+// the GossipSetReconOptional feature bit.
+type QueryChannelSketch struct {
+	// ChainHash denotes the target chain that we're trying to synchronize
+	// channel graph state for.
+	ChainHash chainhash.Hash
+
+	// FirstBlockHeight is the first block in the query range. The sketch
+	// should cover all short channel IDs from this block until this block
+	// plus the specified number of blocks.
+	FirstBlockHeight uint32
+
+	// NumBlocks is the number of blocks beyond the first block that short
+	// channel IDs should be included in the sketch for.
+	NumBlocks uint32
+
+	// NumCells is the number of cells that the sketch of the reply should
+	// have. The more cells a sketch has, the larger the set difference
+	// that can be recovered from it.
+	NumCells uint16
+
+	// Salt is mixed into the hashes of the short channel IDs in the
+	// sketch. The querying node picks a new salt for each query, so that
+	// peers can't craft channels that collide within the sketch.
+	Salt uint64
+
+	// ExtraData is the set of data that was appended to this message to
+	// fill out the full maximum transport message size. These fields can
+	// be used to specify optional data such as custom TLV fields.
+	ExtraData ExtraOpaqueData
+}
+
+// NewQueryChannelSketch creates a new empty QueryChannelSketch message.
+func NewQueryChannelSketch() *QueryChannelSketch {
+	return &QueryChannelSketch{}
+}
+
+// A compile time check to ensure QueryChannelSketch implements the
+// lnwire.Message interface.
+var _ Message = (*QueryChannelSketch)(nil)
+
+// Decode deserializes a serialized QueryChannelSketch message stored in the
+// passed io.Reader observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (q *QueryChannelSketch) Decode(r io.Reader, pver uint32) error {
+	return ReadElements(r,
+		q.ChainHash[:],
+		&q.FirstBlockHeight,
+		&q.NumBlocks,
+		&q.NumCells,
+		&q.Salt,
+		&q.ExtraData,
+	)
+}
+
+// Encode serializes the target QueryChannelSketch into the passed io.Writer
+// observing the protocol version specified.
+//
+// This is part of the lnwire.Message interface.
+func (q *QueryChannelSketch) Encode(w *bytes.Buffer, pver uint32) error {
+	if err := WriteBytes(w, q.ChainHash[:]); err != nil {
+		return err
+	}
+
+	if err := WriteUint32(w, q.FirstBlockHeight); err != nil {
+		return err
+	}
+
+	if err := WriteUint32(w, q.NumBlocks); err != nil {
+		return err
+	}
+
+	if err := WriteUint16(w, q.NumCells); err != nil {
+		return err
+	}
+
+	if err := WriteUint64(w, q.Salt); err != nil {
+		return err
+	}
+
+	return WriteBytes(w, q.ExtraData)
+}
+
+// MsgType returns the integer uniquely identifying this message type on the
+// wire.
+//
+// This is part of the lnwire.Message interface.
+func (q *QueryChannelSketch) MsgType() MessageType {
+	return MsgQueryChannelSketch
+}
+
+// LastBlockHeight returns the last block height covered by the range of a
+// QueryChannelSketch message.
+func (q *QueryChannelSketch) LastBlockHeight() uint32 {
+	// Handle overflows by casting to uint64.
+	lastBlockHeight := uint64(q.FirstBlockHeight) + uint64(q.NumBlocks) - 1
+	if lastBlockHeight > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(lastBlockHeight)
+}
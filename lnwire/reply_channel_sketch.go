@@ -0,0 +1,103 @@
+package lnwire
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// ChannelSketch is the opaque serialized sketch of a set of short channel IDs
+// as sent within a ReplyChannelSketch message.
+type ChannelSketch []byte
+
+// ReplyChannelSketch is the response to the QueryChannelSketch message. It
+// repeats the block range of the query and carries the sketch of the short
+// channel IDs that the responding node knows of within that range.
+type ReplyChannelSketch struct {
+	// ChainHash denotes the target chain that we're trying to synchronize
+	// channel graph state for.
+	ChainHash chainhash.Hash
+
+	// FirstBlockHeight is the first block in the query range.
+	FirstBlockHeight uint32
+
+	// NumBlocks is the number of blocks beyond the first block that the
+	// sketch covers.
+	NumBlocks uint32
+
+	// NumChannels is the number of short channel IDs within the sketch.
+	// Together with the size of its own set, this gives the querying node
+	// a lower bound on the size of the set difference.
+	NumChannels uint32
+
+	// Sketch is the serialized sketch of the short channel IDs. An empty
+	// sketch signals that the responding node is unable to reply to the
+	// query, e.g. because it is on a different chain.
+	Sketch ChannelSketch
+
+	// ExtraData is the set of data that was appended to this message to
+	// fill out the full maximum transport message size. These fields can
+	// be used to specify optional data such as custom TLV fields.
+	ExtraData ExtraOpaqueData
+}
+
+// NewReplyChannelSketch creates a new empty ReplyChannelSketch message.
+func NewReplyChannelSketch() *ReplyChannelSketch {
+	return &ReplyChannelSketch{}
+}
+
+// A compile time check to ensure ReplyChannelSketch implements the
+// lnwire.Message interface.
+var _ Message = (*ReplyChannelSketch)(nil)
+
+// Decode deserializes a serialized ReplyChannelSketch message stored in the
+// passed io.Reader observing the specified protocol version.
+//
+// This is part of the lnwire.Message interface.
+func (c *ReplyChannelSketch) Decode(r io.Reader, pver uint32) error {
+	return ReadElements(r,
+		c.ChainHash[:],
+		&c.FirstBlockHeight,
+		&c.NumBlocks,
+		&c.NumChannels,
+		&c.Sketch,
+		&c.ExtraData,
+	)
+}
+
+// Encode serializes the target ReplyChannelSketch into the passed io.Writer
+// observing the protocol version specified.
+//
+// This is part of the lnwire.Message interface.
+func (c *ReplyChannelSketch) Encode(w *bytes.Buffer, pver uint32) error {
+	if err := WriteBytes(w, c.ChainHash[:]); err != nil {
+		return err
+	}
+
+	if err := WriteUint32(w, c.FirstBlockHeight); err != nil {
+		return err
+	}
+
+	if err := WriteUint32(w, c.NumBlocks); err != nil {
+		return err
+	}
+
+	if err := WriteUint32(w, c.NumChannels); err != nil {
+		return err
+	}
+
+	if err := WriteChannelSketch(w, c.Sketch); err != nil {
+		return err
+	}
+
+	return WriteBytes(w, c.ExtraData)
+}
+
+// MsgType returns the integer uniquely identifying this message type on the
+// wire.
+//
+// This is part of the lnwire.Message interface.
+func (c *ReplyChannelSketch) MsgType() MessageType {
+	return MsgReplyChannelSketch
+}
@@ -0,0 +1,151 @@
+package lnwire
+
+import (
+	"io"
+
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+const (
+	// FeeRecordType is the type of the experimental record used to
+	// communicate the inbound fee of a channel within the extra data of a
+	// channel update.
+	FeeRecordType tlv.Type = 55555
+
+	// feeRateParts is the total number of parts used to express fee rates.
+	feeRateParts = 1e6
+)
+
+// Fee represents a fee schedule that may be negative, such as the inbound fee
+// a node charges, or the discount it grants, for HTLCs arriving over a
+// channel.
+type Fee struct {
+	// BaseFee is the fixed fee in milli-satoshis.
+	BaseFee int32
+
+	// FeeRate is the proportional fee in parts per million.
+	FeeRate int32
+}
+
+// CalcFee returns the fee for the given amount. The result is negative if the
+// fee schedule is a discount.
+func (f Fee) CalcFee(amt MilliSatoshi) int64 {
+	return int64(f.BaseFee) + int64(amt)*int64(f.FeeRate)/feeRateParts
+}
+
+// IsZero returns true if neither a base fee nor a fee rate is set.
+func (f Fee) IsZero() bool {
+	return f.BaseFee == 0 && f.FeeRate == 0
+}
+
+// Record returns a TLV record that can be used to encode/decode the Fee type
+// from a given TLV stream.
+func (f *Fee) Record() tlv.Record {
+	return tlv.MakeStaticRecord(FeeRecordType, f, 8, feeEncoder, feeDecoder)
+}
+
+// feeEncoder is a custom TLV encoder for the Fee record.
+func feeEncoder(w io.Writer, val interface{}, buf *[8]byte) error {
+	if v, ok := val.(*Fee); ok {
+		if err := tlv.EUint32T(w, uint32(v.BaseFee), buf); err != nil {
+			return err
+		}
+
+		return tlv.EUint32T(w, uint32(v.FeeRate), buf)
+	}
+
+	return tlv.NewTypeForEncodingErr(val, "lnwire.Fee")
+}
+
+// feeDecoder is a custom TLV decoder for the Fee record.
+func feeDecoder(r io.Reader, val interface{}, buf *[8]byte, l uint64) error {
+	if v, ok := val.(*Fee); ok && l == 8 {
+		var baseFee, feeRate uint32
+		if err := tlv.DUint32(r, &baseFee, buf, 4); err != nil {
+			return err
+		}
+		if err := tlv.DUint32(r, &feeRate, buf, 4); err != nil {
+			return err
+		}
+
+		v.BaseFee = int32(baseFee)
+		v.FeeRate = int32(feeRate)
+
+		return nil
+	}
+
+	return tlv.NewTypeForDecodingErr(val, "lnwire.Fee", l, 8)
+}
+
+// ExtractInboundFee returns the inbound fee contained in the given extra data
+// of a channel update. A zero fee is returned if the extra data doesn't carry
+// an inbound fee.
+func ExtractInboundFee(extraData ExtraOpaqueData) (Fee, error) {
+	var fee Fee
+	if len(extraData) == 0 {
+		return fee, nil
+	}
+
+	tlvs, err := extraData.ExtractRecords(&fee)
+	if err != nil {
+		return Fee{}, err
+	}
+
+	if _, ok := tlvs[FeeRecordType]; !ok {
+		return Fee{}, nil
+	}
+
+	return fee, nil
+}
+
+// rawRecord is a record producer for a TLV record that is kept as raw bytes.
+type rawRecord struct {
+	typ tlv.Type
+	val []byte
+}
+
+// Record returns a TLV record that encodes the raw value.
+func (r *rawRecord) Record() tlv.Record {
+	return tlv.MakePrimitiveRecord(r.typ, &r.val)
+}
+
+// SetInboundFee returns a copy of the given extra data of a channel update
+// with the inbound fee record replaced by the given fee. A zero fee removes
+// the record. All other records are preserved.
+func SetInboundFee(extraData ExtraOpaqueData, fee Fee) (ExtraOpaqueData,
+	error) {
+
+	var producers []tlv.RecordProducer
+	if len(extraData) != 0 {
+		tlvs, err := extraData.ExtractRecords()
+		if err != nil {
+			return nil, err
+		}
+
+		for typ, val := range tlvs {
+			if typ == FeeRecordType {
+				continue
+			}
+
+			producers = append(producers, &rawRecord{
+				typ: typ,
+				val: val,
+			})
+		}
+	}
+
+	if !fee.IsZero() {
+		producers = append(producers, &fee)
+	}
+
+	var newData ExtraOpaqueData
+	if len(producers) == 0 {
+		return newData, nil
+	}
+
+	if err := newData.PackRecords(producers...); err != nil {
+		return nil, err
+	}
+
+	return newData, nil
+}
@@ -0,0 +1,80 @@
+package lnwire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestFeeEncodeDecode tests that we're able to properly encode and decode
+// negative and positive fees within TLV streams.
+func TestFeeEncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	fee := Fee{
+		BaseFee: -1000,
+		FeeRate: 250,
+	}
+
+	var extraData ExtraOpaqueData
+	require.NoError(t, extraData.PackRecords(&fee))
+
+	var fee2 Fee
+	tlvs, err := extraData.ExtractRecords(&fee2)
+	require.NoError(t, err)
+
+	require.Contains(t, tlvs, FeeRecordType)
+	require.Equal(t, fee, fee2)
+
+	fee3, err := ExtractInboundFee(extraData)
+	require.NoError(t, err)
+	require.Equal(t, fee, fee3)
+
+	// Extra data without a fee record results in a zero fee.
+	fee4, err := ExtractInboundFee(nil)
+	require.NoError(t, err)
+	require.True(t, fee4.IsZero())
+}
+
+// TestFeeCalcFee tests the calculation of positive and negative fees.
+func TestFeeCalcFee(t *testing.T) {
+	t.Parallel()
+
+	require.EqualValues(t, 1100, Fee{1000, 100}.CalcFee(1000000))
+	require.EqualValues(t, -1100, Fee{-1000, -100}.CalcFee(1000000))
+	require.EqualValues(t, 0, Fee{}.CalcFee(1000000))
+}
+
+// TestSetInboundFee tests that the inbound fee record can be replaced and
+// removed without affecting other records in the extra data.
+func TestSetInboundFee(t *testing.T) {
+	t.Parallel()
+
+	leaseExpiry := LeaseExpiry(1337)
+
+	var extraData ExtraOpaqueData
+	require.NoError(t, extraData.PackRecords(&leaseExpiry))
+
+	fee := Fee{BaseFee: -10, FeeRate: -20}
+	extraData, err := SetInboundFee(extraData, fee)
+	require.NoError(t, err)
+
+	fee2, err := ExtractInboundFee(extraData)
+	require.NoError(t, err)
+	require.Equal(t, fee, fee2)
+
+	// Removing the fee must leave the other record intact.
+	extraData, err = SetInboundFee(extraData, Fee{})
+	require.NoError(t, err)
+
+	var leaseExpiry2 LeaseExpiry
+	tlvs, err := extraData.ExtractRecords(&leaseExpiry2)
+	require.NoError(t, err)
+	require.NotContains(t, tlvs, FeeRecordType)
+	require.Equal(t, leaseExpiry, leaseExpiry2)
+
+	// Removing the fee from empty extra data results in no data.
+	extraData, err = SetInboundFee(nil, Fee{})
+	require.NoError(t, err)
+	require.Empty(t, extraData)
+}
@@ -241,6 +241,11 @@ func WritePongPayload(buf *bytes.Buffer, payload PongPayload) error {
 	return writeDataWithLength(buf, payload)
 }
 
+// WriteChannelSketch appends the sketch to the provided buffer.
+func WriteChannelSketch(buf *bytes.Buffer, sketch ChannelSketch) error {
+	return writeDataWithLength(buf, sketch)
+}
+
 // WriteWarningData appends the data to the provided buffer.
 func WriteWarningData(buf *bytes.Buffer, data WarningData) error {
 	return writeDataWithLength(buf, data)
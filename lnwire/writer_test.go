@@ -305,6 +305,20 @@ func TestWritePongPayload(t *testing.T) {
 	require.Equal(t, expectedBytes, buf.Bytes())
 }
 
+func TestWriteChannelSketch(t *testing.T) {
+	buf := new(bytes.Buffer)
+	data := ChannelSketch{1, 1, 1}
+	expectedBytes := []byte{
+		0, 3, // First two bytes encode the length.
+		1, 1, 1, // The actual data.
+	}
+
+	err := WriteChannelSketch(buf, data)
+
+	require.NoError(t, err)
+	require.Equal(t, expectedBytes, buf.Bytes())
+}
+
 func TestWriteErrorData(t *testing.T) {
 	buf := new(bytes.Buffer)
 	data := ErrorData{1, 1, 1}
@@ -0,0 +1,108 @@
+package macaroons
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnwire"
+)
+
+var (
+	// budgetBucketName is the name of the bucket that stores the amounts
+	// spent from the payment budgets of macaroons, keyed by the budget id.
+	budgetBucketName = []byte("macspendingbudgets")
+)
+
+// BudgetStore tracks the amounts spent from the payment budgets of macaroons.
+type BudgetStore interface {
+	// ChargeBudgets charges the given amount to all budgets at the given
+	// time. If the amount exceeds the remainder of any of the budgets,
+	// none of them is charged and an error is returned.
+	ChargeBudgets(budgets []PaymentBudget, amt lnwire.MilliSatoshi,
+		now time.Time) error
+}
+
+// budgetState is the state of a payment budget in its current window.
+type budgetState struct {
+	// windowStart is the start of the current window.
+	windowStart time.Time
+
+	// spent is the amount spent in the current window.
+	spent lnwire.MilliSatoshi
+}
+
+// encode serializes the budget state.
+func (s *budgetState) encode() []byte {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(s.windowStart.UnixNano()))
+	binary.BigEndian.PutUint64(b[8:], uint64(s.spent))
+
+	return b[:]
+}
+
+// decodeBudgetState deserializes a budget state.
+func decodeBudgetState(b []byte) (*budgetState, error) {
+	if len(b) != 16 {
+		return nil, fmt.Errorf("invalid budget state length %d", len(b))
+	}
+
+	return &budgetState{
+		windowStart: time.Unix(
+			0, int64(binary.BigEndian.Uint64(b[:8])),
+		),
+		spent: lnwire.MilliSatoshi(binary.BigEndian.Uint64(b[8:])),
+	}, nil
+}
+
+// ChargeBudgets charges the given amount to all budgets at the given time. If
+// the amount exceeds the remainder of any of the budgets, none of them is
+// charged and an error is returned.
+//
+// NOTE: Part of the BudgetStore interface.
+func (r *RootKeyStorage) ChargeBudgets(budgets []PaymentBudget,
+	amt lnwire.MilliSatoshi, now time.Time) error {
+
+	return kvdb.Update(r.Backend, func(tx kvdb.RwTx) error {
+		bucket, err := tx.CreateTopLevelBucket(budgetBucketName)
+		if err != nil {
+			return err
+		}
+
+		for _, budget := range budgets {
+			state := &budgetState{windowStart: now}
+
+			stateBytes := bucket.Get(budget.ID[:])
+			if stateBytes != nil {
+				state, err = decodeBudgetState(stateBytes)
+				if err != nil {
+					return err
+				}
+			}
+
+			// A new window starts with the first payment after the
+			// previous window ended.
+			windowEnd := state.windowStart.Add(budget.Window)
+			if !now.Before(windowEnd) {
+				state = &budgetState{windowStart: now}
+				windowEnd = now.Add(budget.Window)
+			}
+
+			if state.spent+amt > budget.Amount {
+				return fmt.Errorf("%w: payment of %v exceeds "+
+					"remaining budget of %v until %v",
+					ErrSpendingLimitExceeded, amt,
+					budget.Amount-state.spent, windowEnd)
+			}
+			state.spent += amt
+
+			err := bucket.Put(budget.ID[:], state.encode())
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func() {})
+}
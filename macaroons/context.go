@@ -9,6 +9,10 @@ var (
 	// RootKeyIDContextKey is the key to get rootKeyID from context.
 	RootKeyIDContextKey = contextKey{"rootkeyid"}
 
+	// fullMethodContextKey is the key to get the called gRPC method from
+	// the context passed to the caveat checkers.
+	fullMethodContextKey = contextKey{"fullmethod"}
+
 	// ErrContextRootKeyID is used when the supplied context doesn't have
 	// a root key ID.
 	ErrContextRootKeyID = fmt.Errorf("failed to read root key ID " +
@@ -42,3 +46,18 @@ func RootKeyIDFromContext(ctx context.Context) ([]byte, error) {
 
 	return id, nil
 }
+
+// contextWithFullMethod passes the called gRPC method to the context, so the
+// caveat checkers can take it into account.
+func contextWithFullMethod(ctx context.Context,
+	fullMethod string) context.Context {
+
+	return context.WithValue(ctx, fullMethodContextKey, fullMethod)
+}
+
+// fullMethodFromContext returns the called gRPC method from the context, or
+// an empty string if it isn't known.
+func fullMethodFromContext(ctx context.Context) string {
+	fullMethod, _ := ctx.Value(fullMethodContextKey).(string)
+	return fullMethod
+}
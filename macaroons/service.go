@@ -208,6 +208,9 @@ func (svc *Service) CheckMacAuth(ctx context.Context, macBytes []byte,
 		return err
 	}
 
+	// The caveat checkers may depend on the method being called.
+	ctx = contextWithFullMethod(ctx, fullMethod)
+
 	// Check the method being called against the permitted operation, the
 	// expiration time and IP address and return the result.
 	authChecker := svc.Checker.Auth(macaroon.Slice{mac})
@@ -0,0 +1,478 @@
+package macaroons
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"gopkg.in/macaroon-bakery.v2/bakery/checkers"
+	macaroon "gopkg.in/macaroon.v2"
+)
+
+const (
+	// CondMaxPayment is the first party caveat condition name that limits
+	// the amount of each off-chain payment, in millisatoshis. It is
+	// encoded as "lnd-max-payment <msat>".
+	CondMaxPayment = "lnd-max-payment"
+
+	// CondPaymentBudget is the first party caveat condition name that
+	// limits the total amount of off-chain payments in a time window. It
+	// is encoded as "lnd-payment-budget <msat> <window-seconds>".
+	CondPaymentBudget = "lnd-payment-budget"
+
+	// CondAllowedDestinations is the first party caveat condition name
+	// that limits the destinations of off-chain payments. It is encoded as
+	// "lnd-allowed-dests <pubkey>[,<pubkey>...]" with hex encoded public
+	// keys.
+	CondAllowedDestinations = "lnd-allowed-dests"
+
+	// CondMaxOnChainSend is the first party caveat condition name that
+	// limits the amount of each on-chain send, in satoshis. It is encoded
+	// as "lnd-max-onchain-send <sat>".
+	CondMaxOnChainSend = "lnd-max-onchain-send"
+)
+
+var (
+	// ErrSpendingLimitExceeded is returned when a payment or on-chain send
+	// exceeds a spending limit of the macaroon.
+	ErrSpendingLimitExceeded = errors.New("macaroon spending limit " +
+		"exceeded")
+
+	// ErrDestinationNotAllowed is returned when a payment is sent to a
+	// destination that the macaroon doesn't allow.
+	ErrDestinationNotAllowed = errors.New("payment destination not " +
+		"allowed by macaroon")
+
+	// ErrSpendingLimitUnenforced is returned when a macaroon with spending
+	// limits is used for a call that can move funds without being checked
+	// against the limits.
+	ErrSpendingLimitUnenforced = errors.New("call not allowed with " +
+		"spending limited macaroon")
+
+	// ErrBudgetsUnsupported is returned when a macaroon with a payment
+	// budget is used, but the root key store can't track budgets.
+	ErrBudgetsUnsupported = errors.New("payment budgets not supported " +
+		"by root key store")
+
+	// unenforcedSpendingMethods are the calls that can move funds, but
+	// that aren't checked against the spending limits of a macaroon, and
+	// the calls that change macaroons and their root keys, which could
+	// be used to create a macaroon without the limits. They are rejected
+	// for all macaroons with spending limits.
+	unenforcedSpendingMethods = map[string]struct{}{
+		"/lnrpc.Lightning/OpenChannel":            {},
+		"/lnrpc.Lightning/OpenChannelSync":        {},
+		"/lnrpc.Lightning/BatchOpenChannel":       {},
+		"/lnrpc.Lightning/FundingStateStep":       {},
+		"/lnrpc.Lightning/CloseChannel":           {},
+		"/walletrpc.WalletKit/PublishTransaction": {},
+		"/walletrpc.WalletKit/FundPsbt":           {},
+		"/walletrpc.WalletKit/SignPsbt":           {},
+		"/walletrpc.WalletKit/FinalizePsbt":       {},
+		"/signrpc.Signer/SignOutputRaw":           {},
+		"/signrpc.Signer/ComputeInputScript":      {},
+		"/signrpc.Signer/MuSig2Sign":              {},
+		"/routerrpc.Router/PaymentInterceptor":    {},
+		"/lnrpc.Lightning/BakeMacaroon":           {},
+		"/lnrpc.Lightning/DeleteMacaroonID":       {},
+	}
+)
+
+// PaymentBudget limits the total amount of off-chain payments in a time
+// window. A window starts with the first payment after the previous window
+// ended.
+type PaymentBudget struct {
+	// ID identifies the budget in the budget store. It commits to the
+	// macaroon id and all caveats up to the budget caveat, so macaroons
+	// derived from a macaroon with a budget share its budget.
+	ID [sha256.Size]byte
+
+	// Amount is the total amount that may be paid in a window.
+	Amount lnwire.MilliSatoshi
+
+	// Window is the length of a window.
+	Window time.Duration
+}
+
+// SpendingLimits are the spending limits of a macaroon. If a macaroon has
+// several caveats of the same kind, all of them apply.
+type SpendingLimits struct {
+	// MaxPayment, if set, is the maximum amount of an off-chain payment.
+	MaxPayment *lnwire.MilliSatoshi
+
+	// Budgets are the payment budgets of the macaroon.
+	Budgets []PaymentBudget
+
+	// AllowedDestinations, if not nil, are the only destinations that
+	// off-chain payments may be sent to.
+	AllowedDestinations map[route.Vertex]struct{}
+
+	// MaxOnChainSend, if set, is the maximum amount of an on-chain send.
+	MaxOnChainSend *btcutil.Amount
+}
+
+// MaxPaymentConstraint restricts the amount of each off-chain payment that
+// is made with the macaroon.
+func MaxPaymentConstraint(
+	amt lnwire.MilliSatoshi) func(*macaroon.Macaroon) error {
+
+	return func(mac *macaroon.Macaroon) error {
+		caveat := checkers.Condition(
+			CondMaxPayment, strconv.FormatUint(uint64(amt), 10),
+		)
+		return mac.AddFirstPartyCaveat([]byte(caveat))
+	}
+}
+
+// PaymentBudgetConstraint restricts the total amount of off-chain payments
+// made with the macaroon, and any macaroon derived from it, in each window of
+// the given length.
+func PaymentBudgetConstraint(amt lnwire.MilliSatoshi,
+	window time.Duration) func(*macaroon.Macaroon) error {
+
+	return func(mac *macaroon.Macaroon) error {
+		if window < time.Second {
+			return fmt.Errorf("budget window must be at least " +
+				"one second")
+		}
+
+		caveat := checkers.Condition(CondPaymentBudget, fmt.Sprintf(
+			"%d %d", uint64(amt), int64(window/time.Second),
+		))
+		return mac.AddFirstPartyCaveat([]byte(caveat))
+	}
+}
+
+// AllowedDestinationsConstraint restricts the destinations of off-chain
+// payments made with the macaroon to the given nodes.
+func AllowedDestinationsConstraint(
+	dests []route.Vertex) func(*macaroon.Macaroon) error {
+
+	return func(mac *macaroon.Macaroon) error {
+		if len(dests) == 0 {
+			return fmt.Errorf("at least one destination must be " +
+				"allowed")
+		}
+
+		hexDests := make([]string, 0, len(dests))
+		for _, dest := range dests {
+			hexDests = append(hexDests, dest.String())
+		}
+
+		caveat := checkers.Condition(
+			CondAllowedDestinations, strings.Join(hexDests, ","),
+		)
+		return mac.AddFirstPartyCaveat([]byte(caveat))
+	}
+}
+
+// MaxOnChainSendConstraint restricts the amount of each on-chain send that is
+// made with the macaroon.
+func MaxOnChainSendConstraint(
+	amt btcutil.Amount) func(*macaroon.Macaroon) error {
+
+	return func(mac *macaroon.Macaroon) error {
+		if amt < 0 {
+			return fmt.Errorf("on-chain send limit must not be " +
+				"negative")
+		}
+
+		caveat := checkers.Condition(
+			CondMaxOnChainSend, strconv.FormatInt(int64(amt), 10),
+		)
+		return mac.AddFirstPartyCaveat([]byte(caveat))
+	}
+}
+
+// parseMaxPayment parses the condition of a max payment caveat.
+func parseMaxPayment(arg string) (lnwire.MilliSatoshi, error) {
+	amt, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return lnwire.MilliSatoshi(amt), nil
+}
+
+// parsePaymentBudget parses the condition of a payment budget caveat.
+func parsePaymentBudget(arg string) (lnwire.MilliSatoshi, time.Duration,
+	error) {
+
+	parts := strings.Split(arg, " ")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected amount and window, got %q",
+			arg)
+	}
+
+	amt, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	seconds, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	if seconds == 0 {
+		return 0, 0, fmt.Errorf("budget window must not be zero")
+	}
+
+	return lnwire.MilliSatoshi(amt), time.Duration(seconds) * time.Second,
+		nil
+}
+
+// parseAllowedDestinations parses the condition of an allowed destinations
+// caveat.
+func parseAllowedDestinations(arg string) (map[route.Vertex]struct{},
+	error) {
+
+	dests := make(map[route.Vertex]struct{})
+	for _, hexDest := range strings.Split(arg, ",") {
+		dest, err := route.NewVertexFromStr(hexDest)
+		if err != nil {
+			return nil, err
+		}
+		dests[dest] = struct{}{}
+	}
+
+	return dests, nil
+}
+
+// parseMaxOnChainSend parses the condition of a max on-chain send caveat.
+func parseMaxOnChainSend(arg string) (btcutil.Amount, error) {
+	amt, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if amt < 0 {
+		return 0, fmt.Errorf("negative amount %v", amt)
+	}
+
+	return btcutil.Amount(amt), nil
+}
+
+// spendingChecker returns a checker function for a spending limit caveat. The
+// limit itself is enforced by the calls that spend funds, so the checker
+// only verifies the caveat, and rejects the calls that can move funds without
+// being checked against it.
+func spendingChecker(parse func(arg string) error) checkers.Func {
+	return func(ctx context.Context, cond, arg string) error {
+		if err := parse(arg); err != nil {
+			return fmt.Errorf("invalid %s caveat: %w", cond, err)
+		}
+
+		fullMethod := fullMethodFromContext(ctx)
+		if _, ok := unenforcedSpendingMethods[fullMethod]; ok {
+			return fmt.Errorf("%w: %s", ErrSpendingLimitUnenforced,
+				fullMethod)
+		}
+
+		return nil
+	}
+}
+
+// MaxPaymentChecker verifies max payment caveats. It is of the `Checker`
+// type.
+func MaxPaymentChecker() (string, checkers.Func) {
+	return CondMaxPayment, spendingChecker(func(arg string) error {
+		_, err := parseMaxPayment(arg)
+		return err
+	})
+}
+
+// PaymentBudgetChecker verifies payment budget caveats. It is of the
+// `Checker` type.
+func PaymentBudgetChecker() (string, checkers.Func) {
+	return CondPaymentBudget, spendingChecker(func(arg string) error {
+		_, _, err := parsePaymentBudget(arg)
+		return err
+	})
+}
+
+// AllowedDestinationsChecker verifies allowed destinations caveats. It is of
+// the `Checker` type.
+func AllowedDestinationsChecker() (string, checkers.Func) {
+	return CondAllowedDestinations, spendingChecker(func(arg string) error {
+		_, err := parseAllowedDestinations(arg)
+		return err
+	})
+}
+
+// MaxOnChainSendChecker verifies max on-chain send caveats. It is of the
+// `Checker` type.
+func MaxOnChainSendChecker() (string, checkers.Func) {
+	return CondMaxOnChainSend, spendingChecker(func(arg string) error {
+		_, err := parseMaxOnChainSend(arg)
+		return err
+	})
+}
+
+// SpendingLimitsFromMacaroon returns the spending limits of the given
+// macaroon.
+func SpendingLimitsFromMacaroon(mac *macaroon.Macaroon) (*SpendingLimits,
+	error) {
+
+	limits := &SpendingLimits{}
+
+	// The id of a budget commits to the caveats before it, which can't
+	// be changed by deriving a macaroon.
+	budgetHash := sha256.New()
+	_, _ = budgetHash.Write(mac.Id())
+
+	for _, caveat := range mac.Caveats() {
+		_, _ = budgetHash.Write(caveat.Id)
+
+		if caveat.VerificationId != nil {
+			continue
+		}
+
+		cond, arg, err := checkers.ParseCaveat(string(caveat.Id))
+		if err != nil {
+			continue
+		}
+
+		switch cond {
+		case CondMaxPayment:
+			amt, err := parseMaxPayment(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			if limits.MaxPayment == nil ||
+				amt < *limits.MaxPayment {
+
+				limits.MaxPayment = &amt
+			}
+
+		case CondPaymentBudget:
+			amt, window, err := parsePaymentBudget(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			budget := PaymentBudget{
+				Amount: amt,
+				Window: window,
+			}
+			copy(budget.ID[:], budgetHash.Sum(nil))
+			limits.Budgets = append(limits.Budgets, budget)
+
+		case CondAllowedDestinations:
+			dests, err := parseAllowedDestinations(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			// Only destinations that all caveats allow are
+			// allowed.
+			if limits.AllowedDestinations == nil {
+				limits.AllowedDestinations = dests
+				continue
+			}
+			for dest := range limits.AllowedDestinations {
+				if _, ok := dests[dest]; !ok {
+					delete(limits.AllowedDestinations, dest)
+				}
+			}
+
+		case CondMaxOnChainSend:
+			amt, err := parseMaxOnChainSend(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			if limits.MaxOnChainSend == nil ||
+				amt < *limits.MaxOnChainSend {
+
+				limits.MaxOnChainSend = &amt
+			}
+		}
+	}
+
+	return limits, nil
+}
+
+// spendingLimitsFromContext returns the spending limits of the macaroon of
+// the request in the given context. Requests without a macaroon have no
+// limits.
+func spendingLimitsFromContext(ctx context.Context) (*SpendingLimits, error) {
+	macHex, err := RawMacaroonFromContext(ctx)
+	if err != nil {
+		return &SpendingLimits{}, nil
+	}
+
+	macBytes, err := hex.DecodeString(macHex)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := &macaroon.Macaroon{}
+	if err := mac.UnmarshalBinary(macBytes); err != nil {
+		return nil, err
+	}
+
+	return SpendingLimitsFromMacaroon(mac)
+}
+
+// CheckPayment checks an off-chain payment against the spending limits of the
+// macaroon of the request in the given context, and charges it to the budgets
+// of the macaroon. The amount is the most that the payment can spend, which
+// includes its fees. Payments are charged when they are dispatched, so failed
+// payments count against the budgets too.
+func (svc *Service) CheckPayment(ctx context.Context, dest route.Vertex,
+	amt lnwire.MilliSatoshi) error {
+
+	limits, err := spendingLimitsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxPayment != nil && amt > *limits.MaxPayment {
+		return fmt.Errorf("%w: payment of %v above maximum of %v",
+			ErrSpendingLimitExceeded, amt, *limits.MaxPayment)
+	}
+
+	if limits.AllowedDestinations != nil {
+		if _, ok := limits.AllowedDestinations[dest]; !ok {
+			return fmt.Errorf("%w: %v", ErrDestinationNotAllowed,
+				dest)
+		}
+	}
+
+	if len(limits.Budgets) == 0 {
+		return nil
+	}
+
+	budgetStore, ok := svc.rks.(BudgetStore)
+	if !ok {
+		return ErrBudgetsUnsupported
+	}
+
+	return budgetStore.ChargeBudgets(limits.Budgets, amt, time.Now())
+}
+
+// CheckOnChainSend checks an on-chain send of the given amount against the
+// spending limits of the macaroon of the request in the given context.
+func (svc *Service) CheckOnChainSend(ctx context.Context,
+	amt btcutil.Amount) error {
+
+	limits, err := spendingLimitsFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxOnChainSend != nil && amt > *limits.MaxOnChainSend {
+		return fmt.Errorf("%w: on-chain send of %v above maximum of %v",
+			ErrSpendingLimitExceeded, amt, *limits.MaxOnChainSend)
+	}
+
+	return nil
+}
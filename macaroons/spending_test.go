@@ -0,0 +1,216 @@
+package macaroons_test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/macaroons"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/macaroon-bakery.v2/bakery"
+	macaroon "gopkg.in/macaroon.v2"
+)
+
+// macaroonContext returns an incoming request context carrying the given
+// macaroon.
+func macaroonContext(t *testing.T, mac *macaroon.Macaroon) context.Context {
+	macBytes, err := mac.MarshalBinary()
+	require.NoError(t, err)
+
+	md := metadata.New(map[string]string{
+		"macaroon": hex.EncodeToString(macBytes),
+	})
+
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+// TestSpendingLimitsFromMacaroon asserts that the spending limits of all
+// caveats of a macaroon are combined.
+func TestSpendingLimitsFromMacaroon(t *testing.T) {
+	t.Parallel()
+
+	mac := createDummyMacaroon(t)
+
+	limits, err := macaroons.SpendingLimitsFromMacaroon(mac)
+	require.NoError(t, err)
+	require.Equal(t, &macaroons.SpendingLimits{}, limits)
+
+	dests := []route.Vertex{{1}, {2}, {3}}
+	parent, err := macaroons.AddConstraints(
+		mac, macaroons.MaxPaymentConstraint(5000),
+		macaroons.PaymentBudgetConstraint(100_000, time.Hour),
+		macaroons.AllowedDestinationsConstraint(dests),
+		macaroons.MaxOnChainSendConstraint(20_000),
+	)
+	require.NoError(t, err)
+
+	// A derived macaroon can only tighten the limits.
+	derived, err := macaroons.AddConstraints(
+		parent, macaroons.MaxPaymentConstraint(8000),
+		macaroons.MaxPaymentConstraint(4000),
+		macaroons.AllowedDestinationsConstraint(dests[1:]),
+		macaroons.MaxOnChainSendConstraint(10_000),
+		macaroons.PaymentBudgetConstraint(50_000, 24*time.Hour),
+	)
+	require.NoError(t, err)
+
+	parentLimits, err := macaroons.SpendingLimitsFromMacaroon(parent)
+	require.NoError(t, err)
+
+	limits, err = macaroons.SpendingLimitsFromMacaroon(derived)
+	require.NoError(t, err)
+	require.Equal(t, lnwire.MilliSatoshi(4000), *limits.MaxPayment)
+	require.Equal(t, btcutil.Amount(10_000), *limits.MaxOnChainSend)
+	require.Equal(t, map[route.Vertex]struct{}{
+		{2}: {}, {3}: {},
+	}, limits.AllowedDestinations)
+
+	// The derived macaroon shares the budget of its parent, and adds its
+	// own.
+	require.Len(t, limits.Budgets, 2)
+	require.Equal(t, parentLimits.Budgets[0], limits.Budgets[0])
+	require.Equal(t, lnwire.MilliSatoshi(50_000), limits.Budgets[1].Amount)
+	require.Equal(t, 24*time.Hour, limits.Budgets[1].Window)
+	require.NotEqual(t, limits.Budgets[0].ID, limits.Budgets[1].ID)
+}
+
+// TestChargeBudgets asserts that payments are charged to all budgets, and
+// that budgets are reset once their window ended.
+func TestChargeBudgets(t *testing.T) {
+	t.Parallel()
+
+	_, store := newTestStore(t)
+
+	hourly := macaroons.PaymentBudget{
+		ID:     [32]byte{1},
+		Amount: 1000,
+		Window: time.Hour,
+	}
+	daily := macaroons.PaymentBudget{
+		ID:     [32]byte{2},
+		Amount: 1500,
+		Window: 24 * time.Hour,
+	}
+	budgets := []macaroons.PaymentBudget{hourly, daily}
+
+	now := time.Unix(1600000000, 0)
+	require.NoError(t, store.ChargeBudgets(budgets, 600, now))
+
+	// The hourly budget is exceeded, so neither budget is charged.
+	err := store.ChargeBudgets(budgets, 600, now.Add(time.Minute))
+	require.ErrorIs(t, err, macaroons.ErrSpendingLimitExceeded)
+
+	require.NoError(t, store.ChargeBudgets(budgets, 400, now))
+
+	// Once the hourly window ended, the daily budget still applies.
+	now = now.Add(time.Hour)
+	err = store.ChargeBudgets(budgets, 600, now)
+	require.ErrorIs(t, err, macaroons.ErrSpendingLimitExceeded)
+	require.NoError(t, store.ChargeBudgets(budgets, 500, now))
+
+	// After a day, both budgets are available again.
+	now = now.Add(24 * time.Hour)
+	require.NoError(t, store.ChargeBudgets(budgets, 1000, now))
+}
+
+// TestSpendingLimits asserts that payments and on-chain sends are checked
+// against the spending limits of the macaroon of the request, and that
+// macaroons with spending limits can't be used for calls that aren't.
+func TestSpendingLimits(t *testing.T) {
+	t.Parallel()
+
+	db := setupTestRootKeyStorage(t)
+	rootKeyStore, err := macaroons.NewRootKeyStorage(db)
+	require.NoError(t, err)
+	service, err := macaroons.NewService(
+		rootKeyStore, "lnd", false, macaroons.MaxPaymentChecker,
+		macaroons.PaymentBudgetChecker,
+		macaroons.AllowedDestinationsChecker,
+		macaroons.MaxOnChainSendChecker,
+	)
+	require.NoError(t, err)
+	defer service.Close()
+
+	require.NoError(t, service.CreateUnlock(&defaultPw))
+
+	bakedMac, err := service.NewMacaroon(
+		context.Background(), macaroons.DefaultRootKeyID, testOperation,
+	)
+	require.NoError(t, err)
+
+	mac, err := macaroons.AddConstraints(
+		bakedMac.M(), macaroons.MaxPaymentConstraint(5000),
+		macaroons.PaymentBudgetConstraint(8000, time.Hour),
+		macaroons.AllowedDestinationsConstraint(
+			[]route.Vertex{{1}, {2}},
+		),
+		macaroons.MaxOnChainSendConstraint(20_000),
+	)
+	require.NoError(t, err)
+	ctx := macaroonContext(t, mac)
+
+	// The macaroon is valid for calls that enforce its limits, but not for
+	// calls that could move funds around them.
+	err = service.ValidateMacaroon(
+		ctx, []bakery.Op{testOperation},
+		"/routerrpc.Router/SendPaymentV2",
+	)
+	require.NoError(t, err)
+
+	err = service.ValidateMacaroon(
+		ctx, []bakery.Op{testOperation},
+		"/lnrpc.Lightning/OpenChannelSync",
+	)
+	require.ErrorContains(t, err, "spending limited macaroon")
+
+	// Nor can it be used to bake a macaroon without its limits, or to
+	// change root keys.
+	err = service.ValidateMacaroon(
+		ctx, []bakery.Op{testOperation},
+		"/lnrpc.Lightning/BakeMacaroon",
+	)
+	require.ErrorContains(t, err, "spending limited macaroon")
+
+	err = service.ValidateMacaroon(
+		ctx, []bakery.Op{testOperation},
+		"/lnrpc.Lightning/DeleteMacaroonID",
+	)
+	require.ErrorContains(t, err, "spending limited macaroon")
+
+	// Payments are checked against the maximum amount and destinations.
+	err = service.CheckPayment(ctx, route.Vertex{1}, 6000)
+	require.ErrorIs(t, err, macaroons.ErrSpendingLimitExceeded)
+
+	err = service.CheckPayment(ctx, route.Vertex{3}, 1000)
+	require.ErrorIs(t, err, macaroons.ErrDestinationNotAllowed)
+
+	// Payments are charged to the budget, which is shared by macaroons
+	// derived from the macaroon.
+	require.NoError(t, service.CheckPayment(ctx, route.Vertex{1}, 5000))
+
+	derived, err := macaroons.AddConstraints(
+		mac, macaroons.TimeoutConstraint(60),
+	)
+	require.NoError(t, err)
+	derivedCtx := macaroonContext(t, derived)
+
+	err = service.CheckPayment(derivedCtx, route.Vertex{2}, 4000)
+	require.ErrorIs(t, err, macaroons.ErrSpendingLimitExceeded)
+	err = service.CheckPayment(derivedCtx, route.Vertex{2}, 3000)
+	require.NoError(t, err)
+
+	// On-chain sends are checked against their own limit.
+	err = service.CheckOnChainSend(ctx, 20_001)
+	require.ErrorIs(t, err, macaroons.ErrSpendingLimitExceeded)
+	require.NoError(t, service.CheckOnChainSend(ctx, 20_000))
+
+	// Requests without a macaroon aren't limited.
+	require.NoError(t, service.CheckPayment(
+		context.Background(), route.Vertex{3}, 1_000_000,
+	))
+}
@@ -0,0 +1,113 @@
+package lnd
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/invoices"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/routing"
+)
+
+// paymentPreimageNotifier notifies the invoice registry about the preimages
+// of outgoing payments, using the updates of the control tower.
+type paymentPreimageNotifier struct {
+	controlTower routing.ControlTower
+}
+
+// A compile time check to ensure paymentPreimageNotifier implements the
+// invoices.PaymentPreimageNotifier interface.
+var _ invoices.PaymentPreimageNotifier = (*paymentPreimageNotifier)(nil)
+
+// newPaymentPreimageNotifier creates a new paymentPreimageNotifier.
+func newPaymentPreimageNotifier(
+	controlTower routing.ControlTower) *paymentPreimageNotifier {
+
+	return &paymentPreimageNotifier{
+		controlTower: controlTower,
+	}
+}
+
+// SubscribePaymentPreimage returns a channel that receives the preimage of the
+// outgoing payment with the given hash once one of its htlcs is settled.
+//
+// NOTE: Part of the invoices.PaymentPreimageNotifier interface.
+func (p *paymentPreimageNotifier) SubscribePaymentPreimage(
+	hash lntypes.Hash) (<-chan lntypes.Preimage, func(), error) {
+
+	// We subscribe to all payments, because the payment may not have been
+	// initiated yet. The subscription is made before fetching the payment,
+	// so no settle can be missed in between.
+	sub, err := p.controlTower.SubscribeAllPayments()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		preimageChan = make(chan lntypes.Preimage, 1)
+		quit         = make(chan struct{})
+		wg           sync.WaitGroup
+		once         sync.Once
+	)
+	cancel := func() {
+		once.Do(func() {
+			close(quit)
+			wg.Wait()
+			sub.Close()
+		})
+	}
+
+	payment, err := p.controlTower.FetchPayment(hash)
+	switch {
+	case errors.Is(err, channeldb.ErrPaymentNotInitiated):
+
+	case err != nil:
+		cancel()
+		return nil, nil, err
+
+	default:
+		if settle, _ := payment.TerminalInfo(); settle != nil {
+			preimageChan <- settle.Preimage
+			cancel()
+
+			return preimageChan, func() {}, nil
+		}
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case update, ok := <-sub.Updates():
+				if !ok {
+					return
+				}
+
+				payment, ok := update.(*channeldb.MPPayment)
+				if !ok {
+					continue
+				}
+
+				if payment.Info.PaymentIdentifier != hash {
+					continue
+				}
+
+				settle, _ := payment.TerminalInfo()
+				if settle == nil {
+					continue
+				}
+
+				preimageChan <- settle.Preimage
+				return
+
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return preimageChan, cancel, nil
+}
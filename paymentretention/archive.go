@@ -0,0 +1,123 @@
+package paymentretention
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+)
+
+// archivedPayment is the record of a pruned payment in the archive file. The
+// archive holds one JSON encoded record per line.
+type archivedPayment struct {
+	PaymentHash    string          `json:"payment_hash"`
+	SequenceNum    uint64          `json:"sequence_num"`
+	CreationTime   time.Time       `json:"creation_time"`
+	ValueMsat      uint64          `json:"value_msat"`
+	PaymentRequest string          `json:"payment_request,omitempty"`
+	Status         string          `json:"status"`
+	FailureReason  string          `json:"failure_reason,omitempty"`
+	Htlcs          []*archivedHtlc `json:"htlcs"`
+}
+
+// archivedHtlc is the record of an htlc attempt of a pruned payment.
+type archivedHtlc struct {
+	AttemptID   uint64       `json:"attempt_id"`
+	AttemptTime time.Time    `json:"attempt_time"`
+	AmountMsat  uint64       `json:"amount_msat"`
+	FeeMsat     uint64       `json:"fee_msat"`
+	Hops        []string     `json:"hops"`
+	SettleTime  *time.Time   `json:"settle_time,omitempty"`
+	Failure     *htlcFailure `json:"failure,omitempty"`
+}
+
+// htlcFailure is the record of the failure of an htlc attempt.
+type htlcFailure struct {
+	FailTime    time.Time `json:"fail_time"`
+	Code        string    `json:"code,omitempty"`
+	SourceIndex uint32    `json:"source_index"`
+}
+
+// newArchivedPayment converts a payment to its archive record.
+func newArchivedPayment(payment *channeldb.MPPayment) *archivedPayment {
+	record := &archivedPayment{
+		PaymentHash:    payment.Info.PaymentIdentifier.String(),
+		SequenceNum:    payment.SequenceNum,
+		CreationTime:   payment.Info.CreationTime,
+		ValueMsat:      uint64(payment.Info.Value),
+		PaymentRequest: string(payment.Info.PaymentRequest),
+		Status:         payment.Status.String(),
+		Htlcs:          make([]*archivedHtlc, 0, len(payment.HTLCs)),
+	}
+	if payment.FailureReason != nil {
+		record.FailureReason = payment.FailureReason.String()
+	}
+
+	for _, htlc := range payment.HTLCs {
+		archived := &archivedHtlc{
+			AttemptID:   htlc.AttemptID,
+			AttemptTime: htlc.AttemptTime,
+			AmountMsat:  uint64(htlc.Route.ReceiverAmt()),
+			FeeMsat:     uint64(htlc.Route.TotalFees()),
+		}
+
+		for _, hop := range htlc.Route.Hops {
+			archived.Hops = append(
+				archived.Hops, hex.EncodeToString(
+					hop.PubKeyBytes[:],
+				),
+			)
+		}
+
+		if htlc.Settle != nil {
+			settleTime := htlc.Settle.SettleTime
+			archived.SettleTime = &settleTime
+		}
+
+		if htlc.Failure != nil {
+			archived.Failure = &htlcFailure{
+				FailTime:    htlc.Failure.FailTime,
+				SourceIndex: htlc.Failure.FailureSourceIndex,
+			}
+			if htlc.Failure.Message != nil {
+				archived.Failure.Code =
+					htlc.Failure.Message.Code().String()
+			}
+		}
+
+		record.Htlcs = append(record.Htlcs, archived)
+	}
+
+	return record
+}
+
+// appendToArchive appends the payments to the archive file, creating it if
+// needed. The file is synced before returning, so the payments are only
+// deleted once they are persisted in the archive.
+func appendToArchive(path string, payments []*channeldb.MPPayment) error {
+	file, err := os.OpenFile(
+		path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600,
+	)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, payment := range payments {
+		err := encoder.Encode(newArchivedPayment(payment))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
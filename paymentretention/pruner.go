@@ -0,0 +1,168 @@
+package paymentretention
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/ticker"
+)
+
+const (
+	// DefaultInterval is the default interval at which old payments are
+	// pruned.
+	DefaultInterval = time.Hour
+
+	// batchSize is the maximum number of payments that are pruned in a
+	// single database transaction.
+	batchSize = 1000
+)
+
+// Config houses the dependencies and the retention policy of the pruner.
+type Config struct {
+	// PrunePayments deletes the payments selected by the query, returning
+	// the number of payments pruned.
+	PrunePayments func(channeldb.PaymentPruneQuery) (uint64, error)
+
+	// FailedPaymentsMaxAge is the age after which failed payments are
+	// deleted. Zero disables the deletion of failed payments.
+	FailedPaymentsMaxAge time.Duration
+
+	// FailedHtlcsMaxAge is the age after which the failed htlc attempts
+	// of succeeded payments are deleted. Zero disables the deletion of
+	// failed htlc attempts.
+	FailedHtlcsMaxAge time.Duration
+
+	// ArchiveFile, if set, is the path of the file that pruned payments
+	// are appended to before they are deleted.
+	ArchiveFile string
+
+	// Ticker fires each time old payments should be pruned.
+	Ticker ticker.Ticker
+
+	// Clock is used to determine the age of payments.
+	Clock clock.Clock
+}
+
+// Pruner periodically deletes failed payments and failed htlc attempts that
+// are older than the configured retention policy, optionally archiving them
+// first.
+type Pruner struct {
+	started sync.Once
+	stopped sync.Once
+
+	cfg *Config
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewPruner creates a new payment pruner.
+func NewPruner(cfg *Config) *Pruner {
+	return &Pruner{
+		cfg:  cfg,
+		quit: make(chan struct{}),
+	}
+}
+
+// Start prunes old payments once and launches the goroutine that prunes them
+// periodically.
+func (p *Pruner) Start() error {
+	p.started.Do(func() {
+		log.Infof("Payment pruner starting: "+
+			"failed_payments_max_age=%v, failed_htlcs_max_age=%v, "+
+			"archive_file=%v",
+			p.cfg.FailedPaymentsMaxAge, p.cfg.FailedHtlcsMaxAge,
+			p.cfg.ArchiveFile)
+
+		p.cfg.Ticker.Resume()
+
+		p.wg.Add(1)
+		go p.pruneLoop()
+	})
+
+	return nil
+}
+
+// Stop signals the pruner to exit and waits for it to do so.
+func (p *Pruner) Stop() error {
+	p.stopped.Do(func() {
+		log.Info("Payment pruner shutting down")
+
+		close(p.quit)
+		p.wg.Wait()
+
+		p.cfg.Ticker.Stop()
+	})
+
+	return nil
+}
+
+// pruneLoop prunes old payments on startup and each time the ticker fires.
+//
+// NOTE: This MUST be run as a goroutine.
+func (p *Pruner) pruneLoop() {
+	defer p.wg.Done()
+
+	for {
+		if _, err := p.Prune(); err != nil {
+			log.Errorf("Unable to prune payments: %v", err)
+		}
+
+		select {
+		case <-p.cfg.Ticker.Ticks():
+
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// Prune deletes all payments and htlc attempts that are older than the
+// retention policy, returning the number of payments pruned.
+func (p *Pruner) Prune() (uint64, error) {
+	now := p.cfg.Clock.Now()
+
+	query := channeldb.PaymentPruneQuery{
+		MaxPayments: batchSize,
+	}
+	if p.cfg.FailedPaymentsMaxAge > 0 {
+		query.FailedBefore = now.Add(-p.cfg.FailedPaymentsMaxAge)
+	}
+	if p.cfg.FailedHtlcsMaxAge > 0 {
+		query.FailedHtlcsBefore = now.Add(-p.cfg.FailedHtlcsMaxAge)
+	}
+	if p.cfg.ArchiveFile != "" {
+		query.Archive = func(payments []*channeldb.MPPayment) error {
+			return appendToArchive(p.cfg.ArchiveFile, payments)
+		}
+	}
+
+	// Prune in batches to keep the database transactions small, until a
+	// batch comes back partially filled.
+	var total uint64
+	for {
+		numPruned, err := p.cfg.PrunePayments(query)
+		if err != nil {
+			return total, err
+		}
+		total += numPruned
+
+		if numPruned < batchSize {
+			break
+		}
+
+		select {
+		case <-p.quit:
+			return total, nil
+		default:
+		}
+	}
+
+	if total > 0 {
+		log.Infof("Pruned %d old payments", total)
+	}
+
+	return total, nil
+}
@@ -0,0 +1,177 @@
+package paymentretention
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/ticker"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPrunerPrune tests that the pruner derives the cutoffs from the retention
+// policy and prunes in batches until all old payments are pruned.
+func TestPrunerPrune(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(10_000_000, 0)
+
+	var (
+		queries []channeldb.PaymentPruneQuery
+		results = []uint64{batchSize, batchSize, 3}
+	)
+	pruner := NewPruner(&Config{
+		PrunePayments: func(q channeldb.PaymentPruneQuery) (uint64,
+			error) {
+
+			queries = append(queries, q)
+			result := results[0]
+			results = results[1:]
+
+			return result, nil
+		},
+		FailedPaymentsMaxAge: time.Hour,
+		FailedHtlcsMaxAge:    24 * time.Hour,
+		Clock:                clock.NewTestClock(now),
+	})
+
+	numPruned, err := pruner.Prune()
+	require.NoError(t, err)
+	require.EqualValues(t, 2*batchSize+3, numPruned)
+
+	require.Len(t, queries, 3)
+	for _, q := range queries {
+		require.Equal(t, now.Add(-time.Hour), q.FailedBefore)
+		require.Equal(t, now.Add(-24*time.Hour), q.FailedHtlcsBefore)
+		require.EqualValues(t, batchSize, q.MaxPayments)
+		require.Nil(t, q.Archive)
+	}
+}
+
+// TestPrunerArchive tests that pruned payments are appended to the archive
+// file, one JSON record per line.
+func TestPrunerArchive(t *testing.T) {
+	t.Parallel()
+
+	archiveFile := filepath.Join(t.TempDir(), "payments.archive")
+	now := time.Unix(10_000_000, 0)
+
+	payment := &channeldb.MPPayment{
+		SequenceNum: 5,
+		Info: &channeldb.PaymentCreationInfo{
+			PaymentIdentifier: lntypes.Hash{1, 2, 3},
+			Value:             1000,
+			CreationTime:      now,
+		},
+		HTLCs: []channeldb.HTLCAttempt{{
+			HTLCAttemptInfo: channeldb.HTLCAttemptInfo{
+				AttemptID: 7,
+				Route: route.Route{
+					TotalAmount: 1010,
+					Hops: []*route.Hop{{
+						PubKeyBytes:  route.Vertex{2},
+						AmtToForward: 1000,
+					}},
+				},
+			},
+			Failure: &channeldb.HTLCFailInfo{
+				FailTime: now,
+				Message:  &lnwire.FailTemporaryNodeFailure{},
+				Reason:   channeldb.HTLCFailMessage,
+			},
+		}},
+		Status: channeldb.StatusFailed,
+	}
+
+	pruner := NewPruner(&Config{
+		PrunePayments: func(q channeldb.PaymentPruneQuery) (uint64,
+			error) {
+
+			err := q.Archive([]*channeldb.MPPayment{payment})
+			return 1, err
+		},
+		FailedPaymentsMaxAge: time.Hour,
+		ArchiveFile:          archiveFile,
+		Clock:                clock.NewTestClock(now),
+	})
+
+	// Prune twice, which should append the payment twice.
+	for i := 0; i < 2; i++ {
+		_, err := pruner.Prune()
+		require.NoError(t, err)
+	}
+
+	file, err := os.Open(archiveFile)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []*archivedPayment
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record archivedPayment
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, &record)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, records, 2)
+
+	record := records[0]
+	require.Equal(t, payment.Info.PaymentIdentifier.String(),
+		record.PaymentHash)
+	require.EqualValues(t, 5, record.SequenceNum)
+	require.EqualValues(t, 1000, record.ValueMsat)
+	require.Equal(t, "Failed", record.Status)
+	require.Len(t, record.Htlcs, 1)
+
+	htlc := record.Htlcs[0]
+	require.EqualValues(t, 7, htlc.AttemptID)
+	require.EqualValues(t, 1000, htlc.AmountMsat)
+	require.EqualValues(t, 10, htlc.FeeMsat)
+	require.Len(t, htlc.Hops, 1)
+	require.NotNil(t, htlc.Failure)
+	require.Equal(t, lnwire.CodeTemporaryNodeFailure.String(),
+		htlc.Failure.Code)
+}
+
+// TestPrunerLoop tests that the pruner prunes on startup and on each tick.
+func TestPrunerLoop(t *testing.T) {
+	t.Parallel()
+
+	pruned := make(chan struct{})
+	forceTicker := ticker.NewForce(time.Hour)
+	pruner := NewPruner(&Config{
+		PrunePayments: func(channeldb.PaymentPruneQuery) (uint64,
+			error) {
+
+			pruned <- struct{}{}
+			return 0, nil
+		},
+		FailedPaymentsMaxAge: time.Hour,
+		Ticker:               forceTicker,
+		Clock:                clock.NewDefaultClock(),
+	})
+
+	require.NoError(t, pruner.Start())
+
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			forceTicker.Force <- time.Now()
+		}
+
+		select {
+		case <-pruned:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("payments not pruned")
+		}
+	}
+
+	require.NoError(t, pruner.Stop())
+}
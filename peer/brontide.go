@@ -20,6 +20,7 @@ import (
 	"github.com/lightningnetwork/lnd/buffer"
 	"github.com/lightningnetwork/lnd/build"
 	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/chanfitness"
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/channelnotifier"
 	"github.com/lightningnetwork/lnd/contractcourt"
@@ -260,6 +261,22 @@ type Config struct {
 	// process fails.
 	DisconnectPeer func(*btcec.PublicKey) error
 
+	// CheckBanned returns an error if the node or the address of the peer
+	// is banned, in which case the peer refuses to start.
+	CheckBanned func(*btcec.PublicKey, net.Addr) error
+
+	// ReportPingLatency, if non-nil, is called with the round trip time of
+	// every ping that the peer answers.
+	ReportPingLatency func(time.Duration)
+
+	// ReportTraffic, if non-nil, is called periodically and once the peer
+	// disconnects with the traffic exchanged since the previous call.
+	ReportTraffic func(chanfitness.Traffic)
+
+	// ReportDisconnect, if non-nil, is called once with the reason that
+	// the peer was disconnected.
+	ReportDisconnect func(chanfitness.DisconnectReason)
+
 	// GenNodeAnnouncement is used to send our node announcement to the remote
 	// on startup.
 	GenNodeAnnouncement func(bool,
@@ -366,6 +383,8 @@ type Brontide struct {
 	// MUST be used atomically.
 	bytesReceived uint64
 	bytesSent     uint64
+	msgsReceived  uint64
+	msgsSent      uint64
 
 	// pingTime is a rough estimate of the RTT (round-trip-time) between us
 	// and the connected peer. This time is expressed in microseconds.
@@ -509,6 +528,17 @@ func (p *Brontide) Start() error {
 	p.log.Tracef("starting with conn[%v->%v]",
 		p.cfg.Conn.LocalAddr(), p.cfg.Conn.RemoteAddr())
 
+	// Refuse to start if the peer was banned, even if the connection was
+	// made before the ban or bypassed the checks of our listeners.
+	if p.cfg.CheckBanned != nil {
+		err := p.cfg.CheckBanned(
+			p.cfg.Addr.IdentityKey, p.cfg.Conn.RemoteAddr(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Fetch and then load all the active channels we have with this remote
 	// peer from the database.
 	activeChans, err := p.cfg.ChannelDB.FetchOpenChannels(
@@ -838,11 +868,22 @@ func (p *Brontide) loadActiveChannels(chans []*channeldb.OpenChannel) (
 		// routing policy into a forwarding policy.
 		var forwardingPolicy *htlcswitch.ForwardingPolicy
 		if selfPolicy != nil {
+			// A malformed inbound fee is ignored, so that we don't
+			// refuse to load the channel because of it.
+			inboundFee, err := lnwire.ExtractInboundFee(
+				selfPolicy.ExtraOpaqueData,
+			)
+			if err != nil {
+				p.log.Warnf("Unable to decode inbound fee of "+
+					"channel %v: %v", chanPoint, err)
+			}
+
 			forwardingPolicy = &htlcswitch.ForwardingPolicy{
 				MinHTLCOut:    selfPolicy.MinHTLC,
 				MaxHTLC:       selfPolicy.MaxHTLC,
 				BaseFee:       selfPolicy.FeeBaseMSat,
 				FeeRate:       selfPolicy.FeeProportionalMillionths,
+				InboundFee:    inboundFee,
 				TimeLockDelta: uint32(selfPolicy.TimeLockDelta),
 			}
 		} else {
@@ -1044,6 +1085,15 @@ func (p *Brontide) WaitForDisconnect(ready chan struct{}) {
 // signal is sent to the server and htlcSwitch indicating the resources
 // allocated to the peer can now be cleaned up.
 func (p *Brontide) Disconnect(reason error) {
+	p.disconnectWithReason(chanfitness.DisconnectLocal, reason)
+}
+
+// disconnectWithReason terminates the connection with the remote peer like
+// Disconnect, reporting the given kind of disconnect. Only the first call has
+// any effect.
+func (p *Brontide) disconnectWithReason(kind chanfitness.DisconnectReason,
+	reason error) {
+
 	if !atomic.CompareAndSwapInt32(&p.disconnect, 0, 1) {
 		return
 	}
@@ -1053,6 +1103,10 @@ func (p *Brontide) Disconnect(reason error) {
 
 	p.log.Infof(err.Error())
 
+	if p.cfg.ReportDisconnect != nil {
+		p.cfg.ReportDisconnect(kind)
+	}
+
 	// Ensure that the TCP connection is properly closed before continuing.
 	p.cfg.Conn.Close()
 
@@ -1124,6 +1178,7 @@ func (p *Brontide) readNextMessage() (lnwire.Message, error) {
 	if err != nil {
 		return nil, err
 	}
+	atomic.AddUint64(&p.msgsReceived, 1)
 
 	p.logWireMessage(nextMsg, true)
 
@@ -1425,7 +1480,7 @@ func (p *Brontide) readHandler() {
 	idleTimer := time.AfterFunc(idleTimeout, func() {
 		err := fmt.Errorf("peer %s no answer for %s -- disconnecting",
 			p, idleTimeout)
-		p.Disconnect(err)
+		p.disconnectWithReason(chanfitness.DisconnectIdleTimeout, err)
 	})
 
 	// Initialize our negotiated gossip sync method before reading messages
@@ -1506,6 +1561,12 @@ out:
 			delay := (time.Now().UnixNano() - pingSendTime) / 1000
 			atomic.StoreInt64(&p.pingTime, delay)
 
+			if p.cfg.ReportPingLatency != nil && pingSendTime != 0 {
+				p.cfg.ReportPingLatency(
+					time.Duration(delay) * time.Microsecond,
+				)
+			}
+
 		case *lnwire.Ping:
 			// First, we'll store their latest ping payload within
 			// the relevant atomic variable.
@@ -1576,7 +1637,9 @@ out:
 			*lnwire.QueryShortChanIDs,
 			*lnwire.QueryChannelRange,
 			*lnwire.ReplyChannelRange,
-			*lnwire.ReplyShortChanIDsEnd:
+			*lnwire.ReplyShortChanIDsEnd,
+			*lnwire.QueryChannelSketch,
+			*lnwire.ReplyChannelSketch:
 
 			discStream.AddMsg(msg)
 
@@ -1619,7 +1682,12 @@ out:
 		idleTimer.Reset(idleTimeout)
 	}
 
-	p.Disconnect(errors.New("read handler closed"))
+	// The read handler only exits on its own if it failed to read from
+	// the connection, otherwise the peer is already disconnected.
+	p.disconnectWithReason(
+		chanfitness.DisconnectReadError,
+		errors.New("read handler closed"),
+	)
 
 	p.log.Trace("readHandler for peer done")
 }
@@ -1863,6 +1931,17 @@ func messageSummary(msg lnwire.Message) string {
 			"end_height=%v", msg.ChainHash, msg.FirstBlockHeight,
 			msg.LastBlockHeight())
 
+	case *lnwire.QueryChannelSketch:
+		return fmt.Sprintf("chain_hash=%v, start_height=%v, "+
+			"end_height=%v, num_cells=%v", msg.ChainHash,
+			msg.FirstBlockHeight, msg.LastBlockHeight(),
+			msg.NumCells)
+
+	case *lnwire.ReplyChannelSketch:
+		return fmt.Sprintf("start_height=%v, num_blocks=%v, "+
+			"num_chans=%v, sketch_size=%v", msg.FirstBlockHeight,
+			msg.NumBlocks, msg.NumChannels, len(msg.Sketch))
+
 	case *lnwire.GossipTimestampRange:
 		return fmt.Sprintf("chain_hash=%v, first_stamp=%v, "+
 			"stamp_range=%v", msg.ChainHash,
@@ -1986,6 +2065,7 @@ func (p *Brontide) writeMessage(msg lnwire.Message) error {
 	if err != nil {
 		return err
 	}
+	atomic.AddUint64(&p.msgsSent, 1)
 
 	return flushMsg()
 }
@@ -2002,7 +2082,7 @@ func (p *Brontide) writeHandler() {
 	idleTimer := time.AfterFunc(idleTimeout, func() {
 		err := fmt.Errorf("peer %s no write for %s -- disconnecting",
 			p, idleTimeout)
-		p.Disconnect(err)
+		p.disconnectWithReason(chanfitness.DisconnectIdleTimeout, err)
 	})
 
 	var exitErr error
@@ -2082,7 +2162,7 @@ out:
 	// disconnect.
 	p.wg.Done()
 
-	p.Disconnect(exitErr)
+	p.disconnectWithReason(chanfitness.DisconnectWriteError, exitErr)
 
 	p.log.Trace("writeHandler for peer done")
 }
@@ -2168,6 +2248,11 @@ func (p *Brontide) pingHandler() {
 	// TODO(roasbeef): make dynamic in order to create fake cover traffic
 	const numPongBytes = 16
 
+	// We report our traffic along with each ping, and once more when we
+	// exit so that no traffic goes unreported.
+	var reported chanfitness.Traffic
+	defer p.reportTraffic(&reported)
+
 	blockEpochs, err := p.cfg.ChainNotifier.RegisterBlockEpochNtfn(nil)
 	if err != nil {
 		p.log.Errorf("unable to establish block epoch "+
@@ -2212,12 +2297,38 @@ out:
 			}
 
 			p.queueMsg(pingMsg, nil)
+			p.reportTraffic(&reported)
+
 		case <-p.quit:
 			break out
 		}
 	}
 }
 
+// reportTraffic reports the traffic exchanged with the peer since the
+// previous report, and updates the passed totals of the reported traffic.
+func (p *Brontide) reportTraffic(reported *chanfitness.Traffic) {
+	if p.cfg.ReportTraffic == nil {
+		return
+	}
+
+	total := chanfitness.Traffic{
+		MsgsSent:      atomic.LoadUint64(&p.msgsSent),
+		MsgsReceived:  atomic.LoadUint64(&p.msgsReceived),
+		BytesSent:     atomic.LoadUint64(&p.bytesSent),
+		BytesReceived: atomic.LoadUint64(&p.bytesReceived),
+	}
+
+	p.cfg.ReportTraffic(chanfitness.Traffic{
+		MsgsSent:      total.MsgsSent - reported.MsgsSent,
+		MsgsReceived:  total.MsgsReceived - reported.MsgsReceived,
+		BytesSent:     total.BytesSent - reported.BytesSent,
+		BytesReceived: total.BytesReceived - reported.BytesReceived,
+	})
+
+	*reported = total
+}
+
 // PingTime returns the estimated ping time to the peer in microseconds.
 func (p *Brontide) PingTime() int64 {
 	return atomic.LoadInt64(&p.pingTime)
@@ -2576,6 +2687,18 @@ func (p *Brontide) fetchActiveChanCloser(chanID lnwire.ChannelID) (
 		return nil, ErrChannelNotFound
 	}
 
+	// We can't cooperatively close restored channels or channels that
+	// have experienced local data loss, as we don't know their latest
+	// state. The remote may even be attempting to close them with an
+	// outdated state.
+	chanState := channel.State()
+	if chanState.HasChanStatus(channeldb.ChanStatusRestored) ||
+		chanState.HasChanStatus(channeldb.ChanStatusLocalDataLoss) {
+
+		return nil, fmt.Errorf("cannot cooperatively close channel "+
+			"with state: %v", chanState.ChanStatus())
+	}
+
 	// Optimistically try a link shutdown, erroring out if it failed.
 	if err := p.tryLinkShutdown(chanID); err != nil {
 		p.log.Errorf("failed link shutdown: %v", err)
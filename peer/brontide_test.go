@@ -2,6 +2,9 @@ package peer
 
 import (
 	"bytes"
+	"errors"
+	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,6 +14,7 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/chanfitness"
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/contractcourt"
 	"github.com/lightningnetwork/lnd/htlcswitch"
@@ -1108,3 +1112,69 @@ func TestPeerCustomMessage(t *testing.T) {
 	require.Equal(t, remoteKey, receivedCustom.peer)
 	require.Equal(t, receivedCustomMsg, &receivedCustom.msg)
 }
+
+// TestPeerStartBanned asserts that a peer refuses to start if it is banned.
+func TestPeerStartBanned(t *testing.T) {
+	t.Parallel()
+
+	aliceKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	errBanned := errors.New("banned")
+	var checkedKey *btcec.PublicKey
+	alicePeer := NewBrontide(Config{
+		Addr: &lnwire.NetAddress{
+			IdentityKey: aliceKey.PubKey(),
+		},
+		Conn: newMockConn(t, 1),
+		CheckBanned: func(pub *btcec.PublicKey, _ net.Addr) error {
+			checkedKey = pub
+			return errBanned
+		},
+	})
+
+	require.ErrorIs(t, alicePeer.Start(), errBanned)
+	require.Equal(t, aliceKey.PubKey(), checkedKey)
+}
+
+// TestPeerConnReports asserts that a peer reports the kind of its disconnect
+// only once, and the traffic exchanged since its previous report.
+func TestPeerConnReports(t *testing.T) {
+	t.Parallel()
+
+	var (
+		disconnects []chanfitness.DisconnectReason
+		traffic     []chanfitness.Traffic
+	)
+	alicePeer := NewBrontide(Config{
+		Conn: newMockConn(t, 1),
+		ReportDisconnect: func(reason chanfitness.DisconnectReason) {
+			disconnects = append(disconnects, reason)
+		},
+		ReportTraffic: func(t chanfitness.Traffic) {
+			traffic = append(traffic, t)
+		},
+	})
+
+	var reported chanfitness.Traffic
+	atomic.AddUint64(&alicePeer.msgsSent, 2)
+	atomic.AddUint64(&alicePeer.bytesReceived, 100)
+	alicePeer.reportTraffic(&reported)
+
+	atomic.AddUint64(&alicePeer.msgsSent, 1)
+	alicePeer.reportTraffic(&reported)
+
+	require.Equal(t, []chanfitness.Traffic{
+		{MsgsSent: 2, BytesReceived: 100},
+		{MsgsSent: 1},
+	}, traffic)
+
+	alicePeer.disconnectWithReason(
+		chanfitness.DisconnectIdleTimeout, errors.New("idle"),
+	)
+	alicePeer.Disconnect(errors.New("again"))
+
+	require.Equal(t, []chanfitness.DisconnectReason{
+		chanfitness.DisconnectIdleTimeout,
+	}, disconnects)
+}
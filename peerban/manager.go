@@ -0,0 +1,321 @@
+package peerban
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+var (
+	// ErrBanned is returned when a node or an address is banned.
+	ErrBanned = errors.New("peer is banned")
+)
+
+// BanStore persists the bans of the manager.
+type BanStore interface {
+	// PutPeerBan stores the ban, replacing any existing ban of the same
+	// target.
+	PutPeerBan(ban *channeldb.PeerBan) error
+
+	// FetchPeerBans returns all stored bans.
+	FetchPeerBans() ([]*channeldb.PeerBan, error)
+
+	// DeletePeerBan deletes the ban of the target of the given ban,
+	// returning channeldb.ErrPeerBanNotFound if it isn't banned.
+	DeletePeerBan(ban *channeldb.PeerBan) error
+}
+
+// Config houses the dependencies of the ban manager.
+type Config struct {
+	// Store persists the bans across restarts.
+	Store BanStore
+
+	// Clock is used to create and expire bans.
+	Clock clock.Clock
+}
+
+// Manager keeps track of the nodes and IP ranges that are banned from
+// connecting to us. Unlike firewall rules, bans of nodes also apply to peers
+// that connect through Tor. Expired bans are ignored, and removed from the
+// store once the bans are listed.
+type Manager struct {
+	cfg *Config
+
+	mu        sync.RWMutex
+	nodeBans  map[route.Vertex]*channeldb.PeerBan
+	ipNetBans map[string]*channeldb.PeerBan
+}
+
+// NewManager creates a new ban manager with the bans loaded from the store.
+// The bans are loaded right away, so the manager can be used to filter
+// connections before the rest of the server has started.
+func NewManager(cfg *Config) (*Manager, error) {
+	bans, err := cfg.Store.FetchPeerBans()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		cfg:       cfg,
+		nodeBans:  make(map[route.Vertex]*channeldb.PeerBan),
+		ipNetBans: make(map[string]*channeldb.PeerBan),
+	}
+	for _, ban := range bans {
+		m.add(ban)
+	}
+
+	log.Infof("Loaded %v peer bans", len(bans))
+
+	return m, nil
+}
+
+// add adds the ban to the in-memory index. The caller must hold the mutex.
+func (m *Manager) add(ban *channeldb.PeerBan) {
+	if ban.Node != nil {
+		m.nodeBans[*ban.Node] = ban
+		return
+	}
+
+	m.ipNetBans[ban.IPNet.String()] = ban
+}
+
+// remove removes the ban from the in-memory index. The caller must hold the
+// mutex.
+func (m *Manager) remove(ban *channeldb.PeerBan) {
+	if ban.Node != nil {
+		delete(m.nodeBans, *ban.Node)
+		return
+	}
+
+	delete(m.ipNetBans, ban.IPNet.String())
+}
+
+// ban stores and applies a ban of the given target. A zero duration bans the
+// target permanently.
+func (m *Manager) ban(target *channeldb.PeerBan, duration time.Duration,
+	reason string) (*channeldb.PeerBan, error) {
+
+	if duration < 0 {
+		return nil, fmt.Errorf("negative ban duration %v", duration)
+	}
+
+	now := m.cfg.Clock.Now()
+	ban := &channeldb.PeerBan{
+		Node:      target.Node,
+		IPNet:     target.IPNet,
+		Reason:    reason,
+		CreatedAt: now,
+	}
+	if duration != 0 {
+		ban.Expiry = now.Add(duration)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.cfg.Store.PutPeerBan(ban); err != nil {
+		return nil, err
+	}
+	m.add(ban)
+
+	log.Infof("Banned %v until %v: %v", ban, expiryString(ban), reason)
+
+	return ban, nil
+}
+
+// BanNode bans the node with the given public key for the given duration. A
+// zero duration bans the node permanently. An existing ban of the node is
+// replaced.
+func (m *Manager) BanNode(node route.Vertex, duration time.Duration,
+	reason string) (*channeldb.PeerBan, error) {
+
+	return m.ban(&channeldb.PeerBan{Node: &node}, duration, reason)
+}
+
+// BanIPNet bans all addresses within the given IP range for the given
+// duration. A zero duration bans the range permanently. An existing ban of
+// the same range is replaced.
+func (m *Manager) BanIPNet(ipNet *net.IPNet, duration time.Duration,
+	reason string) (*channeldb.PeerBan, error) {
+
+	return m.ban(&channeldb.PeerBan{IPNet: ipNet}, duration, reason)
+}
+
+// unban deletes the ban of the given target.
+func (m *Manager) unban(target *channeldb.PeerBan) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.cfg.Store.DeletePeerBan(target); err != nil {
+		return err
+	}
+	m.remove(target)
+
+	log.Infof("Unbanned %v", target)
+
+	return nil
+}
+
+// UnbanNode lifts the ban of the given node. channeldb.ErrPeerBanNotFound is
+// returned if the node isn't banned.
+func (m *Manager) UnbanNode(node route.Vertex) error {
+	return m.unban(&channeldb.PeerBan{Node: &node})
+}
+
+// UnbanIPNet lifts the ban of the given IP range, which must match the range
+// of the ban exactly. channeldb.ErrPeerBanNotFound is returned if the range
+// isn't banned.
+func (m *Manager) UnbanIPNet(ipNet *net.IPNet) error {
+	return m.unban(&channeldb.PeerBan{IPNet: ipNet})
+}
+
+// Bans returns all bans that haven't expired yet, ordered by their creation
+// time. Expired bans are deleted from the store.
+func (m *Manager) Bans() ([]*channeldb.PeerBan, error) {
+	now := m.cfg.Clock.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bans := make([]*channeldb.PeerBan, 0, len(m.nodeBans)+len(m.ipNetBans))
+	var expired []*channeldb.PeerBan
+	for _, ban := range m.nodeBans {
+		bans = append(bans, ban)
+	}
+	for _, ban := range m.ipNetBans {
+		bans = append(bans, ban)
+	}
+
+	active := bans[:0]
+	for _, ban := range bans {
+		if ban.Expired(now) {
+			expired = append(expired, ban)
+			continue
+		}
+		active = append(active, ban)
+	}
+
+	for _, ban := range expired {
+		err := m.cfg.Store.DeletePeerBan(ban)
+		if err != nil && err != channeldb.ErrPeerBanNotFound {
+			return nil, err
+		}
+		m.remove(ban)
+
+		log.Debugf("Removed expired ban of %v", ban)
+	}
+
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].CreatedAt.Before(active[j].CreatedAt)
+	})
+
+	return active, nil
+}
+
+// CheckNode returns an error wrapping ErrBanned if the node with the given
+// public key is banned.
+func (m *Manager) CheckNode(pubKey *btcec.PublicKey) error {
+	node := route.NewVertex(pubKey)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ban, ok := m.nodeBans[node]
+	if !ok || ban.Expired(m.cfg.Clock.Now()) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: node %v banned until %v", ErrBanned, node,
+		expiryString(ban))
+}
+
+// CheckAddr returns an error wrapping ErrBanned if the given address is within
+// a banned IP range. Addresses without an IP, like onion addresses, are never
+// banned.
+func (m *Manager) CheckAddr(addr net.Addr) error {
+	ip := addrIP(addr)
+	if ip == nil {
+		return nil
+	}
+
+	now := m.cfg.Clock.Now()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, ban := range m.ipNetBans {
+		if !ban.IPNet.Contains(ip) || ban.Expired(now) {
+			continue
+		}
+
+		return fmt.Errorf("%w: address %v within %v banned until %v",
+			ErrBanned, ip, ban.IPNet, expiryString(ban))
+	}
+
+	return nil
+}
+
+// CheckPeer returns an error wrapping ErrBanned if either the node or the
+// address of a peer is banned.
+func (m *Manager) CheckPeer(pubKey *btcec.PublicKey, addr net.Addr) error {
+	if err := m.CheckNode(pubKey); err != nil {
+		return err
+	}
+
+	return m.CheckAddr(addr)
+}
+
+// ParseIPNet parses an IP range in CIDR notation. A single IP address is
+// parsed as a range that only contains that address.
+func ParseIPNet(s string) (*net.IPNet, error) {
+	if _, ipNet, err := net.ParseCIDR(s); err == nil {
+		return ipNet, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP range %q", s)
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// addrIP returns the IP of the given address, or nil if it doesn't have one.
+func addrIP(addr net.Addr) net.IP {
+	if addr == nil {
+		return nil
+	}
+
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+
+	return net.ParseIP(host)
+}
+
+// expiryString returns a description of the expiry of the ban for log and
+// error messages.
+func expiryString(ban *channeldb.PeerBan) string {
+	if ban.Expiry.IsZero() {
+		return "forever"
+	}
+
+	return ban.Expiry.String()
+}
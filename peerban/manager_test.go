@@ -0,0 +1,136 @@
+package peerban
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/tor"
+	"github.com/stretchr/testify/require"
+)
+
+// mockStore is an in-memory BanStore.
+type mockStore struct {
+	bans map[string]*channeldb.PeerBan
+}
+
+func newMockStore() *mockStore {
+	return &mockStore{bans: make(map[string]*channeldb.PeerBan)}
+}
+
+func (s *mockStore) PutPeerBan(ban *channeldb.PeerBan) error {
+	s.bans[ban.String()] = ban
+	return nil
+}
+
+func (s *mockStore) FetchPeerBans() ([]*channeldb.PeerBan, error) {
+	var bans []*channeldb.PeerBan
+	for _, ban := range s.bans {
+		bans = append(bans, ban)
+	}
+
+	return bans, nil
+}
+
+func (s *mockStore) DeletePeerBan(ban *channeldb.PeerBan) error {
+	if _, ok := s.bans[ban.String()]; !ok {
+		return channeldb.ErrPeerBanNotFound
+	}
+	delete(s.bans, ban.String())
+
+	return nil
+}
+
+// TestManager asserts that banned nodes and addresses are rejected until their
+// bans expire or are lifted, and that bans survive a restart.
+func TestManager(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PubKey()
+	node := route.NewVertex(pubKey)
+
+	store := newMockStore()
+	testClock := clock.NewTestClock(time.Unix(1000, 0))
+	cfg := &Config{
+		Store: store,
+		Clock: testClock,
+	}
+	m, err := NewManager(cfg)
+	require.NoError(t, err)
+
+	addr := &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 9735}
+	onion := &tor.OnionAddr{OnionService: "3g2upl4pq6kufc4m.onion"}
+
+	require.NoError(t, m.CheckPeer(pubKey, addr))
+
+	// A node ban doesn't expire without a duration.
+	_, err = m.BanNode(node, 0, "spam")
+	require.NoError(t, err)
+	require.ErrorIs(t, m.CheckNode(pubKey), ErrBanned)
+	require.ErrorIs(t, m.CheckPeer(pubKey, onion), ErrBanned)
+
+	ipNet, err := ParseIPNet("10.0.0.0/8")
+	require.NoError(t, err)
+	_, err = m.BanIPNet(ipNet, time.Hour, "")
+	require.NoError(t, err)
+	require.ErrorIs(t, m.CheckAddr(addr), ErrBanned)
+	require.NoError(t, m.CheckAddr(onion))
+	require.NoError(t, m.CheckAddr(&net.TCPAddr{IP: net.ParseIP("::1")}))
+
+	// The bans are loaded again after a restart.
+	m, err = NewManager(cfg)
+	require.NoError(t, err)
+	require.ErrorIs(t, m.CheckNode(pubKey), ErrBanned)
+	require.ErrorIs(t, m.CheckAddr(addr), ErrBanned)
+
+	bans, err := m.Bans()
+	require.NoError(t, err)
+	require.Len(t, bans, 2)
+	require.Equal(t, &node, bans[0].Node)
+	require.Equal(t, ipNet, bans[1].IPNet)
+
+	// Once the IP ban expires, it no longer applies and is removed once
+	// the bans are listed.
+	testClock.SetTime(time.Unix(1000, 0).Add(time.Hour))
+	require.NoError(t, m.CheckAddr(addr))
+
+	bans, err = m.Bans()
+	require.NoError(t, err)
+	require.Len(t, bans, 1)
+	require.Len(t, store.bans, 1)
+
+	require.NoError(t, m.UnbanNode(node))
+	require.NoError(t, m.CheckNode(pubKey))
+	require.ErrorIs(
+		t, m.UnbanNode(node), channeldb.ErrPeerBanNotFound,
+	)
+
+	_, err = m.BanNode(node, -time.Second, "")
+	require.Error(t, err)
+}
+
+// TestParseIPNet asserts that IP ranges and single addresses are parsed.
+func TestParseIPNet(t *testing.T) {
+	t.Parallel()
+
+	ipNet, err := ParseIPNet("192.168.1.7/24")
+	require.NoError(t, err)
+	require.Equal(t, "192.168.1.0/24", ipNet.String())
+
+	ipNet, err = ParseIPNet("192.168.1.7")
+	require.NoError(t, err)
+	require.Equal(t, "192.168.1.7/32", ipNet.String())
+
+	ipNet, err = ParseIPNet("2001:db8::1")
+	require.NoError(t, err)
+	require.Equal(t, "2001:db8::1/128", ipNet.String())
+
+	_, err = ParseIPNet("not an ip")
+	require.Error(t, err)
+}
@@ -0,0 +1,221 @@
+package lnd
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/lightningnetwork/lnd/cert"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lncfg"
+	"github.com/lightningnetwork/lnd/replication"
+)
+
+// replicationDialTimeout is the time a standby waits for the connection to its
+// primary to be established.
+const replicationDialTimeout = 10 * time.Second
+
+// replicatedBackends returns the databases that are replicated to a standby
+// by name. The wallet database isn't among them, it is managed by btcwallet.
+func replicatedBackends(
+	backends *lncfg.DatabaseBackends) map[string]kvdb.Backend {
+
+	dbs := map[string]kvdb.Backend{
+		lncfg.NSChannelDB:    backends.ChanStateDB,
+		lncfg.NSMacaroonDB:   backends.MacaroonDB,
+		lncfg.NSDecayedLogDB: backends.DecayedLogDB,
+	}
+	if backends.TowerClientDB != nil {
+		dbs[lncfg.NSTowerClientDB] = backends.TowerClientDB
+	}
+	if backends.TowerServerDB != nil {
+		dbs[lncfg.NSTowerServerDB] = backends.TowerServerDB
+	}
+
+	return dbs
+}
+
+// checkReplicationPromotion makes sure the databases don't belong to a
+// standby that wasn't in sync with its primary, and takes them over
+// otherwise.
+func checkReplicationPromotion(cfg *Config,
+	backends *lncfg.DatabaseBackends) error {
+
+	for name, db := range replicatedBackends(backends) {
+		err := replication.CheckPromotion(
+			db, cfg.Replication.ForcePromote,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to use %v: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// newReplicationPrimary creates the primary side of the replication and
+// replaces the replicated backends with the ones that replicate their
+// transactions. The TLS certificate of the RPC server is used to encrypt the
+// connection to the standby.
+func newReplicationPrimary(cfg *Config,
+	backends *lncfg.DatabaseBackends) (*replication.Primary, error) {
+
+	secret, err := cfg.Replication.ParseSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	certData, _, err := cert.LoadCert(cfg.TLSCertPath, cfg.TLSKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := tls.Listen(
+		"tcp", cfg.Replication.Listen, cert.TLSConfFromCert(certData),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	primary := replication.NewPrimary(&replication.PrimaryConfig{
+		Listener:   listener,
+		Secret:     secret,
+		AckTimeout: cfg.Replication.AckTimeout,
+	})
+
+	wrap := func(name string, db *kvdb.Backend) error {
+		if *db == nil {
+			return nil
+		}
+
+		wrapped, err := primary.Wrap(name, *db)
+		if err != nil {
+			return fmt.Errorf("unable to replicate %v: %w", name,
+				err)
+		}
+		*db = wrapped
+
+		return nil
+	}
+
+	// The graph, channel state and height hint databases are the same
+	// bolt database, so they must share the same wrapped backend.
+	chanDB := backends.ChanStateDB
+	for _, db := range []struct {
+		name string
+		db   *kvdb.Backend
+	}{
+		{lncfg.NSChannelDB, &chanDB},
+		{lncfg.NSMacaroonDB, &backends.MacaroonDB},
+		{lncfg.NSDecayedLogDB, &backends.DecayedLogDB},
+		{lncfg.NSTowerClientDB, &backends.TowerClientDB},
+		{lncfg.NSTowerServerDB, &backends.TowerServerDB},
+	} {
+		if err := wrap(db.name, db.db); err != nil {
+			_ = listener.Close()
+			return nil, err
+		}
+	}
+	backends.GraphDB = chanDB
+	backends.ChanStateDB = chanDB
+	backends.HeightHintDB = chanDB
+
+	return primary, nil
+}
+
+// runReplicationStandby replicates the databases of the configured primary
+// until the quit channel is closed or the standby promotes itself, in which
+// case true is returned.
+func runReplicationStandby(ctx context.Context, cfg *Config,
+	quit <-chan struct{}) (bool, error) {
+
+	secret, err := cfg.Replication.ParseSecret()
+	if err != nil {
+		return false, err
+	}
+
+	tlsCfg, err := replicationStandbyTLSConfig(cfg.Replication.TLSCertPath)
+	if err != nil {
+		return false, err
+	}
+
+	backends, err := cfg.getDatabaseBackends(ctx)
+	if err != nil {
+		return false, fmt.Errorf("unable to obtain database backends: "+
+			"%v", err)
+	}
+	defer func() {
+		for name, closeFunc := range backends.CloseFuncs {
+			if err := closeFunc(); err != nil {
+				ltndLog.Errorf("Error closing %s database: %v",
+					name, err)
+			}
+		}
+	}()
+
+	standby, err := replication.NewStandby(&replication.StandbyConfig{
+		Dial: func() (net.Conn, error) {
+			dialer := &net.Dialer{Timeout: replicationDialTimeout}
+
+			return tls.DialWithDialer(
+				dialer, "tcp", cfg.Replication.Primary, tlsCfg,
+			)
+		},
+		Secret:       secret,
+		DBs:          replicatedBackends(backends),
+		PromoteAfter: cfg.Replication.PromoteAfter,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	ltndLog.Infof("Running as replication standby of %v",
+		cfg.Replication.Primary)
+
+	return standby.Run(quit)
+}
+
+// replicationStandbyTLSConfig returns the TLS configuration the standby
+// connects to its primary with. The primary is authenticated by its exact
+// certificate, as it is usually self-signed and may not be valid for the
+// address the standby connects to.
+func replicationStandbyTLSConfig(certPath string) (*tls.Config, error) {
+	certBytes, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(certBytes)
+	if block == nil {
+		return nil, fmt.Errorf("no certificate found in %v", certPath)
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+
+		// The certificate is verified by VerifyPeerCertificate below.
+		InsecureSkipVerify: true, // nolint:gosec
+		VerifyPeerCertificate: func(rawCerts [][]byte,
+			_ [][]*x509.Certificate) error {
+
+			if len(rawCerts) == 0 ||
+				!bytes.Equal(rawCerts[0], block.Bytes) {
+
+				return errors.New("unexpected certificate of " +
+					"replication primary")
+			}
+
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,364 @@
+package replication
+
+import (
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+// committer is called by a recording transaction to commit itself. It is
+// given the underlying transaction, the changes it made and the function
+// that commits the underlying transaction.
+type committer func(tx kvdb.RwTx, ops []op, commit func() error) error
+
+// backend is a kvdb.Backend that records the changes made by read-write
+// transactions and hands them to its committer instead of committing them
+// directly. Read-only transactions are passed through.
+//
+// NOTE: The backend doesn't implement walletdb.BatchDB, so batched updates
+// fall back to regular updates. Bolt's batching may run a closure more than
+// once, which the recorded changes can't account for.
+type backend struct {
+	kvdb.Backend
+
+	commit committer
+}
+
+// newBackend wraps the given backend so its transactions are committed
+// through the given committer.
+func newBackend(db kvdb.Backend, commit committer) *backend {
+	return &backend{
+		Backend: db,
+		commit:  commit,
+	}
+}
+
+// BeginReadWriteTx opens a database read+write transaction that records its
+// changes.
+//
+// NOTE: This is part of the walletdb.DB interface.
+func (b *backend) BeginReadWriteTx() (kvdb.RwTx, error) {
+	tx, err := b.Backend.BeginReadWriteTx()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rwTx{
+		RwTx:   tx,
+		commit: b.commit,
+	}, nil
+}
+
+// Update opens a database read/write transaction and executes the function f
+// with the transaction passed as a parameter. After f exits, if f did not
+// error, the transaction is committed. Otherwise, if f did error, the
+// transaction is rolled back.
+//
+// NOTE: This is part of the walletdb.DB interface.
+func (b *backend) Update(f func(tx kvdb.RwTx) error, reset func()) error {
+	// Like bolt itself, we don't do any retries, so we call the reset
+	// function once.
+	reset()
+
+	tx, err := b.BeginReadWriteTx()
+	if err != nil {
+		return err
+	}
+
+	// Make sure the transaction rolls back in the event of a panic.
+	defer func() {
+		if tx != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := f(tx); err != nil {
+		// Want to return the original error, not a rollback error if
+		// any occur.
+		_ = tx.Rollback()
+		tx = nil
+		return err
+	}
+
+	err = tx.Commit()
+	tx = nil
+
+	return err
+}
+
+// rwTx is a read-write transaction that records its changes.
+type rwTx struct {
+	kvdb.RwTx
+
+	commit committer
+	ops    []op
+}
+
+// record adds a change to the transaction.
+func (t *rwTx) record(o op) {
+	t.ops = append(t.ops, o)
+}
+
+// ReadWriteBucket opens the root bucket for read/write access.
+//
+// NOTE: This is part of the walletdb.ReadWriteTx interface.
+func (t *rwTx) ReadWriteBucket(key []byte) kvdb.RwBucket {
+	bucket := t.RwTx.ReadWriteBucket(key)
+	if bucket == nil {
+		return nil
+	}
+
+	return &rwBucket{
+		RwBucket: bucket,
+		tx:       t,
+		path:     childPath(nil, key),
+	}
+}
+
+// CreateTopLevelBucket creates the top level bucket for a key if it does not
+// exist.
+//
+// NOTE: This is part of the walletdb.ReadWriteTx interface.
+func (t *rwTx) CreateTopLevelBucket(key []byte) (kvdb.RwBucket, error) {
+	bucket, err := t.RwTx.CreateTopLevelBucket(key)
+	if err != nil {
+		return nil, err
+	}
+
+	path := childPath(nil, key)
+	t.record(op{typ: opCreateBucket, path: path})
+
+	return &rwBucket{
+		RwBucket: bucket,
+		tx:       t,
+		path:     path,
+	}, nil
+}
+
+// DeleteTopLevelBucket deletes the top level bucket for a key.
+//
+// NOTE: This is part of the walletdb.ReadWriteTx interface.
+func (t *rwTx) DeleteTopLevelBucket(key []byte) error {
+	if err := t.RwTx.DeleteTopLevelBucket(key); err != nil {
+		return err
+	}
+
+	t.record(op{typ: opDeleteBucket, path: childPath(nil, key)})
+
+	return nil
+}
+
+// Commit commits the transaction through the committer of the backend.
+//
+// NOTE: This is part of the walletdb.ReadWriteTx interface.
+func (t *rwTx) Commit() error {
+	return t.commit(t.RwTx, t.ops, t.RwTx.Commit)
+}
+
+// rwBucket is a read-write bucket that records its changes in the
+// transaction it belongs to.
+type rwBucket struct {
+	kvdb.RwBucket
+
+	tx   *rwTx
+	path [][]byte
+}
+
+// wrap wraps the nested bucket with the given name.
+func (b *rwBucket) wrap(bucket kvdb.RwBucket, name []byte) *rwBucket {
+	return &rwBucket{
+		RwBucket: bucket,
+		tx:       b.tx,
+		path:     childPath(b.path, name),
+	}
+}
+
+// NestedReadWriteBucket retrieves a nested bucket with the given key.
+//
+// NOTE: This is part of the walletdb.ReadWriteBucket interface.
+func (b *rwBucket) NestedReadWriteBucket(key []byte) kvdb.RwBucket {
+	bucket := b.RwBucket.NestedReadWriteBucket(key)
+	if bucket == nil {
+		return nil
+	}
+
+	return b.wrap(bucket, key)
+}
+
+// CreateBucket creates and returns a new nested bucket with the given key.
+//
+// NOTE: This is part of the walletdb.ReadWriteBucket interface.
+func (b *rwBucket) CreateBucket(key []byte) (kvdb.RwBucket, error) {
+	bucket, err := b.RwBucket.CreateBucket(key)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := b.wrap(bucket, key)
+	b.tx.record(op{typ: opCreateBucket, path: wrapped.path})
+
+	return wrapped, nil
+}
+
+// CreateBucketIfNotExists creates and returns a new nested bucket with the
+// given key if it does not already exist.
+//
+// NOTE: This is part of the walletdb.ReadWriteBucket interface.
+func (b *rwBucket) CreateBucketIfNotExists(key []byte) (kvdb.RwBucket,
+	error) {
+
+	bucket, err := b.RwBucket.CreateBucketIfNotExists(key)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := b.wrap(bucket, key)
+	b.tx.record(op{typ: opCreateBucket, path: wrapped.path})
+
+	return wrapped, nil
+}
+
+// DeleteNestedBucket removes a nested bucket with the given key.
+//
+// NOTE: This is part of the walletdb.ReadWriteBucket interface.
+func (b *rwBucket) DeleteNestedBucket(key []byte) error {
+	if err := b.RwBucket.DeleteNestedBucket(key); err != nil {
+		return err
+	}
+
+	b.tx.record(op{typ: opDeleteBucket, path: childPath(b.path, key)})
+
+	return nil
+}
+
+// Put saves the specified key/value pair to the bucket.
+//
+// NOTE: This is part of the walletdb.ReadWriteBucket interface.
+func (b *rwBucket) Put(key, value []byte) error {
+	if err := b.RwBucket.Put(key, value); err != nil {
+		return err
+	}
+
+	b.tx.record(op{
+		typ:   opPut,
+		path:  b.path,
+		key:   copyBytes(key),
+		value: copyBytes(value),
+	})
+
+	return nil
+}
+
+// Delete removes the specified key from the bucket.
+//
+// NOTE: This is part of the walletdb.ReadWriteBucket interface.
+func (b *rwBucket) Delete(key []byte) error {
+	if err := b.RwBucket.Delete(key); err != nil {
+		return err
+	}
+
+	b.tx.record(op{typ: opDelete, path: b.path, key: copyBytes(key)})
+
+	return nil
+}
+
+// ReadWriteCursor returns a new read-write cursor for this bucket.
+//
+// NOTE: This is part of the walletdb.ReadWriteBucket interface.
+func (b *rwBucket) ReadWriteCursor() kvdb.RwCursor {
+	return &rwCursor{
+		RwCursor: b.RwBucket.ReadWriteCursor(),
+		bucket:   b,
+	}
+}
+
+// Tx returns the bucket's transaction.
+//
+// NOTE: This is part of the walletdb.ReadWriteBucket interface.
+func (b *rwBucket) Tx() kvdb.RwTx {
+	return b.tx
+}
+
+// NextSequence returns an autoincrementing sequence number for this bucket.
+//
+// NOTE: This is part of the walletdb.ReadWriteBucket interface.
+func (b *rwBucket) NextSequence() (uint64, error) {
+	seq, err := b.RwBucket.NextSequence()
+	if err != nil {
+		return 0, err
+	}
+
+	b.tx.record(op{typ: opSetSequence, path: b.path, seq: seq})
+
+	return seq, nil
+}
+
+// SetSequence updates the sequence number for the bucket.
+//
+// NOTE: This is part of the walletdb.ReadWriteBucket interface.
+func (b *rwBucket) SetSequence(v uint64) error {
+	if err := b.RwBucket.SetSequence(v); err != nil {
+		return err
+	}
+
+	b.tx.record(op{typ: opSetSequence, path: b.path, seq: v})
+
+	return nil
+}
+
+// rwCursor is a read-write cursor that records deletions. It remembers the
+// key it is positioned at, as that is the key a deletion applies to.
+type rwCursor struct {
+	kvdb.RwCursor
+
+	bucket *rwBucket
+	key    []byte
+}
+
+// position remembers the key the cursor was moved to.
+func (c *rwCursor) position(k, v []byte) ([]byte, []byte) {
+	c.key = k
+	return k, v
+}
+
+// First positions the cursor at the first key/value pair and returns the
+// pair.
+func (c *rwCursor) First() ([]byte, []byte) {
+	return c.position(c.RwCursor.First())
+}
+
+// Last positions the cursor at the last key/value pair and returns the pair.
+func (c *rwCursor) Last() ([]byte, []byte) {
+	return c.position(c.RwCursor.Last())
+}
+
+// Next moves the cursor one key/value pair forward and returns the new pair.
+func (c *rwCursor) Next() ([]byte, []byte) {
+	return c.position(c.RwCursor.Next())
+}
+
+// Prev moves the cursor one key/value pair backward and returns the new
+// pair.
+func (c *rwCursor) Prev() ([]byte, []byte) {
+	return c.position(c.RwCursor.Prev())
+}
+
+// Seek positions the cursor at the passed seek key. If the key does not
+// exist, the cursor is moved to the next key after seek. Returns the new
+// pair.
+func (c *rwCursor) Seek(seek []byte) ([]byte, []byte) {
+	return c.position(c.RwCursor.Seek(seek))
+}
+
+// Delete removes the current key/value pair the cursor is at without
+// invalidating the cursor.
+//
+// NOTE: This is part of the walletdb.ReadWriteCursor interface.
+func (c *rwCursor) Delete() error {
+	key := copyBytes(c.key)
+	if err := c.RwCursor.Delete(); err != nil {
+		return err
+	}
+
+	c.bucket.tx.record(op{typ: opDelete, path: c.bucket.path, key: key})
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package replication
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// metaBucket is the top-level bucket that holds the replication state
+	// of a database. It is never replicated itself.
+	metaBucket = []byte("replication-meta")
+
+	// dbIDKey is the key of the random id of a database. A standby only
+	// continues to apply changes incrementally if it replicates the
+	// database with the same id.
+	dbIDKey = []byte("db-id")
+
+	// seqKey is the key of the sequence number of the last transaction
+	// committed to the database, or applied to it on a standby.
+	seqKey = []byte("seq")
+
+	// roleKey is the key of the role of the node the database belongs to.
+	roleKey = []byte("role")
+
+	// syncedKey is the key under which a standby stores whether it is in
+	// sync with its primary, and under which a primary stores whether it
+	// has a standby that is in sync.
+	syncedKey = []byte("synced")
+
+	// ackTimeoutKey is the key under which a standby stores the ack timeout
+	// of its primary.
+	ackTimeoutKey = []byte("ack-timeout")
+
+	// roleStandby marks the database of a standby.
+	roleStandby = []byte("standby")
+
+	// rolePrimary marks the database of a primary.
+	rolePrimary = []byte("primary")
+
+	// byteOrder is the byte order of all integers in the meta bucket.
+	byteOrder = binary.BigEndian
+)
+
+var (
+	// ErrStaleStandby is returned if a node is started on the database of
+	// a standby that wasn't in sync with its primary.
+	ErrStaleStandby = errors.New("database belongs to a standby that " +
+		"is not in sync with its primary")
+
+	// ErrPrimaryDB is returned if a standby is started on the database of
+	// a primary.
+	ErrPrimaryDB = errors.New("database belongs to a primary")
+)
+
+// dbIDLen is the length of a database id.
+const dbIDLen = 8
+
+// dbID is the random id of a replicated database.
+type dbID [dbIDLen]byte
+
+// newDBID returns a new random database id. The zero id is reserved for
+// databases whose snapshot is incomplete.
+func newDBID() (dbID, error) {
+	var id dbID
+	for id == (dbID{}) {
+		if _, err := rand.Read(id[:]); err != nil {
+			return id, err
+		}
+	}
+
+	return id, nil
+}
+
+// meta is the replication state of a database.
+type meta struct {
+	id         dbID
+	seq        uint64
+	role       []byte
+	synced     bool
+	ackTimeout time.Duration
+}
+
+// fetchMeta reads the replication state of a database. A zero state is
+// returned if the database hasn't been replicated yet.
+func fetchMeta(tx kvdb.RTx) (*meta, error) {
+	m := &meta{}
+
+	bucket := tx.ReadBucket(metaBucket)
+	if bucket == nil {
+		return m, nil
+	}
+
+	if id := bucket.Get(dbIDKey); id != nil {
+		if len(id) != dbIDLen {
+			return nil, fmt.Errorf("invalid db id %x", id)
+		}
+		copy(m.id[:], id)
+	}
+
+	if seq := bucket.Get(seqKey); seq != nil {
+		if len(seq) != 8 {
+			return nil, fmt.Errorf("invalid seq %x", seq)
+		}
+		m.seq = byteOrder.Uint64(seq)
+	}
+
+	if timeout := bucket.Get(ackTimeoutKey); timeout != nil {
+		if len(timeout) != 8 {
+			return nil, fmt.Errorf("invalid ack timeout %x",
+				timeout)
+		}
+		m.ackTimeout = time.Duration(byteOrder.Uint64(timeout))
+	}
+
+	m.role = copyBytes(bucket.Get(roleKey))
+	m.synced = bytes.Equal(bucket.Get(syncedKey), []byte{1})
+
+	return m, nil
+}
+
+// putMeta writes the replication state of a database.
+func putMeta(tx kvdb.RwTx, m *meta) error {
+	bucket, err := tx.CreateTopLevelBucket(metaBucket)
+	if err != nil {
+		return err
+	}
+
+	var seq, timeout [8]byte
+	byteOrder.PutUint64(seq[:], m.seq)
+	byteOrder.PutUint64(timeout[:], uint64(m.ackTimeout))
+
+	synced := []byte{0}
+	if m.synced {
+		synced[0] = 1
+	}
+
+	for _, kv := range []struct {
+		key   []byte
+		value []byte
+	}{
+		{dbIDKey, m.id[:]},
+		{seqKey, seq[:]},
+		{roleKey, m.role},
+		{syncedKey, synced},
+		{ackTimeoutKey, timeout[:]},
+	} {
+		if err := bucket.Put(kv.key, kv.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// putSeq updates the sequence number of a database.
+func putSeq(tx kvdb.RwTx, seq uint64) error {
+	bucket := tx.ReadWriteBucket(metaBucket)
+	if bucket == nil {
+		return fmt.Errorf("replication meta bucket not found")
+	}
+
+	var b [8]byte
+	byteOrder.PutUint64(b[:], seq)
+
+	return bucket.Put(seqKey, b[:])
+}
+
+// readMeta reads the replication state of the given database.
+func readMeta(db kvdb.Backend) (*meta, error) {
+	var m *meta
+	err := kvdb.View(db, func(tx kvdb.RTx) error {
+		var err error
+		m, err = fetchMeta(tx)
+		return err
+	}, func() {
+		m = nil
+	})
+
+	return m, err
+}
+
+// updateMeta applies the given modification to the replication state of the
+// given database.
+func updateMeta(db kvdb.Backend, modify func(m *meta)) error {
+	return kvdb.Update(db, func(tx kvdb.RwTx) error {
+		m, err := fetchMeta(tx)
+		if err != nil {
+			return err
+		}
+
+		modify(m)
+
+		return putMeta(tx, m)
+	}, func() {})
+}
+
+// CheckPromotion checks that a node that doesn't run as a standby may use the
+// given database. If it belonged to a standby, the standby must have been in
+// sync with its primary, which is the case if it was promoted or if it lost
+// the connection to its primary unexpectedly, as a primary never commits
+// anything a synced standby hasn't received. The database is then taken over
+// under a new id, so the former primary can only replicate it from scratch.
+// With force set, a database that wasn't in sync is taken over as well.
+func CheckPromotion(db kvdb.Backend, force bool) error {
+	m, err := readMeta(db)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(m.role, roleStandby) {
+		return nil
+	}
+
+	if !m.synced {
+		if !force {
+			return ErrStaleStandby
+		}
+
+		log.Warnf("Taking over database of standby that is not in " +
+			"sync with its primary")
+	}
+
+	id, err := newDBID()
+	if err != nil {
+		return err
+	}
+
+	return updateMeta(db, func(m *meta) {
+		m.id = id
+		m.role = rolePrimary
+		m.synced = false
+	})
+}
@@ -0,0 +1,281 @@
+package replication
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+const (
+	// maxFieldSize is the maximum size of a single key, value or bucket
+	// name we accept from the remote side.
+	maxFieldSize = 64 * 1024 * 1024
+
+	// maxPathLen is the maximum nesting depth of buckets we accept from
+	// the remote side.
+	maxPathLen = 64
+)
+
+// opType is the type of a single change made by a transaction.
+type opType uint8
+
+const (
+	// opCreateBucket creates the bucket at the path if it doesn't exist
+	// yet.
+	opCreateBucket opType = iota
+
+	// opDeleteBucket deletes the bucket at the path.
+	opDeleteBucket
+
+	// opPut sets the key of the bucket at the path to the value.
+	opPut
+
+	// opDelete deletes the key from the bucket at the path.
+	opDelete
+
+	// opSetSequence sets the sequence of the bucket at the path.
+	opSetSequence
+)
+
+// op is a single change made by a transaction. The path lists the names of
+// the buckets from the top level down to the bucket the change applies to.
+type op struct {
+	typ   opType
+	path  [][]byte
+	key   []byte
+	value []byte
+	seq   uint64
+}
+
+// copyBytes returns a copy of the given slice, as bolt only guarantees keys
+// and values to be valid for the lifetime of a transaction.
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+
+	return append([]byte{}, b...)
+}
+
+// childPath returns the path of the nested bucket with the given name.
+func childPath(path [][]byte, name []byte) [][]byte {
+	child := make([][]byte, 0, len(path)+1)
+	child = append(child, path...)
+
+	return append(child, copyBytes(name))
+}
+
+// encode writes the binary encoding of the op to w.
+func (o *op) encode(w io.Writer) error {
+	if _, err := w.Write([]byte{byte(o.typ)}); err != nil {
+		return err
+	}
+
+	err := wire.WriteVarInt(w, 0, uint64(len(o.path)))
+	if err != nil {
+		return err
+	}
+	for _, name := range o.path {
+		if err := wire.WriteVarBytes(w, 0, name); err != nil {
+			return err
+		}
+	}
+
+	switch o.typ {
+	case opPut:
+		if err := wire.WriteVarBytes(w, 0, o.key); err != nil {
+			return err
+		}
+		return wire.WriteVarBytes(w, 0, o.value)
+
+	case opDelete:
+		return wire.WriteVarBytes(w, 0, o.key)
+
+	case opSetSequence:
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], o.seq)
+		_, err := w.Write(b[:])
+		return err
+	}
+
+	return nil
+}
+
+// decode reads the binary encoding of an op from r.
+func (o *op) decode(r io.Reader) error {
+	var typ [1]byte
+	if _, err := io.ReadFull(r, typ[:]); err != nil {
+		return err
+	}
+	o.typ = opType(typ[0])
+
+	pathLen, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return err
+	}
+	if pathLen == 0 || pathLen > maxPathLen {
+		return fmt.Errorf("invalid bucket path length %d", pathLen)
+	}
+
+	o.path = make([][]byte, pathLen)
+	for i := range o.path {
+		o.path[i], err = wire.ReadVarBytes(r, 0, maxFieldSize, "bucket")
+		if err != nil {
+			return err
+		}
+	}
+
+	switch o.typ {
+	case opCreateBucket, opDeleteBucket:
+		return nil
+
+	case opPut:
+		o.key, err = wire.ReadVarBytes(r, 0, maxFieldSize, "key")
+		if err != nil {
+			return err
+		}
+		o.value, err = wire.ReadVarBytes(r, 0, maxFieldSize, "value")
+		return err
+
+	case opDelete:
+		o.key, err = wire.ReadVarBytes(r, 0, maxFieldSize, "key")
+		return err
+
+	case opSetSequence:
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return err
+		}
+		o.seq = binary.BigEndian.Uint64(b[:])
+		return nil
+
+	default:
+		return fmt.Errorf("unknown op type %d", o.typ)
+	}
+}
+
+// encodeOps writes the given ops to w, prefixed by their number.
+func encodeOps(w io.Writer, ops []op) error {
+	if err := wire.WriteVarInt(w, 0, uint64(len(ops))); err != nil {
+		return err
+	}
+
+	for i := range ops {
+		if err := ops[i].encode(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeOps reads a list of ops written by encodeOps from r.
+func decodeOps(r io.Reader) ([]op, error) {
+	numOps, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each op takes at least two bytes, which bounds the allocation by the
+	// size of the message.
+	ops := make([]op, 0, min(numOps, maxMsgSize/2))
+	for i := uint64(0); i < numOps; i++ {
+		var o op
+		if err := o.decode(r); err != nil {
+			return nil, err
+		}
+		ops = append(ops, o)
+	}
+
+	return ops, nil
+}
+
+// min returns the smaller of the given values.
+func min(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// bucketAt returns the bucket at the given path.
+func bucketAt(tx kvdb.RwTx, path [][]byte) (kvdb.RwBucket, error) {
+	bucket := tx.ReadWriteBucket(path[0])
+	for _, name := range path[1:] {
+		if bucket == nil {
+			break
+		}
+		bucket = bucket.NestedReadWriteBucket(name)
+	}
+
+	if bucket == nil {
+		return nil, fmt.Errorf("bucket %x not found", path)
+	}
+
+	return bucket, nil
+}
+
+// applyOps applies the given ops to the transaction.
+func applyOps(tx kvdb.RwTx, ops []op) error {
+	for i := range ops {
+		if err := applyOp(tx, &ops[i]); err != nil {
+			return fmt.Errorf("unable to apply op %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// applyOp applies a single op to the transaction.
+func applyOp(tx kvdb.RwTx, o *op) error {
+	parentPath, name := o.path[:len(o.path)-1], o.path[len(o.path)-1]
+
+	switch o.typ {
+	case opCreateBucket:
+		if len(parentPath) == 0 {
+			_, err := tx.CreateTopLevelBucket(name)
+			return err
+		}
+
+		parent, err := bucketAt(tx, parentPath)
+		if err != nil {
+			return err
+		}
+		_, err = parent.CreateBucketIfNotExists(name)
+		return err
+
+	case opDeleteBucket:
+		if len(parentPath) == 0 {
+			return tx.DeleteTopLevelBucket(name)
+		}
+
+		parent, err := bucketAt(tx, parentPath)
+		if err != nil {
+			return err
+		}
+		return parent.DeleteNestedBucket(name)
+	}
+
+	bucket, err := bucketAt(tx, o.path)
+	if err != nil {
+		return err
+	}
+
+	switch o.typ {
+	case opPut:
+		return bucket.Put(o.key, o.value)
+
+	case opDelete:
+		return bucket.Delete(o.key)
+
+	case opSetSequence:
+		return bucket.SetSequence(o.seq)
+
+	default:
+		return fmt.Errorf("unknown op type %d", o.typ)
+	}
+}
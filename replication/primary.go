@@ -0,0 +1,924 @@
+package replication
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+const (
+	// snapshotChunkSize is the approximate size of the chunks a snapshot
+	// is sent in.
+	snapshotChunkSize = 1024 * 1024
+)
+
+var (
+	// ErrFenced is returned for every transaction once the primary fenced
+	// itself because it lost its synced standby.
+	ErrFenced = errors.New("replication primary is fenced after losing " +
+		"its standby")
+
+	// errAckTimeout is the reason a standby is detached if it doesn't
+	// acknowledge a transaction in time.
+	errAckTimeout = errors.New("standby didn't acknowledge transaction " +
+		"in time")
+)
+
+// PrimaryConfig holds the parameters of the primary side of the replication.
+type PrimaryConfig struct {
+	// Listener accepts the connections of standbys. It is expected to
+	// encrypt the connections.
+	Listener net.Listener
+
+	// Secret is the shared secret a standby authenticates with.
+	Secret []byte
+
+	// AckTimeout is the time a synced standby has to acknowledge a
+	// transaction, and the time after which it is considered lost if it
+	// doesn't respond at all.
+	AckTimeout time.Duration
+}
+
+// primaryDB is a database replicated by the primary.
+type primaryDB struct {
+	name string
+	db   kvdb.Backend
+
+	// mtx is held from the moment a transaction is assigned its sequence
+	// number until it is committed, so a snapshot never misses a
+	// transaction that is about to be committed.
+	mtx sync.Mutex
+	id  dbID
+	seq uint64
+}
+
+// standbyConn is the connection to an attached standby.
+type standbyConn struct {
+	conn net.Conn
+
+	// writeMtx serializes writes to the connection.
+	writeMtx sync.Mutex
+
+	// The following fields are guarded by the mutex of the primary.
+	queue     []queuedMsg
+	live      map[string]bool
+	pending   map[string][]queuedMsg
+	acked     map[string]uint64
+	ackNotify chan struct{}
+	graceful  bool
+
+	// goodbyeSent is true once the primary detaches the standby
+	// gracefully.
+	goodbyeSent bool
+
+	queueSignal chan struct{}
+	quit        chan struct{}
+}
+
+// queuedMsg is a message waiting to be written to a standby.
+type queuedMsg struct {
+	typ     msgType
+	payload []byte
+}
+
+// Primary is the primary side of the replication. It records the changes of
+// every transaction committed to the databases it wraps and sends them to a
+// standby, which applies them to its own copy of the databases.
+//
+// Once the standby is in sync, every transaction is only committed after
+// the standby acknowledged it, so the standby never lags behind the primary.
+// If the standby becomes unreachable from then on, the primary fences
+// itself: no further transactions are committed and Fenced is closed, upon
+// which lnd shuts down. That way, a standby that lost its primary can be
+// promoted without the risk of broadcasting a revoked channel state, as long
+// as it waits longer than the ack timeout.
+type Primary struct {
+	cfg *PrimaryConfig
+
+	dbs     map[string]*primaryDB
+	dbNames []string
+
+	// needsStandby is true if the primary had a synced standby when it was
+	// last shut down without detaching it first.
+	needsStandby bool
+
+	mtx     sync.Mutex
+	standby *standbyConn
+	synced  bool
+
+	// syncSignal is closed and replaced every time a standby becomes
+	// synced.
+	syncSignal chan struct{}
+
+	fenced    chan struct{}
+	fenceOnce sync.Once
+
+	quit     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewPrimary creates the primary side of the replication. The databases to
+// replicate are added with Wrap before calling Start.
+func NewPrimary(cfg *PrimaryConfig) *Primary {
+	return &Primary{
+		cfg:        cfg,
+		dbs:        make(map[string]*primaryDB),
+		syncSignal: make(chan struct{}),
+		fenced:     make(chan struct{}),
+		quit:       make(chan struct{}),
+	}
+}
+
+// Wrap registers the given database for replication under the given name
+// and returns the backend all transactions have to go through.
+func (p *Primary) Wrap(name string, db kvdb.Backend) (kvdb.Backend, error) {
+	if _, ok := p.dbs[name]; ok {
+		return nil, fmt.Errorf("database %v already replicated", name)
+	}
+
+	m, err := readMeta(db)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.Equal(m.role, roleStandby):
+		return nil, fmt.Errorf("%w: %v", ErrStaleStandby, name)
+
+	// A database that is replicated for the first time gets a new id, so
+	// that no standby mistakes it for a database it knows.
+	case m.id == dbID{}:
+		m.id, err = newDBID()
+		if err != nil {
+			return nil, err
+		}
+		m.role = rolePrimary
+		m.synced = false
+
+		err := kvdb.Update(db, func(tx kvdb.RwTx) error {
+			return putMeta(tx, m)
+		}, func() {})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if m.synced {
+		p.needsStandby = true
+	}
+
+	pdb := &primaryDB{
+		name: name,
+		db:   db,
+		id:   m.id,
+		seq:  m.seq,
+	}
+	p.dbs[name] = pdb
+	p.dbNames = append(p.dbNames, name)
+
+	return newBackend(db, func(tx kvdb.RwTx, ops []op,
+		commit func() error) error {
+
+		return p.commitTx(pdb, tx, ops, commit)
+	}), nil
+}
+
+// Start starts accepting connections from standbys.
+func (p *Primary) Start() error {
+	log.Infof("Accepting replication standby on %v",
+		p.cfg.Listener.Addr())
+
+	p.wg.Add(1)
+	go p.acceptConns()
+
+	return nil
+}
+
+// Stop detaches the standby gracefully and stops accepting connections. The
+// standby is no longer in sync afterwards.
+func (p *Primary) Stop() error {
+	p.stopOnce.Do(func() {
+		close(p.quit)
+		_ = p.cfg.Listener.Close()
+
+		p.mtx.Lock()
+		sc := p.standby
+		p.mtx.Unlock()
+
+		if sc != nil {
+			p.sayGoodbye(sc)
+		}
+
+		p.wg.Wait()
+	})
+
+	return nil
+}
+
+// sayGoodbye detaches the standby gracefully. The standby closes the
+// connection once it stored that it's no longer in sync, and only then do we
+// consider it detached gracefully. Otherwise, the standby may still promote
+// itself, so we have to wait for it on the next start.
+func (p *Primary) sayGoodbye(sc *standbyConn) {
+	p.mtx.Lock()
+	sc.goodbyeSent = true
+	p.mtx.Unlock()
+
+	if err := p.write(sc, msgGoodbye, nil); err != nil {
+		p.detach(sc, false, err)
+		return
+	}
+
+	select {
+	case <-sc.quit:
+
+	case <-time.After(p.cfg.AckTimeout):
+		p.detach(sc, false, errors.New("standby didn't respond to "+
+			"goodbye"))
+	}
+}
+
+// Fenced returns a channel that is closed once the primary fenced itself
+// because it lost its synced standby. lnd must shut down then.
+func (p *Primary) Fenced() <-chan struct{} {
+	return p.fenced
+}
+
+// NeedsStandby returns true if the primary had a synced standby when it was
+// last shut down without detaching it gracefully. This happens if the
+// primary crashed or fenced itself, in which case the standby may have been
+// promoted. To not run alongside a promoted standby, lnd waits for the
+// standby to be in sync again before it starts.
+func (p *Primary) NeedsStandby() bool {
+	return p.needsStandby
+}
+
+// WaitForStandby blocks until a standby is in sync or the quit channel is
+// closed.
+func (p *Primary) WaitForStandby(quit <-chan struct{}) error {
+	for {
+		p.mtx.Lock()
+		synced := p.synced
+		signal := p.syncSignal
+		p.mtx.Unlock()
+
+		if synced {
+			return nil
+		}
+
+		select {
+		case <-signal:
+
+		case <-quit:
+			return errors.New("shutting down")
+
+		case <-p.fenced:
+			return ErrFenced
+		}
+	}
+}
+
+// fence stops the primary from committing any further transactions.
+func (p *Primary) fence(reason error) {
+	p.fenceOnce.Do(func() {
+		log.Errorf("Fencing replication primary: %v", reason)
+		close(p.fenced)
+	})
+}
+
+// commitTx commits a transaction with the given changes. The changes are sent
+// to the standby before the transaction is committed locally, and if the
+// standby is in sync, it must acknowledge them first.
+func (p *Primary) commitTx(db *primaryDB, tx kvdb.RwTx, ops []op,
+	commit func() error) error {
+
+	select {
+	case <-p.fenced:
+		_ = tx.Rollback()
+		return ErrFenced
+	default:
+	}
+
+	if len(ops) == 0 {
+		return commit()
+	}
+
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	seq := db.seq + 1
+	if err := putSeq(tx, seq); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	sent, err := p.replicate(db, seq, ops)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := commit(); err != nil {
+		// The standby may have applied the transaction already, so
+		// the databases diverged and we can't continue.
+		if sent {
+			p.fence(fmt.Errorf("unable to commit transaction %d "+
+				"of %v: %v", seq, db.name, err))
+		}
+
+		return err
+	}
+
+	db.seq = seq
+
+	return nil
+}
+
+// replicate sends the changes of a transaction to the standby, if there is
+// one, and waits for the acknowledgement if the standby is in sync. It
+// returns true if the changes were sent.
+//
+// NOTE: The mutex of the database must be held.
+func (p *Primary) replicate(db *primaryDB, seq uint64, ops []op) (bool,
+	error) {
+
+	msg := &opsMsg{name: db.name, seq: seq, ops: ops}
+	payload, err := msg.encode()
+	if err != nil {
+		return false, err
+	}
+
+	p.mtx.Lock()
+	sc := p.standby
+	if sc == nil {
+		p.mtx.Unlock()
+		return false, nil
+	}
+
+	// While a snapshot of the database is sent, its changes are held back
+	// until the snapshot is complete.
+	queued := queuedMsg{typ: msgOps, payload: payload}
+	if !sc.live[db.name] {
+		sc.pending[db.name] = append(sc.pending[db.name], queued)
+		p.mtx.Unlock()
+		return true, nil
+	}
+
+	sc.enqueue(queued)
+	synced := p.synced
+	p.mtx.Unlock()
+
+	if !synced {
+		return true, nil
+	}
+
+	return true, p.waitAck(sc, db.name, seq)
+}
+
+// waitAck waits until the standby acknowledged the transaction with the
+// given sequence number.
+func (p *Primary) waitAck(sc *standbyConn, name string, seq uint64) error {
+	timeout := time.NewTimer(p.cfg.AckTimeout)
+	defer timeout.Stop()
+
+	for {
+		p.mtx.Lock()
+		switch {
+		// If the standby detached gracefully, it knows it's no longer
+		// in sync, so we can continue without it.
+		case p.standby != sc && sc.graceful:
+			p.mtx.Unlock()
+			return nil
+
+		case p.standby != sc:
+			p.mtx.Unlock()
+			return ErrFenced
+
+		case sc.acked[name] >= seq:
+			p.mtx.Unlock()
+			return nil
+		}
+		notify := sc.ackNotify
+		p.mtx.Unlock()
+
+		select {
+		case <-notify:
+
+		case <-timeout.C:
+			p.detach(sc, false, errAckTimeout)
+			return ErrFenced
+		}
+	}
+}
+
+// enqueue adds a message to the queue of the standby.
+//
+// NOTE: The mutex of the primary must be held.
+func (sc *standbyConn) enqueue(msg queuedMsg) {
+	sc.queue = append(sc.queue, msg)
+
+	select {
+	case sc.queueSignal <- struct{}{}:
+	default:
+	}
+}
+
+// write writes a message to the standby.
+func (p *Primary) write(sc *standbyConn, typ msgType, payload []byte) error {
+	sc.writeMtx.Lock()
+	defer sc.writeMtx.Unlock()
+
+	err := sc.conn.SetWriteDeadline(time.Now().Add(p.cfg.AckTimeout))
+	if err != nil {
+		return err
+	}
+
+	return writeMsg(sc.conn, typ, payload)
+}
+
+// detach drops the connection to the standby. If the standby was in sync and
+// didn't detach gracefully, the primary fences itself.
+func (p *Primary) detach(sc *standbyConn, graceful bool, reason error) {
+	p.mtx.Lock()
+	if p.standby != sc {
+		p.mtx.Unlock()
+		return
+	}
+
+	wasSynced := p.synced
+	p.standby = nil
+	p.synced = false
+	sc.graceful = graceful
+	close(sc.quit)
+	close(sc.ackNotify)
+	p.mtx.Unlock()
+
+	_ = sc.conn.Close()
+
+	log.Infof("Replication standby %v detached: %v",
+		sc.conn.RemoteAddr(), reason)
+
+	switch {
+	case wasSynced && !graceful:
+		p.fence(fmt.Errorf("lost synced standby: %v", reason))
+
+	// After a graceful detach, the standby knows it's no longer in sync,
+	// so we don't need to wait for it on the next start.
+	case wasSynced:
+		p.setSynced(false)
+	}
+}
+
+// setSynced stores whether the primary has a synced standby.
+func (p *Primary) setSynced(synced bool) {
+	for _, name := range p.dbNames {
+		err := updateMeta(p.dbs[name].db, func(m *meta) {
+			m.synced = synced
+		})
+		if err != nil {
+			log.Errorf("Unable to store replication state of %v: "+
+				"%v", name, err)
+		}
+	}
+}
+
+// acceptConns accepts connections from standbys.
+//
+// NOTE: This must be run as a goroutine.
+func (p *Primary) acceptConns() {
+	defer p.wg.Done()
+
+	for {
+		conn, err := p.cfg.Listener.Accept()
+		if err != nil {
+			select {
+			case <-p.quit:
+				return
+			default:
+			}
+
+			log.Errorf("Unable to accept standby connection: %v",
+				err)
+			continue
+		}
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+
+			if err := p.handleConn(conn); err != nil {
+				log.Warnf("Rejected replication standby %v: "+
+					"%v", conn.RemoteAddr(), err)
+				_ = conn.Close()
+			}
+		}()
+	}
+}
+
+// handleConn authenticates a standby and brings it in sync.
+func (p *Primary) handleConn(conn net.Conn) error {
+	err := conn.SetReadDeadline(time.Now().Add(p.cfg.AckTimeout))
+	if err != nil {
+		return err
+	}
+
+	typ, payload, err := readMsg(conn)
+	if err != nil {
+		return err
+	}
+	if typ != msgHello {
+		return fmt.Errorf("unexpected message %d", typ)
+	}
+
+	var hello helloMsg
+	if err := hello.decode(payload); err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(hello.secret, p.cfg.Secret) != 1 {
+		return errors.New("invalid secret")
+	}
+
+	// The standby must replicate exactly the databases we do, otherwise it
+	// couldn't take over.
+	states := make(map[string]dbState, len(hello.dbs))
+	for _, state := range hello.dbs {
+		if _, ok := p.dbs[state.name]; !ok {
+			return fmt.Errorf("unknown database %v", state.name)
+		}
+		states[state.name] = state
+	}
+	for _, name := range p.dbNames {
+		if _, ok := states[name]; !ok {
+			return fmt.Errorf("standby doesn't replicate %v", name)
+		}
+	}
+
+	sc := &standbyConn{
+		conn:        conn,
+		live:        make(map[string]bool),
+		pending:     make(map[string][]queuedMsg),
+		acked:       make(map[string]uint64),
+		ackNotify:   make(chan struct{}),
+		queueSignal: make(chan struct{}, 1),
+		quit:        make(chan struct{}),
+	}
+
+	p.mtx.Lock()
+	switch {
+	case p.standby != nil:
+		p.mtx.Unlock()
+		return errors.New("another standby is attached")
+
+	case isClosed(p.quit):
+		p.mtx.Unlock()
+		return errors.New("primary shutting down")
+	}
+	p.standby = sc
+	p.mtx.Unlock()
+
+	// The welcome is written before anything else can be, as the snapshots
+	// are written directly rather than through the queue.
+	err = p.write(sc, msgWelcome, encodeDuration(p.cfg.AckTimeout))
+	if err != nil {
+		p.detach(sc, false, err)
+		return nil
+	}
+
+	log.Infof("Replication standby %v attached", conn.RemoteAddr())
+
+	p.wg.Add(3)
+	go p.writeConn(sc)
+	go p.readConn(sc)
+	go p.pingConn(sc)
+
+	for _, name := range p.dbNames {
+		if err := p.syncDB(sc, p.dbs[name], states[name]); err != nil {
+			p.detach(sc, false, err)
+			return nil
+		}
+	}
+
+	p.mtx.Lock()
+	if p.standby != sc {
+		p.mtx.Unlock()
+		return nil
+	}
+	sc.enqueue(queuedMsg{typ: msgSynced})
+	p.synced = true
+	close(p.syncSignal)
+	p.syncSignal = make(chan struct{})
+	p.mtx.Unlock()
+
+	log.Infof("Replication standby %v is in sync", conn.RemoteAddr())
+
+	p.setSynced(true)
+
+	return nil
+}
+
+// syncDB brings the standby's copy of a database up to date. If the standby
+// has the current state of the database already, its changes are sent from
+// now on. Otherwise a snapshot of the database is sent first.
+func (p *Primary) syncDB(sc *standbyConn, db *primaryDB, state dbState) error {
+	db.mtx.Lock()
+
+	if state.id == db.id && state.seq == db.seq {
+		p.mtx.Lock()
+		sc.live[db.name] = true
+		p.mtx.Unlock()
+		db.mtx.Unlock()
+
+		log.Debugf("Standby copy of %v is up to date at %d", db.name,
+			db.seq)
+
+		return nil
+	}
+
+	// A read transaction sees the database as of the last commit. As we
+	// hold the mutex of the database, that is the one with the current
+	// sequence number.
+	tx, err := db.db.BeginReadTx()
+	if err != nil {
+		db.mtx.Unlock()
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	seq := db.seq
+	db.mtx.Unlock()
+
+	log.Infof("Sending snapshot of %v at %d to standby", db.name, seq)
+
+	begin := &seqMsg{name: db.name, id: db.id, seq: seq}
+	payload, err := begin.encode()
+	if err != nil {
+		return err
+	}
+	if err := p.write(sc, msgSnapshotBegin, payload); err != nil {
+		return err
+	}
+
+	err = sendSnapshot(tx, func(ops []op) error {
+		select {
+		case <-sc.quit:
+			return errors.New("standby detached")
+		default:
+		}
+
+		msg := &opsMsg{name: db.name, ops: ops}
+		payload, err := msg.encode()
+		if err != nil {
+			return err
+		}
+
+		return p.write(sc, msgOps, payload)
+	})
+	if err != nil {
+		return err
+	}
+
+	end := &seqMsg{name: db.name, id: db.id, seq: seq}
+	payload, err = end.encode()
+	if err != nil {
+		return err
+	}
+
+	// The end of the snapshot and the changes held back in the meantime
+	// are queued together, so no change can slip in between.
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	sc.enqueue(queuedMsg{typ: msgSnapshotEnd, payload: payload})
+	for _, msg := range sc.pending[db.name] {
+		sc.enqueue(msg)
+	}
+	delete(sc.pending, db.name)
+	sc.live[db.name] = true
+
+	return nil
+}
+
+// sendSnapshot walks all buckets of the transaction except the meta bucket
+// and hands their contents to send in chunks.
+func sendSnapshot(tx kvdb.RTx, send func([]op) error) error {
+	var (
+		chunk []op
+		size  int
+	)
+	add := func(o op) error {
+		chunk = append(chunk, o)
+		size += len(o.key) + len(o.value) + 16
+		for _, name := range o.path {
+			size += len(name)
+		}
+
+		if size < snapshotChunkSize {
+			return nil
+		}
+
+		err := send(chunk)
+		chunk, size = nil, 0
+
+		return err
+	}
+
+	var walk func(bucket kvdb.RBucket, path [][]byte) error
+	walk = func(bucket kvdb.RBucket, path [][]byte) error {
+		err := add(op{typ: opCreateBucket, path: path})
+		if err != nil {
+			return err
+		}
+
+		if b, ok := bucket.(interface{ Sequence() uint64 }); ok &&
+			b.Sequence() != 0 {
+
+			err := add(op{
+				typ:  opSetSequence,
+				path: path,
+				seq:  b.Sequence(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		cursor := bucket.ReadCursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			if v == nil {
+				nested := bucket.NestedReadBucket(k)
+				if nested != nil {
+					err := walk(nested, childPath(path, k))
+					if err != nil {
+						return err
+					}
+
+					continue
+				}
+			}
+
+			err := add(op{
+				typ:   opPut,
+				path:  path,
+				key:   copyBytes(k),
+				value: copyBytes(v),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err := tx.ForEachBucket(func(name []byte) error {
+		if bytes.Equal(name, metaBucket) {
+			return nil
+		}
+
+		return walk(tx.ReadBucket(name), childPath(nil, name))
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(chunk) == 0 {
+		return nil
+	}
+
+	return send(chunk)
+}
+
+// writeConn writes the queued messages to the standby.
+//
+// NOTE: This must be run as a goroutine.
+func (p *Primary) writeConn(sc *standbyConn) {
+	defer p.wg.Done()
+
+	for {
+		p.mtx.Lock()
+		queue := sc.queue
+		sc.queue = nil
+		p.mtx.Unlock()
+
+		for _, msg := range queue {
+			err := p.write(sc, msg.typ, msg.payload)
+			if err != nil {
+				p.detach(sc, false, err)
+				return
+			}
+		}
+
+		select {
+		case <-sc.queueSignal:
+
+		case <-sc.quit:
+			return
+		}
+	}
+}
+
+// readConn reads the acknowledgements and pongs of the standby. A standby
+// that doesn't send anything for longer than the ack timeout is considered
+// lost, which can't happen for a healthy one as it is pinged periodically.
+//
+// NOTE: This must be run as a goroutine.
+func (p *Primary) readConn(sc *standbyConn) {
+	defer p.wg.Done()
+
+	for {
+		err := sc.conn.SetReadDeadline(time.Now().Add(p.cfg.AckTimeout))
+		if err != nil {
+			p.detach(sc, false, err)
+			return
+		}
+
+		typ, payload, err := readMsg(sc.conn)
+		if err != nil {
+			// After our goodbye, the standby closes the connection
+			// once it is no longer in sync.
+			p.mtx.Lock()
+			graceful := sc.goodbyeSent && errors.Is(err, io.EOF)
+			p.mtx.Unlock()
+
+			p.detach(sc, graceful, err)
+			return
+		}
+
+		switch typ {
+		case msgAck:
+			var ack seqMsg
+			if err := ack.decode(payload); err != nil {
+				p.detach(sc, false, err)
+				return
+			}
+
+			p.mtx.Lock()
+			if ack.seq > sc.acked[ack.name] {
+				sc.acked[ack.name] = ack.seq
+			}
+			if p.standby == sc {
+				close(sc.ackNotify)
+				sc.ackNotify = make(chan struct{})
+			}
+			p.mtx.Unlock()
+
+		case msgPong:
+
+		case msgGoodbye:
+			p.detach(sc, true, errors.New("standby shutting down"))
+			return
+
+		default:
+			p.detach(sc, false, fmt.Errorf("unexpected message %d",
+				typ))
+			return
+		}
+	}
+}
+
+// pingConn pings the standby periodically.
+//
+// NOTE: This must be run as a goroutine.
+func (p *Primary) pingConn(sc *standbyConn) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.AckTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.mtx.Lock()
+			if p.standby == sc {
+				sc.enqueue(queuedMsg{typ: msgPing})
+			}
+			p.mtx.Unlock()
+
+		case <-sc.quit:
+			return
+		}
+	}
+}
+
+// isClosed returns true if the given channel is closed.
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
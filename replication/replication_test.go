@@ -0,0 +1,400 @@
+package replication
+
+import (
+	"bytes"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDBName     = "channeldb"
+	testAckTimeout = 500 * time.Millisecond
+)
+
+var testSecret = bytes.Repeat([]byte{1}, 32)
+
+func init() {
+	reconnectInterval = 100 * time.Millisecond
+}
+
+// newTestDB creates a new bolt database in the given directory.
+func newTestDB(t *testing.T, dir string) kvdb.Backend {
+	db, err := kvdb.Create(
+		kvdb.BoltBackendName, filepath.Join(dir, "test.db"), true,
+		kvdb.DefaultDBTimeout,
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
+
+// dump returns the contents of all buckets except the meta bucket,
+// including their sequences.
+func dump(t *testing.T, db kvdb.Backend) map[string]string {
+	contents := make(map[string]string)
+	err := kvdb.View(db, func(tx kvdb.RTx) error {
+		return sendSnapshot(tx, func(ops []op) error {
+			for _, o := range ops {
+				path := string(bytes.Join(o.path, []byte("/")))
+				switch o.typ {
+				case opCreateBucket:
+					contents[path] = "bucket"
+
+				case opSetSequence:
+					var seq [8]byte
+					byteOrder.PutUint64(seq[:], o.seq)
+					contents[path+"#seq"] = string(seq[:])
+
+				case opPut:
+					contents[path+"/"+string(o.key)] =
+						string(o.value)
+				}
+			}
+			return nil
+		})
+	}, func() {
+		contents = make(map[string]string)
+	})
+	require.NoError(t, err)
+
+	return contents
+}
+
+// TestRecordAndApply asserts that the recorded changes of a transaction
+// produce the same database when applied elsewhere.
+func TestRecordAndApply(t *testing.T) {
+	source := newTestDB(t, t.TempDir())
+	target := newTestDB(t, t.TempDir())
+
+	var recorded []op
+	db := newBackend(source, func(_ kvdb.RwTx, ops []op,
+		commit func() error) error {
+
+		recorded = ops
+		return commit()
+	})
+
+	steps := []func(tx kvdb.RwTx) error{
+		func(tx kvdb.RwTx) error {
+			top, err := tx.CreateTopLevelBucket([]byte("top"))
+			require.NoError(t, err)
+			require.NoError(t, top.Put([]byte("a"), []byte("1")))
+			require.NoError(t, top.Put([]byte("b"), []byte{}))
+
+			nested, err := top.CreateBucket([]byte("nested"))
+			require.NoError(t, err)
+			_, err = nested.NextSequence()
+			require.NoError(t, err)
+			require.NoError(t, nested.Put([]byte("c"), []byte("3")))
+
+			_, err = tx.CreateTopLevelBucket([]byte("other"))
+			return err
+		},
+		func(tx kvdb.RwTx) error {
+			top := tx.ReadWriteBucket([]byte("top"))
+			require.NoError(t, top.Delete([]byte("a")))
+			require.NoError(t, top.SetSequence(7))
+
+			nested := top.NestedReadWriteBucket([]byte("nested"))
+			require.NoError(t, nested.Put([]byte("d"), []byte("4")))
+
+			// Deleting through a cursor deletes the key it is
+			// positioned at.
+			cursor := nested.ReadWriteCursor()
+			k, _ := cursor.Seek([]byte("c"))
+			require.Equal(t, []byte("c"), k)
+			require.NoError(t, cursor.Delete())
+
+			deep, err := nested.CreateBucketIfNotExists(
+				[]byte("deep"),
+			)
+			require.NoError(t, err)
+			require.NoError(t, deep.Put([]byte("e"), []byte("5")))
+
+			return tx.DeleteTopLevelBucket([]byte("other"))
+		},
+		func(tx kvdb.RwTx) error {
+			top := tx.ReadWriteBucket([]byte("top"))
+			nested := top.NestedReadWriteBucket([]byte("nested"))
+
+			return nested.DeleteNestedBucket([]byte("deep"))
+		},
+	}
+
+	for _, step := range steps {
+		recorded = nil
+		require.NoError(t, kvdb.Update(db, step, func() {}))
+
+		err := kvdb.Update(target, func(tx kvdb.RwTx) error {
+			return applyOps(tx, recorded)
+		}, func() {})
+		require.NoError(t, err)
+
+		require.Equal(t, dump(t, source), dump(t, target))
+	}
+
+	// A failing transaction isn't committed, so nothing is recorded.
+	recorded = nil
+	err := kvdb.Update(db, func(tx kvdb.RwTx) error {
+		top := tx.ReadWriteBucket([]byte("top"))
+		require.NoError(t, top.Put([]byte("x"), []byte("y")))
+
+		return kvdb.ErrBucketNotFound
+	}, func() {})
+	require.ErrorIs(t, err, kvdb.ErrBucketNotFound)
+	require.Nil(t, recorded)
+}
+
+// TestOpsEncoding asserts that ops survive the encoding.
+func TestOpsEncoding(t *testing.T) {
+	ops := []op{
+		{typ: opCreateBucket, path: [][]byte{{1}, {2, 3}}},
+		{typ: opDeleteBucket, path: [][]byte{{1}}},
+		{
+			typ:   opPut,
+			path:  [][]byte{{1}},
+			key:   []byte{4},
+			value: []byte{},
+		},
+		{typ: opDelete, path: [][]byte{{1}}, key: []byte{5}},
+		{typ: opSetSequence, path: [][]byte{{1}}, seq: 99},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, encodeOps(&b, ops))
+
+	decoded, err := decodeOps(&b)
+	require.NoError(t, err)
+	require.Equal(t, ops, decoded)
+}
+
+// testConns keeps track of the connections of a standby, so a test can cut
+// them.
+type testConns struct {
+	sync.Mutex
+	conns []net.Conn
+}
+
+func (c *testConns) cut() {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, conn := range c.conns {
+		_ = conn.Close()
+	}
+}
+
+// testPrimary is a primary replicating a single database.
+type testPrimary struct {
+	*Primary
+
+	raw  kvdb.Backend
+	db   kvdb.Backend
+	addr string
+}
+
+func newTestPrimary(t *testing.T, raw kvdb.Backend) *testPrimary {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	p := NewPrimary(&PrimaryConfig{
+		Listener:   listener,
+		Secret:     testSecret,
+		AckTimeout: testAckTimeout,
+	})
+	db, err := p.Wrap(testDBName, raw)
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+
+	return &testPrimary{
+		Primary: p,
+		raw:     raw,
+		db:      db,
+		addr:    listener.Addr().String(),
+	}
+}
+
+// runTestStandby runs a standby of the primary at the given address until
+// the returned quit function is called. The result of the standby is sent on
+// the returned channel.
+func runTestStandby(t *testing.T, addr string, db kvdb.Backend,
+	promoteAfter time.Duration) (*Standby, *testConns, func(),
+	<-chan bool) {
+
+	conns := &testConns{}
+	s, err := NewStandby(&StandbyConfig{
+		Dial: func() (net.Conn, error) {
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				return nil, err
+			}
+
+			conns.Lock()
+			conns.conns = append(conns.conns, conn)
+			conns.Unlock()
+
+			return conn, nil
+		},
+		Secret:       testSecret,
+		DBs:          map[string]kvdb.Backend{testDBName: db},
+		PromoteAfter: promoteAfter,
+	})
+	require.NoError(t, err)
+
+	quit := make(chan struct{})
+	promoted := make(chan bool, 1)
+	go func() {
+		p, err := s.Run(quit)
+		require.NoError(t, err)
+		promoted <- p
+	}()
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(quit)
+		})
+	}
+	t.Cleanup(stop)
+
+	return s, conns, stop, promoted
+}
+
+// put writes a key to the test bucket.
+func put(db kvdb.Backend, key, value string) error {
+	return kvdb.Update(db, func(tx kvdb.RwTx) error {
+		bucket, err := tx.CreateTopLevelBucket([]byte("test"))
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put([]byte(key), []byte(value))
+	}, func() {})
+}
+
+// waitSynced waits until the primary has a synced standby.
+func waitSynced(t *testing.T, p *testPrimary) {
+	err := make(chan error, 1)
+	go func() {
+		err <- p.WaitForStandby(nil)
+	}()
+
+	select {
+	case err := <-err:
+		require.NoError(t, err)
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("standby not synced")
+	}
+}
+
+// TestReplication asserts that a standby receives a snapshot of the primary's
+// database, that it receives every transaction before the primary commits
+// it once it is in sync, and that it continues incrementally after it was
+// detached gracefully.
+func TestReplication(t *testing.T) {
+	primary := newTestPrimary(t, newTestDB(t, t.TempDir()))
+	defer primary.Stop()
+
+	// Data written before the standby connects is sent as a snapshot.
+	require.NoError(t, put(primary.db, "before", "1"))
+
+	standbyDB := newTestDB(t, t.TempDir())
+	_, _, stop, promoted := runTestStandby(t, primary.addr, standbyDB, 0)
+	waitSynced(t, primary)
+
+	// Once synced, a commit only returns after the standby applied it and
+	// everything sent before.
+	require.NoError(t, put(primary.db, "synced", "2"))
+	require.Equal(t, dump(t, primary.raw), dump(t, standbyDB))
+
+	// Shutting down the standby detaches it gracefully, so the primary
+	// continues without it.
+	stop()
+	require.False(t, <-promoted)
+	require.NoError(t, put(primary.db, "detached", "3"))
+
+	select {
+	case <-primary.Fenced():
+		t.Fatalf("primary fenced after graceful detach")
+	default:
+	}
+
+	// The standby knows it is no longer in sync, so it can't be taken
+	// over by a regular node.
+	require.ErrorIs(t, CheckPromotion(standbyDB, false), ErrStaleStandby)
+
+	// Reconnecting brings it back in sync.
+	_, _, stop, _ = runTestStandby(t, primary.addr, standbyDB, 0)
+	defer stop()
+	waitSynced(t, primary)
+	require.NoError(t, put(primary.db, "reconnected", "4"))
+	require.Equal(t, dump(t, primary.raw), dump(t, standbyDB))
+}
+
+// TestFailover asserts that a primary that loses its synced standby fences
+// itself, and that the standby promotes itself afterwards.
+func TestFailover(t *testing.T) {
+	primary := newTestPrimary(t, newTestDB(t, t.TempDir()))
+	defer primary.Stop()
+
+	standbyDB := newTestDB(t, t.TempDir())
+	_, conns, _, promoted := runTestStandby(
+		t, primary.addr, standbyDB, 3*testAckTimeout,
+	)
+	waitSynced(t, primary)
+	require.NoError(t, put(primary.db, "synced", "1"))
+
+	// Stop accepting connections, so the standby can't reconnect, and
+	// cut the connection as a network failure would.
+	require.NoError(t, primary.cfg.Listener.Close())
+	conns.cut()
+
+	// The primary refuses to commit anything from now on.
+	select {
+	case <-primary.Fenced():
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("primary not fenced")
+	}
+	require.ErrorIs(t, put(primary.db, "fenced", "2"), ErrFenced)
+
+	// The standby promotes itself with everything the primary committed.
+	select {
+	case p := <-promoted:
+		require.True(t, p)
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("standby not promoted")
+	}
+	require.Equal(t, dump(t, primary.raw), dump(t, standbyDB))
+
+	// The promoted database can be used by a regular node, and would be
+	// sent as a snapshot to the former primary.
+	require.NoError(t, CheckPromotion(standbyDB, false))
+	m, err := readMeta(standbyDB)
+	require.NoError(t, err)
+	require.Equal(t, rolePrimary, m.role)
+
+	// The former primary must wait for a standby before it starts again,
+	// and its database can't be turned into a standby without the
+	// operator moving it away.
+	restarted := NewPrimary(&PrimaryConfig{})
+	_, err = restarted.Wrap(testDBName, primary.raw)
+	require.NoError(t, err)
+	require.True(t, restarted.NeedsStandby())
+
+	_, err = NewStandby(&StandbyConfig{
+		DBs: map[string]kvdb.Backend{testDBName: primary.raw},
+	})
+	require.ErrorIs(t, err, ErrPrimaryDB)
+}
@@ -0,0 +1,533 @@
+package replication
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/lightningnetwork/lnd/kvdb"
+)
+
+var (
+	// reconnectInterval is the time the standby waits before it tries to
+	// reconnect to its primary.
+	reconnectInterval = 5 * time.Second
+
+	// errShuttingDown is returned if the standby is shut down while
+	// connected to its primary.
+	errShuttingDown = errors.New("standby shutting down")
+
+	// errPrimaryGoodbye is returned if the primary detached the standby
+	// gracefully.
+	errPrimaryGoodbye = errors.New("primary shutting down")
+
+	// errNoSession is returned if no connection to the primary could be
+	// established.
+	errNoSession = errors.New("unable to connect to primary")
+)
+
+// StandbyConfig holds the parameters of the standby side of the replication.
+type StandbyConfig struct {
+	// Dial connects to the primary. It is expected to encrypt the
+	// connection and to authenticate the primary.
+	Dial func() (net.Conn, error)
+
+	// Secret is the shared secret the standby authenticates with.
+	Secret []byte
+
+	// DBs are the databases to replicate, by name. They must be the same
+	// as on the primary.
+	DBs map[string]kvdb.Backend
+
+	// PromoteAfter is the time after which a synced standby promotes
+	// itself once it lost the connection to its primary. Zero disables
+	// automatic promotion. It must exceed twice the ack timeout of the
+	// primary, so the primary fenced itself before.
+	PromoteAfter time.Duration
+}
+
+// standbyDB is a database replicated by the standby.
+type standbyDB struct {
+	name string
+	db   kvdb.Backend
+
+	id           dbID
+	seq          uint64
+	snapshotting bool
+}
+
+// Standby is the standby side of the replication. It applies the changes the
+// primary sends to its own copy of the databases, until it is promoted.
+type Standby struct {
+	cfg *StandbyConfig
+
+	dbs map[string]*standbyDB
+
+	// synced is true while the standby is in sync with its primary, or
+	// lost the connection to its primary while being in sync.
+	synced bool
+
+	// ackTimeout is the ack timeout of the primary.
+	ackTimeout time.Duration
+}
+
+// NewStandby creates the standby side of the replication and marks the
+// databases as belonging to a standby. It refuses to replicate into the
+// databases of a primary, as that would overwrite them.
+func NewStandby(cfg *StandbyConfig) (*Standby, error) {
+	s := &Standby{
+		cfg: cfg,
+		dbs: make(map[string]*standbyDB, len(cfg.DBs)),
+	}
+
+	synced := len(cfg.DBs) > 0
+	for name, db := range cfg.DBs {
+		m, err := readMeta(db)
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(m.role, rolePrimary) {
+			return nil, fmt.Errorf("%w: %v", ErrPrimaryDB, name)
+		}
+
+		if !bytes.Equal(m.role, roleStandby) {
+			m = &meta{role: roleStandby}
+			err := kvdb.Update(db, func(tx kvdb.RwTx) error {
+				return putMeta(tx, m)
+			}, func() {})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		synced = synced && m.synced
+		if m.ackTimeout > s.ackTimeout {
+			s.ackTimeout = m.ackTimeout
+		}
+
+		s.dbs[name] = &standbyDB{
+			name: name,
+			db:   db,
+			id:   m.id,
+			seq:  m.seq,
+		}
+	}
+	s.synced = synced
+
+	return s, nil
+}
+
+// Run replicates the databases until the quit channel is closed or the
+// standby promotes itself, in which case true is returned. The databases can
+// be used by a regular node after a promotion.
+func (s *Standby) Run(quit <-chan struct{}) (bool, error) {
+	lostPrimary := time.Now()
+	for {
+		err := s.runSession(quit)
+		switch {
+		case err == errShuttingDown:
+			return false, nil
+
+		case err != nil:
+			log.Warnf("Replication from primary interrupted: %v",
+				err)
+		}
+
+		// We only count the time since the last successful session.
+		if err != errNoSession {
+			lostPrimary = time.Now()
+		}
+
+		if s.promotable(time.Since(lostPrimary)) {
+			return true, s.promote()
+		}
+
+		select {
+		case <-time.After(reconnectInterval):
+
+		case <-quit:
+			return false, nil
+		}
+	}
+}
+
+// promotable returns true if the standby may promote itself after it lost its
+// primary for the given duration.
+func (s *Standby) promotable(lost time.Duration) bool {
+	if !s.synced || s.cfg.PromoteAfter == 0 ||
+		lost < s.cfg.PromoteAfter {
+
+		return false
+	}
+
+	// If the primary waits for our acknowledgements longer than we wait
+	// for it, it may still be committing transactions.
+	if s.cfg.PromoteAfter <= 2*s.ackTimeout {
+		log.Errorf("Not promoting standby: promotion delay %v must "+
+			"exceed twice the ack timeout %v of the primary",
+			s.cfg.PromoteAfter, s.ackTimeout)
+
+		return false
+	}
+
+	return true
+}
+
+// promote takes over the databases, so a regular node can use them.
+func (s *Standby) promote() error {
+	log.Infof("Promoting standby after losing primary")
+
+	for name, db := range s.dbs {
+		if err := CheckPromotion(db.db, false); err != nil {
+			return fmt.Errorf("unable to promote %v: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// setSynced stores whether the standby is in sync with its primary, along
+// with the ack timeout of the primary.
+func (s *Standby) setSynced(synced bool) error {
+	for _, db := range s.dbs {
+		err := updateMeta(db.db, func(m *meta) {
+			m.synced = synced
+			m.ackTimeout = s.ackTimeout
+		})
+		if err != nil {
+			return err
+		}
+	}
+	s.synced = synced
+
+	return nil
+}
+
+// runSession connects to the primary and applies its changes until the
+// connection is lost or the quit channel is closed.
+func (s *Standby) runSession(quit <-chan struct{}) error {
+	conn, err := s.cfg.Dial()
+	if err != nil {
+		log.Debugf("Unable to connect to primary: %v", err)
+		return errNoSession
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-quit:
+			// Unblock the read and say goodbye. The primary
+			// detaches us gracefully then.
+			_ = conn.SetReadDeadline(time.Now())
+
+		case <-done:
+		}
+	}()
+	defer conn.Close()
+
+	hello := &helloMsg{secret: s.cfg.Secret}
+	for _, db := range s.dbs {
+		hello.dbs = append(hello.dbs, dbState{
+			name: db.name,
+			id:   db.id,
+			seq:  db.seq,
+		})
+	}
+	payload, err := hello.encode()
+	if err != nil {
+		return err
+	}
+	if err := s.write(conn, msgHello, payload); err != nil {
+		return errNoSession
+	}
+
+	err = conn.SetReadDeadline(time.Now().Add(reconnectInterval))
+	if err != nil {
+		return err
+	}
+	typ, payload, err := readMsg(conn)
+	if err != nil {
+		return errNoSession
+	}
+	if typ != msgWelcome {
+		return fmt.Errorf("unexpected message %d", typ)
+	}
+	s.ackTimeout, err = decodeDuration(payload)
+	if err != nil {
+		return err
+	}
+
+	// We're connected to a primary that doesn't wait for us until we're
+	// in sync again.
+	if err := s.setSynced(false); err != nil {
+		return err
+	}
+
+	log.Infof("Connected to replication primary %v", conn.RemoteAddr())
+
+	err = s.receive(conn, quit)
+	select {
+	case <-quit:
+		// The primary only considers us detached gracefully once we
+		// stored that we're no longer in sync.
+		if err := s.setSynced(false); err != nil {
+			return err
+		}
+		if s.write(conn, msgGoodbye, nil) == nil {
+			s.drain(conn)
+		}
+
+		return errShuttingDown
+	default:
+	}
+
+	if err == errPrimaryGoodbye {
+		return s.setSynced(false)
+	}
+
+	return err
+}
+
+// write writes a message to the primary.
+func (s *Standby) write(conn net.Conn, typ msgType, payload []byte) error {
+	timeout := s.ackTimeout
+	if timeout == 0 {
+		timeout = reconnectInterval
+	}
+
+	err := conn.SetWriteDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return err
+	}
+
+	return writeMsg(conn, typ, payload)
+}
+
+// drain discards the messages of the primary until it closes the connection
+// in response to our goodbye. Closing the connection ourselves could make the
+// primary fail to write before it read the goodbye, and fence itself.
+func (s *Standby) drain(conn net.Conn) {
+	timeout := s.ackTimeout
+	if timeout == 0 {
+		timeout = reconnectInterval
+	}
+
+	err := conn.SetReadDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return
+	}
+
+	for {
+		if _, _, err := readMsg(conn); err != nil {
+			return
+		}
+	}
+}
+
+// receive applies the messages of the primary until the connection fails or
+// the quit channel is closed.
+func (s *Standby) receive(conn net.Conn, quit <-chan struct{}) error {
+	for {
+		// Setting the read deadline below may override the one that
+		// unblocks us on shutdown.
+		select {
+		case <-quit:
+			return errShuttingDown
+		default:
+		}
+
+		err := conn.SetReadDeadline(time.Now().Add(s.ackTimeout))
+		if err != nil {
+			return err
+		}
+
+		typ, payload, err := readMsg(conn)
+		if err != nil {
+			return err
+		}
+
+		switch typ {
+		case msgSnapshotBegin:
+			err = s.beginSnapshot(payload)
+
+		case msgOps:
+			err = s.applyOps(conn, payload)
+
+		case msgSnapshotEnd:
+			err = s.endSnapshot(payload)
+
+		case msgSynced:
+			log.Infof("Standby is in sync with primary")
+			err = s.setSynced(true)
+
+		case msgPing:
+			err = s.write(conn, msgPong, nil)
+
+		case msgGoodbye:
+			return errPrimaryGoodbye
+
+		default:
+			err = fmt.Errorf("unexpected message %d", typ)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// lookupDB returns the database with the given name.
+func (s *Standby) lookupDB(name string) (*standbyDB, error) {
+	db, ok := s.dbs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown database %v", name)
+	}
+
+	return db, nil
+}
+
+// beginSnapshot clears a database before its snapshot is applied.
+func (s *Standby) beginSnapshot(payload []byte) error {
+	var msg seqMsg
+	if err := msg.decode(payload); err != nil {
+		return err
+	}
+
+	db, err := s.lookupDB(msg.name)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Receiving snapshot of %v at %d", msg.name, msg.seq)
+
+	err = kvdb.Update(db.db, func(tx kvdb.RwTx) error {
+		var names [][]byte
+		err := tx.ForEachBucket(func(name []byte) error {
+			if !bytes.Equal(name, metaBucket) {
+				names = append(names, copyBytes(name))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			if err := tx.DeleteTopLevelBucket(name); err != nil {
+				return err
+			}
+		}
+
+		// The zero id marks the snapshot as incomplete until it
+		// ends.
+		m, err := fetchMeta(tx)
+		if err != nil {
+			return err
+		}
+		m.id = dbID{}
+		m.seq = 0
+		m.synced = false
+
+		return putMeta(tx, m)
+	}, func() {})
+	if err != nil {
+		return err
+	}
+
+	db.id = dbID{}
+	db.seq = 0
+	db.snapshotting = true
+
+	return nil
+}
+
+// endSnapshot marks the snapshot of a database as complete.
+func (s *Standby) endSnapshot(payload []byte) error {
+	var msg seqMsg
+	if err := msg.decode(payload); err != nil {
+		return err
+	}
+
+	db, err := s.lookupDB(msg.name)
+	if err != nil {
+		return err
+	}
+	if !db.snapshotting {
+		return fmt.Errorf("no snapshot of %v in progress", msg.name)
+	}
+
+	err = updateMeta(db.db, func(m *meta) {
+		m.id = msg.id
+		m.seq = msg.seq
+	})
+	if err != nil {
+		return err
+	}
+
+	db.id = msg.id
+	db.seq = msg.seq
+	db.snapshotting = false
+
+	log.Infof("Snapshot of %v complete", msg.name)
+
+	return nil
+}
+
+// applyOps applies the changes of a transaction or a chunk of a snapshot.
+// Transactions are acknowledged once they are committed.
+func (s *Standby) applyOps(conn net.Conn, payload []byte) error {
+	var msg opsMsg
+	if err := msg.decode(payload); err != nil {
+		return err
+	}
+
+	db, err := s.lookupDB(msg.name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case msg.seq == 0 && !db.snapshotting:
+		return fmt.Errorf("snapshot chunk of %v without snapshot",
+			msg.name)
+
+	case msg.seq != 0 && db.snapshotting:
+		return fmt.Errorf("transaction of %v during snapshot",
+			msg.name)
+
+	case msg.seq != 0 && msg.seq != db.seq+1:
+		return fmt.Errorf("transaction %d of %v out of order, "+
+			"expected %d", msg.seq, msg.name, db.seq+1)
+	}
+
+	err = kvdb.Update(db.db, func(tx kvdb.RwTx) error {
+		if err := applyOps(tx, msg.ops); err != nil {
+			return err
+		}
+
+		if msg.seq == 0 {
+			return nil
+		}
+
+		return putSeq(tx, msg.seq)
+	}, func() {})
+	if err != nil {
+		return err
+	}
+
+	if msg.seq == 0 {
+		return nil
+	}
+	db.seq = msg.seq
+
+	ack := &seqMsg{name: msg.name, seq: msg.seq}
+	payload, err = ack.encode()
+	if err != nil {
+		return err
+	}
+
+	return s.write(conn, msgAck, payload)
+}
@@ -0,0 +1,288 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// maxMsgSize is the maximum size of a message we accept from the
+	// remote side.
+	maxMsgSize = 256 * 1024 * 1024
+
+	// maxDBs is the maximum number of databases a standby may announce.
+	maxDBs = 16
+
+	// maxNameLen is the maximum length of a database name.
+	maxNameLen = 64
+)
+
+// msgType is the type of a message exchanged between primary and standby.
+type msgType uint8
+
+const (
+	// msgHello is sent by the standby after connecting. It authenticates
+	// the standby and announces the state of its databases.
+	msgHello msgType = iota
+
+	// msgWelcome is sent by the primary in response to msgHello.
+	msgWelcome
+
+	// msgSnapshotBegin is sent by the primary before it sends a database
+	// from scratch. The standby clears its copy of the database.
+	msgSnapshotBegin
+
+	// msgOps carries the changes of a committed transaction, or a chunk
+	// of a snapshot.
+	msgOps
+
+	// msgSnapshotEnd is sent by the primary once a snapshot is complete.
+	msgSnapshotEnd
+
+	// msgSynced is sent by the primary once all databases of the standby
+	// are up to date. From then on, the primary waits for the standby to
+	// acknowledge every transaction before committing it.
+	msgSynced
+
+	// msgAck is sent by the standby once it applied a transaction.
+	msgAck
+
+	// msgPing is sent by the primary periodically.
+	msgPing
+
+	// msgPong is sent by the standby in response to msgPing.
+	msgPong
+
+	// msgGoodbye is sent by either side before it disconnects on purpose.
+	// The standby is no longer in sync from then on.
+	msgGoodbye
+)
+
+// writeMsg writes a message with the given type and payload to w.
+func writeMsg(w io.Writer, typ msgType, payload []byte) error {
+	var header [5]byte
+	header[0] = byte(typ)
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(payload)
+	return err
+}
+
+// readMsg reads a message from r.
+func readMsg(r io.Reader) (msgType, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxMsgSize {
+		return 0, nil, fmt.Errorf("message of %d bytes exceeds "+
+			"maximum size", size)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	return msgType(header[0]), payload, nil
+}
+
+// dbState is the state of a database as announced by a standby. The id is
+// zero while a snapshot of the database is incomplete.
+type dbState struct {
+	name string
+	id   dbID
+	seq  uint64
+}
+
+// helloMsg is the payload of msgHello.
+type helloMsg struct {
+	secret []byte
+	dbs    []dbState
+}
+
+func (m *helloMsg) encode() ([]byte, error) {
+	var b bytes.Buffer
+	if err := wire.WriteVarBytes(&b, 0, m.secret); err != nil {
+		return nil, err
+	}
+
+	if err := wire.WriteVarInt(&b, 0, uint64(len(m.dbs))); err != nil {
+		return nil, err
+	}
+	for _, db := range m.dbs {
+		err := wire.WriteVarString(&b, 0, db.name)
+		if err != nil {
+			return nil, err
+		}
+
+		var rest [dbIDLen + 8]byte
+		copy(rest[:], db.id[:])
+		binary.BigEndian.PutUint64(rest[dbIDLen:], db.seq)
+		if _, err := b.Write(rest[:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func (m *helloMsg) decode(payload []byte) error {
+	r := bytes.NewReader(payload)
+
+	var err error
+	m.secret, err = wire.ReadVarBytes(r, 0, maxNameLen, "secret")
+	if err != nil {
+		return err
+	}
+
+	numDBs, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return err
+	}
+	if numDBs > maxDBs {
+		return fmt.Errorf("too many databases: %d", numDBs)
+	}
+
+	m.dbs = make([]dbState, numDBs)
+	for i := range m.dbs {
+		db := &m.dbs[i]
+		db.name, err = readName(r)
+		if err != nil {
+			return err
+		}
+
+		var rest [dbIDLen + 8]byte
+		if _, err := io.ReadFull(r, rest[:]); err != nil {
+			return err
+		}
+		copy(db.id[:], rest[:])
+		db.seq = binary.BigEndian.Uint64(rest[dbIDLen:])
+	}
+
+	return nil
+}
+
+// readName reads a database name.
+func readName(r io.Reader) (string, error) {
+	name, err := wire.ReadVarBytes(r, 0, maxNameLen, "name")
+	return string(name), err
+}
+
+// encodeDuration encodes the payload of msgWelcome, which is the ack timeout
+// of the primary.
+func encodeDuration(d time.Duration) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(d))
+
+	return b[:]
+}
+
+// decodeDuration decodes the payload of msgWelcome.
+func decodeDuration(payload []byte) (time.Duration, error) {
+	if len(payload) != 8 {
+		return 0, fmt.Errorf("invalid duration %x", payload)
+	}
+
+	return time.Duration(binary.BigEndian.Uint64(payload)), nil
+}
+
+// seqMsg is the payload of msgSnapshotBegin, msgSnapshotEnd and msgAck. For
+// msgSnapshotBegin, it also carries the id of the database.
+type seqMsg struct {
+	name string
+	id   dbID
+	seq  uint64
+}
+
+func (m *seqMsg) encode() ([]byte, error) {
+	var b bytes.Buffer
+	if err := wire.WriteVarString(&b, 0, m.name); err != nil {
+		return nil, err
+	}
+
+	var rest [dbIDLen + 8]byte
+	copy(rest[:], m.id[:])
+	binary.BigEndian.PutUint64(rest[dbIDLen:], m.seq)
+	if _, err := b.Write(rest[:]); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func (m *seqMsg) decode(payload []byte) error {
+	r := bytes.NewReader(payload)
+
+	var err error
+	m.name, err = readName(r)
+	if err != nil {
+		return err
+	}
+
+	var rest [dbIDLen + 8]byte
+	if _, err := io.ReadFull(r, rest[:]); err != nil {
+		return err
+	}
+	copy(m.id[:], rest[:])
+	m.seq = binary.BigEndian.Uint64(rest[dbIDLen:])
+
+	return nil
+}
+
+// opsMsg is the payload of msgOps. A sequence number of zero marks a chunk
+// of a snapshot.
+type opsMsg struct {
+	name string
+	seq  uint64
+	ops  []op
+}
+
+func (m *opsMsg) encode() ([]byte, error) {
+	var b bytes.Buffer
+	if err := wire.WriteVarString(&b, 0, m.name); err != nil {
+		return nil, err
+	}
+
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], m.seq)
+	if _, err := b.Write(seq[:]); err != nil {
+		return nil, err
+	}
+
+	if err := encodeOps(&b, m.ops); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func (m *opsMsg) decode(payload []byte) error {
+	r := bytes.NewReader(payload)
+
+	var err error
+	m.name, err = readName(r)
+	if err != nil {
+		return err
+	}
+
+	var seq [8]byte
+	if _, err := io.ReadFull(r, seq[:]); err != nil {
+		return err
+	}
+	m.seq = binary.BigEndian.Uint64(seq[:])
+
+	m.ops, err = decodeOps(r)
+	return err
+}
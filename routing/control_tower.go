@@ -54,6 +54,10 @@ type ControlTower interface {
 	// payment.
 	Fail(lntypes.Hash, channeldb.FailureReason) error
 
+	// AddPaymentMetrics adds the given metrics to the metrics recorded for
+	// the payment.
+	AddPaymentMetrics(lntypes.Hash, *channeldb.PaymentMetrics) error
+
 	// FetchInFlightPayments returns all payments with status InFlight.
 	FetchInFlightPayments() ([]*channeldb.MPPayment, error)
 
@@ -250,6 +254,18 @@ func (p *controlTower) Fail(paymentHash lntypes.Hash,
 	return nil
 }
 
+// AddPaymentMetrics adds the given metrics to the metrics recorded for the
+// payment. Subscribers aren't notified, as the metrics don't change the state
+// of the payment.
+func (p *controlTower) AddPaymentMetrics(paymentHash lntypes.Hash,
+	metrics *channeldb.PaymentMetrics) error {
+
+	p.paymentsMtx.Lock(paymentHash)
+	defer p.paymentsMtx.Unlock(paymentHash)
+
+	return p.db.AddPaymentMetrics(paymentHash, metrics)
+}
+
 // FetchInFlightPayments returns all payments with status InFlight.
 func (p *controlTower) FetchInFlightPayments() ([]*channeldb.MPPayment, error) {
 	return p.db.FetchInFlightPayments()
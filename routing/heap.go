@@ -21,9 +21,15 @@ type nodeWithDist struct {
 
 	// amountToReceive is the amount that should be received by this node.
 	// Either as final payment to the final node or as an intermediate
-	// amount that includes also the fees for subsequent hops.
+	// amount that includes also the fees for subsequent hops. It doesn't
+	// include the inbound fee of this node, as that depends on the channel
+	// the amount is received over.
 	amountToReceive lnwire.MilliSatoshi
 
+	// outboundFee is the fee this node charges for forwarding over its
+	// outgoing channel. Its inbound fee can't exceed this as a discount.
+	outboundFee lnwire.MilliSatoshi
+
 	// incomingCltv is the expected absolute expiry height for the incoming
 	// htlc of this node. This value should already include the final cltv
 	// delta.
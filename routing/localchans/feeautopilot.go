@@ -0,0 +1,731 @@
+package localchans
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/ticker"
+	"github.com/lightningnetwork/lnd/tlv"
+)
+
+const (
+	// DefaultFeeAutopilotInterval is the default interval at which the fee
+	// autopilot applies its rules.
+	DefaultFeeAutopilotInterval = time.Hour
+
+	// DefaultFeeAutopilotLookback is the default time window of forwarding
+	// history the fee autopilot takes into account.
+	DefaultFeeAutopilotLookback = 24 * time.Hour
+
+	// forwardingQueryBatch is the maximum number of forwarding events we
+	// fetch from the forwarding log at once.
+	forwardingQueryBatch = 10000
+)
+
+var (
+	// feeAutopilotBucket is the top-level bucket the fee autopilot
+	// persists its rules in.
+	feeAutopilotBucket = []byte("fee-autopilot")
+
+	// feeRulesKey is the key within the fee autopilot bucket that the
+	// serialized rules are stored under.
+	feeRulesKey = []byte("rules")
+
+	// ErrInvalidFeeRule is returned when a fee rule has inconsistent
+	// parameters.
+	ErrInvalidFeeRule = errors.New("invalid fee rule")
+)
+
+const (
+	// TLV types of the fields of a serialized fee rule.
+	ruleNameType          tlv.Type = 0
+	ruleMinLocalType      tlv.Type = 1
+	ruleMaxLocalType      tlv.Type = 2
+	ruleMinForwardsType   tlv.Type = 3
+	ruleForwardsBelowType tlv.Type = 4
+	ruleBaseFeeType       tlv.Type = 5
+	ruleFeeRateType       tlv.Type = 6
+	ruleInboundFeeType    tlv.Type = 7
+)
+
+// FeeRule describes the forwarding fee that should be set on all channels
+// matching the conditions of the rule. A channel matches a rule if both its
+// share of local balance and the number of outgoing forwards within the
+// lookback window of the fee autopilot fall into the ranges of the rule.
+type FeeRule struct {
+	// Name is an optional human readable name of the rule.
+	Name string
+
+	// MinLocalPercent is the inclusive lower bound of the local balance of
+	// the channel, expressed as a percentage of its capacity.
+	MinLocalPercent uint32
+
+	// MaxLocalPercent is the inclusive upper bound of the local balance of
+	// the channel, expressed as a percentage of its capacity.
+	MaxLocalPercent uint32
+
+	// MinForwards is the minimum number of outgoing forwards the channel
+	// must have seen within the lookback window.
+	MinForwards uint32
+
+	// ForwardsBelow, if non-zero, requires the number of outgoing forwards
+	// within the lookback window to be strictly below this value. A value
+	// of 1 for example only matches channels that haven't forwarded at
+	// all.
+	ForwardsBelow uint32
+
+	// BaseFee is the base fee that is set on matching channels.
+	BaseFee lnwire.MilliSatoshi
+
+	// FeeRate is the proportional fee in parts per million that is set on
+	// matching channels.
+	FeeRate uint32
+
+	// InboundFee is the inbound fee that is set on matching channels. A
+	// negative fee is a discount, which for example encourages peers to
+	// forward through depleted channels. A zero fee removes any inbound
+	// fee of the channel.
+	InboundFee lnwire.Fee
+}
+
+// Validate checks that the parameters of the rule are consistent.
+func (r *FeeRule) Validate() error {
+	switch {
+	case r.MaxLocalPercent > 100:
+		return fmt.Errorf("%w %q: max local percent %d exceeds 100",
+			ErrInvalidFeeRule, r.Name, r.MaxLocalPercent)
+
+	case r.MinLocalPercent > r.MaxLocalPercent:
+		return fmt.Errorf("%w %q: min local percent %d exceeds max "+
+			"local percent %d", ErrInvalidFeeRule, r.Name,
+			r.MinLocalPercent, r.MaxLocalPercent)
+
+	case r.ForwardsBelow != 0 && r.ForwardsBelow <= r.MinForwards:
+		return fmt.Errorf("%w %q: forwards below %d must exceed min "+
+			"forwards %d", ErrInvalidFeeRule, r.Name,
+			r.ForwardsBelow, r.MinForwards)
+	}
+
+	return nil
+}
+
+// Matches returns true if a channel with the given local balance percentage
+// and number of outgoing forwards matches the rule.
+func (r *FeeRule) Matches(localPercent, numForwards uint32) bool {
+	if localPercent < r.MinLocalPercent ||
+		localPercent > r.MaxLocalPercent {
+
+		return false
+	}
+
+	if numForwards < r.MinForwards {
+		return false
+	}
+
+	return r.ForwardsBelow == 0 || numForwards < r.ForwardsBelow
+}
+
+// FeeAdjustment describes a fee update of a single channel that results from
+// applying the fee rules.
+type FeeAdjustment struct {
+	// ChanPoint is the funding outpoint of the channel.
+	ChanPoint wire.OutPoint
+
+	// Rule is the index of the first rule the channel matched.
+	Rule int
+
+	// LocalPercent is the local balance of the channel as a percentage of
+	// its capacity.
+	LocalPercent uint32
+
+	// NumForwards is the number of outgoing forwards of the channel within
+	// the lookback window.
+	NumForwards uint32
+
+	// OldBaseFee and OldFeeRate make up the current fee of the channel.
+	OldBaseFee lnwire.MilliSatoshi
+	OldFeeRate uint32
+
+	// NewBaseFee and NewFeeRate make up the fee set by the rule.
+	NewBaseFee lnwire.MilliSatoshi
+	NewFeeRate uint32
+
+	// OldInboundFee is the current inbound fee of the channel.
+	OldInboundFee lnwire.Fee
+
+	// NewInboundFee is the inbound fee set by the rule.
+	NewInboundFee lnwire.Fee
+}
+
+// FeeAutopilotConfig houses the dependencies of the fee autopilot.
+type FeeAutopilotConfig struct {
+	// DB is the database the fee rules are persisted in.
+	DB kvdb.Backend
+
+	// FetchAllOpenChannels returns all of our open channels, which are the
+	// source of the local balances.
+	FetchAllOpenChannels func() ([]*channeldb.OpenChannel, error)
+
+	// QueryForwardingLog queries the forwarding history of the node.
+	QueryForwardingLog func(q channeldb.ForwardingEventQuery) (
+		channeldb.ForwardingLogTimeSlice, error)
+
+	// ForAllOutgoingChannels iterates over the current policies of all our
+	// local channels.
+	ForAllOutgoingChannels func(cb func(kvdb.RTx,
+		*channeldb.ChannelEdgeInfo,
+		*channeldb.ChannelEdgePolicy) error) error
+
+	// UpdatePolicy applies a new policy to the given channels.
+	UpdatePolicy func(newSchema routing.ChannelPolicy,
+		chanPoints ...wire.OutPoint) ([]*lnrpc.FailedUpdate, error)
+
+	// Ticker fires each time the rules should be applied.
+	Ticker ticker.Ticker
+
+	// Lookback is the time window of forwarding history that is taken
+	// into account when matching rules.
+	Lookback time.Duration
+
+	// Clock is used to determine the lookback window.
+	Clock clock.Clock
+}
+
+// FeeAutopilot periodically sets the forwarding fees of our channels according
+// to a list of user defined rules. For every channel the first matching rule
+// determines the new fee. Channels that don't match any rule keep their
+// current fee.
+type FeeAutopilot struct {
+	started sync.Once
+	stopped sync.Once
+
+	cfg *FeeAutopilotConfig
+
+	// rulesMtx guards rules.
+	rulesMtx sync.RWMutex
+	rules    []FeeRule
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewFeeAutopilot creates a new fee autopilot, loading any rules previously
+// persisted in the database.
+func NewFeeAutopilot(cfg *FeeAutopilotConfig) (*FeeAutopilot, error) {
+	var rules []FeeRule
+	err := kvdb.Update(cfg.DB, func(tx kvdb.RwTx) error {
+		bucket, err := tx.CreateTopLevelBucket(feeAutopilotBucket)
+		if err != nil {
+			return err
+		}
+
+		rulesBytes := bucket.Get(feeRulesKey)
+		if rulesBytes == nil {
+			return nil
+		}
+
+		rules, err = deserializeFeeRules(bytes.NewReader(rulesBytes))
+		return err
+	}, func() {
+		rules = nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to load fee rules: %v", err)
+	}
+
+	return &FeeAutopilot{
+		cfg:   cfg,
+		rules: rules,
+		quit:  make(chan struct{}),
+	}, nil
+}
+
+// Start launches the goroutine that periodically applies the fee rules.
+func (f *FeeAutopilot) Start() error {
+	f.started.Do(func() {
+		log.Infof("Fee autopilot starting with %d rule(s)",
+			len(f.Rules()))
+
+		f.cfg.Ticker.Resume()
+
+		f.wg.Add(1)
+		go f.autopilotLoop()
+	})
+
+	return nil
+}
+
+// Stop signals the fee autopilot to exit and waits for it to do so.
+func (f *FeeAutopilot) Stop() error {
+	f.stopped.Do(func() {
+		log.Info("Fee autopilot shutting down")
+
+		close(f.quit)
+		f.wg.Wait()
+
+		f.cfg.Ticker.Stop()
+	})
+
+	return nil
+}
+
+// autopilotLoop applies the fee rules each time the ticker fires.
+//
+// NOTE: This MUST be run as a goroutine.
+func (f *FeeAutopilot) autopilotLoop() {
+	defer f.wg.Done()
+
+	for {
+		select {
+		case <-f.cfg.Ticker.Ticks():
+			if _, err := f.Apply(); err != nil {
+				log.Errorf("Unable to apply fee rules: %v", err)
+			}
+
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// Rules returns the currently active fee rules.
+func (f *FeeAutopilot) Rules() []FeeRule {
+	f.rulesMtx.RLock()
+	defer f.rulesMtx.RUnlock()
+
+	rules := make([]FeeRule, len(f.rules))
+	copy(rules, f.rules)
+
+	return rules
+}
+
+// SetRules validates and persists the given rules, replacing all current
+// rules. An empty list disables the fee autopilot.
+func (f *FeeAutopilot) SetRules(rules []FeeRule) error {
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	var b bytes.Buffer
+	if err := serializeFeeRules(&b, rules); err != nil {
+		return err
+	}
+
+	f.rulesMtx.Lock()
+	defer f.rulesMtx.Unlock()
+
+	err := kvdb.Update(f.cfg.DB, func(tx kvdb.RwTx) error {
+		bucket := tx.ReadWriteBucket(feeAutopilotBucket)
+		if bucket == nil {
+			return fmt.Errorf("fee autopilot bucket not found")
+		}
+
+		return bucket.Put(feeRulesKey, b.Bytes())
+	}, func() {})
+	if err != nil {
+		return err
+	}
+
+	f.rules = make([]FeeRule, len(rules))
+	copy(f.rules, rules)
+
+	log.Infof("Fee autopilot rules updated, %d rule(s) active",
+		len(rules))
+
+	return nil
+}
+
+// DryRun returns the fee adjustments that applying the given rules would
+// result in, without actually updating any channels. If no rules are passed,
+// the currently active rules are used.
+func (f *FeeAutopilot) DryRun(rules []FeeRule) ([]FeeAdjustment, error) {
+	if len(rules) == 0 {
+		rules = f.Rules()
+	}
+
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return f.adjustments(rules)
+}
+
+// Apply determines the fee adjustments resulting from the active rules and
+// updates the policies of the affected channels. The adjustments that were
+// applied successfully are returned.
+func (f *FeeAutopilot) Apply() ([]FeeAdjustment, error) {
+	rules := f.Rules()
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	adjustments, err := f.adjustments(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	// We'll fetch the current policies once more, as we need to carry
+	// over the time lock delta of each channel.
+	policies, err := f.currentPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]FeeAdjustment, 0, len(adjustments))
+	for _, adj := range adjustments {
+		policy, ok := policies[adj.ChanPoint]
+		if !ok {
+			continue
+		}
+
+		inboundFee := adj.NewInboundFee
+		newPolicy := routing.ChannelPolicy{
+			FeeSchema: routing.FeeSchema{
+				BaseFee:    adj.NewBaseFee,
+				FeeRate:    adj.NewFeeRate,
+				InboundFee: &inboundFee,
+			},
+			TimeLockDelta: uint32(policy.TimeLockDelta),
+		}
+
+		failed, err := f.cfg.UpdatePolicy(newPolicy, adj.ChanPoint)
+		if err != nil {
+			return applied, err
+		}
+		if len(failed) != 0 {
+			log.Warnf("Fee autopilot unable to update channel "+
+				"%v: %v", adj.ChanPoint, failed[0].UpdateError)
+
+			continue
+		}
+
+		log.Infof("Fee autopilot updated fee of channel %v from "+
+			"%v/%d ppm (inbound %d msat/%d ppm) to %v/%d ppm "+
+			"(inbound %d msat/%d ppm) (rule %d, local balance "+
+			"%d%%, %d forwards)", adj.ChanPoint, adj.OldBaseFee,
+			adj.OldFeeRate, adj.OldInboundFee.BaseFee,
+			adj.OldInboundFee.FeeRate, adj.NewBaseFee,
+			adj.NewFeeRate, adj.NewInboundFee.BaseFee,
+			adj.NewInboundFee.FeeRate, adj.Rule, adj.LocalPercent,
+			adj.NumForwards)
+
+		applied = append(applied, adj)
+	}
+
+	return applied, nil
+}
+
+// adjustments matches all open channels against the given rules and returns
+// the fee changes for all channels whose current fee differs from the one of
+// the first matching rule.
+func (f *FeeAutopilot) adjustments(rules []FeeRule) ([]FeeAdjustment,
+	error) {
+
+	channels, err := f.cfg.FetchAllOpenChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	forwards, err := f.countForwards()
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := f.currentPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	var adjustments []FeeAdjustment
+	for _, channel := range channels {
+		// We can only adjust the fee of channels that are announced
+		// to us through our own channel updates.
+		policy, ok := policies[channel.FundingOutpoint]
+		if !ok {
+			continue
+		}
+
+		// A malformed inbound fee is treated as if none was set, so
+		// that it's replaced by the one of the matching rule.
+		inboundFee, err := lnwire.ExtractInboundFee(
+			policy.ExtraOpaqueData,
+		)
+		if err != nil {
+			log.Warnf("Unable to extract inbound fee of channel "+
+				"%v: %v", channel.FundingOutpoint, err)
+
+			inboundFee = lnwire.Fee{}
+		}
+
+		localPercent := localBalancePercent(channel)
+		numForwards := forwards[channel.ShortChannelID.ToUint64()]
+
+		for i := range rules {
+			rule := rules[i]
+			if !rule.Matches(localPercent, numForwards) {
+				continue
+			}
+
+			oldFeeRate := uint32(policy.FeeProportionalMillionths)
+			if policy.FeeBaseMSat == rule.BaseFee &&
+				oldFeeRate == rule.FeeRate &&
+				inboundFee == rule.InboundFee {
+
+				break
+			}
+
+			adjustments = append(adjustments, FeeAdjustment{
+				ChanPoint:     channel.FundingOutpoint,
+				Rule:          i,
+				LocalPercent:  localPercent,
+				NumForwards:   numForwards,
+				OldBaseFee:    policy.FeeBaseMSat,
+				OldFeeRate:    oldFeeRate,
+				NewBaseFee:    rule.BaseFee,
+				NewFeeRate:    rule.FeeRate,
+				OldInboundFee: inboundFee,
+				NewInboundFee: rule.InboundFee,
+			})
+
+			break
+		}
+	}
+
+	return adjustments, nil
+}
+
+// currentPolicies returns our current policy of each of our channels, keyed by
+// channel point.
+func (f *FeeAutopilot) currentPolicies() (
+	map[wire.OutPoint]*channeldb.ChannelEdgePolicy, error) {
+
+	policies := make(map[wire.OutPoint]*channeldb.ChannelEdgePolicy)
+	err := f.cfg.ForAllOutgoingChannels(func(_ kvdb.RTx,
+		info *channeldb.ChannelEdgeInfo,
+		edge *channeldb.ChannelEdgePolicy) error {
+
+		if edge != nil {
+			policies[info.ChannelPoint] = edge
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// countForwards returns the number of outgoing forwards within the lookback
+// window, keyed by the short channel ID of the outgoing channel.
+func (f *FeeAutopilot) countForwards() (map[uint64]uint32, error) {
+	now := f.cfg.Clock.Now()
+	query := channeldb.ForwardingEventQuery{
+		StartTime:    now.Add(-f.cfg.Lookback),
+		EndTime:      now,
+		NumMaxEvents: forwardingQueryBatch,
+	}
+
+	counts := make(map[uint64]uint32)
+	for {
+		timeSlice, err := f.cfg.QueryForwardingLog(query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range timeSlice.ForwardingEvents {
+			counts[event.OutgoingChanID.ToUint64()]++
+		}
+
+		if len(timeSlice.ForwardingEvents) < forwardingQueryBatch {
+			return counts, nil
+		}
+
+		query.IndexOffset = timeSlice.LastIndexOffset
+	}
+}
+
+// localBalancePercent returns the local balance of the channel as a percentage
+// of its capacity, rounded down.
+func localBalancePercent(channel *channeldb.OpenChannel) uint32 {
+	if channel.Capacity <= 0 {
+		return 0
+	}
+
+	localBalance := channel.LocalCommitment.LocalBalance.ToSatoshis()
+	percent := uint64(localBalance) * 100 / uint64(channel.Capacity)
+	if percent > 100 {
+		percent = 100
+	}
+
+	return uint32(percent)
+}
+
+// serializeFeeRules writes the given rules to the writer. Each rule is encoded
+// as a TLV stream, so that fields can be added in the future.
+func serializeFeeRules(w io.Writer, rules []FeeRule) error {
+	if err := wire.WriteVarInt(w, 0, uint64(len(rules))); err != nil {
+		return err
+	}
+
+	for i := range rules {
+		var b bytes.Buffer
+		stream, err := feeRuleStream(&rules[i])
+		if err != nil {
+			return err
+		}
+		if err := stream.Encode(&b); err != nil {
+			return err
+		}
+
+		if err := wire.WriteVarBytes(w, 0, b.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deserializeFeeRules reads a list of rules written by serializeFeeRules.
+func deserializeFeeRules(r io.Reader) ([]FeeRule, error) {
+	numRules, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]FeeRule, 0, numRules)
+	for i := uint64(0); i < numRules; i++ {
+		ruleBytes, err := wire.ReadVarBytes(
+			r, 0, tlv.MaxRecordSize, "fee rule",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		var rule FeeRule
+		stream, err := feeRuleStream(&rule)
+		if err != nil {
+			return nil, err
+		}
+		err = stream.Decode(bytes.NewReader(ruleBytes))
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// feeRuleStream returns the TLV stream used to encode and decode the given
+// rule.
+func feeRuleStream(rule *FeeRule) (*tlv.Stream, error) {
+	baseFee := (*uint64)(&rule.BaseFee)
+
+	return tlv.NewStream(
+		tlv.MakeDynamicRecord(
+			ruleNameType, &rule.Name, func() uint64 {
+				return uint64(len(rule.Name))
+			}, encodeString, decodeString,
+		),
+		tlv.MakePrimitiveRecord(
+			ruleMinLocalType, &rule.MinLocalPercent,
+		),
+		tlv.MakePrimitiveRecord(
+			ruleMaxLocalType, &rule.MaxLocalPercent,
+		),
+		tlv.MakePrimitiveRecord(
+			ruleMinForwardsType, &rule.MinForwards,
+		),
+		tlv.MakePrimitiveRecord(
+			ruleForwardsBelowType, &rule.ForwardsBelow,
+		),
+		tlv.MakePrimitiveRecord(ruleBaseFeeType, baseFee),
+		tlv.MakePrimitiveRecord(ruleFeeRateType, &rule.FeeRate),
+		tlv.MakeStaticRecord(
+			ruleInboundFeeType, &rule.InboundFee, 8,
+			encodeInboundFee, decodeInboundFee,
+		),
+	)
+}
+
+// encodeInboundFee is a TLV encoder for inbound fees. The signed base fee and
+// fee rate are encoded as their two's complement.
+func encodeInboundFee(w io.Writer, val interface{}, buf *[8]byte) error {
+	if f, ok := val.(*lnwire.Fee); ok {
+		if err := tlv.EUint32T(w, uint32(f.BaseFee), buf); err != nil {
+			return err
+		}
+
+		return tlv.EUint32T(w, uint32(f.FeeRate), buf)
+	}
+
+	return tlv.NewTypeForEncodingErr(val, "lnwire.Fee")
+}
+
+// decodeInboundFee is a TLV decoder for inbound fees.
+func decodeInboundFee(r io.Reader, val interface{}, buf *[8]byte,
+	l uint64) error {
+
+	if f, ok := val.(*lnwire.Fee); ok && l == 8 {
+		var baseFee, feeRate uint32
+		if err := tlv.DUint32(r, &baseFee, buf, 4); err != nil {
+			return err
+		}
+		if err := tlv.DUint32(r, &feeRate, buf, 4); err != nil {
+			return err
+		}
+
+		f.BaseFee = int32(baseFee)
+		f.FeeRate = int32(feeRate)
+
+		return nil
+	}
+
+	return tlv.NewTypeForDecodingErr(val, "lnwire.Fee", l, 8)
+}
+
+// encodeString is a TLV encoder for strings.
+func encodeString(w io.Writer, val interface{}, buf *[8]byte) error {
+	if s, ok := val.(*string); ok {
+		b := []byte(*s)
+		return tlv.EVarBytes(w, &b, buf)
+	}
+
+	return tlv.NewTypeForEncodingErr(val, "string")
+}
+
+// decodeString is a TLV decoder for strings.
+func decodeString(r io.Reader, val interface{}, buf *[8]byte,
+	l uint64) error {
+
+	if s, ok := val.(*string); ok {
+		var b []byte
+		if err := tlv.DVarBytes(r, &b, buf, l); err != nil {
+			return err
+		}
+
+		*s = string(b)
+
+		return nil
+	}
+
+	return tlv.NewTypeForDecodingErr(val, "string", l, l)
+}
@@ -0,0 +1,300 @@
+package localchans
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/clock"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing"
+	"github.com/lightningnetwork/lnd/ticker"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFeeRuleMatches tests the matching and validation of fee rules.
+func TestFeeRuleMatches(t *testing.T) {
+	t.Parallel()
+
+	rule := FeeRule{
+		MinLocalPercent: 10,
+		MaxLocalPercent: 20,
+		MinForwards:     2,
+		ForwardsBelow:   5,
+	}
+	require.NoError(t, rule.Validate())
+
+	require.True(t, rule.Matches(10, 2))
+	require.True(t, rule.Matches(20, 4))
+	require.False(t, rule.Matches(9, 3))
+	require.False(t, rule.Matches(21, 3))
+	require.False(t, rule.Matches(15, 1))
+	require.False(t, rule.Matches(15, 5))
+
+	// Without an upper bound on the forwards, any number of forwards above
+	// the minimum matches.
+	rule.ForwardsBelow = 0
+	require.True(t, rule.Matches(15, 1000))
+
+	invalid := []FeeRule{
+		{MaxLocalPercent: 101},
+		{MinLocalPercent: 50, MaxLocalPercent: 40},
+		{MaxLocalPercent: 100, MinForwards: 3, ForwardsBelow: 3},
+	}
+	for _, r := range invalid {
+		require.ErrorIs(t, r.Validate(), ErrInvalidFeeRule)
+	}
+}
+
+// TestFeeAutopilot tests that the fee autopilot persists its rules and adjusts
+// the fees of the channels matching them.
+func TestFeeAutopilot(t *testing.T) {
+	t.Parallel()
+
+	db, err := kvdb.Create(
+		kvdb.BoltBackendName, filepath.Join(t.TempDir(), "fee.db"),
+		true, kvdb.DefaultDBTimeout,
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	now := time.Unix(1700000000, 0)
+
+	// We'll create three channels: a depleted one, a balanced busy one and
+	// a balanced idle one.
+	newChannel := func(i byte,
+		local btcutil.Amount) *channeldb.OpenChannel {
+
+		return &channeldb.OpenChannel{
+			FundingOutpoint: wire.OutPoint{
+				Hash: chainhash.Hash{i},
+			},
+			ShortChannelID: lnwire.NewShortChanIDFromInt(
+				uint64(i),
+			),
+			Capacity: 1000000,
+			LocalCommitment: channeldb.ChannelCommitment{
+				LocalBalance: lnwire.NewMSatFromSatoshis(local),
+			},
+		}
+	}
+	channels := []*channeldb.OpenChannel{
+		newChannel(1, 100000),
+		newChannel(2, 500000),
+		newChannel(3, 500000),
+	}
+
+	policies := make(map[wire.OutPoint]*channeldb.ChannelEdgePolicy)
+	for _, c := range channels {
+		policies[c.FundingOutpoint] = &channeldb.ChannelEdgePolicy{
+			FeeBaseMSat:               1000,
+			FeeProportionalMillionths: 100,
+			TimeLockDelta:             40,
+		}
+	}
+
+	// The busy channel forwarded three times within the lookback window.
+	// The query must cover exactly the lookback window.
+	queryForwardingLog := func(q channeldb.ForwardingEventQuery) (
+		channeldb.ForwardingLogTimeSlice, error) {
+
+		require.Equal(t, now.Add(-time.Hour), q.StartTime)
+		require.Equal(t, now, q.EndTime)
+
+		event := channeldb.ForwardingEvent{
+			OutgoingChanID: channels[1].ShortChannelID,
+		}
+
+		return channeldb.ForwardingLogTimeSlice{
+			ForwardingEvents: []channeldb.ForwardingEvent{
+				event, event, event,
+			},
+		}, nil
+	}
+
+	var updates []wire.OutPoint
+	cfg := &FeeAutopilotConfig{
+		DB: db,
+		FetchAllOpenChannels: func() ([]*channeldb.OpenChannel,
+			error) {
+
+			return channels, nil
+		},
+		QueryForwardingLog: queryForwardingLog,
+		ForAllOutgoingChannels: func(cb func(kvdb.RTx,
+			*channeldb.ChannelEdgeInfo,
+			*channeldb.ChannelEdgePolicy) error) error {
+
+			for _, c := range channels {
+				info := &channeldb.ChannelEdgeInfo{
+					ChannelPoint: c.FundingOutpoint,
+				}
+				policy := policies[c.FundingOutpoint]
+				if err := cb(nil, info, policy); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		UpdatePolicy: func(newSchema routing.ChannelPolicy,
+			chanPoints ...wire.OutPoint) ([]*lnrpc.FailedUpdate,
+			error) {
+
+			require.Len(t, chanPoints, 1)
+			require.EqualValues(t, 40, newSchema.TimeLockDelta)
+			require.NotNil(t, newSchema.InboundFee)
+
+			policy := policies[chanPoints[0]]
+			policy.FeeBaseMSat = newSchema.BaseFee
+			policy.FeeProportionalMillionths = lnwire.MilliSatoshi(
+				newSchema.FeeRate,
+			)
+
+			extraData, err := lnwire.SetInboundFee(
+				policy.ExtraOpaqueData, *newSchema.InboundFee,
+			)
+			require.NoError(t, err)
+			policy.ExtraOpaqueData = extraData
+
+			updates = append(updates, chanPoints[0])
+
+			return nil, nil
+		},
+		Ticker:   ticker.NewForce(time.Hour),
+		Lookback: time.Hour,
+		Clock:    clock.NewTestClock(now),
+	}
+
+	autopilot, err := NewFeeAutopilot(cfg)
+	require.NoError(t, err)
+	require.Empty(t, autopilot.Rules())
+
+	rules := []FeeRule{
+		{
+			Name:            "depleted",
+			MaxLocalPercent: 20,
+			BaseFee:         1000,
+			FeeRate:         500,
+			InboundFee: lnwire.Fee{
+				BaseFee: -1000,
+				FeeRate: -200,
+			},
+		},
+		{
+			Name:            "idle",
+			MaxLocalPercent: 100,
+			ForwardsBelow:   1,
+			BaseFee:         0,
+			FeeRate:         50,
+		},
+		{
+			Name:            "default",
+			MaxLocalPercent: 100,
+			BaseFee:         1000,
+			FeeRate:         100,
+		},
+	}
+
+	// Invalid rules are rejected.
+	err = autopilot.SetRules([]FeeRule{{MaxLocalPercent: 200}})
+	require.ErrorIs(t, err, ErrInvalidFeeRule)
+
+	require.NoError(t, autopilot.SetRules(rules))
+	require.Equal(t, rules, autopilot.Rules())
+
+	// The rules must survive a restart.
+	autopilot, err = NewFeeAutopilot(cfg)
+	require.NoError(t, err)
+	require.Equal(t, rules, autopilot.Rules())
+
+	// The busy channel already has the fee of the default rule, so only
+	// the other two channels are adjusted. The depleted channel gets an
+	// inbound discount.
+	inboundDiscount := lnwire.Fee{
+		BaseFee: -1000,
+		FeeRate: -200,
+	}
+	expected := []FeeAdjustment{
+		{
+			ChanPoint:     channels[0].FundingOutpoint,
+			Rule:          0,
+			LocalPercent:  10,
+			OldBaseFee:    1000,
+			OldFeeRate:    100,
+			NewBaseFee:    1000,
+			NewFeeRate:    500,
+			NewInboundFee: inboundDiscount,
+		},
+		{
+			ChanPoint:    channels[2].FundingOutpoint,
+			Rule:         1,
+			LocalPercent: 50,
+			OldBaseFee:   1000,
+			OldFeeRate:   100,
+			NewBaseFee:   0,
+			NewFeeRate:   50,
+		},
+	}
+
+	adjustments, err := autopilot.DryRun(nil)
+	require.NoError(t, err)
+	require.Equal(t, expected, adjustments)
+	require.Empty(t, updates)
+
+	// A dry run with explicit rules doesn't use the active ones.
+	adjustments, err = autopilot.DryRun(rules[2:])
+	require.NoError(t, err)
+	require.Empty(t, adjustments)
+
+	applied, err := autopilot.Apply()
+	require.NoError(t, err)
+	require.Equal(t, expected, applied)
+	require.Equal(t, []wire.OutPoint{
+		channels[0].FundingOutpoint, channels[2].FundingOutpoint,
+	}, updates)
+
+	inboundFee, err := lnwire.ExtractInboundFee(
+		policies[channels[0].FundingOutpoint].ExtraOpaqueData,
+	)
+	require.NoError(t, err)
+	require.Equal(t, inboundDiscount, inboundFee)
+
+	// Now that the fees are set, applying the rules again is a no-op.
+	applied, err = autopilot.Apply()
+	require.NoError(t, err)
+	require.Empty(t, applied)
+
+	// Once the depleted channel is refilled, it matches the idle rule,
+	// which removes the inbound discount again.
+	channels[0].LocalCommitment.LocalBalance = lnwire.NewMSatFromSatoshis(
+		500000,
+	)
+	applied, err = autopilot.Apply()
+	require.NoError(t, err)
+	require.Equal(t, []FeeAdjustment{{
+		ChanPoint:     channels[0].FundingOutpoint,
+		Rule:          1,
+		LocalPercent:  50,
+		OldBaseFee:    1000,
+		OldFeeRate:    500,
+		NewBaseFee:    0,
+		NewFeeRate:    50,
+		OldInboundFee: inboundDiscount,
+	}}, applied)
+	require.Empty(t, policies[channels[0].FundingOutpoint].ExtraOpaqueData)
+
+	// Clearing the rules disables the autopilot.
+	require.NoError(t, autopilot.SetRules(nil))
+	applied, err = autopilot.Apply()
+	require.NoError(t, err)
+	require.Empty(t, applied)
+}
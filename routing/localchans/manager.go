@@ -105,10 +105,15 @@ func (r *Manager) UpdatePolicy(newSchema routing.ChannelPolicy,
 			Edge: edge,
 		})
 
+		// The inbound fee was either just encoded by us or is
+		// unchanged, so it can't be malformed at this point.
+		inboundFee, _ := lnwire.ExtractInboundFee(edge.ExtraOpaqueData)
+
 		// Add updated policy to list of policies to send to switch.
 		policiesToUpdate[info.ChannelPoint] = htlcswitch.ForwardingPolicy{
 			BaseFee:       edge.FeeBaseMSat,
 			FeeRate:       edge.FeeProportionalMillionths,
+			InboundFee:    inboundFee,
 			TimeLockDelta: uint32(edge.TimeLockDelta),
 			MinHTLCOut:    edge.MinHTLC,
 			MaxHTLC:       edge.MaxHTLC,
@@ -182,6 +187,18 @@ func (r *Manager) updateEdge(tx kvdb.RTx, chanPoint wire.OutPoint,
 	)
 	edge.TimeLockDelta = uint16(newSchema.TimeLockDelta)
 
+	// Update the inbound fee if a new one was specified. It is carried in
+	// the extra data of the channel update.
+	if newSchema.InboundFee != nil {
+		extraData, err := lnwire.SetInboundFee(
+			edge.ExtraOpaqueData, *newSchema.InboundFee,
+		)
+		if err != nil {
+			return err
+		}
+		edge.ExtraOpaqueData = extraData
+	}
+
 	// Retrieve negotiated channel htlc amt limits.
 	amtMin, amtMax, err := r.getHtlcAmtLimits(tx, chanPoint)
 	if err != nil {
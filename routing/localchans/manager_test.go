@@ -39,6 +39,10 @@ func TestManager(t *testing.T) {
 		FeeSchema: routing.FeeSchema{
 			BaseFee: 100,
 			FeeRate: 200,
+			InboundFee: &lnwire.Fee{
+				BaseFee: -10,
+				FeeRate: -20,
+			},
 		},
 		TimeLockDelta: 80,
 		MaxHTLC:       5000,
@@ -73,6 +77,9 @@ func TestManager(t *testing.T) {
 		if policy.MaxHTLC != newPolicy.MaxHTLC {
 			t.Fatal("unexpected max htlc")
 		}
+		if policy.InboundFee != *newPolicy.InboundFee {
+			t.Fatal("unexpected inbound fee")
+		}
 	}
 
 	propagateChanPolicyUpdate := func(
@@ -101,6 +108,12 @@ func TestManager(t *testing.T) {
 			if policy.MaxHTLC != newPolicy.MaxHTLC {
 				t.Fatal("unexpected max htlc")
 			}
+
+			inboundFee, err := lnwire.ExtractInboundFee(
+				policy.ExtraOpaqueData,
+			)
+			require.NoError(t, err)
+			require.Equal(t, *newPolicy.InboundFee, inboundFee)
 		}
 
 		return nil
@@ -156,7 +156,8 @@ type mockPaymentSessionOld struct {
 var _ PaymentSession = (*mockPaymentSessionOld)(nil)
 
 func (m *mockPaymentSessionOld) RequestRoute(_, _ lnwire.MilliSatoshi,
-	_, height uint32) (*route.Route, error) {
+	_, height uint32, _ map[uint64]lnwire.MilliSatoshi) (*route.Route,
+	error) {
 
 	if m.release != nil {
 		m.release <- struct{}{}
@@ -251,6 +252,7 @@ type failPaymentArgs struct {
 type testPayment struct {
 	info     channeldb.PaymentCreationInfo
 	attempts []channeldb.HTLCAttempt
+	metrics  *channeldb.PaymentMetrics
 }
 
 type mockControlTowerOld struct {
@@ -489,6 +491,27 @@ func (m *mockControlTowerOld) Fail(phash lntypes.Hash,
 	return nil
 }
 
+func (m *mockControlTowerOld) AddPaymentMetrics(phash lntypes.Hash,
+	metrics *channeldb.PaymentMetrics) error {
+
+	m.Lock()
+	defer m.Unlock()
+
+	p, ok := m.payments[phash]
+	if !ok {
+		return channeldb.ErrPaymentNotInitiated
+	}
+
+	if p.metrics == nil {
+		p.metrics = &channeldb.PaymentMetrics{}
+	}
+	p.metrics.NumAttempts += metrics.NumAttempts
+	p.metrics.PathfindingTime += metrics.PathfindingTime
+	p.metrics.ResultWaitTime += metrics.ResultWaitTime
+
+	return nil
+}
+
 func (m *mockControlTowerOld) FetchPayment(phash lntypes.Hash) (
 	*channeldb.MPPayment, error) {
 
@@ -507,12 +530,15 @@ func (m *mockControlTowerOld) fetchPayment(phash lntypes.Hash) (
 	}
 
 	mp := &channeldb.MPPayment{
-		Info: &p.info,
+		Info:    &p.info,
+		Metrics: p.metrics,
+		Status:  channeldb.StatusInFlight,
 	}
 
 	reason, ok := m.failed[phash]
 	if ok {
 		mp.FailureReason = &reason
+		mp.Status = channeldb.StatusFailed
 	}
 
 	// Return a copy of the current attempts.
@@ -663,7 +689,8 @@ type mockPaymentSession struct {
 var _ PaymentSession = (*mockPaymentSession)(nil)
 
 func (m *mockPaymentSession) RequestRoute(maxAmt, feeLimit lnwire.MilliSatoshi,
-	activeShards, height uint32) (*route.Route, error) {
+	activeShards, height uint32,
+	_ map[uint64]lnwire.MilliSatoshi) (*route.Route, error) {
 
 	args := m.Called(maxAmt, feeLimit, activeShards, height)
 	return args.Get(0).(*route.Route), args.Error(1)
@@ -743,6 +770,14 @@ func (m *mockControlTower) Fail(phash lntypes.Hash,
 	return args.Error(0)
 }
 
+// AddPaymentMetrics isn't recorded by the mock, so that tests don't need to
+// expect the metrics of each payment.
+func (m *mockControlTower) AddPaymentMetrics(lntypes.Hash,
+	*channeldb.PaymentMetrics) error {
+
+	return nil
+}
+
 func (m *mockControlTower) FetchPayment(phash lntypes.Hash) (
 	*channeldb.MPPayment, error) {
 
@@ -219,7 +219,18 @@ func newRoute(sourceVertex route.Vertex,
 			// and its policy for the outgoing channel. This policy
 			// is stored as part of the incoming channel of
 			// the next hop.
-			fee = pathEdges[i+1].ComputeFee(amtToForward)
+			outboundFee := pathEdges[i+1].ComputeFee(amtToForward)
+
+			// The current hop additionally charges an inbound fee
+			// for the channel the HTLC arrives over, which may be
+			// a discount on the outbound fee.
+			inboundFee := cappedInboundFee(
+				edge.InboundFee, amtToForward+outboundFee,
+				outboundFee,
+			)
+			fee = lnwire.MilliSatoshi(
+				int64(outboundFee) + inboundFee,
+			)
 
 			// We'll take the total timelock of the preceding hop as
 			// the outgoing timelock or this hop. Then we'll
@@ -280,6 +291,57 @@ func edgeWeight(lockedAmt lnwire.MilliSatoshi, fee lnwire.MilliSatoshi,
 	return int64(fee) + timeLockPenalty
 }
 
+// cappedInboundFee returns the inbound fee a forwarding node charges for
+// receiving the given amount, capped such that the total fee of the node is
+// never negative. Forwarding nodes treat a negative total fee as zero, so a
+// discount can't exceed the outbound fee of the node.
+func cappedInboundFee(fee lnwire.Fee, amt,
+	outboundFee lnwire.MilliSatoshi) int64 {
+
+	inboundFee := fee.CalcFee(amt)
+	if inboundFee < -int64(outboundFee) {
+		inboundFee = -int64(outboundFee)
+	}
+
+	return inboundFee
+}
+
+// forwardAmtFromIncoming returns the largest amount a node forwards over the
+// outgoing channel with the given policy, when receiving the incoming amount
+// over a channel that it charges the given inbound fee for.
+func forwardAmtFromIncoming(incomingAmt lnwire.MilliSatoshi,
+	policy *channeldb.CachedEdgePolicy,
+	inboundFee lnwire.Fee) lnwire.MilliSatoshi {
+
+	// Without an inbound fee, the amount can be derived directly from the
+	// outbound fee.
+	if inboundFee.IsZero() {
+		return incomingAmt - policy.ComputeFeeFromIncoming(incomingAmt)
+	}
+
+	// Otherwise we search for the largest amount whose total fee still
+	// fits into the incoming amount. As the total fee is never negative
+	// and the incoming amount grows with the forwarded amount, a binary
+	// search suffices.
+	low, high := lnwire.MilliSatoshi(0), incomingAmt
+	for low < high {
+		amt := low + (high-low+1)/2
+
+		outboundFee := policy.ComputeFee(amt)
+		fee := int64(outboundFee) + cappedInboundFee(
+			inboundFee, amt+outboundFee, outboundFee,
+		)
+
+		if int64(amt)+fee <= int64(incomingAmt) {
+			low = amt
+		} else {
+			high = amt - 1
+		}
+	}
+
+	return low
+}
+
 // graphParams wraps the set of graph parameters passed to findPath.
 type graphParams struct {
 	// graph is the ChannelGraph to be used during path finding.
@@ -624,9 +686,22 @@ func findPath(g *graphParams, r *RestrictParams, cfg *PathFindingConfig,
 
 		edgesExpanded++
 
+		// Calculate the inbound fee that the to node charges for
+		// HTLCs arriving over this edge. The target doesn't forward
+		// the payment, so it doesn't charge an inbound fee.
+		var inboundFee int64
+		if toNodeDist.node != target {
+			inboundFee = cappedInboundFee(
+				edge.InboundFee, toNodeDist.amountToReceive,
+				toNodeDist.outboundFee,
+			)
+		}
+
 		// Calculate amount that the candidate node would have to send
-		// out.
-		amountToSend := toNodeDist.amountToReceive
+		// out, including the inbound fee of the to node.
+		amountToSend := lnwire.MilliSatoshi(
+			int64(toNodeDist.amountToReceive) + inboundFee,
+		)
 
 		// Request the success probability for this edge.
 		edgeProbability := r.ProbabilitySource(
@@ -655,10 +730,10 @@ func findPath(g *graphParams, r *RestrictParams, cfg *PathFindingConfig,
 		// Also determine the time lock delta that will be added to the
 		// route if fromVertex is selected. If fromVertex is the source
 		// node, no additional timelock is required.
-		var fee lnwire.MilliSatoshi
+		var outboundFee lnwire.MilliSatoshi
 		var timeLockDelta uint16
 		if fromVertex != source {
-			fee = edge.ComputeFee(amountToSend)
+			outboundFee = edge.ComputeFee(amountToSend)
 			timeLockDelta = edge.TimeLockDelta
 		}
 
@@ -673,8 +748,18 @@ func findPath(g *graphParams, r *RestrictParams, cfg *PathFindingConfig,
 		// the distance map needs to receive from a (to be found)
 		// previous node in the route. That previous node will need to
 		// pay the amount that this node forwards plus the fee it
-		// charges.
-		amountToReceive := amountToSend + fee
+		// charges, as well as the inbound fee this node charges on the
+		// channel it is paid over.
+		amountToReceive := amountToSend + outboundFee
+
+		// The fee that is attributed to this edge is the sum of the
+		// outbound fee of the from node and the inbound fee of the to
+		// node. Path finding doesn't support negative edge weights, so
+		// a net discount doesn't lower the weight.
+		var fee lnwire.MilliSatoshi
+		if signedFee := int64(outboundFee) + inboundFee; signedFee > 0 {
+			fee = lnwire.MilliSatoshi(signedFee)
+		}
 
 		// Check if accumulated fees would exceed fee limit when this
 		// node would be added to the path.
@@ -778,6 +863,7 @@ func findPath(g *graphParams, r *RestrictParams, cfg *PathFindingConfig,
 			weight:          tempWeight,
 			node:            fromVertex,
 			amountToReceive: amountToReceive,
+			outboundFee:     outboundFee,
 			incomingCltv:    incomingCltv,
 			probability:     probability,
 			nextHop:         edge,
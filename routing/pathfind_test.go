@@ -431,6 +431,7 @@ type testChannelPolicy struct {
 	MaxHTLC     lnwire.MilliSatoshi
 	FeeBaseMsat lnwire.MilliSatoshi
 	FeeRate     lnwire.MilliSatoshi
+	InboundFee  lnwire.Fee
 	LastUpdate  time.Time
 	Disabled    bool
 	Features    *lnwire.FeatureVector
@@ -703,6 +704,16 @@ func createTestGraphFromChannels(t *testing.T, useCache bool,
 					Features:    node2Features,
 				},
 			}
+			if !node1.InboundFee.IsZero() {
+				var extraData lnwire.ExtraOpaqueData
+				err := extraData.PackRecords(
+					&node1.InboundFee,
+				)
+				if err != nil {
+					return nil, err
+				}
+				edgePolicy.ExtraOpaqueData = extraData
+			}
 			if err := graph.UpdateEdgePolicy(edgePolicy); err != nil {
 				return nil, err
 			}
@@ -741,6 +752,16 @@ func createTestGraphFromChannels(t *testing.T, useCache bool,
 					Features:    node1Features,
 				},
 			}
+			if !node2.InboundFee.IsZero() {
+				var extraData lnwire.ExtraOpaqueData
+				err := extraData.PackRecords(
+					&node2.InboundFee,
+				)
+				if err != nil {
+					return nil, err
+				}
+				edgePolicy.ExtraOpaqueData = extraData
+			}
 			if err := graph.UpdateEdgePolicy(edgePolicy); err != nil {
 				return nil, err
 			}
@@ -830,6 +851,9 @@ func TestPathFinding(t *testing.T) {
 	}, {
 		name: "with metadata",
 		fn:   runFindPathWithMetadata,
+	}, {
+		name: "inbound fees",
+		fn:   runInboundFees,
 	}}
 
 	// Run with graph cache enabled.
@@ -965,6 +989,141 @@ func runFindLowestFeePath(t *testing.T, useCache bool) {
 	}
 }
 
+// runInboundFees tests that path finding takes the inbound fees of forwarding
+// nodes into account, and that a discount never exceeds the outbound fee of a
+// node.
+func runInboundFees(t *testing.T, useCache bool) {
+	newPolicy := func(feeRate lnwire.MilliSatoshi,
+		inboundRate int32) *testChannelPolicy {
+
+		return &testChannelPolicy{
+			Expiry:  144,
+			FeeRate: feeRate,
+			InboundFee: lnwire.Fee{
+				FeeRate: inboundRate,
+			},
+			MinHTLC: 1,
+			MaxHTLC: 100000000,
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		inboundRate int32
+		expectedFee lnwire.MilliSatoshi
+	}{
+		{
+			// Node a charges 60 msat outbound, minus a discount
+			// of 40 msat. Together with the 40 msat of node first
+			// the route through a is cheaper than the 90 msat of
+			// the route through b.
+			name:        "discount",
+			inboundRate: -400,
+			expectedFee: 60,
+		},
+		{
+			// The discount of 100 msat exceeds the outbound fee
+			// of node a, so only the fee of node first remains.
+			name:        "discount capped",
+			inboundRate: -1000,
+			expectedFee: 40,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Node a grants a discount for HTLCs arriving from
+			// node first, which is part of its own policy.
+			testChannels := []*testChannel{
+				symmetricTestChannel(
+					"roasbeef", "first", 100000,
+					newPolicy(400, 0),
+				),
+				asymmetricTestChannel(
+					"first", "a", 100000,
+					newPolicy(400, 0),
+					newPolicy(400, tc.inboundRate), 0,
+				),
+				symmetricTestChannel(
+					"a", "target", 100000,
+					newPolicy(600, 0),
+				),
+				symmetricTestChannel(
+					"first", "b", 100000,
+					newPolicy(400, 0),
+				),
+				symmetricTestChannel(
+					"b", "target", 100000,
+					newPolicy(500, 0),
+				),
+			}
+
+			ctx := newPathFindingTestContext(
+				t, useCache, testChannels, "roasbeef",
+			)
+
+			paymentAmt := lnwire.NewMSatFromSatoshis(100)
+			target := ctx.keyFromAlias("target")
+			path, err := ctx.findPath(target, paymentAmt)
+			require.NoError(t, err, "unable to find path")
+
+			route, err := newRoute(
+				ctx.source, path, 100, finalHopParams{
+					amt:       paymentAmt,
+					cltvDelta: 1,
+				},
+			)
+			require.NoError(t, err, "unable to create route")
+
+			require.Equal(
+				t, ctx.keyFromAlias("a"),
+				route.Hops[1].PubKeyBytes,
+			)
+			require.Equal(t, tc.expectedFee, route.TotalFees())
+			require.Equal(
+				t, paymentAmt+tc.expectedFee, route.TotalAmount,
+			)
+		})
+	}
+}
+
+// TestForwardAmtFromIncoming tests that the amount a node forwards is derived
+// correctly from the incoming amount, taking inbound fees into account.
+func TestForwardAmtFromIncoming(t *testing.T) {
+	t.Parallel()
+
+	policy := &channeldb.CachedEdgePolicy{
+		FeeBaseMSat:               1000,
+		FeeProportionalMillionths: 500,
+	}
+
+	inboundFees := []lnwire.Fee{
+		{},
+		{BaseFee: 100, FeeRate: 300},
+		{BaseFee: -500, FeeRate: -200},
+		{BaseFee: -5000, FeeRate: -5000},
+	}
+
+	for _, inboundFee := range inboundFees {
+		for _, amt := range []lnwire.MilliSatoshi{1, 1000, 123456789} {
+			outboundFee := policy.ComputeFee(amt)
+			fee := int64(outboundFee) + cappedInboundFee(
+				inboundFee, amt+outboundFee, outboundFee,
+			)
+			require.GreaterOrEqual(t, fee, int64(0))
+
+			incomingAmt := lnwire.MilliSatoshi(int64(amt) + fee)
+			require.Equal(
+				t, amt, forwardAmtFromIncoming(
+					incomingAmt, policy, inboundFee,
+				), "inbound fee %v", inboundFee,
+			)
+		}
+	}
+}
+
 func getAliasFromPubKey(pubKey route.Vertex,
 	aliases map[string]route.Vertex) string {
 
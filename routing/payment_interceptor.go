@@ -0,0 +1,263 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+var (
+	// ErrPaymentRejected is returned when the payment interceptor rejects
+	// an outgoing payment.
+	ErrPaymentRejected = errors.New("payment rejected by interceptor")
+
+	// ErrNoPaymentInterceptor is returned when a payment interceptor is
+	// required, but none is registered.
+	ErrNoPaymentInterceptor = errors.New("no payment interceptor " +
+		"registered")
+)
+
+// PaymentInterceptAction is the decision of the payment interceptor about an
+// outgoing payment.
+type PaymentInterceptAction uint8
+
+const (
+	// PaymentInterceptApprove dispatches the payment, applying the
+	// modifications of the decision.
+	PaymentInterceptApprove PaymentInterceptAction = iota
+
+	// PaymentInterceptReject fails the payment before it is dispatched.
+	PaymentInterceptReject
+)
+
+// InterceptedPayment describes an outgoing payment that is held until the
+// payment interceptor decided about it.
+type InterceptedPayment struct {
+	// Identifier is the payment hash of the payment, or the set id of an
+	// AMP payment.
+	Identifier lntypes.Hash
+
+	// Target is the destination of the payment.
+	Target route.Vertex
+
+	// Amount is the amount that the destination receives. For an MPP or
+	// AMP payment, it is the total amount of all shards.
+	Amount lnwire.MilliSatoshi
+
+	// FeeLimit is the maximum fee of the payment. For a payment along a
+	// route, it is the fee of the route. For an MPP or AMP payment along
+	// routes, it is the fee of the route of the first shard.
+	FeeLimit lnwire.MilliSatoshi
+
+	// OutgoingChannelIDs are the channels the payment may leave through.
+	// If empty, any channel may be used.
+	OutgoingChannelIDs []uint64
+
+	// PaymentRequest is the payment request that the payment pays, if
+	// any.
+	PaymentRequest []byte
+
+	// DestCustomRecords are the custom records sent to the destination.
+	DestCustomRecords record.CustomSet
+
+	// AMP is true if the payment is an AMP payment.
+	AMP bool
+
+	// Route is the route of a payment sent along a given route, and nil
+	// for payments that the router finds routes for. For an MPP or AMP
+	// payment along routes, it is the route of the first shard.
+	Route *route.Route
+}
+
+// Keysend returns true if the payment is a spontaneous keysend payment.
+func (p *InterceptedPayment) Keysend() bool {
+	_, ok := p.DestCustomRecords[record.KeySendType]
+	return ok
+}
+
+// PaymentInterceptDecision is the decision of the payment interceptor about
+// an outgoing payment.
+type PaymentInterceptDecision struct {
+	// Action is the action to take on the payment.
+	Action PaymentInterceptAction
+
+	// FeeLimit, if set, replaces the fee limit of an approved payment.
+	FeeLimit *lnwire.MilliSatoshi
+
+	// OutgoingChannelIDs, if not empty, replaces the channels an approved
+	// payment may leave through.
+	OutgoingChannelIDs []uint64
+
+	// Reason is the reason for rejecting the payment.
+	Reason string
+}
+
+// modifies returns true if the decision modifies the payment.
+func (d *PaymentInterceptDecision) modifies() bool {
+	return d.FeeLimit != nil || len(d.OutgoingChannelIDs) > 0
+}
+
+// PaymentInterceptor decides about outgoing payments before they are
+// dispatched.
+type PaymentInterceptor interface {
+	// InterceptPayment blocks until the decision about the payment is
+	// made. An error rejects the payment.
+	InterceptPayment(payment *InterceptedPayment) (
+		*PaymentInterceptDecision, error)
+}
+
+// SetPaymentInterceptor sets the interceptor that decides about outgoing
+// payments. A nil argument unregisters the current interceptor.
+func (r *ChannelRouter) SetPaymentInterceptor(interceptor PaymentInterceptor) {
+	r.paymentInterceptorMtx.Lock()
+	defer r.paymentInterceptorMtx.Unlock()
+
+	r.paymentInterceptor = interceptor
+}
+
+// interceptPayment hands the payment to the payment interceptor, and returns
+// its decision. Without an interceptor, payments are approved unless an
+// interceptor is required.
+func (r *ChannelRouter) interceptPayment(
+	payment *InterceptedPayment) (*PaymentInterceptDecision, error) {
+
+	r.paymentInterceptorMtx.RLock()
+	interceptor := r.paymentInterceptor
+	r.paymentInterceptorMtx.RUnlock()
+
+	if interceptor == nil {
+		if r.cfg.RequirePaymentInterceptor {
+			return nil, ErrNoPaymentInterceptor
+		}
+
+		return &PaymentInterceptDecision{
+			Action: PaymentInterceptApprove,
+		}, nil
+	}
+
+	log.Debugf("Intercepting payment %v of %v to %v", payment.Identifier,
+		payment.Amount, payment.Target)
+
+	decision, err := interceptor.InterceptPayment(payment)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrPaymentRejected, err)
+
+	case decision.Action == PaymentInterceptReject:
+		log.Infof("Payment %v rejected by interceptor: %v",
+			payment.Identifier, decision.Reason)
+
+		if decision.Reason == "" {
+			return nil, ErrPaymentRejected
+		}
+
+		return nil, fmt.Errorf("%w: %v", ErrPaymentRejected,
+			decision.Reason)
+
+	case decision.Action != PaymentInterceptApprove:
+		return nil, fmt.Errorf("%w: unknown action %v",
+			ErrPaymentRejected, decision.Action)
+	}
+
+	return decision, nil
+}
+
+// interceptLightningPayment hands the payment to the payment interceptor
+// before it is dispatched, and applies the modifications of the decision.
+func (r *ChannelRouter) interceptLightningPayment(
+	payment *LightningPayment) error {
+
+	decision, err := r.interceptPayment(&InterceptedPayment{
+		Identifier:         payment.Identifier(),
+		Target:             payment.Target,
+		Amount:             payment.Amount,
+		FeeLimit:           payment.FeeLimit,
+		OutgoingChannelIDs: payment.OutgoingChannelIDs,
+		PaymentRequest:     payment.PaymentRequest,
+		DestCustomRecords:  payment.DestCustomRecords,
+		AMP:                payment.amp != nil,
+	})
+	if err != nil {
+		return err
+	}
+
+	if decision.FeeLimit != nil {
+		log.Debugf("Payment %x: fee limit changed from %v to %v by "+
+			"interceptor", payment.Identifier(), payment.FeeLimit,
+			*decision.FeeLimit)
+
+		payment.FeeLimit = *decision.FeeLimit
+	}
+
+	if len(decision.OutgoingChannelIDs) > 0 {
+		log.Debugf("Payment %x: outgoing channels changed from %v to "+
+			"%v by interceptor", payment.Identifier(),
+			payment.OutgoingChannelIDs, decision.OutgoingChannelIDs)
+
+		payment.OutgoingChannelIDs = decision.OutgoingChannelIDs
+	}
+
+	return nil
+}
+
+// interceptRoutePayment hands the payment along the given route to the
+// payment interceptor before it is dispatched. As the route is fixed, a
+// decision that modifies the payment rejects it. The amount is the total
+// amount of the payment. An MPP or AMP payment is only intercepted with its
+// first shard, as an approved payment is registered with the control tower
+// before its shard is dispatched. The shards that follow are dispatched as
+// long as the payment is in flight.
+func (r *ChannelRouter) interceptRoutePayment(identifier lntypes.Hash,
+	amt lnwire.MilliSatoshi, rt *route.Route) error {
+
+	r.paymentInterceptorMtx.RLock()
+	intercepting := r.paymentInterceptor != nil ||
+		r.cfg.RequirePaymentInterceptor
+	r.paymentInterceptorMtx.RUnlock()
+
+	finalHop := rt.Hops[len(rt.Hops)-1]
+	isShard := finalHop.MPP != nil || finalHop.AMP != nil
+	if intercepting && isShard {
+		payment, err := r.cfg.Control.FetchPayment(identifier)
+		switch {
+		case err == nil && payment.Status == channeldb.StatusInFlight:
+			log.Debugf("Payment %v already approved by "+
+				"interceptor, dispatching shard", identifier)
+
+			return nil
+
+		case err != nil &&
+			!errors.Is(err, channeldb.ErrPaymentNotInitiated):
+
+			return err
+		}
+	}
+
+	decision, err := r.interceptPayment(&InterceptedPayment{
+		Identifier: identifier,
+		Target:     finalHop.PubKeyBytes,
+		Amount:     amt,
+		FeeLimit:   rt.TotalFees(),
+		OutgoingChannelIDs: []uint64{
+			rt.Hops[0].ChannelID,
+		},
+		DestCustomRecords: finalHop.CustomRecords,
+		AMP:               finalHop.AMP != nil,
+		Route:             rt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if decision.modifies() {
+		return fmt.Errorf("%w: payment along a route can't be "+
+			"modified", ErrPaymentRejected)
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/record"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPaymentInterceptor records the intercepted payments and returns a fixed
+// decision.
+type mockPaymentInterceptor struct {
+	payments []*InterceptedPayment
+	decision *PaymentInterceptDecision
+	err      error
+}
+
+// InterceptPayment records the payment and returns the mock's decision.
+//
+// NOTE: Part of the PaymentInterceptor interface.
+func (m *mockPaymentInterceptor) InterceptPayment(
+	payment *InterceptedPayment) (*PaymentInterceptDecision, error) {
+
+	m.payments = append(m.payments, payment)
+
+	return m.decision, m.err
+}
+
+// TestInterceptLightningPayment asserts that payments are approved, modified
+// or rejected by the payment interceptor.
+func TestInterceptLightningPayment(t *testing.T) {
+	t.Parallel()
+
+	router := &ChannelRouter{cfg: &Config{}}
+
+	newPayment := func() *LightningPayment {
+		payment := &LightningPayment{
+			Target:             route.Vertex{1},
+			Amount:             100_000,
+			FeeLimit:           1000,
+			OutgoingChannelIDs: []uint64{5},
+			DestCustomRecords: record.CustomSet{
+				record.KeySendType: []byte{2},
+			},
+		}
+		require.NoError(t, payment.SetPaymentHash(lntypes.Hash{3}))
+
+		return payment
+	}
+
+	// Without an interceptor, payments are dispatched unless one is
+	// required.
+	require.NoError(t, router.interceptLightningPayment(newPayment()))
+
+	router.cfg.RequirePaymentInterceptor = true
+	err := router.interceptLightningPayment(newPayment())
+	require.ErrorIs(t, err, ErrNoPaymentInterceptor)
+
+	// The interceptor modifies the fee limit and outgoing channels.
+	feeLimit := lnwire.MilliSatoshi(500)
+	interceptor := &mockPaymentInterceptor{
+		decision: &PaymentInterceptDecision{
+			Action:             PaymentInterceptApprove,
+			FeeLimit:           &feeLimit,
+			OutgoingChannelIDs: []uint64{6, 7},
+		},
+	}
+	router.SetPaymentInterceptor(interceptor)
+
+	payment := newPayment()
+	require.NoError(t, router.interceptLightningPayment(payment))
+	require.Equal(t, feeLimit, payment.FeeLimit)
+	require.Equal(t, []uint64{6, 7}, payment.OutgoingChannelIDs)
+
+	require.Len(t, interceptor.payments, 1)
+	intercepted := interceptor.payments[0]
+	require.Equal(t, lntypes.Hash{3}, intercepted.Identifier)
+	require.Equal(t, lnwire.MilliSatoshi(100_000), intercepted.Amount)
+	require.Equal(t, lnwire.MilliSatoshi(1000), intercepted.FeeLimit)
+	require.Equal(t, []uint64{5}, intercepted.OutgoingChannelIDs)
+	require.True(t, intercepted.Keysend())
+	require.False(t, intercepted.AMP)
+	require.Nil(t, intercepted.Route)
+
+	// A rejection carries the reason, and an error rejects the payment
+	// too.
+	interceptor.decision = &PaymentInterceptDecision{
+		Action: PaymentInterceptReject,
+		Reason: "above threshold",
+	}
+	payment = newPayment()
+	err = router.interceptLightningPayment(payment)
+	require.ErrorIs(t, err, ErrPaymentRejected)
+	require.ErrorContains(t, err, "above threshold")
+	require.Equal(t, lnwire.MilliSatoshi(1000), payment.FeeLimit)
+
+	interceptor.err = errors.New("interceptor disconnected")
+	err = router.interceptLightningPayment(newPayment())
+	require.ErrorIs(t, err, ErrPaymentRejected)
+
+	// Once unregistered, payments are rejected again as an interceptor is
+	// required.
+	router.SetPaymentInterceptor(nil)
+	err = router.interceptLightningPayment(newPayment())
+	require.ErrorIs(t, err, ErrNoPaymentInterceptor)
+}
+
+// TestInterceptRoutePayment asserts that payments along a route are passed to
+// the interceptor with their route, and can't be modified. MPP payments are
+// only intercepted with their first shard.
+func TestInterceptRoutePayment(t *testing.T) {
+	t.Parallel()
+
+	rt, err := route.NewRouteFromHops(
+		1100, 100, route.Vertex{1}, []*route.Hop{{
+			PubKeyBytes:  route.Vertex{2},
+			ChannelID:    8,
+			AmtToForward: 1000,
+		}, {
+			PubKeyBytes:  route.Vertex{3},
+			ChannelID:    9,
+			AmtToForward: 1000,
+		}},
+	)
+	require.NoError(t, err)
+
+	interceptor := &mockPaymentInterceptor{
+		decision: &PaymentInterceptDecision{
+			Action: PaymentInterceptApprove,
+		},
+	}
+	router := &ChannelRouter{cfg: &Config{}}
+	router.SetPaymentInterceptor(interceptor)
+
+	err = router.interceptRoutePayment(lntypes.Hash{4}, 1000, rt)
+	require.NoError(t, err)
+
+	require.Len(t, interceptor.payments, 1)
+	intercepted := interceptor.payments[0]
+	require.Equal(t, route.Vertex{3}, intercepted.Target)
+	require.Equal(t, lnwire.MilliSatoshi(100), intercepted.FeeLimit)
+	require.Equal(t, []uint64{8}, intercepted.OutgoingChannelIDs)
+	require.Equal(t, rt, intercepted.Route)
+
+	feeLimit := lnwire.MilliSatoshi(50)
+	interceptor.decision.FeeLimit = &feeLimit
+	err = router.interceptRoutePayment(lntypes.Hash{4}, 1000, rt)
+	require.ErrorIs(t, err, ErrPaymentRejected)
+
+	// The first shard of an MPP payment is intercepted with the total
+	// amount of the payment.
+	interceptor.decision.FeeLimit = nil
+	interceptor.payments = nil
+	control := makeMockControlTower()
+	router.cfg.Control = control
+
+	rt.Hops[1].MPP = record.NewMPP(3000, [32]byte{5})
+	err = router.interceptRoutePayment(lntypes.Hash{6}, 3000, rt)
+	require.NoError(t, err)
+	require.Len(t, interceptor.payments, 1)
+	require.Equal(
+		t, lnwire.MilliSatoshi(3000), interceptor.payments[0].Amount,
+	)
+
+	// Once the approved payment is in flight, its other shards are
+	// dispatched without asking the interceptor again.
+	info := &channeldb.PaymentCreationInfo{
+		PaymentIdentifier: lntypes.Hash{6},
+		Value:             3000,
+	}
+	require.NoError(t, control.InitPayment(lntypes.Hash{6}, info))
+
+	err = router.interceptRoutePayment(lntypes.Hash{6}, 3000, rt)
+	require.NoError(t, err)
+	require.Len(t, interceptor.payments, 1)
+
+	// If the payment failed, a new attempt to pay is intercepted again.
+	require.NoError(t, control.Fail(
+		lntypes.Hash{6}, channeldb.FailureReasonNoRoute,
+	))
+	err = router.interceptRoutePayment(lntypes.Hash{6}, 3000, rt)
+	require.NoError(t, err)
+	require.Len(t, interceptor.payments, 2)
+}
@@ -30,6 +30,15 @@ type paymentLifecycle struct {
 	shardTracker  shards.ShardTracker
 	timeoutChan   <-chan time.Time
 	currentHeight int32
+
+	// maxShardsInFlight is the maximum number of shards that may be in
+	// flight at the same time. Zero means no limit.
+	maxShardsInFlight uint32
+
+	// metrics holds the metrics recorded while running the payment
+	// lifecycle. They are added to the payment's metrics once the
+	// lifecycle exits.
+	metrics channeldb.PaymentMetrics
 }
 
 // payemntState holds a number of key insights learned from a given MPPayment
@@ -66,6 +75,13 @@ func (ps paymentState) needWaitForShards() bool {
 	return ps.terminate || ps.remainingAmt == 0
 }
 
+// inFlightLimitReached returns true if no further shards may be launched
+// before one of the shards in flight is resolved.
+func (p *paymentLifecycle) inFlightLimitReached(ps *paymentState) bool {
+	return p.maxShardsInFlight != 0 &&
+		uint32(ps.numShardsInFlight) >= p.maxShardsInFlight
+}
+
 // fetchPaymentState will query the db for the latest payment state
 // information we need to act on every iteration of the payment loop and update
 // the paymentState.
@@ -128,6 +144,10 @@ func (p *paymentLifecycle) resumePayment() ([32]byte, *route.Route, error) {
 		paySession:   p.paySession,
 	}
 
+	// Once the payment lifecycle loop exited and all sub goroutines
+	// returned, the metrics of this run are recorded.
+	defer p.recordMetrics()
+
 	// When the payment lifecycle loop exits, we make sure to signal any
 	// sub goroutine of the shardHandler to exit, then wait for them to
 	// return.
@@ -213,7 +233,15 @@ lifecycle:
 			// We still have outstanding shards, so wait for a new
 			// outcome to be available before re-evaluating our
 			// state.
-			if err := shardHandler.waitForShard(); err != nil {
+			if err := p.waitForShard(shardHandler); err != nil {
+				return [32]byte{}, nil, err
+			}
+			continue lifecycle
+
+		// If the maximum number of shards is in flight, we'll wait for
+		// one of them to be resolved before launching another one.
+		case p.inFlightLimitReached(currentState):
+			if err := p.waitForShard(shardHandler); err != nil {
 				return [32]byte{}, nil, err
 			}
 			continue lifecycle
@@ -249,12 +277,9 @@ lifecycle:
 		default:
 		}
 
-		// Create a new payment attempt from the given payment session.
-		rt, err := p.paySession.RequestRoute(
-			currentState.remainingAmt, currentState.remainingFees,
-			uint32(currentState.numShardsInFlight),
-			uint32(p.currentHeight),
-		)
+		// Request the routes for the remaining amount from the given
+		// payment session.
+		routes, err := p.requestRoutes(currentState)
 		if err != nil {
 			log.Warnf("Failed to find route for payment %v: %v",
 				p.identifier, err)
@@ -285,59 +310,152 @@ lifecycle:
 
 			// We still have active shards, we'll wait for an
 			// outcome to be available before retrying.
-			if err := shardHandler.waitForShard(); err != nil {
+			if err := p.waitForShard(shardHandler); err != nil {
 				return [32]byte{}, nil, err
 			}
 			continue lifecycle
 		}
 
-		// If this route will consume the last remaining amount to send
-		// to the receiver, this will be our last shard (for now).
-		lastShard := rt.ReceiverAmt() == currentState.remainingAmt
+		// We found routes to try, launch a new shard for each of them.
+		// The shards are sent concurrently.
+		results := shardHandler.launchShards(
+			routes, currentState.remainingAmt,
+		)
+		for _, result := range results {
+			err := p.handleLaunchResult(shardHandler, result)
+			if err != nil {
+				return [32]byte{}, nil, err
+			}
+		}
+	}
+}
 
-		// We found a route to try, launch a new shard.
-		attempt, outcome, err := shardHandler.launchShard(rt, lastShard)
-		switch {
-		// We may get a terminal error if we've processed a shard with
-		// a terminal state (settled or permanent failure), while we
-		// were pathfinding. We know we're in a terminal state here,
-		// so we can continue and wait for our last shards to return.
-		case err == channeldb.ErrPaymentTerminal:
-			log.Infof("Payment %v in terminal state, abandoning "+
-				"shard", p.identifier)
+// requestRoutes requests routes from the payment session until the remaining
+// amount is covered, the maximum number of shards in flight is reached or no
+// further route is found. Finding the routes up front allows their shards to
+// be launched at once. As the shards aren't launched yet, the amount of each
+// route found is reserved on its first hop, so that the routes that follow
+// don't count on the same local bandwidth. An error is only returned if no
+// route is found at all.
+func (p *paymentLifecycle) requestRoutes(
+	ps *paymentState) ([]*route.Route, error) {
+
+	start := time.Now()
+	defer func() {
+		p.metrics.PathfindingTime += time.Since(start)
+	}()
 
-			continue lifecycle
+	var (
+		routes       []*route.Route
+		remainingAmt = ps.remainingAmt
+		feeBudget    = ps.remainingFees
+		activeShards = uint32(ps.numShardsInFlight)
+		reserved     = make(map[uint64]lnwire.MilliSatoshi)
+	)
+	for remainingAmt > 0 {
+		if p.maxShardsInFlight != 0 &&
+			activeShards >= p.maxShardsInFlight {
 
-		case err != nil:
-			return [32]byte{}, nil, err
+			break
 		}
 
-		// If we encountered a non-critical error when launching the
-		// shard, handle it.
-		if outcome.err != nil {
-			log.Warnf("Failed to launch shard %v for "+
-				"payment %v: %v", attempt.AttemptID,
-				p.identifier, outcome.err)
-
-			// We must inspect the error to know whether it was
-			// critical or not, to decide whether we should
-			// continue trying.
-			err := shardHandler.handleSendError(
-				attempt, outcome.err,
-			)
-			if err != nil {
-				return [32]byte{}, nil, err
-			}
+		rt, err := p.paySession.RequestRoute(
+			remainingAmt, feeBudget, activeShards,
+			uint32(p.currentHeight), reserved,
+		)
+		if err != nil && len(routes) == 0 {
+			return nil, err
+		}
 
-			// Error was handled successfully, continue to make a
-			// new attempt.
-			continue lifecycle
+		// If no route is found for the rest of the amount, the shards
+		// found so far are launched anyway. The rest of the amount is
+		// retried once their outcome is known.
+		if err != nil {
+			log.Debugf("Stopped requesting routes for payment %v "+
+				"after %v routes: %v", p.identifier,
+				len(routes), err)
+
+			break
 		}
 
-		// Now that the shard was successfully sent, launch a go
-		// routine that will handle its result when its back.
-		shardHandler.collectResultAsync(attempt)
+		routes = append(routes, rt)
+		remainingAmt -= rt.ReceiverAmt()
+		activeShards++
+		reserved[rt.Hops[0].ChannelID] += rt.TotalAmount
 
+		fees := rt.TotalFees()
+		if fees < feeBudget {
+			feeBudget -= fees
+		} else {
+			feeBudget = 0
+		}
+	}
+
+	return routes, nil
+}
+
+// handleLaunchResult handles the outcome of launching a shard. Only critical
+// errors are returned.
+func (p *paymentLifecycle) handleLaunchResult(shardHandler *shardHandler,
+	result *launchResult) error {
+
+	switch {
+	// We may get a terminal error if we've processed a shard with a
+	// terminal state (settled or permanent failure), while we were
+	// pathfinding. We know we're in a terminal state here, so we can
+	// continue and wait for our last shards to return.
+	case result.err == channeldb.ErrPaymentTerminal:
+		log.Infof("Payment %v in terminal state, abandoning "+
+			"shard", p.identifier)
+
+		return nil
+
+	case result.err != nil:
+		return result.err
+	}
+
+	p.metrics.NumAttempts++
+
+	// If we encountered a non-critical error when launching the shard,
+	// handle it.
+	attempt, outcome := result.attempt, result.outcome
+	if outcome.err != nil {
+		log.Warnf("Failed to launch shard %v for "+
+			"payment %v: %v", attempt.AttemptID,
+			p.identifier, outcome.err)
+
+		// We must inspect the error to know whether it was critical or
+		// not, to decide whether we should continue trying.
+		return shardHandler.handleSendError(attempt, outcome.err)
+	}
+
+	// Now that the shard was successfully sent, launch a go routine that
+	// will handle its result when its back.
+	shardHandler.collectResultAsync(attempt)
+
+	return nil
+}
+
+// waitForShard waits for the outcome of one of the shards in flight, adding
+// the time spent waiting to the payment metrics.
+func (p *paymentLifecycle) waitForShard(shardHandler *shardHandler) error {
+	start := time.Now()
+	defer func() {
+		p.metrics.ResultWaitTime += time.Since(start)
+	}()
+
+	return shardHandler.waitForShard()
+}
+
+// recordMetrics adds the metrics recorded by the lifecycle to the metrics of
+// the payment.
+func (p *paymentLifecycle) recordMetrics() {
+	err := p.router.cfg.Control.AddPaymentMetrics(
+		p.identifier, &p.metrics,
+	)
+	if err != nil {
+		log.Errorf("Unable to record metrics for payment %v: %v",
+			p.identifier, err)
 	}
 }
 
@@ -417,6 +535,95 @@ type launchOutcome struct {
 	attempt *channeldb.HTLCAttempt
 }
 
+// launchResult holds the result of launching a shard.
+type launchResult struct {
+	// attempt is the attempt that was created for the shard. It is nil if
+	// err is set.
+	attempt *channeldb.HTLCAttemptInfo
+
+	// outcome indicates whether the attempt was sent. It is nil if err is
+	// set.
+	outcome *launchOutcome
+
+	// err is a critical error encountered while launching the shard.
+	err error
+}
+
+// launchShards launches a shard along each of the given routes. The attempts
+// are created in order, such that the shard tracker sees the last shard last,
+// and are then registered and sent concurrently. The results are returned in
+// the order of the routes. If the creation of an attempt fails, no attempts
+// are created for the following routes.
+func (p *shardHandler) launchShards(routes []*route.Route,
+	remainingAmt lnwire.MilliSatoshi) []*launchResult {
+
+	type newAttempt struct {
+		firstHop lnwire.ShortChannelID
+		htlcAdd  *lnwire.UpdateAddHTLC
+		attempt  *channeldb.HTLCAttemptInfo
+	}
+
+	var attempts []*newAttempt
+	for _, rt := range routes {
+		// If this route will consume the last remaining amount to
+		// send to the receiver, this will be our last shard (for
+		// now).
+		remainingAmt -= rt.ReceiverAmt()
+		lastShard := remainingAmt == 0
+
+		firstHop, htlcAdd, attempt, err := p.createNewPaymentAttempt(
+			rt, lastShard,
+		)
+		if err != nil {
+			if len(attempts) == 0 {
+				return []*launchResult{{err: err}}
+			}
+
+			log.Errorf("Unable to create attempt for payment %v: "+
+				"%v", p.identifier, err)
+
+			break
+		}
+
+		attempts = append(attempts, &newAttempt{
+			firstHop: firstHop,
+			htlcAdd:  htlcAdd,
+			attempt:  attempt,
+		})
+	}
+
+	results := make([]*launchResult, len(attempts))
+	dispatch := func(i int) {
+		a := attempts[i]
+		outcome, err := p.dispatchAttempt(
+			a.attempt, a.firstHop, a.htlcAdd,
+		)
+		results[i] = &launchResult{
+			attempt: a.attempt,
+			outcome: outcome,
+			err:     err,
+		}
+	}
+
+	// A single shard is sent without spawning a goroutine.
+	if len(attempts) == 1 {
+		dispatch(0)
+		return results
+	}
+
+	var wg sync.WaitGroup
+	for i := range attempts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dispatch(i)
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
 // launchShard creates and sends an HTLC attempt along the given route,
 // registering it with the control tower before sending it. The lastShard
 // argument should be true if this shard will consume the remainder of the
@@ -437,14 +644,28 @@ func (p *shardHandler) launchShard(rt *route.Route,
 		return nil, nil, err
 	}
 
+	outcome, err := p.dispatchAttempt(attempt, firstHop, htlcAdd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attempt, outcome, nil
+}
+
+// dispatchAttempt registers the given attempt with the control tower and sends
+// it. The launchOutcome wraps a non-nil error if the attempt couldn't be sent.
+func (p *shardHandler) dispatchAttempt(attempt *channeldb.HTLCAttemptInfo,
+	firstHop lnwire.ShortChannelID,
+	htlcAdd *lnwire.UpdateAddHTLC) (*launchOutcome, error) {
+
 	// Before sending this HTLC to the switch, we checkpoint the fresh
 	// paymentID and route to the DB. This lets us know on startup the ID
 	// of the payment that we attempted to send, such that we can query the
 	// Switch for its whereabouts. The route is needed to handle the result
 	// when it eventually comes back.
-	err = p.router.cfg.Control.RegisterAttempt(p.identifier, attempt)
+	err := p.router.cfg.Control.RegisterAttempt(p.identifier, attempt)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// Now that the attempt is created and checkpointed to the DB, we send
@@ -455,17 +676,17 @@ func (p *shardHandler) launchShard(rt *route.Route,
 		// from real send errors.
 		htlcAttempt, err := p.failAttempt(attempt, sendErr)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		// Return a launchOutcome indicating the shard failed.
-		return attempt, &launchOutcome{
+		return &launchOutcome{
 			attempt: htlcAttempt,
 			err:     sendErr,
 		}, nil
 	}
 
-	return attempt, &launchOutcome{}, nil
+	return &launchOutcome{}, nil
 }
 
 // shardResult holds the resulting outcome of a shard sent.
@@ -15,6 +15,7 @@ import (
 	"github.com/lightningnetwork/lnd/lntypes"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -1126,3 +1127,220 @@ func makeAttemptInfo(total, amtForwarded int) channeldb.HTLCAttemptInfo {
 		},
 	}
 }
+
+// TestRequestRoutes tests that routes are requested up front until the
+// remaining amount is covered or the maximum number of shards in flight is
+// reached.
+func TestRequestRoutes(t *testing.T) {
+	t.Parallel()
+
+	const (
+		height   = 100
+		totalAmt = lnwire.MilliSatoshi(100_000)
+		feeLimit = lnwire.MilliSatoshi(20_000)
+	)
+
+	shard, err := createTestRoute(totalAmt/4, map[string]route.Vertex{})
+	require.NoError(t, err)
+	shardFees := shard.TotalFees()
+
+	state := &paymentState{
+		numShardsInFlight: 1,
+		remainingAmt:      totalAmt * 3 / 4,
+		remainingFees:     feeLimit,
+	}
+
+	testCases := []struct {
+		name              string
+		maxShardsInFlight uint32
+		numRoutes         int
+	}{
+		{
+			name:      "no limit",
+			numRoutes: 3,
+		},
+		{
+			name:              "limited",
+			maxShardsInFlight: 3,
+			numRoutes:         2,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			session := &mockPaymentSession{}
+			amt, fees := state.remainingAmt, feeLimit
+			for i := 0; i < tc.numRoutes; i++ {
+				session.On("RequestRoute",
+					amt, fees, uint32(i+1), uint32(height),
+				).Return(shard, nil).Once()
+
+				amt -= shard.ReceiverAmt()
+				fees -= shardFees
+			}
+
+			pl := &paymentLifecycle{
+				paySession:        session,
+				currentHeight:     height,
+				maxShardsInFlight: tc.maxShardsInFlight,
+			}
+
+			routes, err := pl.requestRoutes(state)
+			require.NoError(t, err)
+			require.Len(t, routes, tc.numRoutes)
+
+			session.AssertExpectations(t)
+		})
+	}
+
+	// If no route is found after the first one, the route found is
+	// returned.
+	session := &mockPaymentSession{}
+	session.On("RequestRoute",
+		state.remainingAmt, feeLimit, uint32(1), uint32(height),
+	).Return(shard, nil).Once()
+	session.On("RequestRoute",
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+	).Return((*route.Route)(nil), errNoPathFound).Once()
+
+	pl := &paymentLifecycle{
+		paySession:    session,
+		currentHeight: height,
+	}
+
+	routes, err := pl.requestRoutes(state)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+
+	// If no route is found at all, the error is returned.
+	session.On("RequestRoute",
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+	).Return((*route.Route)(nil), errNoPathFound).Once()
+
+	_, err = pl.requestRoutes(state)
+	require.ErrorIs(t, err, errNoPathFound)
+
+	session.AssertExpectations(t)
+}
+
+// TestRequestRoutesReservesBandwidth tests that the local bandwidth taken by a
+// route that is requested up front isn't available to the routes requested
+// after it.
+func TestRequestRoutesReservesBandwidth(t *testing.T) {
+	t.Parallel()
+
+	const (
+		height      = 100
+		limitedChan = 1
+		otherChan   = 2
+	)
+
+	shardAmt := lnwire.MilliSatoshi(500)
+	payment := &LightningPayment{
+		Target:         route.Vertex{9},
+		CltvLimit:      1000,
+		FinalCLTVDelta: 40,
+		Amount:         1000,
+		FeeLimit:       1000,
+		MaxParts:       2,
+		MaxShardAmt:    &shardAmt,
+	}
+	require.NoError(t, payment.SetPaymentHash(lntypes.Hash{}))
+
+	// The limited channel only has the bandwidth for one shard.
+	session, err := newPaymentSession(
+		payment,
+		func(routingGraph) (bandwidthHints, error) {
+			return &mockBandwidthHints{
+				hints: map[uint64]lnwire.MilliSatoshi{
+					limitedChan: 600,
+					otherChan:   1000,
+				},
+			}, nil
+		},
+		func() (routingGraph, func(), error) {
+			return &sessionGraph{}, func() {}, nil
+		},
+		&MissionControl{},
+		PathFindingConfig{},
+	)
+	require.NoError(t, err)
+
+	// The mock path finder prefers the limited channel whenever it has
+	// enough bandwidth left.
+	session.pathFinder = func(g *graphParams, _ *RestrictParams,
+		_ *PathFindingConfig, _, target route.Vertex,
+		amt lnwire.MilliSatoshi, _ float64,
+		_ int32) ([]*channeldb.CachedEdgePolicy, error) {
+
+		chanID := uint64(otherChan)
+		bandwidth, _ := g.bandwidthHints.availableChanBandwidth(
+			limitedChan, amt,
+		)
+		if bandwidth >= amt {
+			chanID = limitedChan
+		}
+
+		return []*channeldb.CachedEdgePolicy{{
+			ChannelID: chanID,
+			ToNodePubKey: func() route.Vertex {
+				return target
+			},
+			ToNodeFeatures: lnwire.EmptyFeatureVector(),
+		}}, nil
+	}
+
+	pl := &paymentLifecycle{
+		paySession:    session,
+		currentHeight: height,
+	}
+
+	routes, err := pl.requestRoutes(&paymentState{
+		remainingAmt:  payment.Amount,
+		remainingFees: payment.FeeLimit,
+	})
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+	require.EqualValues(t, limitedChan, routes[0].Hops[0].ChannelID)
+	require.EqualValues(t, otherChan, routes[1].Hops[0].ChannelID)
+}
+
+// TestPaymentLifecycleMetrics tests that the metrics recorded by the payment
+// lifecycle are added to the metrics of the payment.
+func TestPaymentLifecycleMetrics(t *testing.T) {
+	t.Parallel()
+
+	control := makeMockControlTower()
+
+	info := &channeldb.PaymentCreationInfo{
+		PaymentIdentifier: lntypes.Hash{1},
+		Value:             1000,
+	}
+	require.NoError(t, control.InitPayment(info.PaymentIdentifier, info))
+
+	pl := &paymentLifecycle{
+		router:     &ChannelRouter{cfg: &Config{Control: control}},
+		identifier: info.PaymentIdentifier,
+		metrics: channeldb.PaymentMetrics{
+			NumAttempts:     2,
+			PathfindingTime: time.Second,
+			ResultWaitTime:  time.Minute,
+		},
+	}
+
+	// Recording the metrics twice, as happens if the payment is resumed
+	// after a restart, adds them up.
+	pl.recordMetrics()
+	pl.recordMetrics()
+
+	payment, err := control.FetchPayment(info.PaymentIdentifier)
+	require.NoError(t, err)
+	require.Equal(t, &channeldb.PaymentMetrics{
+		NumAttempts:     4,
+		PathfindingTime: 2 * time.Second,
+		ResultWaitTime:  2 * time.Minute,
+	}, payment.Metrics)
+}
@@ -2,6 +2,7 @@ package routing
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btclog"
@@ -132,12 +133,15 @@ type PaymentSession interface {
 	// fees. It can carry less if the payment is MPP. The activeShards
 	// argument should be set to instruct the payment session about the
 	// number of in flight HTLCS for the payment, such that it can choose
-	// splitting strategy accordingly.
+	// splitting strategy accordingly. The reserved argument holds the
+	// bandwidth of our channels that is taken by routes which were found,
+	// but aren't launched yet.
 	//
 	// A noRouteError is returned if a non-critical error is encountered
 	// during path finding.
 	RequestRoute(maxAmt, feeLimit lnwire.MilliSatoshi,
-		activeShards, height uint32) (*route.Route, error)
+		activeShards, height uint32,
+		reserved map[uint64]lnwire.MilliSatoshi) (*route.Route, error)
 
 	// UpdateAdditionalEdge takes an additional channel edge policy
 	// (private channels) and applies the update from the message. Returns
@@ -188,6 +192,16 @@ type paymentSession struct {
 
 	// log is a payment session-specific logger.
 	log btclog.Logger
+
+	// plan holds the routes of the current split of the payment that
+	// haven't been requested yet. It is only used by the probability
+	// split strategy.
+	plan []*route.Route
+
+	// planHeight is the block height the current plan was made at.
+	planHeight uint32
+
+	planMtx sync.Mutex
 }
 
 // newPaymentSession instantiates a new payment session.
@@ -227,7 +241,8 @@ func newPaymentSession(p *LightningPayment,
 // NOTE: This function is safe for concurrent access.
 // NOTE: Part of the PaymentSession interface.
 func (p *paymentSession) RequestRoute(maxAmt, feeLimit lnwire.MilliSatoshi,
-	activeShards, height uint32) (*route.Route, error) {
+	activeShards, height uint32,
+	reserved map[uint64]lnwire.MilliSatoshi) (*route.Route, error) {
 
 	if p.empty {
 		return nil, errEmptyPaySession
@@ -262,6 +277,30 @@ func (p *paymentSession) RequestRoute(maxAmt, feeLimit lnwire.MilliSatoshi,
 
 	finalHtlcExpiry := int32(height) + int32(finalCltvDelta)
 
+	// If the payment is split by probability, take the next route of the
+	// split. Only if no split is found, we fall back to halving the
+	// amount.
+	if p.payment.SplitStrategy == SplitProbability {
+		rt, err := p.requestPlannedRoute(
+			maxAmt, feeLimit, activeShards, &splitParams{
+				restrictions:    restrictions,
+				finalHtlcExpiry: finalHtlcExpiry,
+				finalCltvDelta:  finalCltvDelta,
+				height:          height,
+				reserved:        reserved,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if rt != nil {
+			return rt, nil
+		}
+
+		p.log.Debugf("No split found for %v, falling back to "+
+			"halving", maxAmt)
+	}
+
 	// Before we enter the loop below, we'll make sure to respect the max
 	// payment shard size (if it's set), which is effectively our
 	// client-side MTU that we'll attempt to respect at all times.
@@ -292,6 +331,13 @@ func (p *paymentSession) RequestRoute(maxAmt, feeLimit lnwire.MilliSatoshi,
 			return nil, err
 		}
 
+		// The bandwidth taken by routes that aren't launched yet isn't
+		// reflected by our channels.
+		reservedHints := &reservedBandwidthHints{
+			bandwidthHints: bandwidthHints,
+			reserved:       reserved,
+		}
+
 		p.log.Debugf("pathfinding for amt=%v", maxAmt)
 
 		sourceVertex := routingGraph.sourceNode()
@@ -300,7 +346,7 @@ func (p *paymentSession) RequestRoute(maxAmt, feeLimit lnwire.MilliSatoshi,
 		path, err := p.pathFinder(
 			&graphParams{
 				additionalEdges: p.additionalEdges,
-				bandwidthHints:  bandwidthHints,
+				bandwidthHints:  reservedHints,
 				graph:           routingGraph,
 			},
 			restrictions, &p.pathFindingConfig,
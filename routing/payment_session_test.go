@@ -236,7 +236,7 @@ func TestRequestRoute(t *testing.T) {
 	}
 
 	route, err := session.RequestRoute(
-		payment.Amount, payment.FeeLimit, 0, height,
+		payment.Amount, payment.FeeLimit, 0, height, nil,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -257,3 +257,157 @@ type sessionGraph struct {
 func (g *sessionGraph) sourceNode() route.Vertex {
 	return route.Vertex{}
 }
+
+// liquidityMissionControl is a mission control mock that estimates the
+// probability of a pair to decrease linearly with the amount, up to the given
+// capacity.
+type liquidityMissionControl struct {
+	mockMissionControl
+
+	capacity lnwire.MilliSatoshi
+}
+
+func (m *liquidityMissionControl) GetProbability(_, _ route.Vertex,
+	amt lnwire.MilliSatoshi) float64 {
+
+	if amt >= m.capacity {
+		return 0
+	}
+
+	return 1 - float64(amt)/float64(m.capacity)
+}
+
+// TestRequestRouteProbabilitySplit tests that the probability split strategy
+// divides the payment into the number of shards with the highest combined
+// success probability, and that the planned routes are returned one by one.
+func TestRequestRouteProbabilitySplit(t *testing.T) {
+	t.Parallel()
+
+	const height = 10
+
+	var (
+		target = route.Vertex{9}
+		b      = route.Vertex{1}
+		c      = route.Vertex{2}
+	)
+
+	features := lnwire.NewFeatureVector(
+		lnwire.NewRawFeatureVector(
+			lnwire.TLVOnionPayloadOptional,
+			lnwire.PaymentAddrOptional,
+			lnwire.MPPOptional,
+		), lnwire.Features,
+	)
+
+	newSession := func(payment *LightningPayment) *paymentSession {
+		var paymentHash [32]byte
+		require.NoError(t, payment.SetPaymentHash(paymentHash))
+
+		session, err := newPaymentSession(
+			payment,
+			func(routingGraph) (bandwidthHints, error) {
+				return &mockBandwidthHints{}, nil
+			},
+			func() (routingGraph, func(), error) {
+				return &sessionGraph{}, func() {}, nil
+			},
+			&liquidityMissionControl{capacity: 1000},
+			PathFindingConfig{},
+		)
+		require.NoError(t, err)
+
+		session.minShardAmt = 100
+
+		// The mock path finder routes either via b or c to the
+		// target, whichever has the highest probability.
+		session.pathFinder = func(_ *graphParams, r *RestrictParams,
+			_ *PathFindingConfig, source, _ route.Vertex,
+			amt lnwire.MilliSatoshi, _ float64,
+			_ int32) ([]*channeldb.CachedEdgePolicy, error) {
+
+			hop, chanID := b, uint64(1)
+			if r.ProbabilitySource(source, c, amt) >
+				r.ProbabilitySource(source, b, amt) {
+
+				hop, chanID = c, 2
+			}
+
+			return []*channeldb.CachedEdgePolicy{
+				{
+					ChannelID: chanID,
+					ToNodePubKey: func() route.Vertex {
+						return hop
+					},
+					ToNodeFeatures: features,
+				},
+				{
+					ChannelID: chanID + 10,
+					ToNodePubKey: func() route.Vertex {
+						return target
+					},
+					ToNodeFeatures: features,
+				},
+			}, nil
+		}
+
+		return session
+	}
+
+	payment := &LightningPayment{
+		Target:         target,
+		CltvLimit:      1000,
+		FinalCLTVDelta: 40,
+		Amount:         800,
+		FeeLimit:       1000,
+		MaxParts:       4,
+		PaymentAddr:    &[32]byte{1},
+		DestFeatures:   features,
+		SplitStrategy:  SplitProbability,
+	}
+	session := newSession(payment)
+
+	// Each hop of a single shard of 800 succeeds with a probability of
+	// 0.2, while each hop of two shards of 400 via b and c succeeds with
+	// 0.6. With four shards of 200, the second shards via b and c only
+	// succeed with 0.6 per hop, given the first ones. We expect the
+	// payment to be split in two.
+	rt, err := session.RequestRoute(payment.Amount, payment.FeeLimit, 0,
+		height, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 400, rt.ReceiverAmt())
+	require.EqualValues(t, 800, rt.FinalHop().MPP.TotalMsat())
+	firstHop := rt.Hops[0].PubKeyBytes
+
+	// The second shard takes the other route of the split.
+	rt, err = session.RequestRoute(400, payment.FeeLimit, 1, height,
+		nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 400, rt.ReceiverAmt())
+	require.NotEqual(t, firstHop, rt.Hops[0].PubKeyBytes)
+	require.Empty(t, session.plan)
+
+	// If a shard fails, the remainder is planned again.
+	rt, err = session.RequestRoute(400, payment.FeeLimit, 1, height,
+		nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 200, rt.ReceiverAmt())
+	require.Len(t, session.plan, 1)
+
+	// A payment that doesn't support mpp isn't split.
+	payment = &LightningPayment{
+		Target:         target,
+		CltvLimit:      1000,
+		FinalCLTVDelta: 40,
+		Amount:         800,
+		FeeLimit:       1000,
+		MaxParts:       4,
+		SplitStrategy:  SplitProbability,
+	}
+	session = newSession(payment)
+
+	rt, err = session.RequestRoute(payment.Amount, payment.FeeLimit, 0,
+		height, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 800, rt.ReceiverAmt())
+	require.Empty(t, session.plan)
+}
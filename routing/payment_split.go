@@ -0,0 +1,322 @@
+package routing
+
+import (
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+)
+
+// SplitStrategy determines how a payment is split into multiple shards.
+type SplitStrategy uint8
+
+const (
+	// SplitHalving first tries to find a route for the full amount. If
+	// no route is found, the amount is halved until a route is found for
+	// it, and the remainder is sent the same way once the shard is
+	// launched.
+	SplitHalving SplitStrategy = iota
+
+	// SplitProbability divides the amount across several routes at once.
+	// The number of shards is chosen such that the combined success
+	// probability of their routes, as estimated by mission control, is
+	// highest. If no split is found, the halving strategy is used.
+	SplitProbability
+)
+
+// String returns a human readable representation of the split strategy.
+func (s SplitStrategy) String() string {
+	switch s {
+	case SplitHalving:
+		return "halving"
+
+	case SplitProbability:
+		return "probability"
+
+	default:
+		return "unknown"
+	}
+}
+
+// reservedBandwidthHints wraps bandwidth hints, reducing the bandwidth of our
+// channels by the amount that is reserved for other shards of a split.
+type reservedBandwidthHints struct {
+	bandwidthHints
+
+	// reserved is the amount reserved per channel.
+	reserved map[uint64]lnwire.MilliSatoshi
+}
+
+// availableChanBandwidth returns the bandwidth of a channel that is not
+// reserved for other shards.
+//
+// NOTE: Part of the bandwidthHints interface.
+func (r *reservedBandwidthHints) availableChanBandwidth(channelID uint64,
+	amount lnwire.MilliSatoshi) (lnwire.MilliSatoshi, bool) {
+
+	bandwidth, ok := r.bandwidthHints.availableChanBandwidth(
+		channelID, amount,
+	)
+	if !ok {
+		return bandwidth, ok
+	}
+
+	reserved := r.reserved[channelID]
+	if reserved >= bandwidth {
+		return 0, true
+	}
+
+	return bandwidth - reserved, true
+}
+
+// splitParams holds the path finding parameters that all routes of a split
+// share.
+type splitParams struct {
+	// restrictions are the path finding restrictions for the payment.
+	restrictions *RestrictParams
+
+	// finalHtlcExpiry is the absolute expiry of the final hop.
+	finalHtlcExpiry int32
+
+	// finalCltvDelta is the cltv delta of the final hop.
+	finalCltvDelta uint16
+
+	// height is the current block height.
+	height uint32
+
+	// reserved is the bandwidth of our channels that is taken by routes
+	// which aren't launched yet.
+	reserved map[uint64]lnwire.MilliSatoshi
+}
+
+// splitAllowed returns true if the payment may be split into multiple shards.
+func (p *paymentSession) splitAllowed() bool {
+	// Don't split if this is a legacy payment without mpp record.
+	if p.payment.PaymentAddr == nil || p.payment.DestFeatures == nil {
+		return false
+	}
+
+	destFeatures := p.payment.DestFeatures
+	return destFeatures.HasFeature(lnwire.MPPOptional) ||
+		destFeatures.HasFeature(lnwire.AMPOptional)
+}
+
+// requestPlannedRoute returns the next route of the split of the amount to
+// send, planning a new split if the amount doesn't match the remainder of the
+// current one. This is the case once a shard of the split failed. Nil is
+// returned if no split is found.
+func (p *paymentSession) requestPlannedRoute(maxAmt,
+	feeLimit lnwire.MilliSatoshi, activeShards uint32,
+	params *splitParams) (*route.Route, error) {
+
+	p.planMtx.Lock()
+	defer p.planMtx.Unlock()
+
+	var plannedAmt lnwire.MilliSatoshi
+	for _, rt := range p.plan {
+		plannedAmt += rt.ReceiverAmt()
+	}
+
+	if plannedAmt != maxAmt || p.planHeight != params.height {
+		plan, err := p.planSplit(maxAmt, feeLimit, activeShards, params)
+		if err != nil {
+			return nil, err
+		}
+
+		p.plan = plan
+		p.planHeight = params.height
+	}
+
+	if len(p.plan) == 0 {
+		return nil, nil
+	}
+
+	rt := p.plan[0]
+	p.plan = p.plan[1:]
+
+	return rt, nil
+}
+
+// planSplit divides the amount into the number of equal shards for which the
+// combined success probability of their routes is highest. As the combined
+// probability usually first rises and then falls with the number of shards,
+// the number of shards is doubled until the probability decreases.
+func (p *paymentSession) planSplit(amt, feeLimit lnwire.MilliSatoshi,
+	activeShards uint32, params *splitParams) ([]*route.Route, error) {
+
+	if activeShards >= p.payment.MaxParts {
+		return nil, nil
+	}
+
+	maxParts := uint32(1)
+	if p.splitAllowed() {
+		maxParts = p.payment.MaxParts - activeShards
+	}
+
+	// Respect the max shard size, which is our client-side MTU.
+	minParts := uint32(1)
+	if p.payment.MaxShardAmt != nil && *p.payment.MaxShardAmt > 0 {
+		maxShardAmt := *p.payment.MaxShardAmt
+		minParts = uint32((amt + maxShardAmt - 1) / maxShardAmt)
+	}
+
+	var (
+		best     []*route.Route
+		bestProb float64
+	)
+	for parts := minParts; parts <= maxParts; {
+		if parts > 1 && amt/lnwire.MilliSatoshi(parts) < p.minShardAmt {
+			break
+		}
+
+		routes, prob, err := p.findSplitRoutes(
+			amt, feeLimit, parts, params,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		p.log.Debugf("Split of %v into %v shards: success "+
+			"probability %v", amt, parts, prob)
+
+		if routes != nil && prob > bestProb {
+			best, bestProb = routes, prob
+		} else if best != nil {
+			break
+		}
+
+		// Double the number of shards, but make sure that the maximum
+		// number of shards is evaluated too.
+		switch {
+		case parts == maxParts:
+			parts++
+
+		case parts*2 > maxParts:
+			parts = maxParts
+
+		default:
+			parts *= 2
+		}
+	}
+
+	if best != nil {
+		p.log.Debugf("Splitting %v into %v shards with success "+
+			"probability %v", amt, len(best), bestProb)
+	}
+
+	return best, nil
+}
+
+// findSplitRoutes finds routes for the given number of equal shards of the
+// amount, returning them along with their combined success probability. The
+// routes are found one after the other. The amounts of the routes found
+// already are reserved on their channels, such that the probabilities and
+// local bandwidth used for the following routes account for them. Nil is
+// returned if no route is found for one of the shards.
+func (p *paymentSession) findSplitRoutes(amt, feeLimit lnwire.MilliSatoshi,
+	parts uint32, params *splitParams) ([]*route.Route, float64, error) {
+
+	routingGraph, cleanup, err := p.getRoutingGraph()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cleanup()
+
+	bandwidthHints, err := p.getBandwidthHints(routingGraph)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	reservedHints := &reservedBandwidthHints{
+		bandwidthHints: bandwidthHints,
+		reserved:       make(map[uint64]lnwire.MilliSatoshi),
+	}
+	for chanID, amt := range params.reserved {
+		reservedHints.reserved[chanID] = amt
+	}
+
+	reservedPairs := make(map[DirectedNodePair]lnwire.MilliSatoshi)
+	probability := func(fromNode, toNode route.Vertex,
+		amt lnwire.MilliSatoshi) float64 {
+
+		pair := NewDirectedNodePair(fromNode, toNode)
+		return p.missionControl.GetProbability(
+			fromNode, toNode, amt+reservedPairs[pair],
+		)
+	}
+
+	restrictions := *params.restrictions
+	restrictions.ProbabilitySource = probability
+
+	var (
+		sourceVertex = routingGraph.sourceNode()
+		shardAmt     = amt / lnwire.MilliSatoshi(parts)
+		routes       = make([]*route.Route, 0, parts)
+		combinedProb = 1.0
+		feesLeft     = feeLimit
+	)
+	for i := uint32(0); i < parts; i++ {
+		// The first shard carries the remainder of the division.
+		amtToSend := shardAmt
+		if i == 0 {
+			amtToSend += amt % lnwire.MilliSatoshi(parts)
+		}
+
+		restrictions.FeeLimit = feesLeft
+
+		path, err := p.pathFinder(
+			&graphParams{
+				additionalEdges: p.additionalEdges,
+				bandwidthHints:  reservedHints,
+				graph:           routingGraph,
+			},
+			&restrictions, &p.pathFindingConfig,
+			sourceVertex, p.payment.Target,
+			amtToSend, p.payment.TimePref, params.finalHtlcExpiry,
+		)
+		switch {
+		case err == errNoPathFound || err == errInsufficientBalance:
+			return nil, 0, nil
+
+		case err != nil:
+			return nil, 0, err
+		}
+
+		rt, err := newRoute(
+			sourceVertex, path, params.height,
+			finalHopParams{
+				amt:         amtToSend,
+				totalAmt:    p.payment.Amount,
+				cltvDelta:   params.finalCltvDelta,
+				records:     p.payment.DestCustomRecords,
+				paymentAddr: p.payment.PaymentAddr,
+				metadata:    p.payment.Metadata,
+			},
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		feesLeft -= rt.TotalFees()
+
+		// Multiply the probability of the route, given the shards
+		// that were routed already, and then reserve its amounts.
+		fromNode := sourceVertex
+		for _, hop := range rt.Hops {
+			combinedProb *= probability(
+				fromNode, hop.PubKeyBytes, hop.AmtToForward,
+			)
+			fromNode = hop.PubKeyBytes
+		}
+
+		fromNode = sourceVertex
+		for _, hop := range rt.Hops {
+			pair := NewDirectedNodePair(fromNode, hop.PubKeyBytes)
+			reservedPairs[pair] += hop.AmtToForward
+			fromNode = hop.PubKeyBytes
+		}
+		reservedHints.reserved[rt.Hops[0].ChannelID] += rt.TotalAmount
+
+		routes = append(routes, rt)
+	}
+
+	return routes, combinedProb, nil
+}
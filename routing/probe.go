@@ -0,0 +1,363 @@
+package routing
+
+import (
+	"crypto/rand"
+	goErrors "errors"
+	"fmt"
+
+	sphinx "github.com/lightningnetwork/lightning-onion"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/htlcswitch"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/lightningnetwork/lnd/routing/shards"
+)
+
+// ErrProbeFailed is returned by ProbeLiquidity if a probe failed for another
+// reason than the liquidity of the probed channel, so that the search can't
+// be continued.
+var ErrProbeFailed = goErrors.New("probe failed for a reason other than " +
+	"the liquidity of the probed channel")
+
+// ProbeResult is the outcome of a probe sent along a route.
+type ProbeResult struct {
+	// Route is the route the probe was sent along.
+	Route *route.Route
+
+	// Success indicates whether the probe reached the destination, which
+	// means that the route was able to carry the amount of the probe at
+	// the time it was sent.
+	Success bool
+
+	// Failure is the failure the probe was resolved with. As nobody knows
+	// the preimage of a probe, a successful probe is failed by the
+	// destination with an incorrect_or_unknown_payment_details failure.
+	// It is nil only if the probe was unexpectedly settled.
+	Failure *channeldb.HTLCFailInfo
+}
+
+// failedAtHop returns true if the probe was failed by the node at the given
+// index of its route with the given failure code. The index of our own node is
+// zero.
+func (p *ProbeResult) failedAtHop(idx int, code lnwire.FailCode) bool {
+	if p.Failure == nil || p.Failure.Reason != channeldb.HTLCFailMessage {
+		return false
+	}
+
+	return p.Failure.FailureSourceIndex == uint32(idx) &&
+		p.Failure.Message.Code() == code
+}
+
+// ProbeRoute sends a probe along the given route and waits for its outcome.
+// A probe is an htlc with a random payment hash, so it can't be settled by
+// the destination. Probes aren't recorded as payments, but their outcome is
+// reported to mission control like that of any other payment attempt.
+func (r *ChannelRouter) ProbeRoute(rt *route.Route) (*ProbeResult, error) {
+	if len(rt.Hops) == 0 {
+		return nil, route.ErrNoRouteHopsProvided
+	}
+
+	var hash lntypes.Hash
+	if _, err := rand.Read(hash[:]); err != nil {
+		return nil, err
+	}
+
+	// The shard handler is only used to create and send the attempt and
+	// to apply channel updates. As the probe isn't known to the control
+	// tower, its result is collected separately.
+	sh := &shardHandler{
+		router:       r,
+		identifier:   hash,
+		shardTracker: shards.NewSimpleShardTracker(hash, nil),
+	}
+
+	firstHop, htlcAdd, attempt, err := sh.createNewPaymentAttempt(
+		rt, false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sendErr := sh.sendPaymentAttempt(attempt, firstHop, htlcAdd)
+	if sendErr == nil {
+		probeResult, err := r.collectProbeResult(attempt, hash)
+		if err != nil {
+			return nil, err
+		}
+
+		sendErr = probeResult.Error
+	}
+
+	result := r.processProbeResult(sh, attempt, sendErr)
+
+	log.Debugf("Probe %v (pid=%v) of %v along route %v: success=%v",
+		hash, attempt.AttemptID, rt.ReceiverAmt(), rt, result.Success)
+
+	return result, nil
+}
+
+// collectProbeResult waits for the switch to resolve the probe.
+func (r *ChannelRouter) collectProbeResult(attempt *channeldb.HTLCAttemptInfo,
+	hash lntypes.Hash) (*htlcswitch.PaymentResult, error) {
+
+	// Regenerate the circuit of the probe to decrypt its failure.
+	_, circuit, err := generateSphinxPacket(
+		&attempt.Route, hash[:], attempt.SessionKey(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	errorDecryptor := &htlcswitch.SphinxErrorDecrypter{
+		OnionErrorDecrypter: sphinx.NewOnionErrorDecrypter(circuit),
+	}
+
+	resultChan, err := r.cfg.Payer.GetPaymentResult(
+		attempt.AttemptID, hash, errorDecryptor,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case result, ok := <-resultChan:
+		if !ok {
+			return nil, htlcswitch.ErrSwitchExiting
+		}
+
+		return result, nil
+
+	case <-r.quit:
+		return nil, ErrRouterShuttingDown
+	}
+}
+
+// processProbeResult applies a channel update contained in the failure of a
+// probe and reports the outcome of the probe to mission control.
+func (r *ChannelRouter) processProbeResult(sh *shardHandler,
+	attempt *channeldb.HTLCAttemptInfo, sendErr error) *ProbeResult {
+
+	result := &ProbeResult{
+		Route: &attempt.Route,
+	}
+
+	// Nobody should know the preimage of the probe, but if it was settled
+	// nevertheless the route did carry the amount.
+	if sendErr == nil {
+		log.Warnf("Probe (pid=%v) was settled", attempt.AttemptID)
+
+		result.Success = true
+		err := r.cfg.MissionControl.ReportPaymentSuccess(
+			attempt.AttemptID, &attempt.Route,
+		)
+		if err != nil {
+			log.Errorf("Error reporting probe success to mc: %v",
+				err)
+		}
+
+		return result
+	}
+
+	result.Failure = marshallError(sendErr, r.cfg.Clock.Now())
+	result.Success = result.failedAtHop(
+		len(attempt.Route.Hops),
+		lnwire.CodeIncorrectOrUnknownPaymentDetails,
+	)
+
+	reportFail := func(srcIdx *int, msg lnwire.FailureMessage) {
+		_, err := r.cfg.MissionControl.ReportPaymentFail(
+			attempt.AttemptID, &attempt.Route, srcIdx, msg,
+		)
+		if err != nil {
+			log.Errorf("Error reporting probe result to mc: %v",
+				err)
+		}
+	}
+
+	if sendErr == htlcswitch.ErrUnreadableFailureMessage {
+		reportFail(nil, nil)
+		return result
+	}
+
+	// Only failures that were returned by a node on the route tell us
+	// something about the route.
+	rtErr, ok := sendErr.(htlcswitch.ClearTextError)
+	if !ok {
+		return result
+	}
+
+	failureSourceIdx := 0
+	if source, ok := rtErr.(*htlcswitch.ForwardingError); ok {
+		failureSourceIdx = source.FailureSourceIdx
+	}
+
+	failureMessage := rtErr.WireMessage()
+	err := sh.handleFailureMessage(
+		&attempt.Route, failureSourceIdx, failureMessage,
+	)
+	if err != nil {
+		log.Errorf("Unable to handle probe failure: %v", err)
+	}
+
+	reportFail(&failureSourceIdx, failureMessage)
+
+	return result
+}
+
+// ProbeDestination probes routes to the target until a probe reaches it, the
+// target fails a probe, no further route is found or maxProbes probes were
+// sent. As the outcome of each probe is reported to mission control, each
+// probe takes a different route. The results of all probes are returned in
+// the order they were sent.
+func (r *ChannelRouter) ProbeDestination(target route.Vertex,
+	amt lnwire.MilliSatoshi, restrictions *RestrictParams,
+	finalExpiry uint16, maxProbes uint32) ([]*ProbeResult, error) {
+
+	var results []*ProbeResult
+	for uint32(len(results)) < maxProbes {
+		rt, err := r.FindRoute(
+			r.selfNode.PubKeyBytes, target, amt, 0, restrictions,
+			nil, nil, finalExpiry,
+		)
+
+		// If there's no route left after probing some, the failed
+		// probes are the answer.
+		if err != nil && len(results) > 0 {
+			log.Debugf("No route left to probe %v: %v", target,
+				err)
+
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := r.ProbeRoute(rt)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+
+		// Stop once the destination was reached, or if the probe
+		// wasn't failed by a node on the route.
+		failure := result.Failure
+		if result.Success || failure == nil ||
+			failure.Reason == channeldb.HTLCFailInternal ||
+			failure.FailureSourceIndex == uint32(len(rt.Hops)) {
+
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// LiquidityProbe describes the channel that is probed by ProbeLiquidity.
+type LiquidityProbe struct {
+	// Hops are the nodes of the route the probes are sent along, not
+	// including our own node. The last hop of the route is the channel
+	// that is probed.
+	Hops []route.Vertex
+
+	// OutgoingChan, if set, is the channel the probes are sent through.
+	OutgoingChan *uint64
+
+	// FinalCltvDelta is the cltv delta of the final hop.
+	FinalCltvDelta int32
+
+	// MinAmt is a known lower bound of the liquidity of the channel.
+	MinAmt lnwire.MilliSatoshi
+
+	// MaxAmt is a known upper bound of the liquidity of the channel, at
+	// most its capacity.
+	MaxAmt lnwire.MilliSatoshi
+
+	// Precision is the width of the range the liquidity is narrowed down
+	// to.
+	Precision lnwire.MilliSatoshi
+
+	// MaxProbes is the maximum number of probes to send.
+	MaxProbes uint32
+}
+
+// LiquidityBound is the range the liquidity of a channel was found to be in.
+type LiquidityBound struct {
+	// MinAmt is the largest amount that the channel was able to forward.
+	MinAmt lnwire.MilliSatoshi
+
+	// MaxAmt is the largest amount the channel may be able to forward.
+	MaxAmt lnwire.MilliSatoshi
+
+	// Probes are the results of all probes, in the order they were sent.
+	Probes []*ProbeResult
+}
+
+// ProbeLiquidity narrows down the liquidity of the last channel of a route by
+// a binary search over the amount of probes sent along the route. The
+// liquidity found is the amount the channel can forward in a single htlc at
+// the time of probing. If a probe fails elsewhere on the route, the search is
+// aborted with ErrProbeFailed, returning the bound found so far.
+func (r *ChannelRouter) ProbeLiquidity(
+	probe *LiquidityProbe) (*LiquidityBound, error) {
+
+	if len(probe.Hops) == 0 {
+		return nil, route.ErrNoRouteHopsProvided
+	}
+	if probe.MinAmt > probe.MaxAmt {
+		return nil, fmt.Errorf("minimum amount %v exceeds maximum "+
+			"amount %v", probe.MinAmt, probe.MaxAmt)
+	}
+
+	bound := &LiquidityBound{
+		MinAmt: probe.MinAmt,
+		MaxAmt: probe.MaxAmt,
+	}
+
+	// The probed channel is forwarded over by the second to last node of
+	// the route.
+	forwardingIdx := len(probe.Hops) - 1
+
+	for bound.MaxAmt-bound.MinAmt > probe.Precision &&
+		uint32(len(bound.Probes)) < probe.MaxProbes {
+
+		amt := bound.MinAmt + (bound.MaxAmt-bound.MinAmt+1)/2
+
+		rt, err := r.BuildRoute(
+			&amt, probe.Hops, probe.OutgoingChan,
+			probe.FinalCltvDelta, nil,
+		)
+
+		// If the channel policies don't allow an htlc of this size,
+		// the channel can't forward it either.
+		var noChanErr ErrNoChannel
+		if goErrors.As(err, &noChanErr) {
+			bound.MaxAmt = amt - 1
+			continue
+		}
+		if err != nil {
+			return bound, err
+		}
+
+		result, err := r.ProbeRoute(rt)
+		if err != nil {
+			return bound, err
+		}
+		bound.Probes = append(bound.Probes, result)
+
+		switch {
+		case result.Success:
+			bound.MinAmt = amt
+
+		case result.failedAtHop(
+			forwardingIdx, lnwire.CodeTemporaryChannelFailure,
+		):
+			bound.MaxAmt = amt - 1
+
+		default:
+			return bound, ErrProbeFailed
+		}
+	}
+
+	return bound, nil
+}
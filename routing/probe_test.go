@@ -0,0 +1,305 @@
+package routing
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/lightningnetwork/lnd/channeldb"
+	"github.com/lightningnetwork/lnd/htlcswitch"
+	"github.com/lightningnetwork/lnd/lntypes"
+	"github.com/lightningnetwork/lnd/lnwire"
+	"github.com/lightningnetwork/lnd/routing/route"
+	"github.com/stretchr/testify/require"
+)
+
+// mockProbeDispatcher is a payment attempt dispatcher that fails all htlcs
+// with the error returned by onProbe.
+type mockProbeDispatcher struct {
+	onProbe func(firstHop lnwire.ShortChannelID,
+		amt lnwire.MilliSatoshi) error
+
+	results map[uint64]*htlcswitch.PaymentResult
+	hashes  []lntypes.Hash
+
+	sync.Mutex
+}
+
+var _ PaymentAttemptDispatcher = (*mockProbeDispatcher)(nil)
+
+func (m *mockProbeDispatcher) SendHTLC(firstHop lnwire.ShortChannelID,
+	pid uint64, htlcAdd *lnwire.UpdateAddHTLC) error {
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.results == nil {
+		m.results = make(map[uint64]*htlcswitch.PaymentResult)
+	}
+
+	m.hashes = append(m.hashes, htlcAdd.PaymentHash)
+	m.results[pid] = &htlcswitch.PaymentResult{
+		Error: m.onProbe(firstHop, htlcAdd.Amount),
+	}
+
+	return nil
+}
+
+func (m *mockProbeDispatcher) GetPaymentResult(pid uint64, _ lntypes.Hash,
+	_ htlcswitch.ErrorDecrypter) (<-chan *htlcswitch.PaymentResult,
+	error) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	result, ok := m.results[pid]
+	if !ok {
+		return nil, htlcswitch.ErrPaymentIDNotFound
+	}
+
+	c := make(chan *htlcswitch.PaymentResult, 1)
+	c <- result
+
+	return c, nil
+}
+
+func (m *mockProbeDispatcher) CleanStore(map[uint64]struct{}) error {
+	return nil
+}
+
+// createProbeTestCtx creates a test context for the given channels, in which
+// probes are resolved by onProbe.
+func createProbeTestCtx(t *testing.T, testChannels []*testChannel,
+	onProbe func(lnwire.ShortChannelID, lnwire.MilliSatoshi) error) (
+	*testCtx, *mockProbeDispatcher) {
+
+	testGraph, err := createTestGraphFromChannels(
+		t, true, testChannels, "a",
+	)
+	require.NoError(t, err)
+
+	ctx := createTestCtxFromGraphInstance(t, 101, testGraph, false)
+
+	dispatcher := &mockProbeDispatcher{onProbe: onProbe}
+	ctx.router.cfg.Payer = dispatcher
+
+	return ctx, dispatcher
+}
+
+// TestProbeRoute tests that probes are sent with random payment hashes, are
+// not recorded as payments and that their outcome is reported to mission
+// control.
+func TestProbeRoute(t *testing.T) {
+	t.Parallel()
+
+	const chanCapSat = btcutil.Amount(100000)
+	policy := &testChannelPolicy{
+		Expiry:  144,
+		MinHTLC: 1,
+		MaxHTLC: lnwire.NewMSatFromSatoshis(chanCapSat),
+	}
+	testChannels := []*testChannel{
+		symmetricTestChannel("a", "b", chanCapSat, policy, 1),
+		symmetricTestChannel("b", "c", chanCapSat, policy, 2),
+	}
+
+	// The probes are failed by node c, unless they exceed the amount that
+	// node b can forward to it.
+	const liquidity = lnwire.MilliSatoshi(50_000_000)
+	ctx, dispatcher := createProbeTestCtx(
+		t, testChannels, func(_ lnwire.ShortChannelID,
+			amt lnwire.MilliSatoshi) error {
+
+			if amt > liquidity {
+				return htlcswitch.NewForwardingError(
+					&lnwire.FailTemporaryChannelFailure{},
+					1,
+				)
+			}
+
+			return htlcswitch.NewForwardingError(
+				lnwire.NewFailIncorrectDetails(amt, 100), 2,
+			)
+		},
+	)
+
+	hops := []route.Vertex{ctx.aliases["b"], ctx.aliases["c"]}
+	mc := ctx.router.cfg.MissionControl.(*MissionControl)
+
+	amt := liquidity
+	rt, err := ctx.router.BuildRoute(&amt, hops, nil, 40, nil)
+	require.NoError(t, err)
+
+	result, err := ctx.router.ProbeRoute(rt)
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	require.EqualValues(t, 2, result.Failure.FailureSourceIndex)
+
+	pair := mc.GetPairHistorySnapshot(ctx.aliases["b"], ctx.aliases["c"])
+	require.Equal(t, liquidity, pair.SuccessAmt)
+
+	amt = liquidity + 1
+	rt, err = ctx.router.BuildRoute(&amt, hops, nil, 40, nil)
+	require.NoError(t, err)
+
+	result, err = ctx.router.ProbeRoute(rt)
+	require.NoError(t, err)
+	require.False(t, result.Success)
+	require.EqualValues(t, 1, result.Failure.FailureSourceIndex)
+	require.Equal(t, channeldb.HTLCFailMessage, result.Failure.Reason)
+
+	pair = mc.GetPairHistorySnapshot(ctx.aliases["b"], ctx.aliases["c"])
+	require.Equal(t, liquidity+1, pair.FailAmt)
+
+	// Each probe used a different payment hash, and none was recorded as
+	// a payment.
+	require.Len(t, dispatcher.hashes, 2)
+	require.NotEqual(t, dispatcher.hashes[0], dispatcher.hashes[1])
+
+	control := ctx.router.cfg.Control.(*mockControlTowerOld)
+	require.Empty(t, control.payments)
+}
+
+// TestProbeDestination tests that routes to the destination are probed until
+// a probe reaches it.
+func TestProbeDestination(t *testing.T) {
+	t.Parallel()
+
+	const chanCapSat = btcutil.Amount(100000)
+	policy := func(feeRate lnwire.MilliSatoshi) *testChannelPolicy {
+		return &testChannelPolicy{
+			Expiry:  144,
+			FeeRate: feeRate,
+			MinHTLC: 1,
+			MaxHTLC: lnwire.NewMSatFromSatoshis(chanCapSat),
+		}
+	}
+
+	// The route via b is cheaper, but b can't forward the probe.
+	testChannels := []*testChannel{
+		symmetricTestChannel("a", "b", chanCapSat, policy(100), 1),
+		symmetricTestChannel("b", "d", chanCapSat, policy(100), 2),
+		symmetricTestChannel("a", "c", chanCapSat, policy(500), 3),
+		symmetricTestChannel("c", "d", chanCapSat, policy(500), 4),
+	}
+
+	ctx, _ := createProbeTestCtx(
+		t, testChannels, func(firstHop lnwire.ShortChannelID,
+			amt lnwire.MilliSatoshi) error {
+
+			if firstHop.ToUint64() == 1 {
+				return htlcswitch.NewForwardingError(
+					&lnwire.FailTemporaryChannelFailure{},
+					1,
+				)
+			}
+
+			return htlcswitch.NewForwardingError(
+				lnwire.NewFailIncorrectDetails(amt, 100), 2,
+			)
+		},
+	)
+
+	mc := ctx.router.cfg.MissionControl.(*MissionControl)
+	restrictions := &RestrictParams{
+		FeeLimit:          lnwire.NewMSatFromSatoshis(1000),
+		CltvLimit:         2016,
+		ProbabilitySource: mc.GetProbability,
+	}
+
+	results, err := ctx.router.ProbeDestination(
+		ctx.aliases["d"], 10_000_000, restrictions, 40, 5,
+	)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	require.False(t, results[0].Success)
+	require.EqualValues(t, 1, results[0].Route.Hops[0].ChannelID)
+
+	require.True(t, results[1].Success)
+	require.EqualValues(t, 3, results[1].Route.Hops[0].ChannelID)
+
+	// The probe limit is respected.
+	require.NoError(t, mc.ResetHistory())
+	results, err = ctx.router.ProbeDestination(
+		ctx.aliases["d"], 10_000_000, restrictions, 40, 1,
+	)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.False(t, results[0].Success)
+}
+
+// TestProbeLiquidity tests that the liquidity of a channel is found by a
+// binary search over the probe amount.
+func TestProbeLiquidity(t *testing.T) {
+	t.Parallel()
+
+	const chanCapSat = btcutil.Amount(100000)
+	policy := &testChannelPolicy{
+		Expiry:  144,
+		MinHTLC: 1,
+		MaxHTLC: lnwire.NewMSatFromSatoshis(chanCapSat),
+	}
+	testChannels := []*testChannel{
+		symmetricTestChannel("a", "b", chanCapSat, policy, 1),
+		symmetricTestChannel("b", "c", chanCapSat, policy, 2),
+		symmetricTestChannel("a", "d", chanCapSat, policy, 3),
+		symmetricTestChannel("d", "c", chanCapSat, policy, 4),
+	}
+
+	// Channel b->c has the given liquidity, while d fails all probes.
+	const liquidity = lnwire.MilliSatoshi(37_123_456)
+	ctx, _ := createProbeTestCtx(
+		t, testChannels, func(firstHop lnwire.ShortChannelID,
+			amt lnwire.MilliSatoshi) error {
+
+			if firstHop.ToUint64() == 3 {
+				return htlcswitch.NewForwardingError(
+					&lnwire.FailUnknownNextPeer{}, 1,
+				)
+			}
+
+			if amt > liquidity {
+				return htlcswitch.NewForwardingError(
+					&lnwire.FailTemporaryChannelFailure{},
+					1,
+				)
+			}
+
+			return htlcswitch.NewForwardingError(
+				lnwire.NewFailIncorrectDetails(amt, 100), 2,
+			)
+		},
+	)
+
+	capacity := lnwire.NewMSatFromSatoshis(chanCapSat)
+	hops := []route.Vertex{ctx.aliases["b"], ctx.aliases["c"]}
+	probe := &LiquidityProbe{
+		Hops:           hops,
+		FinalCltvDelta: 40,
+		MaxAmt:         capacity,
+		Precision:      1000,
+		MaxProbes:      30,
+	}
+
+	bound, err := ctx.router.ProbeLiquidity(probe)
+	require.NoError(t, err)
+	require.LessOrEqual(t, bound.MinAmt, liquidity)
+	require.GreaterOrEqual(t, bound.MaxAmt, liquidity)
+	require.LessOrEqual(t, bound.MaxAmt-bound.MinAmt, probe.Precision)
+	require.Less(t, len(bound.Probes), 20)
+
+	// With fewer probes, the bound is less precise.
+	probe.MaxProbes = 3
+	bound, err = ctx.router.ProbeLiquidity(probe)
+	require.NoError(t, err)
+	require.Len(t, bound.Probes, 3)
+	require.LessOrEqual(t, bound.MinAmt, liquidity)
+	require.GreaterOrEqual(t, bound.MaxAmt, liquidity)
+	require.Greater(t, bound.MaxAmt-bound.MinAmt, probe.Precision)
+
+	// Probes failing before the probed channel abort the search.
+	probe.Hops = []route.Vertex{ctx.aliases["d"], ctx.aliases["c"]}
+	_, err = ctx.router.ProbeLiquidity(probe)
+	require.ErrorIs(t, err, ErrProbeFailed)
+}
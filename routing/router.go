@@ -244,6 +244,11 @@ type FeeSchema struct {
 	// the effective fee rate charged per mSAT will be: (amount *
 	// FeeRate/1,000,000).
 	FeeRate uint32
+
+	// InboundFee is the fee, possibly negative, that is charged for
+	// payments arriving over the channel. If nil, the current inbound fee
+	// is left unchanged.
+	InboundFee *lnwire.Fee
 }
 
 // ChannelPolicy holds the parameters that determine the policy we enforce
@@ -362,6 +367,10 @@ type Config struct {
 	// IsAlias returns whether a passed ShortChannelID is an alias. This is
 	// only used for our local channels.
 	IsAlias func(scid lnwire.ShortChannelID) bool
+
+	// RequirePaymentInterceptor rejects outgoing payments while no
+	// payment interceptor is registered.
+	RequirePaymentInterceptor bool
 }
 
 // EdgeLocator is a struct used to identify a specific edge.
@@ -446,6 +455,13 @@ type ChannelRouter struct {
 	// announcements over a window of defaultStatInterval.
 	stats *routerStats
 
+	// paymentInterceptorMtx locks paymentInterceptor.
+	paymentInterceptorMtx sync.RWMutex
+
+	// paymentInterceptor decides about outgoing payments before they are
+	// dispatched, if it is set.
+	paymentInterceptor PaymentInterceptor
+
 	sync.RWMutex
 
 	quit chan struct{}
@@ -668,8 +684,8 @@ func (r *ChannelRouter) Start() error {
 			// be tried.
 			_, _, err := r.sendPayment(
 				payment.Info.Value, 0,
-				payment.Info.PaymentIdentifier, 0, paySession,
-				shardTracker,
+				payment.Info.PaymentIdentifier, 0, 0,
+				paySession, shardTracker,
 			)
 			if err != nil {
 				log.Errorf("Resuming payment %v failed: %v.",
@@ -1985,6 +2001,15 @@ type LightningPayment struct {
 	// Metadata is additional data that is sent along with the payment to
 	// the payee.
 	Metadata []byte
+
+	// SplitStrategy determines how the payment is split into multiple
+	// shards.
+	SplitStrategy SplitStrategy
+
+	// MaxShardsInFlight is the maximum number of shards that are in flight
+	// at the same time. Zero means that the number of shards is only
+	// limited by MaxParts.
+	MaxShardsInFlight uint32
 }
 
 // AMPOptions houses information that must be known in order to send an AMP
@@ -2049,7 +2074,8 @@ func (r *ChannelRouter) SendPayment(payment *LightningPayment) ([32]byte,
 	// for the existing attempt.
 	return r.sendPayment(
 		payment.Amount, payment.FeeLimit, payment.Identifier(),
-		payment.PayAttemptTimeout, paySession, shardTracker,
+		payment.PayAttemptTimeout, payment.MaxShardsInFlight,
+		paySession, shardTracker,
 	)
 }
 
@@ -2072,7 +2098,8 @@ func (r *ChannelRouter) SendPaymentAsync(payment *LightningPayment) error {
 
 		_, _, err := r.sendPayment(
 			payment.Amount, payment.FeeLimit, payment.Identifier(),
-			payment.PayAttemptTimeout, paySession, shardTracker,
+			payment.PayAttemptTimeout, payment.MaxShardsInFlight,
+			paySession, shardTracker,
 		)
 		if err != nil {
 			log.Errorf("Payment %x failed: %v",
@@ -2105,10 +2132,14 @@ func spewPayment(payment *LightningPayment) logClosure {
 }
 
 // preparePayment creates the payment session and registers the payment with the
-// control tower.
+// control tower, once the payment interceptor approved the payment.
 func (r *ChannelRouter) preparePayment(payment *LightningPayment) (
 	PaymentSession, shards.ShardTracker, error) {
 
+	if err := r.interceptLightningPayment(payment); err != nil {
+		return nil, nil, err
+	}
+
 	// Before starting the HTLC routing attempt, we'll create a fresh
 	// payment session which will report our errors back to mission
 	// control.
@@ -2202,6 +2233,11 @@ func (r *ChannelRouter) sendToRoute(htlcHash lntypes.Hash, rt *route.Route,
 		paymentIdentifier = amp.SetID()
 	}
 
+	err := r.interceptRoutePayment(paymentIdentifier, amt, rt)
+	if err != nil {
+		return nil, err
+	}
+
 	// Record this payment hash with the ControlTower, ensuring it is not
 	// already in-flight.
 	info := &channeldb.PaymentCreationInfo{
@@ -2211,7 +2247,7 @@ func (r *ChannelRouter) sendToRoute(htlcHash lntypes.Hash, rt *route.Route,
 		PaymentRequest:    nil,
 	}
 
-	err := r.cfg.Control.InitPayment(paymentIdentifier, info)
+	err = r.cfg.Control.InitPayment(paymentIdentifier, info)
 	switch {
 	// If this is an MPP attempt and the hash is already registered with
 	// the database, we can go on to launch the shard.
@@ -2335,7 +2371,8 @@ func (r *ChannelRouter) sendToRoute(htlcHash lntypes.Hash, rt *route.Route,
 // the ControlTower.
 func (r *ChannelRouter) sendPayment(
 	totalAmt, feeLimit lnwire.MilliSatoshi, identifier lntypes.Hash,
-	timeout time.Duration, paySession PaymentSession,
+	timeout time.Duration, maxShardsInFlight uint32,
+	paySession PaymentSession,
 	shardTracker shards.ShardTracker) ([32]byte, *route.Route, error) {
 
 	// We'll also fetch the current block height so we can properly
@@ -2355,6 +2392,8 @@ func (r *ChannelRouter) sendPayment(
 		paySession:    paySession,
 		shardTracker:  shardTracker,
 		currentHeight: currentHeight,
+
+		maxShardsInFlight: maxShardsInFlight,
 	}
 
 	// If a timeout is specified, create a timeout channel. If no timeout is
@@ -2763,6 +2802,9 @@ func (r *ChannelRouter) BuildRoute(amt *lnwire.MilliSatoshi,
 	}
 
 	// Traverse hops backwards to accumulate fees in the running amounts.
+	// We keep track of the outbound fee of the to node, as it limits the
+	// discount its inbound fee can grant.
+	var nextOutboundFee lnwire.MilliSatoshi
 	source := r.selfNode.PubKeyBytes
 	for i := len(hops) - 1; i >= 0; i-- {
 		toNode := hops[i]
@@ -2812,9 +2854,22 @@ func (r *ChannelRouter) BuildRoute(amt *lnwire.MilliSatoshi,
 			}
 		}
 
+		// Add the inbound fee the to node charges for this channel.
+		// The final hop doesn't forward, so it doesn't charge one.
+		if i != len(hops)-1 {
+			inboundFee := cappedInboundFee(
+				policy.InboundFee, runningAmt, nextOutboundFee,
+			)
+			runningAmt = lnwire.MilliSatoshi(
+				int64(runningAmt) + inboundFee,
+			)
+		}
+
 		// Add fee for this hop.
+		nextOutboundFee = 0
 		if !localChan {
-			runningAmt += policy.ComputeFee(runningAmt)
+			nextOutboundFee = policy.ComputeFee(runningAmt)
+			runningAmt += nextOutboundFee
 		}
 
 		log.Tracef("Select channel %v at position %v", policy.ChannelID, i)
@@ -2839,8 +2894,8 @@ func (r *ChannelRouter) BuildRoute(amt *lnwire.MilliSatoshi,
 
 		if i > 0 {
 			// Decrease the amount to send while going forward.
-			receiverAmt -= policy.ComputeFeeFromIncoming(
-				receiverAmt,
+			receiverAmt = forwardAmtFromIncoming(
+				receiverAmt, policy, pathEdges[i-1].InboundFee,
 			)
 		}
 
@@ -1,6 +1,8 @@
 package routing
 
 import (
+	"math"
+
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/lightningnetwork/lnd/channeldb"
 	"github.com/lightningnetwork/lnd/lnwire"
@@ -206,7 +208,7 @@ func (u *unifiedPolicy) getPolicyNetwork(
 
 	var (
 		bestPolicy  *channeldb.CachedEdgePolicy
-		maxFee      lnwire.MilliSatoshi
+		maxFee      int64 = math.MinInt64
 		maxTimelock uint16
 	)
 
@@ -230,8 +232,11 @@ func (u *unifiedPolicy) getPolicyNetwork(
 		}
 
 		// Use the policy that results in the highest fee for this
-		// specific amount.
-		fee := edge.policy.ComputeFee(amt)
+		// specific amount. The inbound fee of the to node is taken
+		// into account as well, as it depends on the channel the
+		// forwarding node picks.
+		fee := int64(edge.policy.ComputeFee(amt)) +
+			edge.policy.InboundFee.CalcFee(amt)
 		if fee < maxFee {
 			continue
 		}
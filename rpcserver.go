@@ -64,6 +64,7 @@ import (
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/macaroons"
 	"github.com/lightningnetwork/lnd/peer"
+	"github.com/lightningnetwork/lnd/peerban"
 	"github.com/lightningnetwork/lnd/peernotifier"
 	"github.com/lightningnetwork/lnd/record"
 	"github.com/lightningnetwork/lnd/routing"
@@ -534,6 +535,10 @@ func MainRPCServerPermissions() map[string][]bakery.Op {
 			Entity: "offchain",
 			Action: "write",
 		}},
+		"/lnrpc.Lightning/ReloadChannelAcceptRules": {{
+			Entity: "offchain",
+			Action: "write",
+		}},
 		"/lnrpc.Lightning/BakeMacaroon": {{
 			Entity: "macaroon",
 			Action: "generate",
@@ -554,6 +559,26 @@ func MainRPCServerPermissions() map[string][]bakery.Op {
 			Entity: "macaroon",
 			Action: "read",
 		}},
+		"/lnrpc.Lightning/ListGossipScores": {{
+			Entity: "peers",
+			Action: "read",
+		}},
+		"/lnrpc.Lightning/BanPeer": {{
+			Entity: "peers",
+			Action: "write",
+		}},
+		"/lnrpc.Lightning/UnbanPeer": {{
+			Entity: "peers",
+			Action: "write",
+		}},
+		"/lnrpc.Lightning/ListBans": {{
+			Entity: "peers",
+			Action: "read",
+		}},
+		"/lnrpc.Lightning/ListPeerQuality": {{
+			Entity: "peers",
+			Action: "read",
+		}},
 		"/lnrpc.Lightning/SubscribePeerEvents": {{
 			Entity: "peers",
 			Action: "read",
@@ -619,6 +644,10 @@ type rpcServer struct {
 	// method.
 	chanPredicate chanacceptor.MultiplexAcceptor
 
+	// chanRuleAcceptor applies the configured channel accept rules. It is
+	// nil if no rules are configured.
+	chanRuleAcceptor *chanacceptor.RuleAcceptor
+
 	quit chan struct{}
 
 	// macService is the macaroon service that we need to mint new
@@ -681,7 +710,8 @@ func newRPCServer(cfg *Config, interceptorChain *rpcperms.InterceptorChain,
 func (r *rpcServer) addDeps(s *server, macService *macaroons.Service,
 	subServerCgs *subRPCServerConfigs, atpl *autopilot.Manager,
 	invoiceRegistry *invoices.InvoiceRegistry, tower *watchtower.Standalone,
-	chanPredicate chanacceptor.MultiplexAcceptor) error {
+	chanPredicate chanacceptor.MultiplexAcceptor,
+	chanRuleAcceptor *chanacceptor.RuleAcceptor) error {
 
 	// Set up router rpc backend.
 	selfNode, err := s.graphDB.SourceNode()
@@ -760,8 +790,10 @@ func (r *rpcServer) addDeps(s *server, macService *macaroons.Service,
 	err = subServerCgs.PopulateDependencies(
 		r.cfg, s.cc, r.cfg.networkDir, macService, atpl, invoiceRegistry,
 		s.htlcSwitch, r.cfg.ActiveNetParams.Params, s.chanRouter,
-		routerBackend, s.nodeSigner, s.graphDB, s.chanStateDB,
-		s.sweeper, tower, s.towerClient, s.anchorTowerClient,
+		routerBackend, s.feeAutopilot, s.signPolicy, s.nodeSigner,
+		s.graphDB,
+		s.chanStateDB, s.sweeper, tower, s.towerClient,
+		s.anchorTowerClient,
 		r.cfg.net.ResolveTCPAddr, genInvoiceFeatures,
 		genAmpInvoiceFeatures, getNodeAnnouncement,
 		s.updateAndBrodcastSelfNode, parseAddr, rpcsLog,
@@ -834,6 +866,7 @@ func (r *rpcServer) addDeps(s *server, macService *macaroons.Service,
 	r.subServers = subServers
 	r.routerBackend = routerBackend
 	r.chanPredicate = chanPredicate
+	r.chanRuleAcceptor = chanRuleAcceptor
 	r.macService = macService
 	r.selfNode = selfNode.PubKeyBytes
 
@@ -1382,6 +1415,19 @@ func (r *rpcServer) SendCoins(ctx context.Context,
 			return nil, err
 		}
 
+		// The amount swept is only known now, so we check it against
+		// the spending limits of the macaroon before publishing.
+		if r.macService != nil {
+			err := r.checkSweepAllSpendingLimit(
+				ctx, sweepTxPkg.SweepTx, targetAddr,
+			)
+			if err != nil {
+				sweepTxPkg.CancelSweepAttempt()
+
+				return nil, err
+			}
+		}
+
 		rpcsLog.Debugf("Sweeping all coins from wallet to addr=%v, "+
 			"with tx=%v", in.Addr, spew.Sdump(sweepTxPkg.SweepTx))
 
@@ -1399,6 +1445,16 @@ func (r *rpcServer) SendCoins(ctx context.Context,
 		sweepTXID := sweepTxPkg.SweepTx.TxHash()
 		txid = &sweepTXID
 	} else {
+		// Make sure the amount doesn't exceed the spending limits of
+		// the macaroon.
+		if r.macService != nil {
+			err := r.macService.CheckOnChainSend(
+				ctx, btcutil.Amount(in.Amount),
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
 
 		// We'll now construct out payment map, and use the wallet's
 		// coin selection synchronization method to ensure that no coin
@@ -1427,6 +1483,27 @@ func (r *rpcServer) SendCoins(ctx context.Context,
 	return &lnrpc.SendCoinsResponse{Txid: txid.String()}, nil
 }
 
+// checkSweepAllSpendingLimit checks the amount that the sweep transaction
+// sends to the target address against the spending limits of the macaroon of
+// the request in the given context.
+func (r *rpcServer) checkSweepAllSpendingLimit(ctx context.Context,
+	sweepTx *wire.MsgTx, targetAddr btcutil.Address) error {
+
+	targetScript, err := txscript.PayToAddrScript(targetAddr)
+	if err != nil {
+		return err
+	}
+
+	var sweptAmt btcutil.Amount
+	for _, txOut := range sweepTx.TxOut {
+		if bytes.Equal(txOut.PkScript, targetScript) {
+			sweptAmt += btcutil.Amount(txOut.Value)
+		}
+	}
+
+	return r.macService.CheckOnChainSend(ctx, sweptAmt)
+}
+
 // SendMany handles a request for a transaction create multiple specified
 // outputs in parallel.
 func (r *rpcServer) SendMany(ctx context.Context,
@@ -1456,6 +1533,20 @@ func (r *rpcServer) SendMany(ctx context.Context,
 	rpcsLog.Infof("[sendmany] outputs=%v, sat/kw=%v",
 		spew.Sdump(in.AddrToAmount), int64(feePerKw))
 
+	// Make sure the total amount doesn't exceed the spending limits of the
+	// macaroon.
+	if r.macService != nil {
+		var totalAmt btcutil.Amount
+		for _, amt := range in.AddrToAmount {
+			totalAmt += btcutil.Amount(amt)
+		}
+
+		err := r.macService.CheckOnChainSend(ctx, totalAmt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var txid *chainhash.Hash
 
 	// We'll attempt to send to the target set of outputs, ensuring that we
@@ -2884,9 +2975,48 @@ func (r *rpcServer) GetInfo(_ context.Context,
 		SyncedToGraph:          isGraphSynced,
 		Features:               features,
 		RequireHtlcInterceptor: r.cfg.RequireInterceptor,
+		BackupSinks:            r.rpcBackupSinkStatuses(),
 	}, nil
 }
 
+// rpcBackupSinkStatuses returns the upload status of all remote backup sinks.
+func (r *rpcServer) rpcBackupSinkStatuses() []*lnrpc.BackupSinkStatus {
+	if r.server.backupUploader == nil {
+		return nil
+	}
+
+	// unixTimestamp returns the unix timestamp of the time, or zero if the
+	// time is unset.
+	unixTimestamp := func(t time.Time) int64 {
+		if t.IsZero() {
+			return 0
+		}
+
+		return t.Unix()
+	}
+
+	statuses := r.server.backupUploader.SinkStatuses()
+	rpcStatuses := make([]*lnrpc.BackupSinkStatus, 0, len(statuses))
+	for _, status := range statuses {
+		var lastErr string
+		if status.LastError != nil {
+			lastErr = status.LastError.Error()
+		}
+
+		rpcStatuses = append(rpcStatuses, &lnrpc.BackupSinkStatus{
+			Name:                 status.Name,
+			LastAttemptTimestamp: unixTimestamp(status.LastAttempt),
+			LastSuccessTimestamp: unixTimestamp(status.LastSuccess),
+			LastError:            lastErr,
+			NumFailedAttempts:    status.NumFailedAttempts,
+			Pending:              status.Pending,
+			LastVersion:          status.LastVersion,
+		})
+	}
+
+	return rpcStatuses
+}
+
 // GetRecoveryInfo returns a boolean indicating whether the wallet is started
 // in recovery mode, whether the recovery is finished, and the progress made
 // so far.
@@ -3043,6 +3173,256 @@ func (r *rpcServer) ListPeers(ctx context.Context,
 	return resp, nil
 }
 
+// ListGossipScores returns the gossip accounting of our peers.
+func (r *rpcServer) ListGossipScores(_ context.Context,
+	_ *lnrpc.ListGossipScoresRequest) (*lnrpc.ListGossipScoresResponse,
+	error) {
+
+	scores := r.server.authGossiper.PeerGossipScores()
+
+	resp := &lnrpc.ListGossipScoresResponse{
+		Scores: make([]*lnrpc.GossipPeerScore, 0, len(scores)),
+	}
+	for _, score := range scores {
+		rpcScore := &lnrpc.GossipPeerScore{
+			PubKey:           score.Peer.String(),
+			Invalid:          score.Invalid,
+			Stale:            score.Stale,
+			Duplicate:        score.Duplicate,
+			RateLimited:      score.RateLimited,
+			BandwidthDropped: score.BandwidthDropped,
+			BanScore:         score.BanScore,
+			Banned:           !score.BannedUntil.IsZero(),
+		}
+		if rpcScore.Banned {
+			rpcScore.BannedUntil = score.BannedUntil.Unix()
+		}
+
+		resp.Scores = append(resp.Scores, rpcScore)
+	}
+
+	return resp, nil
+}
+
+// parseBanTarget parses the target of a ban from the given public key or IP
+// range, exactly one of which must be set.
+func parseBanTarget(pubKeyStr, ipRange string) (*btcec.PublicKey,
+	*net.IPNet, error) {
+
+	switch {
+	case pubKeyStr != "" && ipRange != "":
+		return nil, nil, errors.New("only one of pub_key and " +
+			"ip_range can be set")
+
+	case pubKeyStr != "":
+		pubKeyBytes, err := hex.DecodeString(pubKeyStr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to decode "+
+				"pubkey: %v", err)
+		}
+		pubKey, err := btcec.ParsePubKey(pubKeyBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to parse "+
+				"pubkey: %v", err)
+		}
+
+		return pubKey, nil, nil
+
+	case ipRange != "":
+		ipNet, err := peerban.ParseIPNet(ipRange)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return nil, ipNet, nil
+
+	default:
+		return nil, nil, errors.New("either pub_key or ip_range " +
+			"must be set")
+	}
+}
+
+// marshallPeerBan converts a ban into its RPC representation.
+func marshallPeerBan(ban *channeldb.PeerBan) *lnrpc.PeerBan {
+	rpcBan := &lnrpc.PeerBan{
+		Reason:    ban.Reason,
+		CreatedAt: ban.CreatedAt.Unix(),
+	}
+	if ban.Node != nil {
+		rpcBan.PubKey = ban.Node.String()
+	}
+	if ban.IPNet != nil {
+		rpcBan.IpRange = ban.IPNet.String()
+	}
+	if !ban.Expiry.IsZero() {
+		rpcBan.ExpiresAt = ban.Expiry.Unix()
+	}
+
+	return rpcBan
+}
+
+// BanPeer bans a node or an IP range from connecting to us.
+func (r *rpcServer) BanPeer(_ context.Context,
+	in *lnrpc.BanPeerRequest) (*lnrpc.BanPeerResponse, error) {
+
+	pubKey, ipNet, err := parseBanTarget(in.PubKey, in.IpRange)
+	if err != nil {
+		return nil, err
+	}
+
+	// We can't ban ourselves.
+	if pubKey != nil && pubKey.IsEqual(r.server.identityECDH.PubKey()) {
+		return nil, errors.New("cannot ban own node")
+	}
+
+	if in.DurationSec > uint64(math.MaxInt64/time.Second) {
+		return nil, fmt.Errorf("ban duration of %v seconds too long",
+			in.DurationSec)
+	}
+	duration := time.Duration(in.DurationSec) * time.Second
+
+	var ban *channeldb.PeerBan
+	if pubKey != nil {
+		ban, err = r.server.BanNode(pubKey, duration, in.Reason)
+	} else {
+		ban, err = r.server.BanIPNet(ipNet, duration, in.Reason)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.BanPeerResponse{
+		Ban: marshallPeerBan(ban),
+	}, nil
+}
+
+// UnbanPeer lifts the ban of a node or an IP range.
+func (r *rpcServer) UnbanPeer(_ context.Context,
+	in *lnrpc.UnbanPeerRequest) (*lnrpc.UnbanPeerResponse, error) {
+
+	pubKey, ipNet, err := parseBanTarget(in.PubKey, in.IpRange)
+	if err != nil {
+		return nil, err
+	}
+
+	if pubKey != nil {
+		err = r.server.banMgr.UnbanNode(route.NewVertex(pubKey))
+	} else {
+		err = r.server.banMgr.UnbanIPNet(ipNet)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.UnbanPeerResponse{}, nil
+}
+
+// ListBans returns all nodes and IP ranges that are currently banned.
+func (r *rpcServer) ListBans(_ context.Context,
+	_ *lnrpc.ListBansRequest) (*lnrpc.ListBansResponse, error) {
+
+	bans, err := r.server.banMgr.Bans()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &lnrpc.ListBansResponse{
+		Bans: make([]*lnrpc.PeerBan, 0, len(bans)),
+	}
+	for _, ban := range bans {
+		resp.Bans = append(resp.Bans, marshallPeerBan(ban))
+	}
+
+	return resp, nil
+}
+
+// ListPeerQuality returns the quality of our connections to our peers, or to
+// a single peer if requested.
+func (r *rpcServer) ListPeerQuality(_ context.Context,
+	req *lnrpc.ListPeerQualityRequest) (*lnrpc.ListPeerQualityResponse,
+	error) {
+
+	// The event store only serves requests once the server has started.
+	if !r.server.Started() {
+		return nil, ErrServerNotActive
+	}
+
+	var (
+		quality map[route.Vertex]*chanfitness.ConnectionQuality
+		err     error
+	)
+	if req.PubKey != "" {
+		vertex, err := route.NewVertexFromStr(req.PubKey)
+		if err != nil {
+			return nil, err
+		}
+
+		peerQuality, err := r.server.chanEventStore.GetConnQuality(
+			vertex,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		quality = map[route.Vertex]*chanfitness.ConnectionQuality{
+			vertex: peerQuality,
+		}
+	} else {
+		quality, err = r.server.chanEventStore.ListConnQuality()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &lnrpc.ListPeerQualityResponse{
+		Peers: make([]*lnrpc.PeerQuality, 0, len(quality)),
+	}
+	for vertex, peerQuality := range quality {
+		resp.Peers = append(
+			resp.Peers, marshallPeerQuality(vertex, peerQuality),
+		)
+	}
+
+	sort.Slice(resp.Peers, func(i, j int) bool {
+		return resp.Peers[i].PubKey < resp.Peers[j].PubKey
+	})
+
+	return resp, nil
+}
+
+// marshallPeerQuality converts the connection quality of a peer to its RPC
+// representation.
+func marshallPeerQuality(vertex route.Vertex,
+	quality *chanfitness.ConnectionQuality) *lnrpc.PeerQuality {
+
+	rpcQuality := &lnrpc.PeerQuality{
+		PubKey:        vertex.String(),
+		Online:        quality.Online,
+		LastPingTime:  quality.LastPingLatency.Microseconds(),
+		AvgPingTime:   quality.AvgPingLatency.Microseconds(),
+		MsgsSent:      quality.Traffic.MsgsSent,
+		MsgsRecv:      quality.Traffic.MsgsReceived,
+		BytesSent:     quality.Traffic.BytesSent,
+		BytesRecv:     quality.Traffic.BytesReceived,
+		ConnectedSecs: int64(quality.ConnectedTime.Seconds()),
+		BytesPerSec:   quality.BytesPerSecond(),
+		MsgsPerSec:    quality.MsgsPerSecond(),
+		Disconnects:   make(map[string]uint32),
+		FlapCount:     int32(quality.FlapCount),
+	}
+
+	for reason, count := range quality.Disconnects {
+		rpcQuality.Disconnects[reason.String()] = count
+	}
+
+	if !quality.LastDisconnectTime.IsZero() {
+		rpcQuality.LastDisconnect = quality.LastDisconnect.String()
+		rpcQuality.LastDisconnectTime = quality.LastDisconnectTime.Unix()
+	}
+
+	return rpcQuality
+}
+
 // SubscribePeerEvents returns a uni-directional stream (server -> client)
 // for notifying the client of peer online and offline events.
 func (r *rpcServer) SubscribePeerEvents(req *lnrpc.PeerEventSubscription,
@@ -4647,6 +5027,7 @@ func (r *rpcServer) SubscribeChannelEvents(req *lnrpc.ChannelEventSubscription,
 // execute sendPayment. We use this struct as a sort of bridge to enable code
 // re-use between SendPayment and SendToRoute.
 type paymentStream struct {
+	ctx  context.Context
 	recv func() (*rpcPaymentRequest, error)
 	send func(*lnrpc.SendResponse) error
 }
@@ -4666,6 +5047,7 @@ func (r *rpcServer) SendPayment(stream lnrpc.Lightning_SendPaymentServer) error
 	var lock sync.Mutex
 
 	return r.sendPayment(&paymentStream{
+		ctx: stream.Context(),
 		recv: func() (*rpcPaymentRequest, error) {
 			req, err := stream.Recv()
 			if err != nil {
@@ -4694,6 +5076,7 @@ func (r *rpcServer) SendToRoute(stream lnrpc.Lightning_SendToRouteServer) error
 	var lock sync.Mutex
 
 	return r.sendPayment(&paymentStream{
+		ctx: stream.Context(),
 		recv: func() (*rpcPaymentRequest, error) {
 			req, err := stream.Recv()
 			if err != nil {
@@ -5011,9 +5394,34 @@ type paymentIntentResponse struct {
 // pre-built route. The first error this method returns denotes if we were
 // unable to save the payment. The second error returned denotes if the payment
 // didn't succeed.
-func (r *rpcServer) dispatchPaymentIntent(
+func (r *rpcServer) dispatchPaymentIntent(ctx context.Context,
 	payIntent *rpcPaymentIntent) (*paymentIntentResponse, error) {
 
+	// Before dispatching the payment, we make sure it doesn't exceed the
+	// spending limits of the macaroon it was requested with.
+	if r.macService != nil {
+		// The fees of the payment are charged too, up to the fee
+		// limit if the route is yet to be found.
+		dest, amt := payIntent.dest, payIntent.msat+payIntent.feeLimit
+		if amt < payIntent.msat {
+			amt = math.MaxUint64
+		}
+		if payIntent.route != nil {
+			rt := payIntent.route
+			dest = rt.Hops[len(rt.Hops)-1].PubKeyBytes
+			amt = rt.TotalAmount
+		}
+
+		err := r.macService.CheckPayment(ctx, dest, amt)
+		if err != nil {
+			rpcsLog.Warnf("Payment rejected: %v", err)
+
+			return &paymentIntentResponse{
+				Err: err,
+			}, nil
+		}
+	}
+
 	// Construct a payment request to send to the channel router. If the
 	// payment is successful, the route chosen will be returned. Otherwise,
 	// we'll get a non-nil error.
@@ -5230,7 +5638,7 @@ sendLoop:
 				}()
 
 				resp, saveErr := r.dispatchPaymentIntent(
-					payIntent,
+					stream.ctx, payIntent,
 				)
 
 				switch {
@@ -5353,7 +5761,7 @@ func (r *rpcServer) sendPaymentSync(ctx context.Context,
 
 	// With the payment validated, we'll now attempt to dispatch the
 	// payment.
-	resp, saveErr := r.dispatchPaymentIntent(&payIntent)
+	resp, saveErr := r.dispatchPaymentIntent(ctx, &payIntent)
 	switch {
 	case saveErr != nil:
 		return nil, saveErr
@@ -5519,11 +5927,32 @@ func (r *rpcServer) ListInvoices(ctx context.Context,
 	// Next, we'll map the proto request into a format that is understood by
 	// the database.
 	q := channeldb.InvoiceQuery{
-		IndexOffset:    req.IndexOffset,
-		NumMaxInvoices: req.NumMaxInvoices,
-		PendingOnly:    req.PendingOnly,
-		Reversed:       req.Reversed,
+		IndexOffset:       req.IndexOffset,
+		NumMaxInvoices:    req.NumMaxInvoices,
+		PendingOnly:       req.PendingOnly,
+		Reversed:          req.Reversed,
+		CreationDateStart: unixTimeOrZero(req.CreationDateStart),
+		CreationDateEnd:   unixTimeOrZero(req.CreationDateEnd),
+		SettleDateStart:   unixTimeOrZero(req.SettleDateStart),
+		SettleDateEnd:     unixTimeOrZero(req.SettleDateEnd),
+		MemoContains:      req.MemoContains,
+		CountTotal:        req.CountTotalInvoices,
 	}
+
+	for _, rpcState := range req.States {
+		state, err := invoicesrpc.UnmarshallInvoiceState(rpcState)
+		if err != nil {
+			return nil, err
+		}
+		q.States = append(q.States, state)
+	}
+
+	if req.MinValueMsat < 0 || req.MaxValueMsat < 0 {
+		return nil, fmt.Errorf("value range must not be negative")
+	}
+	q.MinValue = lnwire.MilliSatoshi(req.MinValueMsat)
+	q.MaxValue = lnwire.MilliSatoshi(req.MaxValueMsat)
+
 	invoiceSlice, err := r.server.miscDB.QueryInvoices(q)
 	if err != nil {
 		return nil, fmt.Errorf("unable to query invoices: %v", err)
@@ -5535,6 +5964,7 @@ func (r *rpcServer) ListInvoices(ctx context.Context,
 		Invoices:         make([]*lnrpc.Invoice, len(invoiceSlice.Invoices)),
 		FirstIndexOffset: invoiceSlice.FirstIndexOffset,
 		LastIndexOffset:  invoiceSlice.LastIndexOffset,
+		TotalNumInvoices: invoiceSlice.TotalCount,
 	}
 	for i, invoice := range invoiceSlice.Invoices {
 		invoice := invoice
@@ -5549,6 +5979,16 @@ func (r *rpcServer) ListInvoices(ctx context.Context,
 	return resp, nil
 }
 
+// unixTimeOrZero converts a unix timestamp in seconds to a time, mapping zero
+// to the zero time.
+func unixTimeOrZero(timestamp uint64) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(int64(timestamp), 0)
+}
+
 // SubscribeInvoices returns a uni-directional stream (server -> client) for
 // notifying the client of newly added/settled invoices.
 func (r *rpcServer) SubscribeInvoices(req *lnrpc.InvoiceSubscription,
@@ -5823,15 +6263,21 @@ func marshalDBRoutingPolicy(
 
 	customRecords := marshalExtraOpaqueData(policy.ExtraOpaqueData)
 
+	// A malformed inbound fee is reported as no inbound fee, which is
+	// also how path finding treats it.
+	inboundFee, _ := lnwire.ExtractInboundFee(policy.ExtraOpaqueData)
+
 	return &lnrpc.RoutingPolicy{
-		TimeLockDelta:    uint32(policy.TimeLockDelta),
-		MinHtlc:          int64(policy.MinHTLC),
-		MaxHtlcMsat:      uint64(policy.MaxHTLC),
-		FeeBaseMsat:      int64(policy.FeeBaseMSat),
-		FeeRateMilliMsat: int64(policy.FeeProportionalMillionths),
-		Disabled:         disabled,
-		LastUpdate:       uint32(policy.LastUpdate.Unix()),
-		CustomRecords:    customRecords,
+		TimeLockDelta:           uint32(policy.TimeLockDelta),
+		MinHtlc:                 int64(policy.MinHTLC),
+		MaxHtlcMsat:             uint64(policy.MaxHTLC),
+		FeeBaseMsat:             int64(policy.FeeBaseMSat),
+		FeeRateMilliMsat:        int64(policy.FeeProportionalMillionths),
+		Disabled:                disabled,
+		LastUpdate:              uint32(policy.LastUpdate.Unix()),
+		CustomRecords:           customRecords,
+		InboundFeeBaseMsat:      inboundFee.BaseFee,
+		InboundFeeRateMilliMsat: inboundFee.FeeRate,
 	}
 }
 
@@ -6344,6 +6790,9 @@ func (r *rpcServer) ListPayments(ctx context.Context,
 		Reversed:          req.Reversed,
 		IncludeIncomplete: req.IncludeIncomplete,
 		CountTotal:        req.CountTotalPayments,
+		CreationDateStart: unixTimeOrZero(req.CreationDateStart),
+		CreationDateEnd:   unixTimeOrZero(req.CreationDateEnd),
+		FeeStats:          req.FeeStats,
 	}
 
 	// If the maximum number of payments wasn't specified, then we'll
@@ -6352,6 +6801,24 @@ func (r *rpcServer) ListPayments(ctx context.Context,
 		query.MaxPayments = math.MaxUint64
 	}
 
+	for _, rpcStatus := range req.Statuses {
+		status, err := routerrpc.UnmarshallPaymentStatus(rpcStatus)
+		if err != nil {
+			return nil, err
+		}
+
+		query.Statuses = append(query.Statuses, status)
+	}
+
+	if len(req.Destination) > 0 {
+		dest, err := route.NewVertexFromBytes(req.Destination)
+		if err != nil {
+			return nil, err
+		}
+
+		query.Destination = &dest
+	}
+
 	paymentsQuerySlice, err := r.server.miscDB.QueryPayments(query)
 	if err != nil {
 		return nil, err
@@ -6363,6 +6830,17 @@ func (r *rpcServer) ListPayments(ctx context.Context,
 		TotalNumPayments: paymentsQuerySlice.TotalCount,
 	}
 
+	if stats := paymentsQuerySlice.FeeStats; stats != nil {
+		paymentsResp.FeeStats = &lnrpc.PaymentFeeStats{
+			NumSucceeded:   stats.NumSucceeded,
+			NumFailed:      stats.NumFailed,
+			NumInFlight:    stats.NumInFlight,
+			AmountSentMsat: int64(stats.AmountSent),
+			FeesPaidMsat:   int64(stats.FeesPaid),
+			MaxFeePaidMsat: int64(stats.MaxFeePaid),
+		}
+	}
+
 	for _, payment := range paymentsQuerySlice.Payments {
 		payment := payment
 
@@ -6558,13 +7036,22 @@ func (r *rpcServer) FeeReport(ctx context.Context,
 		feeRateFixedPoint := edgePolicy.FeeProportionalMillionths
 		feeRate := float64(feeRateFixedPoint) / feeBase
 
+		inboundFee, err := lnwire.ExtractInboundFee(
+			edgePolicy.ExtraOpaqueData,
+		)
+		if err != nil {
+			return err
+		}
+
 		// TODO(roasbeef): also add stats for revenue for each channel
 		feeReports = append(feeReports, &lnrpc.ChannelFeeReport{
-			ChanId:       chanInfo.ChannelID,
-			ChannelPoint: chanInfo.ChannelPoint.String(),
-			BaseFeeMsat:  int64(edgePolicy.FeeBaseMSat),
-			FeePerMil:    int64(feeRateFixedPoint),
-			FeeRate:      feeRate,
+			ChanId:             chanInfo.ChannelID,
+			ChannelPoint:       chanInfo.ChannelPoint.String(),
+			BaseFeeMsat:        int64(edgePolicy.FeeBaseMSat),
+			FeePerMil:          int64(feeRateFixedPoint),
+			FeeRate:            feeRate,
+			InboundBaseFeeMsat: inboundFee.BaseFee,
+			InboundFeePerMil:   inboundFee.FeeRate,
 		})
 
 		return nil
@@ -6748,6 +7235,14 @@ func (r *rpcServer) UpdateChannelPolicy(ctx context.Context,
 		FeeRate: feeRateFixed,
 	}
 
+	// The inbound fee is only updated if it is set in the request.
+	if req.InboundFee != nil {
+		feeSchema.InboundFee = &lnwire.Fee{
+			BaseFee: req.InboundFee.BaseFeeMsat,
+			FeeRate: req.InboundFee.FeeRatePpm,
+		}
+	}
+
 	maxHtlc := lnwire.MilliSatoshi(req.MaxHtlcMsat)
 	var minHtlc *lnwire.MilliSatoshi
 	if req.MinHtlcMsatSpecified {
@@ -6763,10 +7258,10 @@ func (r *rpcServer) UpdateChannelPolicy(ctx context.Context,
 	}
 
 	rpcsLog.Debugf("[updatechanpolicy] updating channel policy base_fee=%v, "+
-		"rate_fixed=%v, time_lock_delta: %v, "+
+		"rate_fixed=%v, inbound_fee=%v, time_lock_delta: %v, "+
 		"min_htlc=%v, max_htlc=%v, targets=%v",
-		req.BaseFeeMsat, feeRateFixed, req.TimeLockDelta,
-		minHtlc, maxHtlc,
+		req.BaseFeeMsat, feeRateFixed, req.InboundFee,
+		req.TimeLockDelta, minHtlc, maxHtlc,
 		spew.Sdump(targetChans))
 
 	// With the scope resolved, we'll now send this to the local channel
@@ -7168,6 +7663,7 @@ func (r *rpcServer) RestoreChannelBackups(ctx context.Context,
 
 	// We'll accept either a list of Single backups, or a single Multi
 	// backup which contains several single backups.
+	var restoredBackups []chanbackup.Single
 	switch {
 	case in.GetChanBackups() != nil:
 		chanBackupsProtos := in.GetChanBackups()
@@ -7185,7 +7681,8 @@ func (r *rpcServer) RestoreChannelBackups(ctx context.Context,
 		// write the new backups to disk, and then attempt to connect
 		// out to any peers that we know of which were our prior
 		// channel peers.
-		err := chanbackup.UnpackAndRecoverSingles(
+		var err error
+		restoredBackups, err = chanbackup.UnpackAndRecoverSingles(
 			chanbackup.PackedSingles(packedBackups),
 			r.server.cc.KeyRing, chanRestorer, r.server,
 		)
@@ -7202,7 +7699,8 @@ func (r *rpcServer) RestoreChannelBackups(ctx context.Context,
 		// out to any peers that we know of which were our prior
 		// channel peers.
 		packedMulti := chanbackup.PackedMulti(packedMultiBackup)
-		err := chanbackup.UnpackAndRecoverMulti(
+		var err error
+		restoredBackups, err = chanbackup.UnpackAndRecoverMulti(
 			packedMulti, r.server.cc.KeyRing, chanRestorer,
 			r.server,
 		)
@@ -7212,7 +7710,33 @@ func (r *rpcServer) RestoreChannelBackups(ctx context.Context,
 		}
 	}
 
-	return &lnrpc.RestoreBackupResponse{}, nil
+	// Finally, we'll let the caller know what to expect from the recovery
+	// of each of the restored channels.
+	resp := &lnrpc.RestoreBackupResponse{}
+	for _, backup := range restoredBackups {
+		summary, err := chanbackup.NewRecoverySummary(backup)
+		if err != nil {
+			return nil, err
+		}
+
+		remotePub := summary.RemoteNodePub.SerializeCompressed()
+		rpcSummary := &lnrpc.ChannelRecoverySummary{
+			ChannelPoint:    summary.ChanPoint.String(),
+			RemotePubkey:    hex.EncodeToString(remotePub),
+			Capacity:        int64(summary.Capacity),
+			CommitmentType:  rpcCommitmentType(summary.ChanType),
+			HasRecoveryData: summary.HasRecoveryData,
+			CommitHeight:    summary.CommitHeight,
+			ExpectedBalance: int64(summary.ExpectedBalance),
+			CsvDelay:        summary.CsvDelay,
+			LeaseExpiry:     summary.LeaseExpiry,
+		}
+		resp.ChannelSummaries = append(
+			resp.ChannelSummaries, rpcSummary,
+		)
+	}
+
+	return resp, nil
 }
 
 // SubscribeChannelBackups allows a client to sub-subscribe to the most up to
@@ -7321,6 +7845,24 @@ func (r *rpcServer) ChannelAcceptor(stream lnrpc.Lightning_ChannelAcceptorServer
 	return rpcAcceptor.Run()
 }
 
+// ReloadChannelAcceptRules reloads the channel accept rules from the
+// configured file.
+func (r *rpcServer) ReloadChannelAcceptRules(_ context.Context,
+	_ *lnrpc.ReloadChannelAcceptRulesRequest) (
+	*lnrpc.ReloadChannelAcceptRulesResponse, error) {
+
+	if r.chanRuleAcceptor == nil {
+		return nil, fmt.Errorf("no channel accept rules configured, " +
+			"set chan-accept-rules to enable them")
+	}
+
+	if err := r.chanRuleAcceptor.Reload(); err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.ReloadChannelAcceptRulesResponse{}, nil
+}
+
 // BakeMacaroon allows the creation of a new macaroon with custom read and write
 // permissions. No first-party caveats are added since this can be done offline.
 // If the --allow-external-permissions flag is set, the RPC will allow
@@ -22,6 +22,7 @@ import (
 	"github.com/btcsuite/btcd/connmgr"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/waddrmgr"
 	"github.com/go-errors/errors"
 	sphinx "github.com/lightningnetwork/lightning-onion"
 	"github.com/lightningnetwork/lnd/aliasmgr"
@@ -52,12 +53,16 @@ import (
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
 	"github.com/lightningnetwork/lnd/lnwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/btcwallet"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/lightningnetwork/lnd/lnwallet/rpcwallet"
+	"github.com/lightningnetwork/lnd/lnwallet/signpolicy"
 	"github.com/lightningnetwork/lnd/lnwire"
 	"github.com/lightningnetwork/lnd/nat"
 	"github.com/lightningnetwork/lnd/netann"
+	"github.com/lightningnetwork/lnd/paymentretention"
 	"github.com/lightningnetwork/lnd/peer"
+	"github.com/lightningnetwork/lnd/peerban"
 	"github.com/lightningnetwork/lnd/peernotifier"
 	"github.com/lightningnetwork/lnd/pool"
 	"github.com/lightningnetwork/lnd/queue"
@@ -86,6 +91,15 @@ const (
 	// backoffs reduced.
 	defaultStableConnDuration = 10 * time.Minute
 
+	// minUnreliableDisconnects is the number of disconnects we need to
+	// have observed before we judge a peer unreliable by the share of its
+	// connections that failed.
+	minUnreliableDisconnects = 3
+
+	// unreliablePingLatency is the average ping latency above which we
+	// consider a peer whose connection failed to be unreliable.
+	unreliablePingLatency = 5 * time.Second
+
 	// numInstantInitReconnect specifies how many persistent peers we should
 	// always attempt outbound connections to immediately. After this value
 	// is surpassed, the remaining peers will be randomly delayed using
@@ -272,8 +286,21 @@ type server struct {
 
 	authGossiper *discovery.AuthenticatedGossiper
 
+	// banMgr keeps track of the nodes and IP ranges that are banned from
+	// connecting to us.
+	banMgr *peerban.Manager
+
 	localChanMgr *localchans.Manager
 
+	feeAutopilot *localchans.FeeAutopilot
+
+	paymentPruner *paymentretention.Pruner
+
+	// signPolicy is the signing policy that is enforced for all signing
+	// requests of the signer and wallet RPC servers. It is nil if the
+	// policy is disabled.
+	signPolicy *signpolicy.Enforcer
+
 	utxoNursery *contractcourt.UtxoNursery
 
 	sweeper *sweep.UtxoSweeper
@@ -312,6 +339,11 @@ type server struct {
 	// channelNotifier to be notified of newly opened and closed channels.
 	chanSubSwapper *chanbackup.SubSwapper
 
+	// backupUploader uploads each new multi channel backup to the
+	// configured remote backup sinks. This is nil if no remote sinks are
+	// configured.
+	backupUploader *chanbackup.UploadingSwapper
+
 	// chanEventStore tracks the behaviour of channels and their remote peers to
 	// provide insights into their health and performance.
 	chanEventStore *chanfitness.ChannelEventStore
@@ -486,6 +518,16 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		)
 	)
 
+	// The ban manager is created before our listeners, so that banned
+	// nodes and addresses are rejected as soon as we start listening.
+	banMgr, err := peerban.NewManager(&peerban.Config{
+		Store: dbs.ChanStateDB,
+		Clock: clock.NewDefaultClock(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	listeners := make([]net.Listener, len(listenAddrs))
 	for i, listenAddr := range listenAddrs {
 		// Note: though brontide.NewListener uses ResolveTCPAddr, it
@@ -493,6 +535,8 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		// since we are resolving a local address.
 		listeners[i], err = brontide.NewListener(
 			nodeKeyECDH, listenAddr.String(),
+			brontide.WithAddrFilter(banMgr.CheckAddr),
+			brontide.WithPubKeyFilter(banMgr.CheckNode),
 		)
 		if err != nil {
 			return nil, err
@@ -538,11 +582,18 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		NoOptionScidAlias:        !cfg.ProtocolOptions.ScidAlias(),
 		NoZeroConf:               !cfg.ProtocolOptions.ZeroConf(),
 		NoAnySegwit:              cfg.ProtocolOptions.NoAnySegwit(),
+		NoGossipSetRecon:         !cfg.ProtocolOptions.GossipSetRecon(),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	// The control tower is created early, as the invoice registry watches
+	// it for payments that settle hold invoices.
+	paymentControl := channeldb.NewPaymentControl(dbs.ChanStateDB)
+	controlTower := routing.NewControlTower(paymentControl)
+	paymentNotifier := newPaymentPreimageNotifier(controlTower)
+
 	registryConfig := invoices.RegistryConfig{
 		FinalCltvRejectDelta:        lncfg.DefaultFinalCltvRejectDelta,
 		HtlcHoldDuration:            invoices.DefaultHtlcHoldDuration,
@@ -552,6 +603,8 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		GcCanceledInvoicesOnStartup: cfg.GcCanceledInvoicesOnStartup,
 		GcCanceledInvoicesOnTheFly:  cfg.GcCanceledInvoicesOnTheFly,
 		KeysendHoldTime:             cfg.KeysendHoldTime,
+		ChainNotifier:               cc.ChainNotifier,
+		PaymentNotifier:             paymentNotifier,
 	}
 
 	s := &server{
@@ -582,6 +635,8 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 
 		torController: torController,
 
+		banMgr: banMgr,
+
 		persistentPeers:         make(map[string]bool),
 		persistentPeersBackoff:  make(map[string]time.Duration),
 		persistentConnReqs:      make(map[string][]*connmgr.ConnReq),
@@ -909,9 +964,7 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		PathFindingConfig: pathFindingConfig,
 	}
 
-	paymentControl := channeldb.NewPaymentControl(dbs.ChanStateDB)
-
-	s.controlTower = routing.NewControlTower(paymentControl)
+	s.controlTower = controlTower
 
 	strictPruning := (cfg.Bitcoin.Node == "neutrino" ||
 		cfg.Routing.StrictZombiePruning)
@@ -934,6 +987,8 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		Clock:               clock.NewDefaultClock(),
 		StrictZombiePruning: strictPruning,
 		IsAlias:             aliasmgr.IsAlias,
+
+		RequirePaymentInterceptor: cfg.RequirePaymentInterceptor,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("can't create router: %v", err)
@@ -980,6 +1035,11 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		SignAliasUpdate:         s.signAliasUpdate,
 		FindBaseByAlias:         s.aliasMgr.FindBaseSCID,
 		GetAlias:                s.aliasMgr.GetPeerAlias,
+		BanThreshold:            cfg.Gossip.BanThreshold,
+		BanDuration:             cfg.Gossip.BanDuration,
+		PeerBandwidth:           cfg.Gossip.PeerBandwidth,
+		IsChannelPeer:           s.isChannelPeer,
+		DisconnectPeer:          s.DisconnectPeer,
 	}, nodeKeyDesc)
 
 	s.localChanMgr = &localchans.Manager{
@@ -989,6 +1049,82 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		FetchChannel:              s.chanStateDB.FetchChannel,
 	}
 
+	s.feeAutopilot, err = localchans.NewFeeAutopilot(
+		&localchans.FeeAutopilotConfig{
+			DB:                     dbs.ChanStateDB,
+			FetchAllOpenChannels:   s.chanStateDB.FetchAllOpenChannels,
+			QueryForwardingLog:     dbs.ChanStateDB.ForwardingLog().Query,
+			ForAllOutgoingChannels: s.chanRouter.ForAllOutgoingChannels,
+			UpdatePolicy:           s.localChanMgr.UpdatePolicy,
+			Ticker: ticker.New(
+				cfg.FeeAutopilot.Interval,
+			),
+			Lookback: cfg.FeeAutopilot.Lookback,
+			Clock:    clock.NewDefaultClock(),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if retention := cfg.PaymentRetention; retention.Active() {
+		prunerCfg := &paymentretention.Config{
+			PrunePayments:        s.miscDB.PrunePayments,
+			FailedPaymentsMaxAge: retention.FailedPaymentsMaxAge,
+			FailedHtlcsMaxAge:    retention.FailedHtlcsMaxAge,
+			ArchiveFile:          retention.ArchiveFile,
+			Ticker:               ticker.New(retention.Interval),
+			Clock:                clock.NewDefaultClock(),
+		}
+		s.paymentPruner = paymentretention.NewPruner(prunerCfg)
+	}
+
+	if cfg.SignPolicy.Enable {
+		wallet, ok := cc.Wc.(*btcwallet.BtcWallet)
+		if !ok {
+			return nil, fmt.Errorf("signing policy requires the " +
+				"internal wallet")
+		}
+
+		allowedAddrs, err := cfg.SignPolicy.ParseAllowedAddresses(
+			cfg.ActiveNetParams.Params,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		s.signPolicy, err = signpolicy.New(&signpolicy.Config{
+			DB:               dbs.ChanStateDB,
+			AllowedAddresses: allowedAddrs,
+			DailyLimit: btcutil.Amount(
+				cfg.SignPolicy.DailyLimit,
+			),
+			AllowMuSig2:     cfg.SignPolicy.AllowMuSig2,
+			DeriveWalletKey: wallet.DerivePubKeyByBIP32Path,
+			AddressScope: func(addr btcutil.Address) (
+				waddrmgr.KeyScope, bool) {
+
+				info, err := wallet.AddressInfo(addr)
+				if err != nil {
+					return waddrmgr.KeyScope{}, false
+				}
+
+				pubKeyAddr, ok := info.(waddrmgr.ManagedPubKeyAddress)
+				if !ok {
+					return waddrmgr.KeyScope{}, false
+				}
+
+				scope, _, ok := pubKeyAddr.DerivationInfo()
+
+				return scope, ok
+			},
+			Clock: clock.NewDefaultClock(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	utxnStore, err := contractcourt.NewNurseryStore(
 		s.cfg.ActiveNetParams.GenesisHash, dbs.ChanStateDB,
 	)
@@ -1423,8 +1559,31 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 	chanNotifier := &channelNotifier{
 		chanNotifier: s.channelNotifier,
 		addrs:        dbs.ChanStateDB,
+		chanSource:   s.chanStateDB,
 	}
-	backupFile := chanbackup.NewMultiFile(cfg.BackupFilePath)
+	var backupSwapper chanbackup.Swapper = chanbackup.NewMultiFile(
+		cfg.BackupFilePath,
+	)
+
+	// If any remote backup sinks are configured, we'll also upload each
+	// new backup to them once it has been written to disk.
+	backupSinks, err := newBackupSinks(cfg.RemoteBackup)
+	if err != nil {
+		return nil, err
+	}
+	if len(backupSinks) != 0 {
+		s.backupUploader = chanbackup.NewUploadingSwapper(
+			backupSwapper, &chanbackup.UploadConfig{
+				Timeout:    cfg.RemoteBackup.Timeout,
+				MinBackoff: cfg.RemoteBackup.MinBackoff,
+				MaxBackoff: cfg.RemoteBackup.MaxBackoff,
+				Versioning: cfg.RemoteBackup.Versioning,
+				Clock:      clock.NewDefaultClock(),
+			}, backupSinks...,
+		)
+		backupSwapper = s.backupUploader
+	}
+
 	startingChans, err := chanbackup.FetchStaticChanBackups(
 		s.chanStateDB, s.addrSource,
 	)
@@ -1432,7 +1591,7 @@ func newServer(cfg *Config, listenAddrs []net.Addr,
 		return nil, err
 	}
 	s.chanSubSwapper, err = chanbackup.NewSubSwapper(
-		startingChans, chanNotifier, s.cc.KeyRing, backupFile,
+		startingChans, chanNotifier, s.cc.KeyRing, backupSwapper,
 	)
 	if err != nil {
 		return nil, err
@@ -1687,8 +1846,13 @@ func (s *server) createLivenessMonitor(cfg *Config, cc *chainreg.ChainControl) {
 	}
 
 	// If remote signing is enabled, add the healthcheck for the remote
-	// signing RPC interface.
-	if s.cfg.RemoteSigner != nil && s.cfg.RemoteSigner.Enable {
+	// signing RPC interface. The key ring fails over between all
+	// configured signers, so the check only fails if none of them can be
+	// reached.
+	keyRing, isRPCKeyRing := s.cc.KeyRing.(*rpcwallet.RPCKeyRing)
+	if s.cfg.RemoteSigner != nil && s.cfg.RemoteSigner.Enable &&
+		isRPCKeyRing {
+
 		// Because we have two cascading timeouts here, we need to add
 		// some slack to the "outer" one of them in case the "inner"
 		// returns exactly on time.
@@ -1696,9 +1860,7 @@ func (s *server) createLivenessMonitor(cfg *Config, cc *chainreg.ChainControl) {
 
 		remoteSignerConnectionCheck := healthcheck.NewObservation(
 			"remote signer connection",
-			rpcwallet.HealthCheck(
-				s.cfg.RemoteSigner,
-
+			keyRing.HealthCheck(
 				// For the health check we might to be even
 				// stricter than the initial/normal connect, so
 				// we use the health check timeout here.
@@ -1946,7 +2108,7 @@ func (s *server) Start() error {
 			chainArb:   s.chainArb,
 		}
 		if len(s.chansToRestore.PackedSingleChanBackups) != 0 {
-			err := chanbackup.UnpackAndRecoverSingles(
+			_, err := chanbackup.UnpackAndRecoverSingles(
 				s.chansToRestore.PackedSingleChanBackups,
 				s.cc.KeyRing, chanRestorer, s,
 			)
@@ -1957,7 +2119,7 @@ func (s *server) Start() error {
 			}
 		}
 		if len(s.chansToRestore.PackedMultiChanBackup) != 0 {
-			err := chanbackup.UnpackAndRecoverMulti(
+			_, err := chanbackup.UnpackAndRecoverMulti(
 				s.chansToRestore.PackedMultiChanBackup,
 				s.cc.KeyRing, chanRestorer, s,
 			)
@@ -1968,12 +2130,36 @@ func (s *server) Start() error {
 			}
 		}
 
+		if s.backupUploader != nil {
+			if err := s.backupUploader.Start(); err != nil {
+				startErr = err
+				return
+			}
+			cleanup = cleanup.add(s.backupUploader.Stop)
+		}
+
 		if err := s.chanSubSwapper.Start(); err != nil {
 			startErr = err
 			return
 		}
 		cleanup = cleanup.add(s.chanSubSwapper.Stop)
 
+		if s.cfg.FeeAutopilot.Active {
+			if err := s.feeAutopilot.Start(); err != nil {
+				startErr = err
+				return
+			}
+			cleanup = cleanup.add(s.feeAutopilot.Stop)
+		}
+
+		if s.paymentPruner != nil {
+			if err := s.paymentPruner.Start(); err != nil {
+				startErr = err
+				return
+			}
+			cleanup = cleanup.add(s.paymentPruner.Stop)
+		}
+
 		if s.torController != nil {
 			if err := s.createNewHiddenService(); err != nil {
 				startErr = err
@@ -2194,9 +2380,27 @@ func (s *server) Stop() error {
 		if err := s.htlcNotifier.Stop(); err != nil {
 			srvrLog.Warnf("failed to stop htlcNotifier: %v", err)
 		}
+		if s.cfg.FeeAutopilot.Active {
+			if err := s.feeAutopilot.Stop(); err != nil {
+				srvrLog.Warnf("failed to stop feeAutopilot: "+
+					"%v", err)
+			}
+		}
+		if s.paymentPruner != nil {
+			if err := s.paymentPruner.Stop(); err != nil {
+				srvrLog.Warnf("failed to stop paymentPruner: "+
+					"%v", err)
+			}
+		}
 		if err := s.chanSubSwapper.Stop(); err != nil {
 			srvrLog.Warnf("failed to stop chanSubSwapper: %v", err)
 		}
+		if s.backupUploader != nil {
+			if err := s.backupUploader.Stop(); err != nil {
+				srvrLog.Warnf("failed to stop backupUploader: "+
+					"%v", err)
+			}
+		}
 		if err := s.cc.ChainNotifier.Stop(); err != nil {
 			srvrLog.Warnf("Unable to stop ChainNotifier: %v", err)
 		}
@@ -3061,6 +3265,14 @@ func (s *server) establishPersistentConnections() error {
 	// node announcements and attempt to reconnect to each node.
 	var numOutboundConns int
 	for pubStr, nodeAddr := range nodeAddrsMap {
+		// We won't reconnect to banned nodes, even if we have channels
+		// with them.
+		if err := s.banMgr.CheckNode(nodeAddr.pubKey); err != nil {
+			srvrLog.Debugf("Skipping persistent connection: %v",
+				err)
+			continue
+		}
+
 		// Add this peer to the set of peers we should maintain a
 		// persistent connection with. We set the value to false to
 		// indicate that we should not continue to reconnect if the
@@ -3311,9 +3523,10 @@ func (s *server) findPeerByPubStr(pubStr string) (*peer.Brontide, error) {
 
 // nextPeerBackoff computes the next backoff duration for a peer's pubkey using
 // exponential backoff. If no previous backoff was known, the default is
-// returned.
-func (s *server) nextPeerBackoff(pubStr string,
-	startTime time.Time) time.Duration {
+// returned. The quality of our past connections to the peer, which may be nil,
+// is used to back off faster from unreliable peers.
+func (s *server) nextPeerBackoff(pubStr string, startTime time.Time,
+	quality *chanfitness.ConnectionQuality) time.Duration {
 
 	// Now, determine the appropriate backoff to use for the retry.
 	backoff, ok := s.persistentPeersBackoff[pubStr]
@@ -3322,6 +3535,22 @@ func (s *server) nextPeerBackoff(pubStr string,
 		return s.cfg.MinBackoff
 	}
 
+	// If our connections to the peer keep failing, its next connection
+	// is likely to fail as well, no matter how long the last one lasted.
+	// We'll skip the reward for stable connections and back off twice as
+	// fast.
+	if isUnreliablePeer(quality) {
+		srvrLog.Debugf("Backing off from unreliable peer %x: %v of %v "+
+			"connections failed, average ping latency %v",
+			pubStr, quality.Failures(), quality.TotalDisconnects(),
+			quality.AvgPingLatency)
+
+		return computeNextBackoff(
+			computeNextBackoff(backoff, s.cfg.MaxBackoff),
+			s.cfg.MaxBackoff,
+		)
+	}
+
 	// If the peer failed to start properly, we'll just use the previous
 	// backoff to compute the subsequent randomized exponential backoff
 	// duration. This will roughly double on average.
@@ -3354,6 +3583,23 @@ func (s *server) nextPeerBackoff(pubStr string,
 	return s.cfg.MinBackoff
 }
 
+// isUnreliablePeer returns true if the last connection to a peer failed and
+// either most of our connections to it failed, or its average ping latency is
+// too high. A nil quality is never considered unreliable.
+func isUnreliablePeer(quality *chanfitness.ConnectionQuality) bool {
+	if quality == nil || !quality.LastDisconnect.Failure() {
+		return false
+	}
+
+	if quality.AvgPingLatency >= unreliablePingLatency {
+		return true
+	}
+
+	total := quality.TotalDisconnects()
+
+	return total >= minUnreliableDisconnects && 2*quality.Failures() > total
+}
+
 // shouldDropConnection determines if our local connection to a remote peer
 // should be dropped in the case of concurrent connection establishment. In
 // order to deterministically decide which connection should be dropped, we'll
@@ -3384,6 +3630,15 @@ func (s *server) InboundPeerConnected(conn net.Conn) {
 	nodePub := conn.(*brontide.Conn).RemotePub()
 	pubStr := string(nodePub.SerializeCompressed())
 
+	// Refuse connections from banned peers, and from peers whose gossip is
+	// banned unless we have channels with them.
+	if s.isPeerBanned(nodePub, conn.RemoteAddr()) ||
+		s.isGossipBanned(nodePub) {
+
+		conn.Close()
+		return
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -3471,6 +3726,18 @@ func (s *server) OutboundPeerConnected(connReq *connmgr.ConnReq, conn net.Conn)
 	nodePub := conn.(*brontide.Conn).RemotePub()
 	pubStr := string(nodePub.SerializeCompressed())
 
+	// Drop connections to banned peers, and to peers whose gossip is
+	// banned unless we have channels with them.
+	if s.isPeerBanned(nodePub, conn.RemoteAddr()) ||
+		s.isGossipBanned(nodePub) {
+
+		if connReq != nil {
+			s.connMgr.Remove(connReq.ID())
+		}
+		conn.Close()
+		return
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -3739,6 +4006,7 @@ func (s *server) peerConnected(conn net.Conn, connReq *connmgr.ConnReq,
 		PendingCommitInterval:  s.cfg.PendingCommitInterval,
 		ChannelCommitBatchSize: s.cfg.ChannelCommitBatchSize,
 		HandleCustomMessage:    s.handleCustomMessage,
+		CheckBanned:            s.banMgr.CheckPeer,
 		GetAliases:             s.aliasMgr.GetAliases,
 		RequestAlias:           s.aliasMgr.RequestAlias,
 		AddLocalAlias:          s.aliasMgr.AddLocalAlias,
@@ -3748,6 +4016,31 @@ func (s *server) peerConnected(conn net.Conn, connReq *connmgr.ConnReq,
 	copy(pCfg.PubKeyBytes[:], peerAddr.IdentityKey.SerializeCompressed())
 	copy(pCfg.ServerPubKey[:], s.identityECDH.PubKey().SerializeCompressed())
 
+	// Report the quality of the connection to our channel event store,
+	// which is used to tune the backoff of our reconnection attempts.
+	peerVertex := route.Vertex(pCfg.PubKeyBytes)
+	pCfg.ReportPingLatency = func(latency time.Duration) {
+		err := s.chanEventStore.RecordPingLatency(peerVertex, latency)
+		if err != nil {
+			srvrLog.Debugf("Unable to record ping latency of "+
+				"%v: %v", peerVertex, err)
+		}
+	}
+	pCfg.ReportTraffic = func(traffic chanfitness.Traffic) {
+		err := s.chanEventStore.RecordTraffic(peerVertex, traffic)
+		if err != nil {
+			srvrLog.Debugf("Unable to record traffic of %v: %v",
+				peerVertex, err)
+		}
+	}
+	pCfg.ReportDisconnect = func(reason chanfitness.DisconnectReason) {
+		err := s.chanEventStore.RecordDisconnect(peerVertex, reason)
+		if err != nil {
+			srvrLog.Debugf("Unable to record disconnect of %v: %v",
+				peerVertex, err)
+		}
+	}
+
 	p := peer.NewBrontide(pCfg)
 
 	// TODO(roasbeef): update IP address for link-node
@@ -4024,8 +4317,18 @@ func (s *server) peerTerminationWatcher(p *peer.Brontide, ready chan struct{}) {
 		)
 	}
 
+	// Fetch the quality of our past connections to the peer, which may
+	// not be known if the peer never started.
+	quality, err := s.chanEventStore.GetConnQuality(
+		route.NewVertex(p.IdentityKey()),
+	)
+	if err != nil && err != chanfitness.ErrPeerNotFound {
+		srvrLog.Errorf("Unable to fetch connection quality of %v: %v",
+			p, err)
+	}
+
 	// Record the computed backoff in the backoff map.
-	backoff := s.nextPeerBackoff(pubStr, p.StartTime())
+	backoff := s.nextPeerBackoff(pubStr, p.StartTime(), quality)
 	s.persistentPeersBackoff[pubStr] = backoff
 
 	// Initialize a retry canceller for this peer if one does not
@@ -4216,6 +4519,12 @@ func (s *server) ConnectToPeer(addr *lnwire.NetAddress,
 
 	targetPub := string(addr.IdentityKey.SerializeCompressed())
 
+	// Refuse to connect to banned nodes and addresses.
+	err := s.banMgr.CheckPeer(addr.IdentityKey, addr.Address)
+	if err != nil {
+		return err
+	}
+
 	// Acquire mutex, but use explicit unlocking instead of defer for
 	// better granularity.  In certain conditions, this method requires
 	// making an outbound connection to a remote peer, which requires the
@@ -4345,6 +4654,117 @@ func (s *server) DisconnectPeer(pubKey *btcec.PublicKey) error {
 	return nil
 }
 
+// isChannelPeer returns true if we have any open or pending channels with the
+// given peer.
+func (s *server) isChannelPeer(pubKey *btcec.PublicKey) (bool, error) {
+	channels, err := s.chanStateDB.FetchOpenChannels(pubKey)
+	if err != nil {
+		return false, err
+	}
+
+	return len(channels) > 0, nil
+}
+
+// isPeerBanned returns true if either the node or the address of a peer is
+// banned, in which case connections with the peer should be dropped.
+func (s *server) isPeerBanned(pubKey *btcec.PublicKey, addr net.Addr) bool {
+	err := s.banMgr.CheckPeer(pubKey, addr)
+	if err != nil {
+		srvrLog.Debugf("Dropping connection with peer %x: %v",
+			pubKey.SerializeCompressed(), err)
+	}
+
+	return err != nil
+}
+
+// BanNode bans the node with the given public key for the given duration, or
+// permanently if the duration is zero. If we're connected to the node, it is
+// disconnected, and we stop maintaining a persistent connection with it.
+//
+// NOTE: This function is safe for concurrent access.
+func (s *server) BanNode(pubKey *btcec.PublicKey, duration time.Duration,
+	reason string) (*channeldb.PeerBan, error) {
+
+	ban, err := s.banMgr.BanNode(route.NewVertex(pubKey), duration, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dropBannedPeer(string(pubKey.SerializeCompressed()))
+
+	return ban, nil
+}
+
+// BanIPNet bans all addresses within the given IP range for the given
+// duration, or permanently if the duration is zero. All peers connected from
+// within the range are disconnected.
+//
+// NOTE: This function is safe for concurrent access.
+func (s *server) BanIPNet(ipNet *net.IPNet, duration time.Duration,
+	reason string) (*channeldb.PeerBan, error) {
+
+	ban, err := s.banMgr.BanIPNet(ipNet, duration, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for pubStr, peer := range s.peersByPub {
+		if s.banMgr.CheckAddr(peer.Address()) == nil {
+			continue
+		}
+
+		s.dropBannedPeer(pubStr)
+	}
+
+	return ban, nil
+}
+
+// dropBannedPeer disconnects the banned peer with the given public key, if
+// it's connected, and cancels all persistent connection attempts to it.
+//
+// NOTE: This function must be called with the server's mutex held.
+func (s *server) dropBannedPeer(pubStr string) {
+	s.cancelConnReqs(pubStr, nil)
+	delete(s.persistentPeers, pubStr)
+	delete(s.persistentPeersBackoff, pubStr)
+	delete(s.persistentPeerAddrs, pubStr)
+
+	peer, err := s.findPeerByPubStr(pubStr)
+	if err != nil {
+		return
+	}
+
+	srvrLog.Infof("Disconnecting banned peer %v", peer)
+
+	peer.Disconnect(errors.New("peer banned"))
+}
+
+// isGossipBanned returns true if the gossip of the given peer is banned and we
+// don't have any channels with it, in which case connections with the peer
+// should be dropped.
+func (s *server) isGossipBanned(pubKey *btcec.PublicKey) bool {
+	banned, err := s.authGossiper.ShouldDisconnect(pubKey)
+	if err != nil {
+		srvrLog.Errorf("Unable to determine whether peer %x is "+
+			"banned: %v", pubKey.SerializeCompressed(), err)
+
+		return false
+	}
+
+	if banned {
+		srvrLog.Debugf("Dropping connection with peer %x, its gossip "+
+			"is banned", pubKey.SerializeCompressed())
+	}
+
+	return banned
+}
+
 // OpenChannel sends a request to the server to open a channel to the specified
 // peer identified by nodeKey with the passed channel funding parameters.
 //
@@ -4584,3 +5004,40 @@ func shouldPeerBootstrap(cfg *Config) bool {
 	// covering the bootstrapping process.
 	return !cfg.NoNetBootstrap && !isDevNetwork
 }
+
+// newBackupSinks creates the remote backup sinks that are enabled within the
+// passed config.
+func newBackupSinks(cfg *lncfg.RemoteBackup) ([]chanbackup.BackupSink,
+	error) {
+
+	var sinks []chanbackup.BackupSink
+	if cfg.HTTPEnabled() {
+		sink, err := chanbackup.NewHTTPSink(
+			cfg.HTTP.URL, cfg.HTTP.User, cfg.HTTP.Password,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	if cfg.S3Enabled() {
+		sink, err := chanbackup.NewS3Sink(&chanbackup.S3Config{
+			Endpoint:        cfg.S3.Endpoint,
+			Region:          cfg.S3.Region,
+			Bucket:          cfg.S3.Bucket,
+			Prefix:          cfg.S3.Prefix,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			PathStyle:       cfg.S3.PathStyle,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
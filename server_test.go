@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lightningnetwork/lnd/chanfitness"
 	"github.com/lightningnetwork/lnd/lncfg"
 	"github.com/stretchr/testify/require"
 )
@@ -229,3 +230,84 @@ func TestShouldPeerBootstrap(t *testing.T) {
 		}
 	}
 }
+
+// TestIsUnreliablePeer tests which connection quality makes us back off
+// faster from a peer.
+func TestIsUnreliablePeer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		quality    *chanfitness.ConnectionQuality
+		unreliable bool
+	}{
+		{
+			name: "no quality",
+		},
+		{
+			name: "local disconnect",
+			quality: &chanfitness.ConnectionQuality{
+				Disconnects: map[chanfitness.DisconnectReason]uint32{
+					chanfitness.DisconnectReadError: 3,
+					chanfitness.DisconnectLocal:     1,
+				},
+				LastDisconnect: chanfitness.DisconnectLocal,
+				AvgPingLatency: time.Minute,
+			},
+		},
+		{
+			name: "too few disconnects",
+			quality: &chanfitness.ConnectionQuality{
+				Disconnects: map[chanfitness.DisconnectReason]uint32{
+					chanfitness.DisconnectReadError: 2,
+				},
+				LastDisconnect: chanfitness.DisconnectReadError,
+			},
+		},
+		{
+			name: "mostly failures",
+			quality: &chanfitness.ConnectionQuality{
+				Disconnects: map[chanfitness.DisconnectReason]uint32{
+					chanfitness.DisconnectReadError:   1,
+					chanfitness.DisconnectIdleTimeout: 1,
+					chanfitness.DisconnectLocal:       1,
+				},
+				LastDisconnect: chanfitness.DisconnectIdleTimeout,
+			},
+			unreliable: true,
+		},
+		{
+			name: "half failures",
+			quality: &chanfitness.ConnectionQuality{
+				Disconnects: map[chanfitness.DisconnectReason]uint32{
+					chanfitness.DisconnectWriteError: 2,
+					chanfitness.DisconnectLocal:      2,
+				},
+				LastDisconnect: chanfitness.DisconnectWriteError,
+			},
+		},
+		{
+			name: "high latency",
+			quality: &chanfitness.ConnectionQuality{
+				Disconnects: map[chanfitness.DisconnectReason]uint32{
+					chanfitness.DisconnectReadError: 1,
+				},
+				LastDisconnect: chanfitness.DisconnectReadError,
+				AvgPingLatency: unreliablePingLatency,
+			},
+			unreliable: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(
+				t, test.unreliable, isUnreliablePeer(test.quality),
+			)
+		})
+	}
+}